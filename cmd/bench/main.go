@@ -0,0 +1,264 @@
+// Command bench measures the pipeline's throughput in process. It feeds
+// synthetic collector records through the real transform chain with an
+// in-memory source and sink, so no Kafka is involved, and reports events per
+// second, heap allocations per event, and the mean time spent in each
+// transform stage. Every combination of the -geocode and -concurrency lists
+// is measured, so a regression shows up as a change in one row.
+//
+// Usage:
+//
+//	go run ./cmd/bench -events 50000 -concurrency 1,4,8 -geocode on,off
+//
+//	go run ./cmd/bench -json > bench.ndjson
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/couchcryptid/storm-data-etl/internal/synthetic"
+	"github.com/couchcryptid/storm-data-etl/stormtest"
+)
+
+// options are the parsed command-line flags.
+type options struct {
+	events      int
+	batchSize   int
+	seed        uint64
+	concurrency []int
+	geocode     []bool
+	json        bool
+}
+
+// result is the measurement of one configuration.
+type result struct {
+	Geocode        bool               `json:"geocode"`
+	Concurrency    int                `json:"concurrency"`
+	Events         int                `json:"events"`
+	EventsPerSec   float64            `json:"events_per_sec"`
+	AllocsPerEvent float64            `json:"allocs_per_event"`
+	BytesPerEvent  float64            `json:"bytes_per_event"`
+	StageMicros    map[string]float64 `json:"stage_us"`
+}
+
+// logger discards the pipeline's logs, which would otherwise dominate the
+// measurement.
+var logger = slog.New(slog.DiscardHandler)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	opts, err := parseFlags()
+	if err != nil {
+		flag.Usage()
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	raws := synthetic.NewGenerator(opts.seed).RawEvents(opts.events, stormtest.SampleTopic, stormtest.SampleReportDate)
+	warmUp(ctx, raws)
+
+	var results []result
+	for _, geocode := range opts.geocode {
+		for _, n := range opts.concurrency {
+			r, err := measure(ctx, raws, geocode, n, opts.batchSize)
+			if err != nil {
+				return err
+			}
+			results = append(results, r)
+		}
+	}
+	if opts.json {
+		enc := json.NewEncoder(os.Stdout)
+		for _, r := range results {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return printTable(results)
+}
+
+func parseFlags() (options, error) {
+	var opts options
+	var concurrency, geocode string
+	flag.IntVar(&opts.events, "events", 20000, "synthetic events per configuration")
+	flag.IntVar(&opts.batchSize, "batch-size", 500, "events per pipeline batch")
+	flag.Uint64Var(&opts.seed, "seed", 1, "seed of the synthetic event generator")
+	flag.StringVar(&concurrency, "concurrency", "1,4", "comma-separated transform worker counts to measure")
+	flag.StringVar(&geocode, "geocode", "on,off", "comma-separated geocode stage settings to measure (on, off)")
+	flag.BoolVar(&opts.json, "json", false, "print one JSON object per configuration instead of a table")
+	flag.Parse()
+
+	if opts.events < 1 {
+		return opts, fmt.Errorf("-events must be positive, got %d", opts.events)
+	}
+	if opts.batchSize < 1 {
+		return opts, fmt.Errorf("-batch-size must be positive, got %d", opts.batchSize)
+	}
+	for v := range strings.SplitSeq(concurrency, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || n < 1 {
+			return opts, fmt.Errorf("-concurrency: %q is not a positive worker count", v)
+		}
+		opts.concurrency = append(opts.concurrency, n)
+	}
+	for v := range strings.SplitSeq(geocode, ",") {
+		switch strings.TrimSpace(v) {
+		case "on":
+			opts.geocode = append(opts.geocode, true)
+		case "off":
+			opts.geocode = append(opts.geocode, false)
+		default:
+			return opts, fmt.Errorf("-geocode: %q is not on or off", v)
+		}
+	}
+	return opts, nil
+}
+
+// warmUp transforms a sample of the events once, so lazily loaded reference
+// data (places, boundaries) is not charged to the first configuration.
+func warmUp(ctx context.Context, raws []domain.RawEvent) {
+	t := pipeline.NewTransformer(logger)
+	for _, raw := range raws[:min(len(raws), 1000)] {
+		_, _ = t.Transform(ctx, raw)
+	}
+}
+
+// measure runs raws through a pipeline with the given settings and returns
+// its throughput, allocations, and stage timings.
+func measure(ctx context.Context, raws []domain.RawEvent, geocode bool, concurrency, batchSize int) (result, error) {
+	// The stage totals are filled in once the transformer reports its stages,
+	// before any event is transformed.
+	var totals map[string]*atomic.Int64
+	topts := []pipeline.TransformerOption{pipeline.WithStageTiming(func(stage string, d time.Duration) {
+		totals[stage].Add(int64(d))
+	})}
+	if !geocode {
+		topts = append(topts, pipeline.WithoutStage(pipeline.StageGeocode))
+	}
+	transformer := pipeline.NewTransformer(logger, topts...)
+	totals = make(map[string]*atomic.Int64)
+	for _, name := range transformer.Stages() {
+		totals[name] = new(atomic.Int64)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sink := &sink{want: len(raws), done: cancel}
+	p := pipeline.New(&stormtest.Extractor{Batches: slices.Collect(slices.Chunk(raws, batchSize))},
+		transformer, sink, logger, observability.NewMetricsForTesting(), batchSize,
+		pipeline.WithDeadLetter(sink), pipeline.WithTransformConcurrency(concurrency))
+
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	if err := p.Run(ctx); err != nil {
+		return result{}, err
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	if got := sink.events.Load(); got < int64(len(raws)) {
+		return result{}, fmt.Errorf("pipeline stopped after %d of %d events", got, len(raws))
+	}
+	if letters := sink.letters.Load(); letters > 0 {
+		return result{}, fmt.Errorf("%d synthetic events were dead-lettered", letters)
+	}
+
+	n := float64(len(raws))
+	r := result{
+		Geocode:        geocode,
+		Concurrency:    concurrency,
+		Events:         len(raws),
+		EventsPerSec:   n / elapsed.Seconds(),
+		AllocsPerEvent: float64(after.Mallocs-before.Mallocs) / n,
+		BytesPerEvent:  float64(after.TotalAlloc-before.TotalAlloc) / n,
+		StageMicros:    make(map[string]float64, len(totals)),
+	}
+	for name, total := range totals {
+		r.StageMicros[name] = float64(total.Load()) / n / float64(time.Microsecond)
+	}
+	return r, nil
+}
+
+// sink counts loaded events and dead letters and calls done once every
+// event is accounted for.
+type sink struct {
+	want    int
+	done    context.CancelFunc
+	events  atomic.Int64
+	letters atomic.Int64
+}
+
+func (s *sink) LoadBatch(_ context.Context, events []domain.StormEvent) error {
+	s.add(len(events), 0)
+	return nil
+}
+
+func (s *sink) LoadDeadLetters(_ context.Context, letters []domain.DeadLetter) error {
+	s.add(len(letters), len(letters))
+	return nil
+}
+
+func (s *sink) add(events, letters int) {
+	s.letters.Add(int64(letters))
+	if s.events.Add(int64(events)) >= int64(s.want) {
+		s.done()
+	}
+}
+
+// printTable writes the results as an aligned table with one column per
+// transform stage.
+func printTable(results []result) error {
+	if len(results) == 0 {
+		return errors.New("no configurations measured")
+	}
+	stages := []string{pipeline.StageParse, pipeline.StageNormalize, pipeline.StageSeverity, pipeline.StageGeocode}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(w, "geocode\tworkers\tevents/s\tallocs/event\tB/event\t")
+	for _, s := range stages {
+		fmt.Fprintf(w, "%s µs\t", s)
+	}
+	fmt.Fprintln(w)
+	for _, r := range results {
+		geocode := "off"
+		if r.Geocode {
+			geocode = "on"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.0f\t%.1f\t%.0f\t", geocode, r.Concurrency, r.EventsPerSec, r.AllocsPerEvent, r.BytesPerEvent)
+		for _, s := range stages {
+			if us, ok := r.StageMicros[s]; ok {
+				fmt.Fprintf(w, "%.2f\t", us)
+			} else {
+				fmt.Fprint(w, "-\t")
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	return w.Flush()
+}
@@ -3,17 +3,34 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/checkpoint"
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/elasticsearch"
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/fileadapter"
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/grpcadapter"
 	"github.com/couchcryptid/storm-data-etl/internal/adapter/httpadapter"
 	kafkaadapter "github.com/couchcryptid/storm-data-etl/internal/adapter/kafka"
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/nwsalerts"
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/parquet"
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/postgres"
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/s3archive"
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/schemaregistry"
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/spc"
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/sqsadapter"
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/webhook"
+	"github.com/couchcryptid/storm-data-etl/internal/app"
 	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/faultinject"
 	"github.com/couchcryptid/storm-data-etl/internal/observability"
 	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/couchcryptid/storm-data-etl/schemas"
 )
 
 func main() {
@@ -26,17 +43,154 @@ func main() {
 	logger := observability.NewLogger(cfg)
 	metrics := observability.NewMetrics()
 
-	reader := kafkaadapter.NewReader(cfg, logger)
-	writer := kafkaadapter.NewWriter(cfg, logger)
-	transformer := pipeline.NewTransformer(logger)
+	shutdownTracing, err := observability.InitTracing(context.Background(), cfg)
+	if err != nil {
+		logger.Error("failed to initialize tracing", "error", err)
+		os.Exit(1)
+	}
+
+	setupCtx, cancelSetup := context.WithTimeout(context.Background(), 30*time.Second)
+	extractor, closeExtractor, err := newExtractor(setupCtx, cfg, logger, metrics)
+	if err != nil {
+		cancelSetup()
+		logger.Error("failed to create extractor", "error", err, "source", cfg.SourceType)
+		os.Exit(1)
+	}
+	loader, loaderClosers, err := newLoader(setupCtx, cfg, logger, metrics)
+	if err != nil {
+		cancelSetup()
+		logger.Error("failed to create loader", "error", err)
+		os.Exit(1)
+	}
+	faults, err := faultinject.Load()
+	if err != nil {
+		cancelSetup()
+		logger.Error("invalid fault injection settings", "error", err)
+		os.Exit(1)
+	}
+	if faults != nil {
+		logger.Warn("fault injection enabled", "transform_delay", faults.TransformDelay, "transform_error_rate", faults.TransformErrorRate,
+			"loader_error_rate", faults.LoaderErrorRate, "alerts_throttle_rate", faults.AlertsThrottleRate)
+		loader = faults.Loader(loader)
+	}
+	if cfg.SchemaRegistryURL != "" {
+		id, err := schemaregistry.NewClient(cfg).Register(setupCtx, schemas.StormEvent)
+		if err != nil {
+			cancelSetup()
+			logger.Error("failed to register schema", "error", err, "subject", cfg.SchemaRegistrySubject)
+			os.Exit(1)
+		}
+		logger.Info("registered schema", "subject", cfg.SchemaRegistrySubject, "schema_id", id)
+	}
+	cancelSetup()
+	transformOpts, err := app.TransformerOptions(cfg, logger, metrics, nwsalerts.WithTransport(faults.Transport(nil)))
+	if err != nil {
+		logger.Error("invalid transform settings", "error", err)
+		os.Exit(1)
+	}
+	stormTransformer := pipeline.NewTransformer(logger, transformOpts...)
+	transformer := faults.Transformer(stormTransformer)
 
-	p := pipeline.New(reader, transformer, writer, logger, metrics, cfg.BatchSize)
+	opts := []pipeline.Option{
+		pipeline.WithTransformConcurrency(cfg.TransformConcurrency),
+		pipeline.WithFlushInterval(cfg.BatchFlushInterval),
+		pipeline.WithRateLimit(cfg.MaxEventsPerSecond),
+		pipeline.WithLoaderCircuitBreaker(cfg.LoaderCircuitThreshold, cfg.LoaderCircuitCooldown),
+		pipeline.WithValidationRules(cfg.ValidationRules),
+		pipeline.WithEventFilter(cfg.EventFilter),
+		pipeline.WithProvenanceHeaders(cfg.ProvenanceHeaders),
+	}
+	if cfg.MetricsStateLabel {
+		opts = append(opts, pipeline.WithStateLabels())
+	}
+	if cfg.OrderedProcessing {
+		opts = append(opts, pipeline.WithOrderedProcessing())
+	}
+	if cfg.DuplicateMerge {
+		opts = append(opts, pipeline.WithDuplicateMerging(cfg.DuplicateMergeTolerance))
+	}
+	if cfg.EpisodeClustering {
+		opts = append(opts, pipeline.WithEpisodeClustering(cfg.EpisodeTolerance))
+	}
+	if cfg.KafkaSinkOversizePolicy != "" {
+		if sizer, ok := loader.(pipeline.MessageSizer); ok {
+			opts = append(opts, pipeline.WithMessageSizeLimit(sizer, cfg.KafkaSinkMaxMessageBytes, cfg.KafkaSinkOversizePolicy))
+		}
+	}
+	var dlqWriter *kafkaadapter.DeadLetterWriter
+	if cfg.KafkaDLQTopic != "" {
+		dlqWriter, err = kafkaadapter.NewDeadLetterWriter(cfg, logger)
+		if err != nil {
+			logger.Error("failed to create kafka dead-letter writer", "error", err)
+			os.Exit(1)
+		}
+		opts = append(opts, pipeline.WithDeadLetter(dlqWriter))
+	}
+	if cfg.ProgressFile != "" {
+		opts = append(opts, pipeline.WithProgressStore(checkpoint.NewFileStore(cfg.ProgressFile)))
+	}
+	var broadcaster *pipeline.Broadcaster
+	if cfg.GRPCAddr != "" || cfg.StreamWebSocket {
+		broadcaster = pipeline.NewBroadcaster(cfg.StreamBuffer, metrics)
+		opts = append(opts, pipeline.WithBroadcaster(broadcaster))
+	}
+	var grpcSrv *grpcadapter.Server
+	if cfg.GRPCAddr != "" {
+		grpcSrv = grpcadapter.NewServer(cfg.GRPCAddr, broadcaster, logger)
+	}
+
+	p := pipeline.New(extractor, transformer, loader, logger, metrics, cfg.BatchSize, opts...)
 
-	srv := httpadapter.NewServer(cfg.HTTPAddr, p, logger)
+	reload := &reloader{path: cfg.ReloadFile, pipeline: p, transformer: stormTransformer, logger: logger}
+	serverOpts := []httpadapter.Option{
+		httpadapter.WithHealthDetail(p),
+		httpadapter.WithAdmin(p, cfg.AdminToken),
+		httpadapter.WithReload(reload, cfg.AdminToken),
+		httpadapter.WithProgress(p, cfg.AdminToken),
+		httpadapter.WithSchema(schemas.StormEvent),
+	}
+	if len(cfg.AdminTokens) > 0 || cfg.AdminClientCertAuth {
+		serverOpts = append(serverOpts, httpadapter.WithAdminAuth(httpadapter.AdminAuth{
+			Tokens:      cfg.AdminTokens,
+			ClientCerts: cfg.AdminClientCertAuth,
+			Permissions: cfg.AdminPermissions,
+		}))
+	}
+	var dlq *kafkaadapter.DeadLetterQueue
+	if cfg.KafkaDLQTopic != "" && cfg.AdminEnabled() {
+		dlq, err = kafkaadapter.NewDeadLetterQueue(cfg, logger)
+		if err != nil {
+			logger.Error("failed to create kafka dead-letter queue", "error", err)
+			os.Exit(1)
+		}
+		serverOpts = append(serverOpts, httpadapter.WithDeadLetters(dlq, cfg.AdminToken))
+	}
+	if cfg.PprofEnabled {
+		serverOpts = append(serverOpts, httpadapter.WithPprof(cfg.AdminToken))
+	}
+	if cfg.StreamWebSocket {
+		serverOpts = append(serverOpts, httpadapter.WithEventStream(broadcaster, cfg.StreamAllowedOrigins))
+	}
+	if cfg.HTTPTLSCertFile != "" {
+		tlsCfg, err := httpadapter.NewTLSConfig(httpadapter.TLSOptions{
+			CertFile:          cfg.HTTPTLSCertFile,
+			KeyFile:           cfg.HTTPTLSKeyFile,
+			ClientCAFile:      cfg.HTTPTLSClientCAFile,
+			RequireClientCert: cfg.HTTPTLSClientAuth == config.TLSClientAuthRequire,
+		})
+		if err != nil {
+			logger.Error("failed to configure http tls", "error", err)
+			os.Exit(1)
+		}
+		serverOpts = append(serverOpts, httpadapter.WithTLS(tlsCfg))
+	}
+	srv := httpadapter.NewServer(cfg.HTTPAddr, p, logger, serverOpts...)
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	go reload.watchSIGHUP(ctx)
+
 	// Start HTTP server.
 	go func() {
 		if err := srv.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -44,6 +198,14 @@ func main() {
 		}
 	}()
 
+	if grpcSrv != nil {
+		go func() {
+			if err := grpcSrv.Start(); err != nil {
+				logger.Error("grpc server error", "error", err)
+			}
+		}()
+	}
+
 	// Start ETL pipeline.
 	go func() {
 		if err := p.Run(ctx); err != nil {
@@ -60,12 +222,126 @@ func main() {
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		logger.Error("http server shutdown error", "error", err)
 	}
-	if err := reader.Close(); err != nil {
-		logger.Error("kafka reader close error", "error", err)
+	if grpcSrv != nil {
+		if err := grpcSrv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("grpc server shutdown error", "error", err)
+		}
 	}
-	if err := writer.Close(); err != nil {
-		logger.Error("kafka writer close error", "error", err)
+	if closeExtractor != nil {
+		if err := closeExtractor(); err != nil {
+			logger.Error("extractor close error", "error", err, "source", cfg.SourceType)
+		}
+	}
+	for _, c := range loaderClosers {
+		if err := c.close(); err != nil {
+			logger.Error(c.name+" close error", "error", err)
+		}
+	}
+	if dlqWriter != nil {
+		if err := dlqWriter.Close(); err != nil {
+			logger.Error("kafka dead-letter writer close error", "error", err)
+		}
+	}
+	if dlq != nil {
+		if err := dlq.Close(); err != nil {
+			logger.Error("kafka dead-letter queue close error", "error", err)
+		}
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("tracing shutdown error", "error", err)
 	}
 
 	logger.Info("shutdown complete")
 }
+
+// closer pairs a resource's shutdown function with a name for error logs.
+type closer struct {
+	name  string
+	close func() error
+}
+
+// newExtractor builds the extractor selected by SOURCE_TYPE. The returned
+// close function is nil when the extractor holds no resources.
+func newExtractor(ctx context.Context, cfg *config.Config, logger *slog.Logger, metrics *observability.Metrics) (pipeline.BatchExtractor, func() error, error) {
+	switch cfg.SourceType {
+	case config.SourceSPC:
+		return spc.NewExtractor(cfg, logger), nil, nil
+	case config.SourceFile:
+		files, err := fileadapter.NewExtractor(cfg, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		return files, files.Close, nil
+	case config.SourceSQS:
+		queue, err := sqsadapter.NewExtractor(ctx, cfg, logger, metrics)
+		if err != nil {
+			return nil, nil, err
+		}
+		return queue, nil, nil
+	default:
+		reader, err := kafkaadapter.NewReader(cfg, logger, metrics)
+		if err != nil {
+			return nil, nil, err
+		}
+		return reader, reader.Close, nil
+	}
+}
+
+// newLoader builds every configured sink. A single sink is returned as is;
+// several are combined with pipeline.MultiLoader in the order Kafka,
+// PostgreSQL, S3 archive, Parquet, Elasticsearch, webhooks. Every loader with
+// a Close registers it in the returned closers; the S3 archive loader writes
+// each batch before LoadBatch returns and holds nothing to close.
+// The file and SQS sources are extractors, closed through newExtractor.
+func newLoader(ctx context.Context, cfg *config.Config, logger *slog.Logger, metrics *observability.Metrics) (pipeline.BatchLoader, []closer, error) {
+	var loaders pipeline.MultiLoader
+	var closers []closer
+
+	if cfg.KafkaSinkEnabled {
+		writer, err := kafkaadapter.NewWriter(cfg, logger, metrics)
+		if err != nil {
+			return nil, nil, fmt.Errorf("kafka writer: %w", err)
+		}
+		loaders = append(loaders, writer)
+		closers = append(closers, closer{"kafka writer", writer.Close})
+	}
+	if cfg.PostgresDSN != "" {
+		pg, err := postgres.NewLoader(ctx, cfg, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("postgres loader: %w", err)
+		}
+		loaders = append(loaders, pg)
+		closers = append(closers, closer{"postgres loader", pg.Close})
+	}
+	if cfg.ArchiveS3Bucket != "" {
+		archive, err := s3archive.NewLoader(ctx, cfg, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("s3 archive loader: %w", err)
+		}
+		loaders = append(loaders, archive)
+	}
+	if cfg.ParquetPath != "" {
+		pq, err := parquet.NewLoader(ctx, cfg, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parquet loader: %w", err)
+		}
+		loaders = append(loaders, pq)
+		closers = append(closers, closer{"parquet loader", pq.Close})
+	}
+	if cfg.ElasticsearchURL != "" {
+		es := elasticsearch.NewLoader(cfg, logger)
+		loaders = append(loaders, es)
+		closers = append(closers, closer{"elasticsearch loader", es.Close})
+	}
+	if len(cfg.WebhookURLs) > 0 {
+		hooks := webhook.NewLoader(cfg, logger, metrics)
+		loaders = append(loaders, hooks)
+		closers = append(closers, closer{"webhook loader", hooks.Close})
+	}
+
+	if len(loaders) == 1 {
+		return loaders[0], closers, nil
+	}
+	return loaders, closers, nil
+}
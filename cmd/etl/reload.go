@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+)
+
+// reloader applies the reloadable configuration subset (log level, batch
+// size, severity thresholds, rate limit) to the running service. The consumer group is
+// untouched, so partition assignments survive a reload.
+type reloader struct {
+	path        string
+	pipeline    *pipeline.Pipeline
+	transformer *pipeline.StormTransformer
+	logger      *slog.Logger
+	mu          sync.Mutex
+}
+
+// Reload re-reads the environment and CONFIG_RELOAD_FILE and applies the
+// result. Nothing is applied when any value is invalid.
+func (r *reloader) Reload(_ context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	settings, err := config.LoadReloadable(r.path)
+	if err != nil {
+		r.logger.Error("config reload failed", "error", err)
+		return err
+	}
+	applyReloadable(settings, r.pipeline, r.transformer)
+	r.logger.Info("config reloaded", "log_level", settings.LogLevel, "batch_size", settings.BatchSize, "max_events_per_second", settings.MaxEventsPerSecond)
+	return nil
+}
+
+// watchSIGHUP reloads configuration on every SIGHUP until ctx is cancelled.
+func (r *reloader) watchSIGHUP(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			_ = r.Reload(ctx)
+		}
+	}
+}
+
+// applyReloadable pushes reloadable settings into the components that own
+// them. The thresholds replace those of the transformer's current enrichment,
+// keeping its other settings.
+func applyReloadable(settings config.Reloadable, p *pipeline.Pipeline, t *pipeline.StormTransformer) {
+	observability.SetLogLevel(settings.LogLevel)
+	enrichment := t.Enrichment()
+	enrichment.SeverityThresholds = settings.SeverityThresholds
+	t.SetEnrichment(enrichment)
+	p.SetBatchSize(settings.BatchSize)
+	p.SetRateLimit(settings.MaxEventsPerSecond)
+}
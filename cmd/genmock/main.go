@@ -2,12 +2,20 @@
 // for both the ETL and API test suites. It uses the actual ETL domain package
 // to ensure the transformed output matches real pipeline behavior.
 //
+// Each report date (YYMMDD) reads <date>_rpts_hail.csv, _torn.csv, and
+// _wind.csv, uses the date as the Kafka timestamp for HHMM report times, and
+// stamps ProcessedAt at 06:00 UTC the following day. With several dates the
+// days are concatenated into one fixture, and ETL record times are expanded
+// to RFC 3339 (as the collector does) so each record keeps its day.
+//
 // Usage:
 //
 //	go run ./cmd/genmock \
 //	  -csv-dir ../storm-data-system/mock-server/data \
 //	  -etl-out data/mock/storm_reports_240426_combined.json \
 //	  -api-out ../storm-data-api/data/mock/storm_reports_240426_transformed.json
+//
+//	go run ./cmd/genmock -dates 240426,240427 -csv-dir ... -etl-out ... -api-out ...
 package main
 
 import (
@@ -26,14 +34,21 @@ import (
 	"github.com/jonboulle/clockwork"
 )
 
-var baseDate = time.Date(2024, time.April, 26, 0, 0, 0, 0, time.UTC)
+// defaultDate is the report date of the committed fixtures.
+const defaultDate = "240426"
 
 type csvDef struct {
-	file      string
+	suffix    string // file name after the YYMMDD date
 	eventType string
 	magCol    string // column name for magnitude (Size, F_Scale, Speed)
 }
 
+var defs = []csvDef{
+	{suffix: "_rpts_hail.csv", eventType: "hail", magCol: "Size"},
+	{suffix: "_rpts_torn.csv", eventType: "tornado", magCol: "F_Scale"},
+	{suffix: "_rpts_wind.csv", eventType: "wind", magCol: "Speed"},
+}
+
 func main() {
 	if err := run(); err != nil {
 		log.Fatal(err)
@@ -44,37 +59,44 @@ func run() error {
 	csvDir := flag.String("csv-dir", "", "directory containing NOAA SPC CSV files")
 	etlOut := flag.String("etl-out", "", "output path for ETL raw JSON fixture")
 	apiOut := flag.String("api-out", "", "output path for API transformed JSON fixture")
+	date := flag.String("date", "", "report date as YYMMDD (default "+defaultDate+")")
+	dates := flag.String("dates", "", "comma-separated YYMMDD report dates to concatenate into one fixture")
 	flag.Parse()
 
 	if *csvDir == "" || *etlOut == "" || *apiOut == "" {
 		flag.Usage()
 		return fmt.Errorf("missing required flags: -csv-dir, -etl-out, -api-out")
 	}
-
-	defs := []csvDef{
-		{file: "240426_rpts_hail.csv", eventType: "hail", magCol: "Size"},
-		{file: "240426_rpts_torn.csv", eventType: "tornado", magCol: "F_Scale"},
-		{file: "240426_rpts_wind.csv", eventType: "wind", magCol: "Speed"},
+	days, err := parseDates(*date, *dates)
+	if err != nil {
+		flag.Usage()
+		return err
 	}
-
-	// Set a fixed clock for reproducible ProcessedAt timestamps.
-	domain.SetClock(clockwork.NewFakeClockAt(
-		time.Date(2024, time.April, 27, 6, 0, 0, 0, time.UTC),
-	))
 	defer domain.SetClock(nil)
 
 	var rawRecords []domain.RawCSVRecord //nolint:prealloc // size depends on CSV file contents
 	var transformed []domain.StormEvent  //nolint:prealloc // size depends on CSV file contents
 
-	for _, d := range defs {
-		path := filepath.Join(*csvDir, d.file)
-		recs, events, err := processCSV(path, d.eventType, d.magCol)
-		if err != nil {
-			return fmt.Errorf("processing %s: %w", d.file, err)
+	for _, day := range days {
+		// Set a fixed clock for reproducible ProcessedAt timestamps: the
+		// morning after the report day.
+		domain.SetClock(clockwork.NewFakeClockAt(day.AddDate(0, 0, 1).Add(6 * time.Hour)))
+
+		for _, d := range defs {
+			file := day.Format(yymmdd) + d.suffix
+			recs, events, err := processCSV(filepath.Join(*csvDir, file), d.eventType, d.magCol, day)
+			if err != nil {
+				return fmt.Errorf("processing %s: %w", file, err)
+			}
+			if len(days) > 1 {
+				for i := range recs {
+					recs[i].Time = expandTime(day, recs[i].Time)
+				}
+			}
+			rawRecords = append(rawRecords, recs...)
+			transformed = append(transformed, events...)
+			log.Printf("%s %s: %d records", day.Format(time.DateOnly), d.eventType, len(recs))
 		}
-		rawRecords = append(rawRecords, recs...)
-		transformed = append(transformed, events...)
-		log.Printf("%s: %d records", d.eventType, len(recs))
 	}
 
 	log.Printf("total: %d records", len(rawRecords))
@@ -93,7 +115,54 @@ func run() error {
 	return nil
 }
 
-func processCSV(path, eventType, magCol string) ([]domain.RawCSVRecord, []domain.StormEvent, error) {
+// yymmdd is the report date layout used in SPC file names.
+const yymmdd = "060102"
+
+// parseDates returns the report dates selected by -date or -dates, in the
+// order given.
+func parseDates(date, dates string) ([]time.Time, error) {
+	if date != "" && dates != "" {
+		return nil, fmt.Errorf("-date and -dates are mutually exclusive")
+	}
+	list := []string{defaultDate}
+	switch {
+	case date != "":
+		list = []string{date}
+	case dates != "":
+		list = strings.Split(dates, ",")
+	}
+
+	days := make([]time.Time, 0, len(list))
+	seen := map[time.Time]bool{}
+	for _, s := range list {
+		day, err := time.Parse(yymmdd, strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q: want YYMMDD", s)
+		}
+		if seen[day] {
+			return nil, fmt.Errorf("date %s listed twice", s)
+		}
+		seen[day] = true
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// expandTime turns an HHMM report time into an RFC 3339 timestamp on day,
+// matching the collector's output. Values that are not HHMM are kept as-is.
+func expandTime(day time.Time, hhmm string) string {
+	padded := hhmm
+	if len(padded) == 3 {
+		padded = "0" + padded
+	}
+	t, err := time.Parse("1504", padded)
+	if len(padded) != 4 || err != nil {
+		return hhmm
+	}
+	return day.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute).Format(time.RFC3339)
+}
+
+func processCSV(path, eventType, magCol string, day time.Time) ([]domain.RawCSVRecord, []domain.StormEvent, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, nil, fmt.Errorf("open: %w", err)
@@ -156,7 +225,7 @@ func processCSV(path, eventType, magCol string) ([]domain.RawCSVRecord, []domain
 
 		rawEvent := domain.RawEvent{
 			Value:     rawJSON,
-			Timestamp: baseDate,
+			Timestamp: day,
 		}
 
 		parsed, err := domain.ParseRawEvent(rawEvent)
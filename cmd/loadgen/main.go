@@ -0,0 +1,185 @@
+// Command loadgen publishes synthetic raw collector reports to a Kafka topic
+// at a target rate, for soak-testing a deployed service and sizing its
+// consumer group. Reports come from the seeded generator in
+// internal/synthetic, have no key so they spread round-robin across
+// partitions, and are timestamped when published, so the service's lag and
+// latency metrics read as they would for live reports.
+//
+// Broker and security settings come from the same environment variables as
+// the service (KAFKA_BROKERS, KAFKA_SASL_*, KAFKA_TLS_*).
+//
+// Usage:
+//
+//	go run ./cmd/loadgen -rate 500 -duration 30m
+//
+//	go run ./cmd/loadgen -topic raw-weather-reports-soak -count 1000000 -rate 0
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os/signal"
+	"syscall"
+	"time"
+
+	kafkaadapter "github.com/couchcryptid/storm-data-etl/internal/adapter/kafka"
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/synthetic"
+	"golang.org/x/time/rate"
+)
+
+// progressInterval is how often progress is logged during a run.
+const progressInterval = 10 * time.Second
+
+// options are the parsed command-line flags.
+type options struct {
+	topic     string
+	rate      float64
+	count     int
+	duration  time.Duration
+	batchSize int
+	seed      uint64
+}
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	opts, err := parseFlags()
+	if err != nil {
+		flag.Usage()
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	if opts.topic == "" {
+		opts.topic = cfg.KafkaSourceTopics[0]
+	}
+	if opts.seed == 0 {
+		opts.seed = uint64(time.Now().UnixNano()) //nolint:gosec // any seed will do
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if opts.duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.duration)
+		defer cancel()
+	}
+
+	replayer, err := kafkaadapter.NewReplayer(cfg, opts.topic)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = replayer.Close() }()
+
+	g := &loadgen{
+		publisher: replayer,
+		generator: synthetic.NewGenerator(opts.seed),
+		batchSize: opts.batchSize,
+		count:     opts.count,
+	}
+	if opts.rate > 0 {
+		// Keep batches to about a second's worth so the rate stays smooth.
+		g.batchSize = min(opts.batchSize, max(1, int(opts.rate)))
+		g.limiter = rate.NewLimiter(rate.Limit(opts.rate), g.batchSize)
+	}
+
+	log.Printf("publishing to %s (seed %d)", opts.topic, opts.seed)
+	start := time.Now()
+	err = g.run(ctx)
+	elapsed := time.Since(start)
+	log.Printf("published %d messages to %s in %s (%.0f/s)", g.published, opts.topic, elapsed.Round(time.Millisecond), float64(g.published)/elapsed.Seconds())
+	return err
+}
+
+func parseFlags() (options, error) {
+	var opts options
+	flag.StringVar(&opts.topic, "topic", "", "topic to publish to (default: first KAFKA_SOURCE_TOPIC)")
+	flag.Float64Var(&opts.rate, "rate", 100, "messages per second to publish (0 for as fast as the brokers accept)")
+	flag.IntVar(&opts.count, "count", 0, "stop after this many messages (0 for no limit)")
+	flag.DurationVar(&opts.duration, "duration", 0, "stop after this long (0 for no limit)")
+	flag.IntVar(&opts.batchSize, "batch-size", 100, "messages per produce request")
+	flag.Uint64Var(&opts.seed, "seed", 0, "seed of the synthetic report generator (0 for a random seed)")
+	flag.Parse()
+
+	if opts.rate < 0 {
+		return opts, fmt.Errorf("-rate must not be negative, got %g", opts.rate)
+	}
+	if opts.count < 0 {
+		return opts, fmt.Errorf("-count must not be negative, got %d", opts.count)
+	}
+	if opts.duration < 0 {
+		return opts, fmt.Errorf("-duration must not be negative, got %s", opts.duration)
+	}
+	if opts.batchSize < 1 {
+		return opts, fmt.Errorf("-batch-size must be positive, got %d", opts.batchSize)
+	}
+	return opts, nil
+}
+
+// publisher is the subset of kafka.Replayer used to publish.
+type publisher interface {
+	Publish(ctx context.Context, events []domain.RawEvent) error
+}
+
+// loadgen publishes generated reports in rate-limited batches.
+type loadgen struct {
+	publisher publisher
+	generator *synthetic.Generator
+	limiter   *rate.Limiter
+	batchSize int
+	count     int
+	published int
+}
+
+// run publishes until count messages are out or ctx ends. An interrupt or
+// the -duration deadline ends the run without an error.
+func (g *loadgen) run(ctx context.Context) error {
+	progress := time.NewTicker(progressInterval)
+	defer progress.Stop()
+	last, lastAt := 0, time.Now()
+	for g.count == 0 || g.published < g.count {
+		n := g.batchSize
+		if g.count > 0 {
+			n = min(n, g.count-g.published)
+		}
+		if g.limiter != nil {
+			// WaitN fails early when the wait would outlast the deadline.
+			if err := g.limiter.WaitN(ctx, n); err != nil {
+				<-ctx.Done()
+				return nil
+			}
+		}
+		batch := make([]domain.RawEvent, n)
+		now := time.Now()
+		for i := range batch {
+			batch[i] = domain.RawEvent{Value: g.generator.Value(), Timestamp: now}
+		}
+		if err := g.publisher.Publish(ctx, batch); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("publish: %w", err)
+		}
+		g.published += n
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-progress.C:
+			log.Printf("published %d messages (%.0f/s since the last report)", g.published, float64(g.published-last)/now.Sub(lastAt).Seconds())
+			last, lastAt = g.published, now
+		default:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Command nwsalertsmock serves the fake api.weather.gov alerts API from
+// stormtest/nwsalertstest, so the nws_alerts stage can run locally without
+// the real API, whose alerts age out after a few days. Point the service at
+// it with NWS_ALERTS_URL; by default it serves the hand-written alerts for
+// the mock reports of April 26, 2024.
+//
+// Usage:
+//
+//	go run ./cmd/nwsalertsmock -addr :8081
+//
+//	go run ./cmd/nwsalertsmock -alerts alerts.json -latency 300ms -error-rate 0.1
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/data/mock"
+	"github.com/couchcryptid/storm-data-etl/stormtest/nwsalertstest"
+)
+
+// options are the parsed command-line flags.
+type options struct {
+	addr      string
+	alerts    string
+	latency   time.Duration
+	errorRate float64
+}
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	opts, err := parseFlags()
+	if err != nil {
+		flag.Usage()
+		return err
+	}
+	fixture := mock.NWSAlerts
+	if opts.alerts != "" {
+		if fixture, err = os.ReadFile(opts.alerts); err != nil {
+			return err
+		}
+	}
+	handler, err := nwsalertstest.New(fixture, nwsalertstest.WithLatency(opts.latency), nwsalertstest.WithErrorRate(opts.errorRate))
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	srv := &http.Server{Addr: opts.addr, Handler: handler, ReadHeaderTimeout: 5 * time.Second}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+	log.Printf("serving mock NWS alerts on %s", opts.addr)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func parseFlags() (options, error) {
+	var opts options
+	flag.StringVar(&opts.addr, "addr", ":8081", "address to listen on")
+	flag.StringVar(&opts.alerts, "alerts", "", "alerts GeoJSON FeatureCollection to serve (default: the mock alerts for April 26, 2024)")
+	flag.DurationVar(&opts.latency, "latency", 0, "delay before every response")
+	flag.Float64Var(&opts.errorRate, "error-rate", 0, "share of requests answered 503 Service Unavailable, from 0 to 1")
+	flag.Parse()
+
+	if opts.latency < 0 {
+		return opts, fmt.Errorf("-latency must not be negative, got %s", opts.latency)
+	}
+	if opts.errorRate < 0 || opts.errorRate > 1 {
+		return opts, fmt.Errorf("-error-rate must be from 0 to 1, got %g", opts.errorRate)
+	}
+	return opts, nil
+}
@@ -0,0 +1,262 @@
+// Command replay copies raw storm reports back onto the pipeline's source
+// topic for disaster-recovery reprocessing. Reports are read from a Kafka
+// topic (for example a backup mirror or the dead-letter topic) or from
+// archived NDJSON / JSON array files, optionally limited to an offset range
+// and a time window, and published at a controllable rate.
+//
+// Broker and security settings come from the same environment variables as
+// the service (KAFKA_BROKERS, KAFKA_SASL_*, KAFKA_TLS_*).
+//
+// Usage:
+//
+//	go run ./cmd/replay -from-topic raw-weather-reports-backup \
+//	  -since 2024-04-26T00:00:00Z -until 2024-04-27T00:00:00Z -rate 200
+//
+//	go run ./cmd/replay -file 'dumps/*.ndjson' -start-offset 100 -dry-run
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/fileadapter"
+	kafkaadapter "github.com/couchcryptid/storm-data-etl/internal/adapter/kafka"
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"golang.org/x/time/rate"
+)
+
+// options are the parsed command-line flags.
+type options struct {
+	fromTopic   string
+	file        string
+	toTopic     string
+	partition   int
+	startOffset int64
+	endOffset   int64
+	since       time.Time
+	until       time.Time
+	rate        float64
+	batchSize   int
+	dryRun      bool
+}
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	opts, err := parseFlags()
+	if err != nil {
+		flag.Usage()
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	if opts.toTopic == "" {
+		opts.toTopic = cfg.KafkaSourceTopics[0]
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	replayer, err := kafkaadapter.NewReplayer(cfg, opts.toTopic)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = replayer.Close() }()
+
+	r := &replay{opts: opts, publisher: replayer}
+	if opts.rate > 0 {
+		// Keep batches to about a second's worth so the rate stays smooth.
+		r.opts.batchSize = min(opts.batchSize, max(1, int(opts.rate)))
+		r.limiter = rate.NewLimiter(rate.Limit(opts.rate), r.opts.batchSize)
+	}
+	add := func(event domain.RawEvent) error { return r.add(ctx, event) }
+
+	start := time.Now()
+	if opts.file != "" {
+		err = scanFiles(ctx, cfg, opts.file, add)
+	} else {
+		err = replayer.Scan(ctx, opts.fromTopic, opts.partition, opts.startOffset, opts.endOffset, add)
+	}
+	if err == nil {
+		err = r.flush(ctx)
+	}
+
+	action := "replayed"
+	if opts.dryRun {
+		action = "matched (dry run)"
+	}
+	log.Printf("%s %d of %d messages to %s in %s", action, r.published, r.scanned, opts.toTopic, time.Since(start).Round(time.Millisecond))
+	return err
+}
+
+func parseFlags() (options, error) {
+	var opts options
+	var since, until string
+	flag.StringVar(&opts.fromTopic, "from-topic", "", "Kafka topic to copy messages from")
+	flag.StringVar(&opts.file, "file", "", "NDJSON or JSON array file, directory, or glob to copy records from")
+	flag.StringVar(&opts.toTopic, "to-topic", "", "topic to publish to (default: first KAFKA_SOURCE_TOPIC)")
+	flag.IntVar(&opts.partition, "partition", -1, "only read this partition of -from-topic (-1 for all)")
+	flag.Int64Var(&opts.startOffset, "start-offset", -1, "first offset to copy, or record number within each file (-1 for the earliest)")
+	flag.Int64Var(&opts.endOffset, "end-offset", -1, "stop before this offset or record number (-1 for the latest)")
+	flag.StringVar(&since, "since", "", "skip messages timestamped before this RFC 3339 time")
+	flag.StringVar(&until, "until", "", "skip messages timestamped at or after this RFC 3339 time")
+	flag.Float64Var(&opts.rate, "rate", 0, "maximum messages per second to publish (0 for unlimited)")
+	flag.IntVar(&opts.batchSize, "batch-size", 100, "messages per produce request")
+	flag.BoolVar(&opts.dryRun, "dry-run", false, "count matching messages without publishing them")
+	flag.Parse()
+
+	if (opts.fromTopic == "") == (opts.file == "") {
+		return opts, errors.New("exactly one of -from-topic or -file is required")
+	}
+	if opts.file != "" && opts.partition >= 0 {
+		return opts, errors.New("-partition only applies to -from-topic")
+	}
+	if opts.startOffset >= 0 && opts.endOffset >= 0 && opts.startOffset >= opts.endOffset {
+		return opts, fmt.Errorf("-start-offset %d must be before -end-offset %d", opts.startOffset, opts.endOffset)
+	}
+	if opts.rate < 0 {
+		return opts, fmt.Errorf("-rate must not be negative, got %g", opts.rate)
+	}
+	if opts.batchSize < 1 {
+		return opts, fmt.Errorf("-batch-size must be positive, got %d", opts.batchSize)
+	}
+	var err error
+	if opts.since, err = parseTime("since", since); err != nil {
+		return opts, err
+	}
+	if opts.until, err = parseTime("until", until); err != nil {
+		return opts, err
+	}
+	if !opts.since.IsZero() && !opts.until.IsZero() && !opts.since.Before(opts.until) {
+		return opts, errors.New("-since must be before -until")
+	}
+	return opts, nil
+}
+
+func parseTime(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("-%s: %w", name, err)
+	}
+	return t, nil
+}
+
+// scanFiles feeds every record of the matching files to fn. Each record's
+// offset is its 1-based position in its file and its timestamp is the file's
+// report date, as for the service's file source.
+func scanFiles(ctx context.Context, cfg *config.Config, path string, fn func(domain.RawEvent) error) error {
+	fileCfg := *cfg
+	fileCfg.FileSourcePath = path
+	fileCfg.BatchFlushInterval = 0
+	extractor, err := fileadapter.NewExtractor(&fileCfg, discardLogger())
+	if err != nil {
+		return err
+	}
+	defer func() { _ = extractor.Close() }()
+
+	for {
+		batch, err := extractor.ExtractBatch(ctx, 500)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return ctx.Err()
+		}
+		for i := range batch {
+			if err := fn(batch[i]); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// publisher is the subset of kafka.Replayer used to publish, so dry runs and
+// batching stay independent of the broker.
+type publisher interface {
+	Publish(ctx context.Context, events []domain.RawEvent) error
+}
+
+// replay filters scanned messages and publishes them in rate-limited batches.
+type replay struct {
+	opts      options
+	publisher publisher
+	limiter   *rate.Limiter
+	pending   []domain.RawEvent
+	scanned   int
+	published int
+}
+
+// add queues a message for publishing if it passes the offset and time
+// filters, flushing once a batch is full.
+func (r *replay) add(ctx context.Context, event domain.RawEvent) error {
+	r.scanned++
+	if !r.matches(event) {
+		return nil
+	}
+	r.pending = append(r.pending, event)
+	if len(r.pending) < r.opts.batchSize {
+		return nil
+	}
+	return r.flush(ctx)
+}
+
+func (r *replay) matches(event domain.RawEvent) bool {
+	o := r.opts
+	if o.startOffset >= 0 && event.Offset < o.startOffset {
+		return false
+	}
+	if o.endOffset >= 0 && event.Offset >= o.endOffset {
+		return false
+	}
+	if !o.since.IsZero() && event.Timestamp.Before(o.since) {
+		return false
+	}
+	if !o.until.IsZero() && !event.Timestamp.Before(o.until) {
+		return false
+	}
+	return true
+}
+
+// flush publishes the pending batch, waiting on the rate limiter first.
+func (r *replay) flush(ctx context.Context) error {
+	if len(r.pending) == 0 {
+		return nil
+	}
+	batch := r.pending
+	r.pending = r.pending[:0]
+	if r.opts.dryRun {
+		r.published += len(batch)
+		return nil
+	}
+	if r.limiter != nil {
+		if err := r.limiter.WaitN(ctx, len(batch)); err != nil {
+			return err
+		}
+	}
+	if err := r.publisher.Publish(ctx, batch); err != nil {
+		return fmt.Errorf("publish: %w", err)
+	}
+	r.published += len(batch)
+	return nil
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
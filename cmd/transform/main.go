@@ -0,0 +1,164 @@
+// Command transform runs raw collector records through the same transformer
+// as the pipeline and prints the enriched StormEvents, for debugging
+// individual records without Kafka. Input is read from a file or stdin and
+// may hold one JSON object, a JSON array, or newline-delimited objects. The
+// transformer is built from the service's environment exactly as cmd/etl
+// builds it, so enrichment settings (MEASUREMENT_UNITS, SEVERITY_THRESHOLDS,
+// ID_STRATEGY, ...), NWS_ALERTS, and REDACT_COMMENTS apply. Logs go to
+// stderr.
+//
+// Usage:
+//
+//	echo '{"Time":"1510","Size":"175","Location":"8 ESE Chappel","State":"TX","Lat":"31.02","Lon":"-98.44","EventType":"hail"}' |
+//	  go run ./cmd/transform -audit
+//
+//	go run ./cmd/transform -date 2024-04-26 data/mock/storm_reports_240426_combined.json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/app"
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+)
+
+// auditedEvent is the output format with -audit.
+type auditedEvent struct {
+	Event     domain.StormEvent  `json:"event"`
+	Decisions []domain.AuditStep `json:"decisions"`
+}
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	date := flag.String("date", "", "report date (YYYY-MM-DD) for records with bare HHMM times (default: today, UTC)")
+	audit := flag.Bool("audit", false, "also print every normalization decision")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [file]\n\nReads stdin when no file is given.\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 1 {
+		flag.Usage()
+		return errors.New("at most one input file may be given")
+	}
+
+	base := time.Now().UTC().Truncate(24 * time.Hour)
+	if *date != "" {
+		t, err := time.Parse(time.DateOnly, *date)
+		if err != nil {
+			return fmt.Errorf("-date: %w", err)
+		}
+		base = t
+	}
+	transformer, err := newTransformer()
+	if err != nil {
+		return err
+	}
+
+	in := io.Reader(os.Stdin)
+	if path := flag.Arg(0); path != "" && path != "-" {
+		f, err := os.Open(path) //nolint:gosec // path comes from the command line
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		in = f
+	}
+	records, err := readRecords(in)
+	if err != nil {
+		return err
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	ctx := context.Background()
+	rejected := 0
+	for i, value := range records {
+		raw := domain.RawEvent{Value: value, Offset: int64(i + 1), Timestamp: base}
+		var output any
+		if *audit {
+			var event domain.StormEvent
+			var steps []domain.AuditStep
+			event, steps, err = transformer.TransformAudited(ctx, raw)
+			output = auditedEvent{Event: event, Decisions: steps}
+		} else {
+			output, err = transformer.Transform(ctx, raw)
+		}
+		if err != nil {
+			rejected++
+			fmt.Fprintf(os.Stderr, "record %d rejected: %v\n", i+1, err)
+			continue
+		}
+		if err := out.Encode(output); err != nil {
+			return err
+		}
+	}
+	if rejected > 0 {
+		return fmt.Errorf("%d of %d records rejected", rejected, len(records))
+	}
+	return nil
+}
+
+// newTransformer builds the pipeline's transformer from the environment, as
+// the service does at startup.
+func newTransformer() (*pipeline.StormTransformer, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	// stdout carries the events, so logs such as failed alert lookups go to
+	// stderr.
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	opts, err := app.TransformerOptions(cfg, logger, observability.NewMetrics())
+	if err != nil {
+		return nil, err
+	}
+	return pipeline.NewTransformer(logger, opts...), nil
+}
+
+// readRecords splits the input into raw records: the elements of a JSON
+// array, or each top-level object of a single or newline-delimited stream.
+func readRecords(r io.Reader) ([]json.RawMessage, error) {
+	dec := json.NewDecoder(r)
+	var records []json.RawMessage
+	for {
+		var value json.RawMessage
+		err := dec.Decode(&value)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read record %d: %w", len(records)+1, err)
+		}
+		if len(value) > 0 && value[0] == '[' {
+			var elems []json.RawMessage
+			if err := json.Unmarshal(value, &elems); err != nil {
+				return nil, err
+			}
+			records = append(records, elems...)
+			continue
+		}
+		records = append(records, value)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("no records in input")
+	}
+	return records, nil
+}
@@ -1,7 +1,8 @@
 // Command validate performs end-to-end data integrity checks across all mock
 // data sources in the storm data pipeline: source CSVs, collector CSVs, ETL
 // JSON, and API JSON. It verifies row counts, field presence, transformation
-// correctness, and cross-source consistency.
+// correctness, cross-source consistency, and conformance to the published JSON
+// Schemas for RawCSVRecord and StormEvent.
 //
 // Usage:
 //
@@ -10,6 +11,12 @@
 //	  -collector-dir ../storm-data-collector/data/mock \
 //	  -etl-json data/mock/storm_reports_240426_combined.json \
 //	  -api-json ../storm-data-api/data/mock/storm_reports_240426_transformed.json
+//
+// Add -format json or -format junit for a machine-readable report on stdout.
+//
+// Write the JSON Schemas for downstream contract tests:
+//
+//	go run ./cmd/validate -emit-schemas schemas
 package main
 
 import (
@@ -17,9 +24,11 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -29,6 +38,10 @@ import (
 
 var baseDate = time.Date(2024, time.April, 26, 0, 0, 0, 0, time.UTC)
 
+// progress receives status output that is not part of the report: stdout for
+// the text format, stderr for machine-readable formats.
+var progress io.Writer = os.Stdout
+
 // csvSpec maps event types to their CSV file patterns and magnitude columns.
 type csvSpec struct {
 	sourceFile    string // filename in mock-server/data/
@@ -60,19 +73,33 @@ func main() {
 	collectorDir := flag.String("collector-dir", "", "directory containing collector mock CSV files")
 	etlJSON := flag.String("etl-json", "", "path to ETL combined JSON fixture")
 	apiJSON := flag.String("api-json", "", "path to API transformed JSON fixture")
+	emitDir := flag.String("emit-schemas", "", "write the RawCSVRecord and StormEvent JSON Schemas to this directory and exit")
+	format := flag.String("format", formatText, "report format: text, json, or junit")
 	flag.Parse()
 
-	if *sourceDir == "" || *collectorDir == "" || *etlJSON == "" || *apiJSON == "" {
+	if *emitDir != "" {
+		if err := emitSchemas(*emitDir); err != nil {
+			fmt.Fprintf(os.Stderr, "FATAL: emit schemas: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *sourceDir == "" || *collectorDir == "" || *etlJSON == "" || *apiJSON == "" || !slices.Contains(reportFormats, *format) {
 		flag.Usage()
 		os.Exit(1)
 	}
+	if *format != formatText {
+		// Keep stdout for the report alone.
+		progress = os.Stderr
+	}
 
-	if code := run(*sourceDir, *collectorDir, *etlJSON, *apiJSON); code != 0 {
+	if code := run(*sourceDir, *collectorDir, *etlJSON, *apiJSON, *format); code != 0 {
 		os.Exit(code)
 	}
 }
 
-func run(sourceDir, collectorDir, etlJSONPath, apiJSONPath string) int {
+func run(sourceDir, collectorDir, etlJSONPath, apiJSONPath, format string) int {
 	// Set a fixed clock matching genmock for ID reproducibility.
 	domain.SetClock(clockwork.NewFakeClockAt(
 		time.Date(2024, time.April, 27, 6, 0, 0, 0, time.UTC),
@@ -80,8 +107,8 @@ func run(sourceDir, collectorDir, etlJSONPath, apiJSONPath string) int {
 	defer domain.SetClock(nil)
 
 	// ── Load all data sources ──
-	fmt.Println("=== Storm Data Integrity Validation ===")
-	fmt.Println()
+	fmt.Fprintln(progress, "=== Storm Data Integrity Validation ===")
+	fmt.Fprintln(progress)
 
 	sourceSets, err := loadAllCSVs(sourceDir, func(s csvSpec) string { return s.sourceFile })
 	if err != nil {
@@ -107,47 +134,43 @@ func run(sourceDir, collectorDir, etlJSONPath, apiJSONPath string) int {
 		return 1
 	}
 
+	etlDocs, err := loadRawJSON(etlJSONPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "FATAL: load ETL JSON: %v\n", err)
+		return 1
+	}
+	apiDocs, err := loadRawJSON(apiJSONPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "FATAL: load API JSON: %v\n", err)
+		return 1
+	}
+
 	// ── Run validation phases ──
 	phases := []*phase{
 		validateSourceParity(sourceSets, collectorSets),
 		validateETLIntegrity(etlRecords, sourceSets),
 		validateAPITransformation(apiEvents, etlRecords),
 		validateSchemaAlignment(apiEvents),
+		validateJSONSchemas(etlDocs, apiDocs),
 	}
 
 	// ── Report results ──
-	fmt.Println()
-	allPassed := true
-	for _, p := range phases {
-		status := "\033[32mPASS\033[0m"
-		if !p.passed() {
-			status = fmt.Sprintf("\033[31mFAIL (%d errors)\033[0m", len(p.errors))
-			allPassed = false
-		}
-		fmt.Printf("  %-42s %s\n", p.name, status)
+	counts := recordCounts{
+		SourceCSV:    countRows(sourceSets),
+		CollectorCSV: countRows(collectorSets),
+		ETLJSON:      len(etlRecords),
+		APIJSON:      len(apiEvents),
+	}
+	if err := writeReport(os.Stdout, format, phases, counts); err != nil {
+		fmt.Fprintf(os.Stderr, "FATAL: write report: %v\n", err)
+		return 1
 	}
-
-	fmt.Println()
-	fmt.Printf("Records: %d source CSV, %d collector CSV, %d ETL JSON, %d API JSON\n",
-		countRows(sourceSets), countRows(collectorSets), len(etlRecords), len(apiEvents))
-
-	// Print detailed errors.
 	for _, p := range phases {
-		if p.passed() {
-			continue
-		}
-		fmt.Printf("\n--- %s ---\n", p.name)
-		for i, e := range p.errors {
-			fmt.Printf("  [%d] %s\n", i+1, e)
+		if !p.passed() {
+			return 1
 		}
 	}
-
-	if allPassed {
-		fmt.Println("\nAll validations passed.")
-		return 0
-	}
-	fmt.Println("\nValidation FAILED.")
-	return 1
+	return 0
 }
 
 // ── Data loading ──
@@ -214,6 +237,24 @@ func loadJSON[T any](path string) ([]T, error) {
 	return items, nil
 }
 
+// loadRawJSON loads a JSON array without a target type, keeping numbers as
+// json.Number for schema validation.
+func loadRawJSON(path string) ([]any, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.UseNumber()
+	var items []any
+	if err := dec.Decode(&items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 func countRows(sets map[string][]csvRow) int {
 	n := 0
 	for _, rows := range sets {
@@ -370,7 +411,7 @@ func validateAPITransformation(api []domain.StormEvent, etl []domain.RawCSVRecor
 	}
 
 	if dupeCount > 0 {
-		fmt.Printf("  Note: %d duplicate ID(s) found (matching DB upsert first-wins behavior)\n", dupeCount)
+		fmt.Fprintf(progress, "  Note: %d duplicate ID(s) found (matching DB upsert first-wins behavior)\n", dupeCount)
 	}
 
 	// Track which ETL records we've already seen (by ID) to skip duplicates.
@@ -537,6 +578,27 @@ func checkSchemaRequiredFields(pf func(string, ...any), e *domain.StormEvent) {
 	}
 }
 
+// ── Phase 5: JSON Schema Conformance ──
+// Validates both fixtures against the published JSON Schemas, the contract
+// downstream teams test against.
+
+func validateJSONSchemas(etl, api []any) *phase {
+	p := &phase{name: "Phase 5: JSON Schema Conformance"}
+	checkSchemaDocs(p, "ETL", rawCSVRecordSchema(), etl)
+	checkSchemaDocs(p, "API", stormEventSchema(), api)
+	return p
+}
+
+func checkSchemaDocs(p *phase, source string, s *jsonSchema, docs []any) {
+	for i, doc := range docs {
+		var errs []string
+		s.validate(doc, "$", &errs)
+		for _, e := range errs {
+			p.errorf("%s record %d (%s): %s", source, i, s.Title, e)
+		}
+	}
+}
+
 // ── Helpers ──
 
 func floatEq(a, b float64) bool {
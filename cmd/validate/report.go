@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Report formats selected with -format.
+const (
+	formatText  = "text"
+	formatJSON  = "json"
+	formatJUnit = "junit"
+)
+
+var reportFormats = []string{formatText, formatJSON, formatJUnit}
+
+// recordCounts is the number of records loaded from each data source.
+type recordCounts struct {
+	SourceCSV    int `json:"source_csv"`
+	CollectorCSV int `json:"collector_csv"`
+	ETLJSON      int `json:"etl_json"`
+	APIJSON      int `json:"api_json"`
+}
+
+// jsonReport is the -format json output.
+type jsonReport struct {
+	Passed  bool         `json:"passed"`
+	Records recordCounts `json:"records"`
+	Phases  []jsonPhase  `json:"phases"`
+}
+
+type jsonPhase struct {
+	Name   string   `json:"name"`
+	Passed bool     `json:"passed"`
+	Errors []string `json:"errors"`
+}
+
+// junitTestSuites is the -format junit output: one test suite with a test
+// case per phase, failing with every error of that phase.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value int    `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeReport writes the phase results in the given format.
+func writeReport(w io.Writer, format string, phases []*phase, counts recordCounts) error {
+	switch format {
+	case formatJSON:
+		return writeJSONReport(w, phases, counts)
+	case formatJUnit:
+		return writeJUnitReport(w, phases, counts)
+	default:
+		writeTextReport(w, phases, counts)
+		return nil
+	}
+}
+
+func writeTextReport(w io.Writer, phases []*phase, counts recordCounts) {
+	fmt.Fprintln(w)
+	allPassed := true
+	for _, p := range phases {
+		status := "\033[32mPASS\033[0m"
+		if !p.passed() {
+			status = fmt.Sprintf("\033[31mFAIL (%d errors)\033[0m", len(p.errors))
+			allPassed = false
+		}
+		fmt.Fprintf(w, "  %-42s %s\n", p.name, status)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Records: %d source CSV, %d collector CSV, %d ETL JSON, %d API JSON\n",
+		counts.SourceCSV, counts.CollectorCSV, counts.ETLJSON, counts.APIJSON)
+
+	// Print detailed errors.
+	for _, p := range phases {
+		if p.passed() {
+			continue
+		}
+		fmt.Fprintf(w, "\n--- %s ---\n", p.name)
+		for i, e := range p.errors {
+			fmt.Fprintf(w, "  [%d] %s\n", i+1, e)
+		}
+	}
+
+	if allPassed {
+		fmt.Fprintln(w, "\nAll validations passed.")
+		return
+	}
+	fmt.Fprintln(w, "\nValidation FAILED.")
+}
+
+func writeJSONReport(w io.Writer, phases []*phase, counts recordCounts) error {
+	report := jsonReport{Passed: true, Records: counts, Phases: make([]jsonPhase, len(phases))}
+	for i, p := range phases {
+		errs := p.errors
+		if errs == nil {
+			errs = []string{}
+		}
+		report.Phases[i] = jsonPhase{Name: p.name, Passed: p.passed(), Errors: errs}
+		report.Passed = report.Passed && p.passed()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+func writeJUnitReport(w io.Writer, phases []*phase, counts recordCounts) error {
+	suite := junitTestSuite{
+		Name:  "storm-data-validate",
+		Tests: len(phases),
+		Properties: []junitProperty{
+			{Name: "records.source_csv", Value: counts.SourceCSV},
+			{Name: "records.collector_csv", Value: counts.CollectorCSV},
+			{Name: "records.etl_json", Value: counts.ETLJSON},
+			{Name: "records.api_json", Value: counts.APIJSON},
+		},
+	}
+	for _, p := range phases {
+		tc := junitTestCase{ClassName: "validate", Name: p.name}
+		if !p.passed() {
+			suite.Failures++
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d errors", len(p.errors)),
+				Text:    strings.Join(p.errors, "\n"),
+			}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	report := junitTestSuites{Name: suite.Name, Tests: suite.Tests, Failures: suite.Failures, Suites: []junitTestSuite{suite}}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// schemaBaseURL prefixes the $id of the published schemas.
+const schemaBaseURL = "https://github.com/couchcryptid/storm-data-etl/schemas/"
+
+// jsonSchema is the subset of JSON Schema (draft 2020-12) needed to describe
+// the ETL's input and output records. validate implements the same subset, so
+// the published schemas and the contract check cannot drift apart.
+type jsonSchema struct {
+	Schema      string `json:"$schema,omitempty"`
+	ID          string `json:"$id,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	Type                 string                 `json:"type,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+
+	re *regexp.Regexp // compiled Pattern
+}
+
+// publishedSchemas are written by -emit-schemas, keyed by file name.
+func publishedSchemas() map[string]*jsonSchema {
+	return map[string]*jsonSchema{
+		"raw_csv_record.schema.json": rawCSVRecordSchema(),
+		"storm_event.schema.json":    stormEventSchema(),
+	}
+}
+
+// rawCSVRecordSchema describes the flat collector record (domain.RawCSVRecord).
+func rawCSVRecordSchema() *jsonSchema {
+	coordinate := &jsonSchema{Type: "string", Pattern: `^-?[0-9]+(\.[0-9]+)?$`}
+	return &jsonSchema{
+		Schema:      "https://json-schema.org/draft/2020-12/schema",
+		ID:          schemaBaseURL + "raw_csv_record.schema.json",
+		Title:       "RawCSVRecord",
+		Description: "Flat storm report produced by the collector and consumed from the source topic.",
+		Type:        "object",
+		Properties: map[string]*jsonSchema{
+			"Time": {
+				Type:        "string",
+				Description: "Report time as HHMM (legacy) or an RFC 3339 timestamp.",
+				Pattern:     `^([0-9]{3,4}|[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?(Z|[+-][0-9]{2}:[0-9]{2}))$`,
+			},
+			"Size":      {Type: "string", Description: "Hail size in hundredths of an inch."},
+			"F_Scale":   {Type: "string", Description: "Tornado rating, e.g. EF1 or UNK."},
+			"Speed":     {Type: "string", Description: "Wind speed in mph."},
+			"Magnitude": {Type: "string", Description: "Magnitude for report types beyond the SPC CSVs."},
+			"Location":  {Type: "string", Description: "NWS relative location, e.g. 8 ESE Chappel."},
+			"County":    {Type: "string"},
+			"State":     {Type: "string"},
+			"Lat":       coordinate,
+			"Lon":       coordinate,
+			"Comments":  {Type: "string"},
+			"EventType": {Type: "string", Enum: domain.AcceptedEventTypes()},
+			"Deleted":   {Type: "boolean", Description: "Retracts a report SPC removed from a later version of the CSV."},
+			"BeginLat":  coordinate,
+			"BeginLon":  coordinate,
+			"EndLat":    coordinate,
+			"EndLon":    coordinate,
+
+			"MagnitudeMethod": {Type: "string", Description: "Magnitude qualifier: M (measured), E (estimated), or U (unknown)."},
+		},
+		Required:             []string{"Time", "Location", "County", "State", "Lat", "Lon", "Comments", "EventType"},
+		AdditionalProperties: ptr(false),
+	}
+}
+
+// stormEventSchema describes the enriched event (domain.StormEvent) written
+// to the sink topic.
+func stormEventSchema() *jsonSchema {
+	geo := func() *jsonSchema {
+		return object(map[string]*jsonSchema{
+			"lat": {Type: "number", Minimum: ptr(-90.0), Maximum: ptr(90.0)},
+			"lon": {Type: "number", Minimum: ptr(-180.0), Maximum: ptr(180.0)},
+		})
+	}
+	timestamp := func() *jsonSchema { return &jsonSchema{Type: "string", Format: "date-time"} }
+	quantity := object(map[string]*jsonSchema{
+		"magnitude": {Type: "number"},
+		"unit":      {Type: "string"},
+	}, "magnitude", "unit")
+	count := func() *jsonSchema { return &jsonSchema{Type: "integer", Minimum: ptr(0.0)} }
+
+	measurement := object(map[string]*jsonSchema{
+		"magnitude": {Type: "number", Minimum: ptr(0.0)},
+		"unit":      {Type: "string"},
+		"severity":  {Type: "string", Enum: domain.SeverityLevels},
+		"metric":    quantity,
+		"method":    {Type: "string", Enum: []string{domain.MethodMeasured, domain.MethodEstimated}},
+	}, "magnitude", "unit")
+	location := object(map[string]*jsonSchema{
+		"raw":       {Type: "string"},
+		"name":      {Type: "string"},
+		"distance":  {Type: "number", Minimum: ptr(0.0)},
+		"direction": {Type: "string", Pattern: `^[NSEW]{1,3}$`},
+		"state":     {Type: "string", Pattern: `^[A-Z]{2}$`},
+		"county":    {Type: "string"},
+		"place_geo": geo(),
+		"cwa":       {Type: "string", Pattern: `^[A-Z]{3}$`, Description: "NWS County Warning Area containing the report."},
+		"zone":      {Type: "string", Pattern: `^[A-Z]{2}Z[0-9]{3}$`, Description: "NWS public forecast zone (UGC code) containing the report."},
+	})
+	impact := object(map[string]*jsonSchema{
+		"injuries":   count(),
+		"fatalities": count(),
+		"damage":     {Type: "array", Items: &jsonSchema{Type: "string"}},
+	})
+	office := object(map[string]*jsonSchema{
+		"code":  {Type: "string"},
+		"name":  {Type: "string"},
+		"state": {Type: "string"},
+		"geo":   geo(),
+	}, "code", "name", "state", "geo")
+	city := object(map[string]*jsonSchema{
+		"name":      {Type: "string"},
+		"state":     {Type: "string", Pattern: `^[A-Z]{2}$`},
+		"distance":  {Type: "number", Minimum: ptr(0.0), Description: "Miles from the city to the report."},
+		"direction": {Type: "string", Pattern: `^[NSEW]{1,3}$`, Description: "Compass direction from the city to the report."},
+		"geo":       geo(),
+	}, "name", "state", "distance", "direction", "geo")
+	nearbyPlaces := object(map[string]*jsonSchema{
+		"population": {Type: "integer", Minimum: ptr(0.0), Description: "Summed population of the listed places within the radius."},
+		"places":     count(),
+		"radius":     {Type: "number", Minimum: ptr(0.0), Description: "Radius in miles."},
+	}, "population", "places", "radius")
+
+	quality := object(map[string]*jsonSchema{
+		"inferred": {Type: "array", Items: &jsonSchema{Type: "string"}, Description: "JSON paths of values inferred by the pipeline."},
+		"flags": {
+			Type:        "array",
+			Items:       &jsonSchema{Type: "string", Enum: slices.Sorted(slices.Values(append(domain.ValidationChecks(), domain.FlagCommentsTruncated, domain.FlagRawTextDropped)))},
+			Description: "Validation checks the event failed under annotate rules, and how it was shortened to fit the sink message size.",
+		},
+	})
+
+	s := object(map[string]*jsonSchema{
+		"schema_version":       {Type: "integer", Minimum: ptr(1.0), Maximum: ptr(float64(domain.SchemaVersion))},
+		"id":                   {Type: "string", MinLength: ptr(1)},
+		"event_type":           {Type: "string", Enum: domain.EventTypes()},
+		"geo":                  geo(),
+		"measurement":          measurement,
+		"event_time":           timestamp(),
+		"end_time":             timestamp(),
+		"location":             location,
+		"comments":             {Type: "string"},
+		"source_office":        {Type: "string", Pattern: `^[A-Z]{3,5}$`},
+		"impact":               impact,
+		"time_bucket":          timestamp(),
+		"geohash":              {Type: "string", Pattern: `^[0-9b-hjkmnp-z]{1,12}$`, Description: "Geohash of geo; events sharing a prefix lie in the same cell."},
+		"source_office_detail": office,
+		"nearest_city":         city,
+		"nearby_places":        nearbyPlaces,
+		"warning_active":       {Type: "boolean", Description: "Whether the report lies inside an NWS warning polygon in effect at event_time."},
+		"warning_ids":          {Type: "array", Items: &jsonSchema{Type: "string"}, Description: "api.weather.gov IDs of the warnings in effect."},
+		"path_begin":           geo(),
+		"path_end":             geo(),
+		"quality":              quality,
+		"merged_from":          {Type: "array", Items: &jsonSchema{Type: "string"}, Description: "IDs of near-duplicate reports merged into this one."},
+		"episode_id":           {Type: "string", Pattern: `^episode-[0-9a-f]{16}$`},
+		"provenance":           object(map[string]*jsonSchema{"headers": {Type: "object", Description: "Selected source message headers by name."}}),
+		"processed_at":         timestamp(),
+		"deleted":              {Type: "boolean", Description: "Retraction of a previously loaded event; written as a tombstone on Kafka topics."},
+	}, "id", "event_type", "geo", "measurement", "event_time", "location", "time_bucket", "processed_at")
+	s.Schema = "https://json-schema.org/draft/2020-12/schema"
+	s.ID = schemaBaseURL + "storm_event.schema.json"
+	s.Title = "StormEvent"
+	s.Description = fmt.Sprintf("Enriched storm event written to the sink topic (schema version %d).", domain.SchemaVersion)
+	return s
+}
+
+// object returns a closed object schema with the given properties.
+func object(props map[string]*jsonSchema, required ...string) *jsonSchema {
+	return &jsonSchema{Type: "object", Properties: props, Required: required, AdditionalProperties: ptr(false)}
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// emitSchemas writes the published schemas to dir.
+func emitSchemas(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for name, s := range publishedSchemas() {
+		data, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+			return err
+		}
+		fmt.Println("wrote", path)
+	}
+	return nil
+}
+
+// validate appends a message to errs for every way v violates the schema.
+// v must be decoded with json.Decoder.UseNumber so integers can be told apart.
+func (s *jsonSchema) validate(v any, path string, errs *[]string) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			fail("expected object, got %s", jsonType(v))
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(obj)) {
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					fail("unexpected property %q", name)
+				}
+				continue
+			}
+			prop.validate(obj[name], path+"."+name, errs)
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			fail("expected array, got %s", jsonType(v))
+			return
+		}
+		if s.Items != nil {
+			for i, item := range arr {
+				s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			fail("expected boolean, got %s", jsonType(v))
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			fail("expected string, got %s", jsonType(v))
+			return
+		}
+		s.validateString(str, fail)
+	case "number", "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			fail("expected %s, got %s", s.Type, jsonType(v))
+			return
+		}
+		f, err := n.Float64()
+		if err != nil {
+			fail("invalid number %s", n)
+			return
+		}
+		if s.Type == "integer" && strings.ContainsAny(n.String(), ".eE") && f != float64(int64(f)) {
+			fail("expected integer, got %s", n)
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			fail("%s is below the minimum %g", n, *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			fail("%s is above the maximum %g", n, *s.Maximum)
+		}
+	}
+}
+
+func (s *jsonSchema) validateString(str string, fail func(string, ...any)) {
+	if s.MinLength != nil && len(str) < *s.MinLength {
+		fail("shorter than %d characters", *s.MinLength)
+	}
+	if len(s.Enum) > 0 && !slices.Contains(s.Enum, str) {
+		fail("%q is not one of %s", str, strings.Join(s.Enum, ", "))
+	}
+	if s.Pattern != "" {
+		if s.re == nil {
+			s.re = regexp.MustCompile(s.Pattern)
+		}
+		if !s.re.MatchString(str) {
+			fail("%q does not match %s", str, s.Pattern)
+		}
+	}
+	if s.Format == "date-time" {
+		if _, err := time.Parse(time.RFC3339, str); err != nil {
+			fail("%q is not an RFC 3339 date-time", str)
+		}
+	}
+}
+
+func jsonType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
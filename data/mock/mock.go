@@ -0,0 +1,20 @@
+// Package mock embeds the mock fixtures, so tests in other modules can read
+// them without a path into this source tree.
+package mock
+
+import _ "embed"
+
+// StormReports is the JSON array of collector records for the SPC reports of
+// April 26, 2024: 79 hail, 149 tornado, and 43 wind. It is generated with
+// go run ./cmd/genmock.
+//
+//go:embed storm_reports_240426_combined.json
+var StormReports []byte
+
+// NWSAlerts is an api.weather.gov alerts response for April 26, 2024,
+// written by hand around the mock reports: a severe thunderstorm warning
+// over Fort Worth and Arlington, TX, tornado warnings over Lincoln and Omaha,
+// NE, and a tornado watch covering both.
+//
+//go:embed nws_alerts_240426.json
+var NWSAlerts []byte
@@ -0,0 +1,81 @@
+// Package checkpoint persists pipeline progress to a local JSON file so
+// operators can see where the pipeline stopped after an incident without
+// Kafka tooling.
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// fileVersion is written to every checkpoint file so the format can evolve.
+const fileVersion = 1
+
+type checkpointFile struct {
+	Version    int                        `json:"version"`
+	Partitions []domain.PartitionProgress `json:"partitions"`
+}
+
+// FileStore keeps per-partition progress in a JSON file. Saves write a
+// temporary file in the same directory and rename it over the old one, so a
+// crash mid-write never leaves a truncated checkpoint. It implements
+// pipeline.ProgressStore.
+type FileStore struct {
+	path string
+}
+
+// NewFileStore returns a store backed by path. The file is created on the
+// first save; its directory must exist.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// LoadProgress reads the saved progress. A missing file yields no progress.
+func (s *FileStore) LoadProgress(_ context.Context) ([]domain.PartitionProgress, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read checkpoint: %w", err)
+	}
+	var f checkpointFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("decode checkpoint %s: %w", s.path, err)
+	}
+	if f.Version != fileVersion {
+		return nil, fmt.Errorf("checkpoint %s has unsupported version %d", s.path, f.Version)
+	}
+	return f.Partitions, nil
+}
+
+// SaveProgress replaces the saved progress.
+func (s *FileStore) SaveProgress(_ context.Context, progress []domain.PartitionProgress) error {
+	data, err := json.MarshalIndent(checkpointFile{Version: fileVersion, Partitions: progress}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode checkpoint: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	return nil
+}
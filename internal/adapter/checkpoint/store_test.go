@@ -0,0 +1,50 @@
+package checkpoint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFileStore(filepath.Join(dir, "progress.json"))
+
+	progress, err := store.LoadProgress(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, progress, "missing file means no progress yet")
+
+	want := []domain.PartitionProgress{
+		{Topic: "raw-weather-reports", Partition: 0, LastOffset: 41, LastEventTime: time.Date(2024, 4, 26, 21, 20, 0, 0, time.UTC), Loaded: 40, DeadLettered: 2, UpdatedAt: time.Date(2024, 4, 27, 6, 0, 0, 0, time.UTC)},
+		{Topic: "raw-weather-reports", Partition: 1, LastOffset: 7, Loaded: 8, UpdatedAt: time.Date(2024, 4, 27, 6, 0, 0, 0, time.UTC)},
+	}
+	require.NoError(t, store.SaveProgress(context.Background(), want))
+	require.NoError(t, store.SaveProgress(context.Background(), want), "saves replace the file")
+
+	got, err := store.LoadProgress(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files are cleaned up")
+}
+
+func TestFileStore_RejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.json")
+	store := NewFileStore(path)
+
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o600))
+	_, err := store.LoadProgress(context.Background())
+	require.ErrorContains(t, err, "decode checkpoint")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version":99,"partitions":[]}`), 0o600))
+	_, err = store.LoadProgress(context.Background())
+	require.ErrorContains(t, err, "unsupported version 99")
+}
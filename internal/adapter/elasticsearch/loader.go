@@ -0,0 +1,222 @@
+// Package elasticsearch indexes transformed storm events into Elasticsearch
+// or OpenSearch through the bulk API, one index per event-time day, so
+// dashboards can be built directly on the pipeline's output.
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-shared/retry"
+)
+
+// maxRetryBackoff caps the wait between retries of throttled documents.
+const maxRetryBackoff = 30 * time.Second
+
+// maxErrorBody caps how much of a failed response is read into the error.
+const maxErrorBody = 4 << 10
+
+// Loader bulk-indexes event batches into daily indices named
+// {prefix}-YYYY.MM.DD by event time (UTC). Document IDs are the event IDs, so
+// redelivered batches overwrite the same documents, and retracted events are
+// deleted. Documents rejected with 429 are retried with exponential backoff;
+// any other failure fails the batch so the pipeline retries it. It implements
+// pipeline.BatchLoader.
+type Loader struct {
+	client      *http.Client
+	url         string
+	indexPrefix string
+	username    string
+	password    string
+	apiKey      string
+	fields      *domain.FieldProjection
+	maxRetries  int
+	backoff     time.Duration
+	sleep       func(ctx context.Context, d time.Duration) bool
+	logger      *slog.Logger
+}
+
+// NewLoader creates a bulk-indexing loader from the service configuration.
+func NewLoader(cfg *config.Config, logger *slog.Logger) *Loader {
+	return &Loader{
+		client:      &http.Client{Timeout: 30 * time.Second},
+		url:         strings.TrimSuffix(cfg.ElasticsearchURL, "/"),
+		indexPrefix: cfg.ElasticsearchIndexPrefix,
+		username:    cfg.ElasticsearchUsername,
+		password:    cfg.ElasticsearchPassword,
+		apiKey:      cfg.ElasticsearchAPIKey,
+		fields:      cfg.ElasticsearchFields,
+		maxRetries:  cfg.ElasticsearchMaxRetries,
+		backoff:     cfg.ElasticsearchRetryBackoff,
+		sleep:       retry.SleepWithContext,
+		logger:      logger,
+	}
+}
+
+// LoadBatch indexes the batch, retrying throttled documents until they are
+// accepted or the retries run out.
+func (l *Loader) LoadBatch(ctx context.Context, events []domain.StormEvent) error {
+	pending := events
+	backoff := l.backoff
+	for attempt := 0; len(pending) > 0; attempt++ {
+		if attempt > 0 {
+			if attempt > l.maxRetries {
+				return fmt.Errorf("bulk index: %d documents still throttled after %d retries", len(pending), l.maxRetries)
+			}
+			l.logger.Warn("elasticsearch throttled bulk request, retrying", "documents", len(pending), "attempt", attempt, "backoff", backoff)
+			if !l.sleep(ctx, backoff) {
+				return ctx.Err()
+			}
+			backoff = retry.NextBackoff(backoff, maxRetryBackoff)
+		}
+		throttled, err := l.bulk(ctx, pending)
+		if err != nil {
+			return err
+		}
+		pending = throttled
+	}
+	return nil
+}
+
+// bulkResponse is the part of a bulk API response the loader inspects. Each
+// item holds a single entry keyed by the action name.
+type bulkResponse struct {
+	Errors bool                        `json:"errors"`
+	Items  []map[string]bulkItemResult `json:"items"`
+}
+
+type bulkItemResult struct {
+	ID     string `json:"_id"`
+	Status int    `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+// Close releases the client's idle keep-alive connections to the cluster.
+func (l *Loader) Close() error {
+	l.client.CloseIdleConnections()
+	return nil
+}
+
+// bulk sends one bulk request and returns the events that were throttled.
+func (l *Loader) bulk(ctx context.Context, events []domain.StormEvent) ([]domain.StormEvent, error) {
+	body, err := l.bulkBody(events)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.url+"/_bulk", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("bulk index: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	switch {
+	case l.apiKey != "":
+		req.Header.Set("Authorization", "ApiKey "+l.apiKey)
+	case l.username != "":
+		req.SetBasicAuth(l.username, l.password)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("bulk index: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return events, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, fmt.Errorf("bulk index: unexpected status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	var result bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("bulk index: decode response: %w", err)
+	}
+	if !result.Errors {
+		return nil, nil
+	}
+	if len(result.Items) != len(events) {
+		return nil, fmt.Errorf("bulk index: got %d results for %d documents", len(result.Items), len(events))
+	}
+
+	var throttled []domain.StormEvent
+	var failed error
+	for i, item := range result.Items {
+		for action, r := range item {
+			switch {
+			case r.Status < 300:
+			case action == "delete" && r.Status == http.StatusNotFound:
+				// Already gone; the retraction is complete.
+			case r.Status == http.StatusTooManyRequests:
+				throttled = append(throttled, events[i])
+			default:
+				reason := http.StatusText(r.Status)
+				if r.Error != nil {
+					reason = r.Error.Type + ": " + r.Error.Reason
+				}
+				failed = errors.Join(failed, fmt.Errorf("%s %s: %s", action, r.ID, reason))
+			}
+		}
+	}
+	if failed != nil {
+		return nil, fmt.Errorf("bulk index: %w", failed)
+	}
+	return throttled, nil
+}
+
+// bulkAction is the metadata line preceding each document.
+type bulkAction struct {
+	Index string `json:"_index"`
+	ID    string `json:"_id"`
+}
+
+// bulkBody builds the NDJSON request body: an index action and the serialized
+// event for each event, or a delete action for a retraction.
+func (l *Loader) bulkBody(events []domain.StormEvent) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range events {
+		action := bulkAction{Index: l.indexName(events[i]), ID: events[i].ID}
+		if events[i].Deleted {
+			if err := enc.Encode(map[string]bulkAction{"delete": action}); err != nil {
+				return nil, fmt.Errorf("marshal bulk action: %w", err)
+			}
+			continue
+		}
+		if err := enc.Encode(map[string]bulkAction{"index": action}); err != nil {
+			return nil, fmt.Errorf("marshal bulk action: %w", err)
+		}
+		projected, err := l.fields.Apply(events[i])
+		if err == nil {
+			err = enc.Encode(projected)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("marshal event %s: %w", events[i].ID, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// indexName returns {prefix}-YYYY.MM.DD for the event's UTC day. Events
+// without an event time fall back to their processing time.
+func (l *Loader) indexName(e domain.StormEvent) string {
+	t := e.EventTime
+	if t.IsZero() {
+		t = e.ProcessedAt
+	}
+	return l.indexPrefix + "-" + t.UTC().Format("2006.01.02")
+}
@@ -0,0 +1,199 @@
+package elasticsearch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bulkServer records bulk requests and answers each with the next response.
+// A response is a status code and, for 200, the per-document item statuses.
+type bulkServer struct {
+	mu        sync.Mutex
+	requests  [][]map[string]any
+	auth      []string
+	responses []bulkReply
+}
+
+type bulkReply struct {
+	status int
+	items  []int
+}
+
+func (s *bulkServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.URL.Path != "/_bulk" || r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/x-ndjson" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	var lines []map[string]any
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		lines = append(lines, line)
+	}
+	s.requests = append(s.requests, lines)
+	s.auth = append(s.auth, r.Header.Get("Authorization"))
+
+	reply := bulkReply{status: http.StatusOK}
+	if len(s.responses) > 0 {
+		reply, s.responses = s.responses[0], s.responses[1:]
+	}
+	if reply.status != http.StatusOK {
+		http.Error(w, `{"error":"throttled"}`, reply.status)
+		return
+	}
+	// Item results follow the action lines, so each reports its own action.
+	var actions []string
+	for _, line := range lines {
+		for _, action := range []string{"index", "delete"} {
+			if _, ok := line[action]; ok {
+				actions = append(actions, action)
+			}
+		}
+	}
+	var items []string
+	errorsFound := false
+	for i, status := range reply.items {
+		if status >= 300 {
+			errorsFound = true
+		}
+		items = append(items, fmt.Sprintf(`{%q:{"_id":"doc-%d","status":%d,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}}}`, actions[i], i, status))
+	}
+	_, _ = fmt.Fprintf(w, `{"took":3,"errors":%t,"items":[%s]}`, errorsFound, strings.Join(items, ","))
+}
+
+func newTestLoader(t *testing.T, srv *bulkServer, cfg config.Config) (*Loader, *[]time.Duration) {
+	t.Helper()
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	cfg.ElasticsearchURL = ts.URL + "/"
+	if cfg.ElasticsearchIndexPrefix == "" {
+		cfg.ElasticsearchIndexPrefix = "storm-events"
+	}
+	if cfg.ElasticsearchRetryBackoff == 0 {
+		cfg.ElasticsearchRetryBackoff = 100 * time.Millisecond
+	}
+	l := NewLoader(&cfg, slog.New(slog.DiscardHandler))
+	var waits []time.Duration
+	l.sleep = func(_ context.Context, d time.Duration) bool {
+		waits = append(waits, d)
+		return true
+	}
+	return l, &waits
+}
+
+func testEvents() []domain.StormEvent {
+	return []domain.StormEvent{
+		{ID: "hail-1", EventType: "hail", EventTime: time.Date(2024, time.April, 26, 15, 10, 0, 0, time.UTC)},
+		{ID: "wind-1", EventType: "wind", EventTime: time.Date(2024, time.April, 27, 1, 0, 0, 0, time.UTC)},
+		{ID: "torn-1", EventType: "tornado", EventTime: time.Date(2024, time.April, 26, 20, 0, 0, 0, time.UTC), Deleted: true},
+	}
+}
+
+func TestLoadBatch_BulkBody(t *testing.T) {
+	srv := &bulkServer{}
+	l, waits := newTestLoader(t, srv, config.Config{ElasticsearchUsername: "etl", ElasticsearchPassword: "secret"})
+
+	require.NoError(t, l.LoadBatch(context.Background(), testEvents()))
+	require.Len(t, srv.requests, 1)
+	lines := srv.requests[0]
+	require.Len(t, lines, 5, "two index actions with documents and one delete")
+
+	assert.Equal(t, map[string]any{"index": map[string]any{"_index": "storm-events-2024.04.26", "_id": "hail-1"}}, lines[0])
+	assert.Equal(t, "hail-1", lines[1]["id"])
+	assert.Equal(t, "hail", lines[1]["event_type"])
+	assert.Equal(t, map[string]any{"index": map[string]any{"_index": "storm-events-2024.04.27", "_id": "wind-1"}}, lines[2])
+	assert.Equal(t, map[string]any{"delete": map[string]any{"_index": "storm-events-2024.04.26", "_id": "torn-1"}}, lines[4])
+
+	assert.True(t, strings.HasPrefix(srv.auth[0], "Basic "))
+	assert.Empty(t, *waits)
+}
+
+func TestLoadBatch_APIKey(t *testing.T) {
+	srv := &bulkServer{}
+	l, _ := newTestLoader(t, srv, config.Config{ElasticsearchAPIKey: "a2V5"})
+	require.NoError(t, l.LoadBatch(context.Background(), testEvents()[:1]))
+	assert.Equal(t, []string{"ApiKey a2V5"}, srv.auth)
+}
+
+func TestLoadBatch_RetriesThrottledDocuments(t *testing.T) {
+	srv := &bulkServer{responses: []bulkReply{
+		{status: http.StatusTooManyRequests},
+		{status: http.StatusOK, items: []int{201, 429, 200}},
+		{status: http.StatusOK, items: []int{201}},
+	}}
+	l, waits := newTestLoader(t, srv, config.Config{ElasticsearchMaxRetries: 3})
+
+	require.NoError(t, l.LoadBatch(context.Background(), testEvents()))
+	require.Len(t, srv.requests, 3)
+	assert.Len(t, srv.requests[1], 5, "a throttled request is resent whole")
+	require.Len(t, srv.requests[2], 2, "only the throttled document is retried")
+	assert.Equal(t, "wind-1", srv.requests[2][1]["id"])
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}, *waits)
+}
+
+func TestLoadBatch_ThrottledRetriesExhausted(t *testing.T) {
+	srv := &bulkServer{responses: []bulkReply{
+		{status: http.StatusTooManyRequests},
+		{status: http.StatusTooManyRequests},
+		{status: http.StatusTooManyRequests},
+	}}
+	l, _ := newTestLoader(t, srv, config.Config{ElasticsearchMaxRetries: 2})
+
+	err := l.LoadBatch(context.Background(), testEvents())
+	require.ErrorContains(t, err, "3 documents still throttled after 2 retries")
+	assert.Len(t, srv.requests, 3)
+}
+
+func TestLoadBatch_DocumentError(t *testing.T) {
+	srv := &bulkServer{responses: []bulkReply{{status: http.StatusOK, items: []int{201, 400, 429}}}}
+	l, waits := newTestLoader(t, srv, config.Config{ElasticsearchMaxRetries: 3})
+
+	err := l.LoadBatch(context.Background(), testEvents())
+	require.ErrorContains(t, err, "index doc-1: mapper_parsing_exception: failed to parse")
+	assert.Empty(t, *waits, "a rejected document fails the batch instead of being retried here")
+}
+
+func TestLoadBatch_ServerError(t *testing.T) {
+	srv := &bulkServer{responses: []bulkReply{{status: http.StatusServiceUnavailable}}}
+	l, _ := newTestLoader(t, srv, config.Config{})
+
+	err := l.LoadBatch(context.Background(), testEvents())
+	require.ErrorContains(t, err, "unexpected status 503")
+}
+
+func TestLoadBatch_DeleteNotFound(t *testing.T) {
+	srv := &bulkServer{responses: []bulkReply{{status: http.StatusOK, items: []int{201, 201, 404}}}}
+	l, _ := newTestLoader(t, srv, config.Config{})
+
+	require.NoError(t, l.LoadBatch(context.Background(), testEvents()), "retracting a missing document is complete")
+}
+
+func TestClose(t *testing.T) {
+	srv := &bulkServer{}
+	l, _ := newTestLoader(t, srv, config.Config{})
+
+	require.NoError(t, l.LoadBatch(context.Background(), testEvents()))
+	require.NoError(t, l.Close())
+	require.NoError(t, l.LoadBatch(context.Background(), testEvents()), "a closed loader dials again")
+	assert.Len(t, srv.requests, 2)
+}
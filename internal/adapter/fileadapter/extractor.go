@@ -0,0 +1,280 @@
+// Package fileadapter replays raw storm reports from local files so historical
+// dumps can be reprocessed without publishing them to Kafka first.
+package fileadapter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// dataExtensions are the file types picked up when the source path is a directory.
+var dataExtensions = []string{".json", ".jsonl", ".ndjson"}
+
+// reportDatePattern finds a YYMMDD report date in a file name, as in
+// storm_reports_240426_combined.json or 240426_rpts_hail.csv.
+var reportDatePattern = regexp.MustCompile(`(?:^|[^0-9])([0-9]{6})(?:[^0-9]|$)`)
+
+// Extractor reads raw reports from newline-delimited JSON files or JSON array
+// files (such as the mock combined fixture) and emits them as RawEvents.
+// Files are processed in lexical order, one at a time. Once every file has been
+// read, ExtractBatch returns empty batches until the context is cancelled.
+// It implements pipeline.BatchExtractor and is not safe for concurrent use.
+type Extractor struct {
+	files        []string
+	waitInterval time.Duration
+	logger       *slog.Logger
+
+	next      int // index of the next file to open
+	current   *fileSource
+	deferred  error // file error to report after the records read before it
+	exhausted bool
+	emitted   int64
+}
+
+// NewExtractor resolves FILE_SOURCE_PATH, which may be a single file, a
+// directory, or a glob pattern, and returns an extractor over the matches.
+func NewExtractor(cfg *config.Config, logger *slog.Logger) (*Extractor, error) {
+	files, err := resolveFiles(cfg.FileSourcePath)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("file source resolved", "path", cfg.FileSourcePath, "files", len(files))
+	return &Extractor{
+		files:        files,
+		waitInterval: cfg.BatchFlushInterval,
+		logger:       logger,
+	}, nil
+}
+
+// ExtractBatch returns up to batchSize records, moving on to the next file when
+// the current one is exhausted. A file that cannot be opened or parsed is
+// abandoned and the error returned once the records read before it have been
+// emitted; the following call resumes with the next file.
+// Events have no Commit callback since files carry no consumer offsets.
+func (e *Extractor) ExtractBatch(ctx context.Context, batchSize int) ([]domain.RawEvent, error) {
+	if err := e.deferred; err != nil {
+		e.deferred = nil
+		return nil, err
+	}
+
+	batch, err := e.fill(batchSize)
+	e.emitted += int64(len(batch))
+	if err != nil {
+		if len(batch) == 0 {
+			return nil, err
+		}
+		e.deferred = err
+	}
+	if len(batch) > 0 || e.current != nil || e.next < len(e.files) {
+		return batch, nil
+	}
+
+	if !e.exhausted {
+		e.exhausted = true
+		e.logger.Info("file source exhausted", "files", len(e.files), "records", e.emitted)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(e.waitInterval):
+	}
+	return nil, nil
+}
+
+// fill reads records across files until the batch is full or the files run out.
+func (e *Extractor) fill(batchSize int) ([]domain.RawEvent, error) {
+	batch := make([]domain.RawEvent, 0, batchSize)
+	for len(batch) < batchSize {
+		if e.current == nil {
+			if e.next >= len(e.files) {
+				break
+			}
+			path := e.files[e.next]
+			e.next++
+			src, err := openFileSource(path)
+			if err != nil {
+				return batch, err
+			}
+			e.current = src
+			e.logger.Info("reading file", "path", path)
+		}
+
+		value, err := e.current.read()
+		if errors.Is(err, io.EOF) {
+			e.closeCurrent()
+			continue
+		}
+		if err != nil {
+			path := e.current.path
+			e.closeCurrent()
+			return batch, fmt.Errorf("read %s: %w", path, err)
+		}
+		batch = append(batch, e.current.event(value))
+	}
+	return batch, nil
+}
+
+// Close releases the file currently being read, if any.
+func (e *Extractor) Close() error {
+	if e.current == nil {
+		return nil
+	}
+	err := e.current.file.Close()
+	e.current = nil
+	return err
+}
+
+func (e *Extractor) closeCurrent() {
+	if err := e.Close(); err != nil {
+		e.logger.Warn("close file failed", "error", err)
+	}
+}
+
+// fileSource streams records from one file. Files whose first non-space byte
+// is '[' are decoded as a JSON array; anything else is read as one record per line.
+type fileSource struct {
+	file   *os.File
+	path   string
+	date   time.Time
+	reader *bufio.Reader
+	array  *json.Decoder // non-nil for JSON array files
+	offset int64
+}
+
+func openFileSource(path string) (*fileSource, error) {
+	f, err := os.Open(path) //nolint:gosec // path comes from operator configuration
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	date, err := reportDate(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	src := &fileSource{file: f, path: path, date: date, reader: bufio.NewReader(f)}
+	first, err := peekNonSpace(src.reader)
+	if err != nil && !errors.Is(err, io.EOF) {
+		_ = f.Close()
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if first == '[' {
+		src.array = json.NewDecoder(src.reader)
+		if _, err := src.array.Token(); err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+	}
+	return src, nil
+}
+
+// read returns the next raw record, or io.EOF when the file is exhausted.
+// Lines are passed through unparsed so malformed records fail in the
+// transform step and reach the dead-letter topic like any other bad message.
+func (s *fileSource) read() ([]byte, error) {
+	if s.array != nil {
+		if !s.array.More() {
+			return nil, io.EOF
+		}
+		var msg json.RawMessage
+		if err := s.array.Decode(&msg); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	}
+
+	for {
+		line, err := s.reader.ReadBytes('\n')
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			return trimmed, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (s *fileSource) event(value []byte) domain.RawEvent {
+	s.offset++
+	return domain.RawEvent{
+		Value:     value,
+		Headers:   map[string]string{"source_file": filepath.Base(s.path)},
+		Offset:    s.offset,
+		Timestamp: s.date,
+	}
+}
+
+// reportDate determines the date that HHMM report times are anchored to: a
+// YYMMDD date in the file name, or else the file's modification date.
+func reportDate(f *os.File) (time.Time, error) {
+	if m := reportDatePattern.FindStringSubmatch(filepath.Base(f.Name())); m != nil {
+		if t, err := time.Parse("060102", m[1]); err == nil {
+			return t, nil
+		}
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("stat %s: %w", f.Name(), err)
+	}
+	return info.ModTime().UTC().Truncate(24 * time.Hour), nil
+}
+
+// peekNonSpace returns the first non-whitespace byte without consuming it.
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, r.UnreadByte()
+	}
+}
+
+// resolveFiles expands a file, directory, or glob pattern into a sorted list
+// of files. Directories contribute their .json, .jsonl, and .ndjson files.
+func resolveFiles(path string) ([]string, error) {
+	var files []string
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("read dir %s: %w", path, err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && slices.Contains(dataExtensions, filepath.Ext(entry.Name())) {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	} else {
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FILE_SOURCE_PATH %q: %w", path, err)
+		}
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && !info.IsDir() {
+				files = append(files, m)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files match FILE_SOURCE_PATH %q", path)
+	}
+	slices.Sort(files)
+	return files, nil
+}
@@ -0,0 +1,130 @@
+package fileadapter
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestExtractor(t *testing.T, path string) *Extractor {
+	t.Helper()
+	cfg := &config.Config{FileSourcePath: path, BatchFlushInterval: time.Millisecond}
+	e, err := NewExtractor(cfg, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = e.Close() })
+	return e
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestExtractBatch_MockFixture(t *testing.T) {
+	path := filepath.Join("..", "..", "..", "data", "mock", "storm_reports_240426_combined.json")
+	var fixture []domain.RawCSVRecord
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &fixture))
+
+	e := newTestExtractor(t, path)
+
+	var all []domain.RawEvent
+	for {
+		batch, err := e.ExtractBatch(context.Background(), 50)
+		require.NoError(t, err)
+		if len(batch) == 0 {
+			break
+		}
+		all = append(all, batch...)
+	}
+	require.Len(t, all, len(fixture))
+
+	first := all[0]
+	assert.Equal(t, int64(1), first.Offset)
+	assert.Equal(t, time.Date(2024, time.April, 26, 0, 0, 0, 0, time.UTC), first.Timestamp)
+	assert.Equal(t, "storm_reports_240426_combined.json", first.Headers["source_file"])
+
+	event, err := domain.ParseRawEvent(first)
+	require.NoError(t, err)
+	assert.Equal(t, fixture[0].EventType, event.EventType)
+	assert.Equal(t, time.Date(2024, time.April, 26, 15, 10, 0, 0, time.UTC), event.EventTime)
+}
+
+func TestExtractBatch_NDJSONAcrossFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a_240426.jsonl", `{"Time":"1510","EventType":"hail"}`+"\n\n"+`{"Time":"1522","EventType":"hail"}`+"\n")
+	writeFile(t, dir, "b_240427.ndjson", `{"Time":"0100","EventType":"wind"}`)
+	writeFile(t, dir, "notes.txt", "ignored")
+
+	e := newTestExtractor(t, dir)
+
+	batch, err := e.ExtractBatch(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, batch, 3)
+	assert.JSONEq(t, `{"Time":"1522","EventType":"hail"}`, string(batch[1].Value))
+	assert.Equal(t, "b_240427.ndjson", batch[2].Headers["source_file"])
+	assert.Equal(t, int64(1), batch[2].Offset)
+	assert.Equal(t, time.Date(2024, time.April, 27, 0, 0, 0, 0, time.UTC), batch[2].Timestamp)
+}
+
+func TestExtractBatch_Glob(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "one.jsonl", "{}\n{}\n")
+	writeFile(t, dir, "two.jsonl", "{}\n")
+	writeFile(t, dir, "three.json", "[{}]")
+
+	e := newTestExtractor(t, filepath.Join(dir, "*.jsonl"))
+
+	batch, err := e.ExtractBatch(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Len(t, batch, 2)
+
+	batch, err = e.ExtractBatch(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Len(t, batch, 1)
+
+	batch, err = e.ExtractBatch(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Empty(t, batch)
+}
+
+func TestExtractBatch_MalformedArraySkipsFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.json", `[{"Time":"1510"}, {oops}]`)
+	writeFile(t, dir, "b.jsonl", "{}\n")
+
+	e := newTestExtractor(t, dir)
+
+	// Records read before the syntax error are emitted first; the error follows.
+	batch, err := e.ExtractBatch(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Len(t, batch, 1)
+
+	_, err = e.ExtractBatch(context.Background(), 10)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a.json")
+
+	batch, err = e.ExtractBatch(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	assert.Equal(t, "b.jsonl", batch[0].Headers["source_file"])
+}
+
+func TestNewExtractor_NoMatches(t *testing.T) {
+	cfg := &config.Config{FileSourcePath: filepath.Join(t.TempDir(), "*.jsonl")}
+	_, err := NewExtractor(cfg, slog.New(slog.DiscardHandler))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no files match")
+}
@@ -0,0 +1,129 @@
+package grpcadapter
+
+import (
+	"fmt"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/stormpb"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// Field numbers from proto/storm/v1/event_stream.proto. Keep in sync with the schema.
+const (
+	pbRequestEventTypes  protowire.Number = 1
+	pbRequestMinSeverity protowire.Number = 2
+	pbRequestStates      protowire.Number = 3
+
+	pbResponseEvent   protowire.Number = 1
+	pbResponseDeleted protowire.Number = 2
+)
+
+// streamEventsRequest is storm.v1.StreamEventsRequest.
+type streamEventsRequest struct {
+	eventTypes  []string
+	minSeverity string
+	states      []string
+}
+
+// unmarshal decodes the request, skipping unknown fields as protobuf
+// requires so older servers accept newer clients.
+func (r *streamEventsRequest) unmarshal(b []byte) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ == protowire.BytesType && (num == pbRequestEventTypes || num == pbRequestMinSeverity || num == pbRequestStates) {
+			v, m := protowire.ConsumeString(b)
+			if m < 0 {
+				return protowire.ParseError(m)
+			}
+			switch num {
+			case pbRequestEventTypes:
+				r.eventTypes = append(r.eventTypes, v)
+			case pbRequestMinSeverity:
+				r.minSeverity = v
+			case pbRequestStates:
+				r.states = append(r.states, v)
+			}
+			b = b[m:]
+			continue
+		}
+		m := protowire.ConsumeFieldValue(num, typ, b)
+		if m < 0 {
+			return protowire.ParseError(m)
+		}
+		b = b[m:]
+	}
+	return nil
+}
+
+// filter converts the request into an event filter, canonicalizing event
+// types and severity.
+func (r *streamEventsRequest) filter() (domain.EventFilter, error) {
+	f := domain.EventFilter{States: r.states}
+	for _, name := range r.eventTypes {
+		canonical := domain.CanonicalEventType(name)
+		if canonical == "" {
+			return domain.EventFilter{}, fmt.Errorf("unknown event type %q", name)
+		}
+		f.EventTypes = append(f.EventTypes, canonical)
+	}
+	if r.minSeverity != "" {
+		level, err := domain.ParseSeverityLevel(r.minSeverity)
+		if err != nil {
+			return domain.EventFilter{}, err
+		}
+		f.MinSeverity = level
+	}
+	return f, nil
+}
+
+// streamEventsResponse is storm.v1.StreamEventsResponse.
+type streamEventsResponse struct {
+	event domain.StormEvent
+}
+
+func (r *streamEventsResponse) marshal() []byte {
+	var b []byte
+	if ev := stormpb.MarshalStormEvent(r.event); len(ev) > 0 {
+		b = protowire.AppendTag(b, pbResponseEvent, protowire.BytesType)
+		b = protowire.AppendBytes(b, ev)
+	}
+	if r.event.Deleted {
+		b = protowire.AppendTag(b, pbResponseDeleted, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	return b
+}
+
+// codec encodes the hand-written stream messages and defers to the standard
+// protobuf codec for generated ones, such as the health service's. It
+// replaces the server's default "proto" codec, so it keeps that name.
+type codec struct{}
+
+func (codec) Name() string { return "proto" }
+
+func (codec) Marshal(v any) ([]byte, error) {
+	switch m := v.(type) {
+	case *streamEventsResponse:
+		return m.marshal(), nil
+	case proto.Message:
+		return proto.Marshal(m)
+	default:
+		return nil, fmt.Errorf("grpc codec: cannot marshal %T", v)
+	}
+}
+
+func (codec) Unmarshal(data []byte, v any) error {
+	switch m := v.(type) {
+	case *streamEventsRequest:
+		return m.unmarshal(data)
+	case proto.Message:
+		return proto.Unmarshal(data, m)
+	default:
+		return fmt.Errorf("grpc codec: cannot unmarshal into %T", v)
+	}
+}
@@ -0,0 +1,145 @@
+// Package grpcadapter serves the storm.v1.EventStreamService gRPC API, which
+// streams transformed events live to internal tools that do not consume Kafka.
+// Messages are encoded by hand against protowire, like the Kafka protobuf
+// output, so the service needs no generated code; clients generate theirs
+// from proto/storm/v1/event_stream.proto.
+package grpcadapter
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"sync"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// EventSource delivers loaded events that match a filter until cancelled.
+// *pipeline.Broadcaster implements it.
+type EventSource interface {
+	Subscribe(filter domain.EventFilter) (events <-chan domain.StormEvent, cancel func())
+}
+
+// eventStreamServer is the handler type of serviceDesc.
+type eventStreamServer interface {
+	streamEvents(req *streamEventsRequest, stream grpc.ServerStream) error
+}
+
+// serviceDesc describes storm.v1.EventStreamService as protoc-gen-go-grpc
+// would generate it.
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: "storm.v1.EventStreamService",
+	HandlerType: (*eventStreamServer)(nil),
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "StreamEvents",
+		ServerStreams: true,
+		Handler: func(srv any, stream grpc.ServerStream) error {
+			req := new(streamEventsRequest)
+			if err := stream.RecvMsg(req); err != nil {
+				return err
+			}
+			return srv.(eventStreamServer).streamEvents(req, stream)
+		},
+	}},
+	Metadata: "storm/v1/event_stream.proto",
+}
+
+// Server serves the event stream and the standard gRPC health service.
+type Server struct {
+	addr     string
+	grpc     *grpc.Server
+	health   *health.Server
+	source   EventSource
+	logger   *slog.Logger
+	stopping chan struct{}
+	stopOnce sync.Once
+}
+
+// NewServer creates a gRPC server that will listen on addr and stream events
+// from source.
+func NewServer(addr string, source EventSource, logger *slog.Logger) *Server {
+	s := &Server{
+		addr:     addr,
+		grpc:     grpc.NewServer(grpc.ForceServerCodec(codec{})),
+		health:   health.NewServer(),
+		source:   source,
+		logger:   logger,
+		stopping: make(chan struct{}),
+	}
+	s.grpc.RegisterService(&serviceDesc, s)
+	healthpb.RegisterHealthServer(s.grpc, s.health)
+	s.health.SetServingStatus(serviceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	return s
+}
+
+// Start listens on the configured address and serves until Shutdown. It
+// returns nil after a graceful shutdown.
+func (s *Server) Start() error {
+	lis, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(lis)
+}
+
+// Serve serves on an existing listener, useful for testing.
+func (s *Server) Serve(lis net.Listener) error {
+	return s.grpc.Serve(lis)
+}
+
+// Shutdown ends open streams with codes.Unavailable, so clients reconnect to
+// another instance, and waits for them to finish within the context deadline
+// before closing connections forcibly.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		s.health.Shutdown()
+		close(s.stopping)
+	})
+	done := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.grpc.Stop()
+		return ctx.Err()
+	}
+}
+
+// streamEvents sends matching events until the client cancels or the server
+// shuts down.
+func (s *Server) streamEvents(req *streamEventsRequest, stream grpc.ServerStream) error {
+	filter, err := req.filter()
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	events, cancel := s.source.Subscribe(filter)
+	defer cancel()
+	s.logger.Debug("grpc event stream opened", "filter", filter)
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Debug("grpc event stream closed by client")
+			return nil
+		case <-s.stopping:
+			return status.Error(codes.Unavailable, "server shutting down")
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.Unavailable, "event stream closed")
+			}
+			if err := stream.SendMsg(&streamEventsResponse{event: event}); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package grpcadapter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// rawCodec lets the test client send and receive encoded bytes, so messages
+// are checked against the wire format rather than the server's own types.
+type rawCodec struct{}
+
+func (rawCodec) Name() string { return "proto" }
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("rawCodec: cannot marshal %T", v)
+	}
+	return b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	p, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("rawCodec: cannot unmarshal into %T", v)
+	}
+	*p = append([]byte(nil), data...)
+	return nil
+}
+
+func startServer(t *testing.T, source EventSource) (*Server, *grpc.ClientConn) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer("", source, slog.New(slog.DiscardHandler))
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return srv, conn
+}
+
+// openStream calls StreamEvents with an encoded request.
+func openStream(ctx context.Context, t *testing.T, conn *grpc.ClientConn, req []byte) grpc.ClientStream {
+	t.Helper()
+	stream, err := conn.NewStream(ctx, &serviceDesc.Streams[0], "/storm.v1.EventStreamService/StreamEvents", grpc.ForceCodec(rawCodec{}))
+	require.NoError(t, err)
+	require.NoError(t, stream.SendMsg(req))
+	require.NoError(t, stream.CloseSend())
+	return stream
+}
+
+func encodeRequest(eventTypes []string, minSeverity string, states []string) []byte {
+	var b []byte
+	for _, v := range eventTypes {
+		b = protowire.AppendTag(b, pbRequestEventTypes, protowire.BytesType)
+		b = protowire.AppendString(b, v)
+	}
+	if minSeverity != "" {
+		b = protowire.AppendTag(b, pbRequestMinSeverity, protowire.BytesType)
+		b = protowire.AppendString(b, minSeverity)
+	}
+	for _, v := range states {
+		b = protowire.AppendTag(b, pbRequestStates, protowire.BytesType)
+		b = protowire.AppendString(b, v)
+	}
+	// An unknown field from a newer client must be skipped.
+	b = protowire.AppendTag(b, 99, protowire.VarintType)
+	return protowire.AppendVarint(b, 7)
+}
+
+// decodeResponse returns the event ID, event type, and deleted flag of an
+// encoded StreamEventsResponse.
+func decodeResponse(t *testing.T, b []byte) (id, eventType string, deleted bool) {
+	t.Helper()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.Positive(t, n)
+		b = b[n:]
+		switch {
+		case num == pbResponseEvent && typ == protowire.BytesType:
+			event, m := protowire.ConsumeBytes(b)
+			require.Positive(t, m)
+			b = b[m:]
+			for len(event) > 0 {
+				fnum, ftyp, k := protowire.ConsumeTag(event)
+				require.Positive(t, k)
+				event = event[k:]
+				k = protowire.ConsumeFieldValue(fnum, ftyp, event)
+				require.Positive(t, k)
+				if ftyp == protowire.BytesType && (fnum == 1 || fnum == 2) {
+					v, _ := protowire.ConsumeString(event)
+					if fnum == 1 {
+						id = v
+					} else {
+						eventType = v
+					}
+				}
+				event = event[k:]
+			}
+		case num == pbResponseDeleted:
+			v, m := protowire.ConsumeVarint(b)
+			require.Positive(t, m)
+			deleted = v == 1
+			b = b[m:]
+		default:
+			t.Fatalf("unexpected field %d", num)
+		}
+	}
+	return id, eventType, deleted
+}
+
+func waitForSubscribers(t *testing.T, metrics *observability.Metrics, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.StreamSubscribers) == float64(n)
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestStreamEvents_FiltersAndStreams(t *testing.T) {
+	metrics := observability.NewMetricsForTesting()
+	broadcaster := pipeline.NewBroadcaster(16, metrics)
+	_, conn := startServer(t, broadcaster)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream := openStream(ctx, t, conn, encodeRequest([]string{"flash flood"}, "", []string{"ok"}))
+	waitForSubscribers(t, metrics, 1)
+
+	severe := "severe"
+	broadcaster.Publish([]domain.StormEvent{
+		{ID: "hail-1", EventType: "hail", Location: domain.Location{State: "OK"}},
+		{ID: "flood-tx", EventType: "flash_flood", Location: domain.Location{State: "TX"}},
+		{ID: "flood-1", EventType: "flash_flood", Location: domain.Location{State: "OK"}, Measurement: domain.Measurement{Severity: &severe}},
+		{ID: "flood-2", EventType: "flash_flood", Location: domain.Location{State: "OK"}, Deleted: true},
+	})
+
+	var msg []byte
+	require.NoError(t, stream.RecvMsg(&msg))
+	id, eventType, deleted := decodeResponse(t, msg)
+	assert.Equal(t, "flood-1", id)
+	assert.Equal(t, "flash_flood", eventType)
+	assert.False(t, deleted)
+
+	require.NoError(t, stream.RecvMsg(&msg))
+	id, _, deleted = decodeResponse(t, msg)
+	assert.Equal(t, "flood-2", id)
+	assert.True(t, deleted)
+
+	cancel()
+	waitForSubscribers(t, metrics, 0)
+}
+
+func TestStreamEvents_InvalidFilter(t *testing.T) {
+	_, conn := startServer(t, pipeline.NewBroadcaster(1, observability.NewMetricsForTesting()))
+
+	for name, req := range map[string][]byte{
+		"event type": encodeRequest([]string{"meteor"}, "", nil),
+		"severity":   encodeRequest(nil, "catastrophic", nil),
+	} {
+		t.Run(name, func(t *testing.T) {
+			stream := openStream(context.Background(), t, conn, req)
+			var msg []byte
+			err := stream.RecvMsg(&msg)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
+
+func TestShutdown_EndsStreams(t *testing.T) {
+	metrics := observability.NewMetricsForTesting()
+	srv, conn := startServer(t, pipeline.NewBroadcaster(1, metrics))
+
+	stream := openStream(context.Background(), t, conn, encodeRequest(nil, "", nil))
+	waitForSubscribers(t, metrics, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, srv.Shutdown(ctx), "open streams do not hold up a graceful stop")
+
+	var msg []byte
+	assert.Equal(t, codes.Unavailable, status.Code(stream.RecvMsg(&msg)))
+}
+
+func TestHealth(t *testing.T) {
+	_, conn := startServer(t, pipeline.NewBroadcaster(1, observability.NewMetricsForTesting()))
+
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: "storm.v1.EventStreamService"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+}
@@ -0,0 +1,128 @@
+package httpadapter
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"slices"
+
+	sharedobs "github.com/couchcryptid/storm-data-shared/observability"
+)
+
+// Admin actions, each granting a group of admin endpoints. They match the
+// ADMIN_PERMISSIONS action names.
+const (
+	ActionRead    = "read"    // GET /admin/status, /admin/progress, /admin/dlq
+	ActionPause   = "pause"   // POST /admin/pause, /admin/resume
+	ActionReload  = "reload"  // POST /admin/reload
+	ActionRequeue = "requeue" // POST /admin/dlq/requeue
+	ActionDebug   = "debug"   // /debug/pprof/
+)
+
+// tokenPrincipal is the principal of the token passed to the route options.
+const tokenPrincipal = "admin"
+
+// AdminAuth authenticates admin requests beyond the single admin token and
+// authorizes them per action.
+type AdminAuth struct {
+	// Tokens maps principal names to bearer tokens.
+	Tokens map[string]string
+	// ClientCerts authenticates requests that present a client certificate
+	// verified by the server's TLS configuration, using the certificate's
+	// subject common name as the principal.
+	ClientCerts bool
+	// Permissions lists the principals allowed each action; "*" allows every
+	// authenticated principal. An action without an entry is allowed to every
+	// authenticated principal.
+	Permissions map[string][]string
+}
+
+// WithAdminAuth adds principals and per-action permissions to the admin
+// routes. Routes registered with an empty token are served when auth has a
+// principal. /healthz, /readyz, and /metrics stay unauthenticated.
+func WithAdminAuth(auth AdminAuth) Option {
+	return func(s *Server, _ *http.ServeMux) {
+		s.auth = &auth
+	}
+}
+
+type principalKey struct{}
+
+// principal returns the authenticated admin principal of a request.
+func principal(ctx context.Context) string {
+	p, _ := ctx.Value(principalKey{}).(string)
+	return p
+}
+
+// adminEnabled reports whether the admin routes registered with token are
+// served. It is evaluated per request, so WithAdminAuth may follow the route
+// options.
+func (s *Server) adminEnabled(token string) bool {
+	return token != "" || (s.auth != nil && (len(s.auth.Tokens) > 0 || s.auth.ClientCerts))
+}
+
+// requireAdmin serves next to principals allowed action. Requests without
+// valid credentials get 401 and principals without the permission get 403;
+// when no admin principal is configured the route responds 404.
+func (s *Server) requireAdmin(action, token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.adminEnabled(token) {
+			http.NotFound(w, r)
+			return
+		}
+		name, ok := s.authenticate(r, token)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			sharedobs.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		if !s.authorized(name, action) {
+			s.logger.Warn("admin request forbidden", "path", r.URL.Path, "principal", name, "action", action, "remote_addr", r.RemoteAddr)
+			sharedobs.WriteJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, name)))
+	})
+}
+
+// authenticate returns the principal of the request's bearer token or, when
+// enabled, its verified client certificate. Tokens are compared in constant
+// time, and every token is compared so timing does not reveal which matched.
+func (s *Server) authenticate(r *http.Request, token string) (string, bool) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		got := []byte(header)
+		var name string
+		match := func(principal, token string) {
+			if subtle.ConstantTimeCompare(got, []byte("Bearer "+token)) == 1 {
+				name = principal
+			}
+		}
+		if token != "" {
+			match(tokenPrincipal, token)
+		}
+		if s.auth != nil {
+			for principal, token := range s.auth.Tokens {
+				match(principal, token)
+			}
+		}
+		return name, name != ""
+	}
+	if s.auth != nil && s.auth.ClientCerts && r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		if cn := r.TLS.VerifiedChains[0][0].Subject.CommonName; cn != "" {
+			return cn, true
+		}
+	}
+	return "", false
+}
+
+// authorized reports whether principal may perform action.
+func (s *Server) authorized(principal, action string) bool {
+	if s.auth == nil {
+		return true
+	}
+	allowed, ok := s.auth.Permissions[action]
+	if !ok {
+		return true
+	}
+	return slices.Contains(allowed, "*") || slices.Contains(allowed, principal)
+}
@@ -0,0 +1,122 @@
+package httpadapter_test
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/httpadapter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminAuth_NamedTokensAndPermissions(t *testing.T) {
+	ctrl := &mockController{}
+	srv := httpadapter.NewServer(":0", &mockReadiness{}, slog.Default(),
+		httpadapter.WithAdmin(ctrl, "s3cret"),
+		httpadapter.WithAdminAuth(httpadapter.AdminAuth{
+			Tokens: map[string]string{"oncall": "0nc4ll", "dashboard": "d4sh"},
+			Permissions: map[string][]string{
+				httpadapter.ActionRead:  {"*"},
+				httpadapter.ActionPause: {"admin", "oncall"},
+			},
+		}),
+	)
+
+	code, status := adminRequest(t, srv, http.MethodGet, "/admin/status", "d4sh")
+	assert.Equal(t, http.StatusOK, code, "* allows every principal")
+	assert.Equal(t, "running", status)
+
+	code, _ = adminRequest(t, srv, http.MethodPost, "/admin/pause", "d4sh")
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.False(t, ctrl.paused)
+
+	code, status = adminRequest(t, srv, http.MethodPost, "/admin/pause", "0nc4ll")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "paused", status)
+
+	code, status = adminRequest(t, srv, http.MethodPost, "/admin/resume", "s3cret")
+	assert.Equal(t, http.StatusOK, code, "ADMIN_TOKEN authenticates as admin")
+	assert.Equal(t, "running", status)
+
+	code, _ = adminRequest(t, srv, http.MethodPost, "/admin/pause", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestAdminAuth_ActionWithoutPermissionsIsOpen(t *testing.T) {
+	srv := httpadapter.NewServer(":0", &mockReadiness{}, slog.Default(),
+		httpadapter.WithAdmin(&mockController{}, ""),
+		httpadapter.WithPprof(""),
+		httpadapter.WithAdminAuth(httpadapter.AdminAuth{
+			Tokens:      map[string]string{"oncall": "0nc4ll"},
+			Permissions: map[string][]string{httpadapter.ActionRead: {"dashboard"}},
+		}),
+	)
+
+	code, _ := adminRequest(t, srv, http.MethodPost, "/admin/pause", "0nc4ll")
+	assert.Equal(t, http.StatusOK, code, "named tokens enable routes registered without ADMIN_TOKEN")
+	code, _ = adminRequest(t, srv, http.MethodGet, "/admin/status", "0nc4ll")
+	assert.Equal(t, http.StatusForbidden, code)
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/cmdline", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "pprof requires a principal once one is configured")
+
+	for _, path := range []string{"/healthz", "/readyz", "/metrics"} {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+	}
+}
+
+func TestAdminAuth_ClientCertificates(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, "storm-data-etl", 2)
+	tlsCfg, err := httpadapter.NewTLSConfig(httpadapter.TLSOptions{
+		CertFile:     writeFile(t, dir, "tls.crt", certPEM),
+		KeyFile:      writeFile(t, dir, "tls.key", keyPEM),
+		ClientCAFile: writeFile(t, dir, "ca.crt", ca.pem),
+	})
+	require.NoError(t, err)
+
+	ctrl := &mockController{}
+	srv := httpadapter.NewServer(":0", &mockReadiness{}, slog.Default(),
+		httpadapter.WithTLS(tlsCfg),
+		httpadapter.WithAdmin(ctrl, ""),
+		httpadapter.WithAdminAuth(httpadapter.AdminAuth{
+			ClientCerts: true,
+			Permissions: map[string][]string{httpadapter.ActionPause: {"ops"}},
+		}),
+	)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	hs := &http.Server{Handler: srv, ReadHeaderTimeout: time.Second}
+	go func() { _ = hs.Serve(tls.NewListener(lis, tlsCfg)) }()
+	t.Cleanup(func() { _ = hs.Close() })
+	url := "https://" + lis.Addr().String()
+
+	pause := func(client *http.Client) int {
+		t.Helper()
+		resp, err := client.Post(url+"/admin/pause", "application/json", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	clientCert := func(commonName string, serial int64) *tls.Certificate {
+		certPEM, keyPEM := ca.issue(t, commonName, serial)
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		require.NoError(t, err)
+		return &cert
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, pause(tlsClient(ca, nil)))
+	assert.Equal(t, http.StatusForbidden, pause(tlsClient(ca, clientCert("prometheus", 3))))
+	assert.False(t, ctrl.paused)
+	assert.Equal(t, http.StatusOK, pause(tlsClient(ca, clientCert("ops", 4))))
+	assert.True(t, ctrl.paused)
+}
@@ -2,22 +2,219 @@ package httpadapter
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/http/pprof"
+	"strconv"
 	"time"
 
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
 	sharedobs "github.com/couchcryptid/storm-data-shared/observability"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// PipelineController pauses and resumes batch extraction.
+type PipelineController interface {
+	Pause()
+	Resume()
+	Paused() bool
+}
+
+// ConfigReloader re-reads and applies the reloadable configuration subset.
+type ConfigReloader interface {
+	Reload(ctx context.Context) error
+}
+
+// DeadLetterQueue lists and requeues messages on the dead-letter topic.
+type DeadLetterQueue interface {
+	Recent(ctx context.Context, limit int) ([]domain.DeadLetterRecord, error)
+	Requeue(ctx context.Context, positions []domain.DeadLetterPosition) (int, error)
+}
+
+// ProgressReporter reports how far the pipeline has processed each source
+// partition.
+type ProgressReporter interface {
+	Progress() []domain.PartitionProgress
+}
+
+// HealthReporter checks every pipeline component and reports each result.
+type HealthReporter interface {
+	Health(ctx context.Context) domain.HealthReport
+}
+
+// Limits for the dead-letter admin endpoints.
+const (
+	defaultDLQLimit = 50
+	maxDLQLimit     = 500
+)
+
+// Option configures optional Server routes.
+type Option func(*Server, *http.ServeMux)
+
+// WithAdmin registers POST /admin/pause, POST /admin/resume, and
+// GET /admin/status, authenticated with a bearer token or the principals of
+// WithAdminAuth. The routes respond 404 when neither is configured.
+func WithAdmin(ctrl PipelineController, token string) Option {
+	return func(s *Server, mux *http.ServeMux) {
+		mux.Handle("POST /admin/pause", s.requireAdmin(ActionPause, token, s.adminHandler(ctrl, ctrl.Pause)))
+		mux.Handle("POST /admin/resume", s.requireAdmin(ActionPause, token, s.adminHandler(ctrl, ctrl.Resume)))
+		mux.Handle("GET /admin/status", s.requireAdmin(ActionRead, token, s.adminHandler(ctrl, nil)))
+	}
+}
+
+// WithReload registers POST /admin/reload, authenticated like WithAdmin.
+// A failed reload leaves the running configuration unchanged and responds
+// 422 with the error.
+func WithReload(r ConfigReloader, token string) Option {
+	return func(s *Server, mux *http.ServeMux) {
+		mux.Handle("POST /admin/reload", s.requireAdmin(ActionReload, token, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			s.logger.Info("admin request", "path", req.URL.Path, "principal", principal(req.Context()), "remote_addr", req.RemoteAddr)
+			if err := r.Reload(req.Context()); err != nil {
+				sharedobs.WriteJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+				return
+			}
+			sharedobs.WriteJSON(w, http.StatusOK, map[string]string{"status": "reloaded"})
+		})))
+	}
+}
+
+// WithDeadLetters registers GET /admin/dlq and POST /admin/dlq/requeue,
+// authenticated like WithAdmin. GET returns the newest dead letters
+// (?limit=, default 50, at most 500); POST takes
+// {"messages":[{"partition":0,"offset":12}]} and republishes those messages to
+// their source topic.
+func WithDeadLetters(q DeadLetterQueue, token string) Option {
+	return func(s *Server, mux *http.ServeMux) {
+		mux.Handle("GET /admin/dlq", s.requireAdmin(ActionRead, token, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			limit := defaultDLQLimit
+			if v := req.URL.Query().Get("limit"); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 1 || n > maxDLQLimit {
+					sharedobs.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("limit must be 1-%d", maxDLQLimit)})
+					return
+				}
+				limit = n
+			}
+			records, err := q.Recent(req.Context(), limit)
+			if err != nil {
+				s.logger.Error("list dead letters", "error", err)
+				sharedobs.WriteJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+				return
+			}
+			if records == nil {
+				records = []domain.DeadLetterRecord{}
+			}
+			sharedobs.WriteJSON(w, http.StatusOK, map[string]any{"messages": records})
+		})))
+		mux.Handle("POST /admin/dlq/requeue", s.requireAdmin(ActionRequeue, token, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			s.logger.Info("admin request", "path", req.URL.Path, "principal", principal(req.Context()), "remote_addr", req.RemoteAddr)
+			var body struct {
+				Messages []domain.DeadLetterPosition `json:"messages"`
+			}
+			if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, 1<<20)).Decode(&body); err != nil {
+				sharedobs.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body: " + err.Error()})
+				return
+			}
+			if len(body.Messages) == 0 || len(body.Messages) > maxDLQLimit {
+				sharedobs.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("messages must list 1-%d positions", maxDLQLimit)})
+				return
+			}
+			n, err := q.Requeue(req.Context(), body.Messages)
+			if err != nil {
+				s.logger.Error("requeue dead letters", "error", err)
+				sharedobs.WriteJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+				return
+			}
+			sharedobs.WriteJSON(w, http.StatusOK, map[string]int{"requeued": n})
+		})))
+	}
+}
+
+// WithProgress registers GET /admin/progress, authenticated like WithAdmin.
+// It responds {"partitions":[...]} with the last committed offset, last
+// event time, and loaded and dead-lettered counts per source partition.
+func WithProgress(r ProgressReporter, token string) Option {
+	return func(s *Server, mux *http.ServeMux) {
+		mux.Handle("GET /admin/progress", s.requireAdmin(ActionRead, token, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			sharedobs.WriteJSON(w, http.StatusOK, map[string]any{"partitions": r.Progress()})
+		})))
+	}
+}
+
+// WithHealthDetail registers GET /healthz/detail, which reports the status of
+// each component, the loader circuit breaker, pause state, and the time of the
+// last completed batch. It responds 200 when the pipeline is ready and 503
+// otherwise, like /readyz, and needs no token.
+func WithHealthDetail(r HealthReporter) Option {
+	return func(_ *Server, mux *http.ServeMux) {
+		mux.HandleFunc("GET /healthz/detail", func(w http.ResponseWriter, req *http.Request) {
+			ctx, cancel := context.WithTimeout(req.Context(), 2*time.Second)
+			defer cancel()
+			report := r.Health(ctx)
+			status := http.StatusOK
+			if !report.Ready {
+				status = http.StatusServiceUnavailable
+			}
+			sharedobs.WriteJSON(w, status, report)
+		})
+	}
+}
+
+// WithSchema registers GET /schema, which serves the JSON Schema of the
+// events written to the sinks so consumers can validate them. It needs no
+// token.
+func WithSchema(schema []byte) Option {
+	return func(_ *Server, mux *http.ServeMux) {
+		mux.HandleFunc("GET /schema", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/schema+json")
+			_, _ = w.Write(schema)
+		})
+	}
+}
+
+// WithPprof registers the net/http/pprof handlers under /debug/pprof/. When
+// an admin principal is configured they are authenticated like WithAdmin;
+// otherwise they are open. Profile and trace requests may outlive the
+// server's write timeout, so the deadline is lifted for /debug/pprof/
+// requests.
+func WithPprof(token string) Option {
+	return func(s *Server, mux *http.ServeMux) {
+		handle := func(pattern string, h http.HandlerFunc) {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+				h(w, r)
+			})
+			authenticated := s.requireAdmin(ActionDebug, token, handler)
+			mux.Handle(pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !s.adminEnabled(token) {
+					handler(w, r)
+					return
+				}
+				authenticated.ServeHTTP(w, r)
+			}))
+		}
+		handle("GET /debug/pprof/", pprof.Index)
+		handle("GET /debug/pprof/cmdline", pprof.Cmdline)
+		handle("GET /debug/pprof/profile", pprof.Profile)
+		handle("GET /debug/pprof/symbol", pprof.Symbol)
+		handle("POST /debug/pprof/symbol", pprof.Symbol)
+		handle("GET /debug/pprof/trace", pprof.Trace)
+	}
+}
+
 // Server exposes health, readiness, and metrics HTTP endpoints.
 type Server struct {
 	httpServer *http.Server
 	logger     *slog.Logger
+	auth       *AdminAuth
 }
 
 // NewServer creates an HTTP server with /healthz, /readyz, and /metrics routes.
-func NewServer(addr string, ready sharedobs.ReadinessChecker, logger *slog.Logger) *Server {
+// Readiness is reported by ready; if it also has a Processed() bool method,
+// /readyz includes it as messages_processed.
+func NewServer(addr string, ready sharedobs.ReadinessChecker, logger *slog.Logger, opts ...Option) *Server {
 	mux := http.NewServeMux()
 
 	s := &Server{
@@ -32,15 +229,62 @@ func NewServer(addr string, ready sharedobs.ReadinessChecker, logger *slog.Logge
 	}
 
 	mux.HandleFunc("GET /healthz", sharedobs.LivenessHandler())
-	mux.HandleFunc("GET /readyz", sharedobs.ReadinessHandler(ready))
+	mux.HandleFunc("GET /readyz", readinessHandler(ready))
 	mux.Handle("GET /metrics", promhttp.Handler())
 
+	for _, opt := range opts {
+		opt(s, mux)
+	}
+
 	return s
 }
 
-// Start begins listening. Returns http.ErrServerClosed on graceful shutdown.
+// readinessHandler responds like the shared readiness handler and, when the
+// checker reports it, adds whether any message has been processed yet. That
+// signal is informational and does not affect the status code.
+func readinessHandler(checker sharedobs.ReadinessChecker) http.HandlerFunc {
+	progress, _ := checker.(interface{ Processed() bool })
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+
+		body := map[string]any{"status": "ready"}
+		if progress != nil {
+			body["messages_processed"] = progress.Processed()
+		}
+		status := http.StatusOK
+		if err := checker.CheckReadiness(ctx); err != nil {
+			status = http.StatusServiceUnavailable
+			body["status"] = "not ready"
+			body["error"] = err.Error()
+		}
+		sharedobs.WriteJSON(w, status, body)
+	}
+}
+
+// adminHandler applies action (if any) and reports the resulting pipeline state.
+func (s *Server) adminHandler(ctrl PipelineController, action func()) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if action != nil {
+			action()
+			s.logger.Info("admin request", "path", r.URL.Path, "principal", principal(r.Context()), "remote_addr", r.RemoteAddr)
+		}
+		status := "running"
+		if ctrl.Paused() {
+			status = "paused"
+		}
+		sharedobs.WriteJSON(w, http.StatusOK, map[string]string{"status": status})
+	})
+}
+
+// Start begins listening, over HTTPS when configured with WithTLS. Returns
+// http.ErrServerClosed on graceful shutdown.
 func (s *Server) Start() error {
-	s.logger.Info("http server starting", "addr", s.httpServer.Addr)
+	tlsEnabled := s.httpServer.TLSConfig != nil
+	s.logger.Info("http server starting", "addr", s.httpServer.Addr, "tls", tlsEnabled)
+	if tlsEnabled {
+		return s.httpServer.ListenAndServeTLS("", "")
+	}
 	return s.httpServer.ListenAndServe()
 }
 
@@ -3,13 +3,18 @@ package httpadapter_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/couchcryptid/storm-data-etl/internal/adapter/httpadapter"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/schemas"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -77,3 +82,302 @@ func TestMetricsEndpoint(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rec.Code)
 	assert.Contains(t, rec.Body.String(), "go_goroutines")
 }
+
+type mockController struct {
+	paused bool
+}
+
+func (m *mockController) Pause()       { m.paused = true }
+func (m *mockController) Resume()      { m.paused = false }
+func (m *mockController) Paused() bool { return m.paused }
+
+func adminRequest(t *testing.T, srv *httpadapter.Server, method, path, token string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	srv.ServeHTTP(rec, req)
+
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return rec.Code, body["status"]
+}
+
+func TestAdminPauseResume(t *testing.T) {
+	ctrl := &mockController{}
+	srv := httpadapter.NewServer(":0", &mockReadiness{}, slog.Default(), httpadapter.WithAdmin(ctrl, "s3cret"))
+
+	code, status := adminRequest(t, srv, http.MethodPost, "/admin/pause", "s3cret")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "paused", status)
+	assert.True(t, ctrl.paused)
+
+	code, status = adminRequest(t, srv, http.MethodGet, "/admin/status", "s3cret")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "paused", status)
+
+	code, status = adminRequest(t, srv, http.MethodPost, "/admin/resume", "s3cret")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "running", status)
+	assert.False(t, ctrl.paused)
+}
+
+func TestAdminRejectsBadToken(t *testing.T) {
+	ctrl := &mockController{}
+	srv := httpadapter.NewServer(":0", &mockReadiness{}, slog.Default(), httpadapter.WithAdmin(ctrl, "s3cret"))
+
+	for _, token := range []string{"", "wrong"} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/admin/pause", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		srv.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+	assert.False(t, ctrl.paused)
+}
+
+func TestAdminDisabledWithoutToken(t *testing.T) {
+	srv := httpadapter.NewServer(":0", &mockReadiness{}, slog.Default(), httpadapter.WithAdmin(&mockController{}, ""))
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/pause", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+type reloaderFunc func(context.Context) error
+
+func (f reloaderFunc) Reload(ctx context.Context) error { return f(ctx) }
+
+func TestAdminReload(t *testing.T) {
+	calls := 0
+	ok := reloaderFunc(func(context.Context) error { calls++; return nil })
+	srv := httpadapter.NewServer(":0", &mockReadiness{}, slog.Default(), httpadapter.WithReload(ok, "s3cret"))
+
+	code, status := adminRequest(t, srv, http.MethodPost, "/admin/reload", "s3cret")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "reloaded", status)
+	assert.Equal(t, 1, calls)
+
+	code, _ = adminRequest(t, srv, http.MethodPost, "/admin/reload", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestAdminReloadError(t *testing.T) {
+	failing := reloaderFunc(func(context.Context) error { return errors.New("invalid BATCH_SIZE: must be 1-1000") })
+	srv := httpadapter.NewServer(":0", &mockReadiness{}, slog.Default(), httpadapter.WithReload(failing, "s3cret"))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/admin/reload", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	srv.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "BATCH_SIZE")
+}
+
+func TestSchemaEndpoint(t *testing.T) {
+	srv := httpadapter.NewServer(":0", &mockReadiness{}, slog.Default(), httpadapter.WithSchema(schemas.StormEvent))
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/schema", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/schema+json", rec.Header().Get("Content-Type"))
+
+	var schema struct {
+		Title      string         `json:"title"`
+		Properties map[string]any `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &schema))
+	assert.Equal(t, "StormEvent", schema.Title)
+	assert.Contains(t, schema.Properties, "event_type")
+}
+
+func TestPprofEndpoints(t *testing.T) {
+	srv := httpadapter.NewServer(":0", &mockReadiness{}, slog.Default(), httpadapter.WithPprof(""))
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "goroutine")
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/heap", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestPprofRequiresTokenWhenSet(t *testing.T) {
+	srv := httpadapter.NewServer(":0", &mockReadiness{}, slog.Default(), httpadapter.WithPprof("s3cret"))
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/debug/pprof/goroutine?debug=1", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestPprofDisabledByDefault(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestServer(nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+type mockDeadLetters struct {
+	records    []domain.DeadLetterRecord
+	limit      int
+	requeued   []domain.DeadLetterPosition
+	requeueErr error
+}
+
+func (m *mockDeadLetters) Recent(_ context.Context, limit int) ([]domain.DeadLetterRecord, error) {
+	m.limit = limit
+	return m.records, nil
+}
+
+func (m *mockDeadLetters) Requeue(_ context.Context, positions []domain.DeadLetterPosition) (int, error) {
+	if m.requeueErr != nil {
+		return 0, m.requeueErr
+	}
+	m.requeued = append(m.requeued, positions...)
+	return len(positions), nil
+}
+
+func dlqRequest(srv *httpadapter.Server, method, target, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer s3cret")
+	srv.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminDeadLetters(t *testing.T) {
+	dlq := &mockDeadLetters{records: []domain.DeadLetterRecord{{
+		DeadLetterPosition: domain.DeadLetterPosition{Partition: 1, Offset: 7},
+		Value:              "not-json{{{",
+		Reason:             "parse raw event: invalid character",
+		SourceTopic:        "raw-weather-reports",
+	}}}
+	srv := httpadapter.NewServer(":0", &mockReadiness{}, slog.Default(), httpadapter.WithDeadLetters(dlq, "s3cret"))
+
+	rec := dlqRequest(srv, http.MethodGet, "/admin/dlq?limit=10", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 10, dlq.limit)
+	var list struct {
+		Messages []domain.DeadLetterRecord `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Messages, 1)
+	assert.Equal(t, "parse raw event: invalid character", list.Messages[0].Reason)
+	assert.Equal(t, int64(7), list.Messages[0].Offset)
+
+	rec = dlqRequest(srv, http.MethodPost, "/admin/dlq/requeue", `{"messages":[{"partition":1,"offset":7}]}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"requeued":1}`, rec.Body.String())
+	assert.Equal(t, []domain.DeadLetterPosition{{Partition: 1, Offset: 7}}, dlq.requeued)
+}
+
+func TestAdminDeadLettersBadRequests(t *testing.T) {
+	dlq := &mockDeadLetters{}
+	srv := httpadapter.NewServer(":0", &mockReadiness{}, slog.Default(), httpadapter.WithDeadLetters(dlq, "s3cret"))
+
+	assert.Equal(t, http.StatusBadRequest, dlqRequest(srv, http.MethodGet, "/admin/dlq?limit=0", "").Code)
+	assert.Equal(t, http.StatusBadRequest, dlqRequest(srv, http.MethodPost, "/admin/dlq/requeue", `not json`).Code)
+	assert.Equal(t, http.StatusBadRequest, dlqRequest(srv, http.MethodPost, "/admin/dlq/requeue", `{"messages":[]}`).Code)
+	assert.Empty(t, dlq.requeued)
+
+	rec := dlqRequest(srv, http.MethodGet, "/admin/dlq", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 50, dlq.limit)
+	assert.JSONEq(t, `{"messages":[]}`, rec.Body.String())
+
+	dlq.requeueErr = errors.New("dead letter 0/3 not found")
+	rec = dlqRequest(srv, http.MethodPost, "/admin/dlq/requeue", `{"messages":[{"partition":0,"offset":3}]}`)
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+	assert.Contains(t, rec.Body.String(), "not found")
+}
+
+type mockProgress []domain.PartitionProgress
+
+func (m mockProgress) Progress() []domain.PartitionProgress { return m }
+
+func TestAdminProgress(t *testing.T) {
+	progress := mockProgress{{Topic: "raw-weather-reports", Partition: 2, LastOffset: 41, Loaded: 40, DeadLettered: 1}}
+	srv := httpadapter.NewServer(":0", &mockReadiness{}, slog.Default(), httpadapter.WithProgress(progress, "s3cret"))
+
+	rec := dlqRequest(srv, http.MethodGet, "/admin/progress", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Partitions []domain.PartitionProgress `json:"partitions"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, []domain.PartitionProgress(progress), body.Partitions)
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/progress", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+type mockHealth domain.HealthReport
+
+func (m mockHealth) Health(context.Context) domain.HealthReport { return domain.HealthReport(m) }
+
+func TestHealthDetail(t *testing.T) {
+	report := domain.HealthReport{
+		Components: []domain.ComponentHealth{
+			{Name: "kafka_reader", Status: domain.HealthOK},
+			{Name: "kafka_writer", Status: domain.HealthError, Error: "sink topic missing"},
+		},
+		LoaderCircuit: "closed",
+		LastBatchAt:   time.Date(2024, 4, 26, 12, 0, 0, 0, time.UTC),
+	}
+	srv := httpadapter.NewServer(":0", &mockReadiness{}, slog.Default(), httpadapter.WithHealthDetail(mockHealth(report)))
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz/detail", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.JSONEq(t, `{
+		"ready": false,
+		"components": [
+			{"name": "kafka_reader", "status": "ok"},
+			{"name": "kafka_writer", "status": "error", "error": "sink topic missing"}
+		],
+		"paused": false,
+		"loader_circuit": "closed",
+		"messages_processed": false,
+		"last_batch_at": "2024-04-26T12:00:00Z"
+	}`, rec.Body.String())
+
+	report.Ready = true
+	srv = httpadapter.NewServer(":0", &mockReadiness{}, slog.Default(), httpadapter.WithHealthDetail(mockHealth(report)))
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz/detail", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+type mockPipelineReadiness struct {
+	mockReadiness
+	processed bool
+}
+
+func (m *mockPipelineReadiness) Processed() bool { return m.processed }
+
+func TestReadyzReportsProcessedSeparately(t *testing.T) {
+	srv := httpadapter.NewServer(":0", &mockPipelineReadiness{}, slog.Default())
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "an idle pipeline is ready once its dependencies are")
+	assert.JSONEq(t, `{"status":"ready","messages_processed":false}`, rec.Body.String())
+
+	srv = httpadapter.NewServer(":0", &mockPipelineReadiness{mockReadiness: mockReadiness{err: errors.New("kafka brokers unreachable")}, processed: true}, slog.Default())
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.JSONEq(t, `{"status":"not ready","error":"kafka brokers unreachable","messages_processed":true}`, rec.Body.String())
+}
@@ -0,0 +1,175 @@
+package httpadapter
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	sharedobs "github.com/couchcryptid/storm-data-shared/observability"
+	"golang.org/x/net/websocket"
+)
+
+// EventSource delivers loaded events that match a filter until cancelled.
+// *pipeline.Broadcaster implements it.
+type EventSource interface {
+	Subscribe(filter domain.EventFilter) (events <-chan domain.StormEvent, cancel func())
+}
+
+// Timing of the live event feed. A client that cannot take a message within
+// streamWriteTimeout is disconnected; pings keep idle connections open through
+// proxies while no storms are reported.
+const (
+	streamWriteTimeout = 10 * time.Second
+	streamPingInterval = 30 * time.Second
+
+	// streamMaxClientFrame bounds what a client may send; it is only expected
+	// to send control frames.
+	streamMaxClientFrame = 4 << 10
+)
+
+// pingCodec sends an empty WebSocket ping frame.
+var pingCodec = websocket.Codec{Marshal: func(any) ([]byte, byte, error) {
+	return nil, websocket.PingFrame, nil
+}}
+
+// WithEventStream registers GET /events/stream, which upgrades to a WebSocket
+// and sends each loaded event as a JSON text message, retracted events with
+// "deleted": true. The query parameters event_type, state (both repeatable or
+// comma-separated), min_severity, and bbox (minLat,minLon,maxLat,maxLon)
+// filter the feed; an invalid value is rejected with 400 before upgrading.
+// Browsers are accepted only from allowedOrigins, or from any origin when it
+// is empty. Open streams are closed when the server shuts down.
+func WithEventStream(source EventSource, allowedOrigins []string) Option {
+	return func(s *Server, mux *http.ServeMux) {
+		closing := make(chan struct{})
+		var once sync.Once
+		s.httpServer.RegisterOnShutdown(func() { once.Do(func() { close(closing) }) })
+
+		mux.HandleFunc("GET /events/stream", func(w http.ResponseWriter, r *http.Request) {
+			filter, err := streamFilter(r.URL.Query())
+			if err != nil {
+				sharedobs.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			websocket.Server{
+				Handshake: func(_ *websocket.Config, r *http.Request) error {
+					return checkOrigin(r.Header.Get("Origin"), allowedOrigins)
+				},
+				Handler: func(ws *websocket.Conn) {
+					s.streamEvents(ws, source, filter, closing)
+				},
+			}.ServeHTTP(w, r)
+		})
+	}
+}
+
+// streamEvents sends matching events until the client disconnects, a write
+// times out, or the server shuts down.
+func (s *Server) streamEvents(ws *websocket.Conn, source EventSource, filter domain.EventFilter, closing <-chan struct{}) {
+	// The hijacked connection keeps the server's read and write deadlines.
+	_ = ws.SetDeadline(time.Time{})
+	ws.MaxPayloadBytes = streamMaxClientFrame
+
+	events, cancel := source.Subscribe(filter)
+	defer cancel()
+	s.logger.Debug("websocket event stream opened", "remote_addr", ws.Request().RemoteAddr, "filter", filter)
+
+	// The client sends nothing but control frames; reading answers its pings
+	// and notices when it goes away.
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		var discard []byte
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+	}()
+
+	ping := time.NewTicker(streamPingInterval)
+	defer ping.Stop()
+	for {
+		var err error
+		select {
+		case <-gone:
+			s.logger.Debug("websocket event stream closed by client")
+			return
+		case <-closing:
+			_ = ws.Close()
+			return
+		case event, ok := <-events:
+			if !ok {
+				_ = ws.Close()
+				return
+			}
+			_ = ws.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			err = websocket.JSON.Send(ws, event)
+		case <-ping.C:
+			_ = ws.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			err = pingCodec.Send(ws, nil)
+		}
+		if err != nil {
+			s.logger.Debug("websocket event stream write failed", "error", err)
+			_ = ws.Close()
+			return
+		}
+	}
+}
+
+// streamFilter builds an event filter from the /events/stream query string,
+// canonicalizing event types and severity.
+func streamFilter(q url.Values) (domain.EventFilter, error) {
+	f := domain.EventFilter{States: queryList(q["state"])}
+	for _, name := range queryList(q["event_type"]) {
+		canonical := domain.CanonicalEventType(name)
+		if canonical == "" {
+			return domain.EventFilter{}, fmt.Errorf("invalid event_type: unknown event type %q", name)
+		}
+		f.EventTypes = append(f.EventTypes, canonical)
+	}
+	if v := q.Get("min_severity"); v != "" {
+		level, err := domain.ParseSeverityLevel(v)
+		if err != nil {
+			return domain.EventFilter{}, fmt.Errorf("invalid min_severity: %w", err)
+		}
+		f.MinSeverity = level
+	}
+	if v := q.Get("bbox"); v != "" {
+		bbox, err := domain.ParseBoundingBox(v)
+		if err != nil {
+			return domain.EventFilter{}, fmt.Errorf("invalid bbox: %w", err)
+		}
+		f.BBox = &bbox
+	}
+	return f, nil
+}
+
+// queryList flattens repeated and comma-separated query values, dropping
+// empty entries.
+func queryList(values []string) []string {
+	var items []string
+	for _, v := range values {
+		for part := range strings.SplitSeq(v, ",") {
+			if trimmed := strings.TrimSpace(part); trimmed != "" {
+				items = append(items, trimmed)
+			}
+		}
+	}
+	return items
+}
+
+// checkOrigin accepts requests without an Origin header, which browsers
+// always send, and browser requests from an allowed origin.
+func checkOrigin(origin string, allowed []string) error {
+	if origin == "" || len(allowed) == 0 {
+		return nil
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(strings.TrimSuffix(a, "/"), origin) {
+			return nil
+		}
+	}
+	return fmt.Errorf("origin %q not allowed", origin)
+}
@@ -0,0 +1,128 @@
+package httpadapter_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/httpadapter"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+type streamFixture struct {
+	srv         *httpadapter.Server
+	url         string
+	broadcaster *pipeline.Broadcaster
+	metrics     *observability.Metrics
+}
+
+func newStreamFixture(t *testing.T, allowedOrigins ...string) *streamFixture {
+	t.Helper()
+	metrics := observability.NewMetricsForTesting()
+	broadcaster := pipeline.NewBroadcaster(16, metrics)
+	srv := httpadapter.NewServer(":0", &mockReadiness{}, slog.New(slog.DiscardHandler),
+		httpadapter.WithEventStream(broadcaster, allowedOrigins))
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	return &streamFixture{srv: srv, url: ts.URL, broadcaster: broadcaster, metrics: metrics}
+}
+
+func (f *streamFixture) dial(t *testing.T, query, origin string) (*websocket.Conn, error) {
+	t.Helper()
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(f.url, "http")+"/events/stream"+query, "", origin)
+	if err == nil {
+		t.Cleanup(func() { _ = ws.Close() })
+	}
+	return ws, err
+}
+
+func (f *streamFixture) waitForSubscribers(t *testing.T, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(f.metrics.StreamSubscribers) == float64(n)
+	}, time.Second, 5*time.Millisecond)
+}
+
+func receiveEvent(t *testing.T, ws *websocket.Conn) domain.StormEvent {
+	t.Helper()
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	var msg string
+	require.NoError(t, websocket.Message.Receive(ws, &msg))
+	var event domain.StormEvent
+	require.NoError(t, json.Unmarshal([]byte(msg), &event))
+	return event
+}
+
+func TestEventStream_FiltersAndStreams(t *testing.T) {
+	f := newStreamFixture(t)
+	ws, err := f.dial(t, "?event_type=flash+flood,hail&state=ok&min_severity=Moderate", "http://localhost")
+	require.NoError(t, err)
+	f.waitForSubscribers(t, 1)
+
+	minor, severe := "minor", "severe"
+	f.broadcaster.Publish([]domain.StormEvent{
+		{ID: "wind-1", EventType: "wind", Location: domain.Location{State: "OK"}, Measurement: domain.Measurement{Severity: &severe}},
+		{ID: "hail-minor", EventType: "hail", Location: domain.Location{State: "OK"}, Measurement: domain.Measurement{Severity: &minor}},
+		{ID: "hail-tx", EventType: "hail", Location: domain.Location{State: "TX"}, Measurement: domain.Measurement{Severity: &severe}},
+		{ID: "flood-1", EventType: "flash_flood", Location: domain.Location{State: "OK"}, Measurement: domain.Measurement{Severity: &severe}},
+		{ID: "hail-1", EventType: "hail", Location: domain.Location{State: "OK"}, Measurement: domain.Measurement{Severity: &severe}, Deleted: true},
+	})
+
+	event := receiveEvent(t, ws)
+	assert.Equal(t, "flood-1", event.ID)
+	assert.False(t, event.Deleted)
+	event = receiveEvent(t, ws)
+	assert.Equal(t, "hail-1", event.ID)
+	assert.True(t, event.Deleted)
+
+	require.NoError(t, ws.Close())
+	f.waitForSubscribers(t, 0)
+}
+
+func TestEventStream_InvalidFilter(t *testing.T) {
+	f := newStreamFixture(t)
+
+	for _, query := range []string{"?event_type=meteor", "?min_severity=catastrophic", "?bbox=1,2,3"} {
+		t.Run(query, func(t *testing.T) {
+			resp, err := http.Get(f.url + "/events/stream" + query)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
+
+func TestEventStream_Origin(t *testing.T) {
+	f := newStreamFixture(t, "https://dashboard.example.com")
+
+	_, err := f.dial(t, "", "https://evil.example.com")
+	require.Error(t, err)
+
+	_, err = f.dial(t, "", "https://dashboard.example.com")
+	require.NoError(t, err)
+}
+
+func TestEventStream_ClosedOnShutdown(t *testing.T) {
+	f := newStreamFixture(t)
+	ws, err := f.dial(t, "", "http://localhost")
+	require.NoError(t, err)
+	f.waitForSubscribers(t, 1)
+
+	require.NoError(t, f.srv.Shutdown(context.Background()))
+
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	var msg string
+	require.Error(t, websocket.Message.Receive(ws, &msg))
+	f.waitForSubscribers(t, 0)
+}
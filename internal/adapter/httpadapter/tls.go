@@ -0,0 +1,106 @@
+package httpadapter
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLSOptions configures HTTPS for the server.
+type TLSOptions struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile, when set, verifies client certificates against these CAs.
+	// RequireClientCert rejects clients without one; otherwise they are served
+	// unauthenticated, so kubelet probes still work.
+	ClientCAFile      string
+	RequireClientCert bool
+}
+
+// NewTLSConfig loads the server certificate and client CAs. The certificate
+// is reloaded when its files change, so rotated certificates are picked up
+// without a restart; the client CAs are read once.
+func NewTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	certs := &certReloader{certFile: opts.CertFile, keyFile: opts.KeyFile}
+	if _, err := certs.load(); err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.getCertificate,
+	}
+	if opts.ClientCAFile != "" {
+		pem, err := os.ReadFile(opts.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read http client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("http client CA file contains no valid certificates")
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		if opts.RequireClientCert {
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return cfg, nil
+}
+
+// WithTLS serves over HTTPS with cfg, typically from NewTLSConfig.
+func WithTLS(cfg *tls.Config) Option {
+	return func(s *Server, _ *http.ServeMux) {
+		s.httpServer.TLSConfig = cfg
+	}
+}
+
+// certReloader serves the certificate from certFile and keyFile, reloading
+// it when either file's modification time changes.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	certMod time.Time
+	keyMod  time.Time
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert, err := r.load()
+	if err != nil && cert == nil {
+		return nil, err
+	}
+	// A failed reload, e.g. while the certificate and key are being replaced
+	// one after the other, keeps serving the previous certificate.
+	return cert, nil
+}
+
+// load returns the current certificate, reloading it if the files changed.
+// On a failed reload it returns the previous certificate with the error.
+func (r *certReloader) load() (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return r.cert, fmt.Errorf("load http certificate: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return r.cert, fmt.Errorf("load http certificate: %w", err)
+	}
+	if r.cert != nil && certInfo.ModTime().Equal(r.certMod) && keyInfo.ModTime().Equal(r.keyMod) {
+		return r.cert, nil
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return r.cert, fmt.Errorf("load http certificate: %w", err)
+	}
+	r.cert, r.certMod, r.keyMod = &cert, certInfo.ModTime(), keyInfo.ModTime()
+	return r.cert, nil
+}
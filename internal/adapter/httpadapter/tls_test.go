@@ -0,0 +1,176 @@
+package httpadapter_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/httpadapter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCA issues certificates for TLS tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key for commonName, valid for
+// 127.0.0.1 as a server and as a client.
+func (ca *testCA) issue(t *testing.T, commonName string, serial int64) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+// serveTLS serves a server built with opts over TLS on a local port and
+// returns its URL.
+func serveTLS(t *testing.T, opts httpadapter.TLSOptions) string {
+	t.Helper()
+	tlsCfg, err := httpadapter.NewTLSConfig(opts)
+	require.NoError(t, err)
+	srv := httpadapter.NewServer(":0", &mockReadiness{}, slog.Default(), httpadapter.WithTLS(tlsCfg))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	hs := &http.Server{Handler: srv, ReadHeaderTimeout: time.Second}
+	go func() { _ = hs.Serve(tls.NewListener(lis, tlsCfg)) }()
+	t.Cleanup(func() { _ = hs.Close() })
+	return "https://" + lis.Addr().String()
+}
+
+func tlsClient(ca *testCA, clientCert *tls.Certificate) *http.Client {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	cfg := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	if clientCert != nil {
+		cfg.Certificates = []tls.Certificate{*clientCert}
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: cfg, DisableKeepAlives: true}, Timeout: 5 * time.Second}
+}
+
+func TestTLS_ClientCertificates(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, "storm-data-etl", 2)
+	opts := httpadapter.TLSOptions{
+		CertFile:          writeFile(t, dir, "tls.crt", certPEM),
+		KeyFile:           writeFile(t, dir, "tls.key", keyPEM),
+		ClientCAFile:      writeFile(t, dir, "ca.crt", ca.pem),
+		RequireClientCert: true,
+	}
+	clientCertPEM, clientKeyPEM := ca.issue(t, "prometheus", 3)
+	clientCert, err := tls.X509KeyPair(clientCertPEM, clientKeyPEM)
+	require.NoError(t, err)
+
+	url := serveTLS(t, opts)
+	resp, err := tlsClient(ca, &clientCert).Get(url + "/healthz")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, err = tlsClient(ca, nil).Get(url + "/healthz")
+	require.Error(t, err, "a client certificate is required")
+
+	opts.RequireClientCert = false
+	url = serveTLS(t, opts)
+	resp, err = tlsClient(ca, nil).Get(url + "/healthz")
+	require.NoError(t, err, "probes without a certificate are served when client certificates are optional")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestTLS_ReloadsRotatedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, "storm-data-etl", 2)
+	opts := httpadapter.TLSOptions{
+		CertFile: writeFile(t, dir, "tls.crt", certPEM),
+		KeyFile:  writeFile(t, dir, "tls.key", keyPEM),
+	}
+	url := serveTLS(t, opts)
+	serverSerial := func() int64 {
+		resp, err := tlsClient(ca, nil).Get(url + "/healthz")
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.TLS.PeerCertificates[0].SerialNumber.Int64()
+	}
+	assert.Equal(t, int64(2), serverSerial())
+
+	certPEM, keyPEM = ca.issue(t, "storm-data-etl", 3)
+	writeFile(t, dir, "tls.crt", certPEM)
+	writeFile(t, dir, "tls.key", keyPEM)
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(opts.CertFile, later, later))
+	require.NoError(t, os.Chtimes(opts.KeyFile, later, later))
+	assert.Equal(t, int64(3), serverSerial())
+}
+
+func TestNewTLSConfig_Errors(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, "storm-data-etl", 2)
+	certFile := writeFile(t, dir, "tls.crt", certPEM)
+	keyFile := writeFile(t, dir, "tls.key", keyPEM)
+
+	_, err := httpadapter.NewTLSConfig(httpadapter.TLSOptions{CertFile: certFile, KeyFile: filepath.Join(dir, "missing.key")})
+	require.ErrorContains(t, err, "load http certificate")
+
+	_, err = httpadapter.NewTLSConfig(httpadapter.TLSOptions{CertFile: certFile, KeyFile: keyFile, ClientCAFile: keyFile})
+	require.ErrorContains(t, err, "no valid certificates")
+}
@@ -0,0 +1,82 @@
+package kafka
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// Dead-letter header keys describing why and where a message failed.
+const (
+	headerDLQError           = "dlq_error"
+	headerDLQSourceTopic     = "dlq_source_topic"
+	headerDLQSourcePartition = "dlq_source_partition"
+	headerDLQSourceOffset    = "dlq_source_offset"
+	headerDLQFailedAt        = "dlq_failed_at"
+)
+
+// DeadLetterWriter produces failed raw messages to a dead-letter topic.
+// It implements pipeline.DeadLetterLoader.
+type DeadLetterWriter struct {
+	writer *kafkago.Writer
+	logger *slog.Logger
+}
+
+// NewDeadLetterWriter creates a Kafka producer for the configured dead-letter topic.
+func NewDeadLetterWriter(cfg *config.Config, logger *slog.Logger) (*DeadLetterWriter, error) {
+	transport, err := newTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	w := &kafkago.Writer{
+		Addr:         kafkago.TCP(cfg.KafkaBrokers...),
+		Transport:    transport,
+		Topic:        cfg.KafkaDLQTopic,
+		Balancer:     &kafkago.LeastBytes{},
+		RequiredAcks: kafkago.RequireAll,
+	}
+	return &DeadLetterWriter{writer: w, logger: logger}, nil
+}
+
+// LoadDeadLetters publishes the original raw payloads with error metadata headers.
+func (w *DeadLetterWriter) LoadDeadLetters(ctx context.Context, letters []domain.DeadLetter) error {
+	if len(letters) == 0 {
+		return nil
+	}
+	msgs := make([]kafkago.Message, len(letters))
+	for i := range letters {
+		msgs[i] = deadLetterToMessage(letters[i])
+	}
+	return w.writer.WriteMessages(ctx, msgs...)
+}
+
+func (w *DeadLetterWriter) Close() error {
+	return w.writer.Close()
+}
+
+// deadLetterToMessage preserves the original key, value, and headers and
+// appends headers describing the failure and the message's source position.
+func deadLetterToMessage(dl domain.DeadLetter) kafkago.Message {
+	raw := dl.Event
+	headers := make([]kafkago.Header, 0, len(raw.Headers)+5)
+	for k, v := range raw.Headers {
+		headers = append(headers, kafkago.Header{Key: k, Value: []byte(v)})
+	}
+	headers = append(headers,
+		kafkago.Header{Key: headerDLQError, Value: []byte(dl.Reason)},
+		kafkago.Header{Key: headerDLQSourceTopic, Value: []byte(raw.Topic)},
+		kafkago.Header{Key: headerDLQSourcePartition, Value: []byte(strconv.Itoa(raw.Partition))},
+		kafkago.Header{Key: headerDLQSourceOffset, Value: []byte(strconv.FormatInt(raw.Offset, 10))},
+		kafkago.Header{Key: headerDLQFailedAt, Value: []byte(dl.FailedAt.Format(time.RFC3339))},
+	)
+	return kafkago.Message{
+		Key:     raw.Key,
+		Value:   raw.Value,
+		Headers: headers,
+	}
+}
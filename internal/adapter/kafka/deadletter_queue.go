@@ -0,0 +1,160 @@
+package kafka
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// DeadLetterQueue reads the dead-letter topic for the admin API and requeues
+// selected messages onto their source topic. Inspecting never moves any
+// committed offsets.
+type DeadLetterQueue struct {
+	log          partitionLog
+	sourceTopics []string
+	writer       *kafkago.Writer
+	logger       *slog.Logger
+}
+
+// NewDeadLetterQueue creates a reader and requeue producer for the configured
+// dead-letter topic.
+func NewDeadLetterQueue(cfg *config.Config, logger *slog.Logger) (*DeadLetterQueue, error) {
+	dialer, err := newDialer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := newTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// Topic is set per message: requeued messages return to their own source topic.
+	w := &kafkago.Writer{
+		Addr:         kafkago.TCP(cfg.KafkaBrokers...),
+		Transport:    transport,
+		Balancer:     &kafkago.LeastBytes{},
+		RequiredAcks: kafkago.RequireAll,
+	}
+	return &DeadLetterQueue{
+		log:          partitionLog{dialer: dialer, brokers: cfg.KafkaBrokers, topic: cfg.KafkaDLQTopic},
+		sourceTopics: cfg.KafkaSourceTopics,
+		writer:       w,
+		logger:       logger,
+	}, nil
+}
+
+// Recent returns up to limit of the newest dead letters across all partitions,
+// most recently failed first.
+func (q *DeadLetterQueue) Recent(ctx context.Context, limit int) ([]domain.DeadLetterRecord, error) {
+	partitions, err := q.log.partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var records []domain.DeadLetterRecord
+	for _, partition := range partitions {
+		msgs, err := q.log.readTail(ctx, partition, limit)
+		if err != nil {
+			return nil, fmt.Errorf("read dead letters from partition %d: %w", partition, err)
+		}
+		for i := range msgs {
+			records = append(records, messageToDeadLetterRecord(msgs[i]))
+		}
+	}
+	slices.SortFunc(records, func(a, b domain.DeadLetterRecord) int {
+		return cmp.Or(b.FailedAt.Compare(a.FailedAt), cmp.Compare(a.Partition, b.Partition), cmp.Compare(b.Offset, a.Offset))
+	})
+	if len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
+
+// Requeue republishes the dead letters at the given positions to their source
+// topic with the dead-letter headers removed, so the pipeline transforms them
+// again. Nothing is written unless every position can be read.
+func (q *DeadLetterQueue) Requeue(ctx context.Context, positions []domain.DeadLetterPosition) (int, error) {
+	msgs := make([]kafkago.Message, 0, len(positions))
+	for _, pos := range positions {
+		read, err := q.log.readRange(ctx, pos.Partition, pos.Offset, pos.Offset+1)
+		if err != nil {
+			return 0, fmt.Errorf("read dead letter %d/%d: %w", pos.Partition, pos.Offset, err)
+		}
+		if len(read) == 0 || read[0].Offset != pos.Offset {
+			return 0, fmt.Errorf("dead letter %d/%d not found", pos.Partition, pos.Offset)
+		}
+		msgs = append(msgs, requeueMessage(read[0], q.sourceTopics))
+	}
+	if len(msgs) == 0 {
+		return 0, nil
+	}
+	if err := q.writer.WriteMessages(ctx, msgs...); err != nil {
+		return 0, err
+	}
+	q.logger.Info("dead letters requeued", "count", len(msgs))
+	return len(msgs), nil
+}
+
+func (q *DeadLetterQueue) Close() error {
+	return q.writer.Close()
+}
+
+// messageToDeadLetterRecord decodes the failure metadata headers written by
+// deadLetterToMessage.
+func messageToDeadLetterRecord(msg kafkago.Message) domain.DeadLetterRecord {
+	rec := domain.DeadLetterRecord{
+		DeadLetterPosition: domain.DeadLetterPosition{Partition: msg.Partition, Offset: msg.Offset},
+		Key:                string(msg.Key),
+		Value:              string(msg.Value),
+		FailedAt:           msg.Time.UTC(),
+	}
+	for _, h := range msg.Headers {
+		v := string(h.Value)
+		switch h.Key {
+		case headerDLQError:
+			rec.Reason = v
+		case headerDLQSourceTopic:
+			rec.SourceTopic = v
+		case headerDLQSourcePartition:
+			rec.SourcePartition, _ = strconv.Atoi(v)
+		case headerDLQSourceOffset:
+			rec.SourceOffset, _ = strconv.ParseInt(v, 10, 64)
+		case headerDLQFailedAt:
+			if t, err := time.Parse(time.RFC3339, v); err == nil {
+				rec.FailedAt = t
+			}
+		}
+	}
+	return rec
+}
+
+// requeueMessage restores a dead letter's original key, value, and headers.
+// It returns to its recorded source topic when that is still a configured
+// source; otherwise (for example, reports from the SPC or file extractors) it
+// goes to the first source topic.
+func requeueMessage(msg kafkago.Message, sourceTopics []string) kafkago.Message {
+	topic := sourceTopics[0]
+	headers := make([]kafkago.Header, 0, len(msg.Headers))
+	for _, h := range msg.Headers {
+		if h.Key == headerDLQSourceTopic && slices.Contains(sourceTopics, string(h.Value)) {
+			topic = string(h.Value)
+		}
+		if strings.HasPrefix(h.Key, "dlq_") {
+			continue
+		}
+		headers = append(headers, h)
+	}
+	return kafkago.Message{
+		Topic:   topic,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	}
+}
@@ -0,0 +1,44 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// maxPooledBuffer keeps the buffer of an unusually large event, such as one
+// with pasted text in its comments, from being held by the pool.
+const maxPooledBuffer = 64 << 10
+
+// jsonEncoder is a reusable buffer and the encoder writing into it.
+type jsonEncoder struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+// encoderPool holds jsonEncoders shared by the serializing goroutines, so a
+// busy writer stops growing a fresh buffer for every event.
+var encoderPool = sync.Pool{
+	New: func() any {
+		e := &jsonEncoder{}
+		e.enc = json.NewEncoder(&e.buf)
+		return e
+	},
+}
+
+// marshalJSON encodes v as json.Marshal does, through a pooled encoder. The
+// result is copied out of the buffer, which is reused as soon as it returns.
+func marshalJSON(v any) ([]byte, error) {
+	e := encoderPool.Get().(*jsonEncoder)
+	defer func() {
+		if e.buf.Cap() <= maxPooledBuffer {
+			e.buf.Reset()
+			encoderPool.Put(e)
+		}
+	}()
+	if err := e.enc.Encode(v); err != nil {
+		return nil, err
+	}
+	// Encode terminates each value with a newline that Marshal does not.
+	return bytes.Clone(bytes.TrimSuffix(e.buf.Bytes(), []byte{'\n'})), nil
+}
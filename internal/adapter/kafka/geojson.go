@@ -0,0 +1,45 @@
+package kafka
+
+import (
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// contentTypeGeoJSON identifies GeoJSON Feature payloads (RFC 7946).
+const contentTypeGeoJSON = "application/geo+json"
+
+// geoJSONFeature is an RFC 7946 Feature whose properties are the event's
+// JSON encoding.
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	ID         string          `json:"id"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties any             `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+// marshalFeature encodes an event as a GeoJSON Feature: a LineString from
+// PathBegin to PathEnd when the event has a track, otherwise a Point at Geo.
+// Positions are [longitude, latitude] as RFC 7946 requires. The projection
+// applies to the properties only.
+func marshalFeature(e domain.StormEvent, fields *domain.FieldProjection) ([]byte, error) {
+	geometry := geoJSONGeometry{Type: "Point", Coordinates: position(e.Geo)}
+	if e.PathBegin != nil && e.PathEnd != nil {
+		geometry = geoJSONGeometry{
+			Type:        "LineString",
+			Coordinates: [][2]float64{position(*e.PathBegin), position(*e.PathEnd)},
+		}
+	}
+	properties, err := fields.Apply(e)
+	if err != nil {
+		return nil, err
+	}
+	return marshalJSON(geoJSONFeature{Type: "Feature", ID: e.ID, Geometry: geometry, Properties: properties})
+}
+
+func position(g domain.Geo) [2]float64 {
+	return [2]float64{g.Lon, g.Lat}
+}
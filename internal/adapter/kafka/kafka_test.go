@@ -1,10 +1,30 @@
 package kafka
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/couchcryptid/storm-data-etl/internal/config"
 	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	"github.com/couchcryptid/storm-data-etl/internal/stormpb"
+	"github.com/couchcryptid/storm-data-etl/stormtest"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	kafkago "github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -44,7 +64,7 @@ func TestSerializeToMessage(t *testing.T) {
 		ProcessedAt: now,
 	}
 
-	msg, err := serializeToMessage(event)
+	msg, err := serializeToMessage(event, config.OutputFormatJSON, nil)
 	require.NoError(t, err)
 
 	assert.Equal(t, []byte("evt-1"), msg.Key)
@@ -55,3 +75,726 @@ func TestSerializeToMessage(t *testing.T) {
 	assert.Equal(t, "processed_at", msg.Headers[1].Key)
 	assert.Equal(t, []byte(now.Format(time.RFC3339)), msg.Headers[1].Value)
 }
+
+func TestSerializeToMessage_SchemaVersionHeader(t *testing.T) {
+	event := domain.StormEvent{SchemaVersion: domain.SchemaVersion, ID: "evt-1", EventType: "hail"}
+
+	msg, err := serializeToMessage(event, config.OutputFormatJSON, nil)
+	require.NoError(t, err)
+
+	require.Len(t, msg.Headers, 3)
+	assert.Equal(t, headerSchemaVersion, msg.Headers[2].Key)
+	assert.Equal(t, "2", string(msg.Headers[2].Value))
+	assert.Contains(t, string(msg.Value), `"schema_version":2`)
+}
+
+func TestAsSchemaVersion(t *testing.T) {
+	event := domain.StormEvent{
+		SchemaVersion: domain.SchemaVersion,
+		ID:            "evt-1",
+		EventType:     "hail",
+		Measurement:   domain.Measurement{Magnitude: 1.75, Unit: "in", Metric: &domain.Quantity{Magnitude: 44.45, Unit: "mm"}, Method: domain.MethodMeasured},
+		Location:      domain.Location{Raw: "8 ESE Chappel", Name: "Chappel", PlaceGeo: &domain.Geo{Lat: 35.04, Lon: -97.12}, CWA: "OUN", Zone: "OKZ025"},
+		Impact:        &domain.Impact{Damage: []string{"trees down"}},
+		SourceOffice:  "OUN",
+		Provenance:    &domain.Provenance{Headers: map[string]string{"source_file": "250415_rpts_hail.csv"}},
+		EventTime:     time.Date(2024, time.April, 26, 15, 10, 0, 0, time.UTC),
+		EndTime:       time.Date(2024, time.April, 26, 15, 25, 0, 0, time.UTC),
+		PathBegin:     &domain.Geo{Lat: 34.93, Lon: -95.8},
+		PathEnd:       &domain.Geo{Lat: 34.99, Lon: -95.71},
+		Quality:       &domain.DataQuality{Inferred: []string{"measurement.magnitude"}},
+		MergedFrom:    []string{"hail-2"},
+		EpisodeID:     "episode-0123456789abcdef",
+
+		SourceOfficeDetail: &domain.SourceOfficeDetail{Code: "OUN", Name: "Norman"},
+		NearestCity:        &domain.NearestCity{Name: "Norman", State: "OK", Distance: 12.4, Direction: "ESE"},
+		NearbyPlaces:       &domain.NearbyPlaces{Population: 128026, Places: 1, Radius: 10},
+		WarningActive:      new(bool),
+		WarningIDs:         []string{"urn:oid:2.49.0.1.840.0.1"},
+		Geohash:            "9y68qew",
+	}
+
+	current, err := asSchemaVersion(event, domain.SchemaVersion)
+	require.NoError(t, err)
+	assert.Equal(t, event, current)
+
+	v1, err := asSchemaVersion(event, 1)
+	require.NoError(t, err)
+	assert.Zero(t, v1.SchemaVersion)
+	assert.Nil(t, v1.Measurement.Metric)
+	assert.Empty(t, v1.Measurement.Method)
+	assert.Nil(t, v1.Location.PlaceGeo)
+	assert.Empty(t, v1.Location.CWA)
+	assert.Empty(t, v1.Location.Zone)
+	assert.Nil(t, v1.Impact)
+	assert.Nil(t, v1.SourceOfficeDetail)
+	assert.Nil(t, v1.Provenance)
+	assert.True(t, v1.EndTime.IsZero())
+	assert.Nil(t, v1.PathBegin)
+	assert.Nil(t, v1.PathEnd)
+	assert.Nil(t, v1.Quality)
+	assert.Nil(t, v1.MergedFrom)
+	assert.Empty(t, v1.EpisodeID)
+	assert.Nil(t, v1.NearestCity)
+	assert.Nil(t, v1.NearbyPlaces)
+	assert.Nil(t, v1.WarningActive)
+	assert.Nil(t, v1.WarningIDs)
+	assert.Empty(t, v1.Geohash)
+	assert.Equal(t, event.EventTime, v1.EventTime)
+	assert.Equal(t, "Chappel", v1.Location.Name)
+	assert.Equal(t, "OUN", v1.SourceOffice)
+	assert.NotNil(t, event.Measurement.Metric, "downgrade must not modify the original event")
+
+	msg, err := serializeToMessage(v1, config.OutputFormatJSON, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, string(msg.Value), "schema_version")
+	assert.Len(t, msg.Headers, 2)
+
+	_, err = asSchemaVersion(event, 99)
+	require.Error(t, err)
+}
+
+func TestNewWriter_SchemaTargets(t *testing.T) {
+	w, err := NewWriter(&config.Config{
+		KafkaBrokers:         []string{"localhost:9092"},
+		KafkaSinkTopic:       "transformed-weather-data",
+		SchemaVersion:        domain.SchemaVersion,
+		SchemaCompatVersions: []int{1},
+	}, slog.Default(), observability.NewMetricsForTesting())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = w.Close() })
+
+	assert.Equal(t, []sinkTarget{
+		{topic: "transformed-weather-data", version: domain.SchemaVersion},
+		{topic: "transformed-weather-data.v1", version: 1},
+	}, w.targets)
+
+	w, err = NewWriter(&config.Config{KafkaBrokers: []string{"localhost:9092"}, KafkaSinkTopic: "sink"}, slog.Default(), observability.NewMetricsForTesting())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = w.Close() })
+	assert.Equal(t, []sinkTarget{{topic: "sink", version: domain.SchemaVersion}}, w.targets, "unset version defaults to the current schema")
+}
+
+func TestNewWriter_Producer(t *testing.T) {
+	w, err := NewWriter(&config.Config{
+		KafkaBrokers:          []string{"localhost:9092"},
+		KafkaSinkTopic:        "sink",
+		KafkaSinkCompression:  config.CompressionLZ4,
+		KafkaSinkBatchSize:    500,
+		KafkaSinkBatchBytes:   4 << 20,
+		KafkaSinkBatchTimeout: 50 * time.Millisecond,
+		KafkaSinkMaxAttempts:  3,
+	}, slog.Default(), observability.NewMetricsForTesting())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = w.Close() })
+	assert.Equal(t, kafkago.Lz4, w.writer.Compression)
+	assert.Equal(t, 500, w.writer.BatchSize)
+	assert.Equal(t, int64(4<<20), w.writer.BatchBytes)
+	assert.Equal(t, 50*time.Millisecond, w.writer.BatchTimeout)
+	assert.Equal(t, 3, w.writer.MaxAttempts)
+	assert.False(t, w.writer.Async)
+	assert.Nil(t, w.writer.Completion)
+	assert.IsType(t, &kafkago.LeastBytes{}, w.writer.Balancer)
+
+	assert.Equal(t, kafkago.Compression(0), compressionCodec(config.CompressionNone))
+	assert.Equal(t, kafkago.Gzip, compressionCodec(config.CompressionGzip))
+	assert.Equal(t, kafkago.Snappy, compressionCodec(config.CompressionSnappy))
+	assert.Equal(t, kafkago.Zstd, compressionCodec(config.CompressionZstd))
+}
+
+func TestNewWriter_AsyncCountsDeliveryErrors(t *testing.T) {
+	metrics := observability.NewMetricsForTesting()
+	w, err := NewWriter(&config.Config{
+		KafkaBrokers:   []string{"localhost:9092"},
+		KafkaSinkTopic: "sink",
+		KafkaSinkAsync: true,
+	}, slog.Default(), metrics)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = w.Close() })
+	require.True(t, w.writer.Async)
+	require.NotNil(t, w.writer.Completion)
+
+	w.writer.Completion(make([]kafkago.Message, 3), nil)
+	w.writer.Completion(make([]kafkago.Message, 2), errors.New("broker unavailable"))
+	var m dto.Metric
+	require.NoError(t, metrics.SinkAsyncErrors.Write(&m))
+	assert.InDelta(t, 2, m.GetCounter().GetValue(), 0)
+}
+
+func TestWriterMessages_RecordsPayloadSize(t *testing.T) {
+	metrics := observability.NewMetricsForTesting()
+	w, err := NewWriter(&config.Config{
+		KafkaBrokers:         []string{"localhost:9092"},
+		KafkaSinkTopic:       "transformed-weather-data",
+		SchemaCompatVersions: []int{1},
+	}, slog.Default(), metrics)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = w.Close() })
+
+	msgs, err := w.messages([]domain.StormEvent{{ID: "evt-1", EventType: "hail"}})
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+
+	var m dto.Metric
+	require.NoError(t, metrics.EventMessageBytes.Write(&m))
+	assert.Equal(t, uint64(2), m.GetHistogram().GetSampleCount())
+	assert.InDelta(t, float64(len(msgs[0].Value)+len(msgs[1].Value)), m.GetHistogram().GetSampleSum(), 0)
+}
+
+func TestWriterMessages_Tombstone(t *testing.T) {
+	w, err := NewWriter(&config.Config{
+		KafkaBrokers:         []string{"localhost:9092"},
+		KafkaSinkTopic:       "transformed-weather-data",
+		SchemaCompatVersions: []int{1},
+	}, slog.Default(), observability.NewMetricsForTesting())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = w.Close() })
+
+	msgs, err := w.messages([]domain.StormEvent{{
+		ID: "evt-1", EventType: "hail", Deleted: true,
+		TraceContext: map[string]string{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+	}})
+	require.NoError(t, err)
+	require.Len(t, msgs, 2, "retracted on every schema topic")
+	for _, msg := range msgs {
+		assert.Equal(t, []byte("evt-1"), msg.Key)
+		assert.Nil(t, msg.Value)
+		assert.Equal(t, []kafkago.Header{
+			{Key: "event_type", Value: []byte("hail")},
+			{Key: "traceparent", Value: []byte("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")},
+		}, msg.Headers)
+	}
+	assert.Equal(t, []string{"transformed-weather-data", "transformed-weather-data.v1"}, []string{msgs[0].Topic, msgs[1].Topic})
+}
+
+func TestWriterMessages_OrderingKey(t *testing.T) {
+	w, err := NewWriter(&config.Config{
+		KafkaBrokers:      []string{"localhost:9092"},
+		KafkaSinkTopic:    "transformed-weather-data",
+		OrderedProcessing: true,
+	}, slog.Default(), observability.NewMetricsForTesting())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = w.Close() })
+	assert.IsType(t, &kafkago.Hash{}, w.writer.Balancer, "keys must map to a stable partition")
+
+	msgs, err := w.messages([]domain.StormEvent{
+		{ID: "evt-1", EventType: "hail", OrderingKey: "OK-station"},
+		{ID: "evt-2", EventType: "hail", OrderingKey: "OK-station", Deleted: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	for i, id := range []string{"evt-1", "evt-2"} {
+		assert.Equal(t, []byte("OK-station"), msgs[i].Key)
+		assert.Contains(t, msgs[i].Headers, kafkago.Header{Key: "event_id", Value: []byte(id)},
+			"the event ID moves to a header when the key is an ordering key")
+	}
+}
+
+func TestWriterMessages_GeohashKey(t *testing.T) {
+	w, err := NewWriter(&config.Config{
+		KafkaBrokers:          []string{"localhost:9092"},
+		KafkaSinkTopic:        "transformed-weather-data",
+		KafkaSinkKey:          config.SinkKeyGeohash,
+		KafkaSinkKeyPrecision: 4,
+	}, slog.Default(), observability.NewMetricsForTesting())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = w.Close() })
+	assert.IsType(t, &kafkago.Hash{}, w.writer.Balancer, "nearby events must share a partition")
+
+	msgs, err := w.messages([]domain.StormEvent{
+		{ID: "evt-1", EventType: "hail", Geo: domain.Geo{Lat: 35.22, Lon: -97.44}},
+		{ID: "evt-2", EventType: "wind", Geo: domain.Geo{Lat: 35.21, Lon: -97.45}, Deleted: true},
+		{ID: "evt-3", EventType: "wind"},
+	})
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+	for i, id := range []string{"evt-1", "evt-2"} {
+		assert.Equal(t, []byte("9y68"), msgs[i].Key)
+		assert.Contains(t, msgs[i].Headers, kafkago.Header{Key: "event_id", Value: []byte(id)})
+	}
+	assert.Equal(t, []byte("evt-3"), msgs[2].Key, "events without coordinates keep their ID as the key")
+}
+
+func TestSignMessage(t *testing.T) {
+	// HMAC-SHA256("secret", `{"id":"evt-1"}`), computed independently.
+	msg := kafkago.Message{Value: []byte(`{"id":"evt-1"}`)}
+	signMessage(&msg, []byte("secret"), "2024-04")
+	assert.Equal(t, []kafkago.Header{
+		{Key: "signature", Value: []byte("sha256=9fafdf780a4974eed4af6b3232450a1826bf0e7e5eb0f485264cf106b8af3574")},
+		{Key: "signature_key_id", Value: []byte("2024-04")},
+	}, msg.Headers)
+}
+
+func TestWriterMessages_Signed(t *testing.T) {
+	w, err := NewWriter(&config.Config{
+		KafkaBrokers:         []string{"localhost:9092"},
+		KafkaSinkTopic:       "transformed-weather-data",
+		SchemaCompatVersions: []int{1},
+		KafkaSinkSigningKey:  "secret",
+	}, slog.Default(), observability.NewMetricsForTesting())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = w.Close() })
+
+	msgs, err := w.messages([]domain.StormEvent{
+		{ID: "evt-1", EventType: "hail"},
+		{ID: "evt-2", EventType: "wind", Deleted: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, msgs, 4)
+	for _, i := range []int{0, 2} {
+		signed := kafkago.Message{Value: msgs[i].Value}
+		signMessage(&signed, []byte("secret"), "")
+		assert.Contains(t, msgs[i].Headers, signed.Headers[0], "each schema version is signed over its own value")
+	}
+	assert.NotEqual(t, msgs[0].Headers[len(msgs[0].Headers)-1], msgs[2].Headers[len(msgs[2].Headers)-1])
+	for _, tombstone := range []kafkago.Message{msgs[1], msgs[3]} {
+		assert.Nil(t, tombstone.Value)
+		for _, h := range tombstone.Headers {
+			assert.NotEqual(t, "signature", h.Key, "tombstones have no value to sign")
+		}
+	}
+}
+
+func TestWriterMessageBytes(t *testing.T) {
+	w, err := NewWriter(&config.Config{
+		KafkaBrokers:         []string{"localhost:9092"},
+		KafkaSinkTopic:       "transformed-weather-data",
+		SchemaCompatVersions: []int{1},
+		KafkaSinkSigningKey:  "secret",
+	}, slog.Default(), observability.NewMetricsForTesting())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = w.Close() })
+
+	event := domain.StormEvent{ID: "evt-1", EventType: "hail", Comments: "Quarter hail."}
+	msgs, err := w.messages([]domain.StormEvent{event})
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	size, err := w.MessageBytes(event)
+	require.NoError(t, err)
+	assert.Equal(t, max(messageSize(msgs[0]), messageSize(msgs[1])), size, "the largest schema version counts")
+	assert.Greater(t, size, len(msgs[0].Value)+len(msgs[0].Key), "headers and framing count")
+
+	event.Comments += strings.Repeat(" Windows broken.", 10)
+	larger, err := w.MessageBytes(event)
+	require.NoError(t, err)
+	assert.Equal(t, size+160, larger)
+}
+
+func TestDeadLetterToMessage(t *testing.T) {
+	failedAt := time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC)
+	dl := domain.DeadLetter{
+		Event: domain.RawEvent{
+			Key:       []byte("key-1"),
+			Value:     []byte("not-json{{{"),
+			Headers:   map[string]string{"source": "noaa"},
+			Topic:     "raw-weather-reports",
+			Partition: 3,
+			Offset:    99,
+		},
+		Reason:   "parse raw event: invalid character",
+		FailedAt: failedAt,
+	}
+
+	msg := deadLetterToMessage(dl)
+
+	assert.Equal(t, []byte("key-1"), msg.Key)
+	assert.Equal(t, []byte("not-json{{{"), msg.Value)
+
+	headers := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	assert.Equal(t, "noaa", headers["source"])
+	assert.Equal(t, "parse raw event: invalid character", headers[headerDLQError])
+	assert.Equal(t, "raw-weather-reports", headers[headerDLQSourceTopic])
+	assert.Equal(t, "3", headers[headerDLQSourcePartition])
+	assert.Equal(t, "99", headers[headerDLQSourceOffset])
+	assert.Equal(t, failedAt.Format(time.RFC3339), headers[headerDLQFailedAt])
+}
+
+func TestDeadLetterRoundTrip(t *testing.T) {
+	failedAt := time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC)
+	msg := deadLetterToMessage(domain.DeadLetter{
+		Event: domain.RawEvent{
+			Key:       []byte("key-1"),
+			Value:     []byte("not-json{{{"),
+			Headers:   map[string]string{"source": "noaa"},
+			Topic:     "raw-weather-reports",
+			Partition: 3,
+			Offset:    99,
+		},
+		Reason:   "parse raw event: invalid character",
+		FailedAt: failedAt,
+	})
+	msg.Partition, msg.Offset = 1, 12
+
+	rec := messageToDeadLetterRecord(msg)
+	assert.Equal(t, domain.DeadLetterPosition{Partition: 1, Offset: 12}, rec.DeadLetterPosition)
+	assert.Equal(t, "key-1", rec.Key)
+	assert.Equal(t, "not-json{{{", rec.Value)
+	assert.Equal(t, "parse raw event: invalid character", rec.Reason)
+	assert.Equal(t, "raw-weather-reports", rec.SourceTopic)
+	assert.Equal(t, 3, rec.SourcePartition)
+	assert.Equal(t, int64(99), rec.SourceOffset)
+	assert.Equal(t, failedAt, rec.FailedAt)
+
+	requeued := requeueMessage(msg, []string{"other-reports", "raw-weather-reports"})
+	assert.Equal(t, "raw-weather-reports", requeued.Topic)
+	assert.Equal(t, []byte("key-1"), requeued.Key)
+	assert.Equal(t, []byte("not-json{{{"), requeued.Value)
+	assert.Equal(t, []kafkago.Header{{Key: "source", Value: []byte("noaa")}}, requeued.Headers, "dead-letter headers are stripped")
+
+	requeued = requeueMessage(msg, []string{"renamed-reports"})
+	assert.Equal(t, "renamed-reports", requeued.Topic, "unknown source topics fall back to the first source topic")
+}
+
+func TestReplayMessage(t *testing.T) {
+	msg := replayMessage(domain.RawEvent{
+		Key:   []byte("key-1"),
+		Value: []byte(`{"EventType":"hail"}`),
+		Headers: map[string]string{
+			"traceparent":     "00-abc-def-01",
+			"source":          "noaa",
+			headerDLQError:    "parse raw event: invalid character",
+			headerDLQFailedAt: "2024-04-26T15:10:00Z",
+		},
+		Topic:     "raw-weather-reports-backup",
+		Offset:    7,
+		Timestamp: time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC),
+	})
+
+	assert.Empty(t, msg.Topic, "the writer sets the target topic")
+	assert.Equal(t, time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC), msg.Time)
+	assert.Equal(t, []byte("key-1"), msg.Key)
+	assert.Equal(t, []byte(`{"EventType":"hail"}`), msg.Value)
+	assert.Equal(t, []kafkago.Header{
+		{Key: "source", Value: []byte("noaa")},
+		{Key: "traceparent", Value: []byte("00-abc-def-01")},
+	}, msg.Headers, "headers are sorted and dead-letter headers dropped")
+}
+
+func TestSerializeToMessage_TraceContext(t *testing.T) {
+	event := domain.StormEvent{
+		ID:        "evt-trace",
+		EventType: "wind",
+		TraceContext: map[string]string{
+			"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"tracestate":  "vendor=value",
+		},
+	}
+
+	msg, err := serializeToMessage(event, config.OutputFormatJSON, nil)
+	require.NoError(t, err)
+
+	require.Len(t, msg.Headers, 4)
+	assert.Equal(t, "traceparent", msg.Headers[2].Key)
+	assert.Equal(t, event.TraceContext["traceparent"], string(msg.Headers[2].Value))
+	assert.Equal(t, "tracestate", msg.Headers[3].Key)
+	assert.NotContains(t, string(msg.Value), "traceparent", "trace context is transport metadata, not payload")
+}
+
+func TestSerializeToMessage_CorrelationID(t *testing.T) {
+	event := domain.StormEvent{ID: "evt-1", EventType: "hail", CorrelationID: "corr-1"}
+
+	msg, err := serializeToMessage(event, config.OutputFormatJSON, nil)
+	require.NoError(t, err)
+	require.Len(t, msg.Headers, 3)
+	assert.Equal(t, kafkago.Header{Key: "correlation_id", Value: []byte("corr-1")}, msg.Headers[2])
+	assert.NotContains(t, string(msg.Value), "corr-1", "the correlation ID is transport metadata, not payload")
+
+	event.Deleted = true
+	assert.Contains(t, tombstoneMessage(event).Headers, kafkago.Header{Key: "correlation_id", Value: []byte("corr-1")})
+}
+
+func TestSerializeToMessage_Provenance(t *testing.T) {
+	event := domain.StormEvent{
+		ID:         "evt-1",
+		EventType:  "hail",
+		Provenance: &domain.Provenance{Headers: map[string]string{"source_file": "250415_rpts_hail.csv", "collector_run_id": "run-42"}},
+	}
+
+	msg, err := serializeToMessage(event, config.OutputFormatJSON, nil)
+	require.NoError(t, err)
+
+	require.Len(t, msg.Headers, 4)
+	assert.Equal(t, kafkago.Header{Key: "collector_run_id", Value: []byte("run-42")}, msg.Headers[2])
+	assert.Equal(t, kafkago.Header{Key: "source_file", Value: []byte("250415_rpts_hail.csv")}, msg.Headers[3])
+	assert.Contains(t, string(msg.Value), `"provenance":{"headers":{"collector_run_id":"run-42","source_file":"250415_rpts_hail.csv"}}`)
+}
+
+func TestSerializeToMessage_Protobuf(t *testing.T) {
+	now := time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC)
+	severity := "severe"
+	event := domain.StormEvent{
+		ID:          "evt-1",
+		EventType:   "hail",
+		Geo:         domain.Geo{Lat: 35.0, Lon: -97.0},
+		Measurement: domain.Measurement{Magnitude: 1.75, Unit: "in", Severity: &severity, Metric: &domain.Quantity{Magnitude: 44.45, Unit: "mm"}},
+		Location:    domain.Location{Raw: "8 ESE Chappel", PlaceGeo: &domain.Geo{Lat: 35.04, Lon: -97.12}},
+		Impact:      &domain.Impact{Injuries: new(int), Damage: []string{"trees down"}},
+		EventTime:   now,
+		ProcessedAt: now,
+
+		SourceOfficeDetail: &domain.SourceOfficeDetail{Code: "OUN", Name: "Norman", State: "OK", Geo: domain.Geo{Lat: 35.18, Lon: -97.44}},
+	}
+
+	msg, err := serializeToMessage(event, config.OutputFormatProtobuf, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte("evt-1"), msg.Key)
+	require.Len(t, msg.Headers, 3)
+	assert.Equal(t, "content_type", msg.Headers[2].Key)
+	assert.Equal(t, contentTypeProtobuf, string(msg.Headers[2].Value))
+
+	assert.Equal(t, stormpb.MarshalStormEvent(event), msg.Value)
+}
+
+func TestSerializeToMessage_FieldProjection(t *testing.T) {
+	fields, err := domain.ParseFieldProjection("-comments")
+	require.NoError(t, err)
+	event := domain.StormEvent{ID: "hail-1", EventType: "hail", Geo: domain.Geo{Lat: 35.22, Lon: -97.44}, Comments: "spotter at 123 Main St"}
+
+	msg, err := serializeToMessage(event, config.OutputFormatJSON, fields)
+	require.NoError(t, err)
+	assert.Contains(t, string(msg.Value), `"id":"hail-1"`)
+	assert.NotContains(t, string(msg.Value), "Main St")
+
+	msg, err = serializeToMessage(event, config.OutputFormatGeoJSON, fields)
+	require.NoError(t, err)
+	assert.Contains(t, string(msg.Value), `"coordinates":[-97.44,35.22]`, "the geometry is not projected")
+	assert.NotContains(t, string(msg.Value), "Main St")
+}
+
+func TestSerializeToMessage_GeoJSON(t *testing.T) {
+	event := domain.StormEvent{
+		ID:        "tornado-1",
+		EventType: "tornado",
+		Geo:       domain.Geo{Lat: 34.96, Lon: -95.77},
+	}
+
+	msg, err := serializeToMessage(event, config.OutputFormatGeoJSON, nil)
+	require.NoError(t, err)
+	require.Len(t, msg.Headers, 3)
+	assert.Equal(t, kafkago.Header{Key: "content_type", Value: []byte(contentTypeGeoJSON)}, msg.Headers[2])
+	assert.Contains(t, string(msg.Value), `"geometry":{"type":"Point","coordinates":[-95.77,34.96]}`)
+	assert.Contains(t, string(msg.Value), `"type":"Feature","id":"tornado-1"`)
+
+	event.PathBegin = &domain.Geo{Lat: 34.93, Lon: -95.8}
+	event.PathEnd = &domain.Geo{Lat: 34.99, Lon: -95.71}
+	msg, err = serializeToMessage(event, config.OutputFormatGeoJSON, nil)
+	require.NoError(t, err)
+
+	var feature struct {
+		Geometry struct {
+			Type        string       `json:"type"`
+			Coordinates [][2]float64 `json:"coordinates"`
+		} `json:"geometry"`
+		Properties domain.StormEvent `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(msg.Value, &feature))
+	assert.Equal(t, "LineString", feature.Geometry.Type)
+	assert.Equal(t, [][2]float64{{-95.8, 34.93}, {-95.71, 34.99}}, feature.Geometry.Coordinates)
+	assert.Equal(t, "tornado-1", feature.Properties.ID)
+	assert.Equal(t, event.PathEnd, feature.Properties.PathEnd)
+}
+
+func TestReaderConfig(t *testing.T) {
+	rc := readerConfig(&config.Config{
+		KafkaBrokers:              []string{"localhost:9092"},
+		KafkaSourceTopics:         []string{"raw-weather-reports"},
+		KafkaGroupID:              "storm-data-etl",
+		KafkaSourceMinBytes:       64 << 10,
+		KafkaSourceMaxBytes:       50 << 20,
+		KafkaSourceMaxWait:        2 * time.Second,
+		KafkaSourceQueueCapacity:  1000,
+		KafkaSourceCommitInterval: time.Second,
+	})
+	assert.Equal(t, "raw-weather-reports", rc.Topic)
+	assert.Empty(t, rc.GroupTopics)
+	assert.Equal(t, 64<<10, rc.MinBytes)
+	assert.Equal(t, 50<<20, rc.MaxBytes)
+	assert.Equal(t, 2*time.Second, rc.MaxWait)
+	assert.Equal(t, 1000, rc.QueueCapacity)
+	assert.Equal(t, time.Second, rc.CommitInterval)
+	require.NoError(t, rc.Validate())
+
+	rc = readerConfig(&config.Config{KafkaBrokers: []string{"localhost:9092"}, KafkaSourceTopics: []string{"a", "b"}, KafkaGroupID: "g"})
+	assert.Empty(t, rc.Topic)
+	assert.Equal(t, []string{"a", "b"}, rc.GroupTopics)
+}
+
+func TestCheckGroupMembership(t *testing.T) {
+	member := kafkago.DescribeGroupsResponseMember{ClientID: "storm-data-etl-a-1"}
+	stable := kafkago.DescribeGroupsResponseGroup{GroupID: "storm-data-etl", GroupState: "Stable", Members: []kafkago.DescribeGroupsResponseMember{member}}
+
+	require.NoError(t, checkGroupMembership(stable, "storm-data-etl-a-1"))
+
+	err := checkGroupMembership(stable, "storm-data-etl-b-1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "has not joined")
+
+	rebalancing := stable
+	rebalancing.GroupState = "PreparingRebalance"
+	err = checkGroupMembership(rebalancing, "storm-data-etl-a-1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "PreparingRebalance")
+}
+
+func TestReader_ObserveGroup(t *testing.T) {
+	metrics := observability.NewMetricsForTesting()
+	r := &Reader{groupID: "storm-data-etl", clientID: "storm-data-etl-a-1", metrics: metrics, logger: slog.Default()}
+	group := func(state string, topics ...kafkago.GroupMemberTopic) kafkago.DescribeGroupsResponseGroup {
+		return kafkago.DescribeGroupsResponseGroup{GroupID: "storm-data-etl", GroupState: state, Members: []kafkago.DescribeGroupsResponseMember{
+			{ClientID: "storm-data-etl-a-1", MemberAssignments: kafkago.DescribeGroupsResponseAssignments{Topics: topics}},
+			{ClientID: "storm-data-etl-b-1", MemberAssignments: kafkago.DescribeGroupsResponseAssignments{Topics: []kafkago.GroupMemberTopic{{Topic: "raw", Partitions: []int{4, 5}}}}},
+		}}
+	}
+	gauge := func(g prometheus.Gauge) float64 {
+		var m dto.Metric
+		require.NoError(t, g.Write(&m))
+		return m.GetGauge().GetValue()
+	}
+
+	r.observeGroup(group("Stable", kafkago.GroupMemberTopic{Topic: "raw", Partitions: []int{2, 0, 1}}, kafkago.GroupMemberTopic{Topic: "spc", Partitions: []int{0}}))
+	assert.Equal(t, assignment{"raw": {0, 1, 2}, "spc": {0}}, r.assigned)
+	assert.InDelta(t, 3, gauge(metrics.KafkaAssignedPartitions.WithLabelValues("raw")), 0)
+	assert.InDelta(t, 1, gauge(metrics.KafkaAssignedPartitions.WithLabelValues("spc")), 0)
+
+	r.observeGroup(group("PreparingRebalance"))
+	wait := r.rebalanceWait()
+	require.NotNil(t, wait)
+	assert.InDelta(t, 1, gauge(metrics.KafkaRebalancing), 0)
+
+	r.observeGroup(group("Stable", kafkago.GroupMemberTopic{Topic: "raw", Partitions: []int{1, 3}}))
+	assert.Nil(t, r.rebalanceWait())
+	select {
+	case <-wait:
+	default:
+		t.Fatal("waiting extractions were not released")
+	}
+	assert.InDelta(t, 0, gauge(metrics.KafkaRebalancing), 0)
+	assert.Equal(t, assignment{"raw": {1, 3}}, r.assigned)
+	assert.InDelta(t, 2, gauge(metrics.KafkaAssignedPartitions.WithLabelValues("raw")), 0)
+	assert.InDelta(t, 0, gauge(metrics.KafkaAssignedPartitions.WithLabelValues("spc")), 0)
+}
+
+func TestDiffAssignment(t *testing.T) {
+	gained, lost := diffAssignment(assignment{"raw": {0, 1, 2}, "spc": {0}}, assignment{"raw": {1, 3}})
+	assert.Equal(t, assignment{"raw": {3}}, gained)
+	assert.Equal(t, assignment{"raw": {0, 2}, "spc": {0}}, lost)
+
+	gained, lost = diffAssignment(nil, assignment{"raw": {0}})
+	assert.Equal(t, assignment{"raw": {0}}, gained)
+	assert.Empty(t, lost)
+}
+
+func TestExtractBatch_WaitsDuringRebalance(t *testing.T) {
+	r := &Reader{groupID: "storm-data-etl", flushInterval: 50 * time.Millisecond, metrics: observability.NewMetricsForTesting(), logger: slog.Default()}
+	r.setRebalancing(true)
+
+	start := time.Now()
+	batch, err := r.ExtractBatch(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, batch, "no messages are fetched while the group rebalances")
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestSASLMechanism(t *testing.T) {
+	cases := []struct {
+		mechanism string
+		expected  string
+	}{
+		{config.SASLMechanismPlain, "PLAIN"},
+		{config.SASLMechanismSCRAMSHA256, "SCRAM-SHA-256"},
+		{config.SASLMechanismSCRAMSHA512, "SCRAM-SHA-512"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.mechanism, func(t *testing.T) {
+			m, err := saslMechanism(&config.Config{
+				KafkaSASLMechanism: tc.mechanism,
+				KafkaSASLUsername:  "etl",
+				KafkaSASLPassword:  "secret",
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, m.Name())
+		})
+	}
+
+	m, err := saslMechanism(&config.Config{})
+	require.NoError(t, err)
+	assert.Nil(t, m)
+}
+
+func TestNewTransport_DefaultsToNil(t *testing.T) {
+	transport, err := newTransport(&config.Config{})
+	require.NoError(t, err)
+	assert.Nil(t, transport, "plaintext config should use the kafka-go default transport")
+}
+
+func TestTLSConfig(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		tlsCfg, err := tlsConfig(&config.Config{})
+		require.NoError(t, err)
+		assert.Nil(t, tlsCfg)
+	})
+
+	t.Run("custom CA and skip verify", func(t *testing.T) {
+		caFile := writeTestCA(t)
+		tlsCfg, err := tlsConfig(&config.Config{
+			KafkaTLSEnabled:            true,
+			KafkaTLSCAFile:             caFile,
+			KafkaTLSInsecureSkipVerify: true,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, tlsCfg.RootCAs)
+		assert.True(t, tlsCfg.InsecureSkipVerify)
+	})
+
+	t.Run("missing CA file", func(t *testing.T) {
+		_, err := tlsConfig(&config.Config{
+			KafkaTLSEnabled: true,
+			KafkaTLSCAFile:  filepath.Join(t.TempDir(), "missing.pem"),
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CA file")
+	})
+
+	t.Run("CA file without certificates", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "empty.pem")
+		require.NoError(t, os.WriteFile(path, []byte("not a certificate"), 0o600))
+		_, err := tlsConfig(&config.Config{KafkaTLSEnabled: true, KafkaTLSCAFile: path})
+		require.Error(t, err)
+	})
+}
+
+// writeTestCA generates a self-signed CA certificate and writes it as PEM.
+func writeTestCA(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	return path
+}
+
+func BenchmarkSerializeToMessage(b *testing.B) {
+	events := stormtest.SampleStormEvents()
+	b.ReportAllocs()
+	for i := 0; b.Loop(); i++ {
+		if _, err := serializeToMessage(events[i%len(events)], config.OutputFormatJSON, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// partitionReadTimeout bounds a single partition read when the caller's
+// context has no deadline.
+const partitionReadTimeout = 10 * time.Second
+
+// partitionLog reads a topic's partitions directly, without a consumer group,
+// so reading never moves any committed offsets.
+type partitionLog struct {
+	dialer  *kafkago.Dialer
+	brokers []string
+	topic   string
+}
+
+// partitions lists the topic's partition IDs.
+func (l *partitionLog) partitions(ctx context.Context) ([]int, error) {
+	var errs []error
+	for _, broker := range l.brokers {
+		parts, err := l.dialer.LookupPartitions(ctx, "tcp", broker, l.topic)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ids := make([]int, len(parts))
+		for i, p := range parts {
+			ids[i] = p.ID
+		}
+		slices.Sort(ids)
+		return ids, nil
+	}
+	return nil, fmt.Errorf("lookup %s partitions: %w", l.topic, errors.Join(errs...))
+}
+
+// bounds returns the first offset and the high watermark of a partition.
+func (l *partitionLog) bounds(ctx context.Context, partition int) (first, last int64, err error) {
+	conn, err := l.dialLeader(ctx, partition)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() { _ = conn.Close() }()
+	return conn.ReadOffsets()
+}
+
+// readTail reads the last n messages of a partition.
+func (l *partitionLog) readTail(ctx context.Context, partition, n int) ([]kafkago.Message, error) {
+	first, last, err := l.bounds(ctx, partition)
+	if err != nil {
+		return nil, err
+	}
+	return l.readRange(ctx, partition, max(first, last-int64(n)), last)
+}
+
+// readRange reads messages with offsets in [from, to) from a partition. It
+// returns at most one fetch worth of messages, so callers reading large
+// ranges continue from the last offset returned. Offsets missing from the log
+// (retention or compaction) are skipped.
+func (l *partitionLog) readRange(ctx context.Context, partition int, from, to int64) ([]kafkago.Message, error) {
+	if from >= to {
+		return nil, nil
+	}
+	conn, err := l.dialLeader(ctx, partition)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(partitionReadTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Seek(from, kafkago.SeekAbsolute); err != nil {
+		return nil, err
+	}
+
+	var msgs []kafkago.Message
+	batch := conn.ReadBatch(1, 10e6) // 10 MB
+	defer func() { _ = batch.Close() }()
+	for {
+		msg, err := batch.ReadMessage()
+		if err != nil {
+			if len(msgs) > 0 || errors.Is(err, kafkago.OffsetOutOfRange) {
+				return msgs, nil
+			}
+			return nil, err
+		}
+		if msg.Offset >= to {
+			return msgs, nil
+		}
+		msgs = append(msgs, msg)
+		if msg.Offset == to-1 {
+			return msgs, nil
+		}
+	}
+}
+
+func (l *partitionLog) dialLeader(ctx context.Context, partition int) (*kafkago.Conn, error) {
+	var errs []error
+	for _, broker := range l.brokers {
+		conn, err := l.dialer.DialLeader(ctx, "tcp", broker, l.topic, partition)
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
@@ -2,11 +2,15 @@ package kafka
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/couchcryptid/storm-data-etl/internal/config"
 	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
 	kafkago "github.com/segmentio/kafka-go"
 )
 
@@ -14,26 +18,99 @@ import (
 // It implements pipeline.BatchExtractor.
 type Reader struct {
 	reader        *kafkago.Reader
+	client        *kafkago.Client
+	groupID       string
+	clientID      string
 	flushInterval time.Duration
+	metrics       *observability.Metrics
 	logger        *slog.Logger
+
+	// Consumer group assignment, maintained by watchAssignment. rebalanced
+	// is non-nil while the group is rebalancing.
+	mu         sync.Mutex
+	assigned   assignment
+	rebalanced chan struct{}
+	stopWatch  context.CancelFunc
+	watchDone  chan struct{}
+}
+
+// NewReader creates a Kafka consumer for the configured source topics and group.
+// With more than one source topic the consumer group subscribes to all of them
+// and messages from every topic are merged into the same batches.
+func NewReader(cfg *config.Config, logger *slog.Logger, metrics *observability.Metrics) (*Reader, error) {
+	dialer, err := newDialer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// A per-process client ID lets CheckReadiness find this consumer among
+	// the group's members.
+	dialer.ClientID = consumerClientID()
+	rc := readerConfig(cfg)
+	rc.Dialer = dialer
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	r := &Reader{
+		reader:        kafkago.NewReader(rc),
+		client:        client,
+		groupID:       cfg.KafkaGroupID,
+		clientID:      dialer.ClientID,
+		flushInterval: cfg.BatchFlushInterval,
+		metrics:       metrics,
+		logger:        logger,
+		stopWatch:     stopWatch,
+		watchDone:     make(chan struct{}),
+	}
+	go r.watchAssignment(watchCtx)
+	return r, nil
+}
+
+// readerConfig builds the consumer group settings. Zero fetch settings fall
+// back to the kafka-go defaults; a zero commit interval commits synchronously.
+func readerConfig(cfg *config.Config) kafkago.ReaderConfig {
+	rc := kafkago.ReaderConfig{
+		Brokers:        cfg.KafkaBrokers,
+		GroupID:        cfg.KafkaGroupID,
+		StartOffset:    kafkago.FirstOffset,
+		MinBytes:       cfg.KafkaSourceMinBytes,
+		MaxBytes:       cfg.KafkaSourceMaxBytes,
+		MaxWait:        cfg.KafkaSourceMaxWait,
+		QueueCapacity:  cfg.KafkaSourceQueueCapacity,
+		CommitInterval: cfg.KafkaSourceCommitInterval,
+	}
+	if len(cfg.KafkaSourceTopics) == 1 {
+		rc.Topic = cfg.KafkaSourceTopics[0]
+	} else {
+		rc.GroupTopics = cfg.KafkaSourceTopics
+	}
+	return rc
 }
 
-// NewReader creates a Kafka consumer for the configured source topic and group.
-func NewReader(cfg *config.Config, logger *slog.Logger) *Reader {
-	r := kafkago.NewReader(kafkago.ReaderConfig{
-		Brokers:     cfg.KafkaBrokers,
-		Topic:       cfg.KafkaSourceTopic,
-		GroupID:     cfg.KafkaGroupID,
-		StartOffset: kafkago.FirstOffset,
-		MinBytes:    1,
-		MaxBytes:    10e6, // 10 MB
-	})
-	return &Reader{reader: r, flushInterval: cfg.BatchFlushInterval, logger: logger}
+// ComponentName names the reader in the pipeline health report.
+func (r *Reader) ComponentName() string { return "kafka_reader" }
+
+// CheckReadiness verifies that the brokers are reachable and that this
+// consumer is a member of its group after a completed partition assignment.
+// It implements pipeline.ReadinessChecker.
+func (r *Reader) CheckReadiness(ctx context.Context) error {
+	resp, err := r.client.DescribeGroups(ctx, &kafkago.DescribeGroupsRequest{GroupIDs: []string{r.groupID}})
+	if err != nil {
+		return fmt.Errorf("kafka brokers unreachable: %w", err)
+	}
+	if len(resp.Groups) == 0 {
+		return fmt.Errorf("consumer group %s not found", r.groupID)
+	}
+	return checkGroupMembership(resp.Groups[0], r.clientID)
 }
 
 // ExtractBatch fetches up to batchSize messages from Kafka.
 // Each returned RawEvent includes a Commit callback for at-least-once delivery.
 // Returns a partial batch when the flush interval elapses or the context is cancelled.
+// While the consumer group rebalances no messages are fetched: a partial batch
+// is returned at once so its offsets are committed before partitions move, and
+// an empty call waits for the rebalance to end, up to the flush interval.
 func (r *Reader) ExtractBatch(ctx context.Context, batchSize int) ([]domain.RawEvent, error) {
 	batch := make([]domain.RawEvent, 0, batchSize)
 	deadline := time.Now().Add(r.flushInterval)
@@ -43,6 +120,12 @@ func (r *Reader) ExtractBatch(ctx context.Context, batchSize int) ([]domain.RawE
 		if timeout <= 0 {
 			break
 		}
+		if wait := r.rebalanceWait(); wait != nil {
+			if len(batch) > 0 || !waitForRebalance(ctx, wait, timeout) {
+				break
+			}
+			continue
+		}
 
 		fetchCtx, cancel := context.WithTimeout(ctx, timeout)
 		msg, err := r.reader.FetchMessage(fetchCtx)
@@ -61,6 +144,7 @@ func (r *Reader) ExtractBatch(ctx context.Context, batchSize int) ([]domain.RawE
 			return nil, err
 		}
 
+		r.metrics.RawMessageBytes.Observe(float64(len(msg.Value)))
 		raw := mapMessageToRawEvent(msg)
 		raw.Commit = func(commitCtx context.Context) error {
 			return r.reader.CommitMessages(commitCtx, msg)
@@ -71,10 +155,56 @@ func (r *Reader) ExtractBatch(ctx context.Context, batchSize int) ([]domain.RawE
 	return batch, nil
 }
 
+// waitForRebalance waits for wait to close. Returns false if ctx is cancelled
+// or timeout elapses first.
+func waitForRebalance(ctx context.Context, wait <-chan struct{}, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-wait:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return false
+	}
+}
+
 func (r *Reader) Close() error {
+	r.stopWatch()
+	<-r.watchDone
 	return r.reader.Close()
 }
 
+// checkGroupMembership reports whether the member with clientID has joined a
+// group whose partition assignment is complete. A member may hold no
+// partitions when the group has more consumers than partitions; it is still
+// ready to take over partitions on the next rebalance.
+func checkGroupMembership(group kafkago.DescribeGroupsResponseGroup, clientID string) error {
+	if group.Error != nil {
+		return fmt.Errorf("describe consumer group %s: %w", group.GroupID, group.Error)
+	}
+	if group.GroupState != "Stable" {
+		return fmt.Errorf("consumer group %s is %s, waiting for partition assignment", group.GroupID, group.GroupState)
+	}
+	for _, m := range group.Members {
+		if m.ClientID == clientID {
+			return nil
+		}
+	}
+	return fmt.Errorf("consumer has not joined group %s", group.GroupID)
+}
+
+// consumerClientID identifies this process to the brokers, e.g.
+// "storm-data-etl-etl-7d9f-4120".
+func consumerClientID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("storm-data-etl-%s-%d", host, os.Getpid())
+}
+
 func mapMessageToRawEvent(msg kafkago.Message) domain.RawEvent {
 	headers := make(map[string]string, len(msg.Headers))
 	for _, h := range msg.Headers {
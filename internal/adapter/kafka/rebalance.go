@@ -0,0 +1,145 @@
+package kafka
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// assignmentCheckInterval is how often the reader describes its consumer
+// group. kafka-go does not expose rebalance callbacks, so rebalances and
+// assignment changes are observed by polling.
+const assignmentCheckInterval = 2 * time.Second
+
+// assignment maps source topics to this consumer's sorted partitions.
+type assignment map[string][]int
+
+// watchAssignment describes the consumer group every assignmentCheckInterval
+// until ctx is cancelled.
+func (r *Reader) watchAssignment(ctx context.Context) {
+	defer close(r.watchDone)
+	ticker := time.NewTicker(assignmentCheckInterval)
+	defer ticker.Stop()
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, assignmentCheckInterval)
+		resp, err := r.client.DescribeGroups(checkCtx, &kafkago.DescribeGroupsRequest{GroupIDs: []string{r.groupID}})
+		cancel()
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err == nil && len(resp.Groups) > 0 && resp.Groups[0].Error == nil:
+			r.observeGroup(resp.Groups[0])
+		default:
+			// Without a group description extraction is not held back;
+			// CheckReadiness reports the broker problem.
+			r.setRebalancing(false)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// observeGroup applies a group description: while the group rebalances,
+// extraction waits; once it is stable, this consumer's partitions are
+// compared with the previous assignment and gains and losses are logged.
+func (r *Reader) observeGroup(group kafkago.DescribeGroupsResponseGroup) {
+	switch group.GroupState {
+	case "PreparingRebalance", "CompletingRebalance":
+		r.setRebalancing(true)
+		return
+	case "Stable":
+	default:
+		// Empty or Dead: this consumer has not joined yet or is leaving.
+		r.setRebalancing(false)
+		return
+	}
+
+	current := memberAssignment(group, r.clientID)
+	r.mu.Lock()
+	previous := r.assigned
+	r.assigned = current
+	r.mu.Unlock()
+
+	gained, lost := diffAssignment(previous, current)
+	for _, topic := range slices.Sorted(maps.Keys(gained)) {
+		r.logger.Info("kafka partitions assigned", "topic", topic, "partitions", gained[topic])
+	}
+	for _, topic := range slices.Sorted(maps.Keys(lost)) {
+		r.logger.Info("kafka partitions revoked", "topic", topic, "partitions", lost[topic])
+	}
+	for topic := range previous {
+		if _, ok := current[topic]; !ok {
+			r.metrics.KafkaAssignedPartitions.WithLabelValues(topic).Set(0)
+		}
+	}
+	for topic, partitions := range current {
+		r.metrics.KafkaAssignedPartitions.WithLabelValues(topic).Set(float64(len(partitions)))
+	}
+	r.setRebalancing(false)
+}
+
+// setRebalancing records whether the group is rebalancing. Ending a
+// rebalance releases ExtractBatch calls waiting in rebalanceWait.
+func (r *Reader) setRebalancing(rebalancing bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch {
+	case rebalancing && r.rebalanced == nil:
+		r.rebalanced = make(chan struct{})
+		r.metrics.KafkaRebalancing.Set(1)
+		r.logger.Info("kafka consumer group rebalancing, pausing extraction", "group", r.groupID)
+	case !rebalancing && r.rebalanced != nil:
+		close(r.rebalanced)
+		r.rebalanced = nil
+		r.metrics.KafkaRebalancing.Set(0)
+		r.logger.Info("kafka consumer group rebalance complete, resuming extraction", "group", r.groupID)
+	}
+}
+
+// rebalanceWait returns a channel closed when the current rebalance ends, or
+// nil when the group is not rebalancing.
+func (r *Reader) rebalanceWait() <-chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rebalanced
+}
+
+// memberAssignment returns the partitions assigned to the member with
+// clientID, empty when it is not a member.
+func memberAssignment(group kafkago.DescribeGroupsResponseGroup, clientID string) assignment {
+	a := make(assignment)
+	for _, m := range group.Members {
+		if m.ClientID != clientID {
+			continue
+		}
+		for _, t := range m.MemberAssignments.Topics {
+			if len(t.Partitions) > 0 {
+				a[t.Topic] = slices.Sorted(slices.Values(t.Partitions))
+			}
+		}
+	}
+	return a
+}
+
+// diffAssignment returns the partitions in current but not previous (gained)
+// and in previous but not current (lost), omitting topics without changes.
+func diffAssignment(previous, current assignment) (gained, lost assignment) {
+	subtract := func(a, b assignment) assignment {
+		diff := make(assignment)
+		for topic, partitions := range a {
+			for _, p := range partitions {
+				if !slices.Contains(b[topic], p) {
+					diff[topic] = append(diff[topic], p)
+				}
+			}
+		}
+		return diff
+	}
+	return subtract(current, previous), subtract(previous, current)
+}
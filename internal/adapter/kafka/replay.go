@@ -0,0 +1,124 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// Replayer copies raw messages back onto a pipeline source topic for
+// disaster-recovery reprocessing. It reads topics partition by partition
+// without a consumer group and publishes each message with its original key,
+// value, timestamp, and headers.
+type Replayer struct {
+	dialer  *kafkago.Dialer
+	brokers []string
+	writer  *kafkago.Writer
+}
+
+// NewReplayer creates a Replayer that publishes to the target topic.
+func NewReplayer(cfg *config.Config, target string) (*Replayer, error) {
+	dialer, err := newDialer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := newTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	w := &kafkago.Writer{
+		Addr:         kafkago.TCP(cfg.KafkaBrokers...),
+		Transport:    transport,
+		Topic:        target,
+		Balancer:     &kafkago.Hash{},
+		RequiredAcks: kafkago.RequireAll,
+		// Publish is called with whole batches; don't wait for more messages.
+		BatchTimeout: 10 * time.Millisecond,
+	}
+	return &Replayer{dialer: dialer, brokers: cfg.KafkaBrokers, writer: w}, nil
+}
+
+// Scan calls fn for every message of topic with an offset in [from, to), in
+// offset order one partition at a time. A negative partition scans all of
+// them; a negative from starts at the earliest retained offset and a negative
+// to stops at the high watermark when the partition is opened. Scanning stops
+// at the first error returned by fn.
+func (r *Replayer) Scan(ctx context.Context, topic string, partition int, from, to int64, fn func(domain.RawEvent) error) error {
+	log := partitionLog{dialer: r.dialer, brokers: r.brokers, topic: topic}
+	partitions := []int{partition}
+	if partition < 0 {
+		var err error
+		if partitions, err = log.partitions(ctx); err != nil {
+			return err
+		}
+	}
+	for _, p := range partitions {
+		first, last, err := log.bounds(ctx, p)
+		if err != nil {
+			return fmt.Errorf("read %s/%d offsets: %w", topic, p, err)
+		}
+		start, end := max(from, first), last
+		if to >= 0 {
+			end = min(to, last)
+		}
+		for start < end {
+			msgs, err := log.readRange(ctx, p, start, end)
+			if err != nil {
+				return fmt.Errorf("read %s/%d at offset %d: %w", topic, p, start, err)
+			}
+			if len(msgs) == 0 {
+				break
+			}
+			for _, msg := range msgs {
+				if err := fn(mapMessageToRawEvent(msg)); err != nil {
+					return err
+				}
+			}
+			start = msgs[len(msgs)-1].Offset + 1
+		}
+	}
+	return nil
+}
+
+// Publish writes the events to the target topic.
+func (r *Replayer) Publish(ctx context.Context, events []domain.RawEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	msgs := make([]kafkago.Message, len(events))
+	for i := range events {
+		msgs[i] = replayMessage(events[i])
+	}
+	return r.writer.WriteMessages(ctx, msgs...)
+}
+
+func (r *Replayer) Close() error {
+	return r.writer.Close()
+}
+
+// replayMessage restores a raw event's key, value, timestamp, and headers.
+// The timestamp anchors the bare HHMM report times when the service parses
+// the message again. Dead-letter headers are dropped so a replayed dead
+// letter looks like a fresh report.
+func replayMessage(raw domain.RawEvent) kafkago.Message {
+	headers := make([]kafkago.Header, 0, len(raw.Headers))
+	for _, k := range slices.Sorted(maps.Keys(raw.Headers)) {
+		if strings.HasPrefix(k, "dlq_") {
+			continue
+		}
+		headers = append(headers, kafkago.Header{Key: k, Value: []byte(raw.Headers[k])})
+	}
+	return kafkago.Message{
+		Key:     raw.Key,
+		Value:   raw.Value,
+		Headers: headers,
+		Time:    raw.Timestamp,
+	}
+}
@@ -0,0 +1,51 @@
+package kafka
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// headerSchemaVersion carries the payload's schema version so consumers can
+// route or reject messages without decoding them. Version 1 messages omit it.
+const headerSchemaVersion = "schema_version"
+
+// asSchemaVersion rewrites an enriched event into the shape of an older schema
+// version, so consumers that have not migrated yet can keep reading while new
+// consumers move to the current version. Each case strips what its successor
+// added; chain them as versions accumulate.
+func asSchemaVersion(e domain.StormEvent, version int) (domain.StormEvent, error) {
+	switch version {
+	case domain.SchemaVersion:
+		e.SchemaVersion = domain.SchemaVersion
+		return e, nil
+	case 1:
+		e.SchemaVersion = 0
+		e.Measurement.Metric = nil
+		e.Measurement.Method = ""
+		e.Location.PlaceGeo = nil
+		e.Location.CWA, e.Location.Zone = "", ""
+		e.Impact = nil
+		e.SourceOfficeDetail = nil
+		e.Provenance = nil
+		e.EndTime = time.Time{}
+		e.PathBegin, e.PathEnd = nil, nil
+		e.Quality = nil
+		e.MergedFrom = nil
+		e.EpisodeID = ""
+		e.NearestCity = nil
+		e.NearbyPlaces = nil
+		e.WarningActive, e.WarningIDs = nil, nil
+		e.Geohash = ""
+		return e, nil
+	default:
+		return domain.StormEvent{}, fmt.Errorf("unsupported schema version %d", version)
+	}
+}
+
+// versionedTopic is the sink topic for a compatibility schema version, e.g.
+// "transformed-weather-data.v1".
+func versionedTopic(topic string, version int) string {
+	return fmt.Sprintf("%s.v%d", topic, version)
+}
@@ -0,0 +1,124 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// newDialer builds the connection dialer used by Readers. It mirrors
+// kafka-go's default dialer and adds SASL and TLS when configured.
+func newDialer(cfg *config.Config) (*kafkago.Dialer, error) {
+	mechanism, tlsCfg, err := securityOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &kafkago.Dialer{
+		Timeout:       10 * time.Second,
+		DualStack:     true,
+		SASLMechanism: mechanism,
+		TLS:           tlsCfg,
+	}, nil
+}
+
+// newTransport builds the transport used by Writers. Returns a nil interface
+// (kafka-go's default transport) when neither SASL nor TLS is configured.
+func newTransport(cfg *config.Config) (kafkago.RoundTripper, error) {
+	mechanism, tlsCfg, err := securityOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if mechanism == nil && tlsCfg == nil {
+		return nil, nil
+	}
+	return &kafkago.Transport{
+		SASL: mechanism,
+		TLS:  tlsCfg,
+	}, nil
+}
+
+// newClient builds the admin client used for readiness checks, sharing the
+// SASL and TLS settings of the writers.
+func newClient(cfg *config.Config) (*kafkago.Client, error) {
+	transport, err := newTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &kafkago.Client{
+		Addr:      kafkago.TCP(cfg.KafkaBrokers...),
+		Transport: transport,
+		Timeout:   5 * time.Second,
+	}, nil
+}
+
+func securityOptions(cfg *config.Config) (sasl.Mechanism, *tls.Config, error) {
+	mechanism, err := saslMechanism(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	tlsCfg, err := tlsConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return mechanism, tlsCfg, nil
+}
+
+// saslMechanism returns the configured SASL mechanism, or nil when SASL is disabled.
+func saslMechanism(cfg *config.Config) (sasl.Mechanism, error) {
+	switch cfg.KafkaSASLMechanism {
+	case "":
+		return nil, nil
+	case config.SASLMechanismPlain:
+		return plain.Mechanism{Username: cfg.KafkaSASLUsername, Password: cfg.KafkaSASLPassword}, nil
+	case config.SASLMechanismSCRAMSHA256:
+		return scram.Mechanism(scram.SHA256, cfg.KafkaSASLUsername, cfg.KafkaSASLPassword)
+	case config.SASLMechanismSCRAMSHA512:
+		return scram.Mechanism(scram.SHA512, cfg.KafkaSASLUsername, cfg.KafkaSASLPassword)
+	default:
+		return nil, fmt.Errorf("unsupported SASL mechanism %q", cfg.KafkaSASLMechanism)
+	}
+}
+
+// tlsConfig returns the client TLS configuration, or nil when TLS is disabled.
+// The system root pool is used unless a CA file is provided.
+func tlsConfig(cfg *config.Config) (*tls.Config, error) {
+	if !cfg.KafkaTLSEnabled {
+		return nil, nil
+	}
+
+	tlsCfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.KafkaTLSInsecureSkipVerify, //nolint:gosec // opt-in for test clusters with self-signed certs
+	}
+
+	if cfg.KafkaTLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.KafkaTLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read kafka CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("kafka CA file contains no valid certificates")
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.KafkaTLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.KafkaTLSCertFile, cfg.KafkaTLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load kafka client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsCfg, nil
+}
@@ -2,67 +2,372 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/couchcryptid/storm-data-etl/internal/config"
 	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	"github.com/couchcryptid/storm-data-etl/internal/stormpb"
 	kafkago "github.com/segmentio/kafka-go"
 )
 
+// contentTypeProtobuf identifies storm.v1.StormEvent protobuf payloads.
+const contentTypeProtobuf = "application/x-protobuf; messageType=storm.v1.StormEvent"
+
+// sinkTarget is a topic and the schema version written to it.
+type sinkTarget struct {
+	topic   string
+	version int
+}
+
 // Writer produces messages to a Kafka topic.
 // It implements pipeline.BatchLoader.
 type Writer struct {
-	writer *kafkago.Writer
-	logger *slog.Logger
+	writer       *kafkago.Writer
+	client       *kafkago.Client
+	format       string
+	fields       *domain.FieldProjection
+	targets      []sinkTarget
+	keyPrecision int // geohash key length; 0 keys by event ID
+	signingKey   []byte
+	signingKeyID string
+	metrics      *observability.Metrics
+	logger       *slog.Logger
 }
 
-// NewWriter creates a Kafka producer for the configured sink topic.
-func NewWriter(cfg *config.Config, logger *slog.Logger) *Writer {
+// NewWriter creates a Kafka producer for the configured sink topic. The sink
+// topic receives SCHEMA_VERSION; each of SCHEMA_COMPAT_VERSIONS is written to
+// its own "<sink topic>.v<N>" topic so consumers can migrate independently.
+func NewWriter(cfg *config.Config, logger *slog.Logger, metrics *observability.Metrics) (*Writer, error) {
+	transport, err := newTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// Topic is set per message so one writer can serve every schema version.
+	// Zero batching settings fall back to the kafka-go defaults. Ordered
+	// processing and geohash keys hash the message key so each key stays on
+	// one partition.
+	var balancer kafkago.Balancer = &kafkago.LeastBytes{}
+	keyPrecision := 0
+	if cfg.KafkaSinkKey == config.SinkKeyGeohash {
+		keyPrecision = cfg.KafkaSinkKeyPrecision
+	}
+	if cfg.OrderedProcessing || keyPrecision > 0 {
+		balancer = &kafkago.Hash{}
+	}
 	w := &kafkago.Writer{
 		Addr:         kafkago.TCP(cfg.KafkaBrokers...),
-		Topic:        cfg.KafkaSinkTopic,
-		Balancer:     &kafkago.LeastBytes{},
+		Transport:    transport,
+		Balancer:     balancer,
 		RequiredAcks: kafkago.RequireAll,
+		Compression:  compressionCodec(cfg.KafkaSinkCompression),
+		BatchSize:    cfg.KafkaSinkBatchSize,
+		BatchBytes:   int64(cfg.KafkaSinkBatchBytes),
+		BatchTimeout: cfg.KafkaSinkBatchTimeout,
+		MaxAttempts:  cfg.KafkaSinkMaxAttempts,
+		Async:        cfg.KafkaSinkAsync,
+	}
+	if cfg.KafkaSinkAsync {
+		// LoadBatch no longer sees delivery errors, so surface them here.
+		w.Completion = func(messages []kafkago.Message, err error) {
+			if err == nil {
+				return
+			}
+			metrics.SinkAsyncErrors.Add(float64(len(messages)))
+			logger.Error("async sink write failed", "error", err, "messages", len(messages))
+		}
+	}
+	version := cfg.SchemaVersion
+	if version == 0 {
+		version = domain.SchemaVersion
+	}
+	targets := []sinkTarget{{topic: cfg.KafkaSinkTopic, version: version}}
+	for _, v := range cfg.SchemaCompatVersions {
+		targets = append(targets, sinkTarget{topic: versionedTopic(cfg.KafkaSinkTopic, v), version: v})
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{writer: w, client: client, format: cfg.OutputFormat, fields: cfg.KafkaSinkFields, targets: targets, keyPrecision: keyPrecision, signingKey: []byte(cfg.KafkaSinkSigningKey), signingKeyID: cfg.KafkaSinkSigningKeyID, metrics: metrics, logger: logger}, nil
+}
+
+// compressionCodec maps a KAFKA_SINK_COMPRESSION value to the kafka-go codec.
+// Unset and "none" leave messages uncompressed.
+func compressionCodec(name string) kafkago.Compression {
+	switch name {
+	case config.CompressionGzip:
+		return kafkago.Gzip
+	case config.CompressionSnappy:
+		return kafkago.Snappy
+	case config.CompressionLZ4:
+		return kafkago.Lz4
+	case config.CompressionZstd:
+		return kafkago.Zstd
+	default:
+		return 0
 	}
-	return &Writer{writer: w, logger: logger}
+}
+
+// ComponentName names the writer in the pipeline health report.
+func (w *Writer) ComponentName() string { return "kafka_writer" }
+
+// CheckReadiness verifies that the brokers are reachable and serve every sink
+// topic. It implements pipeline.ReadinessChecker.
+func (w *Writer) CheckReadiness(ctx context.Context) error {
+	topics := make([]string, len(w.targets))
+	for i, t := range w.targets {
+		topics[i] = t.topic
+	}
+	resp, err := w.client.Metadata(ctx, &kafkago.MetadataRequest{Topics: topics})
+	if err != nil {
+		return fmt.Errorf("kafka brokers unreachable: %w", err)
+	}
+	for _, t := range resp.Topics {
+		if t.Error != nil {
+			return fmt.Errorf("sink topic %s: %w", t.Name, t.Error)
+		}
+	}
+	return nil
 }
 
 // LoadBatch serializes and publishes multiple storm events to the sink Kafka
-// topic in a single WriteMessages call for efficiency.
+// topics in a single WriteMessages call for efficiency. Each event is written
+// once per configured schema version.
 func (w *Writer) LoadBatch(ctx context.Context, events []domain.StormEvent) error {
 	if len(events) == 0 {
 		return nil
 	}
-	msgs := make([]kafkago.Message, len(events))
-	for i := range events {
-		msg, err := serializeToMessage(events[i])
+	msgs, err := w.messages(events)
+	if err != nil {
+		return err
+	}
+	return w.writer.WriteMessages(ctx, msgs...)
+}
+
+// messages serializes the events once per sink target, signs them when a
+// signing key is configured, and records each serialized size. Deleted events
+// become unsigned tombstones on every target.
+func (w *Writer) messages(events []domain.StormEvent) ([]kafkago.Message, error) {
+	msgs := make([]kafkago.Message, 0, len(events)*len(w.targets))
+	for _, target := range w.targets {
+		for i := range events {
+			msg, err := w.message(events[i], target)
+			if err != nil {
+				return nil, err
+			}
+			if !events[i].Deleted {
+				w.metrics.EventMessageBytes.Observe(float64(len(msg.Value)))
+			}
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, nil
+}
+
+// message builds the message for one event and sink target.
+func (w *Writer) message(event domain.StormEvent, target sinkTarget) (kafkago.Message, error) {
+	keyed := w.keyed(event)
+	if keyed.Deleted {
+		msg := tombstoneMessage(keyed)
+		msg.Topic = target.topic
+		return msg, nil
+	}
+	versioned, err := asSchemaVersion(keyed, target.version)
+	if err != nil {
+		return kafkago.Message{}, err
+	}
+	msg, err := serializeToMessage(versioned, w.format, w.fields)
+	if err != nil {
+		return kafkago.Message{}, err
+	}
+	msg.Topic = target.topic
+	if len(w.signingKey) > 0 {
+		signMessage(&msg, w.signingKey, w.signingKeyID)
+	}
+	return msg, nil
+}
+
+// messageOverhead bounds the bytes a record adds to its key, value, and
+// headers in a produce request: attributes, timestamp and offset deltas, and
+// the length prefixes.
+const messageOverhead = 64
+
+// MessageBytes returns the size of the largest message the event is written
+// as across the sink targets, as the broker and the producer's batch limit
+// count it. It implements pipeline.MessageSizer.
+func (w *Writer) MessageBytes(event domain.StormEvent) (int, error) {
+	largest := 0
+	for _, target := range w.targets {
+		msg, err := w.message(event, target)
 		if err != nil {
-			return err
+			return 0, err
 		}
-		msgs[i] = msg
+		largest = max(largest, messageSize(msg))
 	}
-	return w.writer.WriteMessages(ctx, msgs...)
+	return largest, nil
+}
+
+// messageSize is the size of msg's key, value, and headers, with two length
+// bytes per header, plus messageOverhead.
+func messageSize(msg kafkago.Message) int {
+	n := messageOverhead + len(msg.Key) + len(msg.Value)
+	for _, h := range msg.Headers {
+		n += len(h.Key) + len(h.Value) + 2
+	}
+	return n
+}
+
+// keyed sets the event's OrderingKey to the geohash of its coordinates when
+// sink messages are keyed by locality, so the message is keyed and
+// partitioned like an ordered event. Events without coordinates keep their ID.
+func (w *Writer) keyed(event domain.StormEvent) domain.StormEvent {
+	if w.keyPrecision > 0 {
+		if hash := domain.EncodeGeohash(event.Geo, w.keyPrecision); hash != "" {
+			event.OrderingKey = hash
+		}
+	}
+	return event
 }
 
 func (w *Writer) Close() error {
 	return w.writer.Close()
 }
 
-// serializeToMessage marshals a StormEvent into a Kafka message.
-func serializeToMessage(event domain.StormEvent) (kafkago.Message, error) {
-	data, err := json.Marshal(event)
-	if err != nil {
-		return kafkago.Message{}, fmt.Errorf("serialize storm event: %w", err)
+// tombstoneMessage retracts a previously written event: the same key with a
+// null value, which compacted topics treat as a delete. Only the event_type,
+// event_id, correlation_id, and trace context headers are kept.
+func tombstoneMessage(event domain.StormEvent) kafkago.Message {
+	headers := []kafkago.Header{{Key: "event_type", Value: []byte(event.EventType)}}
+	if event.OrderingKey != "" {
+		headers = append(headers, kafkago.Header{Key: headerEventID, Value: []byte(event.ID)})
+	}
+	if event.CorrelationID != "" {
+		headers = append(headers, kafkago.Header{Key: domain.HeaderCorrelationID, Value: []byte(event.CorrelationID)})
+	}
+	headers = appendSortedHeaders(headers, event.TraceContext)
+	return kafkago.Message{Key: messageKey(event), Headers: headers}
+}
+
+// Signature headers. headerSignature is "sha256=" and the hex HMAC-SHA256 of
+// the message value, as the webhook sink's X-Storm-Signature is of its body.
+const (
+	headerSignature      = "signature"
+	headerSignatureKeyID = "signature_key_id"
+)
+
+// signMessage adds the signature of the message value, and the ID of the key
+// that made it when one is configured. Consumers recompute the HMAC over the
+// value bytes as received and compare in constant time.
+func signMessage(msg *kafkago.Message, key []byte, keyID string) {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(msg.Value)
+	msg.Headers = append(msg.Headers, kafkago.Header{Key: headerSignature, Value: []byte("sha256=" + hex.EncodeToString(mac.Sum(nil)))})
+	if keyID != "" {
+		msg.Headers = append(msg.Headers, kafkago.Header{Key: headerSignatureKeyID, Value: []byte(keyID)})
+	}
+}
+
+// headerEventID carries the event ID when the message key is an ordering key.
+const headerEventID = "event_id"
+
+// messageKey is the event's ordering key in ordered mode or its geohash with
+// KAFKA_SINK_KEY=geohash, otherwise its ID.
+func messageKey(event domain.StormEvent) []byte {
+	if event.OrderingKey != "" {
+		return []byte(event.OrderingKey)
 	}
+	return []byte(event.ID)
+}
+
+// serializeToMessage marshals a StormEvent into a Kafka message using the
+// configured output format and field projection, which applies to the JSON
+// formats only. Protobuf and GeoJSON messages carry a content_type header so
+// consumers can tell them apart from the default JSON encoding, and versioned
+// events carry a schema_version header.
+func serializeToMessage(event domain.StormEvent, format string, fields *domain.FieldProjection) (kafkago.Message, error) {
+	// Room for every header below plus the signature headers the writer may
+	// add, so appending never reallocates.
+	n := 7 + len(event.TraceContext)
+	if event.Provenance != nil {
+		n += len(event.Provenance.Headers)
+	}
+	headers := make([]kafkago.Header, 0, n)
+	headers = append(headers,
+		kafkago.Header{Key: "event_type", Value: []byte(event.EventType)},
+		kafkago.Header{Key: "processed_at", Value: event.ProcessedAt.AppendFormat(make([]byte, 0, len(time.RFC3339)+6), time.RFC3339)},
+	)
+	if event.SchemaVersion != 0 {
+		headers = append(headers, kafkago.Header{Key: headerSchemaVersion, Value: []byte(strconv.Itoa(event.SchemaVersion))})
+	}
+	if event.OrderingKey != "" {
+		headers = append(headers, kafkago.Header{Key: headerEventID, Value: []byte(event.ID)})
+	}
+	if event.CorrelationID != "" {
+		headers = append(headers, kafkago.Header{Key: domain.HeaderCorrelationID, Value: []byte(event.CorrelationID)})
+	}
+
+	var data []byte
+	switch format {
+	case config.OutputFormatProtobuf:
+		data = stormpb.MarshalStormEvent(event)
+		headers = append(headers, kafkago.Header{Key: "content_type", Value: []byte(contentTypeProtobuf)})
+	case config.OutputFormatGeoJSON:
+		var err error
+		data, err = marshalFeature(event, fields)
+		if err != nil {
+			return kafkago.Message{}, fmt.Errorf("serialize storm event: %w", err)
+		}
+		headers = append(headers, kafkago.Header{Key: "content_type", Value: []byte(contentTypeGeoJSON)})
+	default:
+		// A pointer keeps the event from being copied into the interface.
+		var v any = &event
+		if fields != nil {
+			projected, err := fields.Apply(event)
+			if err != nil {
+				return kafkago.Message{}, fmt.Errorf("serialize storm event: %w", err)
+			}
+			v = projected
+		}
+		var err error
+		data, err = marshalJSON(v)
+		if err != nil {
+			return kafkago.Message{}, fmt.Errorf("serialize storm event: %w", err)
+		}
+	}
+
+	// Propagate trace context (traceparent, tracestate) so consumers continue the trace.
+	headers = appendSortedHeaders(headers, event.TraceContext)
+	// Carry the selected source headers through under their own names.
+	if event.Provenance != nil {
+		headers = appendSortedHeaders(headers, event.Provenance.Headers)
+	}
+
 	return kafkago.Message{
-		Key:   []byte(event.ID),
-		Value: data,
-		Headers: []kafkago.Header{
-			{Key: "event_type", Value: []byte(event.EventType)},
-			{Key: "processed_at", Value: []byte(event.ProcessedAt.Format(time.RFC3339))},
-		},
+		Key:     messageKey(event),
+		Value:   data,
+		Headers: headers,
 	}, nil
 }
+
+// appendSortedHeaders appends the entries of m as headers in key order. The
+// keys of the small maps it is given are sorted on the stack.
+func appendSortedHeaders(headers []kafkago.Header, m map[string]string) []kafkago.Header {
+	var buf [8]string
+	keys := buf[:0]
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		headers = append(headers, kafkago.Header{Key: k, Value: []byte(m[k])})
+	}
+	return headers
+}
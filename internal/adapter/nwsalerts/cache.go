@@ -0,0 +1,71 @@
+package nwsalerts
+
+import (
+	"sync"
+	"time"
+)
+
+// maxCacheEntries bounds the response cache. A busy day has a few thousand
+// reports, most at distinct points.
+const maxCacheEntries = 10000
+
+// warning is a warning with a polygon from an alerts response, before it is
+// matched against an event time.
+type warning struct {
+	ID        string
+	Effective time.Time
+	End       time.Time
+}
+
+// cacheKey identifies an alerts query: the point as sent to the API and the
+// hour of the event time, whose window the query covers.
+type cacheKey struct {
+	Point string
+	Hour  time.Time
+}
+
+type cacheEntry struct {
+	warnings []warning
+	expires  time.Time
+}
+
+// responseCache keeps the warnings of recent queries for ttl, so reports at
+// the same point in the same hour (duplicates, reports of several types, and
+// redelivered batches) share one request.
+type responseCache struct {
+	ttl     time.Duration
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[cacheKey]cacheEntry
+}
+
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{ttl: ttl, now: time.Now, entries: make(map[cacheKey]cacheEntry)}
+}
+
+func (c *responseCache) get(k cacheKey) ([]warning, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[k]
+	if !ok || !c.now().Before(e.expires) {
+		return nil, false
+	}
+	return e.warnings, true
+}
+
+func (c *responseCache) put(k cacheKey, warnings []warning) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if len(c.entries) >= maxCacheEntries {
+		for key, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= maxCacheEntries {
+			clear(c.entries)
+		}
+	}
+	c.entries[k] = cacheEntry{warnings: warnings, expires: now.Add(c.ttl)}
+}
@@ -0,0 +1,217 @@
+// Package nwsalerts correlates storm reports with the NWS warnings in effect
+// at their time and place, using the api.weather.gov alerts API.
+package nwsalerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	"golang.org/x/sync/singleflight"
+)
+
+// StageName is the transform stage name the client is registered under.
+const StageName = "nws_alerts"
+
+// maxWarningDuration bounds how long before a report a warning in effect at
+// its time can have been issued. Convective and flash flood warnings run an
+// hour or two; extensions are issued as new alerts.
+const maxWarningDuration = 6 * time.Hour
+
+// maxResponseBytes caps an alerts response. A point rarely sees more than a
+// few dozen alerts in the search window.
+const maxResponseBytes = 5 << 20
+
+// maxErrorBody caps how much of a failed response is read into the error.
+const maxErrorBody = 4 << 10
+
+// alertCollection is the part of an alerts GeoJSON response the client reads.
+type alertCollection struct {
+	Features []struct {
+		Geometry   json.RawMessage `json:"geometry"`
+		Properties struct {
+			ID          string    `json:"id"`
+			Event       string    `json:"event"`
+			MessageType string    `json:"messageType"`
+			Effective   time.Time `json:"effective"`
+			Expires     time.Time `json:"expires"`
+			Ends        time.Time `json:"ends"`
+		} `json:"properties"`
+	} `json:"features"`
+}
+
+// Client looks up the warnings whose polygon contains a report at its event
+// time. It is safe for concurrent use by the transform workers, and
+// concurrent lookups of the same point and hour share one request.
+type Client struct {
+	client    *http.Client
+	baseURL   string
+	userAgent string
+	metrics   *observability.Metrics
+	logger    *slog.Logger
+	cache     *responseCache // nil when NWSAlertsCacheTTL is 0
+	inflight  singleflight.Group
+}
+
+// ClientOption configures optional Client behavior.
+type ClientOption func(*Client)
+
+// WithTransport sends requests through rt instead of the default transport,
+// e.g. to inject faults. A nil rt keeps the default.
+func WithTransport(rt http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.client.Transport = rt
+	}
+}
+
+// NewClient creates an alerts client from the service configuration.
+func NewClient(cfg *config.Config, logger *slog.Logger, metrics *observability.Metrics, opts ...ClientOption) *Client {
+	c := &Client{
+		client:    &http.Client{Timeout: cfg.NWSAlertsTimeout},
+		baseURL:   strings.TrimSuffix(cfg.NWSAlertsURL, "/"),
+		userAgent: cfg.NWSAlertsUserAgent,
+		metrics:   metrics,
+		logger:    logger,
+	}
+	if cfg.NWSAlertsCacheTTL > 0 {
+		c.cache = newResponseCache(cfg.NWSAlertsCacheTTL)
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Stage is a pipeline.StageFunc that sets WarningActive and WarningIDs.
+// Lookup failures are logged and counted but do not reject the event, which
+// is loaded with WarningActive unset; an outage of the API must not stall
+// the pipeline. Events without coordinates or an event time and retractions
+// are passed through unchanged.
+func (c *Client) Stage(ctx context.Context, _ domain.RawEvent, event domain.StormEvent, audit *domain.Audit) (domain.StormEvent, error) {
+	if event.Geo == (domain.Geo{}) || event.EventTime.IsZero() || event.Deleted {
+		return event, nil
+	}
+	ids, err := c.Warnings(ctx, event.Geo, event.EventTime)
+	if err != nil {
+		c.metrics.AlertLookups.WithLabelValues("error").Inc()
+		c.logger.WarnContext(ctx, "nws warning lookup failed", "event_id", event.ID, "error", err)
+		return event, nil
+	}
+	active := len(ids) > 0
+	event.WarningActive = &active
+	event.WarningIDs = ids
+	if !active {
+		c.metrics.AlertLookups.WithLabelValues("outside").Inc()
+		return event, nil
+	}
+	c.metrics.AlertLookups.WithLabelValues("inside").Inc()
+	audit.Add("warning", "", strings.Join(ids, ","), fmt.Sprintf("inside %d warning polygons in effect at event time", len(ids)))
+	return event, nil
+}
+
+// Warnings returns the IDs of the warnings whose polygon contains g and that
+// were in effect at t: issued at or before t and ending after it. Zone-based
+// alerts without a polygon, watches, advisories, and cancellations are
+// ignored. The query covers the whole hour of t, so its response is cached
+// and reused for reports at the same point in that hour.
+func (c *Client) Warnings(ctx context.Context, g domain.Geo, t time.Time) ([]string, error) {
+	key := cacheKey{
+		Point: strconv.FormatFloat(g.Lat, 'f', 4, 64) + "," + strconv.FormatFloat(g.Lon, 'f', 4, 64),
+		Hour:  t.UTC().Truncate(time.Hour),
+	}
+	warnings, err := c.warnings(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, w := range warnings {
+		if w.Effective.After(t) || !w.End.After(t) {
+			continue
+		}
+		ids = append(ids, w.ID)
+	}
+	return ids, nil
+}
+
+// warnings returns the warnings for key from the cache, or fetches them.
+// Failed fetches are not cached.
+func (c *Client) warnings(ctx context.Context, key cacheKey) ([]warning, error) {
+	if c.cache != nil {
+		if warnings, ok := c.cache.get(key); ok {
+			return warnings, nil
+		}
+	}
+	v, err, _ := c.inflight.Do(key.Point+"@"+key.Hour.Format(time.RFC3339), func() (any, error) {
+		warnings, err := c.fetch(ctx, key)
+		if err == nil && c.cache != nil {
+			c.cache.put(key, warnings)
+		}
+		return warnings, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]warning), nil
+}
+
+// fetch asks the API for the warnings with a polygon at key's point issued
+// up to maxWarningDuration before its hour and in effect during it.
+func (c *Client) fetch(ctx context.Context, key cacheKey) ([]warning, error) {
+	q := url.Values{}
+	q.Set("point", key.Point)
+	q.Set("start", key.Hour.Add(-maxWarningDuration).Format(time.RFC3339))
+	q.Set("end", key.Hour.Add(time.Hour).Format(time.RFC3339))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/alerts?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build alerts request: %w", err)
+	}
+	req.Header.Set("Accept", "application/geo+json")
+	req.Header.Set("User-Agent", c.userAgent)
+
+	c.metrics.AlertLookupsInFlight.Inc()
+	defer c.metrics.AlertLookupsInFlight.Dec()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get alerts: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, fmt.Errorf("get alerts: unexpected status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	var alerts alertCollection
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&alerts); err != nil {
+		return nil, fmt.Errorf("decode alerts: %w", err)
+	}
+	var warnings []warning
+	for _, f := range alerts.Features {
+		p := f.Properties
+		if !strings.HasSuffix(p.Event, " Warning") || p.MessageType == "Cancel" || isNull(f.Geometry) {
+			continue
+		}
+		end := p.Ends
+		if end.IsZero() {
+			end = p.Expires
+		}
+		warnings = append(warnings, warning{ID: p.ID, Effective: p.Effective, End: end})
+	}
+	return warnings, nil
+}
+
+// isNull reports whether a GeoJSON geometry is absent or null, as it is for
+// alerts issued by zone rather than by polygon.
+func isNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || bytes.Equal(raw, []byte("null"))
+}
@@ -0,0 +1,172 @@
+package nwsalerts
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// alertsResponse holds, for a report at 2024-04-26T18:00Z: a tornado warning
+// in effect, one that expired before the report, a cancellation, a
+// zone-based warning without a polygon, and a watch.
+const alertsResponse = `{
+  "type": "FeatureCollection",
+  "features": [
+    {"geometry": {"type": "Polygon", "coordinates": []}, "properties": {"id": "urn:oid:active", "event": "Tornado Warning", "messageType": "Alert",
+      "effective": "2024-04-26T17:45:00Z", "expires": "2024-04-26T18:30:00Z", "ends": "2024-04-26T18:30:00Z"}},
+    {"geometry": {"type": "Polygon", "coordinates": []}, "properties": {"id": "urn:oid:expired", "event": "Severe Thunderstorm Warning", "messageType": "Alert",
+      "effective": "2024-04-26T16:00:00Z", "expires": "2024-04-26T17:00:00Z", "ends": null}},
+    {"geometry": {"type": "Polygon", "coordinates": []}, "properties": {"id": "urn:oid:cancel", "event": "Tornado Warning", "messageType": "Cancel",
+      "effective": "2024-04-26T17:50:00Z", "expires": "2024-04-26T18:30:00Z"}},
+    {"geometry": null, "properties": {"id": "urn:oid:zone", "event": "Flood Warning", "messageType": "Alert",
+      "effective": "2024-04-26T12:00:00Z", "expires": "2024-04-27T12:00:00Z"}},
+    {"geometry": {"type": "Polygon", "coordinates": []}, "properties": {"id": "urn:oid:watch", "event": "Tornado Watch", "messageType": "Alert",
+      "effective": "2024-04-26T15:00:00Z", "expires": "2024-04-26T22:00:00Z"}}
+  ]
+}`
+
+var reportTime = time.Date(2024, time.April, 26, 18, 0, 0, 0, time.UTC)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*Client, *observability.Metrics) {
+	t.Helper()
+	return newCachingTestClient(t, 0, handler)
+}
+
+func newCachingTestClient(t *testing.T, cacheTTL time.Duration, handler http.HandlerFunc) (*Client, *observability.Metrics) {
+	t.Helper()
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	metrics := observability.NewMetricsForTesting()
+	c := NewClient(&config.Config{
+		NWSAlertsURL:       ts.URL + "/",
+		NWSAlertsUserAgent: "(storm-etl-test, ops@example.com)",
+		NWSAlertsTimeout:   time.Second,
+		NWSAlertsCacheTTL:  cacheTTL,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)), metrics)
+	return c, metrics
+}
+
+func TestClient_Stage(t *testing.T) {
+	var req *http.Request
+	var metrics *observability.Metrics
+	var inFlight float64
+	c, metrics := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		inFlight = testutil.ToFloat64(metrics.AlertLookupsInFlight)
+		_, _ = io.WriteString(w, alertsResponse)
+	})
+
+	event := domain.StormEvent{ID: "tornado-1", Geo: domain.Geo{Lat: 35.2215, Lon: -97.4395}, EventTime: reportTime}
+	audit := &domain.Audit{}
+	out, err := c.Stage(context.Background(), domain.RawEvent{}, event, audit)
+	require.NoError(t, err)
+	require.NotNil(t, out.WarningActive)
+	assert.True(t, *out.WarningActive)
+	assert.Equal(t, []string{"urn:oid:active"}, out.WarningIDs)
+	assert.Len(t, *audit, 1)
+	assert.InDelta(t, 1, testutil.ToFloat64(metrics.AlertLookups.WithLabelValues("inside")), 0)
+	assert.InDelta(t, 1, inFlight, 0)
+	assert.InDelta(t, 0, testutil.ToFloat64(metrics.AlertLookupsInFlight), 0)
+
+	require.NotNil(t, req)
+	assert.Equal(t, "/alerts", req.URL.Path)
+	assert.Equal(t, "35.2215,-97.4395", req.URL.Query().Get("point"))
+	assert.Equal(t, "2024-04-26T12:00:00Z", req.URL.Query().Get("start"))
+	assert.Equal(t, "2024-04-26T19:00:00Z", req.URL.Query().Get("end"), "the query covers the event's hour")
+	assert.Equal(t, "(storm-etl-test, ops@example.com)", req.Header.Get("User-Agent"))
+}
+
+func TestClient_Stage_NoWarning(t *testing.T) {
+	c, metrics := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"type": "FeatureCollection", "features": []}`)
+	})
+
+	out, err := c.Stage(context.Background(), domain.RawEvent{}, domain.StormEvent{Geo: domain.Geo{Lat: 35, Lon: -97}, EventTime: reportTime}, nil)
+	require.NoError(t, err)
+	require.NotNil(t, out.WarningActive)
+	assert.False(t, *out.WarningActive)
+	assert.Empty(t, out.WarningIDs)
+	assert.InDelta(t, 1, testutil.ToFloat64(metrics.AlertLookups.WithLabelValues("outside")), 0)
+}
+
+func TestClient_Stage_LookupFailure(t *testing.T) {
+	c, metrics := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusServiceUnavailable)
+	})
+
+	out, err := c.Stage(context.Background(), domain.RawEvent{}, domain.StormEvent{Geo: domain.Geo{Lat: 35, Lon: -97}, EventTime: reportTime}, nil)
+	require.NoError(t, err, "a failed lookup must not reject the event")
+	assert.Nil(t, out.WarningActive)
+	assert.InDelta(t, 1, testutil.ToFloat64(metrics.AlertLookups.WithLabelValues("error")), 0)
+
+	_, err = c.Warnings(context.Background(), domain.Geo{Lat: 35, Lon: -97}, reportTime)
+	require.ErrorContains(t, err, "503")
+}
+
+func TestClient_Stage_Skips(t *testing.T) {
+	c, _ := newTestClient(t, func(_ http.ResponseWriter, _ *http.Request) {
+		t.Error("no lookup expected")
+	})
+
+	for _, event := range []domain.StormEvent{
+		{EventTime: reportTime},
+		{Geo: domain.Geo{Lat: 35, Lon: -97}},
+		{Geo: domain.Geo{Lat: 35, Lon: -97}, EventTime: reportTime, Deleted: true},
+	} {
+		out, err := c.Stage(context.Background(), domain.RawEvent{}, event, nil)
+		require.NoError(t, err)
+		assert.Nil(t, out.WarningActive)
+	}
+}
+
+func TestClient_Warnings_Cache(t *testing.T) {
+	var requests atomic.Int32
+	fail := atomic.Bool{}
+	c, _ := newCachingTestClient(t, time.Minute, func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		if fail.Load() {
+			http.Error(w, "upstream unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = io.WriteString(w, alertsResponse)
+	})
+	now := time.Now()
+	c.cache.now = func() time.Time { return now }
+	point := domain.Geo{Lat: 35.2215, Lon: -97.4395}
+
+	ids, err := c.Warnings(context.Background(), point, reportTime)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"urn:oid:active"}, ids)
+
+	// A later report in the same hour is matched against the cached response.
+	ids, err = c.Warnings(context.Background(), point, reportTime.Add(45*time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, ids, "the active warning ended at 18:30")
+	assert.Equal(t, int32(1), requests.Load())
+
+	_, err = c.Warnings(context.Background(), point, reportTime.Add(time.Hour))
+	require.NoError(t, err)
+	_, err = c.Warnings(context.Background(), domain.Geo{Lat: 35.2216, Lon: -97.4395}, reportTime)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), requests.Load(), "another hour or point is another query")
+
+	fail.Store(true)
+	now = now.Add(time.Minute)
+	_, err = c.Warnings(context.Background(), point, reportTime)
+	require.Error(t, err, "expired entries are fetched again")
+	_, err = c.Warnings(context.Background(), point, reportTime)
+	require.Error(t, err, "failures are not cached")
+	assert.Equal(t, int32(5), requests.Load())
+}
@@ -0,0 +1,45 @@
+package parquet
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+)
+
+// newCodec returns the page compression codec for a PARQUET_COMPRESSION value.
+func newCodec(name string) (compress.Codec, error) {
+	switch name {
+	case config.CompressionNone:
+		return &parquet.Uncompressed, nil
+	case config.CompressionSnappy:
+		return &parquet.Snappy, nil
+	case config.CompressionGzip:
+		return &parquet.Gzip, nil
+	case config.CompressionZstd:
+		return &parquet.Zstd, nil
+	default:
+		return nil, fmt.Errorf("unsupported parquet compression %q", name)
+	}
+}
+
+// encodeFile encodes events as a Parquet file with a single row group.
+func encodeFile(events []domain.StormEvent, codec compress.Codec) ([]byte, error) {
+	rows := make([]row, len(events))
+	for i := range events {
+		rows[i] = newRow(&events[i])
+	}
+
+	var buf bytes.Buffer
+	w := parquet.NewGenericWriter[row](&buf, parquet.Compression(codec))
+	if _, err := w.Write(rows); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
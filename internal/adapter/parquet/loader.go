@@ -0,0 +1,295 @@
+// Package parquet writes transformed storm events as time-partitioned Parquet
+// files to a local directory or S3-compatible object storage, so the data lake
+// can query them without a conversion job. Files are encoded with parquet-go.
+package parquet
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/jonboulle/clockwork"
+	"github.com/parquet-go/parquet-go/compress"
+)
+
+const contentTypeParquet = "application/vnd.apache.parquet"
+
+// store persists a finished file under a slash-separated key.
+type store interface {
+	put(ctx context.Context, key string, data []byte) error
+}
+
+// putObjectAPI is the subset of the S3 client used by the loader.
+type putObjectAPI interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+type s3Store struct {
+	client putObjectAPI
+	bucket string
+}
+
+func (s *s3Store) put(ctx context.Context, key string, data []byte) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentTypeParquet),
+	})
+	if err != nil {
+		return fmt.Errorf("put s3://%s/%s: %w", s.bucket, key, err)
+	}
+	return nil
+}
+
+// dirStore writes files under a local directory. Each file is written to a
+// temporary name and renamed into place, so readers scanning the directory
+// never see a partial file.
+type dirStore struct {
+	root string
+}
+
+func (s *dirStore) put(_ context.Context, key string, data []byte) error {
+	name := filepath.Join(s.root, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+// Loader writes events as Parquet files under
+// {prefix}/year=YYYY/month=MM/day=DD/, partitioned by event time (UTC).
+// Events are buffered in memory per day across batches, and a day's buffer is
+// written as one single-row-group file once it holds maxEvents events or is
+// maxAge old, or when the loader is closed. LoadBatch returns once the events
+// are buffered, so their offsets are committed before the file is durable: a
+// crash loses the open buffers, while a graceful shutdown writes them through
+// Close. It implements pipeline.BatchLoader.
+type Loader struct {
+	store     store
+	prefix    string
+	codec     compress.Codec
+	maxEvents int
+	maxAge    time.Duration
+	clock     clockwork.Clock
+	logger    *slog.Logger
+
+	mu      sync.Mutex
+	buffers map[time.Time]*buffer
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// buffer holds the unwritten events of one partition day. An event whose ID
+// is already buffered replaces the earlier copy, so a retried batch does not
+// duplicate rows.
+type buffer struct {
+	opened time.Time
+	events []domain.StormEvent
+	index  map[string]int
+}
+
+func (b *buffer) add(e domain.StormEvent) {
+	if i, ok := b.index[e.ID]; ok {
+		b.events[i] = e
+		return
+	}
+	b.index[e.ID] = len(b.events)
+	b.events = append(b.events, e)
+}
+
+// NewLoader creates a Parquet loader for cfg.ParquetPath: a local directory,
+// or s3://bucket/prefix. S3 credentials and region come from the standard AWS
+// configuration chain; PARQUET_S3_ENDPOINT and path-style addressing support
+// S3-compatible stores such as MinIO.
+func NewLoader(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*Loader, error) {
+	c, err := newCodec(cfg.ParquetCompression)
+	if err != nil {
+		return nil, err
+	}
+	l := newLoader(c, cfg.ParquetFileMaxEvents, cfg.ParquetFileMaxAge, clockwork.NewRealClock(), logger)
+
+	bucket, prefix, isS3 := config.ParseS3URL(cfg.ParquetPath)
+	if !isS3 {
+		l.store = &dirStore{root: cfg.ParquetPath}
+		l.start()
+		return l, nil
+	}
+
+	var opts []func(*awsconfig.LoadOptions) error
+	if cfg.ParquetS3Region != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.ParquetS3Region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("load aws config: %w", err)
+	}
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.ParquetS3Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.ParquetS3Endpoint)
+		}
+		o.UsePathStyle = cfg.ParquetS3PathStyle
+	})
+	l.store = &s3Store{client: client, bucket: bucket}
+	l.prefix = prefix
+	l.start()
+	return l, nil
+}
+
+func newLoader(c compress.Codec, maxEvents int, maxAge time.Duration, clock clockwork.Clock, logger *slog.Logger) *Loader {
+	return &Loader{
+		codec:     c,
+		maxEvents: maxEvents,
+		maxAge:    maxAge,
+		clock:     clock,
+		logger:    logger,
+		buffers:   make(map[time.Time]*buffer),
+	}
+}
+
+// start runs the age check in the background until Close. Buffers are
+// checked every half maxAge, so a file on a quiet stream is written at most
+// 1.5 maxAge after its first event arrived.
+func (l *Loader) start() {
+	l.done = make(chan struct{})
+	l.stopped = make(chan struct{})
+	go func() {
+		defer close(l.stopped)
+		ticker := l.clock.NewTicker(l.maxAge / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-l.done:
+				return
+			case <-ticker.Chan():
+				l.mu.Lock()
+				err := l.flush(context.Background(), false)
+				l.mu.Unlock()
+				if err != nil {
+					l.logger.Error("write expired parquet files", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// LoadBatch adds the events to their day buffers and writes every buffer
+// that is full or expired. File names are derived from the buffered event
+// IDs, so retrying a batch whose write failed overwrites the same files
+// instead of writing duplicates.
+func (l *Loader) LoadBatch(ctx context.Context, events []domain.StormEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	for i := range events {
+		day := partitionDay(events[i])
+		b, ok := l.buffers[day]
+		if !ok {
+			b = &buffer{opened: now, index: make(map[string]int)}
+			l.buffers[day] = b
+		}
+		b.add(events[i])
+	}
+	return l.flush(ctx, false)
+}
+
+// Close stops the age check and writes every open buffer.
+func (l *Loader) Close() error {
+	if l.done != nil {
+		close(l.done)
+		<-l.stopped
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.flush(context.Background(), true)
+}
+
+// flush writes the buffers that are full or expired, or all of them when
+// force is set, in day order. A buffer that fails to write is kept for the
+// next attempt. The caller must hold l.mu.
+func (l *Loader) flush(ctx context.Context, force bool) error {
+	now := l.clock.Now()
+	days := make([]time.Time, 0, len(l.buffers))
+	for day, b := range l.buffers {
+		if force || len(b.events) >= l.maxEvents || now.Sub(b.opened) >= l.maxAge {
+			days = append(days, day)
+		}
+	}
+	slices.SortFunc(days, time.Time.Compare)
+
+	for _, day := range days {
+		events := l.buffers[day].events
+		data, err := encodeFile(events, l.codec)
+		if err != nil {
+			return fmt.Errorf("encode parquet: %w", err)
+		}
+		key := objectKey(l.prefix, day, events)
+		if err := l.store.put(ctx, key, data); err != nil {
+			return err
+		}
+		delete(l.buffers, day)
+		l.logger.Debug("wrote parquet file", "key", key, "events", len(events), "bytes", len(data))
+	}
+	return nil
+}
+
+// partitionDay returns the UTC day an event is partitioned under. Events
+// without an event time fall back to their processing time.
+func partitionDay(e domain.StormEvent) time.Time {
+	t := e.EventTime
+	if t.IsZero() {
+		t = e.ProcessedAt
+	}
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// objectKey builds {prefix}/year=YYYY/month=MM/day=DD/{hash}.parquet, where
+// the hash covers the event IDs in buffer order.
+func objectKey(prefix string, day time.Time, events []domain.StormEvent) string {
+	h := sha256.New()
+	for i := range events {
+		h.Write([]byte(events[i].ID))
+		h.Write([]byte{0})
+	}
+	name := hex.EncodeToString(h.Sum(nil)[:16]) + ".parquet"
+	return path.Join(prefix,
+		fmt.Sprintf("year=%04d", day.Year()),
+		fmt.Sprintf("month=%02d", int(day.Month())),
+		fmt.Sprintf("day=%02d", day.Day()),
+		name,
+	)
+}
@@ -0,0 +1,256 @@
+package parquet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/jonboulle/clockwork"
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readFile decodes a file with parquet-go's reader, checking that every
+// column chunk was written with the wanted codec.
+func readFile(t *testing.T, data []byte, codec compress.Codec) []row {
+	t.Helper()
+	f, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	for _, rg := range f.Metadata().RowGroups {
+		for _, c := range rg.Columns {
+			require.Equal(t, codec.CompressionCodec(), c.MetaData.Codec, "column %v", c.MetaData.PathInSchema)
+		}
+	}
+	rows, err := parquet.Read[row](bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	return rows
+}
+
+func ids(rows []row) []string {
+	out := make([]string, len(rows))
+	for i := range rows {
+		out[i] = rows[i].ID
+	}
+	return out
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func testEvents() []domain.StormEvent {
+	return []domain.StormEvent{
+		{
+			SchemaVersion: domain.SchemaVersion,
+			ID:            "hail-1",
+			EventType:     "hail",
+			Geo:           domain.Geo{Lat: 35.2, Lon: -97.4},
+			Measurement:   domain.Measurement{Magnitude: 1.75, Unit: "in", Severity: ptr("severe")},
+			EventTime:     time.Date(2024, time.April, 26, 15, 10, 0, 0, time.UTC),
+			Location:      domain.Location{Raw: "8 ESE Norman", Name: "Norman", Distance: ptr(8.0), Direction: ptr("ESE"), State: "OK", County: "Cleveland"},
+			Impact:        &domain.Impact{Injuries: ptr(2), Damage: []string{"trees down", "power lines down"}},
+			ProcessedAt:   time.Date(2024, time.April, 26, 15, 12, 0, 0, time.UTC),
+		},
+		{
+			ID:          "wind-1",
+			EventType:   "wind",
+			Measurement: domain.Measurement{Magnitude: 60, Unit: "mph"},
+			EventTime:   time.Date(2024, time.April, 26, 18, 0, 0, 0, time.UTC),
+			Location:    domain.Location{State: "KS"},
+			Deleted:     true,
+		},
+		{
+			ID:        "hail-2",
+			EventType: "hail",
+			Impact:    &domain.Impact{Damage: []string{"hail damage"}},
+		},
+	}
+}
+
+func TestEncodeFile_RoundTrip(t *testing.T) {
+	for _, name := range []string{config.CompressionNone, config.CompressionSnappy, config.CompressionGzip, config.CompressionZstd} {
+		t.Run(name, func(t *testing.T) {
+			c, err := newCodec(name)
+			require.NoError(t, err)
+			data, err := encodeFile(testEvents(), c)
+			require.NoError(t, err)
+
+			f, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), f.NumRows())
+			field, ok := f.Schema().Lookup("measurement_severity")
+			require.True(t, ok)
+			assert.True(t, field.Node.Optional())
+
+			rows := readFile(t, data, c)
+			require.Len(t, rows, 3)
+			assert.Equal(t, []string{"hail-1", "wind-1", "hail-2"}, ids(rows))
+			assert.Equal(t, ptr("severe"), rows[0].MeasurementSeverity)
+			assert.Nil(t, rows[1].MeasurementSeverity)
+			assert.Equal(t, ptr(8.0), rows[0].LocationDistance)
+			assert.Nil(t, rows[1].LocationDistance)
+			assert.Equal(t, ptr(int32(2)), rows[0].ImpactInjuries)
+			assert.Nil(t, rows[2].ImpactInjuries)
+			assert.Equal(t, []bool{false, true, false}, []bool{rows[0].Deleted, rows[1].Deleted, rows[2].Deleted})
+			assert.True(t, rows[0].EventTime.Equal(time.Date(2024, time.April, 26, 15, 10, 0, 0, time.UTC)))
+			assert.True(t, rows[1].EventTime.Equal(time.Date(2024, time.April, 26, 18, 0, 0, 0, time.UTC)))
+			assert.True(t, rows[2].EventTime.IsZero(), "zero times are null")
+			assert.Equal(t, []string{"trees down", "power lines down"}, rows[0].ImpactDamage)
+			assert.Empty(t, rows[1].ImpactDamage)
+			assert.Equal(t, []string{"hail damage"}, rows[2].ImpactDamage)
+		})
+	}
+}
+
+func TestNewCodec_Unsupported(t *testing.T) {
+	_, err := newCodec(config.CompressionLZ4)
+	require.ErrorContains(t, err, `unsupported parquet compression "lz4"`)
+}
+
+type mockS3 struct {
+	err   error
+	calls []*s3.PutObjectInput
+}
+
+func (m *mockS3) PutObject(_ context.Context, in *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.calls = append(m.calls, in)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func newTestLoader(t *testing.T, st store, prefix string) (*Loader, *clockwork.FakeClock) {
+	t.Helper()
+	c, err := newCodec(config.CompressionSnappy)
+	require.NoError(t, err)
+	clock := clockwork.NewFakeClock()
+	l := newLoader(c, 100, 5*time.Minute, clock, slog.New(slog.DiscardHandler))
+	l.store = st
+	l.prefix = prefix
+	return l, clock
+}
+
+func TestLoadBatch_BuffersDaysUntilClose(t *testing.T) {
+	client := &mockS3{}
+	l, _ := newTestLoader(t, &s3Store{client: client, bucket: "lake"}, "storm-events")
+
+	events := testEvents()
+	events = append(events, domain.StormEvent{ID: "wind-2", EventTime: time.Date(2024, time.April, 27, 1, 0, 0, 0, time.UTC)})
+	require.NoError(t, l.LoadBatch(context.Background(), events[:1]))
+	require.NoError(t, l.LoadBatch(context.Background(), events[1:2]))
+	require.NoError(t, l.LoadBatch(context.Background(), events[3:]))
+	assert.Empty(t, client.calls, "nothing is written before a file is full, expired, or closed")
+
+	require.NoError(t, l.Close())
+	require.Len(t, client.calls, 2)
+	assert.Equal(t, "lake", aws.ToString(client.calls[0].Bucket))
+	assert.Equal(t, contentTypeParquet, aws.ToString(client.calls[0].ContentType))
+	assert.Regexp(t, `^storm-events/year=2024/month=04/day=26/[0-9a-f]{32}\.parquet$`, aws.ToString(client.calls[0].Key))
+	assert.Regexp(t, `^storm-events/year=2024/month=04/day=27/`, aws.ToString(client.calls[1].Key))
+
+	body, err := io.ReadAll(client.calls[0].Body)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hail-1", "wind-1"}, ids(readFile(t, body, l.codec)), "one file spans both batches")
+}
+
+func TestLoadBatch_RollsFullFile(t *testing.T) {
+	client := &mockS3{}
+	l, _ := newTestLoader(t, &s3Store{client: client, bucket: "lake"}, "")
+	l.maxEvents = 2
+
+	events := testEvents()
+	require.NoError(t, l.LoadBatch(context.Background(), events[:1]))
+	assert.Empty(t, client.calls)
+	require.NoError(t, l.LoadBatch(context.Background(), events[1:2]))
+	require.Len(t, client.calls, 1, "the second event fills the day's file")
+	assert.Empty(t, l.buffers)
+}
+
+func TestLoadBatch_RollsExpiredFile(t *testing.T) {
+	client := &mockS3{}
+	l, clock := newTestLoader(t, &s3Store{client: client, bucket: "lake"}, "")
+
+	events := testEvents()
+	require.NoError(t, l.LoadBatch(context.Background(), events[:1]))
+	clock.Advance(5 * time.Minute)
+	require.NoError(t, l.LoadBatch(context.Background(), events[2:]))
+
+	require.Len(t, client.calls, 1, "only the expired day is written")
+	assert.Regexp(t, `^year=2024/month=04/day=26/`, aws.ToString(client.calls[0].Key))
+	assert.Len(t, l.buffers, 1)
+}
+
+func TestLoader_WritesExpiredFileInBackground(t *testing.T) {
+	dir := t.TempDir()
+	l, clock := newTestLoader(t, &dirStore{root: dir}, "")
+	l.start()
+
+	require.NoError(t, l.LoadBatch(context.Background(), testEvents()[:1]))
+	require.NoError(t, clock.BlockUntilContext(context.Background(), 1))
+	clock.Advance(5 * time.Minute)
+
+	day := filepath.Join(dir, "year=2024", "month=04", "day=26")
+	require.Eventually(t, func() bool {
+		entries, err := os.ReadDir(day)
+		return err == nil && len(entries) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, l.Close())
+}
+
+func TestLoadBatch_S3ErrorKeepsBuffer(t *testing.T) {
+	client := &mockS3{err: errors.New("access denied")}
+	l, _ := newTestLoader(t, &s3Store{client: client, bucket: "lake"}, "")
+	l.maxEvents = 1
+
+	event := []domain.StormEvent{{ID: "x"}}
+	err := l.LoadBatch(context.Background(), event)
+	require.ErrorContains(t, err, "s3://lake/year=0001/")
+
+	client.err = nil
+	require.NoError(t, l.LoadBatch(context.Background(), event))
+	require.Len(t, client.calls, 1)
+	body, err := io.ReadAll(client.calls[0].Body)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x"}, ids(readFile(t, body, l.codec)), "the retried event replaces its buffered copy")
+}
+
+func TestLoadBatch_DirRetryWritesOneFile(t *testing.T) {
+	dir := t.TempDir()
+	l, _ := newTestLoader(t, &dirStore{root: dir}, "")
+	events := testEvents()
+
+	require.NoError(t, l.LoadBatch(context.Background(), events))
+	require.NoError(t, l.LoadBatch(context.Background(), events))
+	require.NoError(t, l.Close())
+
+	day := filepath.Join(dir, "year=2024", "month=04", "day=26")
+	entries, err := os.ReadDir(day)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "a retried batch is buffered once and leaves no temporary files")
+	data, err := os.ReadFile(filepath.Join(day, entries[0].Name()))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hail-1", "wind-1"}, ids(readFile(t, data, l.codec)))
+
+	// hail-2 has no event or processing time, so it lands in the zero day.
+	_, err = os.Stat(filepath.Join(dir, "year=0001", "month=01", "day=01"))
+	require.NoError(t, err)
+}
+
+func TestLoadBatch_Empty(t *testing.T) {
+	client := &mockS3{}
+	l, _ := newTestLoader(t, &s3Store{client: client, bucket: "lake"}, "")
+	require.NoError(t, l.LoadBatch(context.Background(), nil))
+	require.NoError(t, l.Close())
+	assert.Empty(t, client.calls)
+}
@@ -0,0 +1,150 @@
+package parquet
+
+import (
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// row is the file schema: StormEvent flattened the way the API flattens it
+// for its database, with nested fields joined by underscores (geo_lat,
+// location_state, measurement_severity). Pointer fields and fields tagged
+// optional are nullable; zero timestamps and empty optional strings are
+// written as nulls. Timestamps are microseconds since the epoch, UTC. List
+// columns use the standard three-level LIST layout. Appending fields is
+// backward compatible for readers; renaming or removing one is not.
+type row struct {
+	SchemaVersion              int32     `parquet:"schema_version"`
+	ID                         string    `parquet:"id"`
+	EventType                  string    `parquet:"event_type"`
+	EventTime                  time.Time `parquet:"event_time,optional,timestamp(microsecond)"`
+	GeoLat                     float64   `parquet:"geo_lat"`
+	GeoLon                     float64   `parquet:"geo_lon"`
+	MeasurementMagnitude       float64   `parquet:"measurement_magnitude"`
+	MeasurementUnit            string    `parquet:"measurement_unit"`
+	MeasurementSeverity        *string   `parquet:"measurement_severity"`
+	MeasurementMetricMagnitude *float64  `parquet:"measurement_metric_magnitude"`
+	MeasurementMetricUnit      *string   `parquet:"measurement_metric_unit"`
+	LocationRaw                string    `parquet:"location_raw"`
+	LocationName               string    `parquet:"location_name"`
+	LocationDistance           *float64  `parquet:"location_distance"`
+	LocationDirection          *string   `parquet:"location_direction"`
+	LocationState              string    `parquet:"location_state"`
+	LocationCounty             string    `parquet:"location_county"`
+	LocationPlaceGeoLat        *float64  `parquet:"location_place_geo_lat"`
+	LocationPlaceGeoLon        *float64  `parquet:"location_place_geo_lon"`
+	Comments                   string    `parquet:"comments"`
+	SourceOffice               string    `parquet:"source_office"`
+	SourceOfficeName           *string   `parquet:"source_office_name"`
+	SourceOfficeState          *string   `parquet:"source_office_state"`
+	NearestCityName            *string   `parquet:"nearest_city_name"`
+	NearestCityState           *string   `parquet:"nearest_city_state"`
+	NearestCityDistance        *float64  `parquet:"nearest_city_distance"`
+	NearestCityDirection       *string   `parquet:"nearest_city_direction"`
+	NearbyPlacesPopulation     *int32    `parquet:"nearby_places_population"`
+	NearbyPlacesRadius         *float64  `parquet:"nearby_places_radius"`
+	ImpactInjuries             *int32    `parquet:"impact_injuries"`
+	ImpactFatalities           *int32    `parquet:"impact_fatalities"`
+	ImpactDamage               []string  `parquet:"impact_damage,optional,list"`
+	TimeBucket                 time.Time `parquet:"time_bucket,optional,timestamp(microsecond)"`
+	ProcessedAt                time.Time `parquet:"processed_at,optional,timestamp(microsecond)"`
+	Deleted                    bool      `parquet:"deleted"`
+	EndTime                    time.Time `parquet:"end_time,optional,timestamp(microsecond)"`
+	PathBeginLat               *float64  `parquet:"path_begin_lat"`
+	PathBeginLon               *float64  `parquet:"path_begin_lon"`
+	PathEndLat                 *float64  `parquet:"path_end_lat"`
+	PathEndLon                 *float64  `parquet:"path_end_lon"`
+	MeasurementMethod          string    `parquet:"measurement_method,optional"`
+	QualityInferred            []string  `parquet:"quality_inferred,optional,list"`
+	QualityFlags               []string  `parquet:"quality_flags,optional,list"`
+	MergedFrom                 []string  `parquet:"merged_from,optional,list"`
+	EpisodeID                  string    `parquet:"episode_id,optional"`
+	LocationCWA                string    `parquet:"location_cwa,optional"`
+	LocationZone               string    `parquet:"location_zone,optional"`
+	WarningActive              *bool     `parquet:"warning_active"`
+	WarningIDs                 []string  `parquet:"warning_ids,optional,list"`
+	Geohash                    string    `parquet:"geohash,optional"`
+}
+
+// newRow flattens an event into its file row.
+func newRow(e *domain.StormEvent) row {
+	r := row{
+		SchemaVersion:        int32(e.SchemaVersion),
+		ID:                   e.ID,
+		EventType:            e.EventType,
+		EventTime:            e.EventTime,
+		GeoLat:               e.Geo.Lat,
+		GeoLon:               e.Geo.Lon,
+		MeasurementMagnitude: e.Measurement.Magnitude,
+		MeasurementUnit:      e.Measurement.Unit,
+		MeasurementSeverity:  e.Measurement.Severity,
+		LocationRaw:          e.Location.Raw,
+		LocationName:         e.Location.Name,
+		LocationDistance:     e.Location.Distance,
+		LocationDirection:    e.Location.Direction,
+		LocationState:        e.Location.State,
+		LocationCounty:       e.Location.County,
+		Comments:             e.Comments,
+		SourceOffice:         e.SourceOffice,
+		TimeBucket:           e.TimeBucket,
+		ProcessedAt:          e.ProcessedAt,
+		Deleted:              e.Deleted,
+		EndTime:              e.EndTime,
+		MeasurementMethod:    e.Measurement.Method,
+		MergedFrom:           e.MergedFrom,
+		EpisodeID:            e.EpisodeID,
+		LocationCWA:          e.Location.CWA,
+		LocationZone:         e.Location.Zone,
+		WarningActive:        e.WarningActive,
+		WarningIDs:           e.WarningIDs,
+		Geohash:              e.Geohash,
+	}
+	if m := e.Measurement.Metric; m != nil {
+		r.MeasurementMetricMagnitude = &m.Magnitude
+		r.MeasurementMetricUnit = &m.Unit
+	}
+	if g := e.Location.PlaceGeo; g != nil {
+		r.LocationPlaceGeoLat = &g.Lat
+		r.LocationPlaceGeoLon = &g.Lon
+	}
+	if o := e.SourceOfficeDetail; o != nil {
+		r.SourceOfficeName = &o.Name
+		r.SourceOfficeState = &o.State
+	}
+	if c := e.NearestCity; c != nil {
+		r.NearestCityName = &c.Name
+		r.NearestCityState = &c.State
+		r.NearestCityDistance = &c.Distance
+		r.NearestCityDirection = &c.Direction
+	}
+	if x := e.NearbyPlaces; x != nil {
+		r.NearbyPlacesPopulation = optInt(&x.Population)
+		r.NearbyPlacesRadius = &x.Radius
+	}
+	if i := e.Impact; i != nil {
+		r.ImpactInjuries = optInt(i.Injuries)
+		r.ImpactFatalities = optInt(i.Fatalities)
+		r.ImpactDamage = i.Damage
+	}
+	if p := e.PathBegin; p != nil {
+		r.PathBeginLat = &p.Lat
+		r.PathBeginLon = &p.Lon
+	}
+	if p := e.PathEnd; p != nil {
+		r.PathEndLat = &p.Lat
+		r.PathEndLon = &p.Lon
+	}
+	if q := e.Quality; q != nil {
+		r.QualityInferred = q.Inferred
+		r.QualityFlags = q.Flags
+	}
+	return r
+}
+
+func optInt(p *int) *int32 {
+	if p == nil {
+		return nil
+	}
+	v := int32(*p)
+	return &v
+}
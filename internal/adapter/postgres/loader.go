@@ -0,0 +1,122 @@
+// Package postgres persists transformed storm events directly to PostgreSQL or
+// TimescaleDB, for deployments that run without the downstream API service.
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// insertEventSQL upserts one flattened event. Deterministic IDs make the
+// conflict clause safe for replays and redelivered batches.
+const insertEventSQL = `INSERT INTO storm_events (
+	id, event_type, geo_lat, geo_lon,
+	measurement_magnitude, measurement_unit, measurement_severity,
+	event_time, location_raw, location_name, location_distance, location_direction,
+	location_state, location_county, comments, source_office, time_bucket, processed_at,
+	end_time
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+ON CONFLICT (id, event_time) DO NOTHING`
+
+// deleteEventSQL removes a retracted event.
+const deleteEventSQL = `DELETE FROM storm_events WHERE id = $1`
+
+// Loader writes event batches to the storm_events table.
+// It implements pipeline.BatchLoader.
+type Loader struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+// NewLoader connects to POSTGRES_DSN and, when POSTGRES_MIGRATE is enabled,
+// applies pending schema migrations before returning.
+func NewLoader(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*Loader, error) {
+	pool, err := pgxpool.New(ctx, cfg.PostgresDSN)
+	if err != nil {
+		return nil, fmt.Errorf("connect postgres: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("ping postgres: %w", err)
+	}
+	if cfg.PostgresMigrate {
+		applied, err := Migrate(ctx, pool)
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+		logger.Info("postgres migrations applied", "count", applied)
+	}
+	return &Loader{pool: pool, logger: logger}, nil
+}
+
+// LoadBatch upserts all events in a single transaction using a pipelined
+// pgx batch, so a batch is either fully persisted or retried as a whole.
+// Deleted events remove the stored row instead.
+func (l *Loader) LoadBatch(ctx context.Context, events []domain.StormEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for i := range events {
+		if events[i].Deleted {
+			batch.Queue(deleteEventSQL, events[i].ID)
+			continue
+		}
+		batch.Queue(insertEventSQL, eventArgs(events[i])...)
+	}
+
+	var changed int64
+	err := pgx.BeginFunc(ctx, l.pool, func(tx pgx.Tx) error {
+		results := tx.SendBatch(ctx, batch)
+		for range events {
+			tag, err := results.Exec()
+			if err != nil {
+				_ = results.Close()
+				return fmt.Errorf("write storm event: %w", err)
+			}
+			changed += tag.RowsAffected()
+		}
+		return results.Close()
+	})
+	if err != nil {
+		return err
+	}
+
+	l.logger.Debug("postgres batch loaded", "events", len(events), "rows_changed", changed)
+	return nil
+}
+
+// Close releases all pooled connections.
+func (l *Loader) Close() error {
+	l.pool.Close()
+	return nil
+}
+
+// eventArgs flattens an event into insertEventSQL parameters, using the same
+// location_*, geo_*, and measurement_* column layout as the API.
+func eventArgs(e domain.StormEvent) []any {
+	return []any{
+		e.ID, e.EventType, e.Geo.Lat, e.Geo.Lon,
+		e.Measurement.Magnitude, e.Measurement.Unit, e.Measurement.Severity,
+		e.EventTime, e.Location.Raw, e.Location.Name, e.Location.Distance, e.Location.Direction,
+		e.Location.State, e.Location.County, e.Comments, e.SourceOffice, nullTime(e.TimeBucket), e.ProcessedAt,
+		nullTime(e.EndTime),
+	}
+}
+
+// nullTime maps the zero time to SQL NULL.
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,37 @@
+-- Flattened storm events, matching the downstream API's column naming.
+-- The primary key includes event_time so the table can become a TimescaleDB
+-- hypertable; IDs are deterministic, so replays are absorbed by ON CONFLICT.
+CREATE TABLE IF NOT EXISTS storm_events (
+    id                    TEXT             NOT NULL,
+    event_type            TEXT             NOT NULL,
+    geo_lat               DOUBLE PRECISION NOT NULL,
+    geo_lon               DOUBLE PRECISION NOT NULL,
+    measurement_magnitude DOUBLE PRECISION NOT NULL,
+    measurement_unit      TEXT             NOT NULL,
+    measurement_severity  TEXT,
+    event_time            TIMESTAMPTZ      NOT NULL,
+    location_raw          TEXT             NOT NULL,
+    location_name         TEXT             NOT NULL,
+    location_distance     DOUBLE PRECISION,
+    location_direction    TEXT,
+    location_state        TEXT             NOT NULL,
+    location_county       TEXT             NOT NULL,
+    comments              TEXT             NOT NULL,
+    source_office         TEXT             NOT NULL,
+    time_bucket           TIMESTAMPTZ,
+    processed_at          TIMESTAMPTZ      NOT NULL,
+    PRIMARY KEY (id, event_time)
+);
+
+CREATE INDEX IF NOT EXISTS storm_events_type_time_idx ON storm_events (event_type, event_time DESC);
+CREATE INDEX IF NOT EXISTS storm_events_state_time_idx ON storm_events (location_state, event_time DESC);
+
+-- Convert to a hypertable only when TimescaleDB is installed, so plain
+-- PostgreSQL deployments use the same migration.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'timescaledb') THEN
+        PERFORM create_hypertable('storm_events', 'event_time', if_not_exists => TRUE, migrate_data => TRUE);
+    END IF;
+END
+$$;
@@ -0,0 +1,6 @@
+-- Estimated end of each event, so time-range queries can match events that
+-- overlap a window instead of only those that start in it. Rows loaded before
+-- this migration keep a NULL end_time.
+ALTER TABLE storm_events ADD COLUMN IF NOT EXISTS end_time TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS storm_events_end_time_idx ON storm_events (end_time DESC);
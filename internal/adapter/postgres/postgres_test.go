@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	assert.Equal(t, 1, migrations[0].version)
+	assert.Equal(t, "001_create_storm_events.sql", migrations[0].name)
+	assert.Contains(t, migrations[0].sql, "CREATE TABLE IF NOT EXISTS storm_events")
+	for i := 1; i < len(migrations); i++ {
+		assert.Greater(t, migrations[i].version, migrations[i-1].version, "migrations must be strictly ordered")
+	}
+}
+
+func TestEventArgs(t *testing.T) {
+	distance := 8.0
+	direction := "ESE"
+	severity := "moderate"
+	eventTime := time.Date(2024, time.April, 26, 15, 10, 0, 0, time.UTC)
+	processedAt := time.Date(2024, time.April, 27, 6, 0, 0, 0, time.UTC)
+
+	args := eventArgs(domain.StormEvent{
+		ID:          "hail-abc",
+		EventType:   "hail",
+		Geo:         domain.Geo{Lat: 31.02, Lon: -98.44},
+		Measurement: domain.Measurement{Magnitude: 1.25, Unit: "in", Severity: &severity},
+		EventTime:   eventTime,
+		Location: domain.Location{
+			Raw: "8 ESE Chappel", Name: "Chappel", Distance: &distance, Direction: &direction,
+			State: "TX", County: "San Saba",
+		},
+		Comments:     "Quarter hail (SJT)",
+		SourceOffice: "SJT",
+		ProcessedAt:  processedAt,
+	})
+
+	require.Len(t, args, 19, "one argument per insertEventSQL placeholder")
+	assert.Equal(t, "hail-abc", args[0])
+	assert.Equal(t, &severity, args[6])
+	assert.Equal(t, eventTime, args[7])
+	assert.Equal(t, &distance, args[10])
+	assert.Equal(t, "SJT", args[15])
+	assert.Nil(t, args[16], "zero time bucket is stored as NULL")
+	assert.Equal(t, processedAt, args[17])
+	assert.Nil(t, args[18], "zero end time is stored as NULL")
+}
@@ -0,0 +1,149 @@
+// Package s3archive writes transformed storm events to S3-compatible object
+// storage as time-partitioned NDJSON, keeping an archival copy alongside the
+// Kafka sink.
+package s3archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+const contentTypeNDJSON = "application/x-ndjson"
+
+// putObjectAPI is the subset of the S3 client used by the loader.
+type putObjectAPI interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// Loader archives event batches as NDJSON objects under
+// {prefix}/year=YYYY/month=MM/day=DD/, partitioned by event time (UTC).
+// It implements pipeline.BatchLoader.
+type Loader struct {
+	client putObjectAPI
+	bucket string
+	prefix string
+	fields *domain.FieldProjection
+	logger *slog.Logger
+}
+
+// NewLoader creates an archive loader. Credentials and region come from the
+// standard AWS configuration chain; ARCHIVE_S3_ENDPOINT and path-style
+// addressing support S3-compatible stores such as MinIO.
+func NewLoader(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*Loader, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if cfg.ArchiveS3Region != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.ArchiveS3Region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("load aws config: %w", err)
+	}
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.ArchiveS3Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.ArchiveS3Endpoint)
+		}
+		o.UsePathStyle = cfg.ArchiveS3PathStyle
+	})
+	return &Loader{
+		client: client,
+		bucket: cfg.ArchiveS3Bucket,
+		prefix: cfg.ArchiveS3Prefix,
+		fields: cfg.ArchiveFields,
+		logger: logger,
+	}, nil
+}
+
+// LoadBatch writes one object per event-time day in the batch. Object keys are
+// derived from the event IDs, so retrying a batch overwrites the same objects
+// instead of archiving duplicates.
+func (l *Loader) LoadBatch(ctx context.Context, events []domain.StormEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	partitions := make(map[time.Time][]domain.StormEvent)
+	for i := range events {
+		day := partitionDay(events[i])
+		partitions[day] = append(partitions[day], events[i])
+	}
+
+	days := make([]time.Time, 0, len(partitions))
+	for day := range partitions {
+		days = append(days, day)
+	}
+	slices.SortFunc(days, time.Time.Compare)
+
+	for _, day := range days {
+		if err := l.put(ctx, day, partitions[day]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *Loader) put(ctx context.Context, day time.Time, events []domain.StormEvent) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range events {
+		projected, err := l.fields.Apply(events[i])
+		if err == nil {
+			err = enc.Encode(projected)
+		}
+		if err != nil {
+			return fmt.Errorf("marshal event %s: %w", events[i].ID, err)
+		}
+	}
+
+	key := objectKey(l.prefix, day, events)
+	_, err := l.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(l.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(buf.Bytes()),
+		ContentType: aws.String(contentTypeNDJSON),
+	})
+	if err != nil {
+		return fmt.Errorf("put s3://%s/%s: %w", l.bucket, key, err)
+	}
+	l.logger.Debug("archived batch", "bucket", l.bucket, "key", key, "events", len(events))
+	return nil
+}
+
+// partitionDay returns the UTC day an event is archived under. Events without
+// an event time fall back to their processing time.
+func partitionDay(e domain.StormEvent) time.Time {
+	t := e.EventTime
+	if t.IsZero() {
+		t = e.ProcessedAt
+	}
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// objectKey builds {prefix}/year=YYYY/month=MM/day=DD/{hash}.ndjson, where the
+// hash covers the event IDs in batch order.
+func objectKey(prefix string, day time.Time, events []domain.StormEvent) string {
+	h := sha256.New()
+	for i := range events {
+		h.Write([]byte(events[i].ID))
+		h.Write([]byte{0})
+	}
+	name := hex.EncodeToString(h.Sum(nil)[:16]) + ".ndjson"
+	return path.Join(prefix,
+		fmt.Sprintf("year=%04d", day.Year()),
+		fmt.Sprintf("month=%02d", int(day.Month())),
+		fmt.Sprintf("day=%02d", day.Day()),
+		name,
+	)
+}
@@ -0,0 +1,106 @@
+package s3archive
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type putCall struct {
+	bucket, key, contentType string
+	events                   []domain.StormEvent
+}
+
+type mockS3 struct {
+	err   error
+	calls []putCall
+}
+
+func (m *mockS3) PutObject(_ context.Context, in *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	call := putCall{bucket: aws.ToString(in.Bucket), key: aws.ToString(in.Key), contentType: aws.ToString(in.ContentType)}
+	scanner := bufio.NewScanner(in.Body)
+	for scanner.Scan() {
+		var e domain.StormEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, err
+		}
+		call.events = append(call.events, e)
+	}
+	m.calls = append(m.calls, call)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func newTestLoader(client putObjectAPI) *Loader {
+	return &Loader{client: client, bucket: "archive", prefix: "storm-events", logger: slog.New(slog.DiscardHandler)}
+}
+
+func TestLoadBatch_PartitionsByEventDay(t *testing.T) {
+	client := &mockS3{}
+	l := newTestLoader(client)
+
+	events := []domain.StormEvent{
+		{ID: "hail-1", EventTime: time.Date(2024, time.April, 26, 15, 10, 0, 0, time.UTC)},
+		{ID: "wind-1", EventTime: time.Date(2024, time.April, 27, 1, 0, 0, 0, time.UTC)},
+		{ID: "hail-2", EventTime: time.Date(2024, time.April, 26, 22, 0, 0, 0, time.UTC)},
+	}
+	require.NoError(t, l.LoadBatch(context.Background(), events))
+
+	require.Len(t, client.calls, 2)
+	assert.Equal(t, "archive", client.calls[0].bucket)
+	assert.Equal(t, contentTypeNDJSON, client.calls[0].contentType)
+	assert.Regexp(t, `^storm-events/year=2024/month=04/day=26/[0-9a-f]{32}\.ndjson$`, client.calls[0].key)
+	assert.Regexp(t, `^storm-events/year=2024/month=04/day=27/`, client.calls[1].key)
+
+	require.Len(t, client.calls[0].events, 2)
+	assert.Equal(t, "hail-1", client.calls[0].events[0].ID)
+	assert.Equal(t, "hail-2", client.calls[0].events[1].ID)
+	assert.Equal(t, "wind-1", client.calls[1].events[0].ID)
+}
+
+func TestLoadBatch_RetryWritesSameKey(t *testing.T) {
+	client := &mockS3{}
+	l := newTestLoader(client)
+	events := []domain.StormEvent{{ID: "hail-1", EventTime: time.Date(2024, time.April, 26, 15, 10, 0, 0, time.UTC)}}
+
+	require.NoError(t, l.LoadBatch(context.Background(), events))
+	require.NoError(t, l.LoadBatch(context.Background(), events))
+
+	require.Len(t, client.calls, 2)
+	assert.Equal(t, client.calls[0].key, client.calls[1].key)
+}
+
+func TestLoadBatch_FallsBackToProcessedAt(t *testing.T) {
+	client := &mockS3{}
+	l := newTestLoader(client)
+	events := []domain.StormEvent{{ID: "x", ProcessedAt: time.Date(2025, time.January, 2, 3, 0, 0, 0, time.UTC)}}
+
+	require.NoError(t, l.LoadBatch(context.Background(), events))
+	require.Len(t, client.calls, 1)
+	assert.Regexp(t, `/year=2025/month=01/day=02/`, client.calls[0].key)
+}
+
+func TestLoadBatch_Error(t *testing.T) {
+	l := newTestLoader(&mockS3{err: errors.New("access denied")})
+	err := l.LoadBatch(context.Background(), []domain.StormEvent{{ID: "x"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "s3://archive/")
+}
+
+func TestLoadBatch_Empty(t *testing.T) {
+	client := &mockS3{}
+	require.NoError(t, newTestLoader(client).LoadBatch(context.Background(), nil))
+	assert.Empty(t, client.calls)
+}
@@ -0,0 +1,81 @@
+// Package schemaregistry registers the sink's JSON Schema with a Confluent
+// compatible schema registry, so consumers can look it up by subject.
+package schemaregistry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+)
+
+// contentType is the media type of schema registry API requests.
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+// maxErrorBody caps how much of a failed response is read into the error.
+const maxErrorBody = 4 << 10
+
+// Client registers schemas under a subject.
+type Client struct {
+	client   *http.Client
+	baseURL  string
+	subject  string
+	username string
+	password string
+}
+
+// NewClient creates a registry client from the service configuration.
+func NewClient(cfg *config.Config) *Client {
+	return &Client{
+		client:   &http.Client{Timeout: 10 * time.Second},
+		baseURL:  strings.TrimSuffix(cfg.SchemaRegistryURL, "/"),
+		subject:  cfg.SchemaRegistrySubject,
+		username: cfg.SchemaRegistryUsername,
+		password: cfg.SchemaRegistryPassword,
+	}
+}
+
+// Register registers a JSON Schema under the client's subject and returns its
+// schema ID. Registering a schema the subject already holds returns the
+// existing ID, so it is safe on every start. A schema the subject's
+// compatibility rules reject fails with the registry's message.
+func (c *Client) Register(ctx context.Context, schema []byte) (int, error) {
+	body, err := json.Marshal(map[string]string{"schemaType": "JSON", "schema": string(schema)})
+	if err != nil {
+		return 0, fmt.Errorf("marshal schema: %w", err)
+	}
+	endpoint := c.baseURL + "/subjects/" + url.PathEscape(c.subject) + "/versions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("build register request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", contentType)
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("register schema: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return 0, fmt.Errorf("register schema under %s: unexpected status %s: %s", c.subject, resp.Status, bytes.TrimSpace(msg))
+	}
+	var result struct {
+		ID int `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("decode register response: %w", err)
+	}
+	return result.ID, nil
+}
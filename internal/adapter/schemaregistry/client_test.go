@@ -0,0 +1,58 @@
+package schemaregistry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	var req *http.Request
+	var body map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &body)
+		_, _ = io.WriteString(w, `{"id":42}`)
+	}))
+	t.Cleanup(ts.Close)
+
+	c := NewClient(&config.Config{
+		SchemaRegistryURL:      ts.URL + "/",
+		SchemaRegistrySubject:  "transformed-weather-data-value",
+		SchemaRegistryUsername: "etl",
+		SchemaRegistryPassword: "s3cret",
+	})
+	id, err := c.Register(context.Background(), []byte(`{"title":"StormEvent"}`))
+	require.NoError(t, err)
+	assert.Equal(t, 42, id)
+
+	require.NotNil(t, req)
+	assert.Equal(t, "/subjects/transformed-weather-data-value/versions", req.URL.Path)
+	assert.Equal(t, contentType, req.Header.Get("Content-Type"))
+	user, pass, ok := req.BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "etl", user)
+	assert.Equal(t, "s3cret", pass)
+	assert.Equal(t, map[string]string{"schemaType": "JSON", "schema": `{"title":"StormEvent"}`}, body)
+}
+
+func TestRegister_Incompatible(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = io.WriteString(w, `{"error_code":409,"message":"Schema being registered is incompatible with an earlier schema"}`)
+	}))
+	t.Cleanup(ts.Close)
+
+	c := NewClient(&config.Config{SchemaRegistryURL: ts.URL, SchemaRegistrySubject: "storms-value"})
+	_, err := c.Register(context.Background(), []byte(`{}`))
+	require.ErrorContains(t, err, "409")
+	assert.ErrorContains(t, err, "incompatible")
+}
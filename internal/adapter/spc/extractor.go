@@ -0,0 +1,250 @@
+// Package spc extracts storm reports directly from the NOAA Storm Prediction
+// Center daily CSV files, letting the service run without the upstream
+// collector and Kafka source topic.
+package spc
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/jonboulle/clockwork"
+)
+
+// maxCSVBytes caps a single CSV download. Outbreak days are a few hundred KB.
+const maxCSVBytes = 10 << 20
+
+// report describes one of the three SPC daily CSV files.
+type report struct {
+	suffix    string // file suffix, e.g. "hail" in 240426_rpts_hail.csv
+	eventType string
+	magCol    string // column holding the magnitude (Size, F_Scale, Speed)
+}
+
+var reports = []report{
+	{suffix: "hail", eventType: "hail", magCol: "Size"},
+	{suffix: "torn", eventType: "tornado", magCol: "F_Scale"},
+	{suffix: "wind", eventType: "wind", magCol: "Speed"},
+}
+
+// Extractor polls the SPC daily report CSVs and emits each new row as a
+// RawEvent in the same flat JSON format the collector publishes.
+// It implements pipeline.BatchExtractor and is not safe for concurrent use.
+type Extractor struct {
+	client       *http.Client
+	baseURL      string
+	pollInterval time.Duration
+	lookbackDays int
+	waitInterval time.Duration
+	clock        clockwork.Clock
+	logger       *slog.Logger
+
+	pending  []domain.RawEvent
+	seen     map[string]map[string]struct{} // report date (YYMMDD) -> emitted row payloads
+	nextPoll time.Time
+}
+
+// NewExtractor creates an SPC extractor from the service configuration.
+func NewExtractor(cfg *config.Config, logger *slog.Logger) *Extractor {
+	return &Extractor{
+		client:       &http.Client{Timeout: 30 * time.Second},
+		baseURL:      strings.TrimSuffix(cfg.SPCBaseURL, "/"),
+		pollInterval: cfg.SPCPollInterval,
+		lookbackDays: cfg.SPCLookbackDays,
+		waitInterval: cfg.BatchFlushInterval,
+		clock:        clockwork.NewRealClock(),
+		logger:       logger,
+		seen:         make(map[string]map[string]struct{}),
+	}
+}
+
+// ExtractBatch returns up to batchSize reports not yet emitted. When no
+// reports are pending it polls SPC if the poll interval has elapsed, otherwise
+// it waits at most one flush interval and returns an empty batch.
+// Events have no Commit callback: progress is tracked in memory, so a restart
+// re-emits the current days' reports (IDs are deterministic, so loads stay idempotent).
+func (e *Extractor) ExtractBatch(ctx context.Context, batchSize int) ([]domain.RawEvent, error) {
+	if len(e.pending) == 0 {
+		if wait := e.nextPoll.Sub(e.clock.Now()); wait > 0 {
+			select {
+			case <-ctx.Done():
+			case <-e.clock.After(min(wait, e.waitInterval)):
+			}
+			return nil, nil
+		}
+		if err := e.poll(ctx); err != nil {
+			return nil, err
+		}
+		e.nextPoll = e.clock.Now().Add(e.pollInterval)
+	}
+
+	n := min(batchSize, len(e.pending))
+	batch := e.pending[:n:n]
+	e.pending = e.pending[n:]
+	return batch, nil
+}
+
+// poll downloads every report CSV in the lookback window and queues rows that
+// have not been emitted before.
+func (e *Extractor) poll(ctx context.Context) error {
+	dates := reportDates(e.clock.Now(), e.lookbackDays)
+	window := make(map[string]bool, len(dates))
+
+	for _, date := range dates {
+		key := date.Format("060102")
+		window[key] = true
+		if e.seen[key] == nil {
+			e.seen[key] = make(map[string]struct{})
+		}
+		for _, r := range reports {
+			events, err := e.fetchReport(ctx, date, r)
+			if err != nil {
+				return err
+			}
+			for i := range events {
+				id := string(events[i].Value)
+				if _, ok := e.seen[key][id]; ok {
+					continue
+				}
+				e.seen[key][id] = struct{}{}
+				e.pending = append(e.pending, events[i])
+			}
+		}
+	}
+
+	// Forget dates that have left the window so memory stays bounded.
+	for key := range e.seen {
+		if !window[key] {
+			delete(e.seen, key)
+		}
+	}
+
+	e.logger.Debug("spc poll complete", "dates", len(dates), "pending", len(e.pending))
+	return nil
+}
+
+// fetchReport downloads and parses one CSV. A 404 means SPC has not published
+// the file yet and yields no events.
+func (e *Extractor) fetchReport(ctx context.Context, date time.Time, r report) ([]domain.RawEvent, error) {
+	file := fmt.Sprintf("%s_rpts_%s.csv", date.Format("060102"), r.suffix)
+	url := e.baseURL + "/" + file
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build spc request: %w", err)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", file, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: unexpected status %s", file, resp.Status)
+	}
+
+	events, err := parseReportCSV(io.LimitReader(resp.Body, maxCSVBytes), r, date)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", file, err)
+	}
+	for i := range events {
+		events[i].Headers = map[string]string{"source_file": file}
+	}
+	return events, nil
+}
+
+// parseReportCSV converts SPC CSV rows into RawEvents carrying collector-style
+// JSON. The report date becomes the event timestamp, which ParseRawEvent
+// combines with the HHMM Time column.
+func parseReportCSV(body io.Reader, r report, date time.Time) ([]domain.RawEvent, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cols := make(map[string]int, len(header))
+	for i, h := range header {
+		cols[strings.TrimSpace(h)] = i
+	}
+	get := func(row []string, col string) string {
+		i, ok := cols[col]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	var events []domain.RawEvent
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if t := get(row, "Time"); t == "" || t == "Time" {
+			continue // blank or repeated header row
+		}
+
+		rec := domain.RawCSVRecord{
+			Time:      get(row, "Time"),
+			Location:  get(row, "Location"),
+			County:    get(row, "County"),
+			State:     get(row, "State"),
+			Lat:       get(row, "Lat"),
+			Lon:       get(row, "Lon"),
+			Comments:  get(row, "Comments"),
+			EventType: r.eventType,
+		}
+		switch r.eventType {
+		case "hail":
+			rec.Size = get(row, r.magCol)
+		case "tornado":
+			rec.FScale = get(row, r.magCol)
+		case "wind":
+			rec.Speed = get(row, r.magCol)
+		}
+
+		payload, err := json.Marshal(rec)
+		if err != nil {
+			return nil, fmt.Errorf("marshal row %d: %w", line, err)
+		}
+		events = append(events, domain.RawEvent{
+			Key:       []byte(r.eventType + "-" + date.Format("060102") + "-" + strconv.Itoa(line)),
+			Value:     payload,
+			Offset:    int64(line),
+			Timestamp: date,
+		})
+	}
+	return events, nil
+}
+
+// reportDates returns the SPC report dates to poll, newest first. An SPC day
+// runs 12Z to 12Z, so before 12Z UTC the current report is still yesterday's.
+func reportDates(now time.Time, lookbackDays int) []time.Time {
+	current := now.UTC().Add(-12 * time.Hour).Truncate(24 * time.Hour)
+	dates := make([]time.Time, 0, lookbackDays+1)
+	for i := 0; i <= lookbackDays; i++ {
+		dates = append(dates, current.AddDate(0, 0, -i))
+	}
+	return dates
+}
@@ -0,0 +1,192 @@
+package spc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const hailCSV = `Time,Size,Location,County,State,Lat,Lon,Comments
+1510,125,8 ESE Chappel,San Saba,TX,31.02,-98.44,1.25 inch hail (BWG)
+1522,100,Lometa,Lampasas,TX,31.22,-98.39,
+`
+
+const tornCSV = `Time,F_Scale,Location,County,State,Lat,Lon,Comments
+2005,UNK,2 N Dumont,Butler,IA,42.78,-92.97,Brief tornado (DVN)
+`
+
+// fakeSPC serves report CSVs from an in-memory map keyed by file name.
+type fakeSPC struct {
+	mu    sync.Mutex
+	files map[string]string
+}
+
+func (f *fakeSPC) set(name, body string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.files[name] = body
+}
+
+func (f *fakeSPC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	body, ok := f.files[strings.TrimPrefix(r.URL.Path, "/reports/")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	_, _ = io.WriteString(w, body)
+}
+
+func newTestExtractor(t *testing.T, files map[string]string, now time.Time) (*Extractor, *fakeSPC, *clockwork.FakeClock) {
+	t.Helper()
+	server := &fakeSPC{files: files}
+	srv := httptest.NewServer(server)
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{
+		SPCBaseURL:         srv.URL + "/reports/",
+		SPCPollInterval:    15 * time.Minute,
+		BatchFlushInterval: time.Second,
+	}
+	e := NewExtractor(cfg, slog.New(slog.DiscardHandler))
+	clock := clockwork.NewFakeClockAt(now)
+	e.clock = clock
+	return e, server, clock
+}
+
+func TestExtractBatch_ParsesReports(t *testing.T) {
+	now := time.Date(2024, time.April, 26, 18, 0, 0, 0, time.UTC)
+	e, _, _ := newTestExtractor(t, map[string]string{
+		"240426_rpts_hail.csv": hailCSV,
+		"240426_rpts_torn.csv": tornCSV,
+	}, now)
+
+	batch, err := e.ExtractBatch(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, batch, 3)
+
+	first := batch[0]
+	assert.Equal(t, "hail-240426-2", string(first.Key))
+	assert.Equal(t, int64(2), first.Offset)
+	assert.Equal(t, time.Date(2024, time.April, 26, 0, 0, 0, 0, time.UTC), first.Timestamp)
+	assert.Equal(t, "240426_rpts_hail.csv", first.Headers["source_file"])
+	assert.Nil(t, first.Commit)
+
+	var rec domain.RawCSVRecord
+	require.NoError(t, json.Unmarshal(first.Value, &rec))
+	assert.Equal(t, "1510", rec.Time)
+	assert.Equal(t, "125", rec.Size)
+	assert.Equal(t, "8 ESE Chappel", rec.Location)
+	assert.Equal(t, "hail", rec.EventType)
+
+	require.NoError(t, json.Unmarshal(batch[2].Value, &rec))
+	assert.Equal(t, "tornado", rec.EventType)
+	assert.Equal(t, "UNK", rec.FScale)
+
+	// The raw record must flow through the existing transform unchanged.
+	event, err := domain.ParseRawEvent(first)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.April, 26, 15, 10, 0, 0, time.UTC), event.EventTime)
+}
+
+func TestExtractBatch_RespectsBatchSize(t *testing.T) {
+	now := time.Date(2024, time.April, 26, 18, 0, 0, 0, time.UTC)
+	e, _, _ := newTestExtractor(t, map[string]string{
+		"240426_rpts_hail.csv": hailCSV,
+		"240426_rpts_torn.csv": tornCSV,
+	}, now)
+
+	batch, err := e.ExtractBatch(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Len(t, batch, 2)
+
+	batch, err = e.ExtractBatch(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Len(t, batch, 1)
+}
+
+func TestExtractBatch_RepollEmitsOnlyNewRows(t *testing.T) {
+	now := time.Date(2024, time.April, 26, 18, 0, 0, 0, time.UTC)
+	e, server, clock := newTestExtractor(t, map[string]string{
+		"240426_rpts_hail.csv": hailCSV,
+	}, now)
+
+	batch, err := e.ExtractBatch(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+
+	server.set("240426_rpts_hail.csv", hailCSV+"1600,175,Goldthwaite,Mills,TX,31.45,-98.57,\n")
+	clock.Advance(15 * time.Minute)
+
+	batch, err = e.ExtractBatch(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	var rec domain.RawCSVRecord
+	require.NoError(t, json.Unmarshal(batch[0].Value, &rec))
+	assert.Equal(t, "Goldthwaite", rec.Location)
+}
+
+func TestExtractBatch_WaitsForPollInterval(t *testing.T) {
+	now := time.Date(2024, time.April, 26, 18, 0, 0, 0, time.UTC)
+	e, _, _ := newTestExtractor(t, map[string]string{}, now)
+
+	batch, err := e.ExtractBatch(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, batch)
+
+	// Before the next poll is due, ExtractBatch waits and returns nothing.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	batch, err = e.ExtractBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, batch)
+}
+
+func TestExtractBatch_ServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	e := NewExtractor(&config.Config{SPCBaseURL: srv.URL, SPCPollInterval: time.Minute}, slog.New(slog.DiscardHandler))
+	_, err := e.ExtractBatch(context.Background(), 10)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "502")
+}
+
+func TestReportDates(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      time.Time
+		lookback int
+		want     []string
+	}{
+		{"after 12Z", time.Date(2024, time.April, 26, 18, 0, 0, 0, time.UTC), 0, []string{"240426"}},
+		{"before 12Z", time.Date(2024, time.April, 27, 6, 0, 0, 0, time.UTC), 0, []string{"240426"}},
+		{"lookback", time.Date(2024, time.May, 1, 13, 0, 0, 0, time.UTC), 2, []string{"240501", "240430", "240429"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, d := range reportDates(tt.now, tt.lookback) {
+				got = append(got, d.Format("060102"))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,246 @@
+// Package sqsadapter extracts raw storm reports from an Amazon SQS queue, for
+// deployments where the collector publishes to SQS (directly or through an
+// SNS topic subscribed to the queue) instead of Kafka.
+package sqsadapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	"github.com/jonboulle/clockwork"
+)
+
+// maxReceive is the most messages one ReceiveMessage call may return.
+const maxReceive = 10
+
+// sqsAPI is the subset of the SQS client used by the extractor.
+type sqsAPI interface {
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+	GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error)
+}
+
+// Extractor long-polls an SQS queue and emits each message as a RawEvent.
+// Received messages stay invisible to other consumers for the visibility
+// timeout; committing a message deletes it, so a message whose batch fails to
+// load reappears once the timeout expires and is processed again.
+// It implements pipeline.BatchExtractor and is not safe for concurrent use.
+type Extractor struct {
+	client        sqsAPI
+	queueURL      string
+	queueName     string
+	waitTime      time.Duration
+	visibility    time.Duration
+	snsEnvelope   bool
+	flushInterval time.Duration
+	clock         clockwork.Clock
+	metrics       *observability.Metrics
+	logger        *slog.Logger
+
+	received int64 // messages received so far, used as RawEvent.Offset
+}
+
+// NewExtractor creates an SQS extractor for SQS_QUEUE_URL. Credentials and
+// region come from the standard AWS configuration chain; SQS_ENDPOINT supports
+// local emulators such as LocalStack.
+func NewExtractor(ctx context.Context, cfg *config.Config, logger *slog.Logger, metrics *observability.Metrics) (*Extractor, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if cfg.SQSRegion != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.SQSRegion))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("load aws config: %w", err)
+	}
+	client := sqs.NewFromConfig(awsCfg, func(o *sqs.Options) {
+		if cfg.SQSEndpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.SQSEndpoint)
+		}
+	})
+	return newExtractor(client, cfg, logger, metrics), nil
+}
+
+func newExtractor(client sqsAPI, cfg *config.Config, logger *slog.Logger, metrics *observability.Metrics) *Extractor {
+	return &Extractor{
+		client:        client,
+		queueURL:      cfg.SQSQueueURL,
+		queueName:     path.Base(cfg.SQSQueueURL),
+		waitTime:      cfg.SQSWaitTime,
+		visibility:    cfg.SQSVisibilityTimeout,
+		snsEnvelope:   cfg.SQSSNSEnvelope,
+		flushInterval: cfg.BatchFlushInterval,
+		clock:         clockwork.NewRealClock(),
+		metrics:       metrics,
+		logger:        logger,
+	}
+}
+
+// ComponentName names the extractor in the pipeline health report.
+func (e *Extractor) ComponentName() string { return "sqs_extractor" }
+
+// CheckReadiness verifies that the queue exists and is reachable.
+// It implements pipeline.ReadinessChecker.
+func (e *Extractor) CheckReadiness(ctx context.Context) error {
+	_, err := e.client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(e.queueURL),
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameApproximateNumberOfMessages},
+	})
+	if err != nil {
+		return fmt.Errorf("sqs queue %s unreachable: %w", e.queueName, err)
+	}
+	return nil
+}
+
+// ExtractBatch receives up to batchSize messages, long-polling until the
+// batch is full, a poll comes back empty, or the flush interval elapses.
+// Each RawEvent's Commit deletes the message from the queue.
+func (e *Extractor) ExtractBatch(ctx context.Context, batchSize int) ([]domain.RawEvent, error) {
+	batch := make([]domain.RawEvent, 0, batchSize)
+	deadline := e.clock.Now().Add(e.flushInterval)
+
+	for len(batch) < batchSize {
+		remaining := deadline.Sub(e.clock.Now())
+		if remaining <= 0 {
+			break
+		}
+		// SQS waits in whole seconds; under a second left means a short poll.
+		wait := min(e.waitTime, remaining)
+		out, err := e.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:                    aws.String(e.queueURL),
+			MaxNumberOfMessages:         int32(min(maxReceive, batchSize-len(batch))),
+			WaitTimeSeconds:             int32(wait / time.Second),
+			VisibilityTimeout:           int32(e.visibility / time.Second),
+			MessageAttributeNames:       []string{"All"},
+			MessageSystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameSentTimestamp, types.MessageSystemAttributeNameMessageGroupId},
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			if len(batch) > 0 {
+				return batch, nil
+			}
+			return nil, fmt.Errorf("receive sqs messages: %w", err)
+		}
+		receivedAt := e.clock.Now()
+		for i := range out.Messages {
+			raw, err := e.toRawEvent(out.Messages[i], receivedAt)
+			if err != nil {
+				// Leave the message on the queue; it is retried after the
+				// visibility timeout and, with a redrive policy, eventually
+				// moved to the queue's own dead-letter queue.
+				e.logger.Warn("skipping malformed sqs message", "error", err, "message_id", aws.ToString(out.Messages[i].MessageId))
+				continue
+			}
+			batch = append(batch, raw)
+		}
+		if len(out.Messages) == 0 {
+			break
+		}
+	}
+	return batch, nil
+}
+
+// snsNotification is the JSON envelope SNS wraps around messages it delivers
+// to SQS without raw message delivery.
+type snsNotification struct {
+	Type              string `json:"Type"`
+	MessageID         string `json:"MessageId"`
+	TopicArn          string `json:"TopicArn"`
+	Message           string `json:"Message"`
+	Timestamp         string `json:"Timestamp"`
+	MessageAttributes map[string]struct {
+		Type  string `json:"Type"`
+		Value string `json:"Value"`
+	} `json:"MessageAttributes"`
+}
+
+// toRawEvent maps a received message to a RawEvent. The queue name stands in
+// for the topic and the receive count for the offset, since SQS has neither.
+func (e *Extractor) toRawEvent(msg types.Message, receivedAt time.Time) (domain.RawEvent, error) {
+	body := aws.ToString(msg.Body)
+	headers := make(map[string]string)
+	for k, v := range msg.MessageAttributes {
+		if v.StringValue != nil {
+			headers[k] = *v.StringValue
+		}
+	}
+	ts := receivedAt
+	if ms, err := strconv.ParseInt(msg.Attributes[string(types.MessageSystemAttributeNameSentTimestamp)], 10, 64); err == nil {
+		ts = time.UnixMilli(ms)
+	}
+
+	if e.snsEnvelope {
+		var n snsNotification
+		if err := json.Unmarshal([]byte(body), &n); err != nil {
+			return domain.RawEvent{}, fmt.Errorf("decode sns envelope: %w", err)
+		}
+		if n.Type != "Notification" {
+			return domain.RawEvent{}, fmt.Errorf("unexpected sns message type %q", n.Type)
+		}
+		body = n.Message
+		for k, v := range n.MessageAttributes {
+			if v.Type == "String" {
+				headers[k] = v.Value
+			}
+		}
+		if t, err := time.Parse(time.RFC3339, n.Timestamp); err == nil {
+			ts = t
+		}
+	}
+
+	var key []byte
+	if group := msg.Attributes[string(types.MessageSystemAttributeNameMessageGroupId)]; group != "" {
+		key = []byte(group)
+	}
+	e.received++
+	receipt := msg.ReceiptHandle
+	expires := receivedAt.Add(e.visibility)
+	return domain.RawEvent{
+		Key:       key,
+		Value:     []byte(body),
+		Headers:   headers,
+		Topic:     e.queueName,
+		Offset:    e.received,
+		Timestamp: ts,
+		Commit: func(ctx context.Context) error {
+			return e.deleteMessage(ctx, receipt, expires)
+		},
+	}, nil
+}
+
+// deleteMessage removes a processed message. A delete after the visibility
+// timeout still usually succeeds, but the message may already have been
+// redelivered, so late deletes are counted to show the timeout is too short.
+func (e *Extractor) deleteMessage(ctx context.Context, receipt *string, expires time.Time) error {
+	if late := e.clock.Now().Sub(expires); late > 0 {
+		e.metrics.SQSLateDeletes.Inc()
+		e.logger.Warn("sqs visibility timeout expired before commit, message may be redelivered",
+			"queue", e.queueName, "late_by", late, "visibility_timeout", e.visibility)
+	}
+	_, err := e.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(e.queueURL),
+		ReceiptHandle: receipt,
+	})
+	if err != nil {
+		var invalid *types.ReceiptHandleIsInvalid
+		if errors.As(err, &invalid) {
+			return fmt.Errorf("delete sqs message: receipt expired, message will be redelivered: %w", err)
+		}
+		return fmt.Errorf("delete sqs message: %w", err)
+	}
+	return nil
+}
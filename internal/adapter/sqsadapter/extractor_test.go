@@ -0,0 +1,207 @@
+package sqsadapter
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	"github.com/jonboulle/clockwork"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const queueURL = "https://sqs.us-east-1.amazonaws.com/123456789012/storm-reports"
+
+// mockSQS answers each ReceiveMessage with the next queued response, then
+// with empty polls, and records every request.
+type mockSQS struct {
+	responses  [][]types.Message
+	receiveErr error
+	deleteErr  error
+	attrErr    error
+	receives   []*sqs.ReceiveMessageInput
+	deletes    []string
+}
+
+func (m *mockSQS) ReceiveMessage(_ context.Context, in *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	m.receives = append(m.receives, in)
+	if m.receiveErr != nil && len(m.responses) == 0 {
+		return nil, m.receiveErr
+	}
+	out := &sqs.ReceiveMessageOutput{}
+	if len(m.responses) > 0 {
+		out.Messages, m.responses = m.responses[0], m.responses[1:]
+	}
+	return out, nil
+}
+
+func (m *mockSQS) DeleteMessage(_ context.Context, in *sqs.DeleteMessageInput, _ ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+	if m.deleteErr != nil {
+		return nil, m.deleteErr
+	}
+	m.deletes = append(m.deletes, aws.ToString(in.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func (m *mockSQS) GetQueueAttributes(_ context.Context, _ *sqs.GetQueueAttributesInput, _ ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+	if m.attrErr != nil {
+		return nil, m.attrErr
+	}
+	return &sqs.GetQueueAttributesOutput{}, nil
+}
+
+func message(id, body string) types.Message {
+	return types.Message{
+		MessageId:     aws.String(id),
+		ReceiptHandle: aws.String("receipt-" + id),
+		Body:          aws.String(body),
+		Attributes:    map[string]string{"SentTimestamp": "1714144200000"},
+	}
+}
+
+func newTestExtractor(t *testing.T, client *mockSQS, snsEnvelope bool) (*Extractor, *clockwork.FakeClock, *observability.Metrics) {
+	t.Helper()
+	cfg := &config.Config{
+		SQSQueueURL:          queueURL,
+		SQSWaitTime:          10 * time.Second,
+		SQSVisibilityTimeout: time.Minute,
+		SQSSNSEnvelope:       snsEnvelope,
+		BatchFlushInterval:   5 * time.Second,
+	}
+	metrics := observability.NewMetricsForTesting()
+	e := newExtractor(client, cfg, slog.New(slog.DiscardHandler), metrics)
+	clock := clockwork.NewFakeClock()
+	e.clock = clock
+	return e, clock, metrics
+}
+
+func TestExtractBatch_MapsMessages(t *testing.T) {
+	msg := message("m1", `{"Type":"hail"}`)
+	msg.Attributes["MessageGroupId"] = "OK"
+	msg.MessageAttributes = map[string]types.MessageAttributeValue{
+		"traceparent": {DataType: aws.String("String"), StringValue: aws.String("00-abc-def-01")},
+		"blob":        {DataType: aws.String("Binary"), BinaryValue: []byte{1}},
+	}
+	client := &mockSQS{responses: [][]types.Message{{msg, message("m2", `{"Type":"wind"}`)}}}
+	e, _, _ := newTestExtractor(t, client, false)
+
+	batch, err := e.ExtractBatch(context.Background(), 5)
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+
+	assert.Equal(t, []byte("OK"), batch[0].Key)
+	assert.JSONEq(t, `{"Type":"hail"}`, string(batch[0].Value))
+	assert.Equal(t, map[string]string{"traceparent": "00-abc-def-01"}, batch[0].Headers)
+	assert.Equal(t, "storm-reports", batch[0].Topic)
+	assert.Equal(t, int64(1), batch[0].Offset)
+	assert.Equal(t, time.UnixMilli(1714144200000), batch[0].Timestamp)
+	assert.Nil(t, batch[1].Key)
+	assert.Equal(t, int64(2), batch[1].Offset)
+
+	require.Len(t, client.receives, 2, "polls until a receive comes back empty")
+	in := client.receives[0]
+	assert.Equal(t, queueURL, aws.ToString(in.QueueUrl))
+	assert.Equal(t, int32(5), in.MaxNumberOfMessages)
+	assert.Equal(t, int32(5), in.WaitTimeSeconds, "long poll is capped by the flush interval")
+	assert.Equal(t, int32(60), in.VisibilityTimeout)
+	assert.Equal(t, int32(3), client.receives[1].MaxNumberOfMessages)
+}
+
+func TestExtractBatch_StopsWhenFull(t *testing.T) {
+	client := &mockSQS{responses: [][]types.Message{{message("m1", "{}"), message("m2", "{}")}, {message("m3", "{}")}}}
+	e, _, _ := newTestExtractor(t, client, false)
+
+	batch, err := e.ExtractBatch(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Len(t, batch, 2)
+	assert.Len(t, client.receives, 1)
+}
+
+func TestExtractBatch_SNSEnvelope(t *testing.T) {
+	envelope := `{
+		"Type": "Notification",
+		"MessageId": "sns-1",
+		"TopicArn": "arn:aws:sns:us-east-1:123456789012:storm-reports",
+		"Message": "{\"Type\":\"torn\"}",
+		"Timestamp": "2024-04-26T20:05:00.000Z",
+		"MessageAttributes": {
+			"traceparent": {"Type": "String", "Value": "00-abc-def-01"},
+			"payload": {"Type": "Binary", "Value": "AQ=="}
+		}
+	}`
+	client := &mockSQS{responses: [][]types.Message{{message("m1", envelope), message("m2", "not json")}}}
+	e, _, _ := newTestExtractor(t, client, true)
+
+	batch, err := e.ExtractBatch(context.Background(), 5)
+	require.NoError(t, err)
+	require.Len(t, batch, 1, "a message that is not an SNS notification is skipped")
+	assert.JSONEq(t, `{"Type":"torn"}`, string(batch[0].Value))
+	assert.Equal(t, map[string]string{"traceparent": "00-abc-def-01"}, batch[0].Headers)
+	assert.Equal(t, time.Date(2024, time.April, 26, 20, 5, 0, 0, time.UTC), batch[0].Timestamp)
+}
+
+func TestExtractBatch_ReceiveError(t *testing.T) {
+	client := &mockSQS{receiveErr: errors.New("access denied")}
+	e, _, _ := newTestExtractor(t, client, false)
+
+	_, err := e.ExtractBatch(context.Background(), 5)
+	require.ErrorContains(t, err, "receive sqs messages: access denied")
+
+	client.responses = [][]types.Message{{message("m1", "{}")}}
+	batch, err := e.ExtractBatch(context.Background(), 5)
+	require.NoError(t, err, "a partial batch is returned and the error surfaces on the next poll")
+	assert.Len(t, batch, 1)
+}
+
+func TestCommit_DeletesMessage(t *testing.T) {
+	client := &mockSQS{responses: [][]types.Message{{message("m1", "{}")}}}
+	e, clock, metrics := newTestExtractor(t, client, false)
+
+	batch, err := e.ExtractBatch(context.Background(), 5)
+	require.NoError(t, err)
+	clock.Advance(30 * time.Second)
+	require.NoError(t, batch[0].Commit(context.Background()))
+
+	assert.Equal(t, []string{"receipt-m1"}, client.deletes)
+	assert.Zero(t, testutil.ToFloat64(metrics.SQSLateDeletes))
+}
+
+func TestCommit_AfterVisibilityTimeout(t *testing.T) {
+	client := &mockSQS{responses: [][]types.Message{{message("m1", "{}")}}}
+	e, clock, metrics := newTestExtractor(t, client, false)
+
+	batch, err := e.ExtractBatch(context.Background(), 5)
+	require.NoError(t, err)
+	clock.Advance(2 * time.Minute)
+	require.NoError(t, batch[0].Commit(context.Background()), "the delete is still attempted")
+
+	assert.Equal(t, []string{"receipt-m1"}, client.deletes)
+	assert.InDelta(t, 1, testutil.ToFloat64(metrics.SQSLateDeletes), 0)
+}
+
+func TestCommit_ExpiredReceipt(t *testing.T) {
+	client := &mockSQS{responses: [][]types.Message{{message("m1", "{}")}}}
+	e, _, _ := newTestExtractor(t, client, false)
+
+	batch, err := e.ExtractBatch(context.Background(), 5)
+	require.NoError(t, err)
+	client.deleteErr = &types.ReceiptHandleIsInvalid{Message: aws.String("receipt handle has expired")}
+	require.ErrorContains(t, batch[0].Commit(context.Background()), "message will be redelivered")
+}
+
+func TestCheckReadiness(t *testing.T) {
+	client := &mockSQS{}
+	e, _, _ := newTestExtractor(t, client, false)
+	require.NoError(t, e.CheckReadiness(context.Background()))
+
+	client.attrErr = errors.New("queue does not exist")
+	require.ErrorContains(t, e.CheckReadiness(context.Background()), "sqs queue storm-reports unreachable")
+}
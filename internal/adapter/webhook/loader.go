@@ -0,0 +1,244 @@
+// Package webhook pushes transformed storm events to partner HTTPS endpoints,
+// for consumers that cannot read from Kafka.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	"github.com/couchcryptid/storm-data-shared/retry"
+	"github.com/jonboulle/clockwork"
+)
+
+// Request headers sent with every delivery.
+const (
+	headerDeliveryID = "X-Storm-Delivery-Id"
+	headerTimestamp  = "X-Storm-Timestamp"
+	headerSignature  = "X-Storm-Signature"
+)
+
+// maxRetryBackoff caps the wait between retries, including waits requested
+// by a Retry-After header.
+const maxRetryBackoff = 30 * time.Second
+
+// maxErrorBody caps how much of a failed response is read into the error.
+const maxErrorBody = 4 << 10
+
+// destination is one endpoint and the label its metrics are reported under.
+type destination struct {
+	url   string
+	label string
+}
+
+// payload is the JSON body of a delivery: the events, projected by
+// WEBHOOK_FIELDS.
+type payload struct {
+	Events []any `json:"events"`
+}
+
+// Loader POSTs each batch as {"events": [...]} to every configured endpoint
+// concurrently. Requests are signed with HMAC-SHA256 and carry a delivery ID
+// derived from the event IDs, so a receiver can drop the duplicates sent when
+// the pipeline retries a batch. Network errors, 408, 429, and 5xx responses are
+// retried with exponential backoff; other responses fail the batch. It
+// implements pipeline.BatchLoader.
+type Loader struct {
+	client       *http.Client
+	destinations []destination
+	secret       []byte
+	fields       *domain.FieldProjection
+	maxRetries   int
+	backoff      time.Duration
+	sleep        func(ctx context.Context, d time.Duration) bool
+	clock        clockwork.Clock
+	metrics      *observability.Metrics
+	logger       *slog.Logger
+}
+
+// NewLoader creates a webhook loader for the endpoints in cfg.WebhookURLs.
+func NewLoader(cfg *config.Config, logger *slog.Logger, metrics *observability.Metrics) *Loader {
+	l := &Loader{
+		client:     &http.Client{Timeout: cfg.WebhookTimeout},
+		secret:     []byte(cfg.WebhookSecret),
+		fields:     cfg.WebhookFields,
+		maxRetries: cfg.WebhookMaxRetries,
+		backoff:    cfg.WebhookRetryBackoff,
+		sleep:      retry.SleepWithContext,
+		clock:      clockwork.NewRealClock(),
+		metrics:    metrics,
+		logger:     logger,
+	}
+	for _, raw := range cfg.WebhookURLs {
+		l.destinations = append(l.destinations, destination{url: raw, label: destinationLabel(raw)})
+	}
+	return l
+}
+
+// destinationLabel identifies an endpoint in metrics and logs by host and
+// path, leaving out the query string and user info, which may hold tokens.
+func destinationLabel(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return u.Host + u.Path
+}
+
+// LoadBatch delivers the batch to every endpoint and returns the failures
+// joined. A failure at any endpoint fails the batch, so a retried batch is
+// sent again to the endpoints that already accepted it.
+func (l *Loader) LoadBatch(ctx context.Context, events []domain.StormEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	projected := make([]any, len(events))
+	for i := range events {
+		p, err := l.fields.Apply(events[i])
+		if err != nil {
+			return fmt.Errorf("marshal webhook payload: %w", err)
+		}
+		projected[i] = p
+	}
+	body, err := json.Marshal(payload{Events: projected})
+	if err != nil {
+		return fmt.Errorf("marshal webhook payload: %w", err)
+	}
+	deliveryID := deliveryID(events)
+
+	errs := make([]error, len(l.destinations))
+	var wg sync.WaitGroup
+	for i, dest := range l.destinations {
+		wg.Go(func() {
+			errs[i] = l.deliver(ctx, dest, deliveryID, body, len(events))
+		})
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// deliveryID hashes the event IDs in batch order, so the same batch always
+// gets the same ID.
+func deliveryID(events []domain.StormEvent) string {
+	h := sha256.New()
+	for i := range events {
+		h.Write([]byte(events[i].ID))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// Close releases the client's idle keep-alive connections to the endpoints.
+func (l *Loader) Close() error {
+	l.client.CloseIdleConnections()
+	return nil
+}
+
+// deliver sends the body to one endpoint, retrying transient failures.
+func (l *Loader) deliver(ctx context.Context, dest destination, deliveryID string, body []byte, events int) error {
+	backoff := l.backoff
+	for attempt := 0; ; attempt++ {
+		start := l.clock.Now()
+		wait, err := l.post(ctx, dest.url, deliveryID, body)
+		l.metrics.WebhookRequestDuration.WithLabelValues(dest.label).Observe(l.clock.Since(start).Seconds())
+		if err == nil {
+			l.metrics.WebhookRequests.WithLabelValues(dest.label, "delivered").Inc()
+			l.metrics.WebhookEventsDelivered.WithLabelValues(dest.label).Add(float64(events))
+			return nil
+		}
+		var perm *permanentError
+		if errors.As(err, &perm) || attempt >= l.maxRetries || ctx.Err() != nil {
+			l.metrics.WebhookRequests.WithLabelValues(dest.label, "failed").Inc()
+			return fmt.Errorf("webhook %s: %w", dest.label, err)
+		}
+		l.metrics.WebhookRequests.WithLabelValues(dest.label, "retried").Inc()
+
+		if wait == 0 {
+			wait = backoff
+			backoff = retry.NextBackoff(backoff, maxRetryBackoff)
+		}
+		wait = min(wait, maxRetryBackoff)
+		l.logger.Warn("webhook delivery failed, retrying",
+			"destination", dest.label, "delivery_id", deliveryID, "attempt", attempt+1, "backoff", wait, "error", err)
+		if !l.sleep(ctx, wait) {
+			return fmt.Errorf("webhook %s: %w", dest.label, ctx.Err())
+		}
+	}
+}
+
+// permanentError is a rejection that retrying will not fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// post sends one signed request. On a retryable failure it also returns the
+// wait the endpoint asked for with Retry-After, or zero.
+func (l *Loader) post(ctx context.Context, endpoint, deliveryID string, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, &permanentError{err}
+	}
+	timestamp := strconv.FormatInt(l.clock.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(headerDeliveryID, deliveryID)
+	req.Header.Set(headerTimestamp, timestamp)
+	req.Header.Set(headerSignature, sign(l.secret, timestamp, body))
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return 0, nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	err = fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	switch {
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return retryAfter(resp.Header.Get("Retry-After")), err
+	default:
+		return 0, &permanentError{err}
+	}
+}
+
+// retryAfter parses a Retry-After header given in seconds. HTTP dates and
+// invalid values yield zero, which falls back to exponential backoff.
+func retryAfter(v string) time.Duration {
+	secs, err := strconv.Atoi(v)
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// sign returns the X-Storm-Signature value for a request: "sha256=" and the
+// hex HMAC-SHA256 of the timestamp, a ".", and the body. Receivers recompute
+// it with the shared secret and should reject stale timestamps.
+func sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
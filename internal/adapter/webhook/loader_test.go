@@ -0,0 +1,201 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiver records deliveries and answers each with the next queued status.
+type receiver struct {
+	mu         sync.Mutex
+	statuses   []int
+	retryAfter string
+	requests   []*http.Request
+	bodies     [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	body, _ := io.ReadAll(req.Body)
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+
+	status := http.StatusNoContent
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	if r.retryAfter != "" {
+		w.Header().Set("Retry-After", r.retryAfter)
+	}
+	w.WriteHeader(status)
+}
+
+// newTestLoader points a loader at one TLS server per receiver.
+func newTestLoader(t *testing.T, maxRetries int, receivers ...*receiver) (*Loader, *[]time.Duration, *observability.Metrics) {
+	t.Helper()
+	var urls []string
+	var client *http.Client
+	for _, r := range receivers {
+		ts := httptest.NewTLSServer(r)
+		t.Cleanup(ts.Close)
+		urls = append(urls, ts.URL+"/storms?token=abc")
+		client = ts.Client()
+	}
+	metrics := observability.NewMetricsForTesting()
+	l := NewLoader(&config.Config{
+		WebhookURLs:         urls,
+		WebhookSecret:       "s3cret",
+		WebhookMaxRetries:   maxRetries,
+		WebhookRetryBackoff: 100 * time.Millisecond,
+	}, slog.New(slog.DiscardHandler), metrics)
+	// Every httptest TLS server shares one certificate, so any server's
+	// client trusts all of them.
+	l.client = client
+	var mu sync.Mutex
+	var waits []time.Duration
+	l.sleep = func(_ context.Context, d time.Duration) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		waits = append(waits, d)
+		return true
+	}
+	return l, &waits, metrics
+}
+
+func testEvents() []domain.StormEvent {
+	return []domain.StormEvent{
+		{ID: "hail-1", EventType: "hail", EventTime: time.Date(2024, time.April, 26, 15, 10, 0, 0, time.UTC)},
+		{ID: "torn-1", EventType: "tornado", Deleted: true},
+	}
+}
+
+func TestLoadBatch_SignedDelivery(t *testing.T) {
+	r := &receiver{}
+	l, waits, metrics := newTestLoader(t, 3, r)
+
+	require.NoError(t, l.LoadBatch(context.Background(), testEvents()))
+	require.Len(t, r.requests, 1)
+	req := r.requests[0]
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.Equal(t, deliveryID(testEvents()), req.Header.Get(headerDeliveryID))
+	assert.Equal(t, sign([]byte("s3cret"), req.Header.Get(headerTimestamp), r.bodies[0]), req.Header.Get(headerSignature))
+
+	var got struct {
+		Events []map[string]any `json:"events"`
+	}
+	require.NoError(t, json.Unmarshal(r.bodies[0], &got))
+	require.Len(t, got.Events, 2)
+	assert.Equal(t, "hail-1", got.Events[0]["id"])
+	assert.Equal(t, true, got.Events[1]["deleted"])
+	assert.Empty(t, *waits)
+
+	label := l.destinations[0].label
+	assert.NotContains(t, label, "token", "query strings stay out of metric labels")
+	assert.InDelta(t, 1, testutil.ToFloat64(metrics.WebhookRequests.WithLabelValues(label, "delivered")), 0)
+	assert.InDelta(t, 2, testutil.ToFloat64(metrics.WebhookEventsDelivered.WithLabelValues(label)), 0)
+}
+
+func TestLoadBatch_FieldProjection(t *testing.T) {
+	r := &receiver{}
+	l, _, _ := newTestLoader(t, 0, r)
+	fields, err := domain.ParseFieldProjection("id,event_type")
+	require.NoError(t, err)
+	l.fields = fields
+
+	require.NoError(t, l.LoadBatch(context.Background(), testEvents()[:1]))
+	assert.JSONEq(t, `{"events":[{"id":"hail-1","event_type":"hail"}]}`, string(r.bodies[0]))
+}
+
+func TestSign(t *testing.T) {
+	// HMAC-SHA256("secret", "1700000000.{}"), computed independently.
+	assert.Equal(t, "sha256=b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163", sign([]byte("secret"), "1700000000", []byte("{}")))
+	assert.NotEqual(t, sign([]byte("secret"), "1700000000", []byte("{}")), sign([]byte("secret"), "1700000001", []byte("{}")), "the timestamp is signed")
+}
+
+func TestDeliveryID_StableAcrossRetries(t *testing.T) {
+	assert.Equal(t, deliveryID(testEvents()), deliveryID(testEvents()))
+	assert.NotEqual(t, deliveryID(testEvents()), deliveryID(testEvents()[:1]))
+}
+
+func TestLoadBatch_RetriesTransientFailures(t *testing.T) {
+	r := &receiver{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}}
+	l, waits, metrics := newTestLoader(t, 3, r)
+
+	require.NoError(t, l.LoadBatch(context.Background(), testEvents()))
+	require.Len(t, r.requests, 3)
+	assert.Equal(t, r.requests[0].Header.Get(headerDeliveryID), r.requests[2].Header.Get(headerDeliveryID))
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}, *waits)
+	assert.InDelta(t, 2, testutil.ToFloat64(metrics.WebhookRequests.WithLabelValues(l.destinations[0].label, "retried")), 0)
+}
+
+func TestLoadBatch_HonorsRetryAfter(t *testing.T) {
+	r := &receiver{statuses: []int{http.StatusTooManyRequests}, retryAfter: "7"}
+	l, waits, _ := newTestLoader(t, 3, r)
+
+	require.NoError(t, l.LoadBatch(context.Background(), testEvents()))
+	assert.Equal(t, []time.Duration{7 * time.Second}, *waits)
+}
+
+func TestLoadBatch_RetriesExhausted(t *testing.T) {
+	r := &receiver{statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}}
+	l, _, metrics := newTestLoader(t, 2, r)
+
+	err := l.LoadBatch(context.Background(), testEvents())
+	require.ErrorContains(t, err, "unexpected status 502")
+	assert.Len(t, r.requests, 3)
+	assert.InDelta(t, 1, testutil.ToFloat64(metrics.WebhookRequests.WithLabelValues(l.destinations[0].label, "failed")), 0)
+}
+
+func TestLoadBatch_ClientErrorNotRetried(t *testing.T) {
+	r := &receiver{statuses: []int{http.StatusUnauthorized}}
+	l, waits, _ := newTestLoader(t, 3, r)
+
+	err := l.LoadBatch(context.Background(), testEvents())
+	require.ErrorContains(t, err, "unexpected status 401")
+	assert.Len(t, r.requests, 1)
+	assert.Empty(t, *waits)
+}
+
+func TestLoadBatch_MultipleDestinations(t *testing.T) {
+	ok := &receiver{}
+	failing := &receiver{statuses: []int{http.StatusBadRequest}}
+	l, _, _ := newTestLoader(t, 0, ok, failing)
+
+	err := l.LoadBatch(context.Background(), testEvents())
+	require.Error(t, err, "a failure at any destination fails the batch")
+	assert.Len(t, ok.requests, 1, "the other destinations still receive the batch")
+	assert.Len(t, failing.requests, 1)
+}
+
+func TestLoadBatch_Empty(t *testing.T) {
+	r := &receiver{}
+	l, _, _ := newTestLoader(t, 0, r)
+	require.NoError(t, l.LoadBatch(context.Background(), nil))
+	assert.Empty(t, r.requests)
+}
+
+func TestClose(t *testing.T) {
+	r := &receiver{}
+	l, _, _ := newTestLoader(t, 0, r)
+
+	require.NoError(t, l.LoadBatch(context.Background(), testEvents()))
+	require.NoError(t, l.Close())
+	require.NoError(t, l.LoadBatch(context.Background(), testEvents()), "a closed loader dials again")
+	assert.Len(t, r.requests, 2)
+}
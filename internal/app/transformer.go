@@ -0,0 +1,37 @@
+// Package app wires the pieces the commands share from the service
+// configuration, so a command that reproduces part of the service behaves
+// exactly as the service does.
+package app
+
+import (
+	"log/slog"
+
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/nwsalerts"
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+)
+
+// TransformerOptions returns the transformer options cfg selects: the
+// enrichment settings, the audit log, the nws_alerts stage, and comment
+// redaction, in the order the service registers them. cmd/etl and
+// cmd/transform both build their transformer from it. alertOpts configure
+// the alerts client, e.g. to inject faults.
+func TransformerOptions(cfg *config.Config, logger *slog.Logger, metrics *observability.Metrics, alertOpts ...nwsalerts.ClientOption) ([]pipeline.TransformerOption, error) {
+	enrichment, err := cfg.Enrichment()
+	if err != nil {
+		return nil, err
+	}
+	opts := []pipeline.TransformerOption{pipeline.WithEnrichment(enrichment)}
+	if cfg.AuditLog {
+		opts = append(opts, pipeline.WithAuditLog())
+	}
+	if cfg.NWSAlerts {
+		alerts := nwsalerts.NewClient(cfg, logger, metrics, alertOpts...)
+		opts = append(opts, pipeline.WithStageAfter(pipeline.StageGeocode, nwsalerts.StageName, alerts.Stage))
+	}
+	if cfg.CommentRedactor != nil {
+		opts = append(opts, pipeline.WithCommentRedaction(cfg.CommentRedactor, metrics.CommentRedactions))
+	}
+	return opts, nil
+}
@@ -0,0 +1,44 @@
+package app_test
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/nwsalerts"
+	"github.com/couchcryptid/storm-data-etl/internal/app"
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformerOptions(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	metrics := observability.NewMetricsForTesting()
+
+	opts, err := app.TransformerOptions(&config.Config{}, logger, metrics)
+	require.NoError(t, err)
+	assert.Equal(t, []string{pipeline.StageParse, pipeline.StageNormalize, pipeline.StageSeverity, pipeline.StageGeocode},
+		pipeline.NewTransformer(logger, opts...).Stages())
+
+	thresholds, err := domain.ParseSeverityThresholds("hail=2,3,4")
+	require.NoError(t, err)
+	cfg := &config.Config{
+		SeverityThresholds: thresholds,
+		NWSAlerts:          true,
+		NWSAlertsURL:       "http://127.0.0.1:0",
+		CommentRedactor:    domain.NewRedactor(nil, nil),
+	}
+	opts, err = app.TransformerOptions(cfg, logger, metrics)
+	require.NoError(t, err)
+	transformer := pipeline.NewTransformer(logger, opts...)
+	assert.Equal(t, []string{pipeline.StageParse, pipeline.StageNormalize, pipeline.StageSeverity, pipeline.StageGeocode, nwsalerts.StageName, pipeline.StageRedact},
+		transformer.Stages())
+	assert.Equal(t, thresholds, transformer.Enrichment().SeverityThresholds)
+
+	_, err = app.TransformerOptions(&config.Config{IDStrategy: "md5"}, logger, metrics)
+	require.ErrorContains(t, err, "ID_STRATEGY")
+}
@@ -2,35 +2,365 @@ package config
 
 import (
 	"errors"
-
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
 	sharedcfg "github.com/couchcryptid/storm-data-shared/config"
 )
 
+// Supported OUTPUT_FORMAT values for sink messages.
+const (
+	OutputFormatJSON     = "json"
+	OutputFormatProtobuf = "protobuf"
+	OutputFormatGeoJSON  = "geojson"
+)
+
+// Supported SOURCE_TYPE values selecting where raw reports are extracted from.
+const (
+	SourceKafka = "kafka"
+	SourceSPC   = "spc"
+	SourceFile  = "file"
+	SourceSQS   = "sqs"
+)
+
+// Supported KAFKA_SASL_MECHANISM values. An empty mechanism disables SASL.
+const (
+	SASLMechanismPlain       = "PLAIN"
+	SASLMechanismSCRAMSHA256 = "SCRAM-SHA-256"
+	SASLMechanismSCRAMSHA512 = "SCRAM-SHA-512"
+)
+
+// Admin actions that ADMIN_PERMISSIONS grants, each covering a group of
+// endpoints.
+const (
+	AdminActionRead    = "read"    // GET /admin/status, /admin/progress, /admin/dlq
+	AdminActionPause   = "pause"   // POST /admin/pause, /admin/resume
+	AdminActionReload  = "reload"  // POST /admin/reload
+	AdminActionRequeue = "requeue" // POST /admin/dlq/requeue
+	AdminActionDebug   = "debug"   // /debug/pprof/
+)
+
+// AdminActions lists the valid ADMIN_PERMISSIONS actions.
+var AdminActions = []string{AdminActionRead, AdminActionPause, AdminActionReload, AdminActionRequeue, AdminActionDebug}
+
+// Supported HTTP_TLS_CLIENT_AUTH values. With optional, clients without a
+// certificate (such as kubelet probes) are still served.
+const (
+	TLSClientAuthRequire  = "require"
+	TLSClientAuthOptional = "optional"
+)
+
+// Supported KAFKA_SINK_COMPRESSION codecs for sink messages. PARQUET_COMPRESSION
+// accepts the same names except lz4.
+const (
+	CompressionNone   = "none"
+	CompressionGzip   = "gzip"
+	CompressionSnappy = "snappy"
+	CompressionLZ4    = "lz4"
+	CompressionZstd   = "zstd"
+)
+
+// Supported KAFKA_SINK_KEY values for the sink message key.
+const (
+	SinkKeyID      = "id"
+	SinkKeyGeohash = "geohash"
+)
+
 // Config holds all service settings, populated from environment variables.
 type Config struct {
-	KafkaBrokers     []string
-	KafkaSourceTopic string
-	KafkaSinkTopic   string
-	KafkaGroupID     string
-	HTTPAddr         string
-	LogLevel         string
-	LogFormat        string
-	ShutdownTimeout  time.Duration
+	SourceType string
+
+	KafkaBrokers      []string
+	KafkaSourceTopics []string
+	KafkaSinkTopic    string
+	KafkaSinkEnabled  bool
+	KafkaDLQTopic     string
+	KafkaGroupID      string
+	OutputFormat      string
+	MeasurementUnits  domain.UnitSystem
+	ReportDay         domain.DayConvention
+	IDStrategy        string
+
+	// Source consumer fetch tuning, passed to the kafka-go reader. A zero
+	// KafkaSourceCommitInterval commits synchronously after every batch.
+	KafkaSourceMinBytes       int
+	KafkaSourceMaxBytes       int
+	KafkaSourceMaxWait        time.Duration
+	KafkaSourceQueueCapacity  int
+	KafkaSourceCommitInterval time.Duration
+
+	// SchemaVersion is the StormEvent schema written to KafkaSinkTopic.
+	// SchemaCompatVersions are additionally written to "<sink topic>.v<N>"
+	// while consumers migrate.
+	SchemaVersion        int
+	SchemaCompatVersions []int
+
+	// Sink producer tuning. Compression applies per produce request, so a
+	// larger KafkaSinkBatchSize (messages buffered per partition) compresses
+	// better. KafkaSinkAsync returns from LoadBatch before delivery, trading
+	// at-least-once delivery for throughput.
+	KafkaSinkCompression  string
+	KafkaSinkBatchSize    int
+	KafkaSinkBatchBytes   int
+	KafkaSinkBatchTimeout time.Duration
+	KafkaSinkMaxAttempts  int
+	KafkaSinkAsync        bool
+
+	// KafkaSinkKey selects the sink message key: the event ID, or the
+	// geohash of the event's coordinates truncated to KafkaSinkKeyPrecision
+	// characters, so nearby events share a partition.
+	KafkaSinkKey          string
+	KafkaSinkKeyPrecision int
+
+	// KafkaSinkSigningKey, when set, signs each sink message value with
+	// HMAC-SHA256; KafkaSinkSigningKeyID names the key so consumers can
+	// rotate keys without guessing which one signed a message.
+	KafkaSinkSigningKey   string
+	KafkaSinkSigningKeyID string
+
+	// KafkaSinkOversizePolicy, when set, checks each event's sink message
+	// against KafkaSinkMaxMessageBytes before loading and truncates the
+	// comments, drops the raw text, or dead-letters events that exceed it.
+	KafkaSinkMaxMessageBytes int
+	KafkaSinkOversizePolicy  string
+
+	// Kafka authentication and transport security.
+	KafkaSASLMechanism         string
+	KafkaSASLUsername          string
+	KafkaSASLPassword          string
+	KafkaTLSEnabled            bool
+	KafkaTLSCAFile             string
+	KafkaTLSCertFile           string
+	KafkaTLSKeyFile            string
+	KafkaTLSInsecureSkipVerify bool
+
+	// SPC extractor settings, used when SourceType is "spc".
+	SPCBaseURL      string
+	SPCPollInterval time.Duration
+	SPCLookbackDays int
+
+	// S3-compatible archive of transformed events, enabled when ArchiveS3Bucket is set.
+	ArchiveS3Bucket    string
+	ArchiveS3Prefix    string
+	ArchiveS3Region    string
+	ArchiveS3Endpoint  string
+	ArchiveS3PathStyle bool
+
+	// Parquet sink, enabled when ParquetPath is set: a local directory or
+	// s3://bucket/prefix. The S3 settings apply only to s3:// paths. Events
+	// are buffered per day and a file is written once it holds
+	// ParquetFileMaxEvents events or is ParquetFileMaxAge old.
+	ParquetPath          string
+	ParquetCompression   string
+	ParquetFileMaxEvents int
+	ParquetFileMaxAge    time.Duration
+	ParquetS3Region      string
+	ParquetS3Endpoint    string
+	ParquetS3PathStyle   bool
+
+	// Elasticsearch/OpenSearch sink, enabled when ElasticsearchURL is set.
+	// Authenticate with either an API key or a username and password.
+	ElasticsearchURL          string
+	ElasticsearchIndexPrefix  string
+	ElasticsearchUsername     string
+	ElasticsearchPassword     string
+	ElasticsearchAPIKey       string
+	ElasticsearchMaxRetries   int
+	ElasticsearchRetryBackoff time.Duration
+
+	// Webhook sink, enabled when WebhookURLs is set. Every endpoint receives
+	// each batch, signed with WebhookSecret.
+	WebhookURLs         []string
+	WebhookSecret       string
+	WebhookTimeout      time.Duration
+	WebhookMaxRetries   int
+	WebhookRetryBackoff time.Duration
+
+	// Output field projections, applied by each loader when it serializes an
+	// event; nil keeps every field. The Parquet and PostgreSQL sinks have
+	// fixed columns and are not projected.
+	KafkaSinkFields     *domain.FieldProjection
+	ArchiveFields       *domain.FieldProjection
+	ElasticsearchFields *domain.FieldProjection
+	WebhookFields       *domain.FieldProjection
+
+	// Schema registry the sink's JSON Schema is registered with at startup,
+	// enabled when SchemaRegistryURL is set.
+	SchemaRegistryURL      string
+	SchemaRegistrySubject  string
+	SchemaRegistryUsername string
+	SchemaRegistryPassword string
+
+	// Direct PostgreSQL/TimescaleDB sink, enabled when PostgresDSN is set.
+	PostgresDSN     string
+	PostgresMigrate bool
+
+	// FileSourcePath is a file, directory, or glob read when SourceType is "file".
+	FileSourcePath string
+
+	// SQS extractor settings, used when SourceType is "sqs". SQSSNSEnvelope
+	// unwraps messages delivered by an SNS subscription without raw delivery.
+	SQSQueueURL          string
+	SQSRegion            string
+	SQSEndpoint          string
+	SQSWaitTime          time.Duration
+	SQSVisibilityTimeout time.Duration
+	SQSSNSEnvelope       bool
+
+	// OpenTelemetry tracing, exported over OTLP/HTTP when OTLPEndpoint is set.
+	OTLPEndpoint       string
+	TracingServiceName string
+	TracingSampleRatio float64
+
+	HTTPAddr        string
+	AdminToken      string
+	PprofEnabled    bool
+	LogLevel        string
+	LogFormat       string
+	ShutdownTimeout time.Duration
+
+	// HTTPS for the health, metrics, and admin server. Setting HTTPTLSCertFile
+	// and HTTPTLSKeyFile enables TLS; HTTPTLSClientCAFile also verifies client
+	// certificates, required or optional per HTTPTLSClientAuth.
+	HTTPTLSCertFile     string
+	HTTPTLSKeyFile      string
+	HTTPTLSClientCAFile string
+	HTTPTLSClientAuth   string
+
+	// Admin API principals besides AdminToken (principal "admin"): named bearer
+	// tokens, and with AdminClientCertAuth the common name of a verified client
+	// certificate. AdminPermissions lists the principals allowed each admin
+	// action; an action without an entry is open to every principal.
+	AdminTokens         map[string]string
+	AdminClientCertAuth bool
+	AdminPermissions    map[string][]string
+
+	// Live event streaming. GRPCAddr enables the gRPC StreamEvents API and
+	// StreamWebSocket the GET /events/stream WebSocket feed, which accepts
+	// browsers from StreamAllowedOrigins (any origin when empty). Each stream
+	// subscriber buffers up to StreamBuffer events before dropping.
+	GRPCAddr             string
+	StreamWebSocket      bool
+	StreamAllowedOrigins []string
+	StreamBuffer         int
+
+	// Up to LogSampleBurst warnings or errors with the same message are logged
+	// per LogSampleInterval; further repeats are counted and summarized when
+	// the interval ends. Zero disables sampling.
+	LogSampleBurst    int
+	LogSampleInterval time.Duration
+
+	// MetricsStateLabel adds a state label to the per-event-type counters.
+	MetricsStateLabel bool
+
+	// AuditLog logs every normalization decision per event for lineage reviews.
+	AuditLog bool
 
 	BatchSize          int
 	BatchFlushInterval time.Duration
+
+	// MaxEventsPerSecond caps pipeline throughput; 0 means unlimited.
+	MaxEventsPerSecond float64
+
+	// LoaderCircuitThreshold consecutive load failures open the loader circuit
+	// breaker for LoaderCircuitCooldown; 0 disables it.
+	LoaderCircuitThreshold int
+	LoaderCircuitCooldown  time.Duration
+
+	TransformConcurrency int
+
+	// OrderedProcessing keeps each source partition's events in order through
+	// transformation and keys sink messages by the source message key.
+	OrderedProcessing bool
+
+	// SeverityThresholds override the default severity levels per event type.
+	SeverityThresholds map[string]domain.SeverityThresholds
+
+	// SeverityKeywordRules raises the magnitude-derived severity when the
+	// comments describe a high-impact outcome, such as a fatality.
+	SeverityKeywordRules bool
+
+	// EventDurations override the default window per event type used to
+	// estimate end times when the comments give no duration.
+	EventDurations map[string]time.Duration
+
+	// NearbyPlacesRadius is the radius in miles within which listed places
+	// are counted toward an event's nearby_places.
+	NearbyPlacesRadius float64
+
+	// GeohashPrecision is the length of the geohash emitted for each event.
+	GeohashPrecision int
+
+	// NWSBoundaries are the CWA and forecast zone polygons read from
+	// NWS_BOUNDARIES_FILE, or the embedded ones when it is unset.
+	NWSBoundaries *domain.Boundaries
+
+	// NWSAlerts correlates each event with the NWS warnings in effect at its
+	// time and place, looked up in the api.weather.gov alerts API at
+	// NWSAlertsURL. The API asks callers to identify themselves with a
+	// User-Agent naming the application and a contact. Responses are cached
+	// for NWSAlertsCacheTTL per point and hour; 0 disables the cache.
+	NWSAlerts          bool
+	NWSAlertsURL       string
+	NWSAlertsUserAgent string
+	NWSAlertsTimeout   time.Duration
+	NWSAlertsCacheTTL  time.Duration
+
+	// CommentRedactor removes personal details from comments before the
+	// events are loaded; nil leaves comments as reported.
+	CommentRedactor *domain.Redactor
+
+	// ReloadFile holds reloadable overrides re-read on SIGHUP or POST /admin/reload.
+	ReloadFile string
+
+	// ProgressFile persists per-partition progress across restarts; empty
+	// keeps it in memory only.
+	ProgressFile string
+
+	// EventFilter drops enriched events before they are loaded; the zero
+	// value loads everything.
+	EventFilter domain.EventFilter
+
+	// ValidationRules pass, annotate, quarantine, or drop enriched events
+	// that fail a named check, before EventFilter applies.
+	ValidationRules []domain.ValidationRule
+
+	// DuplicateMerge merges near-duplicate reports of the same storm within
+	// DuplicateMergeTolerance into the higher-quality report.
+	DuplicateMerge          bool
+	DuplicateMergeTolerance domain.MergeTolerance
+
+	// EpisodeClustering assigns reports within EpisodeTolerance of an
+	// earlier report to that report's storm episode.
+	EpisodeClustering bool
+	EpisodeTolerance  domain.EpisodeTolerance
+
+	// ProvenanceHeaders names the source message headers copied into each
+	// event's provenance and onto its sink message.
+	ProvenanceHeaders []string
 }
 
-// Load reads configuration from environment variables, applying defaults where unset.
+// reservedSinkHeaders are set by the Kafka sink writer and cannot be copied
+// from the source with PROVENANCE_HEADERS.
+var reservedSinkHeaders = []string{"event_type", "processed_at", "schema_version", "content_type", "event_id", "correlation_id", "traceparent", "tracestate", "signature", "signature_key_id"}
+
+// Load reads configuration from environment variables, applying defaults where
+// unset. Reloadable settings in CONFIG_RELOAD_FILE take precedence over the environment.
 func Load() (*Config, error) {
 	shutdownTimeout, err := sharedcfg.ParseShutdownTimeout()
 	if err != nil {
 		return nil, err
 	}
 
-	batchSize, err := sharedcfg.ParseBatchSize()
+	reloadFile := os.Getenv("CONFIG_RELOAD_FILE")
+	reloadable, err := LoadReloadable(reloadFile)
 	if err != nil {
 		return nil, err
 	}
@@ -40,28 +370,1136 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	transformConcurrency, err := parsePositiveInt("TRANSFORM_CONCURRENCY", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	circuitThreshold, err := parseIntRange("LOADER_CIRCUIT_THRESHOLD", 5, 0, 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	circuitCooldown, err := parseDuration("LOADER_CIRCUIT_COOLDOWN", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	pprofEnabled, err := parseBool("PPROF_ENABLED", false)
+	if err != nil {
+		return nil, err
+	}
+
+	metricsStateLabel, err := parseBool("METRICS_STATE_LABEL", false)
+	if err != nil {
+		return nil, err
+	}
+
+	auditLog, err := parseBool("AUDIT_LOG", false)
+	if err != nil {
+		return nil, err
+	}
+
+	ordered, err := parseBool("ORDERED_PROCESSING", false)
+	if err != nil {
+		return nil, err
+	}
+
+	keywordRules, err := parseBool("SEVERITY_KEYWORD_RULES", false)
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := &Config{
+		SourceType:         sharedcfg.EnvOrDefault("SOURCE_TYPE", SourceKafka),
 		KafkaBrokers:       sharedcfg.ParseBrokers(sharedcfg.EnvOrDefault("KAFKA_BROKERS", "kafka:9092")),
-		KafkaSourceTopic:   sharedcfg.EnvOrDefault("KAFKA_SOURCE_TOPIC", "raw-weather-reports"),
+		KafkaSourceTopics:  parseList(sharedcfg.EnvOrDefault("KAFKA_SOURCE_TOPIC", "raw-weather-reports")),
 		KafkaSinkTopic:     sharedcfg.EnvOrDefault("KAFKA_SINK_TOPIC", "transformed-weather-data"),
+		KafkaDLQTopic:      sharedcfg.EnvOrDefault("KAFKA_DLQ_TOPIC", ""),
 		KafkaGroupID:       sharedcfg.EnvOrDefault("KAFKA_GROUP_ID", "storm-data-etl"),
+		OutputFormat:       sharedcfg.EnvOrDefault("OUTPUT_FORMAT", OutputFormatJSON),
+		MeasurementUnits:   domain.UnitSystem(sharedcfg.EnvOrDefault("MEASUREMENT_UNITS", string(domain.UnitsImperial))),
+		ReportDay:          domain.DayConvention(sharedcfg.EnvOrDefault("REPORT_DAY_CONVENTION", string(domain.DaySPC))),
+		IDStrategy:         sharedcfg.EnvOrDefault("ID_STRATEGY", domain.IDStrategySHA256),
+		FileSourcePath:     os.Getenv("FILE_SOURCE_PATH"),
 		HTTPAddr:           sharedcfg.EnvOrDefault("HTTP_ADDR", ":8080"),
-		LogLevel:           sharedcfg.EnvOrDefault("LOG_LEVEL", "info"),
+		AdminToken:         os.Getenv("ADMIN_TOKEN"),
+		PprofEnabled:       pprofEnabled,
+		MetricsStateLabel:  metricsStateLabel,
+		AuditLog:           auditLog,
+		LogLevel:           reloadable.LogLevel,
 		LogFormat:          sharedcfg.EnvOrDefault("LOG_FORMAT", "json"),
 		ShutdownTimeout:    shutdownTimeout,
-		BatchSize:          batchSize,
+		BatchSize:          reloadable.BatchSize,
 		BatchFlushInterval: flushInterval,
+		MaxEventsPerSecond: reloadable.MaxEventsPerSecond,
+
+		TransformConcurrency:   transformConcurrency,
+		OrderedProcessing:      ordered,
+		LoaderCircuitThreshold: circuitThreshold,
+		LoaderCircuitCooldown:  circuitCooldown,
+		SeverityThresholds:     reloadable.SeverityThresholds,
+		SeverityKeywordRules:   keywordRules,
+		ReloadFile:             reloadFile,
+		ProgressFile:           os.Getenv("PROGRESS_FILE"),
 	}
 
-	if len(cfg.KafkaBrokers) == 0 {
-		return nil, errors.New("KAFKA_BROKERS is required")
+	if err := loadKafkaSecurity(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadKafkaSource(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadSPC(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadSQS(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadSinks(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadSinkProducer(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadSinkKey(cfg); err != nil {
+		return nil, err
 	}
-	if cfg.KafkaSourceTopic == "" {
-		return nil, errors.New("KAFKA_SOURCE_TOPIC is required")
+	if err := loadSinkSigning(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadSinkSizeLimit(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadParquet(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadElasticsearch(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadWebhook(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadFieldProjections(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadSchemaRegistry(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadTracing(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadHTTPTLS(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadAdminAuth(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadStreaming(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadLogSampling(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadSchemaVersions(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadFilter(cfg); err != nil {
+		return nil, err
 	}
-	if cfg.KafkaSinkTopic == "" {
-		return nil, errors.New("KAFKA_SINK_TOPIC is required")
+	if err := loadEventDurations(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadNearbyPlaces(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadGeohash(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadBoundaries(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadNWSAlerts(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadRedaction(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadValidationRules(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadProvenance(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadDuplicateMerge(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadEpisodeClustering(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
 	}
 
 	return cfg, nil
 }
+
+// validate checks cross-field constraints after all settings are loaded.
+func (c *Config) validate() error {
+	if err := c.validateSource(); err != nil {
+		return err
+	}
+	if len(c.KafkaBrokers) == 0 {
+		return errors.New("KAFKA_BROKERS is required")
+	}
+	if err := c.validateSinks(); err != nil {
+		return err
+	}
+	if _, err := domain.IDStrategyByName(c.IDStrategy); err != nil {
+		return fmt.Errorf("invalid ID_STRATEGY %q: must be one of %s", c.IDStrategy, strings.Join(domain.IDStrategyNames(), ", "))
+	}
+	if c.KafkaDLQTopic == "" && slices.ContainsFunc(c.ValidationRules, func(r domain.ValidationRule) bool { return r.Action == domain.RuleActionQuarantine }) {
+		return errors.New("KAFKA_DLQ_TOPIC is required when a VALIDATION_RULES rule quarantines")
+	}
+	if c.KafkaDLQTopic != "" && (slices.Contains(c.KafkaSourceTopics, c.KafkaDLQTopic) || c.KafkaDLQTopic == c.KafkaSinkTopic) {
+		return errors.New("KAFKA_DLQ_TOPIC must differ from the source and sink topics")
+	}
+	return nil
+}
+
+func (c *Config) validateSource() error {
+	switch c.SourceType {
+	case SourceKafka, SourceSPC:
+	case SourceFile:
+		if c.FileSourcePath == "" {
+			return errors.New("FILE_SOURCE_PATH is required when SOURCE_TYPE is file")
+		}
+	case SourceSQS:
+		if c.SQSQueueURL == "" {
+			return errors.New("SQS_QUEUE_URL is required when SOURCE_TYPE is sqs")
+		}
+	default:
+		return fmt.Errorf("invalid SOURCE_TYPE %q: must be kafka, spc, file, or sqs", c.SourceType)
+	}
+	if len(c.KafkaSourceTopics) == 0 {
+		return errors.New("KAFKA_SOURCE_TOPIC is required")
+	}
+	return nil
+}
+
+func (c *Config) validateSinks() error {
+	if !c.KafkaSinkEnabled && c.PostgresDSN == "" && c.ArchiveS3Bucket == "" && c.ParquetPath == "" && c.ElasticsearchURL == "" && len(c.WebhookURLs) == 0 {
+		return errors.New("no sink configured: enable KAFKA_SINK_ENABLED or set POSTGRES_DSN, ARCHIVE_S3_BUCKET, PARQUET_PATH, ELASTICSEARCH_URL, or WEBHOOK_URL")
+	}
+	if c.KafkaSinkEnabled {
+		if c.KafkaSinkTopic == "" {
+			return errors.New("KAFKA_SINK_TOPIC is required")
+		}
+		if slices.Contains(c.KafkaSourceTopics, c.KafkaSinkTopic) {
+			return errors.New("KAFKA_SINK_TOPIC must not be one of the source topics")
+		}
+	}
+	switch c.OutputFormat {
+	case OutputFormatJSON, OutputFormatProtobuf, OutputFormatGeoJSON:
+	default:
+		return fmt.Errorf("invalid OUTPUT_FORMAT %q: must be json, protobuf, or geojson", c.OutputFormat)
+	}
+	switch c.MeasurementUnits {
+	case domain.UnitsImperial, domain.UnitsMetric, domain.UnitsBoth:
+	default:
+		return fmt.Errorf("invalid MEASUREMENT_UNITS %q: must be imperial, metric, or both", c.MeasurementUnits)
+	}
+	switch c.ReportDay {
+	case domain.DaySPC, domain.DayCalendar:
+	default:
+		return fmt.Errorf("invalid REPORT_DAY_CONVENTION %q: must be spc or calendar", c.ReportDay)
+	}
+	return nil
+}
+
+// loadKafkaSecurity reads the SASL and TLS settings for the Kafka adapters.
+// Setting any client certificate, CA file, or skip-verify flag implies TLS.
+func loadKafkaSecurity(cfg *Config) error {
+	tlsEnabled, err := parseBool("KAFKA_TLS_ENABLED", false)
+	if err != nil {
+		return err
+	}
+	insecure, err := parseBool("KAFKA_TLS_INSECURE_SKIP_VERIFY", false)
+	if err != nil {
+		return err
+	}
+
+	cfg.KafkaSASLMechanism = strings.ToUpper(os.Getenv("KAFKA_SASL_MECHANISM"))
+	cfg.KafkaSASLUsername = os.Getenv("KAFKA_SASL_USERNAME")
+	cfg.KafkaSASLPassword = os.Getenv("KAFKA_SASL_PASSWORD")
+	cfg.KafkaTLSCAFile = os.Getenv("KAFKA_TLS_CA_FILE")
+	cfg.KafkaTLSCertFile = os.Getenv("KAFKA_TLS_CERT_FILE")
+	cfg.KafkaTLSKeyFile = os.Getenv("KAFKA_TLS_KEY_FILE")
+	cfg.KafkaTLSInsecureSkipVerify = insecure
+	cfg.KafkaTLSEnabled = tlsEnabled || insecure ||
+		cfg.KafkaTLSCAFile != "" || cfg.KafkaTLSCertFile != "" || cfg.KafkaTLSKeyFile != ""
+
+	switch cfg.KafkaSASLMechanism {
+	case "":
+	case SASLMechanismPlain, SASLMechanismSCRAMSHA256, SASLMechanismSCRAMSHA512:
+		if cfg.KafkaSASLUsername == "" || cfg.KafkaSASLPassword == "" {
+			return errors.New("KAFKA_SASL_USERNAME and KAFKA_SASL_PASSWORD are required when KAFKA_SASL_MECHANISM is set")
+		}
+	default:
+		return fmt.Errorf("invalid KAFKA_SASL_MECHANISM %q: must be PLAIN, SCRAM-SHA-256, or SCRAM-SHA-512", cfg.KafkaSASLMechanism)
+	}
+
+	if (cfg.KafkaTLSCertFile == "") != (cfg.KafkaTLSKeyFile == "") {
+		return errors.New("KAFKA_TLS_CERT_FILE and KAFKA_TLS_KEY_FILE must be set together")
+	}
+	return nil
+}
+
+// loadKafkaSource reads the fetch and commit settings of the source consumer.
+func loadKafkaSource(cfg *Config) error {
+	minBytes, err := parseIntRange("KAFKA_SOURCE_MIN_BYTES", 1, 1, 100<<20)
+	if err != nil {
+		return err
+	}
+	maxBytes, err := parseIntRange("KAFKA_SOURCE_MAX_BYTES", 10e6, 1<<10, 1<<30)
+	if err != nil {
+		return err
+	}
+	if minBytes > maxBytes {
+		return errors.New("KAFKA_SOURCE_MIN_BYTES must not exceed KAFKA_SOURCE_MAX_BYTES")
+	}
+	maxWait, err := parseDuration("KAFKA_SOURCE_MAX_WAIT", 10*time.Second)
+	if err != nil {
+		return err
+	}
+	queueCapacity, err := parseIntRange("KAFKA_SOURCE_QUEUE_CAPACITY", 100, 1, 100000)
+	if err != nil {
+		return err
+	}
+	commitInterval, err := parseNonNegativeDuration("KAFKA_SOURCE_COMMIT_INTERVAL", 0)
+	if err != nil {
+		return err
+	}
+	cfg.KafkaSourceMinBytes = minBytes
+	cfg.KafkaSourceMaxBytes = maxBytes
+	cfg.KafkaSourceMaxWait = maxWait
+	cfg.KafkaSourceQueueCapacity = queueCapacity
+	cfg.KafkaSourceCommitInterval = commitInterval
+	return nil
+}
+
+// loadSPC reads the settings for the direct SPC CSV extractor.
+func loadSPC(cfg *Config) error {
+	pollInterval, err := parseDuration("SPC_POLL_INTERVAL", 15*time.Minute)
+	if err != nil {
+		return err
+	}
+	lookback, err := parseIntRange("SPC_LOOKBACK_DAYS", 1, 0, 7)
+	if err != nil {
+		return err
+	}
+	cfg.SPCBaseURL = sharedcfg.EnvOrDefault("SPC_BASE_URL", "https://www.spc.noaa.gov/climo/reports")
+	cfg.SPCPollInterval = pollInterval
+	cfg.SPCLookbackDays = lookback
+	return nil
+}
+
+// loadSQS reads the settings for the SQS extractor. Both durations are sent
+// to SQS in whole seconds, within the limits the service accepts.
+func loadSQS(cfg *Config) error {
+	waitTime, err := parseNonNegativeDuration("SQS_WAIT_TIME", 10*time.Second)
+	if err != nil {
+		return err
+	}
+	if waitTime > 20*time.Second {
+		return errors.New("invalid SQS_WAIT_TIME: must be at most 20s")
+	}
+	visibility, err := parseDuration("SQS_VISIBILITY_TIMEOUT", time.Minute)
+	if err != nil {
+		return err
+	}
+	if visibility < time.Second || visibility > 12*time.Hour {
+		return errors.New("invalid SQS_VISIBILITY_TIMEOUT: must be between 1s and 12h")
+	}
+	snsEnvelope, err := parseBool("SQS_SNS_ENVELOPE", false)
+	if err != nil {
+		return err
+	}
+	cfg.SQSQueueURL = os.Getenv("SQS_QUEUE_URL")
+	cfg.SQSRegion = os.Getenv("SQS_REGION")
+	cfg.SQSEndpoint = os.Getenv("SQS_ENDPOINT")
+	cfg.SQSWaitTime = waitTime
+	cfg.SQSVisibilityTimeout = visibility
+	cfg.SQSSNSEnvelope = snsEnvelope
+	return nil
+}
+
+// loadSinks reads which loaders receive transformed events: the Kafka sink
+// topic, the optional S3 archive, and the optional PostgreSQL sink. AWS
+// credentials are resolved by the SDK's default chain and are not part of Config.
+func loadSinks(cfg *Config) error {
+	kafkaSink, err := parseBool("KAFKA_SINK_ENABLED", true)
+	if err != nil {
+		return err
+	}
+	pathStyle, err := parseBool("ARCHIVE_S3_PATH_STYLE", false)
+	if err != nil {
+		return err
+	}
+	migrate, err := parseBool("POSTGRES_MIGRATE", true)
+	if err != nil {
+		return err
+	}
+	cfg.KafkaSinkEnabled = kafkaSink
+	cfg.PostgresDSN = os.Getenv("POSTGRES_DSN")
+	cfg.PostgresMigrate = migrate
+	cfg.ArchiveS3Bucket = os.Getenv("ARCHIVE_S3_BUCKET")
+	cfg.ArchiveS3Prefix = strings.Trim(sharedcfg.EnvOrDefault("ARCHIVE_S3_PREFIX", "storm-events"), "/")
+	cfg.ArchiveS3Region = os.Getenv("ARCHIVE_S3_REGION")
+	cfg.ArchiveS3Endpoint = os.Getenv("ARCHIVE_S3_ENDPOINT")
+	cfg.ArchiveS3PathStyle = pathStyle
+	return nil
+}
+
+// loadSinkProducer reads the compression, batching, and delivery settings of
+// the Kafka sink producer.
+func loadSinkProducer(cfg *Config) error {
+	batchSize, err := parseIntRange("KAFKA_SINK_BATCH_SIZE", 100, 1, 10000)
+	if err != nil {
+		return err
+	}
+	batchBytes, err := parseIntRange("KAFKA_SINK_BATCH_BYTES", 1<<20, 1<<10, 100<<20)
+	if err != nil {
+		return err
+	}
+	batchTimeout, err := parseDuration("KAFKA_SINK_BATCH_TIMEOUT", 10*time.Millisecond)
+	if err != nil {
+		return err
+	}
+	maxAttempts, err := parseIntRange("KAFKA_SINK_MAX_ATTEMPTS", 10, 1, 100)
+	if err != nil {
+		return err
+	}
+	async, err := parseBool("KAFKA_SINK_ASYNC", false)
+	if err != nil {
+		return err
+	}
+	compression := strings.ToLower(sharedcfg.EnvOrDefault("KAFKA_SINK_COMPRESSION", CompressionNone))
+	switch compression {
+	case CompressionNone, CompressionGzip, CompressionSnappy, CompressionLZ4, CompressionZstd:
+	default:
+		return fmt.Errorf("invalid KAFKA_SINK_COMPRESSION %q: must be none, gzip, snappy, lz4, or zstd", compression)
+	}
+	cfg.KafkaSinkCompression = compression
+	cfg.KafkaSinkBatchSize = batchSize
+	cfg.KafkaSinkBatchBytes = batchBytes
+	cfg.KafkaSinkBatchTimeout = batchTimeout
+	cfg.KafkaSinkMaxAttempts = maxAttempts
+	cfg.KafkaSinkAsync = async
+	return nil
+}
+
+// loadSinkKey reads how sink messages are keyed. A geohash key replaces the
+// event ID just as the ordering key does, so the two cannot be combined.
+func loadSinkKey(cfg *Config) error {
+	precision, err := parseIntRange("KAFKA_SINK_KEY_PRECISION", 4, 1, domain.MaxGeohashPrecision)
+	if err != nil {
+		return err
+	}
+	key := strings.ToLower(sharedcfg.EnvOrDefault("KAFKA_SINK_KEY", SinkKeyID))
+	switch key {
+	case SinkKeyID:
+	case SinkKeyGeohash:
+		if cfg.OrderedProcessing {
+			return errors.New("KAFKA_SINK_KEY=geohash cannot be combined with ORDERED_PROCESSING")
+		}
+	default:
+		return fmt.Errorf("invalid KAFKA_SINK_KEY %q: must be id or geohash", key)
+	}
+	cfg.KafkaSinkKey = key
+	cfg.KafkaSinkKeyPrecision = precision
+	return nil
+}
+
+// loadSinkSigning reads the key sink message values are signed with.
+func loadSinkSigning(cfg *Config) error {
+	cfg.KafkaSinkSigningKey = os.Getenv("KAFKA_SINK_SIGNING_KEY")
+	cfg.KafkaSinkSigningKeyID = os.Getenv("KAFKA_SINK_SIGNING_KEY_ID")
+	if cfg.KafkaSinkSigningKeyID != "" && cfg.KafkaSinkSigningKey == "" {
+		return errors.New("KAFKA_SINK_SIGNING_KEY_ID requires KAFKA_SINK_SIGNING_KEY")
+	}
+	return nil
+}
+
+// loadSinkSizeLimit reads the sink message size limit and the policy for
+// events that exceed it. The limit defaults to KAFKA_SINK_BATCH_BYTES, above
+// which the producer rejects a message outright.
+func loadSinkSizeLimit(cfg *Config) error {
+	maxBytes, err := parseIntRange("KAFKA_SINK_MAX_MESSAGE_BYTES", cfg.KafkaSinkBatchBytes, 1<<10, cfg.KafkaSinkBatchBytes)
+	if err != nil {
+		return err
+	}
+	policy := strings.ToLower(os.Getenv("KAFKA_SINK_OVERSIZE_POLICY"))
+	if policy == "" {
+		if os.Getenv("KAFKA_SINK_MAX_MESSAGE_BYTES") != "" {
+			return errors.New("KAFKA_SINK_MAX_MESSAGE_BYTES requires KAFKA_SINK_OVERSIZE_POLICY")
+		}
+		return nil
+	}
+	if !slices.Contains(domain.OversizePolicies, policy) {
+		return fmt.Errorf("invalid KAFKA_SINK_OVERSIZE_POLICY %q: must be one of %s", policy, strings.Join(domain.OversizePolicies, ", "))
+	}
+	if !cfg.KafkaSinkEnabled {
+		return errors.New("KAFKA_SINK_OVERSIZE_POLICY requires KAFKA_SINK_ENABLED")
+	}
+	cfg.KafkaSinkMaxMessageBytes = maxBytes
+	cfg.KafkaSinkOversizePolicy = policy
+	return nil
+}
+
+// loadParquet reads the Parquet sink location, file compression, and file
+// rolling limits.
+func loadParquet(cfg *Config) error {
+	pathStyle, err := parseBool("PARQUET_S3_PATH_STYLE", false)
+	if err != nil {
+		return err
+	}
+	maxEvents, err := parsePositiveInt("PARQUET_FILE_MAX_EVENTS", 100000)
+	if err != nil {
+		return err
+	}
+	maxAge, err := parseDuration("PARQUET_FILE_MAX_AGE", 5*time.Minute)
+	if err != nil {
+		return err
+	}
+	compression := strings.ToLower(sharedcfg.EnvOrDefault("PARQUET_COMPRESSION", CompressionSnappy))
+	switch compression {
+	case CompressionNone, CompressionGzip, CompressionSnappy, CompressionZstd:
+	default:
+		return fmt.Errorf("invalid PARQUET_COMPRESSION %q: must be none, gzip, snappy, or zstd", compression)
+	}
+	cfg.ParquetPath = os.Getenv("PARQUET_PATH")
+	if bucket, _, ok := ParseS3URL(cfg.ParquetPath); ok && bucket == "" {
+		return fmt.Errorf("invalid PARQUET_PATH %q: missing bucket", cfg.ParquetPath)
+	}
+	cfg.ParquetCompression = compression
+	cfg.ParquetFileMaxEvents = maxEvents
+	cfg.ParquetFileMaxAge = maxAge
+	cfg.ParquetS3Region = os.Getenv("PARQUET_S3_REGION")
+	cfg.ParquetS3Endpoint = os.Getenv("PARQUET_S3_ENDPOINT")
+	cfg.ParquetS3PathStyle = pathStyle
+	return nil
+}
+
+// loadElasticsearch reads the Elasticsearch/OpenSearch sink settings.
+func loadElasticsearch(cfg *Config) error {
+	maxRetries, err := parseIntRange("ELASTICSEARCH_MAX_RETRIES", 5, 0, 20)
+	if err != nil {
+		return err
+	}
+	backoff, err := parseDuration("ELASTICSEARCH_RETRY_BACKOFF", 500*time.Millisecond)
+	if err != nil {
+		return err
+	}
+	cfg.ElasticsearchURL = os.Getenv("ELASTICSEARCH_URL")
+	cfg.ElasticsearchIndexPrefix = sharedcfg.EnvOrDefault("ELASTICSEARCH_INDEX_PREFIX", "storm-events")
+	cfg.ElasticsearchUsername = os.Getenv("ELASTICSEARCH_USERNAME")
+	cfg.ElasticsearchPassword = os.Getenv("ELASTICSEARCH_PASSWORD")
+	cfg.ElasticsearchAPIKey = os.Getenv("ELASTICSEARCH_API_KEY")
+	cfg.ElasticsearchMaxRetries = maxRetries
+	cfg.ElasticsearchRetryBackoff = backoff
+
+	if cfg.ElasticsearchURL == "" {
+		return nil
+	}
+	if u, err := url.Parse(cfg.ElasticsearchURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid ELASTICSEARCH_URL %q: must be an http or https URL", cfg.ElasticsearchURL)
+	}
+	// Index names must be lowercase and must not start with _, -, or +.
+	if p := cfg.ElasticsearchIndexPrefix; p != strings.ToLower(p) || strings.ContainsAny(p[:1], "_-+") {
+		return fmt.Errorf("invalid ELASTICSEARCH_INDEX_PREFIX %q: must be lowercase and not start with _, -, or +", p)
+	}
+	if cfg.ElasticsearchAPIKey != "" && cfg.ElasticsearchUsername != "" {
+		return errors.New("set either ELASTICSEARCH_API_KEY or ELASTICSEARCH_USERNAME, not both")
+	}
+	return nil
+}
+
+// loadWebhook reads the webhook sink settings. WEBHOOK_URL accepts a
+// comma-separated list of HTTPS endpoints; signing is mandatory, so a secret
+// is required whenever an endpoint is set.
+func loadWebhook(cfg *Config) error {
+	timeout, err := parseDuration("WEBHOOK_TIMEOUT", 10*time.Second)
+	if err != nil {
+		return err
+	}
+	maxRetries, err := parseIntRange("WEBHOOK_MAX_RETRIES", 5, 0, 20)
+	if err != nil {
+		return err
+	}
+	backoff, err := parseDuration("WEBHOOK_RETRY_BACKOFF", 500*time.Millisecond)
+	if err != nil {
+		return err
+	}
+	cfg.WebhookURLs = parseList(os.Getenv("WEBHOOK_URL"))
+	cfg.WebhookSecret = os.Getenv("WEBHOOK_SECRET")
+	cfg.WebhookTimeout = timeout
+	cfg.WebhookMaxRetries = maxRetries
+	cfg.WebhookRetryBackoff = backoff
+
+	if len(cfg.WebhookURLs) == 0 {
+		return nil
+	}
+	for _, raw := range cfg.WebhookURLs {
+		if u, err := url.Parse(raw); err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("invalid WEBHOOK_URL %q: must be an https URL", raw)
+		}
+	}
+	if cfg.WebhookSecret == "" {
+		return errors.New("WEBHOOK_SECRET is required when WEBHOOK_URL is set")
+	}
+	return nil
+}
+
+// ParseS3URL splits "s3://bucket/prefix" into its bucket and key prefix,
+// trimming slashes from the prefix. ok is false when s is not an s3:// URL.
+func ParseS3URL(s string) (bucket, prefix string, ok bool) {
+	rest, ok := strings.CutPrefix(s, "s3://")
+	if !ok {
+		return "", "", false
+	}
+	bucket, prefix, _ = strings.Cut(rest, "/")
+	return bucket, strings.Trim(prefix, "/"), true
+}
+
+// loadFieldProjections reads the fields each JSON loader includes or
+// excludes and how it names them: <PREFIX>_FIELDS, <PREFIX>_FIELD_NAMING, and
+// <PREFIX>_FLATTEN. The protobuf encoding has a fixed schema, so the Kafka
+// sink can only be projected with a JSON output format.
+func loadFieldProjections(cfg *Config) error {
+	for _, p := range []struct {
+		prefix string
+		target **domain.FieldProjection
+	}{
+		{"KAFKA_SINK", &cfg.KafkaSinkFields},
+		{"ARCHIVE_S3", &cfg.ArchiveFields},
+		{"ELASTICSEARCH", &cfg.ElasticsearchFields},
+		{"WEBHOOK", &cfg.WebhookFields},
+	} {
+		projection, err := domain.ParseFieldProjection(os.Getenv(p.prefix + "_FIELDS"))
+		if err != nil {
+			return fmt.Errorf("invalid %s_FIELDS: %w", p.prefix, err)
+		}
+		naming := domain.FieldNaming(strings.ToLower(sharedcfg.EnvOrDefault(p.prefix+"_FIELD_NAMING", string(domain.NamingSnake))))
+		switch naming {
+		case domain.NamingSnake, domain.NamingCamel:
+		default:
+			return fmt.Errorf("invalid %s_FIELD_NAMING %q: must be snake or camel", p.prefix, naming)
+		}
+		flatten, err := parseBool(p.prefix+"_FLATTEN", false)
+		if err != nil {
+			return err
+		}
+		*p.target = projection.WithStyle(naming, flatten)
+	}
+	if cfg.KafkaSinkFields != nil && cfg.OutputFormat == OutputFormatProtobuf {
+		return errors.New("KAFKA_SINK_FIELDS, KAFKA_SINK_FIELD_NAMING, and KAFKA_SINK_FLATTEN require OUTPUT_FORMAT json or geojson")
+	}
+	return nil
+}
+
+// loadSchemaRegistry reads the schema registry settings. The subject follows
+// the registry's default topic naming, "<KAFKA_SINK_TOPIC>-value". The
+// registered schema describes the full JSON event, so the Kafka sink must
+// write plain JSON without a field projection.
+func loadSchemaRegistry(cfg *Config) error {
+	cfg.SchemaRegistryURL = os.Getenv("SCHEMA_REGISTRY_URL")
+	cfg.SchemaRegistrySubject = sharedcfg.EnvOrDefault("SCHEMA_REGISTRY_SUBJECT", cfg.KafkaSinkTopic+"-value")
+	cfg.SchemaRegistryUsername = os.Getenv("SCHEMA_REGISTRY_USERNAME")
+	cfg.SchemaRegistryPassword = os.Getenv("SCHEMA_REGISTRY_PASSWORD")
+	if cfg.SchemaRegistryURL == "" {
+		return nil
+	}
+	if u, err := url.Parse(cfg.SchemaRegistryURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("invalid SCHEMA_REGISTRY_URL %q: must be an http or https URL", cfg.SchemaRegistryURL)
+	}
+	if !cfg.KafkaSinkEnabled {
+		return errors.New("SCHEMA_REGISTRY_URL requires KAFKA_SINK_ENABLED")
+	}
+	if cfg.OutputFormat != OutputFormatJSON || cfg.KafkaSinkFields != nil {
+		return errors.New("SCHEMA_REGISTRY_URL requires OUTPUT_FORMAT json without KAFKA_SINK_FIELDS, KAFKA_SINK_FIELD_NAMING, or KAFKA_SINK_FLATTEN")
+	}
+	return nil
+}
+
+// loadSchemaVersions reads the schema version for the sink topic and the
+// older versions emitted alongside it during a migration.
+func loadSchemaVersions(cfg *Config) error {
+	version, err := parseIntRange("SCHEMA_VERSION", domain.SchemaVersion, 1, domain.SchemaVersion)
+	if err != nil {
+		return err
+	}
+	var compat []int
+	for _, s := range parseList(os.Getenv("SCHEMA_COMPAT_VERSIONS")) {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 1 || v > domain.SchemaVersion {
+			return fmt.Errorf("invalid SCHEMA_COMPAT_VERSIONS %q: versions must be 1-%d", s, domain.SchemaVersion)
+		}
+		if v == version || slices.Contains(compat, v) {
+			return fmt.Errorf("invalid SCHEMA_COMPAT_VERSIONS: version %d listed twice or equal to SCHEMA_VERSION", v)
+		}
+		compat = append(compat, v)
+	}
+	cfg.SchemaVersion = version
+	cfg.SchemaCompatVersions = compat
+	return nil
+}
+
+// loadFilter reads the criteria that drop events before they are loaded.
+func loadFilter(cfg *Config) error {
+	f := domain.EventFilter{
+		States:        parseList(os.Getenv("FILTER_STATES")),
+		ExcludeStates: parseList(os.Getenv("FILTER_EXCLUDE_STATES")),
+	}
+	for _, name := range parseList(os.Getenv("FILTER_EVENT_TYPES")) {
+		canonical := domain.CanonicalEventType(name)
+		if canonical == "" {
+			return fmt.Errorf("invalid FILTER_EVENT_TYPES: unknown event type %q", name)
+		}
+		f.EventTypes = append(f.EventTypes, canonical)
+	}
+	if s := os.Getenv("FILTER_MIN_SEVERITY"); s != "" {
+		level, err := domain.ParseSeverityLevel(s)
+		if err != nil {
+			return fmt.Errorf("invalid FILTER_MIN_SEVERITY: %w", err)
+		}
+		f.MinSeverity = level
+	}
+	if s := os.Getenv("FILTER_BBOX"); s != "" {
+		bbox, err := domain.ParseBoundingBox(s)
+		if err != nil {
+			return fmt.Errorf("invalid FILTER_BBOX: %w", err)
+		}
+		f.BBox = &bbox
+	}
+	cfg.EventFilter = f
+	return nil
+}
+
+// loadEventDurations reads the per-type end time windows.
+func loadEventDurations(cfg *Config) error {
+	durations, err := domain.ParseEventDurations(os.Getenv("EVENT_DURATIONS"))
+	if err != nil {
+		return fmt.Errorf("invalid EVENT_DURATIONS: %w", err)
+	}
+	cfg.EventDurations = durations
+	return nil
+}
+
+// loadNearbyPlaces reads the radius within which listed places are counted.
+func loadNearbyPlaces(cfg *Config) error {
+	radius, err := parsePositiveFloat("NEARBY_PLACES_RADIUS_MILES", domain.DefaultNearbyPlacesRadius)
+	if err != nil {
+		return err
+	}
+	cfg.NearbyPlacesRadius = radius
+	return nil
+}
+
+// loadGeohash reads the length of the geohash emitted for each event.
+func loadGeohash(cfg *Config) error {
+	precision, err := parseIntRange("GEOHASH_PRECISION", domain.DefaultGeohashPrecision, 1, domain.MaxGeohashPrecision)
+	if err != nil {
+		return err
+	}
+	cfg.GeohashPrecision = precision
+	return nil
+}
+
+// loadBoundaries reads the NWS CWA and forecast zone boundaries from
+// NWS_BOUNDARIES_FILE, falling back to the embedded ones.
+func loadBoundaries(cfg *Config) error {
+	path := os.Getenv("NWS_BOUNDARIES_FILE")
+	if path == "" {
+		cfg.NWSBoundaries = domain.EmbeddedBoundaries()
+		return nil
+	}
+	f, err := os.Open(path) //nolint:gosec // path is operator-supplied configuration
+	if err != nil {
+		return fmt.Errorf("open NWS_BOUNDARIES_FILE: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	b, err := domain.ParseBoundaries(f)
+	if err != nil {
+		return fmt.Errorf("invalid NWS_BOUNDARIES_FILE: %w", err)
+	}
+	cfg.NWSBoundaries = b
+	return nil
+}
+
+// Enrichment returns the enrichment settings for the transformer, so every
+// command that transforms events enriches them the same way. It fails only
+// for an unknown IDStrategy, which Load rejects.
+func (c *Config) Enrichment() (domain.Enrichment, error) {
+	e := domain.Enrichment{
+		Units:                c.MeasurementUnits,
+		DayConvention:        c.ReportDay,
+		EventDurations:       c.EventDurations,
+		NearbyPlacesRadius:   c.NearbyPlacesRadius,
+		GeohashPrecision:     c.GeohashPrecision,
+		SeverityThresholds:   c.SeverityThresholds,
+		SeverityKeywordRules: c.SeverityKeywordRules,
+		Boundaries:           c.NWSBoundaries,
+	}
+	if c.IDStrategy != "" {
+		s, err := domain.IDStrategyByName(c.IDStrategy)
+		if err != nil {
+			return domain.Enrichment{}, fmt.Errorf("invalid ID_STRATEGY: %w", err)
+		}
+		e.IDStrategy = s
+	}
+	return e, nil
+}
+
+// loadNWSAlerts reads the warning correlation settings. The User-Agent is
+// required when correlation is enabled, since api.weather.gov rejects
+// requests without one.
+func loadNWSAlerts(cfg *Config) error {
+	enabled, err := parseBool("NWS_ALERTS", false)
+	if err != nil {
+		return err
+	}
+	timeout, err := parseDuration("NWS_ALERTS_TIMEOUT", 5*time.Second)
+	if err != nil {
+		return err
+	}
+	cacheTTL, err := parseNonNegativeDuration("NWS_ALERTS_CACHE_TTL", 10*time.Minute)
+	if err != nil {
+		return err
+	}
+	cfg.NWSAlerts = enabled
+	cfg.NWSAlertsURL = sharedcfg.EnvOrDefault("NWS_ALERTS_URL", "https://api.weather.gov")
+	cfg.NWSAlertsUserAgent = os.Getenv("NWS_ALERTS_USER_AGENT")
+	cfg.NWSAlertsTimeout = timeout
+	cfg.NWSAlertsCacheTTL = cacheTTL
+
+	if !enabled {
+		return nil
+	}
+	if u, err := url.Parse(cfg.NWSAlertsURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("invalid NWS_ALERTS_URL %q: must be an http or https URL", cfg.NWSAlertsURL)
+	}
+	if cfg.NWSAlertsUserAgent == "" {
+		return errors.New("NWS_ALERTS_USER_AGENT is required when NWS_ALERTS is enabled")
+	}
+	return nil
+}
+
+// loadRedaction reads whether comments are redacted, the patterns applied
+// in addition to the built-in email and phone patterns, and the terms they
+// must never remove.
+func loadRedaction(cfg *Config) error {
+	enabled, err := parseBool("REDACT_COMMENTS", false)
+	if err != nil {
+		return err
+	}
+	patterns, err := domain.ParseRedactionPatterns(os.Getenv("REDACT_PATTERNS"))
+	if err != nil {
+		return fmt.Errorf("invalid REDACT_PATTERNS: %w", err)
+	}
+	allowlist := parseList(os.Getenv("REDACT_ALLOWLIST"))
+	if !enabled {
+		if len(patterns) > 0 || len(allowlist) > 0 {
+			return errors.New("REDACT_PATTERNS and REDACT_ALLOWLIST require REDACT_COMMENTS")
+		}
+		return nil
+	}
+	cfg.CommentRedactor = domain.NewRedactor(patterns, allowlist)
+	return nil
+}
+
+// loadValidationRules reads the checks applied to enriched events and the
+// action taken on each failure.
+func loadValidationRules(cfg *Config) error {
+	rules, err := domain.ParseValidationRules(os.Getenv("VALIDATION_RULES"))
+	if err != nil {
+		return fmt.Errorf("invalid VALIDATION_RULES: %w", err)
+	}
+	cfg.ValidationRules = rules
+	return nil
+}
+
+// loadDuplicateMerge reads whether near-duplicate reports are merged and how
+// close two reports must be to count as the same storm.
+func loadDuplicateMerge(cfg *Config) error {
+	enabled, err := parseBool("DUPLICATE_MERGE", false)
+	if err != nil {
+		return err
+	}
+	miles, err := parsePositiveFloat("DUPLICATE_MERGE_MILES", 2)
+	if err != nil {
+		return err
+	}
+	window, err := parseDuration("DUPLICATE_MERGE_WINDOW", 10*time.Minute)
+	if err != nil {
+		return err
+	}
+	cfg.DuplicateMerge = enabled
+	cfg.DuplicateMergeTolerance = domain.MergeTolerance{Miles: miles, Window: window}
+	return nil
+}
+
+// loadEpisodeClustering reads whether reports are grouped into storm episodes
+// and how close consecutive reports of one episode must be.
+func loadEpisodeClustering(cfg *Config) error {
+	enabled, err := parseBool("EPISODE_CLUSTERING", false)
+	if err != nil {
+		return err
+	}
+	miles, err := parsePositiveFloat("EPISODE_MILES", 20)
+	if err != nil {
+		return err
+	}
+	window, err := parseDuration("EPISODE_WINDOW", 30*time.Minute)
+	if err != nil {
+		return err
+	}
+	cfg.EpisodeClustering = enabled
+	cfg.EpisodeTolerance = domain.EpisodeTolerance{Miles: miles, Window: window}
+	return nil
+}
+
+// loadProvenance reads the source headers propagated for lineage.
+func loadProvenance(cfg *Config) error {
+	headers := parseList(os.Getenv("PROVENANCE_HEADERS"))
+	for _, h := range headers {
+		if slices.Contains(reservedSinkHeaders, h) {
+			return fmt.Errorf("invalid PROVENANCE_HEADERS: %q is set by the sink writer", h)
+		}
+	}
+	cfg.ProvenanceHeaders = headers
+	return nil
+}
+
+// loadTracing reads the OpenTelemetry exporter settings. The endpoint uses the
+// standard OTEL_EXPORTER_OTLP_ENDPOINT variable, e.g. http://otel-collector:4318.
+func loadTracing(cfg *Config) error {
+	s := sharedcfg.EnvOrDefault("TRACE_SAMPLE_RATIO", "1")
+	ratio, err := strconv.ParseFloat(s, 64)
+	if err != nil || ratio < 0 || ratio > 1 {
+		return errors.New("invalid TRACE_SAMPLE_RATIO: must be between 0 and 1")
+	}
+	cfg.OTLPEndpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	cfg.TracingServiceName = sharedcfg.EnvOrDefault("OTEL_SERVICE_NAME", "storm-data-etl")
+	cfg.TracingSampleRatio = ratio
+	return nil
+}
+
+// loadHTTPTLS reads the HTTPS settings of the HTTP server.
+func loadHTTPTLS(cfg *Config) error {
+	cfg.HTTPTLSCertFile = os.Getenv("HTTP_TLS_CERT_FILE")
+	cfg.HTTPTLSKeyFile = os.Getenv("HTTP_TLS_KEY_FILE")
+	cfg.HTTPTLSClientCAFile = os.Getenv("HTTP_TLS_CLIENT_CA_FILE")
+	cfg.HTTPTLSClientAuth = strings.ToLower(os.Getenv("HTTP_TLS_CLIENT_AUTH"))
+	if (cfg.HTTPTLSCertFile == "") != (cfg.HTTPTLSKeyFile == "") {
+		return errors.New("HTTP_TLS_CERT_FILE and HTTP_TLS_KEY_FILE must be set together")
+	}
+	if cfg.HTTPTLSClientCAFile != "" && cfg.HTTPTLSCertFile == "" {
+		return errors.New("HTTP_TLS_CLIENT_CA_FILE requires HTTP_TLS_CERT_FILE and HTTP_TLS_KEY_FILE")
+	}
+	switch cfg.HTTPTLSClientAuth {
+	case "":
+		if cfg.HTTPTLSClientCAFile != "" {
+			cfg.HTTPTLSClientAuth = TLSClientAuthRequire
+		}
+	case TLSClientAuthRequire, TLSClientAuthOptional:
+		if cfg.HTTPTLSClientCAFile == "" {
+			return errors.New("HTTP_TLS_CLIENT_AUTH requires HTTP_TLS_CLIENT_CA_FILE")
+		}
+	default:
+		return fmt.Errorf("invalid HTTP_TLS_CLIENT_AUTH %q: must be require or optional", cfg.HTTPTLSClientAuth)
+	}
+	return nil
+}
+
+// loadAdminAuth reads the admin API principals and their permissions.
+// ADMIN_TOKENS is name:token,...; ADMIN_PERMISSIONS is
+// action=principal,...;... where "*" allows every principal.
+func loadAdminAuth(cfg *Config) error {
+	certAuth, err := parseBool("ADMIN_CLIENT_CERT_AUTH", false)
+	if err != nil {
+		return err
+	}
+	if certAuth && cfg.HTTPTLSClientCAFile == "" {
+		return errors.New("ADMIN_CLIENT_CERT_AUTH requires HTTP_TLS_CLIENT_CA_FILE")
+	}
+	tokens := make(map[string]string)
+	for _, entry := range parseList(os.Getenv("ADMIN_TOKENS")) {
+		name, token, ok := strings.Cut(entry, ":")
+		name, token = strings.TrimSpace(name), strings.TrimSpace(token)
+		if !ok || name == "" || token == "" {
+			return errors.New("invalid ADMIN_TOKENS: entries must be name:token")
+		}
+		if _, dup := tokens[name]; dup || name == "admin" {
+			return fmt.Errorf("invalid ADMIN_TOKENS: principal %q listed twice or reserved for ADMIN_TOKEN", name)
+		}
+		tokens[name] = token
+	}
+	permissions := make(map[string][]string)
+	for entry := range strings.SplitSeq(os.Getenv("ADMIN_PERMISSIONS"), ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		action, principals, ok := strings.Cut(entry, "=")
+		action = strings.ToLower(strings.TrimSpace(action))
+		if !ok || !slices.Contains(AdminActions, action) {
+			return fmt.Errorf("invalid ADMIN_PERMISSIONS %q: expected action=principal,... with action one of %s", entry, strings.Join(AdminActions, ", "))
+		}
+		list := parseList(principals)
+		if len(list) == 0 {
+			return fmt.Errorf("invalid ADMIN_PERMISSIONS: no principals for %s", action)
+		}
+		permissions[action] = append(permissions[action], list...)
+	}
+	cfg.AdminTokens = tokens
+	cfg.AdminClientCertAuth = certAuth
+	cfg.AdminPermissions = permissions
+	return nil
+}
+
+// AdminEnabled reports whether any admin principal is configured, which
+// registers the admin API.
+func (c *Config) AdminEnabled() bool {
+	return c.AdminToken != "" || len(c.AdminTokens) > 0 || c.AdminClientCertAuth
+}
+
+// loadStreaming reads the live event stream settings.
+func loadStreaming(cfg *Config) error {
+	buffer, err := parseIntRange("STREAM_BUFFER", 256, 1, 65536)
+	if err != nil {
+		return err
+	}
+	ws, err := parseBool("STREAM_WEBSOCKET_ENABLED", false)
+	if err != nil {
+		return err
+	}
+	origins := parseList(os.Getenv("STREAM_ALLOWED_ORIGINS"))
+	for _, origin := range origins {
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return fmt.Errorf("invalid STREAM_ALLOWED_ORIGINS %q: must be an http(s) origin such as https://dashboard.example.com", origin)
+		}
+	}
+	cfg.GRPCAddr = os.Getenv("GRPC_ADDR")
+	cfg.StreamWebSocket = ws
+	cfg.StreamAllowedOrigins = origins
+	cfg.StreamBuffer = buffer
+	return nil
+}
+
+// loadLogSampling reads the settings that collapse repeated warnings and
+// errors into summaries.
+func loadLogSampling(cfg *Config) error {
+	burst, err := parseIntRange("LOG_SAMPLE_BURST", 10, 0, 10000)
+	if err != nil {
+		return err
+	}
+	interval, err := parseDuration("LOG_SAMPLE_INTERVAL", time.Minute)
+	if err != nil {
+		return err
+	}
+	cfg.LogSampleBurst = burst
+	cfg.LogSampleInterval = interval
+	return nil
+}
+
+// parseList splits a comma-separated value, trimming whitespace and dropping empty entries.
+func parseList(value string) []string {
+	var items []string
+	for part := range strings.SplitSeq(value, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			items = append(items, trimmed)
+		}
+	}
+	return items
+}
+
+// parseBool reads a boolean environment variable (true/false, 1/0).
+func parseBool(key string, fallback bool) (bool, error) {
+	s := os.Getenv(key)
+	if s == "" {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: must be true or false", key)
+	}
+	return b, nil
+}
+
+// parseDuration reads a duration environment variable that must be positive.
+func parseDuration(key string, fallback time.Duration) (time.Duration, error) {
+	s := os.Getenv(key)
+	if s == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s: must be a positive duration", key)
+	}
+	return d, nil
+}
+
+// parseNonNegativeDuration reads a duration environment variable that may be
+// zero.
+func parseNonNegativeDuration(key string, fallback time.Duration) (time.Duration, error) {
+	s := os.Getenv(key)
+	if s == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s: must be a non-negative duration", key)
+	}
+	return d, nil
+}
+
+// parseIntRange reads an integer environment variable bounded by [lo, hi].
+func parseIntRange(key string, fallback, lo, hi int) (int, error) {
+	s := os.Getenv(key)
+	if s == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < lo || n > hi {
+		return 0, fmt.Errorf("invalid %s: must be %d-%d", key, lo, hi)
+	}
+	return n, nil
+}
+
+// parsePositiveFloat reads a finite number above zero.
+func parsePositiveFloat(key string, fallback float64) (float64, error) {
+	s := os.Getenv(key)
+	if s == "" {
+		return fallback, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || !(f > 0) || math.IsInf(f, 1) {
+		return 0, fmt.Errorf("invalid %s: must be a positive number", key)
+	}
+	return f, nil
+}
+
+// parsePositiveInt reads an integer environment variable that must be >= 1.
+func parsePositiveInt(key string, fallback int) (int, error) {
+	s := os.Getenv(key)
+	if s == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid %s: must be a positive integer", key)
+	}
+	return n, nil
+}
@@ -1,9 +1,12 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -14,43 +17,131 @@ func TestLoad_Defaults(t *testing.T) {
 	cfg, err := Load()
 	require.NoError(t, err)
 
+	assert.Equal(t, SourceKafka, cfg.SourceType)
 	assert.Equal(t, []string{defaultBroker}, cfg.KafkaBrokers)
-	assert.Equal(t, "raw-weather-reports", cfg.KafkaSourceTopic)
+	assert.Equal(t, []string{"raw-weather-reports"}, cfg.KafkaSourceTopics)
 	assert.Equal(t, "transformed-weather-data", cfg.KafkaSinkTopic)
+	assert.Empty(t, cfg.KafkaDLQTopic)
 	assert.Equal(t, "storm-data-etl", cfg.KafkaGroupID)
+	assert.Equal(t, 1, cfg.KafkaSourceMinBytes)
+	assert.Equal(t, 10_000_000, cfg.KafkaSourceMaxBytes)
+	assert.Equal(t, 10*time.Second, cfg.KafkaSourceMaxWait)
+	assert.Equal(t, 100, cfg.KafkaSourceQueueCapacity)
+	assert.Zero(t, cfg.KafkaSourceCommitInterval)
+	assert.Equal(t, OutputFormatJSON, cfg.OutputFormat)
+	assert.Equal(t, domain.UnitsImperial, cfg.MeasurementUnits)
+	assert.Equal(t, domain.DaySPC, cfg.ReportDay)
+	assert.Equal(t, 15*time.Minute, cfg.EventDurations["hail"])
+	assert.Equal(t, domain.IDStrategySHA256, cfg.IDStrategy)
+	assert.Equal(t, domain.SchemaVersion, cfg.SchemaVersion)
+	assert.Empty(t, cfg.SchemaCompatVersions)
+	assert.Equal(t, CompressionNone, cfg.KafkaSinkCompression)
+	assert.Equal(t, 100, cfg.KafkaSinkBatchSize)
+	assert.Equal(t, 1<<20, cfg.KafkaSinkBatchBytes)
+	assert.Equal(t, 10*time.Millisecond, cfg.KafkaSinkBatchTimeout)
+	assert.Equal(t, 10, cfg.KafkaSinkMaxAttempts)
+	assert.False(t, cfg.KafkaSinkAsync)
+	assert.False(t, cfg.MetricsStateLabel)
+	assert.False(t, cfg.AuditLog)
 	assert.Equal(t, ":8080", cfg.HTTPAddr)
+	assert.Empty(t, cfg.GRPCAddr)
+	assert.False(t, cfg.StreamWebSocket)
+	assert.Empty(t, cfg.HTTPTLSCertFile)
+	assert.Empty(t, cfg.HTTPTLSClientAuth)
+	assert.Empty(t, cfg.AdminTokens)
+	assert.False(t, cfg.AdminClientCertAuth)
+	assert.Empty(t, cfg.AdminPermissions)
+	assert.False(t, cfg.AdminEnabled())
+	assert.Empty(t, cfg.ProvenanceHeaders)
+	assert.Empty(t, cfg.StreamAllowedOrigins)
+	assert.Equal(t, 256, cfg.StreamBuffer)
+	assert.Equal(t, 10, cfg.LogSampleBurst)
+	assert.Equal(t, time.Minute, cfg.LogSampleInterval)
 	assert.Equal(t, "info", cfg.LogLevel)
 	assert.Equal(t, "json", cfg.LogFormat)
 	assert.Equal(t, 10*time.Second, cfg.ShutdownTimeout)
 	assert.Equal(t, 50, cfg.BatchSize)
 	assert.Equal(t, 500*time.Millisecond, cfg.BatchFlushInterval)
+	assert.Equal(t, 1, cfg.TransformConcurrency)
+	assert.False(t, cfg.OrderedProcessing)
+	assert.Equal(t, 5, cfg.LoaderCircuitThreshold)
+	assert.Equal(t, 30*time.Second, cfg.LoaderCircuitCooldown)
+	assert.Equal(t, "https://www.spc.noaa.gov/climo/reports", cfg.SPCBaseURL)
+	assert.Equal(t, 15*time.Minute, cfg.SPCPollInterval)
+	assert.Equal(t, 1, cfg.SPCLookbackDays)
+	assert.Empty(t, cfg.SQSQueueURL)
+	assert.Equal(t, 10*time.Second, cfg.SQSWaitTime)
+	assert.Equal(t, time.Minute, cfg.SQSVisibilityTimeout)
+	assert.False(t, cfg.SQSSNSEnvelope)
+	assert.Empty(t, cfg.ArchiveS3Bucket)
+	assert.Empty(t, cfg.ParquetPath)
+	assert.Equal(t, CompressionSnappy, cfg.ParquetCompression)
+	assert.Equal(t, 100000, cfg.ParquetFileMaxEvents)
+	assert.Equal(t, 5*time.Minute, cfg.ParquetFileMaxAge)
+	assert.Empty(t, cfg.ElasticsearchURL)
+	assert.Empty(t, cfg.WebhookURLs)
+	assert.Equal(t, 10*time.Second, cfg.WebhookTimeout)
+	assert.Equal(t, 5, cfg.WebhookMaxRetries)
+	assert.Equal(t, 500*time.Millisecond, cfg.WebhookRetryBackoff)
+	assert.Equal(t, "storm-events", cfg.ElasticsearchIndexPrefix)
+	assert.Equal(t, 5, cfg.ElasticsearchMaxRetries)
+	assert.Equal(t, 500*time.Millisecond, cfg.ElasticsearchRetryBackoff)
+	assert.Equal(t, "storm-events", cfg.ArchiveS3Prefix)
+	assert.True(t, cfg.KafkaSinkEnabled)
+	assert.Empty(t, cfg.PostgresDSN)
+	assert.True(t, cfg.PostgresMigrate)
+	assert.Empty(t, cfg.OTLPEndpoint)
+	assert.Empty(t, cfg.AdminToken)
+	assert.False(t, cfg.PprofEnabled)
+	assert.Equal(t, "storm-data-etl", cfg.TracingServiceName)
+	assert.InDelta(t, 1.0, cfg.TracingSampleRatio, 0)
+	assert.Empty(t, cfg.ReloadFile)
+	assert.Empty(t, cfg.ProgressFile)
+	assert.False(t, cfg.EventFilter.Active())
+	assert.Equal(t, domain.SeverityThresholds{Moderate: 0.75, Severe: 1.5, Extreme: 2.5}, cfg.SeverityThresholds["hail"])
 }
 
 func TestLoad_CustomEnv(t *testing.T) {
 	t.Setenv("KAFKA_BROKERS", "broker1:9092,broker2:9092")
-	t.Setenv("KAFKA_SOURCE_TOPIC", "custom-source")
+	t.Setenv("KAFKA_SOURCE_TOPIC", "custom-hail, custom-wind")
 	t.Setenv("KAFKA_SINK_TOPIC", "custom-sink")
+	t.Setenv("KAFKA_DLQ_TOPIC", "custom-dlq")
 	t.Setenv("KAFKA_GROUP_ID", "custom-group")
+	t.Setenv("OUTPUT_FORMAT", "protobuf")
 	t.Setenv("HTTP_ADDR", ":9090")
+	t.Setenv("ADMIN_TOKEN", "admin-secret")
+	t.Setenv("PPROF_ENABLED", "true")
 	t.Setenv("LOG_LEVEL", "debug")
 	t.Setenv("LOG_FORMAT", "text")
 	t.Setenv("SHUTDOWN_TIMEOUT", "30s")
 	t.Setenv("BATCH_SIZE", "100")
 	t.Setenv("BATCH_FLUSH_INTERVAL", "1s")
+	t.Setenv("TRANSFORM_CONCURRENCY", "8")
+	t.Setenv("ORDERED_PROCESSING", "true")
+	t.Setenv("LOADER_CIRCUIT_THRESHOLD", "0")
+	t.Setenv("LOADER_CIRCUIT_COOLDOWN", "2m")
 
 	cfg, err := Load()
 	require.NoError(t, err)
 
 	assert.Equal(t, []string{"broker1:9092", "broker2:9092"}, cfg.KafkaBrokers)
-	assert.Equal(t, "custom-source", cfg.KafkaSourceTopic)
+	assert.Equal(t, []string{"custom-hail", "custom-wind"}, cfg.KafkaSourceTopics)
 	assert.Equal(t, "custom-sink", cfg.KafkaSinkTopic)
+	assert.Equal(t, "custom-dlq", cfg.KafkaDLQTopic)
 	assert.Equal(t, "custom-group", cfg.KafkaGroupID)
+	assert.Equal(t, OutputFormatProtobuf, cfg.OutputFormat)
 	assert.Equal(t, ":9090", cfg.HTTPAddr)
+	assert.Equal(t, "admin-secret", cfg.AdminToken)
+	assert.True(t, cfg.PprofEnabled)
 	assert.Equal(t, "debug", cfg.LogLevel)
 	assert.Equal(t, "text", cfg.LogFormat)
 	assert.Equal(t, 30*time.Second, cfg.ShutdownTimeout)
 	assert.Equal(t, 100, cfg.BatchSize)
 	assert.Equal(t, 1*time.Second, cfg.BatchFlushInterval)
+	assert.Equal(t, 8, cfg.TransformConcurrency)
+	assert.True(t, cfg.OrderedProcessing)
+	assert.Zero(t, cfg.LoaderCircuitThreshold)
+	assert.Equal(t, 2*time.Minute, cfg.LoaderCircuitCooldown)
 }
 
 func TestLoad_InvalidShutdownTimeout(t *testing.T) {
@@ -87,3 +178,1058 @@ func TestLoad_InvalidBatchFlushInterval(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "BATCH_FLUSH_INTERVAL")
 }
+
+func TestLoad_DLQTopicMatchesSource(t *testing.T) {
+	t.Setenv("KAFKA_DLQ_TOPIC", "raw-weather-reports")
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "KAFKA_DLQ_TOPIC")
+}
+
+func TestLoad_InvalidTransformConcurrency(t *testing.T) {
+	t.Setenv("TRANSFORM_CONCURRENCY", "0")
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TRANSFORM_CONCURRENCY")
+}
+
+func TestLoad_OutputFormatGeoJSON(t *testing.T) {
+	t.Setenv("OUTPUT_FORMAT", "geojson")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, OutputFormatGeoJSON, cfg.OutputFormat)
+}
+
+func TestLoad_InvalidOutputFormat(t *testing.T) {
+	t.Setenv("OUTPUT_FORMAT", "xml")
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "OUTPUT_FORMAT")
+}
+
+func TestLoad_KafkaSecurity(t *testing.T) {
+	t.Setenv("KAFKA_SASL_MECHANISM", "scram-sha-512")
+	t.Setenv("KAFKA_SASL_USERNAME", "etl")
+	t.Setenv("KAFKA_SASL_PASSWORD", "secret")
+	t.Setenv("KAFKA_TLS_CA_FILE", "/etc/kafka/ca.pem")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, SASLMechanismSCRAMSHA512, cfg.KafkaSASLMechanism)
+	assert.Equal(t, "etl", cfg.KafkaSASLUsername)
+	assert.Equal(t, "secret", cfg.KafkaSASLPassword)
+	assert.True(t, cfg.KafkaTLSEnabled, "CA file should imply TLS")
+	assert.Equal(t, "/etc/kafka/ca.pem", cfg.KafkaTLSCAFile)
+	assert.False(t, cfg.KafkaTLSInsecureSkipVerify)
+}
+
+func TestLoad_InvalidSASLMechanism(t *testing.T) {
+	t.Setenv("KAFKA_SASL_MECHANISM", "GSSAPI")
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "KAFKA_SASL_MECHANISM")
+}
+
+func TestLoad_SASLMissingCredentials(t *testing.T) {
+	t.Setenv("KAFKA_SASL_MECHANISM", "PLAIN")
+	t.Setenv("KAFKA_SASL_USERNAME", "etl")
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "KAFKA_SASL_PASSWORD")
+}
+
+func TestLoad_TLSCertWithoutKey(t *testing.T) {
+	t.Setenv("KAFKA_TLS_CERT_FILE", "/etc/kafka/client.pem")
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "KAFKA_TLS_KEY_FILE")
+}
+
+func TestLoad_InvalidTLSEnabled(t *testing.T) {
+	t.Setenv("KAFKA_TLS_ENABLED", "maybe")
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "KAFKA_TLS_ENABLED")
+}
+
+func TestLoad_EmptySourceTopic(t *testing.T) {
+	t.Setenv("KAFKA_SOURCE_TOPIC", " , ")
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "KAFKA_SOURCE_TOPIC")
+}
+
+func TestLoad_SinkTopicIsSource(t *testing.T) {
+	t.Setenv("KAFKA_SOURCE_TOPIC", "raw-hail,raw-wind")
+	t.Setenv("KAFKA_SINK_TOPIC", "raw-wind")
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "KAFKA_SINK_TOPIC")
+}
+
+func TestLoad_SPCSource(t *testing.T) {
+	t.Setenv("SOURCE_TYPE", "spc")
+	t.Setenv("SPC_BASE_URL", "http://mock-server/reports")
+	t.Setenv("SPC_POLL_INTERVAL", "5m")
+	t.Setenv("SPC_LOOKBACK_DAYS", "0")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, SourceSPC, cfg.SourceType)
+	assert.Equal(t, "http://mock-server/reports", cfg.SPCBaseURL)
+	assert.Equal(t, 5*time.Minute, cfg.SPCPollInterval)
+	assert.Equal(t, 0, cfg.SPCLookbackDays)
+}
+
+func TestLoad_InvalidSourceType(t *testing.T) {
+	t.Setenv("SOURCE_TYPE", "ftp")
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SOURCE_TYPE")
+}
+
+func TestLoad_FileSource(t *testing.T) {
+	t.Setenv("SOURCE_TYPE", "file")
+	t.Setenv("FILE_SOURCE_PATH", "/data/replay/*.jsonl")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, SourceFile, cfg.SourceType)
+	assert.Equal(t, "/data/replay/*.jsonl", cfg.FileSourcePath)
+}
+
+func TestLoad_FileSourceRequiresPath(t *testing.T) {
+	t.Setenv("SOURCE_TYPE", "file")
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "FILE_SOURCE_PATH")
+}
+
+func TestLoad_SQSSource(t *testing.T) {
+	t.Setenv("SOURCE_TYPE", "sqs")
+	t.Setenv("SQS_QUEUE_URL", "https://sqs.us-east-2.amazonaws.com/123456789012/storm-reports")
+	t.Setenv("SQS_REGION", "us-east-2")
+	t.Setenv("SQS_ENDPOINT", "http://localstack:4566")
+	t.Setenv("SQS_WAIT_TIME", "0s")
+	t.Setenv("SQS_VISIBILITY_TIMEOUT", "5m")
+	t.Setenv("SQS_SNS_ENVELOPE", "true")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, SourceSQS, cfg.SourceType)
+	assert.Equal(t, "https://sqs.us-east-2.amazonaws.com/123456789012/storm-reports", cfg.SQSQueueURL)
+	assert.Equal(t, "us-east-2", cfg.SQSRegion)
+	assert.Equal(t, "http://localstack:4566", cfg.SQSEndpoint)
+	assert.Zero(t, cfg.SQSWaitTime)
+	assert.Equal(t, 5*time.Minute, cfg.SQSVisibilityTimeout)
+	assert.True(t, cfg.SQSSNSEnvelope)
+}
+
+func TestLoad_SQSSourceRequiresQueueURL(t *testing.T) {
+	t.Setenv("SOURCE_TYPE", "sqs")
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SQS_QUEUE_URL")
+}
+
+func TestLoad_InvalidSQSDurations(t *testing.T) {
+	for key, value := range map[string]string{
+		"SQS_WAIT_TIME":          "30s",
+		"SQS_VISIBILITY_TIMEOUT": "500ms",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			_, err := Load()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), key)
+		})
+	}
+}
+
+func TestLoad_Archive(t *testing.T) {
+	t.Setenv("ARCHIVE_S3_BUCKET", "storm-archive")
+	t.Setenv("ARCHIVE_S3_PREFIX", "/etl/events/")
+	t.Setenv("ARCHIVE_S3_REGION", "us-east-2")
+	t.Setenv("ARCHIVE_S3_ENDPOINT", "http://minio:9000")
+	t.Setenv("ARCHIVE_S3_PATH_STYLE", "true")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "storm-archive", cfg.ArchiveS3Bucket)
+	assert.Equal(t, "etl/events", cfg.ArchiveS3Prefix)
+	assert.Equal(t, "us-east-2", cfg.ArchiveS3Region)
+	assert.Equal(t, "http://minio:9000", cfg.ArchiveS3Endpoint)
+	assert.True(t, cfg.ArchiveS3PathStyle)
+}
+
+func TestLoad_Parquet(t *testing.T) {
+	t.Setenv("KAFKA_SINK_ENABLED", "false")
+	t.Setenv("PARQUET_PATH", "s3://storm-lake/etl/events/")
+	t.Setenv("PARQUET_COMPRESSION", "ZSTD")
+	t.Setenv("PARQUET_FILE_MAX_EVENTS", "5000")
+	t.Setenv("PARQUET_FILE_MAX_AGE", "1m")
+	t.Setenv("PARQUET_S3_REGION", "us-east-2")
+	t.Setenv("PARQUET_S3_ENDPOINT", "http://minio:9000")
+	t.Setenv("PARQUET_S3_PATH_STYLE", "true")
+
+	cfg, err := Load()
+	require.NoError(t, err, "a Parquet path alone is a sink")
+	assert.Equal(t, "s3://storm-lake/etl/events/", cfg.ParquetPath)
+	assert.Equal(t, CompressionZstd, cfg.ParquetCompression)
+	assert.Equal(t, 5000, cfg.ParquetFileMaxEvents)
+	assert.Equal(t, time.Minute, cfg.ParquetFileMaxAge)
+	assert.Equal(t, "us-east-2", cfg.ParquetS3Region)
+	assert.Equal(t, "http://minio:9000", cfg.ParquetS3Endpoint)
+	assert.True(t, cfg.ParquetS3PathStyle)
+
+	bucket, prefix, ok := ParseS3URL(cfg.ParquetPath)
+	assert.True(t, ok)
+	assert.Equal(t, "storm-lake", bucket)
+	assert.Equal(t, "etl/events", prefix)
+	_, _, ok = ParseS3URL("/var/lib/storm-lake")
+	assert.False(t, ok)
+}
+
+func TestLoad_InvalidParquet(t *testing.T) {
+	tests := map[string]struct{ key, value, want string }{
+		"compression": {"PARQUET_COMPRESSION", "lz4", "invalid PARQUET_COMPRESSION"},
+		"bucket":      {"PARQUET_PATH", "s3:///events", "missing bucket"},
+		"path style":  {"PARQUET_S3_PATH_STYLE", "maybe", "PARQUET_S3_PATH_STYLE"},
+		"max events":  {"PARQUET_FILE_MAX_EVENTS", "0", "invalid PARQUET_FILE_MAX_EVENTS"},
+		"max age":     {"PARQUET_FILE_MAX_AGE", "0s", "invalid PARQUET_FILE_MAX_AGE"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(tt.key, tt.value)
+			_, err := Load()
+			require.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestLoad_Elasticsearch(t *testing.T) {
+	t.Setenv("KAFKA_SINK_ENABLED", "false")
+	t.Setenv("ELASTICSEARCH_URL", "https://search:9200")
+	t.Setenv("ELASTICSEARCH_INDEX_PREFIX", "storms")
+	t.Setenv("ELASTICSEARCH_API_KEY", "a2V5")
+	t.Setenv("ELASTICSEARCH_MAX_RETRIES", "8")
+	t.Setenv("ELASTICSEARCH_RETRY_BACKOFF", "2s")
+
+	cfg, err := Load()
+	require.NoError(t, err, "an Elasticsearch URL alone is a sink")
+	assert.Equal(t, "https://search:9200", cfg.ElasticsearchURL)
+	assert.Equal(t, "storms", cfg.ElasticsearchIndexPrefix)
+	assert.Equal(t, "a2V5", cfg.ElasticsearchAPIKey)
+	assert.Equal(t, 8, cfg.ElasticsearchMaxRetries)
+	assert.Equal(t, 2*time.Second, cfg.ElasticsearchRetryBackoff)
+}
+
+func TestLoad_InvalidElasticsearch(t *testing.T) {
+	tests := map[string]struct {
+		env  map[string]string
+		want string
+	}{
+		"url":         {map[string]string{"ELASTICSEARCH_URL": "search:9200"}, "invalid ELASTICSEARCH_URL"},
+		"prefix":      {map[string]string{"ELASTICSEARCH_URL": "http://search:9200", "ELASTICSEARCH_INDEX_PREFIX": "Storms"}, "invalid ELASTICSEARCH_INDEX_PREFIX"},
+		"both auths":  {map[string]string{"ELASTICSEARCH_URL": "http://search:9200", "ELASTICSEARCH_API_KEY": "k", "ELASTICSEARCH_USERNAME": "u"}, "not both"},
+		"max retries": {map[string]string{"ELASTICSEARCH_MAX_RETRIES": "50"}, "ELASTICSEARCH_MAX_RETRIES"},
+		"backoff":     {map[string]string{"ELASTICSEARCH_RETRY_BACKOFF": "0s"}, "ELASTICSEARCH_RETRY_BACKOFF"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			_, err := Load()
+			require.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestLoad_Webhook(t *testing.T) {
+	t.Setenv("KAFKA_SINK_ENABLED", "false")
+	t.Setenv("WEBHOOK_URL", "https://partner-a.example.com/storms, https://partner-b.example.com/hooks/etl")
+	t.Setenv("WEBHOOK_SECRET", "s3cret")
+	t.Setenv("WEBHOOK_TIMEOUT", "3s")
+	t.Setenv("WEBHOOK_MAX_RETRIES", "2")
+	t.Setenv("WEBHOOK_RETRY_BACKOFF", "1s")
+
+	cfg, err := Load()
+	require.NoError(t, err, "a webhook URL alone is a sink")
+	assert.Equal(t, []string{"https://partner-a.example.com/storms", "https://partner-b.example.com/hooks/etl"}, cfg.WebhookURLs)
+	assert.Equal(t, "s3cret", cfg.WebhookSecret)
+	assert.Equal(t, 3*time.Second, cfg.WebhookTimeout)
+	assert.Equal(t, 2, cfg.WebhookMaxRetries)
+	assert.Equal(t, time.Second, cfg.WebhookRetryBackoff)
+}
+
+func TestLoad_InvalidWebhook(t *testing.T) {
+	tests := map[string]struct {
+		env  map[string]string
+		want string
+	}{
+		"http url":    {map[string]string{"WEBHOOK_URL": "http://partner.example.com", "WEBHOOK_SECRET": "s"}, "must be an https URL"},
+		"no secret":   {map[string]string{"WEBHOOK_URL": "https://partner.example.com"}, "WEBHOOK_SECRET is required"},
+		"timeout":     {map[string]string{"WEBHOOK_TIMEOUT": "0s"}, "WEBHOOK_TIMEOUT"},
+		"max retries": {map[string]string{"WEBHOOK_MAX_RETRIES": "-1"}, "WEBHOOK_MAX_RETRIES"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			_, err := Load()
+			require.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestLoad_Streaming(t *testing.T) {
+	t.Setenv("GRPC_ADDR", ":9090")
+	t.Setenv("STREAM_BUFFER", "1024")
+	t.Setenv("STREAM_WEBSOCKET_ENABLED", "true")
+	t.Setenv("STREAM_ALLOWED_ORIGINS", "https://dashboard.example.com, http://localhost:3000")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, ":9090", cfg.GRPCAddr)
+	assert.Equal(t, 1024, cfg.StreamBuffer)
+	assert.True(t, cfg.StreamWebSocket)
+	assert.Equal(t, []string{"https://dashboard.example.com", "http://localhost:3000"}, cfg.StreamAllowedOrigins)
+
+	t.Setenv("STREAM_ALLOWED_ORIGINS", "dashboard.example.com")
+	_, err = Load()
+	require.ErrorContains(t, err, "STREAM_ALLOWED_ORIGINS")
+
+	t.Setenv("STREAM_ALLOWED_ORIGINS", "")
+	t.Setenv("STREAM_BUFFER", "0")
+	_, err = Load()
+	require.ErrorContains(t, err, "STREAM_BUFFER")
+}
+
+func TestLoad_HTTPTLS(t *testing.T) {
+	t.Setenv("HTTP_TLS_CERT_FILE", "/tls/tls.crt")
+	t.Setenv("HTTP_TLS_KEY_FILE", "/tls/tls.key")
+	t.Setenv("HTTP_TLS_CLIENT_CA_FILE", "/tls/ca.crt")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "/tls/tls.crt", cfg.HTTPTLSCertFile)
+	assert.Equal(t, "/tls/tls.key", cfg.HTTPTLSKeyFile)
+	assert.Equal(t, "/tls/ca.crt", cfg.HTTPTLSClientCAFile)
+	assert.Equal(t, TLSClientAuthRequire, cfg.HTTPTLSClientAuth, "a client CA requires client certificates by default")
+
+	t.Setenv("HTTP_TLS_CLIENT_AUTH", "Optional")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, TLSClientAuthOptional, cfg.HTTPTLSClientAuth)
+}
+
+func TestLoad_HTTPTLSInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"cert without key", map[string]string{"HTTP_TLS_CERT_FILE": "/tls/tls.crt"}, "must be set together"},
+		{"client CA without cert", map[string]string{"HTTP_TLS_CLIENT_CA_FILE": "/tls/ca.crt"}, "HTTP_TLS_CLIENT_CA_FILE requires"},
+		{"client auth without CA", map[string]string{"HTTP_TLS_CERT_FILE": "/tls/tls.crt", "HTTP_TLS_KEY_FILE": "/tls/tls.key", "HTTP_TLS_CLIENT_AUTH": "require"}, "HTTP_TLS_CLIENT_AUTH requires"},
+		{"unknown client auth", map[string]string{"HTTP_TLS_CERT_FILE": "/tls/tls.crt", "HTTP_TLS_KEY_FILE": "/tls/tls.key", "HTTP_TLS_CLIENT_CA_FILE": "/tls/ca.crt", "HTTP_TLS_CLIENT_AUTH": "always"}, "invalid HTTP_TLS_CLIENT_AUTH"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			_, err := Load()
+			require.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestLoad_AdminAuth(t *testing.T) {
+	t.Setenv("HTTP_TLS_CERT_FILE", "/tls/tls.crt")
+	t.Setenv("HTTP_TLS_KEY_FILE", "/tls/tls.key")
+	t.Setenv("HTTP_TLS_CLIENT_CA_FILE", "/tls/ca.crt")
+	t.Setenv("ADMIN_CLIENT_CERT_AUTH", "true")
+	t.Setenv("ADMIN_TOKENS", "oncall:s3cret, dashboard:r3ad")
+	t.Setenv("ADMIN_PERMISSIONS", "Read=*; pause=oncall,ops-cert; requeue=oncall")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.AdminClientCertAuth)
+	assert.Equal(t, map[string]string{"oncall": "s3cret", "dashboard": "r3ad"}, cfg.AdminTokens)
+	assert.Equal(t, map[string][]string{
+		AdminActionRead:    {"*"},
+		AdminActionPause:   {"oncall", "ops-cert"},
+		AdminActionRequeue: {"oncall"},
+	}, cfg.AdminPermissions)
+	assert.True(t, cfg.AdminEnabled())
+}
+
+func TestLoad_AdminAuthInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"cert auth without client CA", map[string]string{"ADMIN_CLIENT_CERT_AUTH": "true"}, "ADMIN_CLIENT_CERT_AUTH requires"},
+		{"token without name", map[string]string{"ADMIN_TOKENS": "s3cret"}, "invalid ADMIN_TOKENS"},
+		{"duplicate name", map[string]string{"ADMIN_TOKENS": "oncall:a,oncall:b"}, "listed twice"},
+		{"reserved name", map[string]string{"ADMIN_TOKENS": "admin:s3cret"}, "reserved"},
+		{"unknown action", map[string]string{"ADMIN_PERMISSIONS": "delete=oncall"}, "invalid ADMIN_PERMISSIONS"},
+		{"no principals", map[string]string{"ADMIN_PERMISSIONS": "pause="}, "no principals for pause"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			_, err := Load()
+			require.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestLoad_ProvenanceHeaders(t *testing.T) {
+	t.Setenv("PROVENANCE_HEADERS", "collector_run_id, source_file")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"collector_run_id", "source_file"}, cfg.ProvenanceHeaders)
+
+	t.Setenv("PROVENANCE_HEADERS", "source_file,traceparent")
+	_, err = Load()
+	require.ErrorContains(t, err, `invalid PROVENANCE_HEADERS: "traceparent"`)
+}
+
+func TestLoad_LogSampling(t *testing.T) {
+	t.Setenv("LOG_SAMPLE_BURST", "0")
+	t.Setenv("LOG_SAMPLE_INTERVAL", "30s")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 0, cfg.LogSampleBurst)
+	assert.Equal(t, 30*time.Second, cfg.LogSampleInterval)
+
+	t.Setenv("LOG_SAMPLE_BURST", "-1")
+	_, err = Load()
+	require.ErrorContains(t, err, "LOG_SAMPLE_BURST")
+
+	t.Setenv("LOG_SAMPLE_BURST", "")
+	t.Setenv("LOG_SAMPLE_INTERVAL", "0s")
+	_, err = Load()
+	require.ErrorContains(t, err, "LOG_SAMPLE_INTERVAL")
+}
+
+func TestLoad_PostgresSinkOnly(t *testing.T) {
+	t.Setenv("KAFKA_SINK_ENABLED", "false")
+	t.Setenv("POSTGRES_DSN", "postgres://etl:secret@db:5432/storms")
+	t.Setenv("POSTGRES_MIGRATE", "false")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.KafkaSinkEnabled)
+	assert.Equal(t, "postgres://etl:secret@db:5432/storms", cfg.PostgresDSN)
+	assert.False(t, cfg.PostgresMigrate)
+}
+
+func TestLoad_NoSinkConfigured(t *testing.T) {
+	t.Setenv("KAFKA_SINK_ENABLED", "false")
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no sink configured")
+}
+
+func TestLoad_Tracing(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://otel-collector:4318")
+	t.Setenv("OTEL_SERVICE_NAME", "etl-staging")
+	t.Setenv("TRACE_SAMPLE_RATIO", "0.25")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "http://otel-collector:4318", cfg.OTLPEndpoint)
+	assert.Equal(t, "etl-staging", cfg.TracingServiceName)
+	assert.InDelta(t, 0.25, cfg.TracingSampleRatio, 0)
+}
+
+func TestLoad_InvalidTraceSampleRatio(t *testing.T) {
+	t.Setenv("TRACE_SAMPLE_RATIO", "1.5")
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TRACE_SAMPLE_RATIO")
+}
+
+func TestLoad_InvalidSPCLookbackDays(t *testing.T) {
+	t.Setenv("SPC_LOOKBACK_DAYS", "30")
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SPC_LOOKBACK_DAYS")
+}
+
+func TestLoad_InvalidSeverityThresholds(t *testing.T) {
+	t.Setenv("SEVERITY_THRESHOLDS", "hail=2.5,1.5,0.75")
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SEVERITY_THRESHOLDS")
+}
+
+func writeReloadFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "reload.env")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_ReloadFileOverridesEnv(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("BATCH_SIZE", "10")
+	path := writeReloadFile(t, "# tuned for backfill\nBATCH_SIZE=500\n\nSEVERITY_THRESHOLDS=\"wind=40,60,90\"\n")
+	t.Setenv("CONFIG_RELOAD_FILE", path)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, path, cfg.ReloadFile)
+	assert.Equal(t, "warn", cfg.LogLevel)
+	assert.Equal(t, 500, cfg.BatchSize)
+	assert.Equal(t, domain.SeverityThresholds{Moderate: 40, Severe: 60, Extreme: 90}, cfg.SeverityThresholds["wind"])
+}
+
+func TestLoadReloadable_RejectsNonReloadableKey(t *testing.T) {
+	path := writeReloadFile(t, "KAFKA_BROKERS=other:9092\n")
+	_, err := LoadReloadable(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "KAFKA_BROKERS cannot be reloaded")
+}
+
+func TestLoadReloadable_InvalidValue(t *testing.T) {
+	path := writeReloadFile(t, "BATCH_SIZE=0\n")
+	_, err := LoadReloadable(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "BATCH_SIZE")
+}
+
+func TestLoadReloadable_LogLevel(t *testing.T) {
+	settings, err := LoadReloadable(writeReloadFile(t, "LOG_LEVEL=DEBUG\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "DEBUG", settings.LogLevel)
+
+	for _, value := range []string{"verbose", "warning", "info+"} {
+		_, err = LoadReloadable(writeReloadFile(t, "LOG_LEVEL="+value+"\n"))
+		require.ErrorContains(t, err, "LOG_LEVEL", value)
+	}
+}
+
+func TestLoadReloadable_MaxEventsPerSecond(t *testing.T) {
+	settings, err := LoadReloadable("")
+	require.NoError(t, err)
+	assert.Zero(t, settings.MaxEventsPerSecond, "unlimited by default")
+
+	settings, err = LoadReloadable(writeReloadFile(t, "MAX_EVENTS_PER_SECOND=250.5\n"))
+	require.NoError(t, err)
+	assert.InDelta(t, 250.5, settings.MaxEventsPerSecond, 1e-9)
+
+	for _, value := range []string{"-1", "fast", "NaN", "+Inf"} {
+		_, err = LoadReloadable(writeReloadFile(t, "MAX_EVENTS_PER_SECOND="+value+"\n"))
+		require.Error(t, err, value)
+		assert.Contains(t, err.Error(), "MAX_EVENTS_PER_SECOND")
+	}
+}
+
+func TestLoadReloadable_MissingFile(t *testing.T) {
+	_, err := LoadReloadable(filepath.Join(t.TempDir(), "missing.env"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONFIG_RELOAD_FILE")
+}
+
+func TestLoad_MeasurementUnits(t *testing.T) {
+	t.Setenv("MEASUREMENT_UNITS", "both")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, domain.UnitsBoth, cfg.MeasurementUnits)
+
+	t.Setenv("MEASUREMENT_UNITS", "si")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "MEASUREMENT_UNITS")
+}
+
+func TestLoad_ReportDayConvention(t *testing.T) {
+	t.Setenv("REPORT_DAY_CONVENTION", "calendar")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, domain.DayCalendar, cfg.ReportDay)
+
+	t.Setenv("REPORT_DAY_CONVENTION", "local")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "REPORT_DAY_CONVENTION")
+}
+
+func TestLoad_SeverityKeywordRules(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.SeverityKeywordRules)
+
+	t.Setenv("SEVERITY_KEYWORD_RULES", "true")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.SeverityKeywordRules)
+}
+
+func TestLoad_EventDurations(t *testing.T) {
+	t.Setenv("EVENT_DURATIONS", "hail=5m,tornado=20m")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, cfg.EventDurations["hail"])
+	assert.Equal(t, 20*time.Minute, cfg.EventDurations["tornado"])
+	assert.Equal(t, 10*time.Minute, cfg.EventDurations["wind"])
+
+	t.Setenv("EVENT_DURATIONS", "hail=soon")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "EVENT_DURATIONS")
+}
+
+func TestLoad_ValidationRules(t *testing.T) {
+	t.Setenv("VALIDATION_RULES", "missing_magnitude=annotate;missing_state=drop")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, []domain.ValidationRule{
+		{Check: "missing_magnitude", Action: domain.RuleActionAnnotate},
+		{Check: "missing_state", Action: domain.RuleActionDrop},
+	}, cfg.ValidationRules)
+
+	t.Setenv("VALIDATION_RULES", "future_time=quarantine")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "KAFKA_DLQ_TOPIC")
+
+	t.Setenv("KAFKA_DLQ_TOPIC", "storm-dlq")
+	_, err = Load()
+	require.NoError(t, err)
+
+	t.Setenv("VALIDATION_RULES", "future_time=reject")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "VALIDATION_RULES")
+}
+
+func TestLoad_DuplicateMerge(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.DuplicateMerge)
+	assert.Equal(t, domain.MergeTolerance{Miles: 2, Window: 10 * time.Minute}, cfg.DuplicateMergeTolerance)
+
+	t.Setenv("DUPLICATE_MERGE", "true")
+	t.Setenv("DUPLICATE_MERGE_MILES", "5.5")
+	t.Setenv("DUPLICATE_MERGE_WINDOW", "20m")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.DuplicateMerge)
+	assert.Equal(t, domain.MergeTolerance{Miles: 5.5, Window: 20 * time.Minute}, cfg.DuplicateMergeTolerance)
+
+	for _, bad := range []string{"0", "-1", "NaN", "far"} {
+		t.Setenv("DUPLICATE_MERGE_MILES", bad)
+		_, err = Load()
+		require.Error(t, err, bad)
+		assert.Contains(t, err.Error(), "DUPLICATE_MERGE_MILES")
+	}
+}
+
+func TestLoad_EpisodeClustering(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.EpisodeClustering)
+	assert.Equal(t, domain.EpisodeTolerance{Miles: 20, Window: 30 * time.Minute}, cfg.EpisodeTolerance)
+
+	t.Setenv("EPISODE_CLUSTERING", "true")
+	t.Setenv("EPISODE_MILES", "35")
+	t.Setenv("EPISODE_WINDOW", "45m")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.EpisodeClustering)
+	assert.Equal(t, domain.EpisodeTolerance{Miles: 35, Window: 45 * time.Minute}, cfg.EpisodeTolerance)
+
+	t.Setenv("EPISODE_WINDOW", "-1m")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "EPISODE_WINDOW")
+}
+
+func TestLoad_NearbyPlacesRadius(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.InDelta(t, domain.DefaultNearbyPlacesRadius, cfg.NearbyPlacesRadius, 0)
+
+	t.Setenv("NEARBY_PLACES_RADIUS_MILES", "25")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.InDelta(t, 25.0, cfg.NearbyPlacesRadius, 0)
+
+	t.Setenv("NEARBY_PLACES_RADIUS_MILES", "0")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "NEARBY_PLACES_RADIUS_MILES")
+}
+
+func TestLoad_NWSBoundaries(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Same(t, domain.EmbeddedBoundaries(), cfg.NWSBoundaries)
+
+	t.Setenv("NWS_BOUNDARIES_FILE", filepath.Join("..", "domain", "testdata", "nws_boundaries.geojson"))
+	cfg, err = Load()
+	require.NoError(t, err)
+	require.NotNil(t, cfg.NWSBoundaries)
+	assert.NotSame(t, domain.EmbeddedBoundaries(), cfg.NWSBoundaries)
+
+	t.Setenv("NWS_BOUNDARIES_FILE", filepath.Join(t.TempDir(), "missing.geojson"))
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "NWS_BOUNDARIES_FILE")
+
+	path := filepath.Join(t.TempDir(), "bad.geojson")
+	require.NoError(t, os.WriteFile(path, []byte(`{"features":[{}]}`), 0o600))
+	t.Setenv("NWS_BOUNDARIES_FILE", path)
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid NWS_BOUNDARIES_FILE")
+}
+
+func TestLoad_NWSAlerts(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.NWSAlerts)
+	assert.Equal(t, "https://api.weather.gov", cfg.NWSAlertsURL)
+	assert.Equal(t, 5*time.Second, cfg.NWSAlertsTimeout)
+	assert.Equal(t, 10*time.Minute, cfg.NWSAlertsCacheTTL)
+
+	t.Setenv("NWS_ALERTS", "true")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "NWS_ALERTS_USER_AGENT")
+
+	t.Setenv("NWS_ALERTS_USER_AGENT", "(storm-etl.example.com, ops@example.com)")
+	t.Setenv("NWS_ALERTS_TIMEOUT", "2s")
+	t.Setenv("NWS_ALERTS_CACHE_TTL", "0")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.NWSAlerts)
+	assert.Equal(t, 2*time.Second, cfg.NWSAlertsTimeout)
+	assert.Zero(t, cfg.NWSAlertsCacheTTL)
+
+	t.Setenv("NWS_ALERTS_CACHE_TTL", "-1m")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "NWS_ALERTS_CACHE_TTL")
+	t.Setenv("NWS_ALERTS_CACHE_TTL", "")
+
+	t.Setenv("NWS_ALERTS_URL", "ftp://api.weather.gov")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "NWS_ALERTS_URL")
+}
+
+func TestLoad_Geohash(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, domain.DefaultGeohashPrecision, cfg.GeohashPrecision)
+	assert.Equal(t, SinkKeyID, cfg.KafkaSinkKey)
+
+	t.Setenv("GEOHASH_PRECISION", "9")
+	t.Setenv("KAFKA_SINK_KEY", "geohash")
+	t.Setenv("KAFKA_SINK_KEY_PRECISION", "5")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 9, cfg.GeohashPrecision)
+	assert.Equal(t, SinkKeyGeohash, cfg.KafkaSinkKey)
+	assert.Equal(t, 5, cfg.KafkaSinkKeyPrecision)
+
+	t.Setenv("ORDERED_PROCESSING", "true")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ORDERED_PROCESSING")
+	t.Setenv("ORDERED_PROCESSING", "false")
+
+	t.Setenv("KAFKA_SINK_KEY", "state")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "KAFKA_SINK_KEY")
+
+	t.Setenv("KAFKA_SINK_KEY", "id")
+	t.Setenv("GEOHASH_PRECISION", "13")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "GEOHASH_PRECISION")
+}
+
+func TestLoad_FieldProjections(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Nil(t, cfg.KafkaSinkFields)
+	assert.Nil(t, cfg.WebhookFields)
+
+	t.Setenv("KAFKA_SINK_FIELDS", "-comments,-location.raw")
+	t.Setenv("WEBHOOK_FIELDS", "id,event_type,geo,event_time")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.NotNil(t, cfg.KafkaSinkFields)
+	assert.NotNil(t, cfg.WebhookFields)
+	assert.Nil(t, cfg.ArchiveFields)
+
+	t.Setenv("OUTPUT_FORMAT", "protobuf")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "KAFKA_SINK_FIELDS")
+	t.Setenv("OUTPUT_FORMAT", "json")
+
+	t.Setenv("ELASTICSEARCH_FIELDS", "-location.rawtext")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ELASTICSEARCH_FIELDS")
+
+	t.Setenv("ELASTICSEARCH_FIELDS", "")
+	t.Setenv("ARCHIVE_S3_FIELD_NAMING", "camel")
+	t.Setenv("ARCHIVE_S3_FLATTEN", "true")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.NotNil(t, cfg.ArchiveFields, "a naming convention alone is a projection")
+	assert.Nil(t, cfg.ElasticsearchFields)
+
+	t.Setenv("WEBHOOK_FIELD_NAMING", "kebab")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "WEBHOOK_FIELD_NAMING")
+}
+
+func TestLoad_SchemaRegistry(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.SchemaRegistryURL)
+
+	t.Setenv("SCHEMA_REGISTRY_URL", "https://registry.example.com")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "transformed-weather-data-value", cfg.SchemaRegistrySubject)
+
+	t.Setenv("SCHEMA_REGISTRY_SUBJECT", "storm-events")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "storm-events", cfg.SchemaRegistrySubject)
+
+	t.Setenv("OUTPUT_FORMAT", "protobuf")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SCHEMA_REGISTRY_URL")
+	t.Setenv("OUTPUT_FORMAT", "json")
+
+	t.Setenv("SCHEMA_REGISTRY_URL", "registry:8081")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SCHEMA_REGISTRY_URL")
+}
+
+func TestLoad_SinkSigning(t *testing.T) {
+	t.Setenv("KAFKA_SINK_SIGNING_KEY", "s3cret")
+	t.Setenv("KAFKA_SINK_SIGNING_KEY_ID", "2024-04")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", cfg.KafkaSinkSigningKey)
+	assert.Equal(t, "2024-04", cfg.KafkaSinkSigningKeyID)
+
+	t.Setenv("KAFKA_SINK_SIGNING_KEY", "")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "KAFKA_SINK_SIGNING_KEY")
+
+	t.Setenv("KAFKA_SINK_SIGNING_KEY_ID", "")
+	t.Setenv("PROVENANCE_HEADERS", "signature")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "PROVENANCE_HEADERS")
+}
+
+func TestLoad_SinkSizeLimit(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.KafkaSinkOversizePolicy)
+
+	t.Setenv("KAFKA_SINK_OVERSIZE_POLICY", "Truncate")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "truncate", cfg.KafkaSinkOversizePolicy)
+	assert.Equal(t, cfg.KafkaSinkBatchBytes, cfg.KafkaSinkMaxMessageBytes)
+
+	t.Setenv("KAFKA_SINK_MAX_MESSAGE_BYTES", "524288")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 512<<10, cfg.KafkaSinkMaxMessageBytes)
+
+	for env, value := range map[string]string{
+		"KAFKA_SINK_OVERSIZE_POLICY":   "shrink",
+		"KAFKA_SINK_MAX_MESSAGE_BYTES": "2097152",
+		"KAFKA_SINK_ENABLED":           "false",
+	} {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv(env, value)
+			_, err := Load()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), env)
+		})
+	}
+
+	t.Setenv("KAFKA_SINK_OVERSIZE_POLICY", "")
+	_, err = Load()
+	require.Error(t, err, "a size limit without a policy does nothing")
+}
+
+func TestLoad_Redaction(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Nil(t, cfg.CommentRedactor)
+
+	t.Setenv("REDACT_PATTERNS", `spotter=spotter [A-Z][a-z]+ [A-Z][a-z]+`)
+	t.Setenv("REDACT_ALLOWLIST", "spotter Storm Chaser")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "REDACT_COMMENTS")
+
+	t.Setenv("REDACT_COMMENTS", "true")
+	cfg, err = Load()
+	require.NoError(t, err)
+	require.NotNil(t, cfg.CommentRedactor)
+	text, _ := cfg.CommentRedactor.Redact("Reported by spotter John Smith and spotter Storm Chaser.")
+	assert.Equal(t, "Reported by [REDACTED] and spotter Storm Chaser.", text)
+
+	t.Setenv("REDACT_PATTERNS", `spotter=spotter (`)
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "REDACT_PATTERNS")
+}
+
+func TestLoad_IDStrategy(t *testing.T) {
+	t.Setenv("ID_STRATEGY", "uuidv5")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, domain.IDStrategyUUIDv5, cfg.IDStrategy)
+
+	t.Setenv("ID_STRATEGY", "md5")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ID_STRATEGY")
+}
+
+func TestLoad_SchemaVersions(t *testing.T) {
+	t.Setenv("SCHEMA_COMPAT_VERSIONS", "1")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, domain.SchemaVersion, cfg.SchemaVersion)
+	assert.Equal(t, []int{1}, cfg.SchemaCompatVersions)
+
+	t.Setenv("SCHEMA_VERSION", "1")
+	_, err = Load()
+	require.Error(t, err, "compat version equal to SCHEMA_VERSION")
+
+	t.Setenv("SCHEMA_COMPAT_VERSIONS", "")
+	t.Setenv("SCHEMA_VERSION", "99")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SCHEMA_VERSION")
+}
+
+func TestLoad_EventFilter(t *testing.T) {
+	t.Setenv("FILTER_STATES", "TX, ok")
+	t.Setenv("FILTER_EXCLUDE_STATES", "NM")
+	t.Setenv("FILTER_EVENT_TYPES", "tornado,flash flood")
+	t.Setenv("FILTER_MIN_SEVERITY", "Severe")
+	t.Setenv("FILTER_BBOX", "25.8,-106.7,37,-93.5")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, domain.EventFilter{
+		States:        []string{"TX", "ok"},
+		ExcludeStates: []string{"NM"},
+		EventTypes:    []string{"tornado", "flash_flood"},
+		MinSeverity:   "severe",
+		BBox:          &domain.BoundingBox{MinLat: 25.8, MinLon: -106.7, MaxLat: 37, MaxLon: -93.5},
+	}, cfg.EventFilter)
+}
+
+func TestLoad_InvalidEventFilter(t *testing.T) {
+	for key, value := range map[string]string{
+		"FILTER_EVENT_TYPES":  "earthquake",
+		"FILTER_MIN_SEVERITY": "catastrophic",
+		"FILTER_BBOX":         "40,-90,30,-80",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			_, err := Load()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), key)
+		})
+	}
+}
+
+func TestLoad_SinkProducer(t *testing.T) {
+	t.Setenv("KAFKA_SINK_COMPRESSION", "ZSTD")
+	t.Setenv("KAFKA_SINK_BATCH_SIZE", "500")
+	t.Setenv("KAFKA_SINK_BATCH_BYTES", "4194304")
+	t.Setenv("KAFKA_SINK_BATCH_TIMEOUT", "50ms")
+	t.Setenv("KAFKA_SINK_MAX_ATTEMPTS", "3")
+	t.Setenv("KAFKA_SINK_ASYNC", "true")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, CompressionZstd, cfg.KafkaSinkCompression)
+	assert.Equal(t, 500, cfg.KafkaSinkBatchSize)
+	assert.Equal(t, 4<<20, cfg.KafkaSinkBatchBytes)
+	assert.Equal(t, 50*time.Millisecond, cfg.KafkaSinkBatchTimeout)
+	assert.Equal(t, 3, cfg.KafkaSinkMaxAttempts)
+	assert.True(t, cfg.KafkaSinkAsync)
+
+	t.Setenv("KAFKA_SINK_COMPRESSION", "brotli")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "KAFKA_SINK_COMPRESSION")
+
+	t.Setenv("KAFKA_SINK_COMPRESSION", "")
+	for key, value := range map[string]string{
+		"KAFKA_SINK_BATCH_SIZE":    "0",
+		"KAFKA_SINK_BATCH_BYTES":   "512",
+		"KAFKA_SINK_BATCH_TIMEOUT": "0s",
+		"KAFKA_SINK_MAX_ATTEMPTS":  "0",
+		"KAFKA_SINK_ASYNC":         "maybe",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			_, err := Load()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), key)
+		})
+	}
+}
+
+func TestLoad_KafkaSourceFetch(t *testing.T) {
+	t.Setenv("KAFKA_SOURCE_MIN_BYTES", "65536")
+	t.Setenv("KAFKA_SOURCE_MAX_BYTES", "52428800")
+	t.Setenv("KAFKA_SOURCE_MAX_WAIT", "2s")
+	t.Setenv("KAFKA_SOURCE_QUEUE_CAPACITY", "1000")
+	t.Setenv("KAFKA_SOURCE_COMMIT_INTERVAL", "1s")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 64<<10, cfg.KafkaSourceMinBytes)
+	assert.Equal(t, 50<<20, cfg.KafkaSourceMaxBytes)
+	assert.Equal(t, 2*time.Second, cfg.KafkaSourceMaxWait)
+	assert.Equal(t, 1000, cfg.KafkaSourceQueueCapacity)
+	assert.Equal(t, time.Second, cfg.KafkaSourceCommitInterval)
+
+	t.Setenv("KAFKA_SOURCE_MAX_BYTES", "4096")
+	_, err = Load()
+	require.ErrorContains(t, err, "KAFKA_SOURCE_MIN_BYTES must not exceed KAFKA_SOURCE_MAX_BYTES")
+
+	t.Setenv("KAFKA_SOURCE_MAX_BYTES", "")
+	t.Setenv("KAFKA_SOURCE_COMMIT_INTERVAL", "-1s")
+	_, err = Load()
+	require.ErrorContains(t, err, "KAFKA_SOURCE_COMMIT_INTERVAL")
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// reloadableKeys are the settings that may appear in CONFIG_RELOAD_FILE and
+// are re-applied on SIGHUP or POST /admin/reload without a restart.
+var reloadableKeys = map[string]bool{
+	"LOG_LEVEL":             true,
+	"BATCH_SIZE":            true,
+	"SEVERITY_THRESHOLDS":   true,
+	"MAX_EVENTS_PER_SECOND": true,
+}
+
+// Reloadable is the subset of Config that can change while the service runs.
+// Everything else (brokers, topics, sinks) requires a restart.
+type Reloadable struct {
+	LogLevel           string
+	BatchSize          int
+	SeverityThresholds map[string]domain.SeverityThresholds
+
+	// MaxEventsPerSecond caps pipeline throughput; 0 means unlimited.
+	MaxEventsPerSecond float64
+}
+
+// LoadReloadable reads the reloadable settings from the environment, overlaid
+// by KEY=VALUE lines in path when path is non-empty. Values in the file win,
+// so operators can edit it and signal the process instead of redeploying.
+func LoadReloadable(path string) (Reloadable, error) {
+	overrides, err := readReloadFile(path)
+	if err != nil {
+		return Reloadable{}, err
+	}
+	lookup := func(key, fallback string) string {
+		if v, ok := overrides[key]; ok {
+			return v
+		}
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+		return fallback
+	}
+
+	logLevel := lookup("LOG_LEVEL", "info")
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(logLevel)); err != nil {
+		return Reloadable{}, fmt.Errorf("invalid LOG_LEVEL: %w", err)
+	}
+	batchSize, err := strconv.Atoi(lookup("BATCH_SIZE", "50"))
+	if err != nil || batchSize < 1 || batchSize > 1000 {
+		return Reloadable{}, errors.New("invalid BATCH_SIZE: must be 1-1000")
+	}
+	thresholds, err := domain.ParseSeverityThresholds(lookup("SEVERITY_THRESHOLDS", ""))
+	if err != nil {
+		return Reloadable{}, fmt.Errorf("invalid SEVERITY_THRESHOLDS: %w", err)
+	}
+	maxRate, err := strconv.ParseFloat(lookup("MAX_EVENTS_PER_SECOND", "0"), 64)
+	if err != nil || maxRate < 0 || math.IsInf(maxRate, 0) || math.IsNaN(maxRate) {
+		return Reloadable{}, errors.New("invalid MAX_EVENTS_PER_SECOND: must be a non-negative number (0 = unlimited)")
+	}
+	return Reloadable{
+		LogLevel:           logLevel,
+		BatchSize:          batchSize,
+		SeverityThresholds: thresholds,
+		MaxEventsPerSecond: maxRate,
+	}, nil
+}
+
+// readReloadFile parses a dotenv-style file of reloadable settings. Blank
+// lines and # comments are skipped; keys outside reloadableKeys are rejected
+// so a change that would silently need a restart is reported instead.
+func readReloadFile(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path) //nolint:gosec // path is operator-supplied configuration
+	if err != nil {
+		return nil, fmt.Errorf("open CONFIG_RELOAD_FILE: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		key = strings.TrimSpace(key)
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, line)
+		}
+		if !reloadableKeys[key] {
+			return nil, fmt.Errorf("%s:%d: %s cannot be reloaded", path, line, key)
+		}
+		values[key] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read CONFIG_RELOAD_FILE: %w", err)
+	}
+	return values, nil
+}
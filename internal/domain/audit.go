@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// AuditStep records one enrichment decision for data lineage reviews, e.g.
+// {Step: "magnitude", From: "175", To: "1.75", Reason: "hundredths of an inch"}.
+type AuditStep struct {
+	Step   string `json:"step"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Audit collects AuditSteps during enrichment. A nil *Audit discards them, so
+// the unaudited path pays nothing beyond a nil check.
+type Audit []AuditStep
+
+// Add records one decision.
+func (a *Audit) Add(step, from, to, reason string) {
+	if a != nil {
+		*a = append(*a, AuditStep{Step: step, From: from, To: to, Reason: reason})
+	}
+}
+
+// EnrichStormEventAudited is shorthand for
+// Enrichment{}.EnrichStormEventAudited, for callers that enrich with the
+// default settings.
+func EnrichStormEventAudited(event StormEvent) (StormEvent, []AuditStep) {
+	return Enrichment{}.EnrichStormEventAudited(event)
+}
+
+// EnrichStormEventAudited is EnrichStormEvent that also reports every
+// decision that changed or added a field.
+func (e Enrichment) EnrichStormEventAudited(event StormEvent) (StormEvent, []AuditStep) {
+	audit := Audit{}
+	event = e.enrich(event, &audit)
+	return event, audit
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatQuantity(magnitude float64, unit string) string {
+	if unit == "" {
+		return formatFloat(magnitude)
+	}
+	return formatFloat(magnitude) + " " + unit
+}
+
+// severityReason describes the thresholds that produced a severity label.
+func severityReason(thresholds map[string]SeverityThresholds, eventType string) string {
+	t := thresholds[eventType]
+	return fmt.Sprintf("%s thresholds moderate %s, severe %s, extreme %s",
+		eventType, formatFloat(t.Moderate), formatFloat(t.Severe), formatFloat(t.Extreme))
+}
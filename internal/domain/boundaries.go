@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// boundariesGeoJSON holds the NWS CWA and public forecast zone boundaries,
+// simplified from the NWS shapefiles by make boundaries. Regenerate it when
+// the NWS publishes a new release.
+//
+//go:embed nws_boundaries.geojson
+var boundariesGeoJSON []byte
+
+// embeddedBoundaries parses boundariesGeoJSON on first use, so binaries that
+// never look up a CWA do not pay to decode the polygons.
+var embeddedBoundaries = sync.OnceValue(func() *Boundaries {
+	b, err := ParseBoundaries(bytes.NewReader(boundariesGeoJSON))
+	if err != nil {
+		panic(fmt.Sprintf("parse nws_boundaries.geojson: %v", err))
+	}
+	return b
+})
+
+// EmbeddedBoundaries returns the NWS boundaries built into the binary, the
+// default unless NWS_BOUNDARIES_FILE names a newer or custom file.
+func EmbeddedBoundaries() *Boundaries {
+	return embeddedBoundaries()
+}
+
+// Boundaries holds simplified NWS County Warning Area (CWA) and public
+// forecast zone polygons for point-in-polygon lookups.
+type Boundaries struct {
+	cwas  []boundary
+	zones []boundary
+}
+
+// boundary is one CWA or zone: its ID, a bounding box for a cheap first
+// test, and its polygons, each a list of rings (outer ring, then holes).
+type boundary struct {
+	ID       string
+	Box      BoundingBox
+	Polygons [][][]Geo
+}
+
+// boundaryFeature is the part of a GeoJSON feature ParseBoundaries reads.
+// Property names are those of the NWS shapefiles, so a file converted with
+// ogr2ogr needs no renaming.
+type boundaryFeature struct {
+	Properties struct {
+		CWA   string `json:"CWA"`
+		State string `json:"STATE"`
+		Zone  string `json:"ZONE"`
+	} `json:"properties"`
+	Geometry struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+}
+
+// ParseBoundaries reads a GeoJSON FeatureCollection of NWS boundaries, such
+// as the CWA (w_*) and public zone (z_*) shapefiles converted and simplified
+// with ogr2ogr. A feature with STATE and ZONE properties is a forecast zone,
+// identified by its UGC code, e.g. "OKZ025"; one with only a CWA property is
+// a County Warning Area, e.g. "OUN". Geometries must be Polygon or
+// MultiPolygon.
+func ParseBoundaries(r io.Reader) (*Boundaries, error) {
+	var collection struct {
+		Features []boundaryFeature `json:"features"`
+	}
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, fmt.Errorf("decode boundaries: %w", err)
+	}
+	b := &Boundaries{}
+	for i, f := range collection.Features {
+		polygons, err := parseBoundaryGeometry(f.Geometry.Type, f.Geometry.Coordinates)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+		p := f.Properties
+		switch {
+		case p.State != "" && p.Zone != "":
+			b.zones = append(b.zones, newBoundary(p.State+"Z"+p.Zone, polygons))
+		case p.CWA != "":
+			b.cwas = append(b.cwas, newBoundary(p.CWA, polygons))
+		default:
+			return nil, fmt.Errorf("feature %d: no CWA or STATE and ZONE property", i)
+		}
+	}
+	return b, nil
+}
+
+// parseBoundaryGeometry converts GeoJSON [lon, lat] coordinates to polygons
+// of Geo rings.
+func parseBoundaryGeometry(kind string, raw json.RawMessage) ([][][]Geo, error) {
+	var multi [][][][2]float64
+	switch kind {
+	case "Polygon":
+		var polygon [][][2]float64
+		if err := json.Unmarshal(raw, &polygon); err != nil {
+			return nil, fmt.Errorf("decode polygon: %w", err)
+		}
+		multi = [][][][2]float64{polygon}
+	case "MultiPolygon":
+		if err := json.Unmarshal(raw, &multi); err != nil {
+			return nil, fmt.Errorf("decode multipolygon: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported geometry type %q", kind)
+	}
+	polygons := make([][][]Geo, 0, len(multi))
+	for _, rings := range multi {
+		if len(rings) == 0 || len(rings[0]) < 3 {
+			return nil, errors.New("polygon has no outer ring")
+		}
+		polygon := make([][]Geo, len(rings))
+		for i, ring := range rings {
+			polygon[i] = make([]Geo, len(ring))
+			for j, c := range ring {
+				polygon[i][j] = Geo{Lat: c[1], Lon: c[0]}
+			}
+		}
+		polygons = append(polygons, polygon)
+	}
+	return polygons, nil
+}
+
+// newBoundary computes the bounding box of the outer rings.
+func newBoundary(id string, polygons [][][]Geo) boundary {
+	box := BoundingBox{MinLat: 90, MinLon: 180, MaxLat: -90, MaxLon: -180}
+	for _, polygon := range polygons {
+		for _, g := range polygon[0] {
+			box.MinLat, box.MaxLat = min(box.MinLat, g.Lat), max(box.MaxLat, g.Lat)
+			box.MinLon, box.MaxLon = min(box.MinLon, g.Lon), max(box.MaxLon, g.Lon)
+		}
+	}
+	return boundary{ID: id, Box: box, Polygons: polygons}
+}
+
+// contains reports whether g lies inside one of the boundary's polygons.
+func (b boundary) contains(g Geo) bool {
+	if !b.Box.Contains(g) {
+		return false
+	}
+	for _, polygon := range b.Polygons {
+		if polygonContains(polygon, g) {
+			return true
+		}
+	}
+	return false
+}
+
+// polygonContains applies the even-odd rule to all rings of a polygon, so a
+// point inside a hole is outside the polygon.
+func polygonContains(rings [][]Geo, g Geo) bool {
+	inside := false
+	for _, ring := range rings {
+		for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+			a, b := ring[i], ring[j]
+			if (a.Lat > g.Lat) != (b.Lat > g.Lat) && g.Lon < (b.Lon-a.Lon)*(g.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// lookupBoundary returns the ID of the first boundary containing g, or "".
+func lookupBoundary(list []boundary, g Geo) string {
+	for _, b := range list {
+		if b.contains(g) {
+			return b.ID
+		}
+	}
+	return ""
+}
+
+// lookup returns the CWA and forecast zone containing g. Both are empty when
+// b is nil or g is missing or outside them.
+func (b *Boundaries) lookup(g Geo) (cwa, zone string) {
+	if b == nil || g == (Geo{}) {
+		return "", ""
+	}
+	return lookupBoundary(b.cwas, g), lookupBoundary(b.zones, g)
+}
@@ -0,0 +1,66 @@
+package domain
+
+// FlagOutsideUS marks an event whose coordinates lie outside every US region
+// and could not be corrected. It is also the name of the matching validation
+// check, so VALIDATION_RULES can quarantine or drop such events.
+const FlagOutsideUS = "outside_us"
+
+// usRegions are generous bounding boxes around the US states and territories
+// that issue storm reports. Guam and the western Aleutians lie at positive
+// longitudes, so a positive longitude alone is not an error.
+var usRegions = []BoundingBox{
+	{MinLat: 24, MinLon: -125, MaxLat: 50, MaxLon: -66},    // contiguous US
+	{MinLat: 51, MinLon: -180, MaxLat: 72, MaxLon: -129},   // Alaska
+	{MinLat: 51, MinLon: 172, MaxLat: 55, MaxLon: 180},     // western Aleutians
+	{MinLat: 18, MinLon: -161, MaxLat: 23, MaxLon: -154},   // Hawaii
+	{MinLat: 17, MinLon: -68, MaxLat: 19, MaxLon: -64},     // Puerto Rico and the Virgin Islands
+	{MinLat: 13, MinLon: 144, MaxLat: 21, MaxLon: 146.5},   // Guam and the Northern Mariana Islands
+	{MinLat: -15, MinLon: -171, MaxLat: -11, MaxLon: -168}, // American Samoa
+}
+
+// inUS reports whether g lies inside one of the usRegions.
+func inUS(g Geo) bool {
+	for _, r := range usRegions {
+		if r.Contains(g) {
+			return true
+		}
+	}
+	return false
+}
+
+// coordinateCorrections are the deterministic fixes tried, in order, for
+// coordinates outside the US. Each is only applied when it lands the point
+// inside a US region, so a correct foreign-looking point is never moved.
+var coordinateCorrections = []struct {
+	reason string
+	fix    func(Geo) Geo
+}{
+	{"longitude sign flipped", func(g Geo) Geo { return Geo{Lat: g.Lat, Lon: -g.Lon} }},
+	{"latitude and longitude swapped", func(g Geo) Geo { return Geo{Lat: g.Lon, Lon: g.Lat} }},
+	{"latitude and longitude swapped, longitude sign flipped", func(g Geo) Geo { return Geo{Lat: g.Lon, Lon: -g.Lat} }},
+}
+
+// correctCoordinates returns the corrected coordinates and the correction
+// applied, or g and "" when g is already in the US or no correction puts it
+// there.
+func correctCoordinates(g Geo) (Geo, string) {
+	if inUS(g) {
+		return g, ""
+	}
+	for _, c := range coordinateCorrections {
+		if fixed := c.fix(g); inUS(fixed) {
+			return fixed, c.reason
+		}
+	}
+	return g, ""
+}
+
+// coordinatesInRange reports whether g is a valid, non-null WGS-84 point.
+func coordinatesInRange(g Geo) bool {
+	return (g.Lat != 0 || g.Lon != 0) && g.Lat >= -90 && g.Lat <= 90 && g.Lon >= -180 && g.Lon <= 180
+}
+
+// formatGeo formats g as "lat,lon" for the audit log.
+func formatGeo(g Geo) string {
+	return formatFloat(g.Lat) + "," + formatFloat(g.Lon)
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+// FlagUnknownCounty marks an event whose county is not one of its state's
+// counties. It is also the name of the matching validation check.
+const FlagUnknownCounty = "unknown_county"
+
+// countiesCSV lists the Census county equivalents of each state and the
+// District of Columbia, with the descriptor ("County", "Parish", "Borough",
+// "Census Area") dropped and "St." spelled out. Independent cities keep
+// "City", as in "Baltimore City". Regenerate it with make counties.
+//
+//go:embed counties.csv
+var countiesCSV string
+
+// countyNames maps each state's USPS code to the countyKey of its county
+// names and their spelling. The "" entry indexes every state, for events
+// whose state is unknown.
+var countyNames = mustParseCounties(countiesCSV)
+
+// countySuffixes are stripped from the end of a county name, longest first.
+var countySuffixes = []string{" CITY AND BOROUGH", " CENSUS AREA", " MUNICIPALITY", " COUNTY", " PARISH", " BOROUGH", " CO.", " CO"}
+
+// countyAbbreviations expand the leading word of a county name.
+var countyAbbreviations = map[string]string{
+	"ST":  "SAINT",
+	"STE": "SAINTE",
+	"FT":  "FORT",
+	"MT":  "MOUNT",
+}
+
+// countySmallWords stay lowercase after the first word, as in "Isle of Wight".
+var countySmallWords = map[string]bool{"OF": true, "AND": true, "THE": true}
+
+// normalizeCounty returns the canonical spelling of a county name in state:
+// the "County", "Parish", or "Borough" suffix is stripped, a leading "St." or
+// "Ft." is spelled out, and the name takes its spelling from the embedded
+// list. "ST. CLAIR COUNTY" in IL becomes "Saint Clair". It returns false,
+// with the name title-cased, when state has a county list that does not
+// include the name; a territory or unknown state has none, so its counties
+// are not checked.
+func normalizeCounty(state, s string) (string, bool) {
+	name := strings.ToUpper(strings.Join(strings.Fields(s), " "))
+	for _, suffix := range countySuffixes {
+		if trimmed, ok := strings.CutSuffix(name, suffix); ok && trimmed != "" {
+			name = trimmed
+			break
+		}
+	}
+	words := strings.Fields(name)
+	if len(words) == 0 {
+		return "", true
+	}
+	if full, ok := countyAbbreviations[strings.TrimSuffix(words[0], ".")]; ok && len(words) > 1 {
+		words[0] = full
+	}
+	names, listed := countyNames[state]
+	if !listed {
+		names = countyNames[""]
+	}
+	if spelling, ok := names[countyKey(strings.Join(words, " "))]; ok {
+		return spelling, true
+	}
+	for i, w := range words {
+		if i > 0 && countySmallWords[w] {
+			words[i] = strings.ToLower(w)
+			continue
+		}
+		words[i] = titleCountyWord(w)
+	}
+	return strings.Join(words, " "), !listed
+}
+
+// titleCountyWord title-cases one word of a county name, capitalizing each
+// hyphenated part ("Miami-Dade") and the letter after a "Mc" prefix.
+func titleCountyWord(w string) string {
+	parts := strings.Split(strings.ToLower(w), "-")
+	for i, p := range parts {
+		if p == "" {
+			continue
+		}
+		p = strings.ToUpper(p[:1]) + p[1:]
+		if strings.HasPrefix(p, "Mc") && len(p) > 2 {
+			p = "Mc" + strings.ToUpper(p[2:3]) + p[3:]
+		}
+		parts[i] = p
+	}
+	return strings.Join(parts, "-")
+}
+
+// countyKeyReplacer drops the characters that county names are spelled with
+// and without, and folds the tilde in "Doña Ana".
+var countyKeyReplacer = strings.NewReplacer(".", "", "'", "", " ", "", "-", "", "Ñ", "N")
+
+// countyKey folds case, periods, apostrophes, spaces, and hyphens so that
+// "PRINCE GEORGES" and "Prince George's", or "DE KALB" and "DeKalb", find
+// the same entry.
+func countyKey(name string) string {
+	return countyKeyReplacer.Replace(strings.ToUpper(name))
+}
+
+// mustParseCounties parses the embedded table. It panics on malformed rows
+// since the file ships with the binary and is covered by tests.
+func mustParseCounties(data string) map[string]map[string]string {
+	rows, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("parse counties.csv: %v", err))
+	}
+	names := map[string]map[string]string{"": make(map[string]string, len(rows))}
+	for i, row := range rows[1:] {
+		state, name := row[0], row[1]
+		if len(state) != 2 || name == "" {
+			panic(fmt.Sprintf("parse counties.csv row %d: invalid row %q", i+2, row))
+		}
+		if names[state] == nil {
+			names[state] = make(map[string]string)
+		}
+		key := countyKey(name)
+		names[state][key] = name
+		if _, ok := names[""][key]; !ok {
+			names[""][key] = name
+		}
+	}
+	return names
+}
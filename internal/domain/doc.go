@@ -35,6 +35,11 @@
 //	  - May include "EF" or "F" prefix, which is stripped during parsing.
 //	Wind ("Speed" column):
 //	  - Miles per hour as an integer: 65 = 65 mph
+//	Flood, flash flood, heavy snow ("Magnitude" column):
+//	  - Water depth in feet, or snowfall in inches. These types come from NWS
+//	    Local Storm Reports rather than the SPC CSVs.
+//	Lightning:
+//	  - No magnitude; never assigned a severity.
 //
 // Unknown values:
 //
@@ -56,10 +61,19 @@
 //	  Hail:    <0.75" minor | <1.5" moderate | <2.5" severe | ≥2.5" extreme
 //	  Wind:    <50 mph minor | <74 mph moderate | <96 mph severe | ≥96 mph extreme
 //	  Tornado: EF0–1 minor | EF2 moderate | EF3–4 severe | EF5 extreme
+//	  Flood:   <0.5 ft minor | <2 ft moderate | <6 ft severe | ≥6 ft extreme
+//	  Snow:    <6" minor | <12" moderate | <24" severe | ≥24" extreme
+//
+//	Per-type rules live in the registry in eventtype.go; thresholds can be
+//	overridden with [Enrichment].SeverityThresholds.
 //
 // # ID Generation
 //
 // Event IDs are deterministic SHA-256 hashes of event_type|state|lat|lon|time|magnitude. This
 // enables idempotent upserts downstream (ON CONFLICT DO NOTHING) and replay
 // safety without distributed coordination. See [generateID].
+//
+// The scheme is pluggable ([IDStrategy], selected by ID_STRATEGY). Strategies
+// after the original embed their version in the ID ("hail-v2-…"), so IDs from
+// different schemes never collide and an unversioned ID is always version 1.
 package domain
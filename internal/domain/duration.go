@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tornadoForwardSpeed converts a tornado path length to a duration. 30 mph
+// is a typical forward speed for tornado-producing storms.
+const tornadoForwardSpeed = 30.0 // mph
+
+// maxEventDuration caps estimated durations so a misread comment cannot
+// stretch an event across days.
+const maxEventDuration = 24 * time.Hour
+
+var (
+	// statedDurationRe matches a duration stated in the comments, e.g.
+	// "on the ground for 12 minutes", "lasted about 2 hours", or "for 4 to 5
+	// minutes" (the upper bound of a range is used).
+	statedDurationRe = regexp.MustCompile(`(?i)\b(?:lasted|lasting|for)\s+(?:about\s+|approximately\s+|around\s+)?(?:\d+(?:\.\d+)?\s*(?:to|-)\s*)?(\d+(?:\.\d+)?)\s*(minutes?|mins?|hours?|hrs?)\b`)
+
+	// pathLengthRe matches a tornado path length in miles, e.g.
+	// "path length of 3.2 miles" or "track was approximately 5 mi".
+	pathLengthRe = regexp.MustCompile(`(?i)\b(?:path|track)\s+(?:length\s+)?(?:of\s+|was\s+)?(?:about\s+|approximately\s+|around\s+)?(\d+(?:\.\d+)?)\s*(?:miles?|mi)\b`)
+)
+
+// defaultEventDurations are the Duration windows registered in builtinEventTypes.
+var defaultEventDurations = func() map[string]time.Duration {
+	m := make(map[string]time.Duration, len(builtinEventTypes))
+	for _, spec := range builtinEventTypes {
+		m[spec.Name] = spec.Duration
+	}
+	return m
+}()
+
+// ParseEventDurations parses overrides of the form "hail=15m,wind=10m" into a
+// full duration set. Event types not mentioned keep their defaults. An empty
+// string yields the defaults.
+func ParseEventDurations(s string) (map[string]time.Duration, error) {
+	result := maps.Clone(defaultEventDurations)
+	for entry := range strings.SplitSeq(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		eventType, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("event duration %q: expected type=duration", entry)
+		}
+		spec, known := lookupEventType(strings.TrimSpace(eventType))
+		if !known {
+			return nil, fmt.Errorf("event durations: unknown event type %q", eventType)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("event duration for %s: %w", spec.Name, err)
+		}
+		if d < 0 || d > maxEventDuration {
+			return nil, fmt.Errorf("event duration for %s: must be between 0 and %s", spec.Name, maxEventDuration)
+		}
+		result[spec.Name] = d
+	}
+	return result, nil
+}
+
+// estimateDuration estimates how long an event lasted, preferring a duration
+// stated in the comments, then (for tornadoes) the path length at a typical
+// forward speed, then the event type's window in windows. It also returns the
+// reason for the estimate.
+func estimateDuration(eventType, comments string, windows map[string]time.Duration) (time.Duration, string) {
+	if m := statedDurationRe.FindStringSubmatch(comments); m != nil {
+		v, _ := strconv.ParseFloat(m[1], 64)
+		unit := time.Minute
+		if strings.HasPrefix(strings.ToLower(m[2]), "h") {
+			unit = time.Hour
+		}
+		return min(time.Duration(v*float64(unit)), maxEventDuration), "duration stated in comments"
+	}
+	if eventType == "tornado" {
+		if m := pathLengthRe.FindStringSubmatch(comments); m != nil {
+			miles, _ := strconv.ParseFloat(m[1], 64)
+			d := time.Duration(miles / tornadoForwardSpeed * float64(time.Hour)).Round(time.Minute)
+			return min(max(d, time.Minute), maxEventDuration),
+				fmt.Sprintf("%s mi path at %s mph", formatFloat(miles), formatFloat(tornadoForwardSpeed))
+		}
+	}
+	return windows[eventType], "default window for " + eventType
+}
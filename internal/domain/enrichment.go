@@ -0,0 +1,89 @@
+package domain
+
+import "time"
+
+// Enrichment holds the deployment settings that shape parsing and
+// enrichment. The zero value applies the defaults: imperial units, the SPC
+// day convention, the original SHA-256 IDs, the registered severity
+// thresholds and duration windows, DefaultNearbyPlacesRadius,
+// DefaultGeohashPrecision, no keyword severity rules, and no boundary lookup.
+// ParseRawEvent and EnrichStormEvent use the zero value; services build theirs
+// from config at startup and hand it to the transformer. An Enrichment is
+// read-only once in use, so it is safe to share between transform workers; a
+// configuration reload swaps in a new one instead of changing it.
+type Enrichment struct {
+	// Units selects the units magnitudes are emitted in.
+	Units UnitSystem
+	// DayConvention decides which date a bare HHMM report time falls on.
+	DayConvention DayConvention
+	// IDStrategy derives event IDs; nil selects the SHA-256 scheme.
+	IDStrategy IDStrategy
+	// EventDurations are the per-type windows used to estimate end times, as
+	// returned by ParseEventDurations; nil selects the registered defaults.
+	EventDurations map[string]time.Duration
+	// NearbyPlacesRadius is the radius in miles within which listed places
+	// are counted toward NearbyPlaces.
+	NearbyPlacesRadius float64
+	// GeohashPrecision is the length of the geohash emitted for each event,
+	// capped at MaxGeohashPrecision.
+	GeohashPrecision int
+	// SeverityThresholds are the per-type magnitudes severity labels are
+	// derived from, as returned by ParseSeverityThresholds; nil selects the
+	// registered defaults.
+	SeverityThresholds map[string]SeverityThresholds
+	// SeverityKeywordRules enables the keyword rules that raise the
+	// magnitude-derived severity.
+	SeverityKeywordRules bool
+	// Boundaries assign each event its CWA and forecast zone; nil disables
+	// the lookup.
+	Boundaries *Boundaries
+}
+
+func (e Enrichment) units() UnitSystem {
+	if e.Units == "" {
+		return UnitsImperial
+	}
+	return e.Units
+}
+
+func (e Enrichment) dayConvention() DayConvention {
+	if e.DayConvention == "" {
+		return DaySPC
+	}
+	return e.DayConvention
+}
+
+func (e Enrichment) idStrategy() IDStrategy {
+	if e.IDStrategy == nil {
+		return sha256IDStrategy{}
+	}
+	return e.IDStrategy
+}
+
+func (e Enrichment) eventDurations() map[string]time.Duration {
+	if e.EventDurations == nil {
+		return defaultEventDurations
+	}
+	return e.EventDurations
+}
+
+func (e Enrichment) nearbyPlacesRadius() float64 {
+	if e.NearbyPlacesRadius <= 0 {
+		return DefaultNearbyPlacesRadius
+	}
+	return e.NearbyPlacesRadius
+}
+
+func (e Enrichment) geohashPrecision() int {
+	if e.GeohashPrecision <= 0 {
+		return DefaultGeohashPrecision
+	}
+	return min(e.GeohashPrecision, MaxGeohashPrecision)
+}
+
+func (e Enrichment) severityThresholds() map[string]SeverityThresholds {
+	if e.SeverityThresholds == nil {
+		return defaultSeverityThresholds
+	}
+	return e.SeverityThresholds
+}
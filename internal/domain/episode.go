@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// EpisodeTolerance bounds how far apart two reports may be, in statute miles
+// and in event time, to belong to the same storm episode. Reports are linked
+// in a chain, so an episode can track a storm far beyond the tolerance as
+// long as each report is close to an earlier one.
+type EpisodeTolerance struct {
+	Miles  float64
+	Window time.Duration
+}
+
+// SameEpisode reports whether a and b are close enough in space and time to
+// come from one storm. Event types may differ, since one supercell can
+// produce hail, damaging wind, and a tornado. Retractions never match.
+func (t EpisodeTolerance) SameEpisode(a, b StormEvent) bool {
+	if a.Deleted || b.Deleted {
+		return false
+	}
+	if d := a.EventTime.Sub(b.EventTime); d > t.Window || d < -t.Window {
+		return false
+	}
+	return distanceMiles(a.Geo, b.Geo) <= t.Miles
+}
+
+// NewEpisodeID returns the ID of an episode started by first, derived from
+// the report's ID so that replaying the same reports yields the same IDs.
+func NewEpisodeID(first StormEvent) string {
+	sum := sha256.Sum256([]byte("episode|" + first.ID))
+	return "episode-" + hex.EncodeToString(sum[:8])
+}
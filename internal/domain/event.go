@@ -2,12 +2,13 @@ package domain
 
 import (
 	"context"
+	"slices"
 	"time"
 )
 
 // RawCSVRecord represents the flat JSON structure produced by the collector.
-// Each CSV type has a different magnitude column (Size, F_Scale, Speed),
-// but all share the remaining columns.
+// Each SPC CSV type has a different magnitude column (Size, F_Scale, Speed);
+// other report types use the generic Magnitude column. All share the remaining columns.
 type RawCSVRecord struct {
 	Time      string `json:"Time"`
 	Size      string `json:"Size"`     // hail magnitude (hundredths of inches)
@@ -19,9 +20,31 @@ type RawCSVRecord struct {
 	Lat       string `json:"Lat"`
 	Lon       string `json:"Lon"`
 	Comments  string `json:"Comments"`
-	EventType string `json:"EventType"` // "hail", "wind", or "tornado"
+	EventType string `json:"EventType"` // a registered type, e.g. "hail" or "flash_flood"
+	Magnitude string `json:"Magnitude"` // magnitude for types beyond the SPC CSVs (flood depth, snowfall)
+
+	// MagnitudeMethod qualifies the magnitude as measured (M) or estimated
+	// (E), as in NWS Local Storm Reports. Empty when the source has no such column.
+	MagnitudeMethod string `json:"MagnitudeMethod,omitempty"`
+
+	// Path endpoints, present for tornado reports from sources that record a
+	// track (NCEI BEGIN_LAT/BEGIN_LON and END_LAT/END_LON). Empty otherwise.
+	BeginLat string `json:"BeginLat,omitempty"`
+	BeginLon string `json:"BeginLon,omitempty"`
+	EndLat   string `json:"EndLat,omitempty"`
+	EndLon   string `json:"EndLon,omitempty"`
+
+	// Deleted marks a report that SPC removed from a later version of the
+	// daily CSV. The remaining fields repeat the original row so the event
+	// ID matches the one that was loaded.
+	Deleted bool `json:"Deleted,omitempty"`
 }
 
+// HeaderCorrelationID is the message header carrying a correlation ID that
+// follows a record across services. The pipeline keeps an upstream value and
+// generates one for messages without it.
+const HeaderCorrelationID = "correlation_id"
+
 // RawEvent represents an unprocessed message from the source topic.
 type RawEvent struct {
 	Key       []byte
@@ -34,6 +57,76 @@ type RawEvent struct {
 	Commit    func(ctx context.Context) error
 }
 
+// DeadLetter pairs a raw event that could not be transformed with the reason
+// it failed. Dead letters are published to a separate topic so that poison
+// messages can be inspected and replayed instead of being silently dropped.
+type DeadLetter struct {
+	Event    RawEvent
+	Reason   string
+	FailedAt time.Time
+}
+
+// DeadLetterPosition identifies a message on the dead-letter topic.
+type DeadLetterPosition struct {
+	Partition int   `json:"partition"`
+	Offset    int64 `json:"offset"`
+}
+
+// DeadLetterRecord is a dead letter read back from the dead-letter topic for
+// inspection: its position there, the original payload, and the failure
+// metadata recorded when it was dead-lettered.
+type DeadLetterRecord struct {
+	DeadLetterPosition
+	Key             string    `json:"key,omitempty"`
+	Value           string    `json:"value"`
+	Reason          string    `json:"reason"`
+	SourceTopic     string    `json:"source_topic,omitempty"`
+	SourcePartition int       `json:"source_partition"`
+	SourceOffset    int64     `json:"source_offset"`
+	FailedAt        time.Time `json:"failed_at"`
+}
+
+// PartitionProgress summarizes how far the pipeline has processed one source
+// partition: the last committed offset, the event time of the last loaded
+// event, and how many messages were loaded, dropped by the event filter, or
+// dead-lettered.
+type PartitionProgress struct {
+	Topic         string    `json:"topic"`
+	Partition     int       `json:"partition"`
+	LastOffset    int64     `json:"last_offset"`
+	LastEventTime time.Time `json:"last_event_time,omitzero"`
+	Loaded        int64     `json:"loaded"`
+	Filtered      int64     `json:"filtered"`
+	DeadLettered  int64     `json:"dead_lettered"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ComponentHealth is the readiness of one pipeline component, such as the
+// Kafka reader or a sink. Error is set when Status is "error".
+type ComponentHealth struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Component health statuses.
+const (
+	HealthOK    = "ok"
+	HealthError = "error"
+)
+
+// HealthReport details the pipeline's health: every component that checks its
+// backing service, whether extraction is paused, the loader circuit breaker
+// state ("closed" or "open"), and when a batch last completed.
+type HealthReport struct {
+	Ready             bool              `json:"ready"`
+	Components        []ComponentHealth `json:"components"`
+	Paused            bool              `json:"paused"`
+	LoaderCircuit     string            `json:"loader_circuit"`
+	MessagesProcessed bool              `json:"messages_processed"`
+	LastBatchAt       time.Time         `json:"last_batch_at,omitzero"`
+}
+
 // Location holds both the raw NWS location string and its parsed components.
 // Nested because these fields are tightly coupled: enrichment parses the raw
 // NWS format ("8 ESE Chappel") into name/distance/direction, and all six fields
@@ -45,6 +138,16 @@ type Location struct {
 	Direction *string  `json:"direction,omitempty"`
 	State     string   `json:"state,omitempty"`
 	County    string   `json:"county,omitempty"`
+
+	// PlaceGeo approximates the coordinates of the named place, derived from
+	// the report's Geo and the Distance/Direction offset. Nil without an offset.
+	PlaceGeo *Geo `json:"place_geo,omitempty"`
+
+	// CWA and Zone are the NWS County Warning Area and public forecast zone
+	// (UGC code, e.g. "OKZ025") containing the report. Empty unless
+	// Enrichment has Boundaries.
+	CWA  string `json:"cwa,omitempty"`
+	Zone string `json:"zone,omitempty"`
 }
 
 // Geo represents a WGS-84 latitude/longitude coordinate pair.
@@ -64,8 +167,119 @@ type Measurement struct {
 	Magnitude float64 `json:"magnitude"`
 	Unit      string  `json:"unit"`
 	Severity  *string `json:"severity,omitempty"`
+
+	// Metric repeats the magnitude in metric units when MEASUREMENT_UNITS=both.
+	Metric *Quantity `json:"metric,omitempty"`
+
+	// Method is MethodMeasured or MethodEstimated, taken from the collector's
+	// MagnitudeMethod column or inferred from the comments; empty when unknown.
+	Method string `json:"method,omitempty"`
+}
+
+// Quantity is a magnitude paired with its unit.
+type Quantity struct {
+	Magnitude float64 `json:"magnitude"`
+	Unit      string  `json:"unit"`
+}
+
+// SourceOfficeDetail describes the NWS Weather Forecast Office that issued a
+// report, looked up from the embedded office table by SourceOffice code.
+type SourceOfficeDetail struct {
+	Code  string `json:"code"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+	Geo   Geo    `json:"geo"`
+}
+
+// NearestCity locates a report relative to the closest city in the embedded
+// places table, in the style of an NWS relative location: the report lies
+// Distance statute miles Direction of the city, e.g. 12.4 miles WNW of Norman.
+type NearestCity struct {
+	Name      string  `json:"name"`
+	State     string  `json:"state"`
+	Distance  float64 `json:"distance"`
+	Direction string  `json:"direction"`
+	Geo       Geo     `json:"geo"`
 }
 
+// NearbyPlaces summarizes the embedded places table around a report: Places
+// counts the listed cities and towns whose centers lie within Radius miles,
+// and Population sums their census populations. The table holds about 240
+// larger places, so this is not an estimate of the population exposed to
+// the event: a city counts in full once its center is in range, and towns
+// outside the table and rural areas are never counted.
+type NearbyPlaces struct {
+	Population int     `json:"population"`
+	Places     int     `json:"places"`
+	Radius     float64 `json:"radius"`
+}
+
+// Impact holds casualty counts and damage indicators extracted from the free-text
+// comments. Counts are nil when the comments do not mention them; an explicit
+// "no injuries" is 0. Damage lists canonical keywords such as "trees down".
+type Impact struct {
+	Injuries   *int     `json:"injuries,omitempty"`
+	Fatalities *int     `json:"fatalities,omitempty"`
+	Damage     []string `json:"damage,omitempty"`
+}
+
+// DataQuality flags values the pipeline derived instead of reading them from
+// the source record, and validation checks the event failed, so consumers
+// can treat them with less confidence.
+type DataQuality struct {
+	// Inferred lists the derived fields by JSON path, e.g.
+	// "measurement.magnitude" for a hail size read from the comments.
+	Inferred []string `json:"inferred,omitempty"`
+
+	// Flags lists the validation checks the event failed under rules with
+	// the annotate action, e.g. "missing_location", plus "outside_us" for
+	// coordinates outside every US region that could not be corrected,
+	// "unknown_state" for a state that is not a US state or territory,
+	// "unknown_county" for a county that is not one of its state's counties,
+	// and "comments_truncated" or "raw_text_dropped" for an event shortened
+	// to fit the sink message size.
+	Flags []string `json:"flags,omitempty"`
+}
+
+// AddQualityFlag lists flag in the event's Quality.Flags, once.
+func (e *StormEvent) AddQualityFlag(flag string) {
+	if e.Quality == nil {
+		e.Quality = &DataQuality{}
+	}
+	if !slices.Contains(e.Quality.Flags, flag) {
+		e.Quality.Flags = append(e.Quality.Flags, flag)
+	}
+}
+
+// markInferred lists the JSON path of a derived field in Quality.Inferred.
+func (e *StormEvent) markInferred(path string) {
+	if e.Quality == nil {
+		e.Quality = &DataQuality{}
+	}
+	if !slices.Contains(e.Quality.Inferred, path) {
+		e.Quality.Inferred = append(e.Quality.Inferred, path)
+	}
+}
+
+// Provenance records where an event came from for lineage tracking: the
+// source message headers selected with PROVENANCE_HEADERS, such as the
+// collector run ID or the source file name.
+type Provenance struct {
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// SchemaVersion is the current version of the serialized StormEvent. Bump it
+// when a change would break existing consumers, and add a downgrade for the
+// previous version to the Kafka writer's compatibility layer.
+//
+//	1: original shape, without schema_version
+//	2: adds schema_version, measurement.metric, location.place_geo, impact,
+//	   source_office_detail, provenance, end_time, path_begin, path_end,
+//	   measurement.method, quality, merged_from, episode_id, nearest_city,
+//	   nearby_places, warning_active, warning_ids, geohash, location.cwa, and
+//	   location.zone
+const SchemaVersion = 2
+
 // StormEvent is the domain-rich representation after parsing and enrichment.
 //
 // All fields are grouped into nested structs when they represent cohesive domain
@@ -74,16 +288,76 @@ type Measurement struct {
 // via json.Unmarshal, flattens to prefixed DB columns, and gqlgen auto-resolves
 // the GraphQL types from these structs.
 type StormEvent struct {
+	SchemaVersion int `json:"schema_version,omitempty"`
+
 	ID           string      `json:"id"`
 	EventType    string      `json:"event_type"`
 	Geo          Geo         `json:"geo,omitempty"`
 	Measurement  Measurement `json:"measurement"`
 	EventTime    time.Time   `json:"event_time"`
+	EndTime      time.Time   `json:"end_time,omitzero"`
 	Location     Location    `json:"location,omitempty"`
 	Comments     string      `json:"comments,omitempty"`
 	SourceOffice string      `json:"source_office,omitempty"`
+	Impact       *Impact     `json:"impact,omitempty"`
 	TimeBucket   time.Time   `json:"time_bucket,omitempty"`
+	Geohash      string      `json:"geohash,omitempty"`
+
+	// SourceOfficeDetail is nil when SourceOffice is empty or not a known WFO.
+	SourceOfficeDetail *SourceOfficeDetail `json:"source_office_detail,omitempty"`
+
+	// NearestCity is the closest place in the embedded places table, for
+	// display when no geocoding service is available. Nil without coordinates.
+	NearestCity *NearestCity `json:"nearest_city,omitempty"`
+
+	// NearbyPlaces counts the listed places near the report, from the same
+	// table. Nil without coordinates.
+	NearbyPlaces *NearbyPlaces `json:"nearby_places,omitempty"`
+
+	// WarningActive reports whether the report lies inside an NWS warning
+	// polygon in effect at EventTime, and WarningIDs lists those warnings.
+	// Nil unless warning correlation is enabled and the lookup succeeded.
+	WarningActive *bool    `json:"warning_active,omitempty"`
+	WarningIDs    []string `json:"warning_ids,omitempty"`
+
+	// PathBegin and PathEnd are the endpoints of a tornado track. Both are nil
+	// unless the record carries two valid, distinct endpoints.
+	PathBegin *Geo `json:"path_begin,omitempty"`
+	PathEnd   *Geo `json:"path_end,omitempty"`
+
+	// Quality is nil unless a value was inferred or an annotate rule failed.
+	Quality *DataQuality `json:"quality,omitempty"`
+
+	// Provenance is nil unless a selected header was present on the source
+	// message.
+	Provenance *Provenance `json:"provenance,omitempty"`
+
+	// MergedFrom lists the IDs of near-duplicate reports of the same storm
+	// that were merged into this one, when duplicate merging is enabled.
+	MergedFrom []string `json:"merged_from,omitempty"`
+
+	// EpisodeID groups reports close in space and time, such as those from
+	// one supercell, when episode clustering is enabled.
+	EpisodeID string `json:"episode_id,omitempty"`
 
 	RawPayload  []byte    `json:"-"`
 	ProcessedAt time.Time `json:"processed_at"`
+
+	// Deleted retracts a previously loaded event with the same ID. The Kafka
+	// writer emits a tombstone for it instead of the serialized event.
+	Deleted bool `json:"deleted,omitempty"`
+
+	// TraceContext carries W3C trace-context entries (traceparent, tracestate)
+	// from the source message to the sink so the downstream trace continues.
+	// Set by the pipeline; not part of the serialized event.
+	TraceContext map[string]string `json:"-"`
+
+	// CorrelationID identifies the source message across services. Set by
+	// the pipeline and emitted as a sink header; not serialized.
+	CorrelationID string `json:"-"`
+
+	// OrderingKey, when set, replaces the event ID as the Kafka message key so
+	// events from the same source key or partition stay in order on one sink
+	// partition. Set by the pipeline in ordered mode; not serialized.
+	OrderingKey string `json:"-"`
 }
@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// eventTypeSpec describes how one report type is parsed, normalized, and
+// classified. Adding a type means adding a spec to builtinEventTypes; no
+// switch statements elsewhere in the package need to change.
+type eventTypeSpec struct {
+	// Name is the canonical event_type emitted downstream, e.g. "flash_flood".
+	Name string
+	// Aliases are alternative spellings accepted from the collector, e.g. "flash flood".
+	Aliases []string
+	// Magnitude selects the raw column carrying this type's magnitude.
+	Magnitude func(RawCSVRecord) string
+	// TrimPrefixes are stripped from the raw magnitude before parsing, e.g. "EF".
+	TrimPrefixes []string
+	// DefaultUnit is assigned when the payload carries no unit.
+	DefaultUnit string
+	// Metric converts DefaultUnit when metric output is enabled; nil means the
+	// unit has no metric equivalent (e.g. the F-scale).
+	Metric *metricConversion
+	// Normalize corrects known encoding issues; nil leaves the magnitude as-is.
+	Normalize func(magnitude float64, unit string) float64
+	// Thresholds are the default severity levels. The zero value means the
+	// type has no meaningful magnitude and is never assigned a severity.
+	Thresholds SeverityThresholds
+	// Duration is the default window from EventTime to EndTime when the
+	// comments give no better estimate. Zero marks an instantaneous report.
+	Duration time.Duration
+}
+
+// builtinEventTypes are the report types the collector may publish. The
+// original SPC CSVs only carry hail, tornado, and wind; the rest come from
+// NWS Local Storm Reports and use the generic Magnitude column.
+var builtinEventTypes = []eventTypeSpec{
+	{
+		Name:        "hail",
+		Magnitude:   func(r RawCSVRecord) string { return r.Size },
+		DefaultUnit: "in",
+		Metric:      &metricConversion{Unit: "mm", Factor: 25.4},
+		Normalize:   normalizeHailMagnitude,
+		// NWS severe hail starts at 0.75" (quarter); 2.5" is tennis-ball size.
+		Thresholds: SeverityThresholds{Moderate: 0.75, Severe: 1.5, Extreme: 2.5},
+		// Hail cores typically pass over a point within 10-15 minutes.
+		Duration: 15 * time.Minute,
+	},
+	{
+		Name:        "wind",
+		Magnitude:   func(r RawCSVRecord) string { return r.Speed },
+		DefaultUnit: "mph",
+		Metric:      &metricConversion{Unit: "km/h", Factor: 1.609344},
+		// 50 mph is the NWS severe criterion; 74 is hurricane force; 96 is Cat 2.
+		Thresholds: SeverityThresholds{Moderate: 50, Severe: 74, Extreme: 96},
+		Duration:   10 * time.Minute,
+	},
+	{
+		Name:         "tornado",
+		Magnitude:    func(r RawCSVRecord) string { return r.FScale },
+		TrimPrefixes: []string{"EF", "F"},
+		DefaultUnit:  "f_scale",
+		// Enhanced Fujita: EF0-1 minor, EF2 moderate, EF3-4 severe, EF5 extreme.
+		Thresholds: SeverityThresholds{Moderate: 2, Severe: 3, Extreme: 5},
+		// Used when the comments give no path length or duration.
+		Duration: 10 * time.Minute,
+	},
+	{
+		Name:        "flood",
+		Magnitude:   func(r RawCSVRecord) string { return r.Magnitude },
+		DefaultUnit: "ft",
+		Metric:      &metricConversion{Unit: "m", Factor: 0.3048},
+		// Water depth: 0.5 ft stalls cars, 2 ft floats vehicles, 6 ft reaches rooflines.
+		Thresholds: SeverityThresholds{Moderate: 0.5, Severe: 2, Extreme: 6},
+		Duration:   6 * time.Hour,
+	},
+	{
+		Name:        "flash_flood",
+		Aliases:     []string{"flash flood"},
+		Magnitude:   func(r RawCSVRecord) string { return r.Magnitude },
+		DefaultUnit: "ft",
+		Metric:      &metricConversion{Unit: "m", Factor: 0.3048},
+		Thresholds:  SeverityThresholds{Moderate: 0.5, Severe: 2, Extreme: 6},
+		Duration:    3 * time.Hour,
+	},
+	{
+		Name:      "lightning",
+		Magnitude: func(r RawCSVRecord) string { return r.Magnitude },
+	},
+	{
+		Name:        "heavy_snow",
+		Aliases:     []string{"heavy snow"},
+		Magnitude:   func(r RawCSVRecord) string { return r.Magnitude },
+		DefaultUnit: "in",
+		Metric:      &metricConversion{Unit: "cm", Factor: 2.54},
+		// Snowfall: 6" is a typical warning criterion; 24" is crippling.
+		Thresholds: SeverityThresholds{Moderate: 6, Severe: 12, Extreme: 24},
+		// Snowfall totals accumulate over an event, usually half a day.
+		Duration: 12 * time.Hour,
+	},
+}
+
+// eventTypes indexes builtinEventTypes by canonical name and alias.
+var eventTypes = func() map[string]*eventTypeSpec {
+	m := make(map[string]*eventTypeSpec)
+	for i := range builtinEventTypes {
+		spec := &builtinEventTypes[i]
+		m[spec.Name] = spec
+		for _, alias := range spec.Aliases {
+			m[alias] = spec
+		}
+	}
+	return m
+}()
+
+// lookupEventType returns the spec for a canonical name or alias.
+func lookupEventType(name string) (*eventTypeSpec, bool) {
+	spec, ok := eventTypes[name]
+	return spec, ok
+}
+
+// CanonicalEventType returns the canonical name for a registered event type or
+// alias, or "" when it is not registered.
+func CanonicalEventType(name string) string {
+	return normalizeEventType(name)
+}
+
+// EventTypes returns the canonical names of all supported event types, sorted.
+func EventTypes() []string {
+	names := make([]string, 0, len(builtinEventTypes))
+	for _, spec := range builtinEventTypes {
+		names = append(names, spec.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// AcceptedEventTypes returns every event type name accepted in raw records,
+// canonical names and aliases alike, sorted.
+func AcceptedEventTypes() []string {
+	return slices.Sorted(maps.Keys(eventTypes))
+}
+
+// parseMagnitude parses a raw magnitude column for the given spec.
+// Returns 0 for empty or unknown values like "UNK".
+func (s *eventTypeSpec) parseMagnitude(raw string) float64 {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.EqualFold(raw, "UNK") {
+		return 0
+	}
+	for _, prefix := range s.TrimPrefixes {
+		raw = strings.TrimPrefix(raw, prefix)
+	}
+	return parseFloatOrZero(raw)
+}
+
+// defaultThresholds collects the non-zero default thresholds of every type.
+func defaultThresholds() map[string]SeverityThresholds {
+	m := make(map[string]SeverityThresholds, len(builtinEventTypes))
+	for _, spec := range builtinEventTypes {
+		if spec.Thresholds != (SeverityThresholds{}) {
+			m[spec.Name] = spec.Thresholds
+		}
+	}
+	return m
+}
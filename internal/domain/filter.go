@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SeverityLevels lists the severity labels from least to most severe.
+var SeverityLevels = []string{"minor", "moderate", "severe", "extreme"}
+
+// Reasons reported by EventFilter.Match for a rejected event.
+const (
+	FilterReasonState     = "state"
+	FilterReasonEventType = "event_type"
+	FilterReasonSeverity  = "severity"
+	FilterReasonBBox      = "bbox"
+)
+
+// BoundingBox is a latitude/longitude rectangle, edges inclusive.
+type BoundingBox struct {
+	MinLat, MinLon, MaxLat, MaxLon float64
+}
+
+// Contains reports whether g lies inside the box.
+func (b BoundingBox) Contains(g Geo) bool {
+	return g.Lat >= b.MinLat && g.Lat <= b.MaxLat && g.Lon >= b.MinLon && g.Lon <= b.MaxLon
+}
+
+// ParseBoundingBox parses "minLat,minLon,maxLat,maxLon".
+func ParseBoundingBox(s string) (BoundingBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return BoundingBox{}, fmt.Errorf("bounding box %q: expected minLat,minLon,maxLat,maxLon", s)
+	}
+	var v [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return BoundingBox{}, fmt.Errorf("bounding box %q: %w", s, err)
+		}
+		v[i] = f
+	}
+	b := BoundingBox{MinLat: v[0], MinLon: v[1], MaxLat: v[2], MaxLon: v[3]}
+	if b.MinLat < -90 || b.MaxLat > 90 || b.MinLon < -180 || b.MaxLon > 180 || b.MinLat > b.MaxLat || b.MinLon > b.MaxLon {
+		return BoundingBox{}, fmt.Errorf("bounding box %q: out of range or min above max", s)
+	}
+	return b, nil
+}
+
+// EventFilter selects which enriched events are loaded. Every non-empty
+// criterion must match; the zero value matches everything.
+type EventFilter struct {
+	// States allows only these two-letter state codes; ExcludeStates drops
+	// these. Both compare case-insensitively.
+	States        []string
+	ExcludeStates []string
+
+	// EventTypes allows only these canonical event types (see
+	// CanonicalEventType).
+	EventTypes []string
+
+	// MinSeverity drops events below this level, including events without a
+	// severity (such as tornadoes rated UNK). It must be one of SeverityLevels
+	// (see ParseSeverityLevel).
+	MinSeverity string
+
+	// BBox drops events whose report coordinates fall outside the box.
+	BBox *BoundingBox
+}
+
+// ParseSeverityLevel validates a severity label case-insensitively and
+// returns it in canonical lower case.
+func ParseSeverityLevel(s string) (string, error) {
+	level := strings.ToLower(strings.TrimSpace(s))
+	if !slices.Contains(SeverityLevels, level) {
+		return "", fmt.Errorf("unknown severity %q: must be one of %s", s, strings.Join(SeverityLevels, ", "))
+	}
+	return level, nil
+}
+
+// Active reports whether the filter can reject anything.
+func (f EventFilter) Active() bool {
+	return len(f.States) > 0 || len(f.ExcludeStates) > 0 || len(f.EventTypes) > 0 || f.MinSeverity != "" || f.BBox != nil
+}
+
+// Match reports whether an enriched event passes the filter. When it does
+// not, reason names the first criterion that rejected it (a FilterReason
+// constant).
+func (f EventFilter) Match(event StormEvent) (ok bool, reason string) {
+	sameState := func(code string) bool { return strings.EqualFold(code, event.Location.State) }
+	if (len(f.States) > 0 && !slices.ContainsFunc(f.States, sameState)) || slices.ContainsFunc(f.ExcludeStates, sameState) {
+		return false, FilterReasonState
+	}
+	if len(f.EventTypes) > 0 && !slices.Contains(f.EventTypes, event.EventType) {
+		return false, FilterReasonEventType
+	}
+	if f.MinSeverity != "" {
+		if event.Measurement.Severity == nil ||
+			slices.Index(SeverityLevels, *event.Measurement.Severity) < slices.Index(SeverityLevels, f.MinSeverity) {
+			return false, FilterReasonSeverity
+		}
+	}
+	if f.BBox != nil && !f.BBox.Contains(event.Geo) {
+		return false, FilterReasonBBox
+	}
+	return true, ""
+}
@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mockFixture is the combined collector fixture generated from the SPC mock CSVs.
+const mockFixture = "../../data/mock/storm_reports_240426_combined.json"
+
+var compassRe = regexp.MustCompile(`^[NSEW]{1,3}$`)
+
+// addMockRecords seeds f with every record of the mock fixture, passing each
+// record's raw JSON and its fields to add.
+func addMockRecords(f *testing.F, add func(raw []byte, rec RawCSVRecord)) {
+	f.Helper()
+	data, err := os.ReadFile(mockFixture)
+	if err != nil {
+		f.Fatalf("read seed corpus: %v", err)
+	}
+	var records []json.RawMessage
+	if err := json.Unmarshal(data, &records); err != nil {
+		f.Fatalf("decode seed corpus: %v", err)
+	}
+	for _, raw := range records {
+		var rec RawCSVRecord
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			f.Fatalf("decode seed record: %v", err)
+		}
+		add(raw, rec)
+	}
+}
+
+func FuzzParseRawEvent(f *testing.F) {
+	base := time.Date(2024, 4, 26, 0, 0, 0, 0, time.UTC).Unix()
+	addMockRecords(f, func(raw []byte, _ RawCSVRecord) { f.Add(raw, base) })
+	for _, seed := range []string{
+		`{"Time":"1510","Size":"175","Lat":"31.02","Lon":"-98.44","EventType":"hail"`,
+		`{"Time":"2024-04-26T15:10:00Z","Speed":"65","Lat":"35.1","Lon":"-97.4","EventType":"wind","Comments":"Trèes döwn 🌪️ (OUN)"}`,
+		`{"Size":"NaN","Lat":"Inf","Lon":"-Inf","EventType":"hail"}`,
+		`{"Size":"1e308","F_Scale":"EF99999","Lat":"1e400","Lon":"-0","EventType":"tornado"}`,
+		`{"Magnitude":"-3","Location":"999999999999 NNW x","Lat":"89.9999","Lon":"179.9999","EventType":"flood"}`,
+		`[]`, `null`, `""`, `{"EventType":1}`,
+	} {
+		f.Add([]byte(seed), base)
+	}
+
+	f.Fuzz(func(t *testing.T, value []byte, unix int64) {
+		event, err := ParseRawEvent(RawEvent{Value: value, Timestamp: time.Unix(unix, 0).UTC()})
+		if err != nil {
+			return
+		}
+		for name, v := range map[string]float64{"lat": event.Geo.Lat, "lon": event.Geo.Lon, "magnitude": event.Measurement.Magnitude} {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				t.Fatalf("parsed %s is %g", name, v)
+			}
+		}
+		if event.ID == "" {
+			t.Fatal("parsed event has no ID")
+		}
+		if ValidateStormEvent(event) != nil {
+			return
+		}
+
+		enriched := EnrichStormEvent(event)
+		if enriched.EventType != CanonicalEventType(event.EventType) {
+			t.Fatalf("event type %q enriched to %q", event.EventType, enriched.EventType)
+		}
+		if _, err := json.Marshal(enriched); err != nil {
+			t.Fatalf("enriched event does not serialize: %v", err)
+		}
+	})
+}
+
+func FuzzDecodeRawCSVRecord(f *testing.F) {
+	addMockRecords(f, func(raw []byte, _ RawCSVRecord) {
+		// Collector records must take the scanner, not the fallback.
+		if !scanRawCSVRecord(string(raw), &RawCSVRecord{}) {
+			f.Fatalf("scanner rejected collector record %s", raw)
+		}
+		f.Add(raw)
+	})
+	for _, seed := range []string{
+		`{}`, ` { "Time" : "1510" , "Deleted" : true } `, `{"Comments":"Trees \"down\" \u0026 lines\/poles"}`,
+		"{\"Comments\":\"caf\xe9\"}", `{"Lat":null,"Deleted":null}`, `{"Lat":"1","Lat":"2"}`, `{"lat":"31.02"}`,
+		`{"Extra":{"nested":[1,2]},"State":"TX"}`, `{"Deleted":"true"}`, `{"Size":175}`, `{"Time":"1510"}x`,
+		`{"Time":"15\q"}`, "{\"Time\":\"a\x01\"}", `{"Time":"1510",}`, `{"Deleted":truex}`, `[]`, `null`, ``,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var want RawCSVRecord
+		wantErr := json.Unmarshal(data, &want)
+		got, err := decodeRawCSVRecord(data)
+		if (err != nil) != (wantErr != nil) {
+			t.Fatalf("decode %q: error %v, json.Unmarshal error %v", data, err, wantErr)
+		}
+		if err == nil && got != want {
+			t.Fatalf("decode %q = %+v, json.Unmarshal = %+v", data, got, want)
+		}
+	})
+}
+
+func FuzzParseLocation(f *testing.F) {
+	addMockRecords(f, func(_ []byte, rec RawCSVRecord) { f.Add(rec.Location) })
+	for _, seed := range []string{"", "  ", "8 ESE", "0.5 N Ville Platte", "12 XYZ Nowhere", "1.2.3 N Town", "99999999999999999999999 S Big", "3 NW Çañon\u0000"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, location string) {
+		name, distance, direction := parseLocation(location)
+		if distance == nil || direction == nil {
+			if distance != nil || direction != nil {
+				t.Fatalf("partial parse of %q: distance %v, direction %v", location, distance, direction)
+			}
+			if name != strings.TrimSpace(location) {
+				t.Fatalf("unparsed location %q returned name %q", location, name)
+			}
+			return
+		}
+		if *distance < 0 || math.IsInf(*distance, 0) || math.IsNaN(*distance) {
+			t.Fatalf("location %q parsed distance %g", location, *distance)
+		}
+		if !compassRe.MatchString(*direction) {
+			t.Fatalf("location %q parsed direction %q", location, *direction)
+		}
+		if name == "" {
+			t.Fatalf("location %q parsed an empty name", location)
+		}
+	})
+}
+
+func FuzzParseHHMM(f *testing.F) {
+	addMockRecords(f, func(_ []byte, rec RawCSVRecord) { f.Add(rec.Time) })
+	for _, seed := range []string{"", "5", "959", "0000", "2359", "2400", "1260", "-130", "+159", "12345", "１２３４", "2024-04-26T15:10:00Z"} {
+		f.Add(seed)
+	}
+	base := time.Date(2024, 4, 26, 0, 0, 0, 0, time.UTC)
+
+	f.Fuzz(func(t *testing.T, hhmm string) {
+		got := parseHHMM(base, hhmm)
+		if got.Year() != base.Year() || got.YearDay() != base.YearDay() {
+			t.Fatalf("parseHHMM(%q) = %s, left the base date", hhmm, got)
+		}
+		if got.Second() != 0 || got.Nanosecond() != 0 {
+			t.Fatalf("parseHHMM(%q) = %s, has sub-minute precision", hhmm, got)
+		}
+	})
+}
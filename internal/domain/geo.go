@@ -0,0 +1,84 @@
+package domain
+
+import "math"
+
+// earthRadiusMiles is the mean Earth radius used for great-circle offsets.
+// NWS relative locations are given in statute miles.
+const earthRadiusMiles = 3958.8
+
+// compassBearings maps the 16-point compass directions used in NWS locations
+// to bearings in degrees clockwise from true north.
+var compassBearings = map[string]float64{
+	"N": 0, "NNE": 22.5, "NE": 45, "ENE": 67.5,
+	"E": 90, "ESE": 112.5, "SE": 135, "SSE": 157.5,
+	"S": 180, "SSW": 202.5, "SW": 225, "WSW": 247.5,
+	"W": 270, "WNW": 292.5, "NW": 315, "NNW": 337.5,
+}
+
+// derivePlaceGeo estimates the coordinates of the named place in an NWS
+// relative location. "8 ESE Chappel" means the report is 8 miles ESE of
+// Chappel, so the place lies 8 miles from the report point on the reciprocal
+// bearing (WNW). Returns nil when the report has no coordinates or the
+// location has no parsed offset. Reports at the place itself (no offset)
+// also return nil, since the report point already is the place.
+func derivePlaceGeo(report Geo, distance *float64, direction *string) *Geo {
+	if report.Lat == 0 && report.Lon == 0 {
+		return nil
+	}
+	if distance == nil || direction == nil {
+		return nil
+	}
+	bearing, ok := compassBearings[*direction]
+	if !ok {
+		return nil
+	}
+	place := destination(report, math.Mod(bearing+180, 360), *distance)
+	return &place
+}
+
+// destination returns the point reached by travelling miles from start along
+// an initial great-circle bearing (degrees clockwise from north).
+func destination(start Geo, bearing, miles float64) Geo {
+	lat1 := start.Lat * math.Pi / 180
+	lon1 := start.Lon * math.Pi / 180
+	theta := bearing * math.Pi / 180
+	delta := miles / earthRadiusMiles
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(delta) + math.Cos(lat1)*math.Sin(delta)*math.Cos(theta))
+	lon2 := lon1 + math.Atan2(
+		math.Sin(theta)*math.Sin(delta)*math.Cos(lat1),
+		math.Cos(delta)-math.Sin(lat1)*math.Sin(lat2),
+	)
+
+	return Geo{
+		Lat: roundTo(lat2*180/math.Pi, 4),
+		Lon: roundTo(math.Mod(lon2*180/math.Pi+540, 360)-180, 4),
+	}
+}
+
+// distanceMiles returns the great-circle distance between two points.
+func distanceMiles(a, b Geo) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMiles * math.Asin(math.Sqrt(min(h, 1)))
+}
+
+// bearingDegrees returns the initial great-circle bearing from a to b in
+// degrees clockwise from true north.
+func bearingDegrees(a, b Geo) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+// compassPoints are the 16 compass directions in clockwise order from north.
+var compassPoints = []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+// compassDirection returns the 16-point compass direction nearest a bearing.
+func compassDirection(bearing float64) string {
+	return compassPoints[int(math.Round(bearing/22.5))%len(compassPoints)]
+}
@@ -0,0 +1,49 @@
+package domain
+
+// DefaultGeohashPrecision is the geohash length emitted unless
+// Enrichment.GeohashPrecision overrides it. Seven characters is a cell of
+// about 150 m, finer than the precision of most spotter reports.
+const DefaultGeohashPrecision = 7
+
+// MaxGeohashPrecision is the longest supported geohash, a cell of a few
+// centimeters.
+const MaxGeohashPrecision = 12
+
+// geohashAlphabet is the base-32 alphabet of geohashes, which leaves out
+// a, i, l, and o.
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// EncodeGeohash returns the geohash of g with precision characters. Events
+// sharing a prefix lie in the same cell, so a prefix serves as a cheap
+// proximity bucket. It returns "" for missing (0, 0) coordinates.
+func EncodeGeohash(g Geo, precision int) string {
+	if g == (Geo{}) || precision <= 0 {
+		return ""
+	}
+	precision = min(precision, MaxGeohashPrecision)
+	lat, lon := [2]float64{-90, 90}, [2]float64{-180, 180}
+	hash := make([]byte, 0, precision)
+	even := true
+	var bits, ch int
+	for len(hash) < precision {
+		// Bits alternate between longitude and latitude, longitude first.
+		r, v := &lat, g.Lat
+		if even {
+			r, v = &lon, g.Lon
+		}
+		mid := (r[0] + r[1]) / 2
+		ch <<= 1
+		if v >= mid {
+			ch |= 1
+			r[0] = mid
+		} else {
+			r[1] = mid
+		}
+		even = !even
+		if bits++; bits == 5 {
+			hash = append(hash, geohashAlphabet[ch])
+			bits, ch = 0, 0
+		}
+	}
+	return string(hash)
+}
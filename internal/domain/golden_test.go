@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the current output")
+
+// enrichGolden holds the parse+enrich output for every mock fixture record.
+const enrichGolden = "testdata/enrich.golden.json"
+
+// goldenRecord is one fixture record's outcome: the enriched event, or the
+// error that rejected it.
+type goldenRecord struct {
+	Event *StormEvent `json:"event,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// TestEnrichGolden runs the full parse, validate, and enrich path over the
+// mock fixture and compares the result with the committed golden file, so
+// every behavioral change to enrichment shows up in review. After an intended
+// change, regenerate it with:
+//
+//	go test ./internal/domain -run TestEnrichGolden -update
+func TestEnrichGolden(t *testing.T) {
+	SetClock(clockwork.NewFakeClockAt(time.Date(2024, 4, 27, 6, 0, 0, 0, time.UTC)))
+	t.Cleanup(func() { SetClock(nil) })
+
+	data, err := os.ReadFile(mockFixture)
+	require.NoError(t, err)
+	var records []json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &records))
+
+	reportDate := time.Date(2024, 4, 26, 0, 0, 0, 0, time.UTC)
+	out := make([]goldenRecord, len(records))
+	for i, value := range records {
+		event, err := ParseRawEvent(RawEvent{Value: value, Timestamp: reportDate})
+		if err == nil {
+			err = ValidateStormEvent(event)
+		}
+		if err != nil {
+			out[i].Error = err.Error()
+			continue
+		}
+		enriched := EnrichStormEvent(event)
+		out[i].Event = &enriched
+	}
+	got, err := json.MarshalIndent(out, "", "  ")
+	require.NoError(t, err)
+	got = append(got, '\n')
+
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(enrichGolden), 0o755))
+		require.NoError(t, os.WriteFile(enrichGolden, got, 0o644)) //nolint:gosec // golden files are committed source
+		return
+	}
+	want, err := os.ReadFile(enrichGolden)
+	require.NoError(t, err, "golden file missing; run with -update to create it")
+	assert.Equal(t, string(want), string(got), "enrichment output changed; if intended, run: go test ./internal/domain -run TestEnrichGolden -update")
+}
@@ -0,0 +1,54 @@
+package domain
+
+import "regexp"
+
+// hailSizeTerms map the descriptive hail sizes spotters use to diameters in
+// inches, following the NWS hail size chart. Each pattern must be followed by
+// "size"/"sized" or "hail", so "quarter mile" or "larger than a quarter" do
+// not match.
+var hailSizeTerms = func() []hailSizeTerm {
+	terms := []struct {
+		pattern string
+		inches  float64
+	}{
+		{`pea`, 0.25},
+		{`(?:marble|mothball)`, 0.50},
+		{`penny`, 0.75},
+		{`nickel`, 0.88},
+		{`quarter`, 1.00},
+		{`half[\s-]?dollar`, 1.25},
+		{`(?:ping[\s-]?pong(?:[\s-]?ball)?|walnut)`, 1.50},
+		{`golf[\s-]?ball`, 1.75},
+		{`(?:hen[\s-]?)?egg`, 2.00},
+		{`tennis[\s-]?ball`, 2.50},
+		{`baseball`, 2.75},
+		{`tea[\s-]?cup`, 3.00},
+		{`grapefruit`, 4.00},
+		{`softball`, 4.50},
+	}
+	out := make([]hailSizeTerm, len(terms))
+	for i, t := range terms {
+		out[i] = hailSizeTerm{
+			re:     regexp.MustCompile(`(?i)\b(` + t.pattern + `)(?:[\s-]+sized?\b|[\s-]+hail\b)`),
+			inches: t.inches,
+		}
+	}
+	return out
+}()
+
+type hailSizeTerm struct {
+	re     *regexp.Regexp
+	inches float64
+}
+
+// inferHailSize returns the largest hail diameter in inches described in the
+// comments ("pea to quarter size hail" gives 1.00) and the phrase it came
+// from, or 0 when no descriptive size is mentioned.
+func inferHailSize(comments string) (inches float64, phrase string) {
+	for _, t := range hailSizeTerms {
+		if m := t.re.FindString(comments); m != "" && t.inches > inches {
+			inches, phrase = t.inches, m
+		}
+	}
+	return inches, phrase
+}
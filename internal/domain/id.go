@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// IDKey holds the raw report fields an event ID is derived from.
+type IDKey struct {
+	EventType string
+	State     string
+	Lat       float64
+	Lon       float64
+	Time      string
+	Magnitude float64
+}
+
+// IDStrategy derives a deterministic event ID from an IDKey. Each strategy has
+// a fixed version that is embedded in the IDs it produces (except version 1,
+// which predates versioning), so IDs from different schemes never collide and
+// consumers can tell which scheme produced a given ID.
+type IDStrategy interface {
+	// Name is the ID_STRATEGY value that selects the strategy.
+	Name() string
+	// Version is embedded in generated IDs as "v<N>-".
+	Version() int
+	// ID returns the event ID for k.
+	ID(k IDKey) string
+}
+
+// Supported ID_STRATEGY values.
+const (
+	IDStrategySHA256            = "sha256"
+	IDStrategySHA256NoMagnitude = "sha256-nomag"
+	IDStrategyUUIDv5            = "uuidv5"
+)
+
+// idStrategies are the registered strategies keyed by name. Never change the
+// output of an existing strategy: add a new one with the next version instead.
+var idStrategies = map[string]IDStrategy{
+	IDStrategySHA256:            sha256IDStrategy{},
+	IDStrategySHA256NoMagnitude: sha256NoMagnitudeIDStrategy{},
+	IDStrategyUUIDv5:            uuidV5IDStrategy{},
+}
+
+// IDStrategyByName returns the registered strategy with the given name.
+func IDStrategyByName(name string) (IDStrategy, error) {
+	s, ok := idStrategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown ID strategy %q", name)
+	}
+	return s, nil
+}
+
+// IDStrategyNames returns the registered strategy names, sorted.
+func IDStrategyNames() []string {
+	names := make([]string, 0, len(idStrategies))
+	for name := range idStrategies {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// generateID produces a deterministic ID from the event's key fields using
+// strategy s. Deterministic IDs enable idempotent upserts (ON CONFLICT
+// DO NOTHING) and replay safety — reprocessing the same raw event produces the same ID.
+func generateID(s IDStrategy, eventType, state string, lat, lon float64, timeStr string, magnitude float64) string {
+	return s.ID(IDKey{EventType: eventType, State: state, Lat: lat, Lon: lon, Time: timeStr, Magnitude: magnitude})
+}
+
+// formatID prefixes a strategy-specific hash with the event type and version.
+// Version 1 IDs carry no version marker: "hail-<hash>".
+func formatID(eventType string, version int, hash string) string {
+	if version > 1 {
+		hash = "v" + strconv.Itoa(version) + "-" + hash
+	}
+	if eventType == "" {
+		return hash
+	}
+	return eventType + "-" + hash
+}
+
+// sha256IDStrategy is the original scheme: the first 8 bytes of SHA-256 over
+// type|state|lat|lon|time|magnitude. Two reports differing only in magnitude
+// (e.g. a corrected hail size) get different IDs.
+type sha256IDStrategy struct{}
+
+func (sha256IDStrategy) Name() string { return IDStrategySHA256 }
+func (sha256IDStrategy) Version() int { return 1 }
+
+func (s sha256IDStrategy) ID(k IDKey) string {
+	input := fmt.Sprintf("%s|%s|%.4f|%.4f|%s|%g", k.EventType, k.State, k.Lat, k.Lon, k.Time, k.Magnitude)
+	hash := sha256.Sum256([]byte(input))
+	return formatID(k.EventType, s.Version(), hex.EncodeToString(hash[:8]))
+}
+
+// sha256NoMagnitudeIDStrategy hashes type|state|lat|lon|time, so a later
+// correction of the magnitude upserts onto the same event instead of creating
+// a second one.
+type sha256NoMagnitudeIDStrategy struct{}
+
+func (sha256NoMagnitudeIDStrategy) Name() string { return IDStrategySHA256NoMagnitude }
+func (sha256NoMagnitudeIDStrategy) Version() int { return 2 }
+
+func (s sha256NoMagnitudeIDStrategy) ID(k IDKey) string {
+	input := fmt.Sprintf("%s|%s|%.4f|%.4f|%s", k.EventType, k.State, k.Lat, k.Lon, k.Time)
+	hash := sha256.Sum256([]byte(input))
+	return formatID(k.EventType, s.Version(), hex.EncodeToString(hash[:8]))
+}
+
+// idNamespace is the UUIDv5 namespace for storm event IDs.
+var idNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://github.com/couchcryptid/storm-data-etl/storm-event"))
+
+// uuidV5IDStrategy produces RFC 9562 name-based UUIDs over the same fields as
+// the sha256 strategy, for stores that require UUID keys.
+type uuidV5IDStrategy struct{}
+
+func (uuidV5IDStrategy) Name() string { return IDStrategyUUIDv5 }
+func (uuidV5IDStrategy) Version() int { return 3 }
+
+func (s uuidV5IDStrategy) ID(k IDKey) string {
+	input := fmt.Sprintf("%s|%s|%.4f|%.4f|%s|%g", k.EventType, k.State, k.Lat, k.Lon, k.Time, k.Magnitude)
+	return formatID(k.EventType, s.Version(), uuid.NewSHA1(idNamespace, []byte(input)).String())
+}
@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// countWord matches a casualty count written as digits or a small number word,
+// as NWS spotters commonly spell out ("two injuries", "1 fatality").
+const countWord = `(\d+|one|two|three|four|five|six|seven|eight|nine|ten|a|an)`
+
+// casualtyQualifiers are the words allowed between a count and the casualty
+// noun ("two people injured", "1 minor injury"). Keeping the list closed stops
+// phrases like "two trees dead" from being counted.
+const casualtyQualifiers = `(?:(?:people|persons?|minor|serious|critical|confirmed|additional|other|were|was)\s+){0,2}`
+
+var (
+	// injuriesRe matches "3 injuries", "two people injured", "1 minor injury".
+	injuriesRe = regexp.MustCompile(`(?i)\b` + countWord + `\s+` + casualtyQualifiers + `injur(?:y|ies|ed)\b`)
+
+	// fatalitiesRe matches "1 fatality", "two people killed", "3 deaths".
+	fatalitiesRe = regexp.MustCompile(`(?i)\b` + countWord + `\s+` + casualtyQualifiers + `(?:fatalit(?:y|ies)|deaths?|killed|dead)\b`)
+
+	// noInjuriesRe and noFatalitiesRe match explicit "no injuries" / "no fatalities" reports.
+	noInjuriesRe   = regexp.MustCompile(`(?i)\bno\s+(?:[a-z]+\s+)?injur(?:y|ies)\b`)
+	noFatalitiesRe = regexp.MustCompile(`(?i)\bno\s+(?:[a-z]+\s+)?(?:fatalit(?:y|ies)|deaths?)\b`)
+)
+
+var numberWords = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10,
+}
+
+// damageIndicators map a canonical damage keyword to the phrasings spotters
+// use for it. Keywords are reported in this order.
+var damageIndicators = []struct {
+	keyword string
+	re      *regexp.Regexp
+}{
+	{"roof damage", regexp.MustCompile(`(?i)\broofs?\b[^.;]{0,30}\b(?:damage|damaged|blown off|torn off|removed|peeled)|\b(?:damage|damaged|damaging)\s+(?:to\s+)?(?:the\s+)?roofs?\b|\bshingles\b`)},
+	{"trees down", regexp.MustCompile(`(?i)\btrees?\b[^.;]{0,20}\b(?:down|downed|uprooted|snapped|blown over)\b|\b(?:downed|uprooted|snapped)\s+trees?\b|\btree damage\b`)},
+	{"power lines down", regexp.MustCompile(`(?i)\b(?:power|utility|electric)\s*lines?\b[^.;]{0,20}\b(?:down|downed|blown over)\b|\b(?:downed|down)\s+(?:power|utility)\s*lines?\b|\b(?:power|utility)\s+poles?\b`)},
+	{"structure damage", regexp.MustCompile(`(?i)\b(?:home|house|barn|building|garage|shed|outbuilding|mobile home)s?\b[^.;]{0,30}\b(?:damage|damaged|destroyed)\b|\b(?:damaged|destroyed|fell on)\s+(?:(?:a|an|the|their|several|\d+)\s+)?(?:home|house|barn|building|garage|shed|outbuilding|mobile home)s?\b`)},
+	{"vehicle damage", regexp.MustCompile(`(?i)\b(?:car|vehicle|truck|windshield)s?\b[^.;]{0,30}\b(?:damage|damaged|broken|overturned)\b|\bbroken windshields?\b|\b(?:damaged|overturned|fell on)\s+(?:(?:a|an|the|their|several|\d+)\s+)?(?:car|vehicle|truck)s?\b`)},
+	{"broken windows", regexp.MustCompile(`(?i)\b(?:broken|shattered)\s+windows?\b|\bwindows?\b[^.;]{0,20}\b(?:broken|shattered|blown out)\b`)},
+}
+
+// extractImpact parses casualty counts and damage keywords from free-text
+// comments. Counts stay nil when not mentioned; an explicit "no injuries"
+// yields 0. Returns nil when the comments mention no impact at all.
+func extractImpact(comments string) *Impact {
+	comments = strings.TrimSpace(comments)
+	if comments == "" {
+		return nil
+	}
+
+	impact := Impact{
+		Injuries:   extractCount(comments, injuriesRe, noInjuriesRe),
+		Fatalities: extractCount(comments, fatalitiesRe, noFatalitiesRe),
+	}
+	for _, d := range damageIndicators {
+		if d.re.MatchString(comments) {
+			impact.Damage = append(impact.Damage, d.keyword)
+		}
+	}
+
+	if impact.Injuries == nil && impact.Fatalities == nil && len(impact.Damage) == 0 {
+		return nil
+	}
+	return &impact
+}
+
+// extractCount sums every count matched by re, so "1 injury ... 2 injuries"
+// gives 3. Without a count, a match of noneRe yields 0 and otherwise nil.
+func extractCount(comments string, re, noneRe *regexp.Regexp) *int {
+	matches := re.FindAllStringSubmatch(comments, -1)
+	if len(matches) == 0 {
+		if noneRe.MatchString(comments) {
+			zero := 0
+			return &zero
+		}
+		return nil
+	}
+
+	total := 0
+	for _, m := range matches {
+		word := strings.ToLower(m[1])
+		if n, ok := numberWords[word]; ok {
+			total += n
+			continue
+		}
+		n, err := strconv.Atoi(word)
+		if err != nil {
+			continue
+		}
+		total += n
+	}
+	return &total
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// MergeTolerance bounds how far apart two reports of the same type may be,
+// in statute miles and in event time, to count as one storm reported twice.
+type MergeTolerance struct {
+	Miles  float64
+	Window time.Duration
+}
+
+// IsNearDuplicate reports whether a and b describe the same storm: distinct
+// reports of the same type within the tolerance. Adjacent WFOs often both
+// report a storm near their shared border, so reports from the same known
+// office are never duplicates of each other. Retractions never match.
+func (t MergeTolerance) IsNearDuplicate(a, b StormEvent) bool {
+	if a.ID == b.ID || a.Deleted || b.Deleted || a.EventType != b.EventType {
+		return false
+	}
+	if a.SourceOffice != "" && a.SourceOffice == b.SourceOffice {
+		return false
+	}
+	if d := a.EventTime.Sub(b.EventTime); d > t.Window || d < -t.Window {
+		return false
+	}
+	return distanceMiles(a.Geo, b.Geo) <= t.Miles
+}
+
+// MergeDuplicates keeps the higher-quality of two near-duplicate reports and
+// records the other, and anything it had absorbed, in the kept report's
+// MergedFrom. On a tie the first report is kept, so the one loaded first
+// stays in place.
+func MergeDuplicates(first, second StormEvent) (kept, dropped StormEvent) {
+	kept, dropped = first, second
+	if slices.Compare(recordQuality(second), recordQuality(first)) > 0 {
+		kept, dropped = second, first
+	}
+	merged := slices.Clone(kept.MergedFrom)
+	for _, id := range append([]string{dropped.ID}, dropped.MergedFrom...) {
+		if !slices.Contains(merged, id) {
+			merged = append(merged, id)
+		}
+	}
+	kept.MergedFrom = merged
+	return kept, dropped
+}
+
+// recordQuality ranks a report for MergeDuplicates; higher compares better.
+// In order: fewer inferred values and quality flags, a measured rather than
+// estimated magnitude, a known magnitude, a known issuing office, and longer
+// comments.
+func recordQuality(e StormEvent) []int {
+	var doubts int
+	if e.Quality != nil {
+		doubts = len(e.Quality.Inferred) + len(e.Quality.Flags)
+	}
+	var method int
+	switch e.Measurement.Method {
+	case MethodMeasured:
+		method = 2
+	case MethodEstimated:
+		method = 1
+	}
+	return []int{-doubts, method, boolRank(e.Measurement.Magnitude > 0), boolRank(e.SourceOfficeDetail != nil), len(e.Comments)}
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Measurement methods: whether the magnitude was measured by an instrument or
+// estimated by an observer. Forecasters weigh the two very differently.
+const (
+	MethodMeasured  = "measured"
+	MethodEstimated = "estimated"
+)
+
+var (
+	// measuredRe matches comments reporting an instrument reading, e.g.
+	// "MEASURED 72 MPH" or "gust recorded by the ASOS".
+	measuredRe = regexp.MustCompile(`(?i)\b(?:measured|asos|awos|mesonet|anemometer)\b`)
+
+	// estimatedRe matches comments reporting an observer's estimate, e.g.
+	// "estimated 60 mph gust" or "est. 1 inch hail".
+	estimatedRe = regexp.MustCompile(`(?i)\b(?:estimated|est\.)`)
+
+	// timeEstimateRe matches estimates of the report time rather than the
+	// magnitude ("Time estimated from radar"), which are removed before
+	// estimatedRe is applied.
+	timeEstimateRe = regexp.MustCompile(`(?i)\btimes?\s+(?:was\s+|were\s+|is\s+)?estimated\b|\bestimated\s+(?:from\s+radar|times?)\b`)
+)
+
+// parseMethodColumn maps a collector magnitude qualifier to a method. NWS
+// Local Storm Reports qualify magnitudes as M (measured) or E (estimated);
+// anything else, including U (unknown), yields "".
+func parseMethodColumn(v string) string {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "m", MethodMeasured:
+		return MethodMeasured
+	case "e", MethodEstimated:
+		return MethodEstimated
+	}
+	return ""
+}
+
+// inferMethod infers the measurement method from the comments. An instrument
+// reading wins over an estimate, since comments often estimate one quantity
+// and measure another ("estimated 60 mph, ASOS measured 72 mph"). Returns ""
+// when the comments mention neither.
+func inferMethod(comments string) string {
+	if measuredRe.MatchString(comments) {
+		return MethodMeasured
+	}
+	if estimatedRe.MatchString(timeEstimateRe.ReplaceAllString(comments, "")) {
+		return MethodEstimated
+	}
+	return ""
+}
@@ -0,0 +1,22 @@
+package domain
+
+// DefaultNearbyPlacesRadius is the radius, in miles, within which embedded
+// places are counted unless Enrichment.NearbyPlacesRadius overrides it.
+const DefaultNearbyPlacesRadius = 10.0
+
+// nearbyPlaces counts the embedded places whose centers lie within radius
+// miles of g and sums their populations. It returns nil for missing (0, 0)
+// coordinates.
+func nearbyPlaces(g Geo, radius float64) *NearbyPlaces {
+	if g == (Geo{}) {
+		return nil
+	}
+	nearby := &NearbyPlaces{Radius: radius}
+	for i := range places {
+		if distanceMiles(places[i].Geo, g) <= radius {
+			nearby.Population += places[i].Population
+			nearby.Places++
+		}
+	}
+	return nearby
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"slices"
+	"unicode/utf8"
+)
+
+// Oversize policies decide what happens to an event whose sink message would
+// exceed the size limit. Truncate shortens the comments; drop_raw clears the
+// verbatim source text, the comments and the raw location; dead_letter
+// dead-letters the source message unchanged. The first two dead-letter the
+// message too when shrinking the event is not enough.
+const (
+	OversizeTruncate   = "truncate"
+	OversizeDropRaw    = "drop_raw"
+	OversizeDeadLetter = "dead_letter"
+)
+
+// OversizePolicies lists the valid oversize policies.
+var OversizePolicies = []string{OversizeTruncate, OversizeDropRaw, OversizeDeadLetter}
+
+// Quality flags marking an event shortened to fit the sink message size.
+const (
+	FlagCommentsTruncated = "comments_truncated"
+	FlagRawTextDropped    = "raw_text_dropped"
+)
+
+// truncationMarker ends truncated comments.
+const truncationMarker = "…"
+
+// TruncateComments shortens the event's comments by at least n bytes,
+// cutting at a character boundary and ending them with an ellipsis, and flags
+// the event. Comments too short to lose n bytes are cleared.
+func TruncateComments(e StormEvent, n int) StormEvent {
+	if e.Comments == "" || n <= 0 {
+		return e
+	}
+	keep := len(e.Comments) - n - len(truncationMarker)
+	for keep > 0 && !utf8.RuneStart(e.Comments[keep]) {
+		keep--
+	}
+	if keep > 0 {
+		e.Comments = e.Comments[:keep] + truncationMarker
+	} else {
+		e.Comments = ""
+	}
+	return flagCopy(e, FlagCommentsTruncated)
+}
+
+// DropRawText clears the free text copied verbatim from the source record,
+// the comments and the raw location, and flags the event. The parsed
+// location fields are kept.
+func DropRawText(e StormEvent) StormEvent {
+	if e.Comments == "" && e.Location.Raw == "" {
+		return e
+	}
+	e.Comments = ""
+	e.Location.Raw = ""
+	return flagCopy(e, FlagRawTextDropped)
+}
+
+// flagCopy adds flag to a copy of the event's Quality, so the event it was
+// copied from is left as it was.
+func flagCopy(e StormEvent, flag string) StormEvent {
+	if e.Quality != nil {
+		q := *e.Quality
+		q.Flags = slices.Clone(q.Flags)
+		e.Quality = &q
+	}
+	e.AddQualityFlag(flag)
+	return e
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// placesCSV lists populated places: the larger cities of each state and
+// territory, plus smaller towns where a WFO or sparse coverage would
+// otherwise leave the nearest city hundreds of miles away. Population is the
+// 2020 census count.
+//
+//go:embed places.csv
+var placesCSV string
+
+// place is a row of places.csv.
+type place struct {
+	Name       string
+	State      string
+	Geo        Geo
+	Population int
+}
+
+// places holds the embedded table in file order.
+var places = mustParsePlaces(placesCSV)
+
+// nearestCity returns the embedded place closest to g, with the distance in
+// miles, rounded to a tenth, and the direction from the place to g. It
+// returns nil for missing (0, 0) coordinates.
+func nearestCity(g Geo) *NearestCity {
+	if g == (Geo{}) {
+		return nil
+	}
+	best, bestMiles := -1, 0.0
+	for i := range places {
+		if d := distanceMiles(places[i].Geo, g); best < 0 || d < bestMiles {
+			best, bestMiles = i, d
+		}
+	}
+	p := places[best]
+	return &NearestCity{
+		Name:      p.Name,
+		State:     p.State,
+		Distance:  roundTo(bestMiles, 1),
+		Direction: compassDirection(bearingDegrees(p.Geo, g)),
+		Geo:       p.Geo,
+	}
+}
+
+// mustParsePlaces parses the embedded table. It panics on malformed rows
+// since the file ships with the binary and is covered by tests.
+func mustParsePlaces(data string) []place {
+	rows, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("parse places.csv: %v", err))
+	}
+	parsed := make([]place, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		lat, errLat := strconv.ParseFloat(row[2], 64)
+		lon, errLon := strconv.ParseFloat(row[3], 64)
+		population, errPop := strconv.Atoi(row[4])
+		if errLat != nil || errLon != nil || errPop != nil {
+			panic(fmt.Sprintf("parse places.csv row %d: invalid number", i+2))
+		}
+		parsed = append(parsed, place{Name: row[0], State: row[1], Geo: Geo{Lat: lat, Lon: lon}, Population: population})
+	}
+	return parsed
+}
@@ -0,0 +1,259 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FieldNaming is the naming convention of the JSON fields a loader writes.
+type FieldNaming string
+
+// Supported field naming conventions. Snake case matches the struct tags.
+const (
+	NamingSnake FieldNaming = "snake"
+	NamingCamel FieldNaming = "camel"
+)
+
+// FieldProjection shapes the StormEvent JSON a loader serializes: which
+// fields it keeps, so a privacy-constrained sink can drop the free-text
+// comments or a mobile feed can receive a slim payload, and how they are
+// named, so JavaScript consumers can read camelCase. Fields are selected by
+// their snake_case JSON path, e.g. "comments" or "location.raw", whatever the
+// naming. A nil projection keeps the event as it is.
+type FieldProjection struct {
+	include [][]string
+	exclude [][]string
+	naming  FieldNaming
+	flatten bool
+}
+
+// ParseFieldProjection parses a comma-separated list of JSON field paths.
+// Plain paths are kept and every other field dropped; paths prefixed with "-"
+// are dropped. Both may be combined, e.g. "id,event_type,location,-location.raw"
+// keeps the location without its raw NWS text. An empty string yields nil.
+func ParseFieldProjection(s string) (*FieldProjection, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	p := &FieldProjection{naming: NamingSnake}
+	for entry := range strings.SplitSeq(s, ",") {
+		entry = strings.TrimSpace(entry)
+		exclude := strings.HasPrefix(entry, "-")
+		name := strings.TrimPrefix(entry, "-")
+		if name == "" {
+			return nil, errors.New("empty field name")
+		}
+		path := strings.Split(name, ".")
+		if !isEventField(path) {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		if exclude {
+			p.exclude = append(p.exclude, path)
+		} else {
+			p.include = append(p.include, path)
+		}
+	}
+	return p, nil
+}
+
+// WithStyle returns p with the given field naming and, when flatten is set,
+// nested objects flattened into their parent's fields, e.g. "location_state"
+// or "locationState". It returns nil when p is nil and the style is the
+// default snake case without flattening.
+func (p *FieldProjection) WithStyle(naming FieldNaming, flatten bool) *FieldProjection {
+	if p == nil {
+		if naming == NamingSnake && !flatten {
+			return nil
+		}
+		p = &FieldProjection{}
+	}
+	p.naming, p.flatten = naming, flatten
+	return p
+}
+
+// isEventField reports whether path names a field of the StormEvent JSON
+// encoding, descending through nested structs.
+func isEventField(path []string) bool {
+	t := reflect.TypeFor[StormEvent]()
+	for _, name := range path {
+		t = indirectType(t)
+		if t.Kind() != reflect.Struct {
+			return false
+		}
+		field, ok := jsonField(t, name)
+		if !ok {
+			return false
+		}
+		t = field.Type
+	}
+	return true
+}
+
+// indirectType returns the element type of pointer and slice types.
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t
+}
+
+// jsonField returns the field of struct type t encoded under name.
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if f.IsExported() && tag == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// Apply returns the event's JSON representation with the projection applied,
+// ready to be encoded by a loader. Objects left empty by an exclusion are
+// removed, as omitempty would. Only the event's own fields are renamed or
+// flattened; the keys of map fields such as provenance headers are data and
+// kept as they are. A nil projection returns e unchanged.
+func (p *FieldProjection) Apply(e StormEvent) (any, error) {
+	if p == nil {
+		return e, nil
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var fields map[string]any
+	if err := dec.Decode(&fields); err != nil {
+		return nil, err
+	}
+	if len(p.include) > 0 {
+		kept := map[string]any{}
+		for _, path := range p.include {
+			copyField(kept, fields, path)
+		}
+		fields = kept
+	}
+	for _, path := range p.exclude {
+		deleteField(fields, path)
+	}
+	if p.naming == NamingSnake && !p.flatten {
+		return fields, nil
+	}
+	shaped := map[string]any{}
+	p.reshape(shaped, fields, reflect.TypeFor[StormEvent](), "")
+	return shaped, nil
+}
+
+// reshape copies the fields of m, the JSON encoding of struct type t, into
+// dst, renaming them and prefixing them with prefix. Nested structs are
+// flattened into dst or reshaped in place.
+func (p *FieldProjection) reshape(dst, m map[string]any, t reflect.Type, prefix string) {
+	t = indirectType(t)
+	for name, v := range m {
+		key := p.fieldName(prefix, name)
+		f, ok := jsonField(t, name)
+		if !ok {
+			dst[key] = v
+			continue
+		}
+		ft := indirectType(f.Type)
+		switch v := v.(type) {
+		case map[string]any:
+			if ft.Kind() != reflect.Struct {
+				dst[key] = v
+			} else if p.flatten {
+				p.reshape(dst, v, ft, key)
+			} else {
+				nested := map[string]any{}
+				p.reshape(nested, v, ft, "")
+				dst[key] = nested
+			}
+		case []any:
+			if ft.Kind() == reflect.Struct {
+				for i, elem := range v {
+					if obj, ok := elem.(map[string]any); ok {
+						nested := map[string]any{}
+						p.reshape(nested, obj, ft, "")
+						v[i] = nested
+					}
+				}
+			}
+			dst[key] = v
+		default:
+			dst[key] = v
+		}
+	}
+}
+
+// fieldName returns the output name of a snake_case field nested under the
+// output name prefix.
+func (p *FieldProjection) fieldName(prefix, name string) string {
+	if p.naming != NamingCamel {
+		if prefix == "" {
+			return name
+		}
+		return prefix + "_" + name
+	}
+	var b strings.Builder
+	b.WriteString(prefix)
+	for i, word := range strings.Split(name, "_") {
+		if word == "" {
+			continue
+		}
+		if i == 0 && prefix == "" {
+			b.WriteString(word)
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// copyField copies the value at path from src to dst, creating the enclosing
+// objects in dst. Paths missing from src are skipped.
+func copyField(dst, src map[string]any, path []string) {
+	v, ok := src[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		dst[path[0]] = v
+		return
+	}
+	child, ok := v.(map[string]any)
+	if !ok {
+		return
+	}
+	next, ok := dst[path[0]].(map[string]any)
+	if !ok {
+		next = map[string]any{}
+		dst[path[0]] = next
+	}
+	copyField(next, child, path[1:])
+	if len(next) == 0 {
+		delete(dst, path[0])
+	}
+}
+
+// deleteField removes the value at path, and the enclosing objects it leaves
+// empty.
+func deleteField(m map[string]any, path []string) {
+	if len(path) == 1 {
+		delete(m, path[0])
+		return
+	}
+	child, ok := m[path[0]].(map[string]any)
+	if !ok {
+		return
+	}
+	deleteField(child, path[1:])
+	if len(child) == 0 {
+		delete(m, path[0])
+	}
+}
@@ -0,0 +1,201 @@
+package domain
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+)
+
+// decodeRawCSVRecord decodes a collector record. The collector writes a flat
+// object of string fields, which is scanned by hand: the value is copied to a
+// string once and the fields are slices of it, so a typical record costs one
+// allocation instead of the reflection and per-field copies of json.Unmarshal.
+// Anything the scanner does not expect, such as an unknown or differently
+// cased key, a non-string value, or malformed JSON, falls back to
+// json.Unmarshal, so the result and errors match it exactly.
+func decodeRawCSVRecord(data []byte) (RawCSVRecord, error) {
+	var rec RawCSVRecord
+	if scanRawCSVRecord(string(data), &rec) {
+		return rec, nil
+	}
+	return unmarshalRawCSVRecord(data)
+}
+
+// unmarshalRawCSVRecord decodes a record with json.Unmarshal. It is kept
+// apart so the scanner's record does not escape to the heap.
+func unmarshalRawCSVRecord(data []byte) (RawCSVRecord, error) {
+	var rec RawCSVRecord
+	err := json.Unmarshal(data, &rec)
+	return rec, err
+}
+
+// scanRawCSVRecord decodes the JSON object s into rec, reporting false when s
+// is not a flat object of known keys that the scanner can decode.
+func scanRawCSVRecord(s string, rec *RawCSVRecord) bool {
+	i := skipSpace(s, 0)
+	if i >= len(s) || s[i] != '{' {
+		return false
+	}
+	i = skipSpace(s, i+1)
+	if i < len(s) && s[i] == '}' {
+		return skipSpace(s, i+1) == len(s)
+	}
+	for {
+		key, end, plain := scanString(s, i)
+		if end < 0 || !plain {
+			return false
+		}
+		i = skipSpace(s, end)
+		if i >= len(s) || s[i] != ':' {
+			return false
+		}
+		i = skipSpace(s, i+1)
+		if i = scanField(s, i, rec, key); i < 0 {
+			return false
+		}
+		i = skipSpace(s, i)
+		if i >= len(s) {
+			return false
+		}
+		switch s[i] {
+		case ',':
+			i = skipSpace(s, i+1)
+		case '}':
+			return skipSpace(s, i+1) == len(s)
+		default:
+			return false
+		}
+	}
+}
+
+// scanField decodes the value at s[i:] into the field of rec named key and
+// returns the offset after it, or -1 when the key or value is unexpected.
+func scanField(s string, i int, rec *RawCSVRecord, key string) int {
+	if key == "Deleted" {
+		return scanBool(s, i, &rec.Deleted)
+	}
+	field := rec.stringField(key)
+	if field == nil {
+		return -1
+	}
+	if hasLiteral(s, i, "null") {
+		// As with json.Unmarshal, null leaves the field unchanged.
+		return i + len("null")
+	}
+	v, end, plain := scanString(s, i)
+	if end < 0 {
+		return -1
+	}
+	if !plain {
+		// Escapes and invalid UTF-8 are rare; let encoding/json unquote them.
+		if json.Unmarshal([]byte(s[i:end]), field) != nil {
+			return -1
+		}
+		return end
+	}
+	*field = v
+	return end
+}
+
+// stringField returns the string field of r encoded under the JSON key, or
+// nil when there is none.
+func (r *RawCSVRecord) stringField(key string) *string {
+	switch key {
+	case "Time":
+		return &r.Time
+	case "Size":
+		return &r.Size
+	case "F_Scale":
+		return &r.FScale
+	case "Speed":
+		return &r.Speed
+	case "Location":
+		return &r.Location
+	case "County":
+		return &r.County
+	case "State":
+		return &r.State
+	case "Lat":
+		return &r.Lat
+	case "Lon":
+		return &r.Lon
+	case "Comments":
+		return &r.Comments
+	case "EventType":
+		return &r.EventType
+	case "Magnitude":
+		return &r.Magnitude
+	case "MagnitudeMethod":
+		return &r.MagnitudeMethod
+	case "BeginLat":
+		return &r.BeginLat
+	case "BeginLon":
+		return &r.BeginLon
+	case "EndLat":
+		return &r.EndLat
+	case "EndLon":
+		return &r.EndLon
+	}
+	return nil
+}
+
+// scanBool decodes a true, false, or null literal at s[i:] into b and
+// returns the offset after it, or -1 for any other value.
+func scanBool(s string, i int, b *bool) int {
+	switch {
+	case hasLiteral(s, i, "true"):
+		*b = true
+		return i + len("true")
+	case hasLiteral(s, i, "false"):
+		*b = false
+		return i + len("false")
+	case hasLiteral(s, i, "null"):
+		return i + len("null")
+	}
+	return -1
+}
+
+// hasLiteral reports whether s holds lit at offset i. The caller's check for
+// a following delimiter rejects longer words such as "nullx".
+func hasLiteral(s string, i int, lit string) bool {
+	return len(s)-i >= len(lit) && s[i:i+len(lit)] == lit
+}
+
+// scanString scans the JSON string starting at s[i] and returns its contents
+// and the offset after the closing quote, or an end of -1 when there is no
+// well-formed string. plain reports whether the contents are the decoded
+// value as is, without escapes or invalid UTF-8.
+func scanString(s string, i int) (v string, end int, plain bool) {
+	if i >= len(s) || s[i] != '"' {
+		return "", -1, false
+	}
+	escaped, ascii := false, true
+	for j := i + 1; j < len(s); j++ {
+		switch c := s[j]; {
+		case c == '"':
+			v = s[i+1 : j]
+			return v, j + 1, !escaped && (ascii || utf8.ValidString(v))
+		case c == '\\':
+			escaped = true
+			j++
+		case c < 0x20:
+			return "", -1, false
+		case c >= utf8.RuneSelf:
+			ascii = false
+		}
+	}
+	return "", -1, false
+}
+
+// skipSpace returns the offset of the first non-whitespace byte of s at or
+// after i.
+func skipSpace(s string, i int) int {
+	for i < len(s) {
+		switch s[i] {
+		case ' ', '\t', '\n', '\r':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// RedactedText replaces each redacted match in a comment.
+const RedactedText = "[REDACTED]"
+
+// RedactionPattern is a named regular expression whose matches are removed
+// from comments. The name labels the redaction metric.
+type RedactionPattern struct {
+	Name string
+	Re   *regexp.Regexp
+}
+
+// builtinRedactionPatterns catch the contact details spotters most often
+// leave in comments: e-mail addresses and ten-digit North American phone
+// numbers such as "(405) 555-0142" or "405.555.0142".
+var builtinRedactionPatterns = []RedactionPattern{
+	{Name: "email", Re: regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}\b`)},
+	{Name: "phone", Re: regexp.MustCompile(`(?:\+?1[\s.-]?)?\(?\b[2-9][0-9]{2}\)?[\s.-]?[0-9]{3}[\s.-]?[0-9]{4}\b`)},
+}
+
+// redactionNameRe restricts pattern names to metric label friendly words.
+var redactionNameRe = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// ParseRedactionPatterns parses semicolon-separated name=regex entries, e.g.
+// `spotter=(?i)spotter [A-Z][a-z]+ [A-Z][a-z]+`. Names must be lowercase words
+// and not repeat a built-in pattern. An empty string yields none.
+func ParseRedactionPatterns(s string) ([]RedactionPattern, error) {
+	var patterns []RedactionPattern
+	for entry := range strings.SplitSeq(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, expr, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || expr == "" {
+			return nil, fmt.Errorf("entry %q: want name=regex", entry)
+		}
+		if !redactionNameRe.MatchString(name) {
+			return nil, fmt.Errorf("pattern name %q: must be a lowercase word", name)
+		}
+		taken := func(p RedactionPattern) bool { return p.Name == name }
+		if slices.ContainsFunc(builtinRedactionPatterns, taken) || slices.ContainsFunc(patterns, taken) {
+			return nil, fmt.Errorf("pattern name %q listed twice or built in", name)
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %s: %w", name, err)
+		}
+		if re.MatchString("") {
+			return nil, fmt.Errorf("pattern %s matches the empty string", name)
+		}
+		patterns = append(patterns, RedactionPattern{Name: name, Re: re})
+	}
+	return patterns, nil
+}
+
+// Redactor removes personal details, such as spotter names and phone
+// numbers, from report comments. It is safe for concurrent use.
+type Redactor struct {
+	patterns []RedactionPattern
+	allow    map[string]bool
+}
+
+// NewRedactor returns a Redactor applying the built-in email and phone
+// patterns and then extra, in order. A match equal to an allowlist term,
+// ignoring case, is kept, so a broad name pattern can spare "Emergency
+// Manager" or "Storm Chaser".
+func NewRedactor(extra []RedactionPattern, allowlist []string) *Redactor {
+	r := &Redactor{
+		patterns: slices.Concat(builtinRedactionPatterns, extra),
+		allow:    make(map[string]bool, len(allowlist)),
+	}
+	for _, term := range allowlist {
+		r.allow[strings.ToLower(strings.TrimSpace(term))] = true
+	}
+	return r
+}
+
+// Redact replaces every match in text with RedactedText and returns the
+// number of replacements per pattern name, or nil when nothing matched.
+func (r *Redactor) Redact(text string) (string, map[string]int) {
+	var counts map[string]int
+	for _, p := range r.patterns {
+		text = p.Re.ReplaceAllStringFunc(text, func(match string) string {
+			if r.allow[strings.ToLower(match)] {
+				return match
+			}
+			if counts == nil {
+				counts = map[string]int{}
+			}
+			counts[p.Name]++
+			return RedactedText
+		})
+	}
+	return text, counts
+}
@@ -0,0 +1,14 @@
+package domain
+
+// DayConvention decides which calendar date a bare HHMM report time falls on
+// relative to the report date it is anchored to.
+type DayConvention string
+
+// Supported day conventions.
+const (
+	// DaySPC follows the SPC convention: a daily report covers 12:00 UTC to
+	// 11:59 UTC the next day, so times before 1200 fall on the following date.
+	DaySPC DayConvention = "spc"
+	// DayCalendar puts every time on the report date.
+	DayCalendar DayConvention = "calendar"
+)
@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Validation rule actions. Pass only counts the violation; annotate also
+// lists the check in the event's Quality.Flags; quarantine dead-letters the
+// source message; drop commits it without loading, like the event filter.
+const (
+	RuleActionPass       = "pass"
+	RuleActionAnnotate   = "annotate"
+	RuleActionQuarantine = "quarantine"
+	RuleActionDrop       = "drop"
+)
+
+// RuleActions lists the valid validation rule actions.
+var RuleActions = []string{RuleActionPass, RuleActionAnnotate, RuleActionQuarantine, RuleActionDrop}
+
+// maxClockSkew is how far past the current time an event may be dated before
+// the future_time check fails, allowing for clock skew between the collector
+// and this service.
+const maxClockSkew = time.Hour
+
+// validationChecks are the named checks a ValidationRule can apply to an
+// enriched event. Each reports whether the event violates it.
+var validationChecks = map[string]func(StormEvent) bool{
+	// magnitude_unparsed: the magnitude column held a value other than UNK
+	// that could not be parsed and was read as 0.
+	"magnitude_unparsed": func(e StormEvent) bool {
+		rec, ok := rawRecord(e)
+		if !ok {
+			return false
+		}
+		spec, ok := lookupEventType(rec.EventType)
+		if !ok {
+			return false
+		}
+		raw := strings.TrimSpace(spec.Magnitude(rec))
+		return raw != "" && !strings.EqualFold(raw, "UNK") && spec.parseMagnitude(raw) == 0
+	},
+	// missing_magnitude: a type with severity thresholds has no magnitude.
+	"missing_magnitude": func(e StormEvent) bool {
+		spec, ok := lookupEventType(e.EventType)
+		return ok && spec.Thresholds != (SeverityThresholds{}) && e.Measurement.Magnitude == 0
+	},
+	// missing_time: the record had no Time, so the event time is the source
+	// message timestamp.
+	"missing_time": func(e StormEvent) bool {
+		rec, ok := rawRecord(e)
+		return ok && strings.TrimSpace(rec.Time) == ""
+	},
+	// future_time: the event time is more than maxClockSkew ahead of now.
+	"future_time": func(e StormEvent) bool {
+		return e.EventTime.After(clock.Now().Add(maxClockSkew))
+	},
+	// unknown_office: the comments name an office that is not a known WFO.
+	"unknown_office": func(e StormEvent) bool {
+		return e.SourceOffice != "" && e.SourceOfficeDetail == nil
+	},
+	// missing_location: the record has no NWS relative location.
+	"missing_location": func(e StormEvent) bool {
+		return strings.TrimSpace(e.Location.Raw) == ""
+	},
+	// outside_us: the coordinates lie outside every US region and could not
+	// be corrected.
+	FlagOutsideUS: func(e StormEvent) bool {
+		return e.Geo != (Geo{}) && !inUS(e.Geo)
+	},
+	// missing_state: the event has no state code, including an unknown value
+	// cleared during normalization.
+	"missing_state": func(e StormEvent) bool {
+		return strings.TrimSpace(e.Location.State) == ""
+	},
+	// unknown_state: the state column held a value that is not a US state or
+	// territory.
+	FlagUnknownState: func(e StormEvent) bool {
+		return e.Quality != nil && slices.Contains(e.Quality.Flags, FlagUnknownState)
+	},
+	// unknown_county: the county is not one of the state's counties.
+	FlagUnknownCounty: func(e StormEvent) bool {
+		return e.Quality != nil && slices.Contains(e.Quality.Flags, FlagUnknownCounty)
+	},
+}
+
+// rawRecord decodes the collector record an event was parsed from, for checks
+// that need a value enrichment replaced.
+func rawRecord(e StormEvent) (RawCSVRecord, bool) {
+	if len(e.RawPayload) == 0 {
+		return RawCSVRecord{}, false
+	}
+	rec, err := decodeRawCSVRecord(e.RawPayload)
+	if err != nil {
+		return RawCSVRecord{}, false
+	}
+	return rec, true
+}
+
+// ValidationChecks returns the names of the checks available to
+// ValidationRules, sorted.
+func ValidationChecks() []string {
+	return slices.Sorted(maps.Keys(validationChecks))
+}
+
+// ValidationRule applies Action to every event that fails the named Check.
+type ValidationRule struct {
+	Check  string
+	Action string
+}
+
+// ParseValidationRules parses rules of the form
+// "missing_magnitude=annotate;future_time=quarantine". Rules keep their
+// order, which decides the action when several quarantine or drop rules
+// fail. An empty string yields no rules.
+func ParseValidationRules(s string) ([]ValidationRule, error) {
+	var rules []ValidationRule
+	for entry := range strings.SplitSeq(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		check, action, ok := strings.Cut(entry, "=")
+		check = strings.ToLower(strings.TrimSpace(check))
+		action = strings.ToLower(strings.TrimSpace(action))
+		if !ok {
+			return nil, fmt.Errorf("validation rule %q: expected check=action", entry)
+		}
+		if _, known := validationChecks[check]; !known {
+			return nil, fmt.Errorf("validation rule %q: unknown check, must be one of %s", entry, strings.Join(ValidationChecks(), ", "))
+		}
+		if !slices.Contains(RuleActions, action) {
+			return nil, fmt.Errorf("validation rule %q: unknown action, must be one of %s", entry, strings.Join(RuleActions, ", "))
+		}
+		if slices.ContainsFunc(rules, func(r ValidationRule) bool { return r.Check == check }) {
+			return nil, fmt.Errorf("validation rule %q: check listed twice", entry)
+		}
+		rules = append(rules, ValidationRule{Check: check, Action: action})
+	}
+	return rules, nil
+}
+
+// Violations returns the rules whose checks the event fails, in rule order.
+func Violations(rules []ValidationRule, event StormEvent) []ValidationRule {
+	var failed []ValidationRule
+	for _, r := range rules {
+		if check, ok := validationChecks[r.Check]; ok && check(event) {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+)
+
+// SeverityThresholds are the magnitudes at which an event type moves up a
+// severity level: below Moderate is minor, below Severe is moderate, below
+// Extreme is severe, and anything at or above Extreme is extreme.
+type SeverityThresholds struct {
+	Moderate float64
+	Severe   float64
+	Extreme  float64
+}
+
+// defaultSeverityThresholds are the thresholds registered in builtinEventTypes,
+// informed by NWS Severe Weather Criteria and the Enhanced Fujita Scale.
+var defaultSeverityThresholds = defaultThresholds()
+
+// ParseSeverityThresholds parses overrides of the form
+// "hail=0.75,1.5,2.5;wind=50,74,96" into a full threshold set. Any registered
+// event type may be given, including types without default thresholds. Event
+// types not mentioned keep their defaults. An empty string yields the defaults.
+func ParseSeverityThresholds(s string) (map[string]SeverityThresholds, error) {
+	result := maps.Clone(defaultSeverityThresholds)
+	for entry := range strings.SplitSeq(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		eventType, values, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("severity thresholds %q: expected type=moderate,severe,extreme", entry)
+		}
+		spec, known := lookupEventType(strings.TrimSpace(eventType))
+		if !known {
+			return nil, fmt.Errorf("severity thresholds: unknown event type %q", eventType)
+		}
+		eventType = spec.Name
+		parts := strings.Split(values, ",")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("severity thresholds for %s: expected 3 values, got %d", eventType, len(parts))
+		}
+		var bounds [3]float64
+		for i, part := range parts {
+			v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return nil, fmt.Errorf("severity thresholds for %s: %w", eventType, err)
+			}
+			bounds[i] = v
+		}
+		if bounds[0] <= 0 || bounds[0] >= bounds[1] || bounds[1] >= bounds[2] {
+			return nil, fmt.Errorf("severity thresholds for %s: values must be positive and strictly increasing", eventType)
+		}
+		result[eventType] = SeverityThresholds{Moderate: bounds[0], Severe: bounds[1], Extreme: bounds[2]}
+	}
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"regexp"
+	"slices"
+)
+
+// severityRule raises an event's severity to at least Level when its
+// comments describe an impact the magnitude alone does not capture, such as a
+// fatality from a tornado rated EF1.
+type severityRule struct {
+	// Name identifies the rule in the audit log, e.g. "overturned vehicles".
+	Name  string
+	Level string
+	Match func(StormEvent) bool
+}
+
+var (
+	// destroyedRe matches destruction of a structure or vehicle, e.g.
+	// "home destroyed" or "destroyed two barns".
+	destroyedRe = regexp.MustCompile(`(?i)\b(?:destroyed|leveled|flattened|swept away)\b`)
+
+	// overturnedRe matches overturned vehicles, e.g. "overturned a semi" or
+	// "two cars were overturned".
+	overturnedRe = regexp.MustCompile(`(?i)\b(?:overturned|flipped|rolled over)\s+(?:(?:a|an|the|several|two|three|\d+)\s+)?(?:car|vehicle|truck|semi|trailer|rv)s?\b|\b(?:car|vehicle|truck|semi|trailer|rv)s?\s+(?:(?:was|were)\s+)?(?:overturned|flipped|rolled over)\b`)
+)
+
+// builtinSeverityRules are the keyword rules applied when
+// SEVERITY_KEYWORD_RULES is enabled, from most to least severe. Casualties
+// come from the extracted Impact, so "no fatalities" does not match.
+var builtinSeverityRules = []severityRule{
+	{Name: "fatality", Level: "extreme", Match: func(e StormEvent) bool {
+		return e.Impact != nil && e.Impact.Fatalities != nil && *e.Impact.Fatalities > 0
+	}},
+	{Name: "destroyed", Level: "severe", Match: func(e StormEvent) bool { return destroyedRe.MatchString(e.Comments) }},
+	{Name: "overturned vehicles", Level: "severe", Match: func(e StormEvent) bool { return overturnedRe.MatchString(e.Comments) }},
+	{Name: "injury", Level: "moderate", Match: func(e StormEvent) bool {
+		return e.Impact != nil && e.Impact.Injuries != nil && *e.Impact.Injuries > 0
+	}},
+}
+
+// applySeverityRules returns the level of the first matching keyword rule
+// that is above the current severity, and the rule's name. It returns ""
+// when e disables the rules, the event type is never assigned a severity, or
+// no rule raises it. A rule may assign a severity to an event without a
+// magnitude, such as a tornado rated UNK that destroyed homes.
+func (e Enrichment) applySeverityRules(event StormEvent) (level, rule string) {
+	if !e.SeverityKeywordRules {
+		return "", ""
+	}
+	if _, ok := e.severityThresholds()[event.EventType]; !ok {
+		return "", ""
+	}
+	current := -1
+	if event.Measurement.Severity != nil {
+		current = slices.Index(SeverityLevels, *event.Measurement.Severity)
+	}
+	for _, r := range builtinSeverityRules {
+		if slices.Index(SeverityLevels, r.Level) > current && r.Match(event) {
+			return r.Level, r.Name
+		}
+	}
+	return "", ""
+}
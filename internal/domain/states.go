@@ -0,0 +1,49 @@
+package domain
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+// FlagUnknownState marks an event whose state column held a value that is
+// neither a USPS code nor the name of a US state or territory. It is also the
+// name of the matching validation check.
+const FlagUnknownState = "unknown_state"
+
+// statesCSV lists the USPS codes and names of the states, the District of
+// Columbia, and the territories that issue storm reports.
+//
+//go:embed states.csv
+var statesCSV string
+
+// stateCodes maps upper-cased USPS codes and state names to the USPS code.
+var stateCodes = mustParseStates(statesCSV)
+
+// normalizeState returns the USPS code for a state code or name in any case,
+// e.g. "tx", "Texas", or "NORTH  CAROLINA". Periods are ignored, so "D.C."
+// is DC. It returns false for an empty or unknown value.
+func normalizeState(s string) (string, bool) {
+	key := strings.ToUpper(strings.Join(strings.Fields(strings.ReplaceAll(s, ".", "")), " "))
+	code, ok := stateCodes[key]
+	return code, ok
+}
+
+// mustParseStates parses the embedded table. It panics on malformed rows
+// since the file ships with the binary and is covered by tests.
+func mustParseStates(data string) map[string]string {
+	rows, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("parse states.csv: %v", err))
+	}
+	codes := make(map[string]string, 2*len(rows))
+	for i, row := range rows[1:] {
+		if len(row[0]) != 2 {
+			panic(fmt.Sprintf("parse states.csv row %d: invalid code %q", i+2, row[0]))
+		}
+		codes[row[0]] = row[0]
+		codes[strings.ToUpper(row[1])] = row[0]
+	}
+	return codes
+}
@@ -1,10 +1,8 @@
 package domain
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
-	"encoding/json"
 	"fmt"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
@@ -21,79 +19,106 @@ var (
 	locationRe = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s+([NSEW]{1,3})\s+(.+)$`)
 )
 
-// ParseRawEvent deserializes a RawEvent's value into a StormEvent.
-// It expects the flat CSV-style JSON produced by the collector service.
+// ParseRawEvent is shorthand for Enrichment{}.ParseRawEvent, for callers
+// that parse with the default settings.
 func ParseRawEvent(raw RawEvent) (StormEvent, error) {
-	var rec RawCSVRecord
-	if err := json.Unmarshal(raw.Value, &rec); err != nil {
-		return StormEvent{}, fmt.Errorf("parse raw event: %w", err)
+	return Enrichment{}.ParseRawEvent(raw)
+}
+
+// ParseRawEvent deserializes a RawEvent's value, the flat CSV-style JSON
+// produced by the collector service, into a StormEvent, dating bare HHMM
+// times by e's day convention and deriving the ID with e's strategy.
+func (e Enrichment) ParseRawEvent(raw RawEvent) (StormEvent, error) {
+	rec, err := decodeRawCSVRecord(raw.Value)
+	if err != nil {
+		return StormEvent{}, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
 	}
 
 	lat := parseFloatOrZero(rec.Lat)
 	lon := parseFloatOrZero(rec.Lon)
-	magnitude := parseMagnitudeField(rec.EventType, rec.Size, rec.FScale, rec.Speed)
-	eventTime := parseEventTime(raw.Timestamp, rec.Time)
+	magnitude := parseMagnitudeField(rec.EventType, rec)
+	eventTime := parseEventTime(raw.Timestamp, rec.Time, e.dayConvention())
+	pathBegin, pathEnd := parsePath(rec)
 
 	return StormEvent{
-		ID:          generateID(rec.EventType, rec.State, lat, lon, rec.Time, magnitude),
+		ID:          generateID(e.idStrategy(), rec.EventType, rec.State, lat, lon, rec.Time, magnitude),
 		EventType:   rec.EventType,
 		Geo:         Geo{Lat: lat, Lon: lon},
-		Measurement: Measurement{Magnitude: magnitude},
+		Measurement: Measurement{Magnitude: magnitude, Method: parseMethodColumn(rec.MagnitudeMethod)},
 		EventTime:   eventTime,
 		Location:    Location{Raw: rec.Location, State: rec.State, County: rec.County},
 		Comments:    rec.Comments,
+		PathBegin:   pathBegin,
+		PathEnd:     pathEnd,
+		Deleted:     rec.Deleted,
 
 		RawPayload: raw.Value,
 	}, nil
 }
 
 // parseFloatOrZero parses a string as float64, returning 0 on failure.
+// NaN and infinities (which strconv accepts as "NaN", "Inf", or overflowing
+// exponents) count as failures, since they cannot be serialized as JSON.
 func parseFloatOrZero(s string) float64 {
 	s = strings.TrimSpace(s)
 	if s == "" {
 		return 0
 	}
 	v, err := strconv.ParseFloat(s, 64)
-	if err != nil {
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
 		return 0
 	}
 	return v
 }
 
-// parseMagnitudeField selects and parses the correct magnitude column based on event type.
-// Returns 0 for unknown values like "UNK".
-func parseMagnitudeField(eventType, size, fScale, speed string) float64 {
-	var raw string
-	switch eventType {
-	case "hail":
-		raw = size
-	case "tornado":
-		raw = fScale
-	case "wind":
-		raw = speed
-	default:
-		return 0
+// parsePath parses the track endpoints of a record. It returns nils unless
+// both endpoints are present, in range, not (0, 0), and distinct, so a
+// partial or degenerate path is treated as no path.
+func parsePath(rec RawCSVRecord) (begin, end *Geo) {
+	parse := func(lat, lon string) *Geo {
+		if strings.TrimSpace(lat) == "" || strings.TrimSpace(lon) == "" {
+			return nil
+		}
+		g := Geo{Lat: parseFloatOrZero(lat), Lon: parseFloatOrZero(lon)}
+		if (g.Lat == 0 && g.Lon == 0) || g.Lat < -90 || g.Lat > 90 || g.Lon < -180 || g.Lon > 180 {
+			return nil
+		}
+		return &g
 	}
-
-	raw = strings.TrimSpace(raw)
-	if raw == "" || strings.EqualFold(raw, "UNK") {
-		return 0
+	begin, end = parse(rec.BeginLat, rec.BeginLon), parse(rec.EndLat, rec.EndLon)
+	if begin == nil || end == nil || *begin == *end {
+		return nil, nil
 	}
-	raw = strings.TrimPrefix(raw, "EF")
-	raw = strings.TrimPrefix(raw, "F")
+	return begin, end
+}
 
-	v, err := strconv.ParseFloat(raw, 64)
-	if err != nil {
+// parseMagnitudeField selects and parses the magnitude column registered for
+// the event type. Returns 0 for unknown types and values like "UNK".
+func parseMagnitudeField(eventType string, rec RawCSVRecord) float64 {
+	spec, ok := lookupEventType(eventType)
+	if !ok {
 		return 0
 	}
-	return v
+	return spec.parseMagnitude(spec.Magnitude(rec))
 }
 
 // parseHHMM combines a base date with an HHMM time string (e.g. "1510" → 15:10).
 func parseHHMM(baseDate time.Time, hhmm string) time.Time {
+	hour, mins, ok := parseClock(hhmm)
+	if !ok {
+		return baseDate
+	}
+	return time.Date(
+		baseDate.Year(), baseDate.Month(), baseDate.Day(),
+		hour, mins, 0, 0, time.UTC,
+	)
+}
+
+// parseClock parses an HHMM (or HMM) time string into its hour and minute.
+func parseClock(hhmm string) (hour, mins int, ok bool) {
 	hhmm = strings.TrimSpace(hhmm)
 	if len(hhmm) < 3 {
-		return baseDate
+		return 0, 0, false
 	}
 	if len(hhmm) == 3 {
 		hhmm = "0" + hhmm
@@ -102,20 +127,17 @@ func parseHHMM(baseDate time.Time, hhmm string) time.Time {
 	hour, errH := strconv.Atoi(hhmm[:2])
 	mins, errM := strconv.Atoi(hhmm[2:])
 	if errH != nil || errM != nil || hour < 0 || hour > 23 || mins < 0 || mins > 59 {
-		return baseDate
+		return 0, 0, false
 	}
-
-	return time.Date(
-		baseDate.Year(), baseDate.Month(), baseDate.Day(),
-		hour, mins, 0, 0, time.UTC,
-	)
+	return hour, mins, true
 }
 
 // parseEventTime parses the Time field from the collector payload.
 // New-format payloads contain a full RFC 3339 timestamp (e.g. "2024-04-26T15:10:00Z")
 // set by the collector's expandHHMMToISO. Legacy payloads contain bare HHMM (e.g. "1510")
-// which is combined with the Kafka message timestamp as the base date.
-func parseEventTime(kafkaTimestamp time.Time, timeStr string) time.Time {
+// which is combined with the message timestamp as the report date. Under the
+// SPC day convention times before 1200 fall on the day after the report date.
+func parseEventTime(kafkaTimestamp time.Time, timeStr string, convention DayConvention) time.Time {
 	timeStr = strings.TrimSpace(timeStr)
 	if timeStr == "" {
 		return kafkaTimestamp
@@ -125,138 +147,286 @@ func parseEventTime(kafkaTimestamp time.Time, timeStr string) time.Time {
 		return t
 	}
 
+	hour, _, ok := parseClock(timeStr)
+	if ok && convention == DaySPC && hour < 12 {
+		return parseHHMM(kafkaTimestamp.AddDate(0, 0, 1), timeStr)
+	}
 	return parseHHMM(kafkaTimestamp, timeStr)
 }
 
-// generateID produces a deterministic ID from the event's key fields.
-// Deterministic IDs enable idempotent upserts (ON CONFLICT DO NOTHING) and
-// replay safety — reprocessing the same raw event produces the same ID.
-func generateID(eventType, state string, lat, lon float64, timeStr string, magnitude float64) string {
-	input := fmt.Sprintf("%s|%s|%.4f|%.4f|%s|%g", eventType, state, lat, lon, timeStr, magnitude)
-	hash := sha256.Sum256([]byte(input))
-	short := hex.EncodeToString(hash[:8])
-	if eventType == "" {
-		return short
-	}
-	return eventType + "-" + short
+// EnrichStormEvent is shorthand for Enrichment{}.EnrichStormEvent, for
+// callers that enrich with the default settings.
+func EnrichStormEvent(event StormEvent) StormEvent {
+	return Enrichment{}.EnrichStormEvent(event)
 }
 
 // EnrichStormEvent normalizes, classifies, and enriches a parsed storm event.
-// It validates the event type, infers default units, corrects magnitude encoding
-// issues, derives a severity label, extracts the NWS source office from comments,
-// parses structured location fields, and assigns an hourly time bucket.
-func EnrichStormEvent(event StormEvent) StormEvent {
+// It validates the event type, infers default units, corrects magnitude
+// encoding issues, derives a severity label, converts to e's unit system,
+// extracts the NWS source office and impact details from comments, estimates
+// the end time, parses structured location fields (including the named
+// place's approximate coordinates), assigns an hourly time bucket, and stamps
+// the current SchemaVersion.
+func (e Enrichment) EnrichStormEvent(event StormEvent) StormEvent {
+	return e.enrich(event, nil)
+}
+
+// enrich implements EnrichStormEvent, recording each decision in audit when
+// it is non-nil.
+func (e Enrichment) enrich(event StormEvent, audit *Audit) StormEvent {
+	event = e.NormalizeStormEvent(event, audit)
+	event = e.ClassifyStormEvent(event, audit)
+	event = e.GeocodeStormEvent(event, audit)
+	return FinalizeStormEvent(event)
+}
+
+// NormalizeStormEvent is the first enrichment step: it corrects swapped or
+// sign-flipped coordinates and flags points outside the US, normalizes the
+// state to its USPS code and the county name, resolves the canonical event
+// type and unit, corrects magnitude encoding issues, infers unknown hail
+// sizes from descriptive comments, extracts the NWS source office and impact
+// details from the comments, infers whether the magnitude was measured or
+// estimated, and estimates the end time. Decisions are recorded in audit when
+// it is non-nil.
+func (e Enrichment) NormalizeStormEvent(event StormEvent, audit *Audit) StormEvent {
+	if event.Geo != (Geo{}) {
+		if fixed, reason := correctCoordinates(event.Geo); reason != "" {
+			audit.Add("geo", formatGeo(event.Geo), formatGeo(fixed), reason)
+			event.Geo = fixed
+			event.markInferred("geo")
+		} else if !inUS(event.Geo) {
+			audit.Add("geo", "", "", "outside US bounds, not corrected")
+			event.AddQualityFlag(FlagOutsideUS)
+		}
+	}
+
+	if rawState := event.Location.State; strings.TrimSpace(rawState) != "" {
+		if code, ok := normalizeState(rawState); !ok {
+			event.Location.State = ""
+			event.AddQualityFlag(FlagUnknownState)
+			audit.Add("state", rawState, "", "not a US state or territory")
+		} else if code != rawState {
+			event.Location.State = code
+			audit.Add("state", rawState, code, "normalized to USPS code")
+		}
+	}
+
+	if rawCounty := event.Location.County; rawCounty != "" {
+		county, ok := normalizeCounty(event.Location.State, rawCounty)
+		event.Location.County = county
+		if !ok {
+			event.AddQualityFlag(FlagUnknownCounty)
+			audit.Add("county", rawCounty, county, "not a county of "+event.Location.State)
+		} else if county != rawCounty {
+			audit.Add("county", rawCounty, county, "normalized")
+		}
+	}
+
+	rawType := event.EventType
 	event.EventType = normalizeEventType(event.EventType)
+	if event.EventType != rawType {
+		audit.Add("event_type", rawType, event.EventType, "registered alias")
+	}
+
+	rawUnit := event.Measurement.Unit
 	event.Measurement.Unit = normalizeUnit(event.EventType, event.Measurement.Unit)
+	if event.Measurement.Unit != rawUnit {
+		reason := "normalized"
+		if rawUnit == "" {
+			reason = "default unit for " + event.EventType
+		}
+		audit.Add("unit", rawUnit, event.Measurement.Unit, reason)
+	}
+
+	rawMagnitude := event.Measurement.Magnitude
 	event.Measurement.Magnitude = normalizeMagnitude(event.EventType, event.Measurement.Magnitude, event.Measurement.Unit)
-	event.Measurement.Severity = deriveSeverity(event.EventType, event.Measurement.Magnitude)
+	if event.Measurement.Magnitude != rawMagnitude {
+		audit.Add("magnitude", formatFloat(rawMagnitude), formatFloat(event.Measurement.Magnitude), "legacy encoding corrected (hundredths of an inch)")
+	}
+	if event.EventType == "hail" && event.Measurement.Magnitude == 0 && event.Measurement.Unit == "in" {
+		if inches, phrase := inferHailSize(event.Comments); inches > 0 {
+			event.Measurement.Magnitude = inches
+			event.markInferred("measurement.magnitude")
+			audit.Add("magnitude", "", formatFloat(inches), "inferred from "+strconv.Quote(phrase)+" in comments")
+		}
+	}
+
 	event.SourceOffice = extractSourceOffice(event.Comments)
+	event.SourceOfficeDetail = lookupSourceOffice(event.SourceOffice)
+	switch {
+	case event.SourceOfficeDetail != nil:
+		audit.Add("source_office", "", event.SourceOffice, event.SourceOfficeDetail.Name+", "+event.SourceOfficeDetail.State)
+	case event.SourceOffice != "":
+		audit.Add("source_office", "", event.SourceOffice, "not a known WFO")
+	}
+
+	event.Impact = extractImpact(event.Comments)
+	if event.Impact != nil {
+		audit.Add("impact", "", "", "casualties or damage mentioned in comments")
+	}
+
+	if event.Measurement.Method == "" && event.Measurement.Magnitude > 0 {
+		event.Measurement.Method = inferMethod(event.Comments)
+		if event.Measurement.Method != "" {
+			audit.Add("method", "", event.Measurement.Method, "inferred from comments")
+		}
+	}
+
+	if !event.EventTime.IsZero() {
+		d, reason := estimateDuration(event.EventType, event.Comments, e.eventDurations())
+		event.EndTime = event.EventTime.Add(d)
+		if d > 0 {
+			audit.Add("end_time", "", event.EndTime.Format(time.RFC3339), d.String()+", "+reason)
+		}
+	}
+	return event
+}
+
+// ClassifyStormEvent derives the severity label from the normalized magnitude,
+// raises it when enabled keyword rules match the comments, and then converts
+// the measurement to e's unit system. It expects a normalized event.
+func (e Enrichment) ClassifyStormEvent(event StormEvent, audit *Audit) StormEvent {
+	event.Measurement.Severity = deriveSeverity(e.severityThresholds(), event.EventType, event.Measurement.Magnitude)
+	if event.Measurement.Severity != nil {
+		audit.Add("severity", formatQuantity(event.Measurement.Magnitude, event.Measurement.Unit), *event.Measurement.Severity, severityReason(e.severityThresholds(), event.EventType))
+	}
+	if level, rule := e.applySeverityRules(event); level != "" {
+		from := ""
+		if event.Measurement.Severity != nil {
+			from = *event.Measurement.Severity
+		}
+		event.Measurement.Severity = &level
+		audit.Add("severity", from, level, "keyword rule "+strconv.Quote(rule)+" in comments")
+	}
+
+	imperial := event.Measurement
+	event.Measurement = convertUnits(e.units(), event.EventType, event.Measurement)
+	if m := event.Measurement; m.Metric != nil {
+		audit.Add("units", formatQuantity(imperial.Magnitude, imperial.Unit), formatQuantity(m.Metric.Magnitude, m.Metric.Unit), "metric added")
+	} else if m.Unit != imperial.Unit {
+		audit.Add("units", formatQuantity(imperial.Magnitude, imperial.Unit), formatQuantity(m.Magnitude, m.Unit), "converted to metric")
+	}
+	return event
+}
+
+// GeocodeStormEvent parses the NWS relative location into the named place,
+// distance, and direction, approximates the place's coordinates from the
+// report's Geo, finds the nearest city and counts the nearby places in the
+// embedded places table, encodes the geohash, and looks up the NWS CWA and
+// forecast zone.
+func (e Enrichment) GeocodeStormEvent(event StormEvent, audit *Audit) StormEvent {
 	locationName, locationDistance, locationDirection := parseLocation(event.Location.Raw)
 	event.Location.Name = locationName
 	event.Location.Distance = locationDistance
 	event.Location.Direction = locationDirection
+	event.Location.PlaceGeo = derivePlaceGeo(event.Geo, locationDistance, locationDirection)
+	if locationDistance != nil {
+		audit.Add("location", event.Location.Raw, locationName, fmt.Sprintf("%s mi %s of place", formatFloat(*locationDistance), *locationDirection))
+	}
+	if g := event.Location.PlaceGeo; g != nil {
+		audit.Add("place_geo", "", formatGeo(*g), "offset from report coordinates on the reciprocal bearing")
+	}
+	event.NearestCity = nearestCity(event.Geo)
+	if c := event.NearestCity; c != nil {
+		audit.Add("nearest_city", "", c.Name+", "+c.State, fmt.Sprintf("%s mi %s of city", formatFloat(c.Distance), c.Direction))
+	}
+	event.NearbyPlaces = nearbyPlaces(event.Geo, e.nearbyPlacesRadius())
+	if x := event.NearbyPlaces; x != nil && x.Places > 0 {
+		audit.Add("nearby_places", "", strconv.Itoa(x.Population), fmt.Sprintf("%d places within %s mi", x.Places, formatFloat(x.Radius)))
+	}
+	event.Geohash = EncodeGeohash(event.Geo, e.geohashPrecision())
+	event.Location.CWA, event.Location.Zone = e.Boundaries.lookup(event.Geo)
+	if event.Location.CWA != "" {
+		audit.Add("cwa", "", event.Location.CWA, "report inside the CWA boundary")
+	}
+	if event.Location.Zone != "" {
+		audit.Add("zone", "", event.Location.Zone, "report inside the forecast zone boundary")
+	}
+	return event
+}
+
+// FinalizeStormEvent is the last enrichment step: it assigns the hourly time
+// bucket and stamps the processing time and current SchemaVersion.
+func FinalizeStormEvent(event StormEvent) StormEvent {
 	event.TimeBucket = deriveTimeBucket(event.EventTime)
 	event.ProcessedAt = clock.Now()
+	event.SchemaVersion = SchemaVersion
 	return event
 }
 
 // normalizeEventType validates and normalizes the event type metadata added by the upstream service.
 // Event type is not part of the original CSV data; it's added when converting CSV to JSON.
-// Accepts: "hail", "wind", "tornado" (exact matches only)
+// Accepts the registered names and aliases in builtinEventTypes (exact matches
+// only) and returns the canonical name, e.g. "flash flood" -> "flash_flood".
 func normalizeEventType(value string) string {
-	switch value {
-	case "hail", "wind", "tornado":
-		return value
-	default:
+	spec, ok := lookupEventType(value)
+	if !ok {
 		return ""
 	}
+	return spec.Name
 }
 
-// normalizeUnit returns the unit as-is if present, otherwise infers the default
-// unit for the event type: inches for hail, mph for wind, F-scale for tornado.
+// normalizeUnit returns the unit as-is if present, otherwise the registered
+// default unit for the event type (e.g. inches for hail, mph for wind).
 func normalizeUnit(eventType, unit string) string {
 	unit = strings.ToLower(strings.TrimSpace(unit))
 	if unit != "" {
 		return unit
 	}
-
-	switch eventType {
-	case "hail":
-		return "in"
-	case "wind":
-		return "mph"
-	case "tornado":
-		return "f_scale"
-	default:
+	spec, ok := lookupEventType(eventType)
+	if !ok {
 		return ""
 	}
+	return spec.DefaultUnit
 }
 
-// normalizeMagnitude corrects known encoding issues in upstream data.
-// Some hail reports encode diameter in hundredths of inches (e.g. 175 = 1.75in).
-// Values >= 10 with unit "in" are assumed to use this encoding and are divided
-// by 100. The threshold of 10 is safe because the largest hail ever recorded in
-// the US was approximately 8 inches (Vivian, SD, 2010).
+// normalizeMagnitude applies the event type's registered correction for known
+// encoding issues in upstream data. Types without one are returned unchanged.
 func normalizeMagnitude(eventType string, magnitude float64, unit string) float64 {
 	if magnitude == 0 {
 		return magnitude
 	}
-	if eventType == "hail" && unit == "in" && magnitude >= 10 {
+	spec, ok := lookupEventType(eventType)
+	if !ok || spec.Normalize == nil {
+		return magnitude
+	}
+	return spec.Normalize(magnitude, unit)
+}
+
+// normalizeHailMagnitude corrects hail diameters encoded in hundredths of
+// inches (e.g. 175 = 1.75in). Values >= 10 with unit "in" are assumed to use
+// this encoding and are divided by 100. The threshold of 10 is safe because the
+// largest hail ever recorded in the US was approximately 8 inches (Vivian, SD, 2010).
+func normalizeHailMagnitude(magnitude float64, unit string) float64 {
+	if unit == "in" && magnitude >= 10 {
 		return magnitude / 100.0
 	}
 	return magnitude
 }
 
-// deriveSeverity maps magnitude to a severity label based on operational thresholds
-// informed by NWS Severe Weather Criteria and the Enhanced Fujita Scale:
-//   - hail: <0.75in minor, <1.5in moderate, <2.5in severe, else extreme
-//   - wind: <50mph minor, <74mph moderate (tropical storm threshold), <96mph severe (hurricane Cat 2), else extreme
-//   - tornado: EF0-1 minor, EF2 moderate, EF3-4 severe, EF5 extreme
-//
+// deriveSeverity maps magnitude to a severity label using the event type's
+// entry in thresholds.
 // The four-level scale is a project-specific simplification for user-facing queries.
-// Returns nil when magnitude is 0 or the event type is unrecognized.
-func deriveSeverity(eventType string, magnitude float64) *string {
+// Returns nil when magnitude is 0 or the event type has no thresholds.
+func deriveSeverity(thresholds map[string]SeverityThresholds, eventType string, magnitude float64) *string {
 	if magnitude == 0 {
 		return nil
 	}
+	t, ok := thresholds[eventType]
+	if !ok {
+		return nil
+	}
 
 	var s string
-	switch eventType {
-	case "hail":
-		switch {
-		case magnitude < 0.75:
-			s = "minor"
-		case magnitude < 1.5:
-			s = "moderate"
-		case magnitude < 2.5:
-			s = "severe"
-		default:
-			s = "extreme"
-		}
-	case "wind":
-		switch {
-		case magnitude < 50:
-			s = "minor"
-		case magnitude < 74:
-			s = "moderate"
-		case magnitude < 96:
-			s = "severe"
-		default:
-			s = "extreme"
-		}
-	case "tornado":
-		switch {
-		case magnitude <= 1:
-			s = "minor"
-		case magnitude == 2:
-			s = "moderate"
-		case magnitude <= 4:
-			s = "severe"
-		default:
-			s = "extreme"
-		}
+	switch {
+	case magnitude < t.Moderate:
+		s = "minor"
+	case magnitude < t.Severe:
+		s = "moderate"
+	case magnitude < t.Extreme:
+		s = "severe"
 	default:
-		return nil
+		s = "extreme"
 	}
 	return &s
 }
@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"encoding/json"
+	"os"
 	"strings"
 	"testing"
 	"time"
@@ -44,6 +46,40 @@ func TestParseRawEvent(t *testing.T) {
 		assert.Equal(t, data, result.RawPayload)
 	})
 
+	t.Run("deleted marker keeps the event ID", func(t *testing.T) {
+		data := []byte(`{"Time":"1510","Size":"125","Location":"8 ESE Chappel","County":"San Saba","State":"TX","Lat":"31.02","Lon":"-98.44","Comments":"1.25 inch hail reported. (SJT)","EventType":"hail"}`)
+		loaded, err := ParseRawEvent(RawEvent{Value: data, Timestamp: baseDate})
+		require.NoError(t, err)
+
+		retracted := []byte(`{"Time":"1510","Size":"125","Location":"8 ESE Chappel","County":"San Saba","State":"TX","Lat":"31.02","Lon":"-98.44","Comments":"1.25 inch hail reported. (SJT)","EventType":"hail","Deleted":true}`)
+		result, err := ParseRawEvent(RawEvent{Value: retracted, Timestamp: baseDate})
+		require.NoError(t, err)
+		assert.True(t, result.Deleted)
+		assert.False(t, loaded.Deleted)
+		assert.Equal(t, loaded.ID, result.ID)
+	})
+
+	t.Run("tornado path endpoints", func(t *testing.T) {
+		data := []byte(`{"Time":"1223","F_Scale":"EF2","Location":"2 N Mcalester","State":"OK","Lat":"34.96","Lon":"-95.77","EventType":"tornado","BeginLat":"34.93","BeginLon":"-95.80","EndLat":"34.99","EndLon":"-95.71"}`)
+		result, err := ParseRawEvent(RawEvent{Value: data, Timestamp: baseDate})
+		require.NoError(t, err)
+		assert.Equal(t, &Geo{Lat: 34.93, Lon: -95.80}, result.PathBegin)
+		assert.Equal(t, &Geo{Lat: 34.99, Lon: -95.71}, result.PathEnd)
+
+		for _, path := range []string{
+			`"BeginLat":"34.93","BeginLon":"-95.80"`,
+			`"BeginLat":"34.93","BeginLon":"-95.80","EndLat":"34.93","EndLon":"-95.80"`,
+			`"BeginLat":"34.93","BeginLon":"-95.80","EndLat":"134.99","EndLon":"-95.71"`,
+			`"BeginLat":"0","BeginLon":"0","EndLat":"34.99","EndLon":"-95.71"`,
+		} {
+			data := []byte(`{"Time":"1223","F_Scale":"EF2","Lat":"34.96","Lon":"-95.77","EventType":"tornado",` + path + `}`)
+			result, err := ParseRawEvent(RawEvent{Value: data, Timestamp: baseDate})
+			require.NoError(t, err)
+			assert.Nil(t, result.PathBegin, path)
+			assert.Nil(t, result.PathEnd, path)
+		}
+	})
+
 	t.Run("tornado CSV record", func(t *testing.T) {
 		data := []byte(`{"Time":"1223","F_Scale":"EF2","Location":"2 N Mcalester","County":"Pittsburg","State":"OK","Lat":"34.96","Lon":"-95.77","Comments":"Tornado confirmed (TSA)","EventType":"tornado"}`)
 		raw := RawEvent{Value: data, Timestamp: baseDate}
@@ -150,19 +186,30 @@ func TestParseEventTime(t *testing.T) {
 	}{
 		{"RFC 3339 timestamp", "2024-04-26T15:10:00Z", time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC)},
 		{"HHMM fallback", "1510", time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC)},
-		{"three digit HHMM fallback", "930", time.Date(2024, 4, 26, 9, 30, 0, 0, time.UTC)},
+		{"three digit HHMM before 12Z is next day", "930", time.Date(2024, 4, 27, 9, 30, 0, 0, time.UTC)},
+		{"12Z stays on report date", "1200", time.Date(2024, 4, 26, 12, 0, 0, 0, time.UTC)},
+		{"1159 is next day", "1159", time.Date(2024, 4, 27, 11, 59, 0, 0, time.UTC)},
 		{testEmptyStr, "", baseDate},
 		{"invalid string", "not-a-time", baseDate},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := parseEventTime(baseDate, tt.timeStr)
+			result := parseEventTime(baseDate, tt.timeStr, DaySPC)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
 }
 
+func TestParseEventTime_CalendarConvention(t *testing.T) {
+	baseDate := time.Date(2024, 4, 26, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2024, 4, 26, 9, 30, 0, 0, time.UTC), parseEventTime(baseDate, "930", DayCalendar))
+	assert.Equal(t, time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC), parseEventTime(baseDate, "1510", DayCalendar))
+	assert.Equal(t, time.Date(2024, 4, 27, 3, 0, 0, 0, time.UTC), parseEventTime(baseDate, "2024-04-27T03:00:00Z", DayCalendar),
+		"RFC 3339 timestamps are used as-is")
+}
+
 func TestParseMagnitudeField(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -183,7 +230,8 @@ func TestParseMagnitudeField(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := parseMagnitudeField(tt.typ, tt.size, tt.fScale, tt.speed)
+			rec := RawCSVRecord{Size: tt.size, FScale: tt.fScale, Speed: tt.speed, Magnitude: "99"}
+			result := parseMagnitudeField(tt.typ, rec)
 			assert.InDelta(t, tt.expected, result, 0.0001)
 		})
 	}
@@ -191,36 +239,63 @@ func TestParseMagnitudeField(t *testing.T) {
 
 func TestGenerateID(t *testing.T) {
 	t.Run("includes event type prefix", func(t *testing.T) {
-		id := generateID("hail", "TX", 31.02, -98.44, "1510", 1.75)
+		id := generateID(sha256IDStrategy{}, "hail", "TX", 31.02, -98.44, "1510", 1.75)
 		assert.True(t, strings.HasPrefix(id, "hail-"))
 	})
 
 	t.Run("deterministic", func(t *testing.T) {
-		id1 := generateID("wind", "OK", 34.94, -95.77, "1251", 65)
-		id2 := generateID("wind", "OK", 34.94, -95.77, "1251", 65)
+		id1 := generateID(sha256IDStrategy{}, "wind", "OK", 34.94, -95.77, "1251", 65)
+		id2 := generateID(sha256IDStrategy{}, "wind", "OK", 34.94, -95.77, "1251", 65)
 		assert.Equal(t, id1, id2)
 	})
 
 	t.Run("different inputs produce different IDs", func(t *testing.T) {
-		id1 := generateID("hail", "TX", 31.02, -98.44, "1510", 1.75)
-		id2 := generateID("hail", "TX", 31.02, -98.44, "1511", 1.75)
+		id1 := generateID(sha256IDStrategy{}, "hail", "TX", 31.02, -98.44, "1510", 1.75)
+		id2 := generateID(sha256IDStrategy{}, "hail", "TX", 31.02, -98.44, "1511", 1.75)
 		assert.NotEqual(t, id1, id2)
 	})
 
 	t.Run("different magnitudes produce different IDs", func(t *testing.T) {
-		id1 := generateID("wind", "AZ", 34.08, -112.14, "0100", 60)
-		id2 := generateID("wind", "AZ", 34.08, -112.14, "0100", 0)
+		id1 := generateID(sha256IDStrategy{}, "wind", "AZ", 34.08, -112.14, "0100", 60)
+		id2 := generateID(sha256IDStrategy{}, "wind", "AZ", 34.08, -112.14, "0100", 0)
 		assert.NotEqual(t, id1, id2)
 	})
 
 	t.Run("empty type", func(t *testing.T) {
-		id := generateID("", "TX", 31.02, -98.44, "1510", 1.75)
+		id := generateID(sha256IDStrategy{}, "", "TX", 31.02, -98.44, "1510", 1.75)
 		assert.NotEmpty(t, id)
 		// No type prefix, just the hex hash
 		assert.NotContains(t, id, "hail")
 	})
 }
 
+func TestIDStrategies(t *testing.T) {
+	key := IDKey{EventType: "hail", State: "TX", Lat: 31.02, Lon: -98.44, Time: "1510", Magnitude: 1.75}
+
+	v1 := idStrategies[IDStrategySHA256].ID(key)
+	assert.Regexp(t, `^hail-[0-9a-f]{16}$`, v1)
+	assert.Equal(t, Enrichment{}.idStrategy().ID(key), v1, "default strategy must stay unversioned")
+
+	v2 := idStrategies[IDStrategySHA256NoMagnitude].ID(key)
+	assert.Regexp(t, `^hail-v2-[0-9a-f]{16}$`, v2)
+	corrected := key
+	corrected.Magnitude = 2.0
+	assert.Equal(t, v2, idStrategies[IDStrategySHA256NoMagnitude].ID(corrected))
+
+	v3 := idStrategies[IDStrategyUUIDv5].ID(key)
+	assert.Regexp(t, `^hail-v3-[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, v3)
+	assert.Equal(t, v3, idStrategies[IDStrategyUUIDv5].ID(key))
+
+	uuidv5 := Enrichment{IDStrategy: idStrategies[IDStrategyUUIDv5]}
+	event, err := uuidv5.ParseRawEvent(RawEvent{Value: []byte(`{"EventType":"hail","State":"TX","Lat":"31.02","Lon":"-98.44","Time":"1510","Size":"1.75"}`)})
+	require.NoError(t, err)
+	assert.Equal(t, v3, event.ID)
+	assert.Equal(t, []string{"sha256", "sha256-nomag", "uuidv5"}, IDStrategyNames())
+
+	_, err = IDStrategyByName("md5")
+	require.Error(t, err)
+}
+
 func TestEnrichStormEvent(t *testing.T) {
 	fixedTime := time.Date(2024, 4, 26, 12, 30, 45, 0, time.UTC)
 	mockClock := clockwork.NewFakeClockAt(fixedTime)
@@ -294,6 +369,10 @@ func TestNormalizeEventType(t *testing.T) {
 		{"hail", "hail", "hail"},
 		{"wind", "wind", "wind"},
 		{"tornado", "tornado", "tornado"},
+		{"flood", "flood", "flood"},
+		{"flash flood alias", "flash flood", "flash_flood"},
+		{"heavy snow alias", "heavy snow", "heavy_snow"},
+		{"lightning", "lightning", "lightning"},
 		{"torn rejected", "torn", ""},
 		{"uppercase rejected", "HAIL", ""},
 		{"mixed case rejected", "Hail", ""},
@@ -324,6 +403,9 @@ func TestNormalizeUnit(t *testing.T) {
 		{"hail default", "hail", "", "in"},
 		{"wind default", "wind", "", "mph"},
 		{"tornado default", "tornado", "", "f_scale"},
+		{"flash flood default", "flash_flood", "", "ft"},
+		{"heavy snow default", "heavy_snow", "", "in"},
+		{"lightning has no unit", "lightning", "", ""},
 		{testUnknown, "earthquake", "", ""},
 		{"empty type and unit", "", "", ""},
 	}
@@ -394,6 +476,12 @@ func TestDeriveSeverity(t *testing.T) {
 		{"tornado severe F4", "tornado", 4, stringPtr("severe")},
 		{"tornado extreme F5", "tornado", 5, stringPtr("extreme")},
 
+		// Registered non-SPC types
+		{"flood moderate", "flood", 1, stringPtr("moderate")},
+		{"flash flood extreme", "flash_flood", 6, stringPtr("extreme")},
+		{"heavy snow severe", "heavy_snow", 18, stringPtr("severe")},
+		{"lightning never classified", "lightning", 3, nil},
+
 		// Edge cases
 		{"zero magnitude", "hail", 0, nil},
 		{testUnknown, "earthquake", 5.5, nil},
@@ -402,12 +490,399 @@ func TestDeriveSeverity(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := deriveSeverity(tt.eventType, tt.magnitude)
+			result := deriveSeverity(defaultSeverityThresholds, tt.eventType, tt.magnitude)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
 }
 
+func TestEnrichStormEvent_SeverityKeywordRules(t *testing.T) {
+	tornado := StormEvent{EventType: "tornado", Measurement: Measurement{Magnitude: 1}, Comments: "Mobile home destroyed. One person killed. (OUN)"}
+
+	assert.Equal(t, stringPtr("minor"), EnrichStormEvent(tornado).Measurement.Severity, "rules are off by default")
+
+	rules := Enrichment{SeverityKeywordRules: true}
+	enriched, steps := rules.EnrichStormEventAudited(tornado)
+	assert.Equal(t, stringPtr("extreme"), enriched.Measurement.Severity)
+	assert.Contains(t, steps, AuditStep{Step: "severity", From: "minor", To: "extreme", Reason: `keyword rule "fatality" in comments`})
+
+	tests := []struct {
+		name  string
+		event StormEvent
+		want  *string
+	}{
+		{"overturned vehicles", StormEvent{EventType: "wind", Measurement: Measurement{Magnitude: 60}, Comments: "Two semis were overturned on I-40."}, stringPtr("severe")},
+		{"injury", StormEvent{EventType: "hail", Measurement: Measurement{Magnitude: 0.5}, Comments: "1 injury from falling hail."}, stringPtr("moderate")},
+		{"no fatalities", StormEvent{EventType: "wind", Measurement: Measurement{Magnitude: 60}, Comments: "No fatalities reported."}, stringPtr("moderate")},
+		{"never lowered", StormEvent{EventType: "wind", Measurement: Measurement{Magnitude: 100}, Comments: "1 injury."}, stringPtr("extreme")},
+		{"no magnitude", StormEvent{EventType: "tornado", Comments: "Several homes destroyed."}, stringPtr("severe")},
+		{"no thresholds", StormEvent{EventType: "lightning", Comments: "1 person killed by lightning."}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, rules.EnrichStormEvent(tt.event).Measurement.Severity)
+		})
+	}
+}
+
+func TestParseSeverityThresholds(t *testing.T) {
+	got, err := ParseSeverityThresholds("hail=1, 2, 3; tornado=1,3,4; heavy snow=4,8,16")
+	require.NoError(t, err)
+	assert.Equal(t, SeverityThresholds{Moderate: 1, Severe: 2, Extreme: 3}, got["hail"])
+	assert.Equal(t, SeverityThresholds{Moderate: 1, Severe: 3, Extreme: 4}, got["tornado"])
+	assert.Equal(t, defaultSeverityThresholds["wind"], got["wind"])
+	assert.Equal(t, SeverityThresholds{Moderate: 4, Severe: 8, Extreme: 16}, got["heavy_snow"])
+
+	defaults, err := ParseSeverityThresholds("")
+	require.NoError(t, err)
+	assert.Equal(t, defaultSeverityThresholds, defaults)
+
+	for _, bad := range []string{"hail", "hail=1,2", "hail=3,2,1", "hail=0,1,2", "hail=a,b,c", "earthquake=1,2,3"} {
+		_, err := ParseSeverityThresholds(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestEnrichStormEvent_SeverityThresholds(t *testing.T) {
+	thresholds, err := ParseSeverityThresholds("hail=1,2,3")
+	require.NoError(t, err)
+	custom := Enrichment{SeverityThresholds: thresholds}
+	hail := StormEvent{EventType: "hail", Measurement: Measurement{Magnitude: 0.75, Unit: "in"}}
+
+	enriched, steps := custom.EnrichStormEventAudited(hail)
+	assert.Equal(t, stringPtr("minor"), enriched.Measurement.Severity)
+	assert.Contains(t, steps, AuditStep{Step: "severity", From: "0.75 in", To: "minor", Reason: "hail thresholds moderate 1, severe 2, extreme 3"})
+	assert.Equal(t, stringPtr("moderate"), custom.EnrichStormEvent(StormEvent{EventType: "wind", Measurement: Measurement{Magnitude: 50}}).Measurement.Severity,
+		"types not overridden keep their defaults")
+
+	assert.Equal(t, stringPtr("moderate"), EnrichStormEvent(hail).Measurement.Severity)
+}
+
+func TestParseAndEnrich_RegisteredTypes(t *testing.T) {
+	tests := []struct {
+		name      string
+		payload   string
+		eventType string
+		magnitude float64
+		unit      string
+		severity  *string
+	}{
+		{"flash flood alias", `{"EventType":"flash flood","Magnitude":"2.5","Time":"1510"}`, "flash_flood", 2.5, "ft", stringPtr("severe")},
+		{"heavy snow", `{"EventType":"heavy_snow","Magnitude":"14","Time":"1510"}`, "heavy_snow", 14, "in", stringPtr("severe")},
+		{"lightning without magnitude", `{"EventType":"lightning","Time":"1510"}`, "lightning", 0, "", nil},
+		{"SPC columns ignored for new types", `{"EventType":"flood","Size":"175","Time":"1510"}`, "flood", 0, "ft", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := RawEvent{Value: []byte(tt.payload), Timestamp: time.Date(2024, 4, 26, 0, 0, 0, 0, time.UTC)}
+			parsed, err := ParseRawEvent(raw)
+			require.NoError(t, err)
+			event := EnrichStormEvent(parsed)
+			assert.Equal(t, tt.eventType, event.EventType)
+			assert.InDelta(t, tt.magnitude, event.Measurement.Magnitude, 0.0001)
+			assert.Equal(t, tt.unit, event.Measurement.Unit)
+			assert.Equal(t, tt.severity, event.Measurement.Severity)
+		})
+	}
+}
+
+func TestConvertUnits(t *testing.T) {
+	severe := stringPtr("severe")
+	tests := []struct {
+		name      string
+		system    UnitSystem
+		eventType string
+		in        Measurement
+		expected  Measurement
+	}{
+		{"imperial unchanged", UnitsImperial, "hail", Measurement{Magnitude: 1.75, Unit: "in"}, Measurement{Magnitude: 1.75, Unit: "in"}},
+		{"hail to mm", UnitsMetric, "hail", Measurement{Magnitude: 1.75, Unit: "in", Severity: severe}, Measurement{Magnitude: 44.45, Unit: "mm", Severity: severe}},
+		{"wind to km/h", UnitsMetric, "wind", Measurement{Magnitude: 65, Unit: "mph"}, Measurement{Magnitude: 104.61, Unit: "km/h"}},
+		{"snow to cm", UnitsMetric, "heavy_snow", Measurement{Magnitude: 12, Unit: "in"}, Measurement{Magnitude: 30.48, Unit: "cm"}},
+		{"flood to m", UnitsMetric, "flood", Measurement{Magnitude: 2, Unit: "ft"}, Measurement{Magnitude: 0.61, Unit: "m"}},
+		{"tornado has no metric unit", UnitsMetric, "tornado", Measurement{Magnitude: 3, Unit: "f_scale"}, Measurement{Magnitude: 3, Unit: "f_scale"}},
+		{"already metric left alone", UnitsMetric, "hail", Measurement{Magnitude: 5, Unit: "cm"}, Measurement{Magnitude: 5, Unit: "cm"}},
+		{"zero magnitude", UnitsMetric, "hail", Measurement{Unit: "in"}, Measurement{Unit: "in"}},
+		{"both keeps imperial", UnitsBoth, "wind", Measurement{Magnitude: 74, Unit: "mph", Severity: severe},
+			Measurement{Magnitude: 74, Unit: "mph", Severity: severe, Metric: &Quantity{Magnitude: 119.09, Unit: "km/h"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, convertUnits(tt.system, tt.eventType, tt.in))
+		})
+	}
+}
+
+func TestEnrichStormEvent_MetricSeverityFromImperial(t *testing.T) {
+	event := Enrichment{Units: UnitsMetric}.EnrichStormEvent(StormEvent{EventType: "hail", Measurement: Measurement{Magnitude: 175}})
+	assert.InDelta(t, 44.45, event.Measurement.Magnitude, 0.0001)
+	assert.Equal(t, "mm", event.Measurement.Unit)
+	assert.Equal(t, stringPtr("severe"), event.Measurement.Severity)
+}
+
+func TestDerivePlaceGeo(t *testing.T) {
+	report := Geo{Lat: 31.0, Lon: -98.0}
+
+	// "8 ESE Chappel": Chappel lies 8 miles WNW of the report.
+	place := derivePlaceGeo(report, float64Ptr(8), stringPtr("ESE"))
+	require.NotNil(t, place)
+	assert.InDelta(t, 31.0443, place.Lat, 0.001)
+	assert.InDelta(t, -98.1247, place.Lon, 0.001)
+
+	// "10 N Town": Town lies 10 miles due south.
+	place = derivePlaceGeo(report, float64Ptr(10), stringPtr("N"))
+	require.NotNil(t, place)
+	assert.InDelta(t, 30.8553, place.Lat, 0.001)
+	assert.InDelta(t, -98.0, place.Lon, 0.0001)
+
+	assert.Nil(t, derivePlaceGeo(report, nil, nil), "report at the named place")
+	assert.Nil(t, derivePlaceGeo(Geo{}, float64Ptr(8), stringPtr("ESE")), "no report coordinates")
+	assert.Nil(t, derivePlaceGeo(report, float64Ptr(8), stringPtr("XYZ")), "unknown direction")
+}
+
+func TestEnrichStormEvent_PlaceGeo(t *testing.T) {
+	event := EnrichStormEvent(StormEvent{Geo: Geo{Lat: 31.0, Lon: -98.0}, Location: Location{Raw: "8 ESE Chappel"}})
+	require.NotNil(t, event.Location.PlaceGeo)
+	assert.InDelta(t, 31.0443, event.Location.PlaceGeo.Lat, 0.001)
+
+	event = EnrichStormEvent(StormEvent{Geo: Geo{Lat: 31.0, Lon: -98.0}, Location: Location{Raw: "Chappel"}})
+	assert.Nil(t, event.Location.PlaceGeo)
+}
+
+func intPtr(n int) *int { return &n }
+
+func TestExtractImpact(t *testing.T) {
+	tests := []struct {
+		name     string
+		comments string
+		expected *Impact
+	}{
+		{"empty", "", nil},
+		{"no impact", "Quarter size hail reported by spotter. (OUN)", nil},
+		{"digit counts", "Tornado destroyed a barn. 3 injuries and 1 fatality reported. (FWD)",
+			&Impact{Injuries: intPtr(3), Fatalities: intPtr(1), Damage: []string{"structure damage"}}},
+		{"word counts", "Two people injured when a tree fell on their car.",
+			&Impact{Injuries: intPtr(2), Damage: []string{"vehicle damage"}}},
+		{"counts summed", "1 minor injury in town. 2 serious injuries at the farm.", &Impact{Injuries: intPtr(3)}},
+		{"explicit none", "Roof damage to several homes. No injuries.", &Impact{Injuries: intPtr(0), Damage: []string{"roof damage"}}},
+		{"uppercase", "NUMEROUS TREES DOWN AND POWER LINES DOWN ACROSS THE COUNTY.", &Impact{Damage: []string{"trees down", "power lines down"}}},
+		{"downed phrasing", "Downed trees blocking road. Broken windows at school.", &Impact{Damage: []string{"trees down", "broken windows"}}},
+		{"verb phrasing", "Tornado moved across town... damaging the roofs of homes... powerlines blown over.", &Impact{Damage: []string{"roof damage", "power lines down"}}},
+		{"non-casualty count ignored", "Two trees dead after the storm.", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, extractImpact(tt.comments))
+		})
+	}
+}
+
+func TestEstimateDuration(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType string
+		comments  string
+		want      time.Duration
+		reason    string
+	}{
+		{"hail default", "hail", "Quarter hail. (FWD)", 15 * time.Minute, "default window for hail"},
+		{"lightning is instantaneous", "lightning", "", 0, "default window for lightning"},
+		{"stated minutes", "wind", "Winds gusted for about 20 minutes. (OUN)", 20 * time.Minute, "duration stated in comments"},
+		{"stated range uses upper bound", "hail", "Hail fell for 4 to 5 minutes. (TSA)", 5 * time.Minute, "duration stated in comments"},
+		{"stated hours", "flash_flood", "Road flooding lasted 2 hours. (LZK)", 2 * time.Hour, "duration stated in comments"},
+		{"tornado path length", "tornado", "EF1 tornado with a path length of 7.5 miles. (OUN)", 15 * time.Minute, "7.5 mi path at 30 mph"},
+		{"short tornado path", "tornado", "Brief touchdown, path of 0.1 mi. (OUN)", time.Minute, "0.1 mi path at 30 mph"},
+		{"path ignored for other types", "wind", "Damage path of 7.5 miles. (OUN)", 10 * time.Minute, "default window for wind"},
+		{"stated duration wins over path", "tornado", "Path length 10 miles, on the ground for 12 minutes. (OUN)", 12 * time.Minute, "duration stated in comments"},
+		{"capped", "heavy_snow", "Snow lasting 40 hours. (BOU)", maxEventDuration, "duration stated in comments"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := estimateDuration(tt.eventType, tt.comments, defaultEventDurations)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.reason, reason)
+		})
+	}
+}
+
+func TestParseEventDurations(t *testing.T) {
+	got, err := ParseEventDurations("hail=5m, heavy snow=6h")
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, got["hail"])
+	assert.Equal(t, 6*time.Hour, got["heavy_snow"])
+	assert.Equal(t, defaultEventDurations["wind"], got["wind"])
+
+	defaults, err := ParseEventDurations("")
+	require.NoError(t, err)
+	assert.Equal(t, defaultEventDurations, defaults)
+
+	for _, bad := range []string{"hail", "hail=5", "hail=-5m", "hail=48h", "earthquake=5m"} {
+		_, err := ParseEventDurations(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestEnrichStormEvent_EndTime(t *testing.T) {
+	begin := time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC)
+
+	event := EnrichStormEvent(StormEvent{EventType: "hail", EventTime: begin})
+	assert.Equal(t, begin.Add(15*time.Minute), event.EndTime)
+
+	durations, err := ParseEventDurations("hail=30m")
+	require.NoError(t, err)
+	custom := Enrichment{EventDurations: durations}
+	event = custom.EnrichStormEvent(StormEvent{EventType: "hail", EventTime: begin})
+	assert.Equal(t, begin.Add(30*time.Minute), event.EndTime)
+
+	_, steps := custom.EnrichStormEventAudited(StormEvent{EventType: "hail", EventTime: begin})
+	assert.Contains(t, steps, AuditStep{Step: "end_time", To: "2024-04-26T15:40:00Z", Reason: "30m0s, default window for hail"})
+
+	assert.True(t, EnrichStormEvent(StormEvent{EventType: "hail"}).EndTime.IsZero(), "no end time without an event time")
+}
+
+func TestInferMethod(t *testing.T) {
+	tests := []struct {
+		comments string
+		want     string
+	}{
+		{"MEASURED 72 MPH GUST AT THE AIRPORT. (OUN)", MethodMeasured},
+		{"Delayed report. ASOS at Eppley Airfield. (OAX)", MethodMeasured},
+		{"Trained spotter estimated a 60 mph wind gust. (FGZ)", MethodEstimated},
+		{"Est. 1 inch hail. (TSA)", MethodEstimated},
+		{"Spotter estimated 60 mph; mesonet site measured 72 mph. (OUN)", MethodMeasured},
+		{"Large tree limbs down. Time estimated from radar. (TSA)", ""},
+		{"Tree down. Time and location estimated from radar. (EAX)", ""},
+		{"Quarter size hail. (SJT)", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, inferMethod(tt.comments), tt.comments)
+	}
+}
+
+func TestEnrichStormEvent_Method(t *testing.T) {
+	raw := `{"Time":"1510","Speed":"72","Lat":"35.0","Lon":"-97.0","Comments":"Estimated 60 mph. (OUN)","EventType":"wind","MagnitudeMethod":"M"}`
+	event, err := ParseRawEvent(RawEvent{Value: []byte(raw)})
+	require.NoError(t, err)
+	assert.Equal(t, MethodMeasured, EnrichStormEvent(event).Measurement.Method, "the collector column wins over the comments")
+
+	event.Measurement.Method = ""
+	enriched, steps := EnrichStormEventAudited(event)
+	assert.Equal(t, MethodEstimated, enriched.Measurement.Method)
+	assert.Contains(t, steps, AuditStep{Step: "method", To: MethodEstimated, Reason: "inferred from comments"})
+
+	event.Measurement.Magnitude = 0
+	assert.Empty(t, EnrichStormEvent(event).Measurement.Method, "no method without a magnitude")
+
+	assert.Equal(t, MethodEstimated, parseMethodColumn(" e "))
+	assert.Empty(t, parseMethodColumn("U"))
+}
+
+func TestInferHailSize(t *testing.T) {
+	tests := []struct {
+		comments string
+		inches   float64
+	}{
+		{"Golf ball size hail in Worth County. (EAX)", 1.75},
+		{"golfball sized hail", 1.75},
+		{"Photo of ping pong ball size hail. (EAX)", 1.5},
+		{"Pea to quarter size hail covering the ground. (OUN)", 1.0},
+		{"HALF-DOLLAR HAIL AT HOME. (TSA)", 1.25},
+		{"Hen egg sized hail broke windows. (LBF)", 2.0},
+		{"Hail a quarter mile north of town. (OUN)", 0},
+		{"Hail larger than a quarter. (OUN)", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		inches, _ := inferHailSize(tt.comments)
+		assert.InDelta(t, tt.inches, inches, 0.001, tt.comments)
+	}
+}
+
+func TestEnrichStormEvent_InferredHailSize(t *testing.T) {
+	raw := `{"Time":"1510","Size":"UNK","Lat":"35.0","Lon":"-97.0","Comments":"Golf ball size hail. (OUN)","EventType":"hail"}`
+	event, err := ParseRawEvent(RawEvent{Value: []byte(raw)})
+	require.NoError(t, err)
+
+	enriched, steps := EnrichStormEventAudited(event)
+	assert.InDelta(t, 1.75, enriched.Measurement.Magnitude, 0.001)
+	assert.Equal(t, stringPtr("severe"), enriched.Measurement.Severity)
+	assert.Equal(t, &DataQuality{Inferred: []string{"measurement.magnitude"}}, enriched.Quality)
+	assert.Contains(t, steps, AuditStep{Step: "magnitude", To: "1.75", Reason: `inferred from "Golf ball size" in comments`})
+
+	event.Measurement.Magnitude = 1
+	enriched = EnrichStormEvent(event)
+	assert.InDelta(t, 1.0, enriched.Measurement.Magnitude, 0.001, "a reported size is never overridden")
+	assert.Nil(t, enriched.Quality)
+}
+
+func TestLookupSourceOffice(t *testing.T) {
+	oun := lookupSourceOffice("OUN")
+	require.NotNil(t, oun)
+	assert.Equal(t, SourceOfficeDetail{Code: "OUN", Name: "Norman", State: "OK", Geo: Geo{Lat: 35.18, Lon: -97.44}}, *oun)
+
+	icao := lookupSourceOffice("KFWD")
+	require.NotNil(t, icao)
+	assert.Equal(t, "FWD", icao.Code)
+
+	assert.Nil(t, lookupSourceOffice(""))
+	assert.Nil(t, lookupSourceOffice("ABCDE"))
+	assert.Len(t, wfoOffices, 123)
+}
+
+func TestEnrichStormEvent_SourceOfficeDetail(t *testing.T) {
+	event := EnrichStormEvent(StormEvent{Comments: "Large trees down. (SJT)"})
+	require.NotNil(t, event.SourceOfficeDetail)
+	assert.Equal(t, "San Angelo", event.SourceOfficeDetail.Name)
+	assert.Equal(t, "TX", event.SourceOfficeDetail.State)
+
+	event = EnrichStormEvent(StormEvent{Comments: "Report from radar (ABCDE)"})
+	assert.Equal(t, "ABCDE", event.SourceOffice)
+	assert.Nil(t, event.SourceOfficeDetail)
+}
+
+func TestEnrichStormEventAudited(t *testing.T) {
+	event := StormEvent{
+		EventType:   "hail",
+		Geo:         Geo{Lat: 30.3, Lon: -97.8},
+		Measurement: Measurement{Magnitude: 175},
+		Comments:    "Large hail reported. (SJT)",
+		Location:    Location{Raw: testLocationNW},
+	}
+
+	audited, steps := EnrichStormEventAudited(event)
+	audited.ProcessedAt, event = time.Time{}, EnrichStormEvent(event)
+	event.ProcessedAt = time.Time{}
+	assert.Equal(t, event, audited, "auditing must not change the result")
+
+	byStep := make(map[string]AuditStep, len(steps))
+	for _, s := range steps {
+		byStep[s.Step] = s
+	}
+	assert.Equal(t, AuditStep{Step: "unit", To: "in", Reason: "default unit for hail"}, byStep["unit"])
+	assert.Equal(t, "175", byStep["magnitude"].From)
+	assert.Equal(t, "1.75", byStep["magnitude"].To)
+	assert.Equal(t, "1.75 in", byStep["severity"].From)
+	assert.Equal(t, "severe", byStep["severity"].To)
+	assert.Contains(t, byStep["severity"].Reason, "hail thresholds")
+	assert.Equal(t, AuditStep{Step: "source_office", To: "SJT", Reason: "San Angelo, TX"}, byStep["source_office"])
+	assert.Equal(t, "AUSTIN", byStep["location"].To)
+	assert.Contains(t, byStep, "place_geo")
+	assert.NotContains(t, byStep, "event_type", "canonical names are not rewritten")
+	assert.NotContains(t, byStep, "units", "imperial output is not converted")
+
+	_, steps = EnrichStormEventAudited(StormEvent{EventType: "flash flood"})
+	require.NotEmpty(t, steps)
+	assert.Equal(t, AuditStep{Step: "event_type", From: "flash flood", To: "flash_flood", Reason: "registered alias"}, steps[0])
+}
+
+func TestEventTypes(t *testing.T) {
+	assert.Equal(t, []string{"flash_flood", "flood", "hail", "heavy_snow", "lightning", "tornado", "wind"}, EventTypes())
+	assert.Equal(t, []string{"flash flood", "flash_flood", "flood", "hail", "heavy snow", "heavy_snow", "lightning", "tornado", "wind"}, AcceptedEventTypes())
+}
+
 func TestExtractSourceOffice(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -525,3 +1000,605 @@ func TestSetClock(t *testing.T) {
 		assert.Less(t, time.Since(now), time.Second)
 	})
 }
+
+func TestValidateStormEvent(t *testing.T) {
+	valid := StormEvent{EventType: "flash flood", Geo: Geo{Lat: 31.02, Lon: -98.44}}
+	require.NoError(t, ValidateStormEvent(valid))
+
+	cases := []struct {
+		name  string
+		event StormEvent
+		want  error
+	}{
+		{"unknown type", StormEvent{EventType: "earthquake", Geo: valid.Geo}, ErrUnknownEventType},
+		{"empty type", StormEvent{Geo: valid.Geo}, ErrUnknownEventType},
+		{"missing coordinates", StormEvent{EventType: "hail"}, ErrInvalidCoordinates},
+		{"latitude out of range", StormEvent{EventType: "hail", Geo: Geo{Lat: 95, Lon: -98}}, ErrInvalidCoordinates},
+		{"longitude out of range", StormEvent{EventType: "hail", Geo: Geo{Lat: 31, Lon: -198}}, ErrInvalidCoordinates},
+		{"latitude out of range outside the US when swapped", StormEvent{EventType: "hail", Geo: Geo{Lat: -120, Lon: 60}}, ErrInvalidCoordinates},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorIs(t, ValidateStormEvent(tc.event), tc.want)
+		})
+	}
+
+	swapped := StormEvent{EventType: "hail", Geo: Geo{Lat: -98.44, Lon: 31.02}}
+	require.NoError(t, ValidateStormEvent(swapped), "swapped coordinates are corrected during enrichment")
+
+	_, err := ParseRawEvent(RawEvent{Value: []byte("not-json{{{")})
+	require.ErrorIs(t, err, ErrInvalidPayload)
+	assert.Contains(t, err.Error(), "parse raw event: invalid character")
+}
+
+func TestCorrectCoordinates(t *testing.T) {
+	cases := []struct {
+		name   string
+		in     Geo
+		want   Geo
+		reason string
+	}{
+		{"conus unchanged", Geo{Lat: 35.2, Lon: -97.4}, Geo{Lat: 35.2, Lon: -97.4}, ""},
+		{"guam unchanged", Geo{Lat: 13.47, Lon: 144.75}, Geo{Lat: 13.47, Lon: 144.75}, ""},
+		{"positive conus longitude", Geo{Lat: 35.2, Lon: 97.4}, Geo{Lat: 35.2, Lon: -97.4}, "longitude sign flipped"},
+		{"swapped", Geo{Lat: -97.4, Lon: 35.2}, Geo{Lat: 35.2, Lon: -97.4}, "latitude and longitude swapped"},
+		{"swapped and positive", Geo{Lat: 97.4, Lon: 35.2}, Geo{Lat: 35.2, Lon: -97.4}, "latitude and longitude swapped, longitude sign flipped"},
+		{"indian ocean", Geo{Lat: -20, Lon: 75}, Geo{Lat: -20, Lon: 75}, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, reason := correctCoordinates(tc.in)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.reason, reason)
+		})
+	}
+}
+
+func TestEnrichStormEvent_Coordinates(t *testing.T) {
+	event := StormEvent{EventType: "hail", Geo: Geo{Lat: 35.2, Lon: 97.4}, Measurement: Measurement{Magnitude: 1}}
+	enriched, steps := EnrichStormEventAudited(event)
+	assert.Equal(t, Geo{Lat: 35.2, Lon: -97.4}, enriched.Geo)
+	assert.Equal(t, &DataQuality{Inferred: []string{"geo"}}, enriched.Quality)
+	assert.Contains(t, steps, AuditStep{Step: "geo", From: "35.2,97.4", To: "35.2,-97.4", Reason: "longitude sign flipped"})
+
+	event.Geo = Geo{Lat: -20, Lon: 75}
+	enriched = EnrichStormEvent(event)
+	assert.Equal(t, Geo{Lat: -20, Lon: 75}, enriched.Geo, "uncorrectable coordinates are kept")
+	assert.Equal(t, &DataQuality{Flags: []string{FlagOutsideUS}}, enriched.Quality)
+	assert.Equal(t, []ValidationRule{{Check: FlagOutsideUS, Action: RuleActionDrop}},
+		Violations([]ValidationRule{{Check: FlagOutsideUS, Action: RuleActionDrop}}, enriched))
+}
+
+func TestNormalizeState(t *testing.T) {
+	cases := map[string]string{
+		"TX":                   "TX",
+		"tx":                   "TX",
+		"Texas":                "TX",
+		" north  carolina ":    "NC",
+		"D.C.":                 "DC",
+		"District of Columbia": "DC",
+		"puerto rico":          "PR",
+	}
+	for in, want := range cases {
+		got, ok := normalizeState(in)
+		assert.True(t, ok, in)
+		assert.Equal(t, want, got, in)
+	}
+	for _, bad := range []string{"", "XX", "Texass", "Ontario"} {
+		_, ok := normalizeState(bad)
+		assert.False(t, ok, bad)
+	}
+}
+
+func TestNormalizeCounty(t *testing.T) {
+	cases := []struct{ state, in, want string }{
+		{"TX", "San Saba", "San Saba"},
+		{"IL", "ST. CLAIR", "Saint Clair"},
+		{"MI", "St Clair County", "Saint Clair"},
+		{"AL", "saint clair", "Saint Clair"},
+		{"LA", "ORLEANS PARISH", "Orleans"},
+		{"TX", "MCLENNAN", "McLennan"},
+		{"GA", "DEKALB", "DeKalb"},
+		{"IN", "DE KALB", "DeKalb"},
+		{"MD", "PRINCE GEORGES", "Prince George's"},
+		{"VA", "ISLE OF WIGHT", "Isle of Wight"},
+		{"FL", "MIAMI DADE", "Miami-Dade"},
+		{"MN", "lac qui parle co.", "Lac qui Parle"},
+		{"TX", "FT. BEND", "Fort Bend"},
+		{"OK", "  pittsburg  ", "Pittsburg"},
+		{"AK", "Matanuska-Susitna Borough", "Matanuska-Susitna"},
+		{"AK", "Juneau City and Borough", "Juneau"},
+		{"AK", "Bethel Census Area", "Bethel"},
+		{"NM", "DONA ANA", "Doña Ana"},
+		{"MO", "STE. GENEVIEVE", "Sainte Genevieve"},
+		{"MO", "ST. LOUIS CITY", "Saint Louis City"},
+		{"VA", "Richmond city", "Richmond City"},
+		{"", "DEKALB", "DeKalb"},
+		{"PR", "SAN JUAN", "San Juan"},
+	}
+	for _, c := range cases {
+		got, ok := normalizeCounty(c.state, c.in)
+		assert.True(t, ok, c.in)
+		assert.Equal(t, c.want, got, c.in)
+	}
+
+	got, ok := normalizeCounty("OK", "PITTSBURGH")
+	assert.False(t, ok, "not an Oklahoma county")
+	assert.Equal(t, "Pittsburgh", got)
+	_, ok = normalizeCounty("KS", "Cleveland")
+	assert.False(t, ok, "Cleveland is in OK, not KS")
+
+	got, ok = normalizeCounty("TX", "  ")
+	assert.True(t, ok)
+	assert.Empty(t, got)
+}
+
+func TestCountyNames(t *testing.T) {
+	// Census 2020 county equivalents, including independent cities.
+	totals := map[string]int{"TX": 254, "GA": 159, "VA": 133, "KY": 120, "MO": 115, "AK": 30, "DC": 1}
+	for state, n := range totals {
+		assert.Len(t, countyNames[state], n, state)
+	}
+	var all int
+	for state, names := range countyNames {
+		if state != "" {
+			_, ok := normalizeState(state)
+			assert.True(t, ok, state)
+			all += len(names)
+		}
+	}
+	assert.Equal(t, 3143, all)
+	assert.Len(t, countyNames, 52, "50 states, DC, and the all-states index")
+}
+
+func TestEnrichStormEvent_County(t *testing.T) {
+	event := StormEvent{EventType: "hail", Geo: Geo{Lat: 35.22, Lon: -97.44}, Location: Location{State: "OK", County: "CLEVELAND"}}
+	enriched, steps := EnrichStormEventAudited(event)
+	assert.Equal(t, "Cleveland", enriched.Location.County)
+	assert.Nil(t, enriched.Quality)
+	assert.Contains(t, steps, AuditStep{Step: "county", From: "CLEVELAND", To: "Cleveland", Reason: "normalized"})
+
+	event.Location.County = "Clevland"
+	enriched, steps = EnrichStormEventAudited(event)
+	assert.Equal(t, "Clevland", enriched.Location.County, "unknown counties are kept")
+	assert.Equal(t, &DataQuality{Flags: []string{FlagUnknownCounty}}, enriched.Quality)
+	assert.Contains(t, steps, AuditStep{Step: "county", From: "Clevland", To: "Clevland", Reason: "not a county of OK"})
+	require.Len(t, Violations([]ValidationRule{{Check: FlagUnknownCounty, Action: RuleActionDrop}}, enriched), 1)
+}
+
+func TestEnrichStormEvent_State(t *testing.T) {
+	event := StormEvent{EventType: "hail", Geo: Geo{Lat: 31.02, Lon: -98.44}, Location: Location{State: "texas"}}
+	enriched, steps := EnrichStormEventAudited(event)
+	assert.Equal(t, "TX", enriched.Location.State)
+	assert.Nil(t, enriched.Quality)
+	assert.Contains(t, steps, AuditStep{Step: "state", From: "texas", To: "TX", Reason: "normalized to USPS code"})
+
+	event.Location.State = "Tejas"
+	enriched, steps = EnrichStormEventAudited(event)
+	assert.Empty(t, enriched.Location.State, "unknown values are not passed through")
+	assert.Equal(t, &DataQuality{Flags: []string{FlagUnknownState}}, enriched.Quality)
+	assert.Contains(t, steps, AuditStep{Step: "state", From: "Tejas", Reason: "not a US state or territory"})
+
+	var failed []string
+	for _, r := range Violations([]ValidationRule{{Check: "missing_state", Action: RuleActionPass}, {Check: FlagUnknownState, Action: RuleActionDrop}}, enriched) {
+		failed = append(failed, r.Check)
+	}
+	assert.Equal(t, []string{"missing_state", FlagUnknownState}, failed)
+}
+
+func TestMergeTolerance_IsNearDuplicate(t *testing.T) {
+	at := time.Date(2024, 4, 26, 18, 0, 0, 0, time.UTC)
+	tol := MergeTolerance{Miles: 2, Window: 10 * time.Minute}
+	base := StormEvent{ID: "a", EventType: "hail", SourceOffice: "OUN", EventTime: at, Geo: Geo{Lat: 35.0, Lon: -97.0}}
+
+	cases := []struct {
+		name  string
+		other func(*StormEvent)
+		want  bool
+	}{
+		{"adjacent office", func(e *StormEvent) { e.SourceOffice = "TSA"; e.Geo.Lat = 35.02 }, true},
+		{"unknown office", func(e *StormEvent) { e.SourceOffice = "" }, true},
+		{"same office", func(e *StormEvent) {}, false},
+		{"same id", func(e *StormEvent) { e.ID = "a"; e.SourceOffice = "TSA" }, false},
+		{"other type", func(e *StormEvent) { e.SourceOffice = "TSA"; e.EventType = "wind" }, false},
+		{"too far", func(e *StormEvent) { e.SourceOffice = "TSA"; e.Geo.Lat = 35.05 }, false},
+		{"too late", func(e *StormEvent) { e.SourceOffice = "TSA"; e.EventTime = at.Add(11 * time.Minute) }, false},
+		{"too early", func(e *StormEvent) { e.SourceOffice = "TSA"; e.EventTime = at.Add(-11 * time.Minute) }, false},
+		{"retraction", func(e *StormEvent) { e.SourceOffice = "TSA"; e.Deleted = true }, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			other := base
+			other.ID = "b"
+			tc.other(&other)
+			assert.Equal(t, tc.want, tol.IsNearDuplicate(base, other))
+		})
+	}
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	estimated := StormEvent{ID: "a", Measurement: Measurement{Magnitude: 1, Method: MethodEstimated}, MergedFrom: []string{"x"}}
+	measured := StormEvent{ID: "b", Measurement: Measurement{Magnitude: 1, Method: MethodMeasured}}
+
+	kept, dropped := MergeDuplicates(estimated, measured)
+	assert.Equal(t, "b", kept.ID)
+	assert.Equal(t, "a", dropped.ID)
+	assert.Equal(t, []string{"a", "x"}, kept.MergedFrom)
+
+	inferred := measured
+	inferred.ID, inferred.Quality = "c", &DataQuality{Inferred: []string{"geo"}}
+	kept, _ = MergeDuplicates(inferred, estimated)
+	assert.Equal(t, "a", kept.ID, "an inferred value outweighs the method")
+	assert.Equal(t, []string{"x", "c"}, kept.MergedFrom)
+
+	kept, _ = MergeDuplicates(measured, StormEvent{ID: "d", Measurement: measured.Measurement})
+	assert.Equal(t, "b", kept.ID, "ties keep the first report")
+}
+
+func TestEpisodeTolerance_SameEpisode(t *testing.T) {
+	at := time.Date(2024, 4, 26, 18, 0, 0, 0, time.UTC)
+	tol := EpisodeTolerance{Miles: 20, Window: 30 * time.Minute}
+	hail := StormEvent{ID: "a", EventType: "hail", EventTime: at, Geo: Geo{Lat: 35.0, Lon: -98.0}}
+
+	wind := StormEvent{ID: "b", EventType: "wind", EventTime: at.Add(20 * time.Minute), Geo: Geo{Lat: 35.1, Lon: -97.8}}
+	assert.True(t, tol.SameEpisode(hail, wind))
+	assert.True(t, tol.SameEpisode(wind, hail))
+
+	far := wind
+	far.Geo.Lon = -97.5
+	assert.False(t, tol.SameEpisode(hail, far))
+
+	late := wind
+	late.EventTime = at.Add(31 * time.Minute)
+	assert.False(t, tol.SameEpisode(hail, late))
+
+	wind.Deleted = true
+	assert.False(t, tol.SameEpisode(hail, wind))
+
+	assert.Equal(t, NewEpisodeID(hail), NewEpisodeID(StormEvent{ID: "a"}), "the ID depends only on the first report's ID")
+	assert.Regexp(t, `^episode-[0-9a-f]{16}$`, NewEpisodeID(hail))
+}
+
+func TestNearestCity(t *testing.T) {
+	city := nearestCity(Geo{Lat: 35.10, Lon: -97.20})
+	require.NotNil(t, city)
+	assert.Equal(t, "Norman", city.Name)
+	assert.Equal(t, "OK", city.State)
+	assert.InDelta(t, 15.9, city.Distance, 0.001)
+	assert.Equal(t, "ESE", city.Direction)
+
+	assert.Nil(t, nearestCity(Geo{}))
+	assert.Len(t, places, 237)
+}
+
+func TestCompassDirection(t *testing.T) {
+	assert.Equal(t, "N", compassDirection(0))
+	assert.Equal(t, "N", compassDirection(355))
+	assert.Equal(t, "NNE", compassDirection(20))
+	assert.Equal(t, "E", compassDirection(bearingDegrees(Geo{Lat: 35, Lon: -98}, Geo{Lat: 35, Lon: -97})))
+	assert.Equal(t, "S", compassDirection(bearingDegrees(Geo{Lat: 35, Lon: -98}, Geo{Lat: 34, Lon: -98})))
+}
+
+func TestEnrichStormEvent_NearestCity(t *testing.T) {
+	event := EnrichStormEvent(StormEvent{Geo: Geo{Lat: 35.10, Lon: -97.20}})
+	require.NotNil(t, event.NearestCity)
+	assert.Equal(t, "Norman", event.NearestCity.Name)
+
+	assert.Nil(t, EnrichStormEvent(StormEvent{}).NearestCity)
+}
+
+func TestNearbyPlaces(t *testing.T) {
+	okc := nearbyPlaces(Geo{Lat: 35.35, Lon: -97.48}, DefaultNearbyPlacesRadius)
+	require.NotNil(t, okc)
+	assert.Equal(t, NearbyPlaces{Population: 681054 + 128026, Places: 2, Radius: DefaultNearbyPlacesRadius}, *okc)
+
+	okc = nearbyPlaces(Geo{Lat: 35.35, Lon: -97.48}, 5)
+	require.NotNil(t, okc)
+	assert.Zero(t, okc.Population, "no place lies within 5 miles")
+	assert.InDelta(t, 5.0, okc.Radius, 0)
+
+	assert.Nil(t, nearbyPlaces(Geo{}, DefaultNearbyPlacesRadius))
+}
+
+func TestParseBoundaries(t *testing.T) {
+	f, err := os.Open("testdata/nws_boundaries.geojson")
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	b, err := ParseBoundaries(f)
+	require.NoError(t, err)
+	cwa, zone := b.lookup(Geo{Lat: 35.22, Lon: -97.44})
+	assert.Equal(t, "OUN", cwa)
+	assert.Equal(t, "OKZ025", zone)
+
+	cwa, zone = b.lookup(Geo{Lat: 35.05, Lon: -97.25})
+	assert.Equal(t, "OUN", cwa)
+	assert.Empty(t, zone, "a point in a hole is outside the zone")
+
+	cwa, zone = b.lookup(Geo{Lat: 40.0, Lon: -97.44})
+	assert.Empty(t, cwa)
+	assert.Empty(t, zone)
+
+	event := Enrichment{Boundaries: b}.EnrichStormEvent(StormEvent{Geo: Geo{Lat: 35.22, Lon: -97.44}})
+	assert.Equal(t, "OUN", event.Location.CWA)
+	assert.Equal(t, "OKZ025", event.Location.Zone)
+
+	require.NotNil(t, EmbeddedBoundaries(), "the embedded file must parse")
+
+	_, err = ParseBoundaries(strings.NewReader(`{"features":[{"properties":{"CWA":"OUN"},"geometry":{"type":"Point","coordinates":[-97,35]}}]}`))
+	require.ErrorContains(t, err, "unsupported geometry type")
+	_, err = ParseBoundaries(strings.NewReader(`{"features":[{"properties":{},"geometry":{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}}]}`))
+	require.ErrorContains(t, err, "no CWA")
+}
+
+func TestEmbeddedBoundaries_Norman(t *testing.T) {
+	b := EmbeddedBoundaries()
+	if len(b.cwas) == 0 {
+		t.Skip("nws_boundaries.geojson has no features; run make boundaries")
+	}
+	cwa, zone := b.lookup(Geo{Lat: 35.22, Lon: -97.44})
+	assert.Equal(t, "OUN", cwa)
+	assert.Equal(t, "OKZ025", zone)
+}
+
+func TestEncodeGeohash(t *testing.T) {
+	assert.Equal(t, "9y6", EncodeGeohash(Geo{Lat: 35.22, Lon: -97.44}, 3))
+	assert.Equal(t, "9y68qew", EncodeGeohash(Geo{Lat: 35.22, Lon: -97.44}, DefaultGeohashPrecision))
+	assert.Equal(t, "u4pruydqqvj", EncodeGeohash(Geo{Lat: 57.64911, Lon: 10.40744}, 11))
+	assert.Len(t, EncodeGeohash(Geo{Lat: 35.22, Lon: -97.44}, 20), MaxGeohashPrecision)
+	assert.Empty(t, EncodeGeohash(Geo{}, 7))
+
+	event := Enrichment{GeohashPrecision: 5}.EnrichStormEvent(StormEvent{Geo: Geo{Lat: 35.22, Lon: -97.44}})
+	assert.Equal(t, "9y68q", event.Geohash)
+}
+
+func TestParseFieldProjection(t *testing.T) {
+	p, err := ParseFieldProjection("")
+	require.NoError(t, err)
+	assert.Nil(t, p)
+
+	for _, spec := range []string{"id, -comments", "location.raw", "measurement.metric.unit", "-nearest_city.geo.lat"} {
+		_, err := ParseFieldProjection(spec)
+		require.NoError(t, err, spec)
+	}
+	for _, spec := range []string{"raw_payload", "location.rawtext", "comments.text", "id,,comments", "-"} {
+		_, err := ParseFieldProjection(spec)
+		require.Error(t, err, spec)
+	}
+}
+
+func TestFieldProjection_Apply(t *testing.T) {
+	event := StormEvent{
+		ID:        "hail-1",
+		EventType: "hail",
+		Geo:       Geo{Lat: 35.22, Lon: -97.44},
+		Comments:  "Reported by a trained spotter at 123 Main St.",
+		Location:  Location{Raw: "2 N Norman", Name: "Norman", State: "OK"},
+		EventTime: time.Date(2024, time.April, 26, 15, 10, 0, 0, time.UTC),
+	}
+
+	var p *FieldProjection
+	got, err := p.Apply(event)
+	require.NoError(t, err)
+	assert.Equal(t, event, got, "a nil projection keeps the event")
+
+	p, err = ParseFieldProjection("-comments,-location.raw,-location.name,-location.state")
+	require.NoError(t, err)
+	got, err = p.Apply(event)
+	require.NoError(t, err)
+	fields := got.(map[string]any)
+	assert.Equal(t, "hail-1", fields["id"])
+	assert.NotContains(t, fields, "comments")
+	assert.NotContains(t, fields, "location", "an object emptied by exclusions is removed")
+	assert.Contains(t, fields, "processed_at")
+
+	p, err = ParseFieldProjection("id,geo.lat,location,-location.raw")
+	require.NoError(t, err)
+	got, err = p.Apply(event)
+	require.NoError(t, err)
+	data, err := json.Marshal(got)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"hail-1","geo":{"lat":35.22},"location":{"name":"Norman","state":"OK"}}`, string(data))
+}
+
+func TestFieldProjection_Style(t *testing.T) {
+	event := StormEvent{
+		ID:          "hail-1",
+		EventType:   "hail",
+		Geo:         Geo{Lat: 35.22, Lon: -97.44},
+		Location:    Location{Raw: "2 N Norman", State: "OK"},
+		WarningIDs:  []string{"urn:oid:active"},
+		Provenance:  &Provenance{Headers: map[string]string{"x_source": "spotter"}},
+		ProcessedAt: time.Date(2024, time.April, 26, 15, 12, 0, 0, time.UTC),
+	}
+	assert.Nil(t, (*FieldProjection)(nil).WithStyle(NamingSnake, false))
+
+	p, err := ParseFieldProjection("id,event_type,location,warning_ids,provenance.headers")
+	require.NoError(t, err)
+	got, err := p.WithStyle(NamingCamel, false).Apply(event)
+	require.NoError(t, err)
+	data, err := json.Marshal(got)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"hail-1","eventType":"hail","location":{"raw":"2 N Norman","state":"OK"},
+		"warningIds":["urn:oid:active"],"provenance":{"headers":{"x_source":"spotter"}}}`, string(data),
+		"header names are data and keep their case")
+
+	got, err = (*FieldProjection)(nil).WithStyle(NamingCamel, true).Apply(event)
+	require.NoError(t, err)
+	fields := got.(map[string]any)
+	assert.Equal(t, "2 N Norman", fields["locationRaw"])
+	assert.Equal(t, map[string]any{"x_source": "spotter"}, fields["provenanceHeaders"])
+	assert.Contains(t, fields, "geoLat")
+	assert.Equal(t, "2024-04-26T15:12:00Z", fields["processedAt"], "times are values, not objects")
+
+	got, err = (*FieldProjection)(nil).WithStyle(NamingSnake, true).Apply(event)
+	require.NoError(t, err)
+	fields = got.(map[string]any)
+	assert.Equal(t, "OK", fields["location_state"])
+	assert.NotContains(t, fields, "location")
+}
+
+func TestRedactor(t *testing.T) {
+	r := NewRedactor(nil, nil)
+	for _, tc := range []struct {
+		in, want string
+		counts   map[string]int
+	}{
+		{"Call (405) 555-0142 or 405.555.0199 for photos.", "Call [REDACTED] or [REDACTED] for photos.", map[string]int{"phone": 2}},
+		{"Spotter +1 405-555-0142, jdoe@example.com.", "Spotter [REDACTED], [REDACTED].", map[string]int{"phone": 1, "email": 1}},
+		{"1.75 inch hail at 1510. 2 trees down on Hwy 9.", "1.75 inch hail at 1510. 2 trees down on Hwy 9.", nil},
+		{"", "", nil},
+	} {
+		got, counts := r.Redact(tc.in)
+		assert.Equal(t, tc.want, got, tc.in)
+		assert.Equal(t, tc.counts, counts, tc.in)
+	}
+
+	extra, err := ParseRedactionPatterns(`name=\b(?:Mr|Mrs|Ms)\. [A-Z][a-z]+; badge=[A-Z]{2}-[0-9]{3}`)
+	require.NoError(t, err)
+	r = NewRedactor(extra, []string{"Mr. Weather"})
+	got, counts := r.Redact("Mrs. Jones (badge OK-123) and Mr. Weather reported damage.")
+	assert.Equal(t, "[REDACTED] (badge [REDACTED]) and Mr. Weather reported damage.", got)
+	assert.Equal(t, map[string]int{"name": 1, "badge": 1}, counts)
+}
+
+func TestParseRedactionPatterns(t *testing.T) {
+	patterns, err := ParseRedactionPatterns("")
+	require.NoError(t, err)
+	assert.Empty(t, patterns)
+
+	for _, spec := range []string{"spotter", "Spotter=x", "phone=[0-9]+", "a=x;a=y", "a=(", "a=x*"} {
+		_, err := ParseRedactionPatterns(spec)
+		require.Error(t, err, spec)
+	}
+}
+
+func TestTruncateComments(t *testing.T) {
+	e := StormEvent{Comments: "Hail covered the ground near Café Rio.", Quality: &DataQuality{Flags: []string{"missing_time"}}}
+	out := TruncateComments(e, 3)
+	assert.Equal(t, "Hail covered the ground near Caf…", out.Comments, "the cut backs off to a character boundary")
+	assert.LessOrEqual(t, len(out.Comments), len(e.Comments)-3)
+	assert.Equal(t, []string{"missing_time", FlagCommentsTruncated}, out.Quality.Flags)
+	assert.Equal(t, []string{"missing_time"}, e.Quality.Flags, "the original event is unchanged")
+
+	assert.Empty(t, TruncateComments(e, 100).Comments)
+	assert.Equal(t, StormEvent{}, TruncateComments(StormEvent{}, 10))
+}
+
+func TestDropRawText(t *testing.T) {
+	e := StormEvent{Comments: "Trees down.", Location: Location{Raw: "8 ESE Chappel", Name: "Chappel"}}
+	out := DropRawText(e)
+	assert.Empty(t, out.Comments)
+	assert.Equal(t, Location{Name: "Chappel"}, out.Location)
+	assert.Equal(t, []string{FlagRawTextDropped}, out.Quality.Flags)
+	assert.Nil(t, DropRawText(StormEvent{ID: "evt-1"}).Quality)
+}
+
+func TestParseValidationRules(t *testing.T) {
+	rules, err := ParseValidationRules(" missing_magnitude = annotate ; FUTURE_TIME=Quarantine;")
+	require.NoError(t, err)
+	assert.Equal(t, []ValidationRule{
+		{Check: "missing_magnitude", Action: RuleActionAnnotate},
+		{Check: "future_time", Action: RuleActionQuarantine},
+	}, rules)
+
+	rules, err = ParseValidationRules("")
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	for _, bad := range []string{"missing_magnitude", "no_such_check=drop", "missing_state=reject", "missing_state=drop;missing_state=pass"} {
+		_, err := ParseValidationRules(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestViolations(t *testing.T) {
+	fc := clockwork.NewFakeClockAt(time.Date(2024, 4, 26, 18, 0, 0, 0, time.UTC))
+	SetClock(fc)
+	t.Cleanup(func() { SetClock(nil) })
+
+	raw := `{"Time":"","Size":"1,75","Location":"8 ESE Chappel","State":"TX","Lat":"31.02","Lon":"-98.44","Comments":"Hail. (ZZZ)","EventType":"hail"}`
+	event, err := ParseRawEvent(RawEvent{Value: []byte(raw), Timestamp: fc.Now().Add(2 * time.Hour)})
+	require.NoError(t, err)
+	event = EnrichStormEvent(event)
+
+	all := make([]ValidationRule, 0, len(ValidationChecks()))
+	for _, check := range ValidationChecks() {
+		all = append(all, ValidationRule{Check: check, Action: RuleActionPass})
+	}
+	var failed []string
+	for _, r := range Violations(all, event) {
+		failed = append(failed, r.Check)
+	}
+	assert.Equal(t, []string{"future_time", "magnitude_unparsed", "missing_magnitude", "missing_time", "unknown_office"}, failed)
+
+	event.Location = Location{}
+	assert.Len(t, Violations([]ValidationRule{{Check: "missing_location", Action: RuleActionDrop}, {Check: "missing_state", Action: RuleActionAnnotate}}, event), 2)
+}
+
+func TestEventFilter(t *testing.T) {
+	severe := "severe"
+	minor := "minor"
+	txTornado := StormEvent{EventType: "tornado", Geo: Geo{Lat: 31.0, Lon: -98.4}, Location: Location{State: "TX"}, Measurement: Measurement{Severity: &severe}}
+
+	bbox, err := ParseBoundingBox("25.8, -106.7, 36.5, -93.5")
+	require.NoError(t, err)
+	level, err := ParseSeverityLevel("Moderate")
+	require.NoError(t, err)
+	f := EventFilter{States: []string{"tx", "ok"}, ExcludeStates: []string{"OK"}, EventTypes: []string{"tornado", "flash_flood"}, MinSeverity: level, BBox: &bbox}
+	assert.True(t, f.Active())
+
+	ok, reason := f.Match(txTornado)
+	assert.True(t, ok)
+	assert.Empty(t, reason)
+
+	cases := []struct {
+		name   string
+		modify func(*StormEvent)
+		reason string
+	}{
+		{"state not allowed", func(e *StormEvent) { e.Location.State = "KS" }, FilterReasonState},
+		{"state excluded", func(e *StormEvent) { e.Location.State = "OK" }, FilterReasonState},
+		{"type not allowed", func(e *StormEvent) { e.EventType = "hail" }, FilterReasonEventType},
+		{"below minimum severity", func(e *StormEvent) { e.Measurement.Severity = &minor }, FilterReasonSeverity},
+		{"no severity", func(e *StormEvent) { e.Measurement.Severity = nil }, FilterReasonSeverity},
+		{"outside bounding box", func(e *StormEvent) { e.Geo = Geo{Lat: 40.0, Lon: -98.4} }, FilterReasonBBox},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			event := txTornado
+			tc.modify(&event)
+			ok, reason := f.Match(event)
+			assert.False(t, ok)
+			assert.Equal(t, tc.reason, reason)
+		})
+	}
+
+	var none EventFilter
+	assert.False(t, none.Active())
+	ok, _ = none.Match(StormEvent{})
+	assert.True(t, ok, "zero filter matches everything")
+
+	_, err = ParseSeverityLevel("catastrophic")
+	require.ErrorContains(t, err, "unknown severity")
+	for _, bad := range []string{"1,2,3", "a,b,c,d", "40,-100,30,-90", "-91,0,0,0"} {
+		_, err = ParseBoundingBox(bad)
+		require.Error(t, err, bad)
+	}
+}
+
+func BenchmarkParseRawEvent(b *testing.B) {
+	data, err := os.ReadFile(mockFixture)
+	require.NoError(b, err)
+	var records []json.RawMessage
+	require.NoError(b, json.Unmarshal(data, &records))
+	ts := time.Date(2024, 4, 26, 0, 0, 0, 0, time.UTC)
+
+	b.ReportAllocs()
+	i := 0
+	for b.Loop() {
+		if _, err := ParseRawEvent(RawEvent{Value: records[i%len(records)], Timestamp: ts}); err != nil {
+			b.Fatal(err)
+		}
+		i++
+	}
+}
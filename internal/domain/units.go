@@ -0,0 +1,50 @@
+package domain
+
+import "math"
+
+// UnitSystem selects which units EnrichStormEvent emits magnitudes in.
+type UnitSystem string
+
+// Supported unit systems. Imperial matches the SPC source data.
+const (
+	UnitsImperial UnitSystem = "imperial"
+	UnitsMetric   UnitSystem = "metric"
+	UnitsBoth     UnitSystem = "both"
+)
+
+// metricConversion converts a type's default imperial unit to its metric equivalent.
+type metricConversion struct {
+	Unit   string
+	Factor float64
+}
+
+// convertUnits applies unit system u to a measurement. Severity
+// is derived beforehand from the imperial value, so it is the same in every
+// mode. Magnitudes in a unit other than the type's default (e.g. hail already
+// reported in cm) and types without a metric equivalent are left unchanged.
+func convertUnits(u UnitSystem, eventType string, m Measurement) Measurement {
+	if u == UnitsImperial || m.Magnitude == 0 {
+		return m
+	}
+	spec, ok := lookupEventType(eventType)
+	if !ok || spec.Metric == nil || m.Unit != spec.DefaultUnit {
+		return m
+	}
+
+	metric := Quantity{
+		Magnitude: roundTo(m.Magnitude*spec.Metric.Factor, 2),
+		Unit:      spec.Metric.Unit,
+	}
+	if u == UnitsBoth {
+		m.Metric = &metric
+		return m
+	}
+	m.Magnitude, m.Unit = metric.Magnitude, metric.Unit
+	return m
+}
+
+// roundTo rounds v to the given number of decimal places.
+func roundTo(v float64, places int) float64 {
+	p := math.Pow10(places)
+	return math.Round(v*p) / p
+}
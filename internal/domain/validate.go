@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Transform failure categories. Errors from ParseRawEvent and
+// ValidateStormEvent wrap exactly one of these, so callers can tell bad input
+// apart from unexpected failures with errors.Is.
+var (
+	ErrInvalidPayload     = errors.New("parse raw event")
+	ErrUnknownEventType   = errors.New("unknown event type")
+	ErrInvalidCoordinates = errors.New("invalid coordinates")
+)
+
+// ValidateStormEvent rejects parsed events that cannot be enriched into a
+// usable record: unregistered event types, and coordinates that are missing
+// (0, 0), unparseable, or out of range. Out-of-range coordinates that
+// enrichment can correct, such as a swapped latitude and longitude, pass.
+func ValidateStormEvent(event StormEvent) error {
+	if CanonicalEventType(event.EventType) == "" {
+		return fmt.Errorf("%w %q", ErrUnknownEventType, event.EventType)
+	}
+	if !coordinatesInRange(event.Geo) {
+		if event.Geo == (Geo{}) {
+			return fmt.Errorf("%w: lat %g, lon %g", ErrInvalidCoordinates, event.Geo.Lat, event.Geo.Lon)
+		}
+		if _, reason := correctCoordinates(event.Geo); reason == "" {
+			return fmt.Errorf("%w: lat %g, lon %g", ErrInvalidCoordinates, event.Geo.Lat, event.Geo.Lon)
+		}
+	}
+	return nil
+}
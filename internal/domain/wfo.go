@@ -0,0 +1,60 @@
+package domain
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// wfoCSV lists NWS Weather Forecast Offices: code, name, state, and the
+// approximate coordinates of the office. Offices are rarely added or moved;
+// update the file when NWS announces a change.
+//
+//go:embed wfo.csv
+var wfoCSV string
+
+// wfoOffices indexes wfo.csv by office code.
+var wfoOffices = mustParseWFOs(wfoCSV)
+
+// lookupSourceOffice returns metadata for a WFO code. Four-letter ICAO-style
+// identifiers (KOUN, PAFC) are also accepted by dropping the leading letter.
+// Returns nil for unknown codes.
+func lookupSourceOffice(code string) *SourceOfficeDetail {
+	if code == "" {
+		return nil
+	}
+	office, ok := wfoOffices[code]
+	if !ok && len(code) == 4 {
+		office, ok = wfoOffices[code[1:]]
+	}
+	if !ok {
+		return nil
+	}
+	return &office
+}
+
+// mustParseWFOs parses the embedded table. It panics on malformed rows since
+// the file ships with the binary and is covered by tests.
+func mustParseWFOs(data string) map[string]SourceOfficeDetail {
+	rows, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("parse wfo.csv: %v", err))
+	}
+	offices := make(map[string]SourceOfficeDetail, len(rows))
+	for i, row := range rows[1:] {
+		lat, errLat := strconv.ParseFloat(row[3], 64)
+		lon, errLon := strconv.ParseFloat(row[4], 64)
+		if errLat != nil || errLon != nil {
+			panic(fmt.Sprintf("parse wfo.csv row %d: invalid coordinates", i+2))
+		}
+		offices[row[0]] = SourceOfficeDetail{
+			Code:  row[0],
+			Name:  row[1],
+			State: row[2],
+			Geo:   Geo{Lat: lat, Lon: lon},
+		}
+	}
+	return offices
+}
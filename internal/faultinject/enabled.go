@@ -0,0 +1,6 @@
+//go:build faultinject
+
+package faultinject
+
+// enabled is set in binaries built with the faultinject tag.
+const enabled = true
@@ -0,0 +1,185 @@
+// Package faultinject injects faults into the running service so chaos tests
+// can drive its backoff, dead-letter, and circuit-breaker paths against real
+// Kafka. Faults are read from FAULT_* environment variables, but only by
+// binaries built with the faultinject build tag:
+//
+//	go build -tags faultinject -o bin/etl-chaos ./cmd/etl
+//
+// In any other build Load returns nil, the wrappers return what they are
+// given, and the variables are ignored.
+package faultinject
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+)
+
+// ErrInjected is returned by injected transform and loader failures.
+var ErrInjected = errors.New("injected fault")
+
+// Faults are the faults to inject. Rates are probabilities from 0 to 1,
+// drawn independently per event, batch, or request. A nil *Faults injects
+// nothing.
+type Faults struct {
+	// TransformDelay bounds a random delay added before each transform,
+	// which stretches batches and holds transform workers busy.
+	TransformDelay time.Duration
+
+	// TransformErrorRate fails transforms with ErrInjected, so the messages
+	// are dead-lettered.
+	TransformErrorRate float64
+
+	// LoaderErrorRate fails batch loads with ErrInjected before the real
+	// loader runs, so the batch backs off and is retried and consecutive
+	// failures open the loader circuit breaker.
+	LoaderErrorRate float64
+
+	// AlertsThrottleRate answers NWS alerts requests with 429 Too Many
+	// Requests, as api.weather.gov does under load. The geocode stage is
+	// local, so the alerts lookup is the only per-event HTTP call to throttle.
+	AlertsThrottleRate float64
+}
+
+// Load returns the faults set in the environment, or nil when none are set
+// or the binary was built without the faultinject tag.
+func Load() (*Faults, error) {
+	if !enabled {
+		return nil, nil
+	}
+	return fromEnv(os.Getenv)
+}
+
+// fromEnv parses the FAULT_* variables with getenv.
+func fromEnv(getenv func(string) string) (*Faults, error) {
+	var f Faults
+	if v := getenv("FAULT_TRANSFORM_DELAY"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid FAULT_TRANSFORM_DELAY %q: want a non-negative duration", v)
+		}
+		f.TransformDelay = d
+	}
+	for key, rate := range map[string]*float64{
+		"FAULT_TRANSFORM_ERROR_RATE": &f.TransformErrorRate,
+		"FAULT_LOADER_ERROR_RATE":    &f.LoaderErrorRate,
+		"FAULT_ALERTS_THROTTLE_RATE": &f.AlertsThrottleRate,
+	} {
+		v := getenv(key)
+		if v == "" {
+			continue
+		}
+		r, err := strconv.ParseFloat(v, 64)
+		if err != nil || r < 0 || r > 1 {
+			return nil, fmt.Errorf("invalid %s %q: want a rate from 0 to 1", key, v)
+		}
+		*rate = r
+	}
+	if f == (Faults{}) {
+		return nil, nil
+	}
+	return &f, nil
+}
+
+// hit reports whether a fault with the given rate fires.
+func hit(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate //nolint:gosec // fault timing, not security sensitive
+}
+
+// Transformer wraps t with the transform delay and error faults.
+func (f *Faults) Transformer(t pipeline.Transformer) pipeline.Transformer {
+	if f == nil || (f.TransformDelay == 0 && f.TransformErrorRate == 0) {
+		return t
+	}
+	return &transformer{faults: f, next: t}
+}
+
+type transformer struct {
+	faults *Faults
+	next   pipeline.Transformer
+}
+
+func (t *transformer) Transform(ctx context.Context, raw domain.RawEvent) (domain.StormEvent, error) {
+	if d := t.faults.TransformDelay; d > 0 {
+		// A shutdown cuts the delay short rather than failing the message.
+		select {
+		case <-time.After(rand.N(d + 1)): //nolint:gosec // fault timing, not security sensitive
+		case <-ctx.Done():
+		}
+	}
+	if hit(t.faults.TransformErrorRate) {
+		return domain.StormEvent{}, fmt.Errorf("transform: %w", ErrInjected)
+	}
+	return t.next.Transform(ctx, raw)
+}
+
+// Loader puts a failing loader in front of l when a loader error rate is
+// set. The result is a pipeline.MultiLoader, so readiness checks and message
+// sizes still reach l.
+func (f *Faults) Loader(l pipeline.BatchLoader) pipeline.BatchLoader {
+	if f == nil || f.LoaderErrorRate == 0 {
+		return l
+	}
+	if m, ok := l.(pipeline.MultiLoader); ok {
+		return append(pipeline.MultiLoader{loader{f.LoaderErrorRate}}, m...)
+	}
+	return pipeline.MultiLoader{loader{f.LoaderErrorRate}, l}
+}
+
+// loader fails a share of batches and accepts the rest without loading them.
+type loader struct {
+	rate float64
+}
+
+func (l loader) LoadBatch(_ context.Context, events []domain.StormEvent) error {
+	if hit(l.rate) {
+		return fmt.Errorf("load %d events: %w", len(events), ErrInjected)
+	}
+	return nil
+}
+
+// Transport wraps base, or http.DefaultTransport when base is nil, to answer
+// a share of requests with 429 Too Many Requests. It returns base unchanged
+// when no throttle rate is set.
+func (f *Faults) Transport(base http.RoundTripper) http.RoundTripper {
+	if f == nil || f.AlertsThrottleRate == 0 {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &throttle{rate: f.AlertsThrottleRate, next: base}
+}
+
+type throttle struct {
+	rate float64
+	next http.RoundTripper
+}
+
+func (t *throttle) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !hit(t.rate) {
+		return t.next.RoundTrip(req)
+	}
+	body := []byte("injected fault: rate limited")
+	return &http.Response{
+		Status:        "429 Too Many Requests",
+		StatusCode:    http.StatusTooManyRequests,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Retry-After": {"5"}, "Content-Type": {"text/plain"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,101 @@
+package faultinject
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/couchcryptid/storm-data-etl/stormtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromEnv(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	f, err := fromEnv(env(nil))
+	require.NoError(t, err)
+	assert.Nil(t, f, "no faults set")
+
+	f, err = fromEnv(env(map[string]string{
+		"FAULT_TRANSFORM_DELAY":      "250ms",
+		"FAULT_TRANSFORM_ERROR_RATE": "0.05",
+		"FAULT_LOADER_ERROR_RATE":    "1",
+		"FAULT_ALERTS_THROTTLE_RATE": "0.5",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, &Faults{TransformDelay: 250 * time.Millisecond, TransformErrorRate: 0.05, LoaderErrorRate: 1, AlertsThrottleRate: 0.5}, f)
+
+	for key, bad := range map[string]string{
+		"FAULT_TRANSFORM_DELAY":      "-1s",
+		"FAULT_TRANSFORM_ERROR_RATE": "1.5",
+		"FAULT_LOADER_ERROR_RATE":    "often",
+		"FAULT_ALERTS_THROTTLE_RATE": "-0.1",
+	} {
+		_, err := fromEnv(env(map[string]string{key: bad}))
+		require.ErrorContains(t, err, key)
+	}
+}
+
+func TestLoad_RequiresBuildTag(t *testing.T) {
+	t.Setenv("FAULT_LOADER_ERROR_RATE", "1")
+	f, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, enabled, f != nil)
+}
+
+func TestFaults_Transformer(t *testing.T) {
+	next := &stormtest.Transformer{}
+	var none *Faults
+	assert.Same(t, next, none.Transformer(next), "nil faults leave the transformer as it is")
+
+	raw := stormtest.RawEvent(t, domain.StormEvent{ID: "evt-1", EventType: "hail"})
+	delayed := (&Faults{TransformDelay: time.Millisecond}).Transformer(next)
+	event, err := delayed.Transform(context.Background(), raw)
+	require.NoError(t, err)
+	assert.Equal(t, "evt-1", event.ID)
+
+	failing := (&Faults{TransformErrorRate: 1}).Transformer(next)
+	_, err = failing.Transform(context.Background(), raw)
+	require.ErrorIs(t, err, ErrInjected)
+}
+
+func TestFaults_Loader(t *testing.T) {
+	sink := &stormtest.Loader{}
+	var none *Faults
+	assert.Same(t, sink, none.Loader(sink))
+
+	failing := (&Faults{LoaderErrorRate: 1}).Loader(sink)
+	err := failing.LoadBatch(context.Background(), []domain.StormEvent{{ID: "evt-1"}})
+	require.ErrorIs(t, err, ErrInjected)
+	assert.Empty(t, sink.Batches(), "a failed batch never reaches the real loader")
+
+	multi := (&Faults{LoaderErrorRate: 1}).Loader(pipeline.MultiLoader{sink, sink})
+	assert.Len(t, multi, 3, "a MultiLoader is extended, not nested")
+}
+
+func TestFaults_Transport(t *testing.T) {
+	var none *Faults
+	assert.Nil(t, none.Transport(nil))
+
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: (&Faults{AlertsThrottleRate: 1}).Transport(nil)}
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "5", resp.Header.Get("Retry-After"))
+	assert.Zero(t, requests, "throttled requests never reach the server")
+}
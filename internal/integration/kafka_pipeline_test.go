@@ -14,6 +14,7 @@ import (
 	"github.com/couchcryptid/storm-data-etl/internal/domain"
 	"github.com/couchcryptid/storm-data-etl/internal/observability"
 	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/couchcryptid/storm-data-etl/stormtest/kafkatest"
 	kafkago "github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -24,57 +25,27 @@ const (
 	testSinkTopic   = "test-sink"
 )
 
-// transformedMessage holds a deserialized message read from the sink topic.
-type transformedMessage struct {
-	Event   domain.StormEvent
-	Key     string
-	Headers map[string]string
-}
-
-// readTransformed reads a single message from the sink consumer and deserializes it.
-func readTransformed(ctx context.Context, t *testing.T, consumer *kafkago.Reader) transformedMessage {
-	t.Helper()
-	readCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
-
-	msg, err := consumer.ReadMessage(readCtx)
-	require.NoError(t, err, "read from sink topic")
-
-	headers := make(map[string]string, len(msg.Headers))
-	for _, h := range msg.Headers {
-		headers[h.Key] = string(h.Value)
-	}
-	var event domain.StormEvent
-	require.NoError(t, json.Unmarshal(msg.Value, &event), "unmarshal sink message")
-
-	return transformedMessage{
-		Event:   event,
-		Key:     string(msg.Key),
-		Headers: headers,
-	}
-}
-
 // TestKafkaReaderWriter verifies the adapter layer: kafka.Reader (Extractor) and
 // kafka.Writer (Loader) correctly round-trip a message through Kafka.
 func TestKafkaReaderWriter(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
 	defer cancel()
 
-	broker := startKafka(ctx, t)
+	broker := kafkatest.StartKafka(ctx, t)
 
-	createTopic(t, broker, testSourceTopic)
-	createTopic(t, broker, testSinkTopic)
+	kafkatest.CreateTopic(t, broker, testSourceTopic)
+	kafkatest.CreateTopic(t, broker, testSinkTopic)
 
 	cfg := &config.Config{
 		KafkaBrokers:       []string{broker},
-		KafkaSourceTopic:   testSourceTopic,
+		KafkaSourceTopics:  []string{testSourceTopic},
 		KafkaSinkTopic:     testSinkTopic,
 		KafkaGroupID:       fmt.Sprintf("test-reader-%d", time.Now().UnixNano()),
 		BatchFlushInterval: 5 * time.Second,
 	}
 
 	// Publish a raw CSV record to the source topic.
-	records := loadMockData(t)
+	records := kafkatest.LoadMockData(t)
 	record := records[0] // first hail record: 8 ESE Chappel, TX
 	payload, err := json.Marshal(record)
 	require.NoError(t, err)
@@ -92,10 +63,13 @@ func TestKafkaReaderWriter(t *testing.T) {
 		Time:  baseDate,
 	}))
 
+	metrics := observability.NewMetricsForTesting()
+
 	// Extract via kafka.Reader.
 	// Retry because the consumer group may need time to rebalance before
 	// partitions are assigned and messages become available.
-	reader := kafka.NewReader(cfg, discardLogger())
+	reader, err := kafka.NewReader(cfg, discardLogger(), metrics)
+	require.NoError(t, err)
 	t.Cleanup(func() { _ = reader.Close() })
 
 	var batch []domain.RawEvent
@@ -126,7 +100,8 @@ func TestKafkaReaderWriter(t *testing.T) {
 	require.NoError(t, err)
 
 	// Load via kafka.Writer.
-	writer := kafka.NewWriter(cfg, discardLogger())
+	writer, err := kafka.NewWriter(cfg, discardLogger(), metrics)
+	require.NoError(t, err)
 	t.Cleanup(func() { _ = writer.Close() })
 
 	require.NoError(t, writer.LoadBatch(ctx, []domain.StormEvent{event}))
@@ -140,7 +115,7 @@ func TestKafkaReaderWriter(t *testing.T) {
 	})
 	t.Cleanup(func() { _ = consumer.Close() })
 
-	tm := readTransformed(ctx, t, consumer)
+	tm := kafkatest.ReadTransformed(ctx, t, consumer)
 	assert.Equal(t, "hail", tm.Headers["event_type"])
 	assert.Contains(t, tm.Headers, "processed_at")
 	_, err = time.Parse(time.RFC3339, tm.Headers["processed_at"])
@@ -159,21 +134,21 @@ func TestPipelineEndToEnd(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 	defer cancel()
 
-	broker := startKafka(ctx, t)
+	broker := kafkatest.StartKafka(ctx, t)
 
-	createTopic(t, broker, testSourceTopic)
-	createTopic(t, broker, testSinkTopic)
+	kafkatest.CreateTopic(t, broker, testSourceTopic)
+	kafkatest.CreateTopic(t, broker, testSinkTopic)
 
 	cfg := &config.Config{
 		KafkaBrokers:       []string{broker},
-		KafkaSourceTopic:   testSourceTopic,
+		KafkaSourceTopics:  []string{testSourceTopic},
 		KafkaSinkTopic:     testSinkTopic,
 		KafkaGroupID:       fmt.Sprintf("test-pipeline-%d", time.Now().UnixNano()),
 		BatchFlushInterval: 5 * time.Second,
 	}
 
 	// Publish all mock CSV records to the source topic.
-	records := loadMockData(t)
+	records := kafkatest.LoadMockData(t)
 	baseDate := time.Date(2024, time.April, 26, 0, 0, 0, 0, time.UTC)
 
 	producer := &kafkago.Writer{
@@ -195,15 +170,17 @@ func TestPipelineEndToEnd(t *testing.T) {
 	require.NoError(t, producer.WriteMessages(ctx, msgs...))
 
 	// Wire up the pipeline.
-	reader := kafka.NewReader(cfg, discardLogger())
+	metrics := observability.NewMetricsForTesting()
+	reader, err := kafka.NewReader(cfg, discardLogger(), metrics)
+	require.NoError(t, err)
 	t.Cleanup(func() { _ = reader.Close() })
 
 	transformer := pipeline.NewTransformer(discardLogger())
 
-	writer := kafka.NewWriter(cfg, discardLogger())
+	writer, err := kafka.NewWriter(cfg, discardLogger(), metrics)
+	require.NoError(t, err)
 	t.Cleanup(func() { _ = writer.Close() })
 
-	metrics := observability.NewMetricsForTesting()
 	p := pipeline.New(reader, transformer, writer, discardLogger(), metrics, 50)
 
 	// Run the pipeline in a goroutine.
@@ -220,9 +197,9 @@ func TestPipelineEndToEnd(t *testing.T) {
 	})
 	t.Cleanup(func() { _ = consumer.Close() })
 
-	received := make([]transformedMessage, 0, len(records))
+	received := make([]kafkatest.TransformedMessage, 0, len(records))
 	for len(received) < len(records) {
-		tm := readTransformed(ctx, t, consumer)
+		tm := kafkatest.ReadTransformed(ctx, t, consumer)
 		received = append(received, tm)
 	}
 
@@ -291,14 +268,14 @@ func TestPipelineTransformError(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
 	defer cancel()
 
-	broker := startKafka(ctx, t)
+	broker := kafkatest.StartKafka(ctx, t)
 
-	createTopic(t, broker, testSourceTopic)
-	createTopic(t, broker, testSinkTopic)
+	kafkatest.CreateTopic(t, broker, testSourceTopic)
+	kafkatest.CreateTopic(t, broker, testSinkTopic)
 
 	cfg := &config.Config{
 		KafkaBrokers:       []string{broker},
-		KafkaSourceTopic:   testSourceTopic,
+		KafkaSourceTopics:  []string{testSourceTopic},
 		KafkaSinkTopic:     testSinkTopic,
 		KafkaGroupID:       fmt.Sprintf("test-poison-%d", time.Now().UnixNano()),
 		BatchFlushInterval: 5 * time.Second,
@@ -307,7 +284,7 @@ func TestPipelineTransformError(t *testing.T) {
 	baseDate := time.Date(2024, time.April, 26, 0, 0, 0, 0, time.UTC)
 
 	// Publish: invalid JSON, then a valid CSV record.
-	records := loadMockData(t)
+	records := kafkatest.LoadMockData(t)
 	validPayload, err := json.Marshal(records[0])
 	require.NoError(t, err)
 
@@ -323,15 +300,17 @@ func TestPipelineTransformError(t *testing.T) {
 	))
 
 	// Wire up the pipeline.
-	reader := kafka.NewReader(cfg, discardLogger())
+	metrics := observability.NewMetricsForTesting()
+	reader, err := kafka.NewReader(cfg, discardLogger(), metrics)
+	require.NoError(t, err)
 	t.Cleanup(func() { _ = reader.Close() })
 
 	transformer := pipeline.NewTransformer(discardLogger())
 
-	writer := kafka.NewWriter(cfg, discardLogger())
+	writer, err := kafka.NewWriter(cfg, discardLogger(), metrics)
+	require.NoError(t, err)
 	t.Cleanup(func() { _ = writer.Close() })
 
-	metrics := observability.NewMetricsForTesting()
 	p := pipeline.New(reader, transformer, writer, discardLogger(), metrics, 50)
 
 	pipelineCtx, pipelineCancel := context.WithCancel(ctx)
@@ -347,7 +326,7 @@ func TestPipelineTransformError(t *testing.T) {
 	})
 	t.Cleanup(func() { _ = consumer.Close() })
 
-	tm := readTransformed(ctx, t, consumer)
+	tm := kafkatest.ReadTransformed(ctx, t, consumer)
 	assert.Equal(t, "hail", tm.Event.EventType)
 	assert.Equal(t, "TX", tm.Event.Location.State)
 
@@ -360,3 +339,125 @@ func TestPipelineTransformError(t *testing.T) {
 	pipelineCancel()
 	require.NoError(t, <-errCh)
 }
+
+// TestDeadLetterQueueRequeue verifies that dead letters can be listed from the
+// dead-letter topic and requeued onto the source topic without their
+// dead-letter headers.
+func TestDeadLetterQueueRequeue(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+
+	broker := kafkatest.StartKafka(ctx, t)
+
+	const dlqTopic = "test-dlq"
+	kafkatest.CreateTopic(t, broker, testSourceTopic)
+	kafkatest.CreateTopic(t, broker, dlqTopic)
+
+	cfg := &config.Config{
+		KafkaBrokers:      []string{broker},
+		KafkaSourceTopics: []string{testSourceTopic},
+		KafkaDLQTopic:     dlqTopic,
+	}
+
+	dlqWriter, err := kafka.NewDeadLetterWriter(cfg, discardLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dlqWriter.Close() })
+
+	require.NoError(t, dlqWriter.LoadDeadLetters(ctx, []domain.DeadLetter{{
+		Event: domain.RawEvent{
+			Key:     []byte("bad"),
+			Value:   []byte("not-json{{{"),
+			Headers: map[string]string{"source": "noaa"},
+			Topic:   testSourceTopic,
+			Offset:  4,
+		},
+		Reason:   "parse raw event: invalid character",
+		FailedAt: time.Now(),
+	}}))
+
+	dlq, err := kafka.NewDeadLetterQueue(cfg, discardLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dlq.Close() })
+
+	records, err := dlq.Recent(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "parse raw event: invalid character", records[0].Reason)
+	assert.Equal(t, "not-json{{{", records[0].Value)
+	assert.Equal(t, int64(4), records[0].SourceOffset)
+
+	n, err := dlq.Requeue(ctx, []domain.DeadLetterPosition{records[0].DeadLetterPosition})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	_, err = dlq.Requeue(ctx, []domain.DeadLetterPosition{{Partition: 0, Offset: 99}})
+	require.Error(t, err, "missing offsets are rejected")
+
+	consumer := kafkago.NewReader(kafkago.ReaderConfig{
+		Brokers:     []string{broker},
+		Topic:       testSourceTopic,
+		GroupID:     fmt.Sprintf("test-requeue-%d", time.Now().UnixNano()),
+		StartOffset: kafkago.FirstOffset,
+	})
+	t.Cleanup(func() { _ = consumer.Close() })
+
+	readCtx, readCancel := context.WithTimeout(ctx, 30*time.Second)
+	defer readCancel()
+	msg, err := consumer.ReadMessage(readCtx)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("bad"), msg.Key)
+	assert.Equal(t, []kafkago.Header{{Key: "source", Value: []byte("noaa")}}, msg.Headers)
+}
+
+func TestReplayerCopiesOffsetRange(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+
+	broker := kafkatest.StartKafka(ctx, t)
+
+	const backupTopic = "test-backup"
+	kafkatest.CreateTopic(t, broker, testSourceTopic)
+	kafkatest.CreateTopic(t, broker, backupTopic)
+
+	backup := &kafkago.Writer{Addr: kafkago.TCP(broker), Topic: backupTopic}
+	t.Cleanup(func() { _ = backup.Close() })
+	for i := range 5 {
+		require.NoError(t, backup.WriteMessages(ctx, kafkago.Message{
+			Key:     []byte(fmt.Sprintf("key-%d", i)),
+			Value:   []byte(fmt.Sprintf(`{"n":%d}`, i)),
+			Headers: []kafkago.Header{{Key: "source", Value: []byte("noaa")}},
+		}))
+	}
+
+	cfg := &config.Config{KafkaBrokers: []string{broker}}
+	replayer, err := kafka.NewReplayer(cfg, testSourceTopic)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = replayer.Close() })
+
+	var scanned []domain.RawEvent
+	require.NoError(t, replayer.Scan(ctx, backupTopic, -1, 1, 4, func(raw domain.RawEvent) error {
+		scanned = append(scanned, raw)
+		return nil
+	}))
+	require.Len(t, scanned, 3)
+	assert.Equal(t, int64(1), scanned[0].Offset)
+	assert.Equal(t, int64(3), scanned[2].Offset)
+	require.NoError(t, replayer.Publish(ctx, scanned))
+
+	consumer := kafkago.NewReader(kafkago.ReaderConfig{
+		Brokers:     []string{broker},
+		Topic:       testSourceTopic,
+		GroupID:     fmt.Sprintf("test-replay-%d", time.Now().UnixNano()),
+		StartOffset: kafkago.FirstOffset,
+	})
+	t.Cleanup(func() { _ = consumer.Close() })
+
+	readCtx, readCancel := context.WithTimeout(ctx, 30*time.Second)
+	defer readCancel()
+	for i := 1; i <= 3; i++ {
+		msg, err := consumer.ReadMessage(readCtx)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf(`{"n":%d}`, i), string(msg.Value))
+		assert.Equal(t, []kafkago.Header{{Key: "source", Value: []byte("noaa")}}, msg.Headers)
+	}
+}
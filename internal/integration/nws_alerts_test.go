@@ -0,0 +1,116 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/kafka"
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/nwsalerts"
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/couchcryptid/storm-data-etl/stormtest/kafkatest"
+	"github.com/couchcryptid/storm-data-etl/stormtest/nwsalertstest"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPipelineNWSAlerts runs the mock reports through the pipeline with the
+// nws_alerts stage pointed at the fake alerts API and checks that reports
+// inside the mock warnings are flagged and the rest are not.
+func TestPipelineNWSAlerts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	broker := kafkatest.StartKafka(ctx, t)
+
+	kafkatest.CreateTopic(t, broker, testSourceTopic)
+	kafkatest.CreateTopic(t, broker, testSinkTopic)
+
+	cfg := &config.Config{
+		KafkaBrokers:       []string{broker},
+		KafkaSourceTopics:  []string{testSourceTopic},
+		KafkaSinkTopic:     testSinkTopic,
+		KafkaGroupID:       fmt.Sprintf("test-nws-alerts-%d", time.Now().UnixNano()),
+		BatchFlushInterval: 5 * time.Second,
+		NWSAlerts:          true,
+		NWSAlertsURL:       nwsalertstest.Start(t, nwsalertstest.WithLatency(5*time.Millisecond)),
+		NWSAlertsUserAgent: "(storm-etl-integration, ops@example.com)",
+		NWSAlertsTimeout:   5 * time.Second,
+	}
+
+	records := kafkatest.LoadMockData(t)
+	baseDate := time.Date(2024, time.April, 26, 0, 0, 0, 0, time.UTC)
+
+	producer := &kafkago.Writer{
+		Addr:  kafkago.TCP(broker),
+		Topic: testSourceTopic,
+	}
+	t.Cleanup(func() { _ = producer.Close() })
+
+	msgs := make([]kafkago.Message, 0, len(records))
+	for i, rec := range records {
+		payload, err := json.Marshal(rec)
+		require.NoError(t, err)
+		msgs = append(msgs, kafkago.Message{
+			Key:   []byte(fmt.Sprintf("record-%d", i)),
+			Value: payload,
+			Time:  baseDate,
+		})
+	}
+	require.NoError(t, producer.WriteMessages(ctx, msgs...))
+
+	metrics := observability.NewMetricsForTesting()
+	reader, err := kafka.NewReader(cfg, discardLogger(), metrics)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = reader.Close() })
+
+	alerts := nwsalerts.NewClient(cfg, discardLogger(), metrics)
+	transformer := pipeline.NewTransformer(discardLogger(),
+		pipeline.WithStageAfter(pipeline.StageGeocode, nwsalerts.StageName, alerts.Stage))
+
+	writer, err := kafka.NewWriter(cfg, discardLogger(), metrics)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = writer.Close() })
+
+	p := pipeline.New(reader, transformer, writer, discardLogger(), metrics, 50)
+
+	pipelineCtx, pipelineCancel := context.WithCancel(ctx)
+	errCh := make(chan error, 1)
+	go func() { errCh <- p.Run(pipelineCtx) }()
+
+	consumer := kafkago.NewReader(kafkago.ReaderConfig{
+		Brokers:     []string{broker},
+		Topic:       testSinkTopic,
+		GroupID:     fmt.Sprintf("test-nws-alerts-sink-%d", time.Now().UnixNano()),
+		StartOffset: kafkago.FirstOffset,
+	})
+	t.Cleanup(func() { _ = consumer.Close() })
+
+	warned := map[string]int{}
+	for range records {
+		tm := kafkatest.ReadTransformed(ctx, t, consumer)
+		require.NotNil(t, tm.Event.WarningActive, "every lookup should succeed: %s", tm.Key)
+		assert.Equal(t, *tm.Event.WarningActive, len(tm.Event.WarningIDs) > 0)
+		for _, id := range tm.Event.WarningIDs {
+			warned[id]++
+		}
+		if tm.Event.Location.State == "TX" && tm.Event.Location.County == "San Saba" {
+			assert.False(t, *tm.Event.WarningActive, "no mock warning covers San Saba County")
+		}
+	}
+
+	pipelineCancel()
+	require.NoError(t, <-errCh)
+
+	assert.Positive(t, warned["urn:oid:2.49.0.1.840.0.mock.ne-tor-lincoln.001.1"], "reports inside the Lincoln warning")
+	assert.Positive(t, warned["urn:oid:2.49.0.1.840.0.mock.ne-tor-omaha.001.1"], "reports inside the Omaha warning")
+	assert.Positive(t, warned["urn:oid:2.49.0.1.840.0.mock.tx-svr-arlington.001.1"], "reports inside the Arlington warning")
+	assert.NotContains(t, warned, "urn:oid:2.49.0.1.840.0.mock.ne-tor-watch.001.1", "watches are not warnings")
+}
@@ -0,0 +1,84 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/postgres"
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/stormtest/kafkatest"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tcPostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+)
+
+// TestPostgresLoader verifies migrations and idempotent upserts against a
+// real PostgreSQL instance: loading the same batch twice stores each event once.
+func TestPostgresLoader(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+
+	pc, err := tcPostgres.Run(ctx, "postgres:16-alpine",
+		tcPostgres.WithDatabase("storms"),
+		tcPostgres.BasicWaitStrategies(),
+	)
+	require.NoError(t, err, "start postgres container")
+	t.Cleanup(func() { _ = pc.Terminate(context.Background()) })
+
+	dsn, err := pc.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	cfg := &config.Config{PostgresDSN: dsn, PostgresMigrate: true}
+	loader, err := postgres.NewLoader(ctx, cfg, discardLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = loader.Close() })
+
+	// Migrations are idempotent across restarts.
+	second, err := postgres.NewLoader(ctx, cfg, discardLogger())
+	require.NoError(t, err)
+	require.NoError(t, second.Close())
+
+	records := kafkatest.LoadMockData(t)
+	events := make([]domain.StormEvent, 0, len(records))
+	base := time.Date(2024, time.April, 26, 0, 0, 0, 0, time.UTC)
+	for i := range records {
+		data, err := json.Marshal(records[i])
+		require.NoError(t, err)
+		raw := domain.RawEvent{Value: data, Timestamp: base}
+		event, err := domain.ParseRawEvent(raw)
+		require.NoError(t, err)
+		events = append(events, domain.EnrichStormEvent(event))
+	}
+
+	require.NoError(t, loader.LoadBatch(ctx, events))
+	require.NoError(t, loader.LoadBatch(ctx, events))
+
+	conn, err := pgx.Connect(ctx, dsn)
+	require.NoError(t, err)
+	defer func() { _ = conn.Close(ctx) }()
+
+	var count int
+	require.NoError(t, conn.QueryRow(ctx, "SELECT count(*) FROM storm_events").Scan(&count))
+	assert.Equal(t, uniqueIDs(events), count)
+
+	var state, unit string
+	require.NoError(t, conn.QueryRow(ctx,
+		"SELECT location_state, measurement_unit FROM storm_events WHERE id = $1", events[0].ID,
+	).Scan(&state, &unit))
+	assert.Equal(t, events[0].Location.State, state)
+	assert.Equal(t, events[0].Measurement.Unit, unit)
+}
+
+func uniqueIDs(events []domain.StormEvent) int {
+	seen := make(map[string]struct{}, len(events))
+	for i := range events {
+		seen[events[i].ID+events[i].EventTime.String()] = struct{}{}
+	}
+	return len(seen)
+}
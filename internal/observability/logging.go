@@ -1,13 +1,94 @@
 package observability
 
 import (
+	"context"
 	"log/slog"
 
 	"github.com/couchcryptid/storm-data-etl/internal/config"
 	sharedobs "github.com/couchcryptid/storm-data-shared/observability"
+	"github.com/jonboulle/clockwork"
 )
 
+// logLevel is the minimum level of loggers created by NewLogger. It can be
+// changed at runtime with SetLogLevel.
+var logLevel slog.LevelVar
+
 // NewLogger creates a structured logger based on config and sets it as the default.
+// The level is held in a LevelVar so configuration reloads take effect without
+// recreating loggers that were already handed out. Repeated warnings and
+// errors are sampled per LOG_SAMPLE_BURST and LOG_SAMPLE_INTERVAL. Records
+// logged with a context from WithCorrelationID carry its correlation_id.
 func NewLogger(cfg *config.Config) *slog.Logger {
-	return sharedobs.NewLogger(cfg.LogLevel, cfg.LogFormat)
+	SetLogLevel(cfg.LogLevel)
+	var handler slog.Handler = correlationHandler{Handler: sharedobs.NewLogger("debug", cfg.LogFormat).Handler()}
+	if cfg.LogSampleBurst > 0 {
+		handler = samplingHandler{Handler: handler, sampler: newLogSampler(cfg.LogSampleBurst, cfg.LogSampleInterval, clockwork.NewRealClock())}
+	}
+	logger := slog.New(levelHandler{Handler: handler})
+	slog.SetDefault(logger)
+	return logger
+}
+
+// SetLogLevel changes the level of all loggers created by NewLogger. It
+// parses level as slog does, like LOG_LEVEL, and falls back to info for a
+// value config would have rejected.
+func SetLogLevel(level string) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		l = slog.LevelInfo
+	}
+	logLevel.Set(l)
+}
+
+// levelHandler filters records below logLevel before the wrapped handler,
+// which is built to accept everything.
+type levelHandler struct {
+	slog.Handler
+}
+
+func (h levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= logLevel.Level() && h.Handler.Enabled(ctx, level)
+}
+
+func (h levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return levelHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h levelHandler) WithGroup(name string) slog.Handler {
+	return levelHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+type correlationKey struct{}
+
+// WithCorrelationID returns ctx carrying a message's correlation ID, which
+// loggers created by NewLogger add to every record logged with ctx.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationID returns the correlation ID carried by ctx, if any.
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// correlationHandler adds the context's correlation ID to each record.
+type correlationHandler struct {
+	slog.Handler
+}
+
+func (h correlationHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := CorrelationID(ctx); id != "" {
+		r = r.Clone()
+		r.AddAttrs(slog.String("correlation_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h correlationHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return correlationHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h correlationHandler) WithGroup(name string) slog.Handler {
+	return correlationHandler{Handler: h.Handler.WithGroup(name)}
 }
@@ -0,0 +1,22 @@
+package observability
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCorrelationHandler(t *testing.T) {
+	rec := newRecordingHandler()
+	logger := slog.New(correlationHandler{Handler: rec}).With("component", "transform")
+
+	ctx := WithCorrelationID(context.Background(), "corr-1")
+	logger.InfoContext(ctx, "transform audit")
+	assert.Equal(t, "corr-1", recordAttrs(rec.last())["correlation_id"])
+	assert.Equal(t, "transform", recordAttrs(rec.last())["component"], "attributes from With are kept")
+
+	logger.Info("pipeline started")
+	assert.NotContains(t, recordAttrs(rec.last()), "correlation_id")
+}
@@ -1,15 +1,117 @@
 package observability
 
 import (
+	"slices"
+
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// eventLabels break per-event counters down by event type and, when enabled
+// with METRICS_STATE_LABEL, by state. The state label is empty otherwise,
+// which Prometheus treats as absent.
+var eventLabels = []string{"event_type", "state"}
+
+// transformErrorLabels add the failure category: invalid_json,
+// unknown_event_type, invalid_coordinates, or internal for anything that is
+// not a known data problem.
+var transformErrorLabels = append(slices.Clone(eventLabels), "error_type")
+
+// payloadSizeBuckets span 64 B to 1 MiB in powers of four. A single storm
+// report is a few hundred bytes, so the upper buckets flag oversized payloads.
+var payloadSizeBuckets = prometheus.ExponentialBuckets(64, 4, 9)
+
+// sourceLagBuckets span 50 ms to about 3.6 hours in powers of four, from a
+// collector publishing just ahead of the pipeline to a long consumer outage.
+var sourceLagBuckets = prometheus.ExponentialBuckets(0.05, 4, 10)
+
+// eventLatencyBuckets span one minute to three days. Spotter reports reach
+// the SPC minutes to hours after the event; replays of older reports land in
+// the top buckets.
+var eventLatencyBuckets = []float64{60, 300, 900, 1800, 3600, 7200, 14400, 43200, 86400, 259200}
+
 // Metrics holds the Prometheus counters, histograms, and gauges for the ETL pipeline.
 type Metrics struct {
 	MessagesConsumed prometheus.Counter
-	MessagesProduced prometheus.Counter
-	TransformErrors  prometheus.Counter
+	MessagesProduced *prometheus.CounterVec
+	TransformErrors  *prometheus.CounterVec
+	EventsFiltered   *prometheus.CounterVec
 	PipelineRunning  prometheus.Gauge
+	PipelinePaused   prometheus.Gauge
+	RateLimit        prometheus.Gauge
+
+	// ValidationViolations counts failed validation checks by check and the
+	// action the rule applied.
+	ValidationViolations *prometheus.CounterVec
+
+	// EventsMerged counts near-duplicate reports merged into another report.
+	EventsMerged prometheus.Counter
+
+	// EpisodesStarted counts storm episodes started by episode clustering.
+	EpisodesStarted prometheus.Counter
+
+	// Loader circuit breaker metrics.
+	LoaderCircuitOpen  prometheus.Gauge
+	LoaderCircuitTrips prometheus.Counter
+
+	// Dead-letter metrics.
+	DeadLetterMessages prometheus.Counter
+	DeadLetterErrors   prometheus.Counter
+
+	// Transform worker pool metrics.
+	TransformWorkers     prometheus.Gauge
+	TransformWorkersBusy prometheus.Gauge
+
+	// Internal pressure: batches between extraction and commit, extracted
+	// messages whose offsets are not yet committed, and api.weather.gov
+	// warning lookups awaiting a response.
+	BatchesInFlight      prometheus.Gauge
+	MessagesUncommitted  prometheus.Gauge
+	AlertLookupsInFlight prometheus.Gauge
+
+	// SinkAsyncErrors counts sink messages that failed delivery after
+	// LoadBatch returned, which only happens with KAFKA_SINK_ASYNC.
+	SinkAsyncErrors prometheus.Counter
+
+	// SQSLateDeletes counts SQS messages committed after their visibility
+	// timeout expired, which may already have been redelivered.
+	SQSLateDeletes prometheus.Counter
+
+	// Webhook sink metrics, labeled by destination host and path. Request
+	// outcomes are delivered, retried, or failed.
+	WebhookRequests        *prometheus.CounterVec
+	WebhookEventsDelivered *prometheus.CounterVec
+	WebhookRequestDuration *prometheus.HistogramVec
+
+	// AlertLookups counts api.weather.gov warning lookups by result: inside
+	// (a warning was in effect), outside, or error.
+	AlertLookups *prometheus.CounterVec
+
+	// CommentRedactions counts matches removed from comments by redaction
+	// pattern.
+	CommentRedactions *prometheus.CounterVec
+
+	// OversizeEvents counts events whose sink message exceeded the size
+	// limit, by the action taken.
+	OversizeEvents *prometheus.CounterVec
+
+	// Live event stream metrics. Events are dropped for subscribers that
+	// fall behind rather than blocking the pipeline.
+	StreamSubscribers   prometheus.Gauge
+	StreamEventsDropped prometheus.Counter
+
+	// Kafka consumer group assignment, as last described by the brokers.
+	KafkaAssignedPartitions *prometheus.GaugeVec
+	KafkaRebalancing        prometheus.Gauge
+
+	// Kafka payload sizes, to catch upstream format regressions.
+	RawMessageBytes   prometheus.Histogram
+	EventMessageBytes prometheus.Histogram
+
+	// Freshness of loaded events by event type: SourceLag runs from the
+	// source message timestamp, EventLatency from the event time, both to
+	// when the event was loaded.
+	SourceLag    *prometheus.HistogramVec
+	EventLatency *prometheus.HistogramVec
 
 	// Batch processing metrics.
 	BatchSize               prometheus.Histogram
@@ -24,21 +126,181 @@ func NewMetrics() *Metrics {
 			Name:      "messages_consumed_total",
 			Help:      "Total messages read from the source topic.",
 		}),
-		MessagesProduced: prometheus.NewCounter(prometheus.CounterOpts{
+		MessagesProduced: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace: "storm_etl",
 			Name:      "messages_produced_total",
 			Help:      "Total messages written to the sink topic.",
-		}),
-		TransformErrors: prometheus.NewCounter(prometheus.CounterOpts{
+		}, eventLabels),
+		TransformErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace: "storm_etl",
 			Name:      "transform_errors_total",
-			Help:      "Total transformation failures.",
+			Help:      "Total transformation failures by cause.",
+		}, transformErrorLabels),
+		EventsFiltered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "events_filtered_total",
+			Help:      "Total enriched events dropped by the event filter, by the criterion that rejected them.",
+		}, []string{"reason"}),
+		ValidationViolations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "validation_violations_total",
+			Help:      "Total enriched events failing a validation rule, by check and action.",
+		}, []string{"check", "action"}),
+		EventsMerged: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "events_merged_total",
+			Help:      "Total near-duplicate reports merged into a higher-quality report of the same storm.",
+		}),
+		EpisodesStarted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "episodes_started_total",
+			Help:      "Total storm episodes started by a report close to no earlier report.",
 		}),
 		PipelineRunning: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace: "storm_etl",
 			Name:      "pipeline_running",
 			Help:      "1 when the pipeline is active, 0 when shut down.",
 		}),
+		PipelinePaused: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "storm_etl",
+			Name:      "pipeline_paused",
+			Help:      "1 while extraction is paused via the admin API.",
+		}),
+		RateLimit: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "storm_etl",
+			Name:      "rate_limit_events_per_second",
+			Help:      "Configured maximum events extracted per second; 0 when unlimited.",
+		}),
+		LoaderCircuitOpen: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "storm_etl",
+			Name:      "loader_circuit_open",
+			Help:      "1 while the loader circuit breaker is open and extraction is stopped.",
+		}),
+		LoaderCircuitTrips: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "loader_circuit_trips_total",
+			Help:      "Total times the loader circuit breaker opened after consecutive load failures.",
+		}),
+		DeadLetterMessages: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "dead_letter_messages_total",
+			Help:      "Total failed messages written to the dead-letter topic.",
+		}),
+		DeadLetterErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "dead_letter_errors_total",
+			Help:      "Total failed attempts to write to the dead-letter topic.",
+		}),
+		SinkAsyncErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "sink_async_errors_total",
+			Help:      "Total sink messages that failed delivery in async mode.",
+		}),
+		SQSLateDeletes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "sqs_late_deletes_total",
+			Help:      "Total SQS messages deleted after their visibility timeout expired.",
+		}),
+		WebhookRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "webhook_requests_total",
+			Help:      "Total webhook delivery attempts by destination and outcome.",
+		}, []string{"destination", "outcome"}),
+		WebhookEventsDelivered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "webhook_events_delivered_total",
+			Help:      "Total events accepted by each webhook destination.",
+		}, []string{"destination"}),
+		WebhookRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "storm_etl",
+			Name:      "webhook_request_duration_seconds",
+			Help:      "Duration of webhook delivery attempts by destination.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"destination"}),
+		AlertLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "alert_lookups_total",
+			Help:      "Total NWS warning lookups by result.",
+		}, []string{"result"}),
+		CommentRedactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "comment_redactions_total",
+			Help:      "Total matches redacted from event comments by pattern.",
+		}, []string{"pattern"}),
+		OversizeEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "oversize_events_total",
+			Help:      "Total events whose sink message exceeded the size limit by action.",
+		}, []string{"action"}),
+		StreamSubscribers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "storm_etl",
+			Name:      "stream_subscribers",
+			Help:      "Number of clients currently subscribed to the live event stream.",
+		}),
+		StreamEventsDropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storm_etl",
+			Name:      "stream_events_dropped_total",
+			Help:      "Total live stream events dropped because a subscriber's buffer was full.",
+		}),
+		TransformWorkers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "storm_etl",
+			Name:      "transform_workers",
+			Help:      "Configured number of concurrent transform workers.",
+		}),
+		TransformWorkersBusy: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "storm_etl",
+			Name:      "transform_workers_busy",
+			Help:      "Number of transform workers currently transforming a message.",
+		}),
+		BatchesInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "storm_etl",
+			Name:      "batches_in_flight",
+			Help:      "Batches extracted and not yet loaded and committed.",
+		}),
+		MessagesUncommitted: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "storm_etl",
+			Name:      "messages_uncommitted",
+			Help:      "Extracted messages whose offsets are not yet committed.",
+		}),
+		AlertLookupsInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "storm_etl",
+			Name:      "alert_lookups_in_flight",
+			Help:      "NWS warning lookups awaiting a response from api.weather.gov.",
+		}),
+		KafkaAssignedPartitions: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "storm_etl",
+			Name:      "kafka_assigned_partitions",
+			Help:      "Number of source partitions assigned to this consumer by topic.",
+		}, []string{"topic"}),
+		KafkaRebalancing: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "storm_etl",
+			Name:      "kafka_rebalance_in_progress",
+			Help:      "Whether the consumer group is rebalancing (1) or not (0); extraction waits while it is.",
+		}),
+		RawMessageBytes: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "storm_etl",
+			Name:      "raw_message_bytes",
+			Help:      "Size of raw message values read from the source topic.",
+			Buckets:   payloadSizeBuckets,
+		}),
+		EventMessageBytes: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "storm_etl",
+			Name:      "event_message_bytes",
+			Help:      "Size of serialized events written to the sink topic.",
+			Buckets:   payloadSizeBuckets,
+		}),
+		SourceLag: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "storm_etl",
+			Name:      "source_lag_seconds",
+			Help:      "Time from the source message timestamp to loading the event.",
+			Buckets:   sourceLagBuckets,
+		}, []string{"event_type"}),
+		EventLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "storm_etl",
+			Name:      "event_latency_seconds",
+			Help:      "Time from the event time of a report to loading it.",
+			Buckets:   eventLatencyBuckets,
+		}, []string{"event_type"}),
 		BatchSize: prometheus.NewHistogram(prometheus.HistogramOpts{
 			Namespace: "storm_etl",
 			Name:      "batch_size",
@@ -57,7 +319,38 @@ func NewMetrics() *Metrics {
 		m.MessagesConsumed,
 		m.MessagesProduced,
 		m.TransformErrors,
+		m.EventsFiltered,
+		m.ValidationViolations,
+		m.EventsMerged,
+		m.EpisodesStarted,
 		m.PipelineRunning,
+		m.PipelinePaused,
+		m.RateLimit,
+		m.LoaderCircuitOpen,
+		m.LoaderCircuitTrips,
+		m.DeadLetterMessages,
+		m.DeadLetterErrors,
+		m.SinkAsyncErrors,
+		m.SQSLateDeletes,
+		m.WebhookRequests,
+		m.WebhookEventsDelivered,
+		m.WebhookRequestDuration,
+		m.AlertLookups,
+		m.CommentRedactions,
+		m.OversizeEvents,
+		m.StreamSubscribers,
+		m.StreamEventsDropped,
+		m.TransformWorkers,
+		m.TransformWorkersBusy,
+		m.BatchesInFlight,
+		m.MessagesUncommitted,
+		m.AlertLookupsInFlight,
+		m.KafkaAssignedPartitions,
+		m.KafkaRebalancing,
+		m.RawMessageBytes,
+		m.EventMessageBytes,
+		m.SourceLag,
+		m.EventLatency,
 		m.BatchSize,
 		m.BatchProcessingDuration,
 	)
@@ -70,9 +363,40 @@ func NewMetrics() *Metrics {
 func NewMetricsForTesting() *Metrics {
 	return &Metrics{
 		MessagesConsumed:        prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "messages_consumed_total"}),
-		MessagesProduced:        prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "messages_produced_total"}),
-		TransformErrors:         prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "transform_errors_total"}),
+		MessagesProduced:        prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: "storm_etl", Name: "messages_produced_total"}, eventLabels),
+		TransformErrors:         prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: "storm_etl", Name: "transform_errors_total"}, transformErrorLabels),
+		EventsFiltered:          prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: "storm_etl", Name: "events_filtered_total"}, []string{"reason"}),
+		ValidationViolations:    prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: "storm_etl", Name: "validation_violations_total"}, []string{"check", "action"}),
+		EventsMerged:            prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "events_merged_total"}),
+		EpisodesStarted:         prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "episodes_started_total"}),
 		PipelineRunning:         prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "storm_etl", Name: "pipeline_running"}),
+		PipelinePaused:          prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "storm_etl", Name: "pipeline_paused"}),
+		RateLimit:               prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "storm_etl", Name: "rate_limit_events_per_second"}),
+		LoaderCircuitOpen:       prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "storm_etl", Name: "loader_circuit_open"}),
+		LoaderCircuitTrips:      prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "loader_circuit_trips_total"}),
+		DeadLetterMessages:      prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "dead_letter_messages_total"}),
+		DeadLetterErrors:        prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "dead_letter_errors_total"}),
+		SinkAsyncErrors:         prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "sink_async_errors_total"}),
+		SQSLateDeletes:          prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "sqs_late_deletes_total"}),
+		WebhookRequests:         prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: "storm_etl", Name: "webhook_requests_total"}, []string{"destination", "outcome"}),
+		WebhookEventsDelivered:  prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: "storm_etl", Name: "webhook_events_delivered_total"}, []string{"destination"}),
+		WebhookRequestDuration:  prometheus.NewHistogramVec(prometheus.HistogramOpts{Namespace: "storm_etl", Name: "webhook_request_duration_seconds"}, []string{"destination"}),
+		AlertLookups:            prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: "storm_etl", Name: "alert_lookups_total"}, []string{"result"}),
+		CommentRedactions:       prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: "storm_etl", Name: "comment_redactions_total"}, []string{"pattern"}),
+		OversizeEvents:          prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: "storm_etl", Name: "oversize_events_total"}, []string{"action"}),
+		StreamSubscribers:       prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "storm_etl", Name: "stream_subscribers"}),
+		StreamEventsDropped:     prometheus.NewCounter(prometheus.CounterOpts{Namespace: "storm_etl", Name: "stream_events_dropped_total"}),
+		TransformWorkers:        prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "storm_etl", Name: "transform_workers"}),
+		TransformWorkersBusy:    prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "storm_etl", Name: "transform_workers_busy"}),
+		BatchesInFlight:         prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "storm_etl", Name: "batches_in_flight"}),
+		MessagesUncommitted:     prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "storm_etl", Name: "messages_uncommitted"}),
+		AlertLookupsInFlight:    prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "storm_etl", Name: "alert_lookups_in_flight"}),
+		KafkaAssignedPartitions: prometheus.NewGaugeVec(prometheus.GaugeOpts{Namespace: "storm_etl", Name: "kafka_assigned_partitions"}, []string{"topic"}),
+		KafkaRebalancing:        prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "storm_etl", Name: "kafka_rebalance_in_progress"}),
+		RawMessageBytes:         prometheus.NewHistogram(prometheus.HistogramOpts{Namespace: "storm_etl", Name: "raw_message_bytes"}),
+		EventMessageBytes:       prometheus.NewHistogram(prometheus.HistogramOpts{Namespace: "storm_etl", Name: "event_message_bytes"}),
+		SourceLag:               prometheus.NewHistogramVec(prometheus.HistogramOpts{Namespace: "storm_etl", Name: "source_lag_seconds"}, []string{"event_type"}),
+		EventLatency:            prometheus.NewHistogramVec(prometheus.HistogramOpts{Namespace: "storm_etl", Name: "event_latency_seconds"}, []string{"event_type"}),
 		BatchSize:               prometheus.NewHistogram(prometheus.HistogramOpts{Namespace: "storm_etl", Name: "batch_size"}),
 		BatchProcessingDuration: prometheus.NewHistogram(prometheus.HistogramOpts{Namespace: "storm_etl", Name: "batch_processing_duration_seconds"}),
 	}
@@ -0,0 +1,113 @@
+package observability
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+)
+
+// logSampler limits how often a warning or error with the same level and
+// message is logged, so a poisoned input cannot flood the logs with identical
+// lines. Within each interval, starting at the first occurrence, the first
+// burst records are logged; later ones are counted and reported in a single
+// summary record when the interval ends. Records below warn, such as the
+// per-event audit log, are never sampled.
+type logSampler struct {
+	burst    int
+	interval time.Duration
+	clock    clockwork.Clock
+
+	mu      sync.Mutex
+	windows map[sampleKey]*sampleWindow
+}
+
+type sampleKey struct {
+	level   slog.Level
+	message string
+}
+
+type sampleWindow struct {
+	logged     int
+	suppressed int
+	// handler is the handler of the first suppressed record, so the summary
+	// carries that logger's attributes.
+	handler slog.Handler
+}
+
+func newLogSampler(burst int, interval time.Duration, clock clockwork.Clock) *logSampler {
+	return &logSampler{
+		burst:    burst,
+		interval: interval,
+		clock:    clock,
+		windows:  make(map[sampleKey]*sampleWindow),
+	}
+}
+
+// allow reports whether r should be logged, counting it against its window
+// otherwise.
+func (s *logSampler) allow(h slog.Handler, r slog.Record) bool {
+	if r.Level < slog.LevelWarn {
+		return true
+	}
+	key := sampleKey{level: r.Level, message: r.Message}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.windows[key]
+	if !ok {
+		w = &sampleWindow{}
+		s.windows[key] = w
+		s.clock.AfterFunc(s.interval, func() { s.flush(key) })
+	}
+	if w.logged < s.burst {
+		w.logged++
+		return true
+	}
+	if w.suppressed == 0 {
+		w.handler = h
+	}
+	w.suppressed++
+	return false
+}
+
+// flush ends the window for key and logs how many records it suppressed.
+func (s *logSampler) flush(key sampleKey) {
+	s.mu.Lock()
+	w := s.windows[key]
+	delete(s.windows, key)
+	s.mu.Unlock()
+	if w == nil || w.suppressed == 0 {
+		return
+	}
+	r := slog.NewRecord(s.clock.Now(), key.level, "repeated log messages suppressed", 0)
+	r.AddAttrs(
+		slog.String("message", key.message),
+		slog.Int("suppressed", w.suppressed),
+		slog.Duration("interval", s.interval),
+	)
+	_ = w.handler.Handle(context.Background(), r)
+}
+
+// samplingHandler drops records its sampler rejects before the wrapped
+// handler. Handlers derived with WithAttrs or WithGroup share the sampler.
+type samplingHandler struct {
+	slog.Handler
+	sampler *logSampler
+}
+
+func (h samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if !h.sampler.allow(h.Handler, r) {
+		return nil
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return samplingHandler{Handler: h.Handler.WithAttrs(attrs), sampler: h.sampler}
+}
+
+func (h samplingHandler) WithGroup(name string) slog.Handler {
+	return samplingHandler{Handler: h.Handler.WithGroup(name), sampler: h.sampler}
+}
@@ -0,0 +1,114 @@
+package observability
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingHandler keeps every record it handles, with the attributes added
+// through WithAttrs.
+type recordingHandler struct {
+	mu      *sync.Mutex
+	records *[]slog.Record
+	attrs   []slog.Attr
+}
+
+func newRecordingHandler() recordingHandler {
+	return recordingHandler{mu: new(sync.Mutex), records: new([]slog.Record)}
+}
+
+func (h recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	r = r.Clone()
+	r.AddAttrs(h.attrs...)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	*h.records = append(*h.records, r)
+	return nil
+}
+
+func (h recordingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return h
+}
+
+func (h recordingHandler) WithGroup(string) slog.Handler { return h }
+
+func (h recordingHandler) messages() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var msgs []string
+	for _, r := range *h.records {
+		msgs = append(msgs, r.Message)
+	}
+	return msgs
+}
+
+func (h recordingHandler) last() slog.Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return (*h.records)[len(*h.records)-1]
+}
+
+func recordAttrs(r slog.Record) map[string]any {
+	attrs := make(map[string]any)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.Any()
+		return true
+	})
+	return attrs
+}
+
+func TestSamplingHandler_SummarizesRepeatedWarnings(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	rec := newRecordingHandler()
+	logger := slog.New(samplingHandler{Handler: rec, sampler: newLogSampler(3, time.Minute, clock)})
+	component := logger.With("component", "pipeline")
+
+	for i := range 10 {
+		component.Warn("transform failed, skipping message", "offset", i)
+		logger.Info("transform audit", "offset", i)
+	}
+	logger.Error("load failed")
+
+	msgs := rec.messages()
+	assert.Len(t, msgs, 3+10+1, "three warnings, every info record, and the first error")
+	assert.Equal(t, "load failed", msgs[len(msgs)-1])
+
+	clock.Advance(time.Minute)
+	require.Eventually(t, func() bool { return len(rec.messages()) == 15 }, time.Second, time.Millisecond)
+	summary := rec.last()
+	assert.Equal(t, slog.LevelWarn, summary.Level)
+	assert.Equal(t, "repeated log messages suppressed", summary.Message)
+	assert.Equal(t, map[string]any{
+		"message":    "transform failed, skipping message",
+		"suppressed": int64(7),
+		"interval":   time.Minute,
+		"component":  "pipeline",
+	}, recordAttrs(summary))
+
+	// A new interval starts with a fresh burst.
+	component.Warn("transform failed, skipping message")
+	assert.Len(t, rec.messages(), 16)
+}
+
+func TestSamplingHandler_NoSummaryWithoutSuppression(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	rec := newRecordingHandler()
+	logger := slog.New(samplingHandler{Handler: rec, sampler: newLogSampler(3, time.Minute, clock)})
+
+	logger.Warn("consumer lag high")
+	logger.Warn("consumer lag high")
+	clock.Advance(time.Minute)
+	logger.Warn("consumer lag high")
+
+	assert.Equal(t, []string{"consumer lag high", "consumer lag high", "consumer lag high"}, rec.messages())
+}
@@ -0,0 +1,96 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/couchcryptid/storm-data-shared/retry"
+)
+
+// ErrLoaderCircuitOpen is reported by CheckReadiness while the loader circuit
+// breaker is open.
+var ErrLoaderCircuitOpen = errors.New("loader circuit open: sink is failing")
+
+// loaderBreaker tracks consecutive LoadBatch failures. Only the Run goroutine
+// touches the plain fields; open is also read by CheckReadiness.
+type loaderBreaker struct {
+	threshold int // 0 disables the breaker
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	trial     bool // cooldown elapsed; the next load is the trial
+	open      atomic.Bool
+}
+
+// WithLoaderCircuitBreaker stops extraction after threshold consecutive load
+// failures. While the circuit is open, CheckReadiness fails and no batches are
+// extracted; after cooldown a single trial batch is let through, which closes
+// the circuit on success or reopens it for another cooldown on failure. A
+// threshold below 1 disables the breaker, leaving load failures to back off
+// and retry indefinitely.
+func WithLoaderCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(p *Pipeline) {
+		p.breaker.threshold = max(threshold, 0)
+		p.breaker.cooldown = cooldown
+	}
+}
+
+// LoaderCircuitOpen reports whether the loader circuit breaker is open.
+func (p *Pipeline) LoaderCircuitOpen() bool {
+	return p.breaker.open.Load()
+}
+
+// loadFailed counts a failed load and opens the circuit once the threshold is
+// reached. A failed trial batch reopens it immediately.
+func (p *Pipeline) loadFailed() {
+	b := &p.breaker
+	if b.threshold == 0 {
+		return
+	}
+	b.failures++
+	if !b.open.Load() && b.failures < b.threshold {
+		return
+	}
+	b.openedAt = time.Now()
+	b.trial = false
+	if !b.open.Swap(true) {
+		p.metrics.LoaderCircuitOpen.Set(1)
+		p.metrics.LoaderCircuitTrips.Inc()
+		p.logger.Error("loader circuit opened, extraction stopped",
+			"consecutive_failures", b.failures, "cooldown", b.cooldown)
+	}
+}
+
+// loadSucceeded resets the failure count and closes an open circuit.
+func (p *Pipeline) loadSucceeded() {
+	b := &p.breaker
+	b.failures = 0
+	b.trial = false
+	if b.open.Swap(false) {
+		p.metrics.LoaderCircuitOpen.Set(0)
+		p.logger.Info("loader circuit closed, sink recovered")
+	}
+}
+
+// waitForCircuit blocks while the circuit is open until the cooldown since
+// the last failure has elapsed, then lets batches through until one reaches
+// the loader as the trial. On an idle source the circuit therefore stays open
+// until the next message arrives. Returns false if the context is cancelled
+// while waiting.
+func (p *Pipeline) waitForCircuit(ctx context.Context) bool {
+	b := &p.breaker
+	if !b.open.Load() || b.trial {
+		return true
+	}
+	if wait := b.cooldown - time.Since(b.openedAt); wait > 0 {
+		if !retry.SleepWithContext(ctx, wait) {
+			p.logger.Info("pipeline stopping", "reason", ctx.Err())
+			return false
+		}
+	}
+	b.trial = true
+	p.logger.Info("loader circuit half-open, trying one batch")
+	return true
+}
@@ -0,0 +1,82 @@
+package pipeline
+
+import (
+	"sync"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+)
+
+// Broadcaster fans loaded events out to live subscribers, such as streaming
+// API clients. Delivery is best effort: a subscriber whose buffer is full
+// misses events rather than slowing the pipeline, and nothing is replayed to
+// new or reconnecting subscribers.
+type Broadcaster struct {
+	mu      sync.Mutex
+	subs    map[*subscriber]struct{}
+	buffer  int
+	metrics *observability.Metrics
+}
+
+type subscriber struct {
+	events chan domain.StormEvent
+	filter domain.EventFilter
+}
+
+// NewBroadcaster creates a Broadcaster that buffers up to buffer events per
+// subscriber. Values below 1 are treated as 1.
+func NewBroadcaster(buffer int, metrics *observability.Metrics) *Broadcaster {
+	return &Broadcaster{
+		subs:    make(map[*subscriber]struct{}),
+		buffer:  max(buffer, 1),
+		metrics: metrics,
+	}
+}
+
+// Subscribe registers a subscriber for loaded events that match filter. The
+// returned cancel function unsubscribes and closes the channel; it is safe to
+// call more than once.
+func (b *Broadcaster) Subscribe(filter domain.EventFilter) (events <-chan domain.StormEvent, cancel func()) {
+	s := &subscriber{events: make(chan domain.StormEvent, b.buffer), filter: filter}
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.metrics.StreamSubscribers.Set(float64(len(b.subs)))
+	b.mu.Unlock()
+
+	var once sync.Once
+	return s.events, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, s)
+			b.metrics.StreamSubscribers.Set(float64(len(b.subs)))
+			b.mu.Unlock()
+			close(s.events)
+		})
+	}
+}
+
+// Publish offers each event to every subscriber whose filter it matches,
+// without blocking.
+func (b *Broadcaster) Publish(events []domain.StormEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subs {
+		for i := range events {
+			if ok, _ := s.filter.Match(events[i]); !ok {
+				continue
+			}
+			select {
+			case s.events <- events[i]:
+			default:
+				b.metrics.StreamEventsDropped.Inc()
+			}
+		}
+	}
+}
+
+// WithBroadcaster publishes each successfully loaded batch to b.
+func WithBroadcaster(b *Broadcaster) Option {
+	return func(p *Pipeline) {
+		p.broadcaster = b
+	}
+}
@@ -0,0 +1,21 @@
+package pipeline
+
+import (
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/google/uuid"
+)
+
+// assignCorrelationIDs gives every message without an upstream correlation_id
+// header a new one. The header is stored on the message itself, so a retried
+// batch keeps its IDs and dead letters carry them to the dead-letter topic.
+func assignCorrelationIDs(rawBatch []domain.RawEvent) {
+	for i := range rawBatch {
+		if rawBatch[i].Headers[domain.HeaderCorrelationID] != "" {
+			continue
+		}
+		if rawBatch[i].Headers == nil {
+			rawBatch[i].Headers = make(map[string]string, 1)
+		}
+		rawBatch[i].Headers[domain.HeaderCorrelationID] = uuid.NewString()
+	}
+}
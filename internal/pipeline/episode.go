@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"slices"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// maxEpisodeReports caps how many loaded reports are kept for linking later
+// reports to their episode, bounding memory during a large backfill.
+const maxEpisodeReports = 10000
+
+// WithEpisodeClustering assigns each loaded report an EpisodeID shared with
+// earlier reports within the tolerance, so consumers can aggregate per storm
+// rather than per report. A report close to none starts a new episode; one
+// close to several joins the episode of the earliest. Episodes are never
+// merged or split afterwards, so an assigned ID is stable.
+func WithEpisodeClustering(tolerance domain.EpisodeTolerance) Option {
+	return func(p *Pipeline) {
+		p.episodes = &episodeTracker{tolerance: tolerance}
+	}
+}
+
+// episodeTracker remembers recently loaded reports and their episodes. Only
+// the Run loop uses it, so it is not guarded.
+type episodeTracker struct {
+	tolerance domain.EpisodeTolerance
+	recent    []domain.StormEvent
+}
+
+// assign sets the EpisodeID of each event about to be loaded, linking it to
+// recently loaded reports and to the events before it in the batch. A report
+// loaded again keeps its episode. Retractions get none.
+func (t *episodeTracker) assign(events []domain.StormEvent, started prometheus.Counter) {
+	for i := range events {
+		e := &events[i]
+		if e.Deleted {
+			continue
+		}
+		if j := slices.IndexFunc(t.recent, func(r domain.StormEvent) bool { return r.ID == e.ID }); j >= 0 {
+			e.EpisodeID = t.recent[j].EpisodeID
+			continue
+		}
+		var earliest *domain.StormEvent
+		link := func(r *domain.StormEvent) {
+			if r.EpisodeID != "" && t.tolerance.SameEpisode(*r, *e) && (earliest == nil || r.EventTime.Before(earliest.EventTime)) {
+				earliest = r
+			}
+		}
+		for j := range t.recent {
+			link(&t.recent[j])
+		}
+		for j := range events[:i] {
+			link(&events[j])
+		}
+		if earliest != nil {
+			e.EpisodeID = earliest.EpisodeID
+			continue
+		}
+		e.EpisodeID = domain.NewEpisodeID(*e)
+		started.Inc()
+	}
+}
+
+// remember records the loaded events for linking later reports.
+func (t *episodeTracker) remember(loaded []domain.StormEvent) {
+	t.recent = rememberLoaded(t.recent, loaded, t.tolerance.Window, maxEpisodeReports)
+}
@@ -0,0 +1,12 @@
+package pipeline
+
+import "github.com/couchcryptid/storm-data-etl/internal/domain"
+
+// WithEventFilter drops enriched events that do not match f before they reach
+// the loader. Dropped events are counted by reason and their offsets are
+// committed with the rest of the batch, so they are not redelivered.
+func WithEventFilter(f domain.EventFilter) Option {
+	return func(p *Pipeline) {
+		p.filter = f
+	}
+}
@@ -0,0 +1,93 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// ComponentNamer is implemented by stages that name themselves in the health
+// report, e.g. "kafka_reader". Unnamed stages are reported as "extractor" or
+// "loader".
+type ComponentNamer interface {
+	ComponentName() string
+}
+
+// Loader circuit breaker states in the health report.
+const (
+	circuitClosed = "closed"
+	circuitOpen   = "open"
+)
+
+// namedChecker is a stage that supports readiness checks and its name in the
+// health report.
+type namedChecker struct {
+	name    string
+	checker ReadinessChecker
+}
+
+// Health checks every component that supports readiness checks, each
+// member of a MultiLoader separately, and reports every result with the
+// pipeline's own state. Unlike CheckReadiness it runs the checks concurrently
+// and does not stop at the first failure; Ready agrees with it.
+func (p *Pipeline) Health(ctx context.Context) domain.HealthReport {
+	checkers := readinessComponents(p.extractor, "extractor")
+	checkers = append(checkers, readinessComponents(p.loader, "loader")...)
+
+	components := make([]domain.ComponentHealth, len(checkers))
+	var wg sync.WaitGroup
+	for i, c := range checkers {
+		wg.Go(func() {
+			components[i] = domain.ComponentHealth{Name: c.name, Status: domain.HealthOK}
+			if err := c.checker.CheckReadiness(ctx); err != nil {
+				components[i].Status = domain.HealthError
+				components[i].Error = err.Error()
+			}
+		})
+	}
+	wg.Wait()
+
+	report := domain.HealthReport{
+		Ready:             true,
+		Components:        components,
+		Paused:            p.Paused(),
+		LoaderCircuit:     circuitClosed,
+		MessagesProcessed: p.Processed(),
+	}
+	if p.LoaderCircuitOpen() {
+		report.Ready = false
+		report.LoaderCircuit = circuitOpen
+	}
+	for _, c := range components {
+		if c.Status != domain.HealthOK {
+			report.Ready = false
+		}
+	}
+	if ns := p.lastBatchAt.Load(); ns != 0 {
+		report.LastBatchAt = time.Unix(0, ns).UTC()
+	}
+	return report
+}
+
+// readinessComponents lists the readiness checkers of a stage, one per member
+// of a MultiLoader.
+func readinessComponents(stage any, fallback string) []namedChecker {
+	if m, ok := stage.(MultiLoader); ok {
+		var checkers []namedChecker
+		for _, l := range m {
+			checkers = append(checkers, readinessComponents(l, fallback)...)
+		}
+		return checkers
+	}
+	rc, ok := stage.(ReadinessChecker)
+	if !ok {
+		return nil
+	}
+	name := fallback
+	if n, ok := stage.(ComponentNamer); ok {
+		name = n.ComponentName()
+	}
+	return []namedChecker{{name: name, checker: rc}}
+}
@@ -0,0 +1,54 @@
+package pipeline
+
+import (
+	"slices"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// uncommittedTracker counts the extracted messages whose offsets are not yet
+// committed. A commit covers every earlier offset of its partition, as a
+// Kafka commit does, so the messages of a batch that failed to load stop
+// counting once a later message of their partition is committed. Messages
+// without a Commit callback, from the file and SPC extractors, have nothing
+// to commit and are not counted. Only Run uses it, so it is not guarded.
+type uncommittedTracker struct {
+	offsets map[partitionKey][]int64
+	count   int
+}
+
+// extracted adds the batch's committable messages and returns the new count.
+func (t *uncommittedTracker) extracted(batch []domain.RawEvent) int {
+	if t.offsets == nil {
+		t.offsets = make(map[partitionKey][]int64)
+	}
+	for i := range batch {
+		raw := &batch[i]
+		if raw.Commit == nil {
+			continue
+		}
+		key := partitionKey{raw.Topic, raw.Partition}
+		t.offsets[key] = append(t.offsets[key], raw.Offset)
+		t.count++
+	}
+	return t.count
+}
+
+// committed removes the message and the earlier offsets of its partition and
+// returns the new count.
+func (t *uncommittedTracker) committed(raw domain.RawEvent) int {
+	key := partitionKey{raw.Topic, raw.Partition}
+	pending, ok := t.offsets[key]
+	if !ok {
+		return t.count
+	}
+	before := len(pending)
+	pending = slices.DeleteFunc(pending, func(offset int64) bool { return offset <= raw.Offset })
+	t.count -= before - len(pending)
+	if len(pending) == 0 {
+		delete(t.offsets, key)
+	} else {
+		t.offsets[key] = pending
+	}
+	return t.count
+}
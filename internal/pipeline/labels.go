@@ -0,0 +1,80 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// Label values for events whose type or state cannot be determined. Values
+// are bounded so malformed input cannot create unbounded metric series.
+const (
+	labelUnknown = "unknown"
+	labelOther   = "other"
+)
+
+// eventLabels are the event_type and state label values for a metric. state
+// is empty unless state labels are enabled.
+type eventLabels struct {
+	eventType string
+	state     string
+}
+
+// labelsForEvent returns the metric labels for an enriched event.
+func (p *Pipeline) labelsForEvent(event domain.StormEvent) eventLabels {
+	l := eventLabels{eventType: event.EventType}
+	if l.eventType == "" {
+		l.eventType = labelUnknown
+	}
+	if p.stateLabels {
+		l.state = stateLabel(event.Location.State)
+	}
+	return l
+}
+
+// labelsForRaw returns best-effort metric labels for a raw event that failed
+// to transform, read straight from the collector JSON. Unregistered types and
+// unparseable payloads are labeled "unknown".
+func (p *Pipeline) labelsForRaw(raw domain.RawEvent) eventLabels {
+	var rec struct {
+		EventType string `json:"EventType"`
+		State     string `json:"State"`
+	}
+	_ = json.Unmarshal(raw.Value, &rec)
+	return p.labelsForEvent(domain.StormEvent{
+		EventType: domain.CanonicalEventType(rec.EventType),
+		Location:  domain.Location{State: rec.State},
+	})
+}
+
+// errorType classifies a transform failure for the error_type label. Data
+// problems map to their category; anything else is reported as "internal"
+// because it points at a bug rather than bad input.
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrInvalidPayload):
+		return "invalid_json"
+	case errors.Is(err, domain.ErrUnknownEventType):
+		return "unknown_event_type"
+	case errors.Is(err, domain.ErrInvalidCoordinates):
+		return "invalid_coordinates"
+	default:
+		return "internal"
+	}
+}
+
+// stateLabel accepts two-letter state and territory codes and maps anything
+// else to "other" (or "unknown" when empty).
+func stateLabel(state string) string {
+	state = strings.ToUpper(strings.TrimSpace(state))
+	switch {
+	case state == "":
+		return labelUnknown
+	case len(state) == 2 && state[0] >= 'A' && state[0] <= 'Z' && state[1] >= 'A' && state[1] <= 'Z':
+		return state
+	default:
+		return labelOther
+	}
+}
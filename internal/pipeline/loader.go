@@ -0,0 +1,51 @@
+package pipeline
+
+import (
+	"context"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// MultiLoader fans each batch out to several loaders in order, e.g. the Kafka
+// sink followed by an archive. It stops at the first failure so the pipeline
+// retries the batch without committing offsets; loaders therefore see
+// redelivered events and should be idempotent.
+type MultiLoader []BatchLoader
+
+// CheckReadiness checks each loader that supports readiness checks in turn.
+func (m MultiLoader) CheckReadiness(ctx context.Context) error {
+	for _, l := range m {
+		if rc, ok := l.(ReadinessChecker); ok {
+			if err := rc.CheckReadiness(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// MessageBytes returns the largest message size reported by the loaders that
+// implement MessageSizer, or 0 when none does.
+func (m MultiLoader) MessageBytes(event domain.StormEvent) (int, error) {
+	largest := 0
+	for _, l := range m {
+		if ms, ok := l.(MessageSizer); ok {
+			n, err := ms.MessageBytes(event)
+			if err != nil {
+				return 0, err
+			}
+			largest = max(largest, n)
+		}
+	}
+	return largest, nil
+}
+
+// LoadBatch loads the batch into each loader in turn.
+func (m MultiLoader) LoadBatch(ctx context.Context, events []domain.StormEvent) error {
+	for _, l := range m {
+		if err := l.LoadBatch(ctx, events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package pipeline
+
+import (
+	"slices"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// maxMergeCandidates caps how many loaded reports are kept for matching
+// later near-duplicates, bounding memory during a large backfill.
+const maxMergeCandidates = 10000
+
+// WithDuplicateMerging merges near-duplicate reports of the same storm, such
+// as one tornado reported by two adjacent WFOs, into the higher-quality
+// report, which lists the others in MergedFrom. Reports are matched within
+// a batch and against recently loaded reports; when a later report wins, the
+// earlier one is retracted, and when it loses, the earlier one is reloaded
+// with its updated MergedFrom.
+func WithDuplicateMerging(tolerance domain.MergeTolerance) Option {
+	return func(p *Pipeline) {
+		p.merger = &duplicateMerger{tolerance: tolerance}
+	}
+}
+
+// duplicateMerger remembers recently loaded reports for matching. Only the
+// Run loop uses it, so it is not guarded.
+type duplicateMerger struct {
+	tolerance domain.MergeTolerance
+	recent    []domain.StormEvent
+}
+
+// mergeEntry is an event to load. owner indexes the pending commit that
+// produced it, or is -1 for a reloaded or retracted earlier report; loaded
+// marks a report already in the sink.
+type mergeEntry struct {
+	event  domain.StormEvent
+	owner  int
+	loaded bool
+}
+
+// merge returns the events to load for the processed messages that passed
+// the rules and filter, merging near-duplicates. Messages whose report was
+// merged into another are marked filtered so their offsets are committed
+// without being loaded.
+func (m *duplicateMerger) merge(processed []pendingCommit, merged prometheus.Counter) []domain.StormEvent {
+	var entries []mergeEntry
+	for k := range processed {
+		if processed[k].outcome != outcomeLoaded {
+			continue
+		}
+		event := processed[k].event
+		var i int
+		entries, i = m.match(entries, event)
+		if i < 0 {
+			entries = append(entries, mergeEntry{event: event, owner: k})
+			continue
+		}
+		merged.Inc()
+
+		e := &entries[i]
+		kept, _ := domain.MergeDuplicates(e.event, event)
+		if kept.ID != event.ID {
+			processed[k].outcome = outcomeFiltered
+			e.event = kept
+			continue
+		}
+		if e.owner >= 0 {
+			processed[e.owner].outcome = outcomeFiltered
+		}
+		if !e.loaded {
+			*e = mergeEntry{event: kept, owner: k}
+			continue
+		}
+		retraction := e.event
+		retraction.Deleted = true
+		*e = mergeEntry{event: retraction, owner: -1}
+		entries = append(entries, mergeEntry{event: kept, owner: k})
+	}
+
+	out := make([]domain.StormEvent, len(entries))
+	for i := range entries {
+		out[i] = entries[i].event
+	}
+	return out
+}
+
+// match returns the index of the entry that event duplicates, adding the
+// matching recently loaded report as an entry when no entry matches. The
+// index is -1 when event is not a near-duplicate.
+func (m *duplicateMerger) match(entries []mergeEntry, event domain.StormEvent) ([]mergeEntry, int) {
+	if i := slices.IndexFunc(entries, func(e mergeEntry) bool { return m.tolerance.IsNearDuplicate(e.event, event) }); i >= 0 {
+		return entries, i
+	}
+	for _, r := range m.recent {
+		inBatch := slices.ContainsFunc(entries, func(e mergeEntry) bool { return e.event.ID == r.ID })
+		if !inBatch && m.tolerance.IsNearDuplicate(r, event) {
+			return append(entries, mergeEntry{event: r, owner: -1, loaded: true}), len(entries)
+		}
+	}
+	return entries, -1
+}
+
+// remember records the loaded events as candidates for later matches.
+func (m *duplicateMerger) remember(loaded []domain.StormEvent) {
+	m.recent = rememberLoaded(m.recent, loaded, m.tolerance.Window, maxMergeCandidates)
+}
+
+// rememberLoaded adds loaded events to recent, replacing earlier copies with
+// the same ID and dropping retracted ones, then forgets reports more than
+// window older than the newest one and the oldest beyond limit.
+func rememberLoaded(recent, loaded []domain.StormEvent, window time.Duration, limit int) []domain.StormEvent {
+	for _, e := range loaded {
+		recent = slices.DeleteFunc(recent, func(r domain.StormEvent) bool { return r.ID == e.ID })
+		if !e.Deleted {
+			recent = append(recent, e)
+		}
+	}
+	if len(recent) == 0 {
+		return recent
+	}
+	newest := slices.MaxFunc(recent, func(a, b domain.StormEvent) int { return a.EventTime.Compare(b.EventTime) }).EventTime
+	cutoff := newest.Add(-window)
+	recent = slices.DeleteFunc(recent, func(r domain.StormEvent) bool { return r.EventTime.Before(cutoff) })
+	if over := len(recent) - limit; over > 0 {
+		recent = slices.Delete(recent, 0, over)
+	}
+	return recent
+}
@@ -49,8 +49,11 @@ func TestStormTransformer_WithMockJSONData(t *testing.T) {
 				assert.Equal(t, tc.eventType, event.EventType)
 				assert.Equal(t, tc.expectedUnit, event.Measurement.Unit)
 				assert.Equal(t, row["State"], event.Location.State)
-				assert.Equal(t, row["County"], event.Location.County)
+				assert.Equal(t, strings.Replace(row["County"], "St. ", "Saint ", 1), event.Location.County)
 				assert.True(t, strings.HasPrefix(event.ID, tc.eventType+"-"))
+				if event.SourceOffice != "" {
+					assert.NotNil(t, event.SourceOfficeDetail, "unknown WFO %q", event.SourceOffice)
+				}
 			}
 		})
 	}
@@ -0,0 +1,52 @@
+package pipeline
+
+import (
+	"strconv"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// WithOrderedProcessing preserves per-partition ordering end to end. Each
+// source partition's messages in a batch are transformed in order by a single
+// worker, and every event is stamped with an OrderingKey derived from its
+// source message so the sink can keep related events on one partition.
+func WithOrderedProcessing() Option {
+	return func(p *Pipeline) {
+		p.ordered = true
+	}
+}
+
+// transformGroups splits the batch into index groups that must be transformed
+// sequentially: one group per source partition in ordered mode, otherwise one
+// group per message.
+func (p *Pipeline) transformGroups(rawBatch []domain.RawEvent) [][]int {
+	if !p.ordered {
+		groups := make([][]int, len(rawBatch))
+		for i := range rawBatch {
+			groups[i] = []int{i}
+		}
+		return groups
+	}
+	var groups [][]int
+	index := make(map[partitionKey]int)
+	for i, raw := range rawBatch {
+		key := partitionKey{raw.Topic, raw.Partition}
+		g, ok := index[key]
+		if !ok {
+			g = len(groups)
+			index[key] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
+
+// orderingKey is the source message key, or "<topic>/<partition>" for unkeyed
+// messages so they stay together as well.
+func orderingKey(raw domain.RawEvent) string {
+	if len(raw.Key) > 0 {
+		return string(raw.Key)
+	}
+	return raw.Topic + "/" + strconv.Itoa(raw.Partition)
+}
@@ -2,14 +2,19 @@ package pipeline
 
 import (
 	"context"
-	"errors"
 	"log/slog"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/couchcryptid/storm-data-etl/internal/domain"
 	"github.com/couchcryptid/storm-data-etl/internal/observability"
 	"github.com/couchcryptid/storm-data-shared/retry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 )
 
 // BatchExtractor reads up to batchSize raw events from the source.
@@ -18,6 +23,8 @@ type BatchExtractor interface {
 }
 
 // Transformer converts a raw event into a domain storm event.
+// Implementations must be safe for concurrent use when the pipeline is
+// configured with more than one transform worker.
 type Transformer interface {
 	Transform(ctx context.Context, raw domain.RawEvent) (domain.StormEvent, error)
 }
@@ -27,43 +34,189 @@ type BatchLoader interface {
 	LoadBatch(ctx context.Context, events []domain.StormEvent) error
 }
 
+// DeadLetterLoader publishes raw events that failed transformation.
+type DeadLetterLoader interface {
+	LoadDeadLetters(ctx context.Context, letters []domain.DeadLetter) error
+}
+
+// ReadinessChecker is implemented by extractors and loaders that can verify
+// their backing service, e.g. broker reachability and consumer group membership.
+type ReadinessChecker interface {
+	CheckReadiness(ctx context.Context) error
+}
+
 // Pipeline orchestrates the extract-transform-load loop.
 type Pipeline struct {
 	extractor   BatchExtractor
 	transformer Transformer
 	loader      BatchLoader
+	deadLetter  DeadLetterLoader
+	broadcaster *Broadcaster
 	logger      *slog.Logger
 	metrics     *observability.Metrics
-	ready       atomic.Bool
-	batchSize   int
+	processed   atomic.Bool
+	lastBatchAt atomic.Int64 // unix nanoseconds of the last completed batch
+	paused      atomic.Bool
+	resumed     chan struct{} // signalled by Resume to wake a paused Run loop
+	batchSize   atomic.Int64
+	limiter     *rate.Limiter
+	breaker     loaderBreaker
+	filter      domain.EventFilter
+	rules       []domain.ValidationRule
+	sizeGuard   *sizeGuard
+	merger      *duplicateMerger
+	episodes    *episodeTracker
+	concurrency int
+	ordered     bool
+	provenance  []string
+	stateLabels bool
+	flush       time.Duration
+	tracer      trace.Tracer
+	propagator  propagation.TextMapPropagator
+
+	// Committed offsets per source partition, optionally persisted.
+	progress      progressTracker
+	progressStore ProgressStore
+	uncommitted   uncommittedTracker
+}
+
+// Option configures optional Pipeline behavior.
+type Option func(*Pipeline)
+
+// WithDeadLetter routes messages that fail transformation to the given loader
+// before their offsets are committed. Without it, failures are only logged.
+func WithDeadLetter(dl DeadLetterLoader) Option {
+	return func(p *Pipeline) {
+		p.deadLetter = dl
+	}
+}
+
+// WithTransformConcurrency sets the number of workers that transform a batch
+// in parallel. Values below 1 are treated as 1 (serial transformation).
+func WithTransformConcurrency(n int) Option {
+	return func(p *Pipeline) {
+		p.concurrency = max(n, 1)
+	}
+}
+
+// WithFlushInterval bounds how long the pipeline spends filling one batch.
+// Extraction is repeated until the batch is full or the interval elapses, and
+// whatever has been collected by then is transformed and loaded, regardless of
+// how long the extractor itself would block. Zero disables accumulation and
+// uses each ExtractBatch result as-is.
+func WithFlushInterval(d time.Duration) Option {
+	return func(p *Pipeline) {
+		p.flush = d
+	}
+}
+
+// WithStateLabels adds the event's state to the per-event-type metrics.
+func WithStateLabels() Option {
+	return func(p *Pipeline) {
+		p.stateLabels = true
+	}
+}
+
+// WithTracing sets the tracer provider and propagator used for pipeline spans
+// and trace-context propagation. Defaults to the OpenTelemetry globals.
+func WithTracing(tp trace.TracerProvider, propagator propagation.TextMapPropagator) Option {
+	return func(p *Pipeline) {
+		p.tracer = tp.Tracer(tracerName)
+		p.propagator = propagator
+	}
 }
 
 // New creates a Pipeline with the given stages and observability.
-func New(e BatchExtractor, t Transformer, l BatchLoader, logger *slog.Logger, metrics *observability.Metrics, batchSize int) *Pipeline {
-	return &Pipeline{
+func New(e BatchExtractor, t Transformer, l BatchLoader, logger *slog.Logger, metrics *observability.Metrics, batchSize int, opts ...Option) *Pipeline {
+	p := &Pipeline{
 		extractor:   e,
 		transformer: t,
 		loader:      l,
 		logger:      logger,
 		metrics:     metrics,
-		batchSize:   batchSize,
+		concurrency: 1,
+		resumed:     make(chan struct{}, 1),
+		limiter:     rate.NewLimiter(rate.Inf, 1),
+		tracer:      otel.Tracer(tracerName),
+		propagator:  otel.GetTextMapPropagator(),
+	}
+	p.batchSize.Store(int64(batchSize))
+	for _, opt := range opts {
+		opt(p)
 	}
+	return p
 }
 
-// CheckReadiness returns nil if the pipeline has processed at least one message,
-// or an error describing why the service is not yet ready.
-func (p *Pipeline) CheckReadiness(_ context.Context) error {
-	if !p.ready.Load() {
-		return errors.New("pipeline has not processed any messages yet")
+// CheckReadiness returns ErrLoaderCircuitOpen while the loader circuit
+// breaker is open. Otherwise it returns nil when the extractor and loader that
+// support readiness checks report their backing services as reachable, or the
+// first error. It does not wait for a message, so a deployment against an idle topic
+// still becomes ready; see Processed for that signal.
+func (p *Pipeline) CheckReadiness(ctx context.Context) error {
+	if p.LoaderCircuitOpen() {
+		return ErrLoaderCircuitOpen
+	}
+	for _, stage := range []any{p.extractor, p.loader} {
+		if rc, ok := stage.(ReadinessChecker); ok {
+			if err := rc.CheckReadiness(ctx); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
 
+// Processed reports whether the pipeline has loaded at least one batch.
+func (p *Pipeline) Processed() bool {
+	return p.processed.Load()
+}
+
+// Pause stops extraction after the batch in progress completes. The source
+// stays connected (a Kafka consumer keeps its partition assignments), so
+// Resume continues from the last committed offsets.
+func (p *Pipeline) Pause() {
+	if !p.paused.Swap(true) {
+		p.metrics.PipelinePaused.Set(1)
+		p.logger.Info("pipeline paused")
+	}
+}
+
+// Resume restarts extraction after Pause.
+func (p *Pipeline) Resume() {
+	if p.paused.Swap(false) {
+		p.metrics.PipelinePaused.Set(0)
+		p.logger.Info("pipeline resumed")
+		select {
+		case p.resumed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// SetBatchSize changes the number of events extracted per batch, starting
+// with the next batch. Values below 1 are ignored.
+func (p *Pipeline) SetBatchSize(n int) {
+	if n < 1 {
+		return
+	}
+	if old := p.batchSize.Swap(int64(n)); old != int64(n) {
+		p.logger.Info("pipeline batch size changed", "from", old, "to", n)
+	}
+}
+
+// Paused reports whether extraction is currently paused.
+func (p *Pipeline) Paused() bool {
+	return p.paused.Load()
+}
+
 // Run executes the batch ETL loop until the context is cancelled.
 func (p *Pipeline) Run(ctx context.Context) error {
-	p.logger.Info("pipeline started", "batch_size", p.batchSize)
+	p.logger.Info("pipeline started", "batch_size", p.batchSize.Load(), "flush_interval", p.flush, "transform_concurrency", p.concurrency, "ordered", p.ordered, "rate_limit", p.RateLimit())
 	p.metrics.PipelineRunning.Set(1)
+	p.metrics.TransformWorkers.Set(float64(p.concurrency))
+	p.metrics.RateLimit.Set(p.RateLimit())
 	defer p.metrics.PipelineRunning.Set(0)
+	p.loadProgress(ctx)
 
 	// Exponential backoff: start at 200ms, double each retry, cap at 5s.
 	// Keeps retry storms short while avoiding tight loops during Kafka outages.
@@ -78,17 +231,34 @@ func (p *Pipeline) Run(ctx context.Context) error {
 		default:
 		}
 
+		if !p.waitWhilePaused(ctx) || !p.waitForCircuit(ctx) {
+			return nil
+		}
 		if !p.processBatch(ctx, &backoff, maxBackoff) {
 			return nil
 		}
 	}
 }
 
+// waitWhilePaused blocks until the pipeline is resumed. Returns false if the
+// context is cancelled while waiting.
+func (p *Pipeline) waitWhilePaused(ctx context.Context) bool {
+	for p.paused.Load() {
+		select {
+		case <-ctx.Done():
+			p.logger.Info("pipeline stopping", "reason", ctx.Err())
+			return false
+		case <-p.resumed:
+		}
+	}
+	return true
+}
+
 // processBatch runs one extract-transform-load cycle. Returns false if the pipeline should stop.
 func (p *Pipeline) processBatch(ctx context.Context, backoff *time.Duration, maxBackoff time.Duration) bool {
 	start := time.Now()
 
-	rawBatch, err := p.extractor.ExtractBatch(ctx, p.batchSize)
+	rawBatch, err := p.extractBatch(ctx)
 	if err != nil {
 		if ctx.Err() != nil {
 			return false
@@ -100,63 +270,320 @@ func (p *Pipeline) processBatch(ctx context.Context, backoff *time.Duration, max
 	if len(rawBatch) == 0 {
 		return ctx.Err() == nil
 	}
+	if !p.throttle(ctx, len(rawBatch)) {
+		return false
+	}
 
 	p.metrics.MessagesConsumed.Add(float64(len(rawBatch)))
 	p.metrics.BatchSize.Observe(float64(len(rawBatch)))
+	p.metrics.MessagesUncommitted.Set(float64(p.uncommitted.extracted(rawBatch)))
+	p.metrics.BatchesInFlight.Inc()
+	defer p.metrics.BatchesInFlight.Dec()
 	*backoff = 200 * time.Millisecond
 
+	// Batch spans start retroactively so idle polls that return nothing are not traced.
+	ctx, span := p.tracer.Start(ctx, "process batch",
+		trace.WithTimestamp(start),
+		trace.WithLinks(p.messageLinks(rawBatch)...),
+		trace.WithAttributes(attribute.Int("batch.size", len(rawBatch))),
+	)
+	defer span.End()
+	_, extractSpan := p.tracer.Start(ctx, "extract", trace.WithTimestamp(start))
+	extractSpan.End()
+
 	loaded, ok := p.transformAndLoad(ctx, rawBatch, backoff, maxBackoff)
+	p.saveProgress(ctx)
 	if !ok {
 		return false
 	}
 
 	if loaded > 0 {
 		p.metrics.BatchProcessingDuration.Observe(time.Since(start).Seconds())
-		p.ready.Store(true)
+		p.processed.Store(true)
 	}
 	return true
 }
 
-// transformAndLoad transforms each message in the batch, loads the successes,
-// and commits offsets. Returns the number of successfully loaded messages and
-// false if the pipeline should stop.
+// extractBatch collects up to batchSize raw events. With a flush interval it
+// keeps extracting until the batch is full, the interval elapses, or the
+// extractor has nothing more, so partial batches never wait longer than the
+// interval. An extract error after some events were collected flushes them
+// and is left for the next cycle to surface.
+func (p *Pipeline) extractBatch(ctx context.Context) ([]domain.RawEvent, error) {
+	batchSize := int(p.batchSize.Load())
+	if p.flush <= 0 {
+		return p.extractor.ExtractBatch(ctx, batchSize)
+	}
+
+	fillCtx, cancel := context.WithTimeout(ctx, p.flush)
+	defer cancel()
+
+	var batch []domain.RawEvent
+	for len(batch) < batchSize {
+		more, err := p.extractor.ExtractBatch(fillCtx, batchSize-len(batch))
+		batch = append(batch, more...)
+		if err != nil {
+			switch {
+			case fillCtx.Err() == nil && len(batch) > 0:
+				p.logger.Warn("extract failed, flushing partial batch", "error", err, "batch_size", len(batch))
+			case fillCtx.Err() == nil, ctx.Err() != nil && len(batch) == 0:
+				return nil, err
+			}
+			// Otherwise the flush interval elapsed: flush what was collected.
+			break
+		}
+		if len(more) == 0 || fillCtx.Err() != nil {
+			break
+		}
+	}
+	return batch, nil
+}
+
+// transformAndLoad transforms each message in the batch, loads the successes
+// that pass the validation rules and event filter, optionally merging
+// near-duplicates and assigning episodes, dead-letters the failures and quarantined events, and
+// commits offsets. The dead-letter write and the load are retried in place
+// until they succeed, because the source has already moved past the batch:
+// abandoning it would let the next batch's cumulative commit skip its
+// messages. Offsets are committed in source order only once both have
+// succeeded, so a commit never covers a message that is not yet durable.
+// Returns the number of successfully loaded messages and false if the
+// pipeline should stop.
 func (p *Pipeline) transformAndLoad(ctx context.Context, rawBatch []domain.RawEvent, backoff *time.Duration, maxBackoff time.Duration) (int, bool) {
 	outBatch := make([]domain.StormEvent, 0, len(rawBatch))
-	successfulRaws := make([]domain.RawEvent, 0, len(rawBatch))
+	processed := make([]pendingCommit, 0, len(rawBatch))
+	var failed []domain.DeadLetter
 
-	for _, raw := range rawBatch {
-		out, err := p.transformer.Transform(ctx, raw)
+	assignCorrelationIDs(rawBatch)
+	results := p.transformBatch(ctx, rawBatch)
+	for i, raw := range rawBatch {
+		out, err := results[i].event, results[i].err
 		if err != nil {
 			p.logger.Warn("transform failed, skipping message",
 				"error", err,
+				"error_type", errorType(err),
 				"topic", raw.Topic,
 				"partition", raw.Partition,
 				"offset", raw.Offset,
+				"correlation_id", raw.Headers[domain.HeaderCorrelationID],
 			)
-			p.metrics.TransformErrors.Inc()
-			p.commitOffset(ctx, raw)
+			l := p.labelsForRaw(raw)
+			p.metrics.TransformErrors.WithLabelValues(l.eventType, l.state, errorType(err)).Inc()
+			failed = append(failed, domain.DeadLetter{Event: raw, Reason: err.Error(), FailedAt: time.Now().UTC()})
+			processed = append(processed, pendingCommit{raw: raw, outcome: outcomeDeadLettered})
+			continue
+		}
+		if rule, blocked := p.applyRules(&out); blocked {
+			if rule.Action == domain.RuleActionQuarantine {
+				p.logger.Warn("validation rule quarantined message",
+					"check", rule.Check,
+					"event_id", out.ID,
+					"topic", raw.Topic,
+					"partition", raw.Partition,
+					"offset", raw.Offset,
+					"correlation_id", raw.Headers[domain.HeaderCorrelationID],
+				)
+				failed = append(failed, domain.DeadLetter{Event: raw, Reason: "validation rule: " + rule.Check, FailedAt: time.Now().UTC()})
+				processed = append(processed, pendingCommit{raw: raw, outcome: outcomeDeadLettered})
+				continue
+			}
+			processed = append(processed, pendingCommit{raw: raw, event: out, outcome: outcomeFiltered})
+			continue
+		}
+		if ok, reason := p.filter.Match(out); !ok {
+			p.metrics.EventsFiltered.WithLabelValues(reason).Inc()
+			processed = append(processed, pendingCommit{raw: raw, event: out, outcome: outcomeFiltered})
 			continue
 		}
+		if p.sizeGuard != nil {
+			fitted, action, err := p.sizeGuard.fit(out)
+			if action != "" {
+				p.metrics.OversizeEvents.WithLabelValues(action).Inc()
+			}
+			if err != nil {
+				p.logger.Warn("oversized message dead-lettered",
+					"error", err,
+					"event_id", out.ID,
+					"topic", raw.Topic,
+					"partition", raw.Partition,
+					"offset", raw.Offset,
+					"correlation_id", raw.Headers[domain.HeaderCorrelationID],
+				)
+				failed = append(failed, domain.DeadLetter{Event: raw, Reason: err.Error(), FailedAt: time.Now().UTC()})
+				processed = append(processed, pendingCommit{raw: raw, outcome: outcomeDeadLettered})
+				continue
+			}
+			out = fitted
+		}
 		outBatch = append(outBatch, out)
-		successfulRaws = append(successfulRaws, raw)
+		processed = append(processed, pendingCommit{raw: raw, event: out, outcome: outcomeLoaded})
+	}
+	if p.merger != nil {
+		outBatch = p.merger.merge(processed, p.metrics.EventsMerged)
+	}
+	if p.episodes != nil {
+		p.episodes.assign(outBatch, p.metrics.EpisodesStarted)
 	}
 
-	if len(outBatch) == 0 {
-		return 0, true
+	for len(failed) > 0 && !p.loadDeadLetters(ctx, failed) {
+		if !p.backoffOrStop(ctx, backoff, maxBackoff) {
+			return 0, false
+		}
+	}
+
+	if len(outBatch) > 0 {
+		if !p.loadWithRetry(ctx, outBatch, backoff, maxBackoff) {
+			return 0, false
+		}
+		if p.merger != nil {
+			p.merger.remember(outBatch)
+		}
+		if p.episodes != nil {
+			p.episodes.remember(outBatch)
+		}
+		p.recordProduced(outBatch)
+		p.recordLatency(processed)
+		if p.broadcaster != nil {
+			p.broadcaster.Publish(outBatch)
+		}
 	}
 
-	if err := p.loader.LoadBatch(ctx, outBatch); err != nil {
-		p.logger.Error("load batch failed", "error", err, "batch_size", len(outBatch))
-		return 0, p.backoffOrStop(ctx, backoff, maxBackoff)
+	if len(processed) > 0 {
+		p.commitOffsets(ctx, processed)
 	}
+	p.lastBatchAt.Store(time.Now().UnixNano())
+	return len(outBatch), true
+}
 
-	p.metrics.MessagesProduced.Add(float64(len(outBatch)))
+// loadWithRetry loads the batch, retrying with backoff until it succeeds.
+// Failures count towards the loader circuit breaker, and while the circuit is
+// open the retries wait out its cooldown, so the next attempt is the trial.
+// Returns false if the context is cancelled first.
+func (p *Pipeline) loadWithRetry(ctx context.Context, events []domain.StormEvent, backoff *time.Duration, maxBackoff time.Duration) bool {
+	for {
+		loadCtx, span := p.tracer.Start(ctx, "load", trace.WithAttributes(attribute.Int("batch.size", len(events))))
+		err := p.loader.LoadBatch(loadCtx, events)
+		endSpan(span, err)
+		if err == nil {
+			p.loadSucceeded()
+			return true
+		}
+		p.logger.Error("load batch failed", "error", err, "batch_size", len(events))
+		p.loadFailed()
+		if !p.backoffOrStop(ctx, backoff, maxBackoff) || !p.waitForCircuit(ctx) {
+			return false
+		}
+	}
+}
 
-	for _, raw := range successfulRaws {
-		p.commitOffset(ctx, raw)
+// recordProduced counts loaded events by their metric labels.
+func (p *Pipeline) recordProduced(events []domain.StormEvent) {
+	counts := make(map[eventLabels]int)
+	for i := range events {
+		counts[p.labelsForEvent(events[i])]++
+	}
+	for l, n := range counts {
+		p.metrics.MessagesProduced.WithLabelValues(l.eventType, l.state).Add(float64(n))
 	}
+}
 
-	return len(outBatch), true
+// recordLatency observes, for every loaded message, the time since its source
+// message timestamp and since its event time. Messages without either time
+// are skipped, and clock skew that puts a time in the future counts as zero.
+func (p *Pipeline) recordLatency(processed []pendingCommit) {
+	now := time.Now()
+	for i := range processed {
+		c := &processed[i]
+		if c.outcome != outcomeLoaded {
+			continue
+		}
+		eventType := p.labelsForEvent(c.event).eventType
+		if !c.raw.Timestamp.IsZero() {
+			p.metrics.SourceLag.WithLabelValues(eventType).Observe(max(now.Sub(c.raw.Timestamp), 0).Seconds())
+		}
+		if !c.event.EventTime.IsZero() {
+			p.metrics.EventLatency.WithLabelValues(eventType).Observe(max(now.Sub(c.event.EventTime), 0).Seconds())
+		}
+	}
+}
+
+// transformResult holds the outcome of transforming one raw event.
+type transformResult struct {
+	event domain.StormEvent
+	err   error
+}
+
+// transformBatch transforms every message in the batch using up to
+// p.concurrency workers. Results are indexed by batch position so that
+// offsets are committed in source order regardless of completion order. In
+// ordered mode a worker takes a whole source partition at a time.
+func (p *Pipeline) transformBatch(ctx context.Context, rawBatch []domain.RawEvent) []transformResult {
+	results := make([]transformResult, len(rawBatch))
+	batchLink := trace.LinkFromContext(ctx)
+	transform := func(i int) {
+		p.metrics.TransformWorkersBusy.Inc()
+		defer p.metrics.TransformWorkersBusy.Dec()
+		correlationID := rawBatch[i].Headers[domain.HeaderCorrelationID]
+		msgCtx, span := p.tracer.Start(p.messageContext(ctx, rawBatch[i]), "transform",
+			trace.WithLinks(batchLink), messageAttributes(rawBatch[i]))
+		msgCtx = observability.WithCorrelationID(msgCtx, correlationID)
+		event, err := p.transformer.Transform(msgCtx, rawBatch[i])
+		if err == nil {
+			event.CorrelationID = correlationID
+			event.TraceContext = p.traceContext(msgCtx)
+			event.Provenance = p.provenanceOf(rawBatch[i])
+			if p.ordered {
+				event.OrderingKey = orderingKey(rawBatch[i])
+			}
+		}
+		endSpan(span, err)
+		results[i] = transformResult{event: event, err: err}
+	}
+
+	groups := p.transformGroups(rawBatch)
+	workers := min(p.concurrency, len(groups))
+	if workers <= 1 {
+		for i := range rawBatch {
+			transform(i)
+		}
+		return results
+	}
+
+	jobs := make(chan []int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for group := range jobs {
+				for _, i := range group {
+					transform(i)
+				}
+			}
+		})
+	}
+	for _, group := range groups {
+		jobs <- group
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// loadDeadLetters publishes failed messages to the dead-letter loader, if one
+// is configured. Returns false if the write failed.
+func (p *Pipeline) loadDeadLetters(ctx context.Context, letters []domain.DeadLetter) bool {
+	if p.deadLetter == nil {
+		return true
+	}
+	dlCtx, span := p.tracer.Start(ctx, "dead letter", trace.WithAttributes(attribute.Int("batch.size", len(letters))))
+	err := p.deadLetter.LoadDeadLetters(dlCtx, letters)
+	endSpan(span, err)
+	if err != nil {
+		p.logger.Error("dead-letter write failed", "error", err, "count", len(letters))
+		p.metrics.DeadLetterErrors.Inc()
+		return false
+	}
+	p.metrics.DeadLetterMessages.Add(float64(len(letters)))
+	return true
 }
 
 // backoffOrStop checks for context cancellation, sleeps with the current backoff,
@@ -172,13 +599,29 @@ func (p *Pipeline) backoffOrStop(ctx context.Context, backoff *time.Duration, ma
 	return true
 }
 
+// commitOffsets commits each message's offset in batch order under one span
+// and records the committed ones as progress.
+func (p *Pipeline) commitOffsets(ctx context.Context, pending []pendingCommit) {
+	ctx, span := p.tracer.Start(ctx, "commit", trace.WithAttributes(attribute.Int("batch.size", len(pending))))
+	defer span.End()
+	for i := range pending {
+		if p.commitOffset(ctx, pending[i].raw) {
+			p.progress.record(&pending[i])
+			p.metrics.MessagesUncommitted.Set(float64(p.uncommitted.committed(pending[i].raw)))
+		}
+	}
+}
+
 // commitOffset commits the message offset if a commit function is available.
-func (p *Pipeline) commitOffset(ctx context.Context, raw domain.RawEvent) {
+// Returns false if the commit failed.
+func (p *Pipeline) commitOffset(ctx context.Context, raw domain.RawEvent) bool {
 	if raw.Commit == nil {
-		return
+		return true
 	}
 	if err := raw.Commit(ctx); err != nil {
 		p.logger.Warn("commit offset failed", "error", err,
 			"topic", raw.Topic, "partition", raw.Partition, "offset", raw.Offset)
+		return false
 	}
+	return true
 }
@@ -1,10 +1,14 @@
 package pipeline_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -12,52 +16,18 @@ import (
 	"github.com/couchcryptid/storm-data-etl/internal/domain"
 	"github.com/couchcryptid/storm-data-etl/internal/observability"
 	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/couchcryptid/storm-data-etl/stormtest"
 	"github.com/jonboulle/clockwork"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
-// --- mocks ---
-
-type mockBatchExtractor struct {
-	batches [][]domain.RawEvent
-	index   atomic.Int64
-}
-
-func (m *mockBatchExtractor) ExtractBatch(ctx context.Context, _ int) ([]domain.RawEvent, error) {
-	i := int(m.index.Add(1) - 1)
-	if i >= len(m.batches) {
-		// block until context cancelled to simulate waiting for messages
-		<-ctx.Done()
-		return nil, ctx.Err()
-	}
-	return m.batches[i], nil
-}
-
-type mockTransformer struct {
-	err error
-}
-
-func (m *mockTransformer) Transform(_ context.Context, raw domain.RawEvent) (domain.StormEvent, error) {
-	if m.err != nil {
-		return domain.StormEvent{}, m.err
-	}
-	var event domain.StormEvent
-	if err := json.Unmarshal(raw.Value, &event); err != nil {
-		return domain.StormEvent{}, err
-	}
-	return event, nil
-}
-
-type mockBatchLoader struct {
-	batches [][]domain.StormEvent
-}
-
-func (m *mockBatchLoader) LoadBatch(_ context.Context, events []domain.StormEvent) error {
-	m.batches = append(m.batches, events)
-	return nil
-}
-
 func newTestMetrics() *observability.Metrics {
 	// Use a fresh registry to avoid "already registered" panics in tests.
 	return observability.NewMetricsForTesting()
@@ -70,9 +40,9 @@ const testBatchSize = 50
 func TestPipeline_Run_HappyPath(t *testing.T) {
 	raw := makeRawEvent(t, "evt-1", "hail")
 
-	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{raw}}}
-	transformer := &mockTransformer{}
-	loader := &mockBatchLoader{}
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{raw}}}
+	transformer := &stormtest.Transformer{}
+	loader := &stormtest.Loader{}
 	metrics := newTestMetrics()
 
 	p := pipeline.New(ext, transformer, loader, slog.Default(), metrics, testBatchSize)
@@ -82,19 +52,19 @@ func TestPipeline_Run_HappyPath(t *testing.T) {
 
 	err := p.Run(ctx)
 	require.NoError(t, err)
-	require.Len(t, loader.batches, 1)
-	assert.Len(t, loader.batches[0], 1)
-	assert.Equal(t, "evt-1", loader.batches[0][0].ID)
-	assert.NoError(t, p.CheckReadiness(context.Background()))
+	require.Len(t, loader.Batches(), 1)
+	assert.Len(t, loader.Batches()[0], 1)
+	assert.Equal(t, "evt-1", loader.Batches()[0][0].ID)
+	assert.True(t, p.Processed())
 }
 
 func TestPipeline_Run_BatchMultipleMessages(t *testing.T) {
 	raw1 := makeRawEvent(t, "evt-1", "hail")
 	raw2 := makeRawEvent(t, "evt-2", "tornado")
 
-	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{raw1, raw2}}}
-	transformer := &mockTransformer{}
-	loader := &mockBatchLoader{}
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{raw1, raw2}}}
+	transformer := &stormtest.Transformer{}
+	loader := &stormtest.Loader{}
 	metrics := newTestMetrics()
 
 	p := pipeline.New(ext, transformer, loader, slog.Default(), metrics, testBatchSize)
@@ -104,14 +74,14 @@ func TestPipeline_Run_BatchMultipleMessages(t *testing.T) {
 
 	err := p.Run(ctx)
 	require.NoError(t, err)
-	require.Len(t, loader.batches, 1)
-	assert.Len(t, loader.batches[0], 2)
+	require.Len(t, loader.Batches(), 1)
+	assert.Len(t, loader.Batches()[0], 2)
 }
 
 func TestPipeline_Run_ContextCancellation(t *testing.T) {
-	ext := &mockBatchExtractor{} // no batches — will block
-	transformer := &mockTransformer{}
-	loader := &mockBatchLoader{}
+	ext := &stormtest.Extractor{} // no batches — will block
+	transformer := &stormtest.Transformer{}
+	loader := &stormtest.Loader{}
 	metrics := newTestMetrics()
 
 	p := pipeline.New(ext, transformer, loader, slog.Default(), metrics, testBatchSize)
@@ -121,15 +91,15 @@ func TestPipeline_Run_ContextCancellation(t *testing.T) {
 
 	err := p.Run(ctx)
 	require.NoError(t, err)
-	assert.Empty(t, loader.batches)
+	assert.Empty(t, loader.Batches())
 }
 
 func TestPipeline_Run_TransformError(t *testing.T) {
 	raw := makeRawEvent(t, "evt-2", "hail")
 
-	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{raw}}}
-	transformer := &mockTransformer{err: errors.New("bad data")}
-	loader := &mockBatchLoader{}
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{raw}}}
+	transformer := &stormtest.Transformer{Err: errors.New("bad data")}
+	loader := &stormtest.Loader{}
 	metrics := newTestMetrics()
 
 	p := pipeline.New(ext, transformer, loader, slog.Default(), metrics, testBatchSize)
@@ -139,17 +109,17 @@ func TestPipeline_Run_TransformError(t *testing.T) {
 
 	err := p.Run(ctx)
 	require.NoError(t, err)
-	assert.Empty(t, loader.batches)
-	assert.Error(t, p.CheckReadiness(context.Background()))
+	assert.Empty(t, loader.Batches())
+	assert.False(t, p.Processed())
 }
 
 func TestPipeline_Run_PartialTransformFailure(t *testing.T) {
 	raw1 := makeRawEvent(t, "evt-1", "hail")
 	raw2 := makeRawEvent(t, "evt-2", "tornado")
 
-	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{raw1, raw2}}}
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{raw1, raw2}}}
 	transformer := &partialFailTransformer{failOn: 2}
-	loader := &mockBatchLoader{}
+	loader := &stormtest.Loader{}
 	metrics := newTestMetrics()
 
 	p := pipeline.New(ext, transformer, loader, slog.Default(), metrics, testBatchSize)
@@ -159,8 +129,8 @@ func TestPipeline_Run_PartialTransformFailure(t *testing.T) {
 
 	err := p.Run(ctx)
 	require.NoError(t, err)
-	require.Len(t, loader.batches, 1)
-	assert.Len(t, loader.batches[0], 1, "only the first message should be loaded")
+	require.Len(t, loader.Batches(), 1)
+	assert.Len(t, loader.Batches()[0], 1, "only the first message should be loaded")
 }
 
 func TestPipeline_Run_CommitsAfterLoad(t *testing.T) {
@@ -173,9 +143,9 @@ func TestPipeline_Run_CommitsAfterLoad(t *testing.T) {
 		return nil
 	}
 
-	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{raw}}}
-	transformer := &mockTransformer{}
-	loader := &mockBatchLoader{}
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{raw}}}
+	transformer := &stormtest.Transformer{}
+	loader := &stormtest.Loader{}
 	metrics := newTestMetrics()
 
 	p := pipeline.New(ext, transformer, loader, slog.Default(), metrics, testBatchSize)
@@ -202,9 +172,9 @@ func TestPipeline_Run_BatchCommitAll(t *testing.T) {
 	raw2 := makeRawEvent(t, "evt-2", "tornado")
 	raw2.Commit = makeCommit()
 
-	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{raw1, raw2}}}
-	transformer := &mockTransformer{}
-	loader := &mockBatchLoader{}
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{raw1, raw2}}}
+	transformer := &stormtest.Transformer{}
+	loader := &stormtest.Loader{}
 	metrics := newTestMetrics()
 
 	p := pipeline.New(ext, transformer, loader, slog.Default(), metrics, testBatchSize)
@@ -217,7 +187,7 @@ func TestPipeline_Run_BatchCommitAll(t *testing.T) {
 	assert.Equal(t, int64(2), commitCount.Load())
 }
 
-// --- additional mocks ---
+// --- mocks ---
 
 type partialFailTransformer struct {
 	count  atomic.Int64
@@ -271,8 +241,8 @@ func (m *failingBatchLoader) LoadBatch(_ context.Context, events []domain.StormE
 func TestPipeline_Run_LoadError_Backoff(t *testing.T) {
 	raw := makeRawEvent(t, "evt-backoff", "hail")
 
-	ext := &retryBatchExtractor{event: raw, max: 2}
-	transformer := &mockTransformer{}
+	ext := &retryBatchExtractor{event: raw, max: 1}
+	transformer := &stormtest.Transformer{}
 	loader := &failingBatchLoader{failUntil: 1}
 	metrics := newTestMetrics()
 
@@ -284,6 +254,36 @@ func TestPipeline_Run_LoadError_Backoff(t *testing.T) {
 	err := p.Run(ctx)
 	require.NoError(t, err)
 	assert.Len(t, loader.batches, 1, "second attempt should succeed after backoff")
+	assert.Equal(t, int64(2), loader.callCount.Load(), "the failed batch is retried in place, not abandoned")
+}
+
+func TestPipeline_Run_UncommittedMessages(t *testing.T) {
+	var committed atomic.Int64
+	raw := func(id string, partition int, offset int64) domain.RawEvent {
+		r := makeRawEvent(t, id, "hail")
+		r.Topic, r.Partition, r.Offset = "raw-weather-reports", partition, offset
+		r.Commit = func(_ context.Context) error {
+			committed.Add(1)
+			return nil
+		}
+		return r
+	}
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{
+		{raw("evt-1", 0, 1), raw("evt-2", 1, 1)},
+		{raw("evt-3", 0, 2)},
+	}}
+	metrics := newTestMetrics()
+	loader := &stormtest.Loader{Err: errors.New("sink unavailable")}
+	p := pipeline.New(ext, &stormtest.Transformer{}, loader, slog.Default(), metrics, testBatchSize)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, p.Run(ctx))
+
+	assert.Zero(t, committed.Load())
+	assert.Equal(t, 1, ext.Calls(), "a failing batch holds up the next one instead of being skipped")
+	assert.InDelta(t, 2, testutil.ToFloat64(metrics.MessagesUncommitted), 0)
+	assert.InDelta(t, 0, testutil.ToFloat64(metrics.BatchesInFlight), 0)
 }
 
 func TestPipeline_Run_CommitError(t *testing.T) {
@@ -292,9 +292,9 @@ func TestPipeline_Run_CommitError(t *testing.T) {
 		return errors.New("commit failed")
 	}
 
-	ext := &mockBatchExtractor{batches: [][]domain.RawEvent{{raw}}}
-	transformer := &mockTransformer{}
-	loader := &mockBatchLoader{}
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{raw}}}
+	transformer := &stormtest.Transformer{}
+	loader := &stormtest.Loader{}
 	metrics := newTestMetrics()
 
 	p := pipeline.New(ext, transformer, loader, slog.Default(), metrics, testBatchSize)
@@ -304,8 +304,956 @@ func TestPipeline_Run_CommitError(t *testing.T) {
 
 	err := p.Run(ctx)
 	require.NoError(t, err)
-	require.Len(t, loader.batches, 1)
-	assert.Len(t, loader.batches[0], 1)
+	require.Len(t, loader.Batches(), 1)
+	assert.Len(t, loader.Batches()[0], 1)
+}
+
+func TestPipeline_Run_DeadLettersTransformFailures(t *testing.T) {
+	var commitCount atomic.Int64
+	raw1 := makeRawEvent(t, "evt-1", "hail")
+	raw2 := makeRawEvent(t, "evt-2", "tornado")
+	raw2.Topic = "raw-weather-reports"
+	raw2.Offset = 7
+	raw2.Commit = func(_ context.Context) error {
+		commitCount.Add(1)
+		return nil
+	}
+
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{raw1, raw2}}}
+	transformer := &partialFailTransformer{failOn: 2}
+	loader := &stormtest.Loader{}
+	dlq := &stormtest.DeadLetterLoader{}
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, transformer, loader, slog.Default(), metrics, testBatchSize, pipeline.WithDeadLetter(dlq))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	err := p.Run(ctx)
+	require.NoError(t, err)
+	require.Len(t, loader.Batches(), 1)
+	assert.Len(t, loader.Batches()[0], 1)
+	require.Len(t, dlq.Letters(), 1)
+	assert.Equal(t, int64(7), dlq.Letters()[0].Event.Offset)
+	assert.Equal(t, "transform failure", dlq.Letters()[0].Reason)
+	assert.False(t, dlq.Letters()[0].FailedAt.IsZero())
+	assert.Equal(t, int64(1), commitCount.Load())
+}
+
+func TestPipeline_Run_DeadLetterErrorSkipsCommit(t *testing.T) {
+	var commitCount atomic.Int64
+	raw := makeRawEvent(t, "evt-dlq-err", "hail")
+	raw.Commit = func(_ context.Context) error {
+		commitCount.Add(1)
+		return nil
+	}
+
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{raw}}}
+	transformer := &stormtest.Transformer{Err: errors.New("bad data")}
+	loader := &stormtest.Loader{}
+	dlq := &stormtest.DeadLetterLoader{Err: errors.New("dlq unavailable")}
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, transformer, loader, slog.Default(), metrics, testBatchSize, pipeline.WithDeadLetter(dlq))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	err := p.Run(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, loader.Batches())
+	assert.Zero(t, commitCount.Load(), "offset must not be committed when the dead-letter write fails")
+}
+
+// flakyDeadLetterLoader fails its first failures calls.
+type flakyDeadLetterLoader struct {
+	stormtest.DeadLetterLoader
+	failures atomic.Int64
+}
+
+func (d *flakyDeadLetterLoader) LoadDeadLetters(ctx context.Context, letters []domain.DeadLetter) error {
+	if d.failures.Add(-1) >= 0 {
+		return errors.New("dlq unavailable")
+	}
+	return d.DeadLetterLoader.LoadDeadLetters(ctx, letters)
+}
+
+func TestPipeline_Run_DeadLetterErrorRetriesInPlace(t *testing.T) {
+	var mu sync.Mutex
+	var commits []int64
+	at := func(raw domain.RawEvent, offset int64) domain.RawEvent {
+		raw.Offset = offset
+		raw.Commit = func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			commits = append(commits, offset)
+			return nil
+		}
+		return raw
+	}
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{
+		at(makeRawEvent(t, "evt-1", "hail"), 1),
+		at(domain.RawEvent{Value: []byte("not-json{{{")}, 2),
+		at(makeRawEvent(t, "evt-3", "wind"), 3),
+	}}}
+	loader := &stormtest.Loader{}
+	dlq := &flakyDeadLetterLoader{}
+	dlq.failures.Store(2)
+
+	p := pipeline.New(ext, &stormtest.Transformer{}, loader, slog.Default(), newTestMetrics(), testBatchSize, pipeline.WithDeadLetter(dlq))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, p.Run(ctx))
+
+	require.Len(t, dlq.Letters(), 1)
+	assert.Len(t, loader.Events(), 2, "the good events in the batch are still loaded")
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []int64{1, 2, 3}, commits, "offsets are committed once, in source order, after both writes")
+}
+
+type slowTransformer struct {
+	inFlight    atomic.Int64
+	maxInFlight atomic.Int64
+}
+
+func (m *slowTransformer) Transform(_ context.Context, raw domain.RawEvent) (domain.StormEvent, error) {
+	n := m.inFlight.Add(1)
+	defer m.inFlight.Add(-1)
+	for {
+		cur := m.maxInFlight.Load()
+		if n <= cur || m.maxInFlight.CompareAndSwap(cur, n) {
+			break
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	var event domain.StormEvent
+	if err := json.Unmarshal(raw.Value, &event); err != nil {
+		return domain.StormEvent{}, err
+	}
+	return event, nil
+}
+
+func TestPipeline_Run_ConcurrentTransformPreservesOrder(t *testing.T) {
+	batch := make([]domain.RawEvent, 0, 8)
+	for i := range 8 {
+		batch = append(batch, makeRawEvent(t, fmt.Sprintf("evt-%d", i), "hail"))
+	}
+
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{batch}}
+	transformer := &slowTransformer{}
+	loader := &stormtest.Loader{}
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, transformer, loader, slog.Default(), metrics, testBatchSize, pipeline.WithTransformConcurrency(4))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	err := p.Run(ctx)
+	require.NoError(t, err)
+	require.Len(t, loader.Batches(), 1)
+	require.Len(t, loader.Batches()[0], 8)
+	for i, event := range loader.Batches()[0] {
+		assert.Equal(t, fmt.Sprintf("evt-%d", i), event.ID)
+	}
+	assert.Greater(t, transformer.maxInFlight.Load(), int64(1), "transforms should overlap")
+	assert.LessOrEqual(t, transformer.maxInFlight.Load(), int64(4), "transforms should not exceed the worker limit")
+}
+
+// orderRecordingTransformer records the order in which each source partition's
+// messages reach Transform.
+type orderRecordingTransformer struct {
+	slowTransformer
+	mu    sync.Mutex
+	order map[int][]int64
+}
+
+func (m *orderRecordingTransformer) Transform(ctx context.Context, raw domain.RawEvent) (domain.StormEvent, error) {
+	m.mu.Lock()
+	m.order[raw.Partition] = append(m.order[raw.Partition], raw.Offset)
+	m.mu.Unlock()
+	return m.slowTransformer.Transform(ctx, raw)
+}
+
+func TestPipeline_Run_OrderedProcessing(t *testing.T) {
+	batch := make([]domain.RawEvent, 0, 8)
+	for i := range 8 {
+		raw := makeRawEvent(t, fmt.Sprintf("evt-%d", i), "hail")
+		raw.Topic = "raw-weather-reports"
+		raw.Partition = i % 2
+		raw.Offset = int64(i / 2)
+		raw.Key = nil
+		if raw.Partition == 0 {
+			raw.Key = []byte("OK-station")
+		}
+		batch = append(batch, raw)
+	}
+
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{batch}}
+	transformer := &orderRecordingTransformer{order: make(map[int][]int64)}
+	loader := &stormtest.Loader{}
+	metrics := newTestMetrics()
+
+	p := pipeline.New(ext, transformer, loader, slog.Default(), metrics, testBatchSize,
+		pipeline.WithTransformConcurrency(4), pipeline.WithOrderedProcessing())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	err := p.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[int][]int64{0: {0, 1, 2, 3}, 1: {0, 1, 2, 3}}, transformer.order,
+		"each partition is transformed in offset order")
+	assert.Equal(t, int64(2), transformer.maxInFlight.Load(), "one worker per source partition")
+
+	require.Len(t, loader.Batches(), 1)
+	require.Len(t, loader.Batches()[0], 8)
+	for i, event := range loader.Batches()[0] {
+		assert.Equal(t, fmt.Sprintf("evt-%d", i), event.ID)
+		if i%2 == 0 {
+			assert.Equal(t, "OK-station", event.OrderingKey, "keyed messages keep their source key")
+		} else {
+			assert.Equal(t, "raw-weather-reports/1", event.OrderingKey, "unkeyed messages are keyed by source partition")
+		}
+	}
+}
+
+func TestPipeline_Run_CorrelationIDs(t *testing.T) {
+	upstream := makeRawEvent(t, "evt-1", "hail")
+	upstream.Headers = map[string]string{domain.HeaderCorrelationID: "corr-upstream"}
+	generated := makeRawEvent(t, "evt-2", "hail")
+	generated.Headers = nil
+	bad := domain.RawEvent{Value: []byte("not json"), Topic: "raw-weather-reports", Offset: 3}
+
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{upstream, generated, bad}}}
+	transformer := &partialFailTransformer{failOn: 3}
+	loader := &stormtest.Loader{}
+	dlq := &stormtest.DeadLetterLoader{}
+	p := pipeline.New(ext, transformer, loader, slog.Default(), newTestMetrics(), testBatchSize, pipeline.WithDeadLetter(dlq))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	require.NoError(t, p.Run(ctx))
+
+	require.Len(t, loader.Batches(), 1)
+	events := loader.Batches()[0]
+	require.Len(t, events, 2)
+	assert.Equal(t, "corr-upstream", events[0].CorrelationID, "an upstream correlation ID is kept")
+	assert.Len(t, events[1].CorrelationID, 36, "messages without one get a generated UUID")
+
+	letters := dlq.Letters()
+	require.Len(t, letters, 1)
+	assert.NotEmpty(t, letters[0].Event.Headers[domain.HeaderCorrelationID], "dead letters carry the correlation ID")
+	assert.NotEqual(t, events[1].CorrelationID, letters[0].Event.Headers[domain.HeaderCorrelationID])
+}
+
+func TestPipeline_Run_ProvenanceHeaders(t *testing.T) {
+	tagged := makeRawEvent(t, "evt-1", "hail")
+	tagged.Headers = map[string]string{"collector_run_id": "run-42", "source_file": "250415_rpts_hail.csv", "traceparent": "00-abc"}
+	untagged := makeRawEvent(t, "evt-2", "hail")
+
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{tagged, untagged}}}
+	loader := &stormtest.Loader{}
+	p := pipeline.New(ext, &stormtest.Transformer{}, loader, slog.Default(), newTestMetrics(), testBatchSize,
+		pipeline.WithProvenanceHeaders([]string{"collector_run_id", "source_file", "source_host"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	require.NoError(t, p.Run(ctx))
+
+	require.Len(t, loader.Batches(), 1)
+	events := loader.Batches()[0]
+	require.Len(t, events, 2)
+	require.NotNil(t, events[0].Provenance)
+	assert.Equal(t, map[string]string{"collector_run_id": "run-42", "source_file": "250415_rpts_hail.csv"}, events[0].Provenance.Headers,
+		"only the selected headers present on the message are kept")
+	assert.Nil(t, events[1].Provenance)
+}
+
+func TestPipeline_Run_FlushIntervalAccumulatesSmallBatches(t *testing.T) {
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{
+		{makeRawEvent(t, "evt-1", "hail")},
+		{makeRawEvent(t, "evt-2", "wind")},
+		{makeRawEvent(t, "evt-3", "tornado")},
+	}}
+	loader := &stormtest.Loader{}
+
+	p := pipeline.New(ext, &stormtest.Transformer{}, loader, slog.Default(), newTestMetrics(), 2,
+		pipeline.WithFlushInterval(100*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, p.Run(ctx))
+	require.Len(t, loader.Batches(), 2, "first two single-event extracts fill one batch")
+	assert.Len(t, loader.Batches()[0], 2)
+	assert.Len(t, loader.Batches()[1], 1, "partial batch is flushed once the interval elapses")
+	assert.Equal(t, "evt-3", loader.Batches()[1][0].ID)
+}
+
+func TestPipeline_Run_FlushIntervalBoundsBlockingExtractor(t *testing.T) {
+	// The extractor hands over one event and then blocks until its context
+	// ends, so without a flush deadline the partial batch would never load.
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{makeRawEvent(t, "evt-1", "hail")}}}
+	loader := &stormtest.Loader{}
+
+	p := pipeline.New(ext, &stormtest.Transformer{}, loader, slog.Default(), newTestMetrics(), testBatchSize,
+		pipeline.WithFlushInterval(50*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = p.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return p.Processed()
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	require.Len(t, loader.Batches(), 1)
+	assert.Equal(t, "evt-1", loader.Batches()[0][0].ID)
+}
+
+func TestPipeline_Run_TracesAndPropagatesContext(t *testing.T) {
+	const upstream = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	raw := makeRawEvent(t, "evt-1", "hail")
+	raw.Headers = map[string]string{"traceparent": upstream}
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{raw}}}
+	loader := &stormtest.Loader{}
+
+	p := pipeline.New(ext, &stormtest.Transformer{}, loader, slog.Default(), newTestMetrics(), testBatchSize,
+		pipeline.WithTracing(tp, propagation.TraceContext{}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	require.NoError(t, p.Run(ctx))
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+	}
+	for _, name := range []string{"process batch", "extract", "transform", "load", "commit"} {
+		require.Contains(t, spans, name)
+	}
+
+	transform := spans["transform"]
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", transform.SpanContext().TraceID().String(),
+		"transform span joins the upstream trace")
+	assert.Equal(t, "00f067aa0ba902b7", transform.Parent().SpanID().String())
+	require.Len(t, spans["process batch"].Links(), 1)
+
+	require.Len(t, loader.Batches(), 1)
+	traceparent := loader.Batches()[0][0].TraceContext["traceparent"]
+	assert.Contains(t, traceparent, "4bf92f3577b34da6a3ce929d0e0e4736")
+	assert.Contains(t, traceparent, transform.SpanContext().SpanID().String(),
+		"sink messages continue from the transform span")
+}
+
+func TestPipeline_PauseResume(t *testing.T) {
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{makeRawEvent(t, "evt-1", "hail")}}}
+	loader := &stormtest.Loader{}
+	p := pipeline.New(ext, &stormtest.Transformer{}, loader, slog.Default(), newTestMetrics(), testBatchSize)
+
+	p.Pause()
+	assert.True(t, p.Paused())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = p.Run(ctx)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 0, ext.Calls(), "paused pipeline must not extract")
+
+	p.Resume()
+	assert.False(t, p.Paused())
+	require.Eventually(t, func() bool {
+		return p.Processed()
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+	require.Len(t, loader.Batches(), 1)
+}
+
+func TestPipeline_PausedRunStopsOnCancel(t *testing.T) {
+	p := pipeline.New(&stormtest.Extractor{}, &stormtest.Transformer{}, &stormtest.Loader{}, slog.Default(), newTestMetrics(), testBatchSize)
+	p.Pause()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.NoError(t, p.Run(ctx))
+}
+
+// sizeRecordingExtractor records the batch size of each ExtractBatch call.
+type sizeRecordingExtractor struct {
+	sizes chan int
+}
+
+func (m *sizeRecordingExtractor) ExtractBatch(ctx context.Context, batchSize int) ([]domain.RawEvent, error) {
+	select {
+	case m.sizes <- batchSize:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return nil, nil
+}
+
+func TestPipeline_SetBatchSize(t *testing.T) {
+	ext := &sizeRecordingExtractor{sizes: make(chan int)}
+	p := pipeline.New(ext, &stormtest.Transformer{}, &stormtest.Loader{}, slog.Default(), newTestMetrics(), testBatchSize)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = p.Run(ctx) }()
+
+	assert.Equal(t, testBatchSize, <-ext.sizes)
+	p.SetBatchSize(7)
+	p.SetBatchSize(0) // ignored
+	require.Eventually(t, func() bool { return <-ext.sizes == 7 }, time.Second, time.Millisecond)
+}
+
+func TestPipeline_RateLimit(t *testing.T) {
+	batches := make([][]domain.RawEvent, 3)
+	for i := range batches {
+		for j := range 10 {
+			batches[i] = append(batches[i], makeRawEvent(t, fmt.Sprintf("evt-%d-%d", i, j), "hail"))
+		}
+	}
+	ext := &stormtest.Extractor{Batches: batches}
+	loader := &stormtest.Loader{}
+	metrics := newTestMetrics()
+	p := pipeline.New(ext, &stormtest.Transformer{}, loader, slog.Default(), metrics, 10, pipeline.WithRateLimit(20))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = p.Run(ctx) }()
+
+	// A full bucket (one second of tokens) admits the first two batches at once;
+	// the third waits about half a second for ten more tokens.
+	require.Eventually(t, func() bool { return len(loader.Batches()) == 2 }, time.Second, time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+	assert.Len(t, loader.Batches(), 2, "third batch must wait for tokens")
+	require.Eventually(t, func() bool { return len(loader.Batches()) == 3 }, 2*time.Second, 10*time.Millisecond)
+	assert.InDelta(t, 20.0, testutil.ToFloat64(metrics.RateLimit), 0)
+}
+
+func TestPipeline_SetRateLimit(t *testing.T) {
+	metrics := newTestMetrics()
+	p := pipeline.New(&stormtest.Extractor{}, &stormtest.Transformer{}, &stormtest.Loader{}, slog.Default(), metrics, testBatchSize)
+	assert.Zero(t, p.RateLimit(), "unlimited by default")
+
+	p.SetRateLimit(500)
+	assert.InDelta(t, 500.0, p.RateLimit(), 0)
+	assert.InDelta(t, 500.0, testutil.ToFloat64(metrics.RateLimit), 0)
+
+	p.SetRateLimit(0)
+	assert.Zero(t, p.RateLimit())
+	assert.Zero(t, testutil.ToFloat64(metrics.RateLimit))
+}
+
+func TestPipeline_LoaderCircuitBreaker(t *testing.T) {
+	ext := &retryBatchExtractor{event: makeRawEvent(t, "evt-1", "hail"), max: 1}
+	// The batch is retried in place: two failures open the circuit, the first
+	// trial fails and reopens it, and the second trial succeeds.
+	loader := &failingBatchLoader{failUntil: 3}
+	metrics := newTestMetrics()
+	p := pipeline.New(ext, &stormtest.Transformer{}, loader, slog.Default(), metrics, testBatchSize,
+		pipeline.WithLoaderCircuitBreaker(2, 300*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = p.Run(ctx)
+	}()
+
+	require.Eventually(t, p.LoaderCircuitOpen, 2*time.Second, time.Millisecond)
+	require.ErrorIs(t, p.CheckReadiness(context.Background()), pipeline.ErrLoaderCircuitOpen)
+	assert.InDelta(t, 1.0, testutil.ToFloat64(metrics.LoaderCircuitOpen), 0)
+	calls := ext.count.Load()
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, calls, ext.count.Load(), "open circuit must stop extraction")
+
+	require.Eventually(t, p.Processed, 5*time.Second, 10*time.Millisecond)
+	assert.False(t, p.LoaderCircuitOpen())
+	require.NoError(t, p.CheckReadiness(context.Background()))
+	assert.Zero(t, testutil.ToFloat64(metrics.LoaderCircuitOpen))
+	assert.InDelta(t, 1.0, testutil.ToFloat64(metrics.LoaderCircuitTrips), 0, "a failed trial reopens without a new trip")
+
+	cancel()
+	<-done
+	assert.Equal(t, int64(4), loader.callCount.Load())
+}
+
+type memoryProgressStore struct {
+	mu    sync.Mutex
+	saved []domain.PartitionProgress
+	saves int
+}
+
+func (m *memoryProgressStore) LoadProgress(_ context.Context) ([]domain.PartitionProgress, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.saved, nil
+}
+
+func (m *memoryProgressStore) SaveProgress(_ context.Context, progress []domain.PartitionProgress) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.saved = progress
+	m.saves++
+	return nil
+}
+
+func TestPipeline_ProgressTracksCommittedOffsets(t *testing.T) {
+	at := func(raw domain.RawEvent, partition int, offset int64) domain.RawEvent {
+		raw.Topic, raw.Partition, raw.Offset = "raw-weather-reports", partition, offset
+		raw.Commit = func(context.Context) error { return nil }
+		return raw
+	}
+	eventTime := time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC)
+	hail := stormtest.RawEvent(t, domain.StormEvent{ID: "evt-1", EventType: "hail", EventTime: eventTime})
+	malformed := domain.RawEvent{Value: []byte("not-json{{{")}
+	failedCommit := at(hail, 1, 9)
+	failedCommit.Commit = func(context.Context) error { return errors.New("rebalanced") }
+
+	store := &memoryProgressStore{saved: []domain.PartitionProgress{
+		{Topic: "raw-weather-reports", Partition: 0, LastOffset: 2, Loaded: 3},
+	}}
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{
+		{at(hail, 0, 3), at(malformed, 0, 4), at(hail, 1, 8), failedCommit},
+	}}
+	p := pipeline.New(ext, &stormtest.Transformer{}, &stormtest.Loader{}, slog.Default(), newTestMetrics(), testBatchSize,
+		pipeline.WithProgressStore(store))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	require.NoError(t, p.Run(ctx))
+
+	progress := p.Progress()
+	require.Len(t, progress, 2)
+	assert.Equal(t, 0, progress[0].Partition)
+	assert.Equal(t, int64(4), progress[0].LastOffset, "dead letters are committed in source order with the loaded messages")
+	assert.Equal(t, int64(4), progress[0].Loaded, "counts continue from the stored progress")
+	assert.Equal(t, int64(1), progress[0].DeadLettered)
+	assert.Equal(t, eventTime, progress[0].LastEventTime)
+	assert.Equal(t, 1, progress[1].Partition)
+	assert.Equal(t, int64(8), progress[1].LastOffset, "a failed commit is not progress")
+	assert.Equal(t, int64(1), progress[1].Loaded)
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	assert.Equal(t, 1, store.saves)
+	assert.Equal(t, progress, store.saved)
+}
+
+func TestPipeline_EventFilter(t *testing.T) {
+	var committed []int64
+	at := func(event domain.StormEvent, offset int64) domain.RawEvent {
+		raw := stormtest.RawEvent(t, event)
+		raw.Topic, raw.Offset = "raw-weather-reports", offset
+		raw.Commit = func(context.Context) error {
+			committed = append(committed, offset)
+			return nil
+		}
+		return raw
+	}
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{
+		at(domain.StormEvent{ID: "evt-ok", EventType: "hail", Location: domain.Location{State: "OK"}}, 0),
+		at(domain.StormEvent{ID: "evt-tx", EventType: "hail", Location: domain.Location{State: "TX"}}, 1),
+		at(domain.StormEvent{ID: "evt-ks", EventType: "wind", Location: domain.Location{State: "KS"}}, 2),
+	}}}
+	loader := &stormtest.Loader{}
+	metrics := newTestMetrics()
+	p := pipeline.New(ext, &stormtest.Transformer{}, loader, slog.Default(), metrics, testBatchSize,
+		pipeline.WithEventFilter(domain.EventFilter{States: []string{"tx"}}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	require.NoError(t, p.Run(ctx))
+
+	loaded := loader.Events()
+	require.Len(t, loaded, 1)
+	assert.Equal(t, "evt-tx", loaded[0].ID)
+	assert.InDelta(t, 2, testutil.ToFloat64(metrics.EventsFiltered.WithLabelValues(domain.FilterReasonState)), 0)
+	assert.Equal(t, []int64{0, 1, 2}, committed, "filtered offsets are committed with the loaded batch")
+
+	progress := p.Progress()
+	require.Len(t, progress, 1)
+	assert.Equal(t, int64(1), progress[0].Loaded)
+	assert.Equal(t, int64(2), progress[0].Filtered)
+	assert.Equal(t, int64(2), progress[0].LastOffset)
+}
+
+func TestPipeline_ValidationRules(t *testing.T) {
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{
+		stormtest.RawEvent(t, domain.StormEvent{ID: "evt-ok", EventType: "hail", Measurement: domain.Measurement{Magnitude: 1}, Location: domain.Location{Raw: "Ravenna", State: "TX"}}),
+		stormtest.RawEvent(t, domain.StormEvent{ID: "evt-no-loc", EventType: "hail", Measurement: domain.Measurement{Magnitude: 1}, Location: domain.Location{State: "TX"}}),
+		stormtest.RawEvent(t, domain.StormEvent{ID: "evt-no-mag", EventType: "hail", Location: domain.Location{Raw: "Ravenna", State: "TX"}}),
+		stormtest.RawEvent(t, domain.StormEvent{ID: "evt-no-state", EventType: "wind", Measurement: domain.Measurement{Magnitude: 60}}),
+	}}}
+	loader := &stormtest.Loader{}
+	dlq := &stormtest.DeadLetterLoader{}
+	metrics := newTestMetrics()
+	rules, err := domain.ParseValidationRules("missing_state=drop;missing_location=annotate;missing_magnitude=quarantine")
+	require.NoError(t, err)
+	p := pipeline.New(ext, &stormtest.Transformer{}, loader, slog.Default(), metrics, testBatchSize,
+		pipeline.WithValidationRules(rules), pipeline.WithDeadLetter(dlq))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	require.NoError(t, p.Run(ctx))
+
+	loaded := loader.Events()
+	require.Len(t, loaded, 2)
+	assert.Equal(t, "evt-ok", loaded[0].ID)
+	assert.Nil(t, loaded[0].Quality)
+	assert.Equal(t, "evt-no-loc", loaded[1].ID)
+	assert.Equal(t, &domain.DataQuality{Flags: []string{"missing_location"}}, loaded[1].Quality)
+
+	require.Len(t, dlq.Letters(), 1)
+	assert.Equal(t, "validation rule: missing_magnitude", dlq.Letters()[0].Reason)
+
+	assert.InDelta(t, 1, testutil.ToFloat64(metrics.ValidationViolations.WithLabelValues("missing_state", domain.RuleActionDrop)), 0)
+	assert.InDelta(t, 2, testutil.ToFloat64(metrics.ValidationViolations.WithLabelValues("missing_location", domain.RuleActionAnnotate)), 0,
+		"the dropped event is counted for every check it fails")
+	assert.InDelta(t, 1, testutil.ToFloat64(metrics.ValidationViolations.WithLabelValues("missing_magnitude", domain.RuleActionQuarantine)), 0)
+}
+
+// jsonSizer sizes an event as its JSON encoding.
+type jsonSizer struct{}
+
+func (jsonSizer) MessageBytes(event domain.StormEvent) (int, error) {
+	data, err := json.Marshal(event)
+	return len(data), err
+}
+
+func TestPipeline_MessageSizeLimit(t *testing.T) {
+	long := strings.Repeat("Large hail broke windows. ", 40)
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{
+		stormtest.RawEvent(t, domain.StormEvent{ID: "evt-small", EventType: "hail", Comments: "Quarter hail."}),
+		stormtest.RawEvent(t, domain.StormEvent{ID: "evt-long-comments", EventType: "hail", Comments: long}),
+		stormtest.RawEvent(t, domain.StormEvent{ID: "evt-long-location", EventType: "hail", Location: domain.Location{Raw: long}}),
+	}}}
+	loader := &stormtest.Loader{}
+	dlq := &stormtest.DeadLetterLoader{}
+	metrics := newTestMetrics()
+	p := pipeline.New(ext, &stormtest.Transformer{}, loader, slog.Default(), metrics, testBatchSize,
+		pipeline.WithMessageSizeLimit(jsonSizer{}, 512, domain.OversizeTruncate), pipeline.WithDeadLetter(dlq))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	require.NoError(t, p.Run(ctx))
+
+	loaded := loader.Events()
+	require.Len(t, loaded, 2)
+	assert.Equal(t, "Quarter hail.", loaded[0].Comments)
+	assert.Equal(t, "evt-long-comments", loaded[1].ID)
+	assert.True(t, strings.HasSuffix(loaded[1].Comments, "…"))
+	assert.Equal(t, []string{domain.FlagCommentsTruncated}, loaded[1].Quality.Flags)
+	size, err := jsonSizer{}.MessageBytes(loaded[1])
+	require.NoError(t, err)
+	assert.LessOrEqual(t, size, 512)
+
+	require.Len(t, dlq.Letters(), 1, "an event without comments to truncate is dead-lettered")
+	assert.Contains(t, dlq.Letters()[0].Reason, "exceeds the 512 byte limit")
+
+	assert.InDelta(t, 1, testutil.ToFloat64(metrics.OversizeEvents.WithLabelValues("truncated")), 0)
+	assert.InDelta(t, 1, testutil.ToFloat64(metrics.OversizeEvents.WithLabelValues("dead_lettered")), 0)
+}
+
+func TestPipeline_DuplicateMerging(t *testing.T) {
+	at := time.Date(2024, 4, 26, 18, 0, 0, 0, time.UTC)
+	report := func(id, office, method string, minutes int, lat float64) domain.StormEvent {
+		return domain.StormEvent{
+			ID: id, EventType: "tornado", SourceOffice: office, EventTime: at.Add(time.Duration(minutes) * time.Minute),
+			Geo: domain.Geo{Lat: lat, Lon: -97.0}, Measurement: domain.Measurement{Magnitude: 1, Method: method},
+		}
+	}
+	longer := report("evt-fwd", "FWD", domain.MethodMeasured, 6, 35.02)
+	longer.Comments = "Tornado crossed the county line. (FWD)"
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{
+		{
+			stormtest.RawEvent(t, report("evt-oun", "OUN", domain.MethodEstimated, 0, 35.0)),
+			stormtest.RawEvent(t, report("evt-tsa", "TSA", domain.MethodMeasured, 2, 35.01)),
+			stormtest.RawEvent(t, report("evt-far", "AMA", domain.MethodMeasured, 1, 36.5)),
+		},
+		{stormtest.RawEvent(t, report("evt-ict", "ICT", "", 5, 35.0))},
+		{stormtest.RawEvent(t, longer)},
+	}}
+	loader := &stormtest.Loader{}
+	metrics := newTestMetrics()
+	p := pipeline.New(ext, &stormtest.Transformer{}, loader, slog.Default(), metrics, testBatchSize,
+		pipeline.WithDuplicateMerging(domain.MergeTolerance{Miles: 3, Window: 10 * time.Minute}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	require.NoError(t, p.Run(ctx))
+
+	type loaded struct {
+		ID         string
+		MergedFrom []string
+		Deleted    bool
+	}
+	var batches [][]loaded
+	for _, batch := range loader.Batches() {
+		var b []loaded
+		for _, e := range batch {
+			b = append(b, loaded{e.ID, e.MergedFrom, e.Deleted})
+		}
+		batches = append(batches, b)
+	}
+	assert.Equal(t, [][]loaded{
+		{{ID: "evt-tsa", MergedFrom: []string{"evt-oun"}}, {ID: "evt-far"}},
+		{{ID: "evt-tsa", MergedFrom: []string{"evt-oun", "evt-ict"}}},
+		{{ID: "evt-tsa", MergedFrom: []string{"evt-oun", "evt-ict"}, Deleted: true}, {ID: "evt-fwd", MergedFrom: []string{"evt-tsa", "evt-oun", "evt-ict"}}},
+	}, batches, "a losing later report reloads the kept one; a winning one retracts it")
+	assert.InDelta(t, 3, testutil.ToFloat64(metrics.EventsMerged), 0)
+}
+
+func TestPipeline_EpisodeClustering(t *testing.T) {
+	at := time.Date(2024, 4, 26, 18, 0, 0, 0, time.UTC)
+	report := func(id, eventType string, minutes int, lon float64) domain.StormEvent {
+		return domain.StormEvent{ID: id, EventType: eventType, EventTime: at.Add(time.Duration(minutes) * time.Minute), Geo: domain.Geo{Lat: 35.0, Lon: lon}}
+	}
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{
+		{
+			stormtest.RawEvent(t, report("evt-hail", "hail", 0, -98.0)),
+			stormtest.RawEvent(t, report("evt-other", "hail", 5, -95.0)),
+			stormtest.RawEvent(t, report("evt-wind", "wind", 15, -97.8)),
+		},
+		{
+			stormtest.RawEvent(t, report("evt-tornado", "tornado", 35, -97.6)),
+			stormtest.RawEvent(t, report("evt-late", "hail", 120, -97.6)),
+			stormtest.RawEvent(t, report("evt-hail", "hail", 0, -98.0)),
+		},
+	}}
+	loader := &stormtest.Loader{}
+	metrics := newTestMetrics()
+	p := pipeline.New(ext, &stormtest.Transformer{}, loader, slog.Default(), metrics, testBatchSize,
+		pipeline.WithEpisodeClustering(domain.EpisodeTolerance{Miles: 20, Window: 30 * time.Minute}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	require.NoError(t, p.Run(ctx))
+
+	episodes := make(map[string]string)
+	for _, e := range loader.Events() {
+		episodes[e.ID] = e.EpisodeID
+	}
+	storm := domain.NewEpisodeID(domain.StormEvent{ID: "evt-hail"})
+	assert.Equal(t, map[string]string{
+		"evt-hail":    storm,
+		"evt-other":   domain.NewEpisodeID(domain.StormEvent{ID: "evt-other"}),
+		"evt-wind":    storm,
+		"evt-tornado": storm,
+		"evt-late":    domain.NewEpisodeID(domain.StormEvent{ID: "evt-late"}),
+	}, episodes, "reports chain into one episode across batches and types")
+	assert.InDelta(t, 3, testutil.ToFloat64(metrics.EpisodesStarted), 0)
+}
+
+func TestMultiLoader_LoadsEachInOrder(t *testing.T) {
+	first := &stormtest.Loader{}
+	second := &stormtest.Loader{}
+	events := []domain.StormEvent{{ID: "evt-1"}, {ID: "evt-2"}}
+
+	err := pipeline.MultiLoader{first, second}.LoadBatch(context.Background(), events)
+	require.NoError(t, err)
+	assert.Equal(t, [][]domain.StormEvent{events}, first.Batches())
+	assert.Equal(t, [][]domain.StormEvent{events}, second.Batches())
+}
+
+func TestMultiLoader_StopsAtFirstError(t *testing.T) {
+	failing := &failingBatchLoader{failUntil: 1}
+	after := &stormtest.Loader{}
+
+	err := pipeline.MultiLoader{failing, after}.LoadBatch(context.Background(), []domain.StormEvent{{ID: "evt-1"}})
+	require.Error(t, err)
+	assert.Empty(t, after.Batches())
+}
+
+func TestPipeline_BroadcastsLoadedEvents(t *testing.T) {
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{
+		makeRawEvent(t, "evt-hail", "hail"),
+		makeRawEvent(t, "evt-wind", "wind"),
+	}}}
+	loader := &failingBatchLoader{failUntil: 1}
+	metrics := newTestMetrics()
+	broadcaster := pipeline.NewBroadcaster(8, metrics)
+	all, cancelAll := broadcaster.Subscribe(domain.EventFilter{})
+	defer cancelAll()
+	wind, cancelWind := broadcaster.Subscribe(domain.EventFilter{EventTypes: []string{"wind"}})
+	defer cancelWind()
+	assert.InDelta(t, 2, testutil.ToFloat64(metrics.StreamSubscribers), 0)
+
+	p := pipeline.New(ext, &stormtest.Transformer{}, loader, slog.Default(), metrics, testBatchSize,
+		pipeline.WithBroadcaster(broadcaster))
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	require.NoError(t, p.Run(ctx))
+
+	require.Len(t, all, 2, "the batch is published once, after its load succeeds")
+	assert.Equal(t, "evt-hail", (<-all).ID)
+	assert.Equal(t, "evt-wind", (<-all).ID)
+	require.Len(t, wind, 1)
+	assert.Equal(t, "evt-wind", (<-wind).ID)
+}
+
+func TestBroadcaster_DropsForSlowSubscribers(t *testing.T) {
+	metrics := newTestMetrics()
+	broadcaster := pipeline.NewBroadcaster(1, metrics)
+	events, cancel := broadcaster.Subscribe(domain.EventFilter{})
+
+	broadcaster.Publish([]domain.StormEvent{{ID: "evt-1"}, {ID: "evt-2"}})
+	assert.Equal(t, "evt-1", (<-events).ID)
+	assert.InDelta(t, 1, testutil.ToFloat64(metrics.StreamEventsDropped), 0)
+
+	cancel()
+	cancel()
+	_, open := <-events
+	assert.False(t, open, "cancel closes the channel")
+	assert.Zero(t, testutil.ToFloat64(metrics.StreamSubscribers))
+	broadcaster.Publish([]domain.StormEvent{{ID: "evt-3"}})
+}
+
+type readinessLoader struct {
+	stormtest.Loader
+	err error
+}
+
+func (l *readinessLoader) CheckReadiness(_ context.Context) error { return l.err }
+
+func TestPipeline_CheckReadiness(t *testing.T) {
+	loader := &readinessLoader{}
+	p := pipeline.New(&stormtest.Extractor{}, &stormtest.Transformer{}, pipeline.MultiLoader{&stormtest.Loader{}, loader}, slog.Default(), newTestMetrics(), testBatchSize)
+
+	require.NoError(t, p.CheckReadiness(context.Background()), "ready before any message is processed")
+	assert.False(t, p.Processed())
+
+	loader.err = errors.New("kafka brokers unreachable")
+	assert.EqualError(t, p.CheckReadiness(context.Background()), "kafka brokers unreachable")
+}
+
+type namedReadinessExtractor struct {
+	stormtest.Extractor
+	err error
+}
+
+func (e *namedReadinessExtractor) CheckReadiness(_ context.Context) error { return e.err }
+func (e *namedReadinessExtractor) ComponentName() string                  { return "kafka_reader" }
+
+func TestPipeline_Health(t *testing.T) {
+	extractor := &namedReadinessExtractor{Extractor: stormtest.Extractor{Batches: [][]domain.RawEvent{{makeRawCSVEvent(t, "hail", "175")}}}}
+	sink, archive := &readinessLoader{}, &readinessLoader{}
+	p := pipeline.New(extractor, pipeline.NewTransformer(slog.Default()), pipeline.MultiLoader{sink, &stormtest.Loader{}, archive}, slog.Default(), newTestMetrics(), testBatchSize)
+
+	report := p.Health(context.Background())
+	assert.True(t, report.Ready)
+	assert.Equal(t, []domain.ComponentHealth{
+		{Name: "kafka_reader", Status: domain.HealthOK},
+		{Name: "loader", Status: domain.HealthOK},
+		{Name: "loader", Status: domain.HealthOK},
+	}, report.Components, "loaders without readiness checks are not listed")
+	assert.Equal(t, "closed", report.LoaderCircuit)
+	assert.Zero(t, report.LastBatchAt)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = p.Run(ctx) }()
+	require.Eventually(t, p.Processed, 2*time.Second, time.Millisecond)
+
+	extractor.err = errors.New("kafka brokers unreachable")
+	archive.err = errors.New("bucket not found")
+	report = p.Health(context.Background())
+	assert.False(t, report.Ready)
+	assert.Equal(t, []domain.ComponentHealth{
+		{Name: "kafka_reader", Status: domain.HealthError, Error: "kafka brokers unreachable"},
+		{Name: "loader", Status: domain.HealthOK},
+		{Name: "loader", Status: domain.HealthError, Error: "bucket not found"},
+	}, report.Components, "every component is checked after a failure")
+	assert.True(t, report.MessagesProcessed)
+	assert.WithinDuration(t, time.Now(), report.LastBatchAt, time.Minute)
+}
+
+func TestPipeline_EventTypeMetrics(t *testing.T) {
+	hail := makeRawCSVEvent(t, "hail", "175")
+	tornado := makeRawCSVEvent(t, "tornado", "EF1")
+	malformed := domain.RawEvent{Value: []byte(`{"EventType":"wind","State":"OK"`)}
+	unknownType := domain.RawEvent{Value: []byte(`{"EventType":"earthquake","State":"TX","Lat":"31.02","Lon":"-98.44"}`)}
+	noCoords := domain.RawEvent{Value: []byte(`{"EventType":"hail","State":"TX","Size":"100"}`)}
+
+	for _, stateLabels := range []bool{false, true} {
+		t.Run(fmt.Sprintf("state labels %v", stateLabels), func(t *testing.T) {
+			ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{hail, tornado, hail, malformed, unknownType, noCoords}}}
+			metrics := newTestMetrics()
+			var opts []pipeline.Option
+			state := ""
+			if stateLabels {
+				opts = append(opts, pipeline.WithStateLabels())
+				state = "TX"
+			}
+			p := pipeline.New(ext, pipeline.NewTransformer(slog.Default()), &stormtest.Loader{}, slog.Default(), metrics, testBatchSize, opts...)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+			require.NoError(t, p.Run(ctx))
+
+			assert.InDelta(t, 2, testutil.ToFloat64(metrics.MessagesProduced.WithLabelValues("hail", state)), 0)
+			assert.InDelta(t, 1, testutil.ToFloat64(metrics.MessagesProduced.WithLabelValues("tornado", state)), 0)
+			unknownState := ""
+			if stateLabels {
+				unknownState = "unknown"
+			}
+			assert.InDelta(t, 1, testutil.ToFloat64(metrics.TransformErrors.WithLabelValues("unknown", unknownState, "invalid_json")), 0,
+				"unparseable payloads are labeled unknown")
+			assert.InDelta(t, 1, testutil.ToFloat64(metrics.TransformErrors.WithLabelValues("unknown", state, "unknown_event_type")), 0)
+			assert.InDelta(t, 1, testutil.ToFloat64(metrics.TransformErrors.WithLabelValues("hail", state, "invalid_coordinates")), 0)
+		})
+	}
+}
+
+func TestPipeline_LatencyMetrics(t *testing.T) {
+	now := time.Now()
+	fresh := stormtest.RawEvent(t, domain.StormEvent{ID: "evt-1", EventType: "hail", EventTime: now.Add(-time.Hour)})
+	fresh.Timestamp = now.Add(-2 * time.Second)
+	untimed := stormtest.RawEvent(t, domain.StormEvent{ID: "evt-2", EventType: "wind"})
+	ext := &stormtest.Extractor{Batches: [][]domain.RawEvent{{fresh, untimed}}}
+	metrics := newTestMetrics()
+	p := pipeline.New(ext, &stormtest.Transformer{}, &stormtest.Loader{}, slog.Default(), metrics, testBatchSize)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	require.NoError(t, p.Run(ctx))
+
+	histogram := func(vec *prometheus.HistogramVec, eventType string) *dto.Histogram {
+		var m dto.Metric
+		require.NoError(t, vec.WithLabelValues(eventType).(prometheus.Histogram).Write(&m))
+		return m.GetHistogram()
+	}
+	lag := histogram(metrics.SourceLag, "hail")
+	assert.Equal(t, uint64(1), lag.GetSampleCount())
+	assert.InDelta(t, 2, lag.GetSampleSum(), 1)
+	latency := histogram(metrics.EventLatency, "hail")
+	assert.Equal(t, uint64(1), latency.GetSampleCount())
+	assert.InDelta(t, 3600, latency.GetSampleSum(), 1)
+
+	assert.Zero(t, histogram(metrics.SourceLag, "wind").GetSampleCount(), "messages without a timestamp are skipped")
+	assert.Zero(t, histogram(metrics.EventLatency, "wind").GetSampleCount())
 }
 
 // --- domain tests (unchanged) ---
@@ -320,6 +1268,175 @@ func TestStormTransformer_Transform(t *testing.T) {
 	assert.Equal(t, "tornado", event.EventType)
 }
 
+func TestStormTransformer_WithEnrichment(t *testing.T) {
+	raw := makeRawCSVEvent(t, "hail", "175")
+	transformer := pipeline.NewTransformer(slog.Default(),
+		pipeline.WithEnrichment(domain.Enrichment{Units: domain.UnitsMetric, GeohashPrecision: 4}),
+		pipeline.WithoutStage(pipeline.StageGeocode), // option order must not matter
+	)
+
+	event, err := transformer.Transform(context.Background(), raw)
+	require.NoError(t, err)
+	assert.Equal(t, "mm", event.Measurement.Unit)
+	assert.Empty(t, event.Geohash)
+
+	transformer = pipeline.NewTransformer(slog.Default(),
+		pipeline.WithStageAfter(pipeline.StageGeocode, "noop", func(_ context.Context, _ domain.RawEvent, e domain.StormEvent, _ *domain.Audit) (domain.StormEvent, error) {
+			return e, nil
+		}),
+		pipeline.WithEnrichment(domain.Enrichment{GeohashPrecision: 4}),
+	)
+	event, err = transformer.Transform(context.Background(), raw)
+	require.NoError(t, err)
+	assert.Len(t, event.Geohash, 4, "enrichment set after the chain is built still applies")
+}
+
+func TestStormTransformer_SetEnrichment(t *testing.T) {
+	raw := makeRawCSVEvent(t, "hail", "175")
+	transformer := pipeline.NewTransformer(slog.Default(), pipeline.WithEnrichment(domain.Enrichment{Units: domain.UnitsMetric}))
+
+	event, err := transformer.Transform(context.Background(), raw)
+	require.NoError(t, err)
+	assert.Equal(t, "severe", *event.Measurement.Severity)
+
+	thresholds, err := domain.ParseSeverityThresholds("hail=2,3,4")
+	require.NoError(t, err)
+	enrichment := transformer.Enrichment()
+	enrichment.SeverityThresholds = thresholds
+	transformer.SetEnrichment(enrichment)
+
+	event, err = transformer.Transform(context.Background(), raw)
+	require.NoError(t, err)
+	assert.Equal(t, "minor", *event.Measurement.Severity)
+	assert.Equal(t, "mm", event.Measurement.Unit, "settings not replaced are kept")
+}
+
+func TestStormTransformer_AuditLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	transformer := pipeline.NewTransformer(logger, pipeline.WithAuditLog())
+
+	_, err := transformer.Transform(context.Background(), makeRawCSVEvent(t, "hail", "175"))
+	require.NoError(t, err)
+	_, err = transformer.Transform(context.Background(), domain.RawEvent{Value: []byte(`{"EventType":"earthquake"}`)})
+	require.Error(t, err)
+
+	var lines []map[string]any
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var line map[string]any
+		require.NoError(t, dec.Decode(&line))
+		lines = append(lines, line)
+	}
+	require.Len(t, lines, 2)
+
+	assert.Equal(t, "transform audit", lines[0]["msg"])
+	assert.Equal(t, "hail", lines[0]["event_type"])
+	assert.Contains(t, fmt.Sprint(lines[0]["decisions"]), "magnitude")
+
+	assert.Equal(t, "transform audit", lines[1]["msg"])
+	assert.Contains(t, lines[1]["rejected"], "unknown event type")
+}
+
+func TestStormTransformer_TransformAudited(t *testing.T) {
+	var buf bytes.Buffer
+	transformer := pipeline.NewTransformer(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	event, steps, err := transformer.TransformAudited(context.Background(), makeRawCSVEvent(t, "hail", "175"))
+	require.NoError(t, err)
+	assert.InDelta(t, 1.75, event.Measurement.Magnitude, 0)
+	assert.Contains(t, steps, domain.AuditStep{Step: "magnitude", From: "175", To: "1.75", Reason: "legacy encoding corrected (hundredths of an inch)"})
+	assert.Zero(t, buf.Len(), "the audit line is logged only with WithAuditLog")
+
+	_, _, err = transformer.TransformAudited(context.Background(), domain.RawEvent{Value: []byte(`{"EventType":"earthquake"}`)})
+	require.Error(t, err)
+}
+
+func TestStormTransformer_CustomStages(t *testing.T) {
+	var sawType string
+	var buf bytes.Buffer
+	transformer := pipeline.NewTransformer(slog.New(slog.NewJSONHandler(&buf, nil)),
+		pipeline.WithAuditLog(),
+		pipeline.WithStage("tag", func(_ context.Context, raw domain.RawEvent, event domain.StormEvent, audit *domain.Audit) (domain.StormEvent, error) {
+			if raw.Headers["site"] == "blocked" {
+				return domain.StormEvent{}, errors.New("blocked site")
+			}
+			audit.Add("comments", event.Comments, "tagged", "site rule")
+			event.Comments = "tagged"
+			return event, nil
+		}),
+		pipeline.WithStageAfter(pipeline.StageNormalize, "inspect", func(_ context.Context, _ domain.RawEvent, event domain.StormEvent, _ *domain.Audit) (domain.StormEvent, error) {
+			sawType = event.EventType
+			return event, nil
+		}),
+	)
+	assert.Equal(t, []string{"parse", "normalize", "inspect", "severity", "geocode", "tag"}, transformer.Stages())
+
+	raw := makeRawCSVEvent(t, "hail", "175")
+	event, err := transformer.Transform(context.Background(), raw)
+	require.NoError(t, err)
+	assert.Equal(t, "hail", sawType)
+	assert.Equal(t, "tagged", event.Comments)
+	require.NotNil(t, event.Measurement.Severity, "custom stages see the built-in enrichment")
+	assert.Equal(t, domain.SchemaVersion, event.SchemaVersion)
+	assert.Contains(t, buf.String(), `"step":"comments"`)
+
+	raw.Headers = map[string]string{"site": "blocked"}
+	_, err = transformer.Transform(context.Background(), raw)
+	require.EqualError(t, err, "tag stage: blocked site")
+
+	assert.Panics(t, func() {
+		pipeline.NewTransformer(slog.Default(), pipeline.WithStageAfter("missing", "x", nil))
+	})
+}
+
+func TestStormTransformer_StageTiming(t *testing.T) {
+	var mu sync.Mutex
+	runs := map[string]int{}
+	transformer := pipeline.NewTransformer(slog.Default(),
+		pipeline.WithoutStage(pipeline.StageGeocode),
+		pipeline.WithStageTiming(func(stage string, d time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			runs[stage]++
+			assert.GreaterOrEqual(t, d, time.Duration(0))
+		}),
+	)
+	assert.Equal(t, []string{"parse", "normalize", "severity"}, transformer.Stages())
+
+	event, err := transformer.Transform(context.Background(), makeRawCSVEvent(t, "hail", "175"))
+	require.NoError(t, err)
+	assert.Empty(t, event.Geohash, "the geocode stage was removed")
+	assert.Equal(t, map[string]int{"parse": 1, "normalize": 1, "severity": 1}, runs)
+
+	_, err = transformer.Transform(context.Background(), domain.RawEvent{Value: []byte("not json")})
+	require.Error(t, err)
+	assert.Equal(t, 2, runs["parse"], "failed stages are timed too")
+
+	assert.Panics(t, func() { pipeline.NewTransformer(slog.Default(), pipeline.WithoutStage("missing")) })
+	assert.Panics(t, func() { pipeline.NewTransformer(slog.Default(), pipeline.WithoutStage(pipeline.StageParse)) })
+}
+
+func TestStormTransformer_CommentRedaction(t *testing.T) {
+	metrics := observability.NewMetricsForTesting()
+	var buf bytes.Buffer
+	transformer := pipeline.NewTransformer(slog.New(slog.NewJSONHandler(&buf, nil)),
+		pipeline.WithAuditLog(),
+		pipeline.WithCommentRedaction(domain.NewRedactor(nil, nil), metrics.CommentRedactions),
+	)
+	assert.Equal(t, pipeline.StageRedact, transformer.Stages()[len(transformer.Stages())-1])
+
+	raw := makeRawCSVEvent(t, "hail", "175")
+	raw.Value = bytes.Replace(raw.Value, []byte("Test report."), []byte("Roof destroyed. Call 405-555-0142."), 1)
+	event, err := transformer.Transform(context.Background(), raw)
+	require.NoError(t, err)
+	assert.Equal(t, "Roof destroyed. Call [REDACTED]. (SJT)", event.Comments)
+	assert.Equal(t, "SJT", event.SourceOffice, "enrichment reads the original comments")
+	assert.InDelta(t, 1, testutil.ToFloat64(metrics.CommentRedactions.WithLabelValues("phone")), 0)
+	assert.Contains(t, buf.String(), "redacted 1 matches: phone")
+	assert.NotContains(t, buf.String(), "555-0142", "the audit log must not leak what was redacted")
+}
+
 func TestDomain_ParseRawEvent(t *testing.T) {
 	raw := makeRawCSVEvent(t, "wind", "65")
 	event, err := domain.ParseRawEvent(raw)
@@ -413,15 +1530,10 @@ func makeRawCSVEvent(t *testing.T, eventType, magnitude string) domain.RawEvent
 
 func makeRawEvent(t *testing.T, id, eventType string) domain.RawEvent {
 	t.Helper()
-	data, err := json.Marshal(domain.StormEvent{
+	return stormtest.RawEvent(t, domain.StormEvent{
 		ID:        id,
 		EventType: eventType,
 		Geo:       domain.Geo{Lat: 35.0, Lon: -97.0},
 		EventTime: time.Now(),
 	})
-	require.NoError(t, err)
-	return domain.RawEvent{
-		Key:   []byte(id),
-		Value: data,
-	}
 }
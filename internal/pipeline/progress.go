@@ -0,0 +1,151 @@
+package pipeline
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// ProgressStore persists per-partition progress so it survives restarts.
+type ProgressStore interface {
+	LoadProgress(ctx context.Context) ([]domain.PartitionProgress, error)
+	SaveProgress(ctx context.Context, progress []domain.PartitionProgress) error
+}
+
+// WithProgressStore seeds partition progress from store when Run starts and
+// saves it after every batch that committed offsets. Without a store,
+// progress is tracked in memory only.
+func WithProgressStore(store ProgressStore) Option {
+	return func(p *Pipeline) {
+		p.progressStore = store
+	}
+}
+
+// commitOutcome is what happened to a message whose offset is committed.
+type commitOutcome int
+
+const (
+	outcomeLoaded commitOutcome = iota
+	outcomeFiltered
+	outcomeDeadLettered
+)
+
+// pendingCommit is a processed message awaiting its offset commit. event is
+// unset for dead letters.
+type pendingCommit struct {
+	raw     domain.RawEvent
+	event   domain.StormEvent
+	outcome commitOutcome
+}
+
+type partitionKey struct {
+	topic     string
+	partition int
+}
+
+// progressTracker records committed offsets per source partition. Run updates
+// it while admin requests read it, so access is guarded by mu.
+type progressTracker struct {
+	mu         sync.Mutex
+	partitions map[partitionKey]*domain.PartitionProgress
+	dirty      bool
+}
+
+func (t *progressTracker) seed(progress []domain.PartitionProgress) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.partitions = make(map[partitionKey]*domain.PartitionProgress, len(progress))
+	for i := range progress {
+		pp := progress[i]
+		t.partitions[partitionKey{pp.Topic, pp.Partition}] = &pp
+	}
+}
+
+// record notes that a message's offset was committed.
+func (t *progressTracker) record(c *pendingCommit) {
+	raw := c.raw
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.partitions == nil {
+		t.partitions = make(map[partitionKey]*domain.PartitionProgress)
+	}
+	key := partitionKey{raw.Topic, raw.Partition}
+	pp, ok := t.partitions[key]
+	if !ok {
+		pp = &domain.PartitionProgress{Topic: raw.Topic, Partition: raw.Partition}
+		t.partitions[key] = pp
+	}
+	pp.LastOffset = raw.Offset
+	switch c.outcome {
+	case outcomeLoaded:
+		pp.Loaded++
+		pp.LastEventTime = c.event.EventTime
+	case outcomeFiltered:
+		pp.Filtered++
+	case outcomeDeadLettered:
+		pp.DeadLettered++
+	}
+	pp.UpdatedAt = time.Now().UTC()
+	t.dirty = true
+}
+
+// snapshot returns a copy of every partition's progress, ordered by topic and
+// partition.
+func (t *progressTracker) snapshot() []domain.PartitionProgress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]domain.PartitionProgress, 0, len(t.partitions))
+	for _, pp := range t.partitions {
+		out = append(out, *pp)
+	}
+	slices.SortFunc(out, func(a, b domain.PartitionProgress) int {
+		return cmp.Or(cmp.Compare(a.Topic, b.Topic), cmp.Compare(a.Partition, b.Partition))
+	})
+	return out
+}
+
+// takeDirty reports whether progress changed since the previous call.
+func (t *progressTracker) takeDirty() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	dirty := t.dirty
+	t.dirty = false
+	return dirty
+}
+
+// Progress returns the committed progress of every source partition the
+// pipeline has processed, including partitions restored from the store.
+func (p *Pipeline) Progress() []domain.PartitionProgress {
+	return p.progress.snapshot()
+}
+
+// loadProgress seeds the tracker from the store, if one is configured. A
+// failure is logged and progress starts empty rather than blocking the pipeline.
+func (p *Pipeline) loadProgress(ctx context.Context) {
+	if p.progressStore == nil {
+		return
+	}
+	progress, err := p.progressStore.LoadProgress(ctx)
+	if err != nil {
+		p.logger.Warn("load pipeline progress failed, starting empty", "error", err)
+		return
+	}
+	p.progress.seed(progress)
+}
+
+// saveProgress persists progress when it changed since the last save.
+func (p *Pipeline) saveProgress(ctx context.Context) {
+	if p.progressStore == nil {
+		return
+	}
+	if !p.progress.takeDirty() {
+		return
+	}
+	if err := p.progressStore.SaveProgress(ctx, p.progress.snapshot()); err != nil {
+		p.logger.Warn("save pipeline progress failed", "error", err)
+	}
+}
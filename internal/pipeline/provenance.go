@@ -0,0 +1,32 @@
+package pipeline
+
+import "github.com/couchcryptid/storm-data-etl/internal/domain"
+
+// WithProvenanceHeaders copies the named source message headers into each
+// event's Provenance, so sinks can record which collector run or source file
+// produced it. Headers missing from a message are skipped.
+func WithProvenanceHeaders(keys []string) Option {
+	return func(p *Pipeline) {
+		p.provenance = keys
+	}
+}
+
+// provenanceOf returns the selected headers of raw, or nil when it has none
+// of them.
+func (p *Pipeline) provenanceOf(raw domain.RawEvent) *domain.Provenance {
+	var headers map[string]string
+	for _, k := range p.provenance {
+		v, ok := raw.Headers[k]
+		if !ok {
+			continue
+		}
+		if headers == nil {
+			headers = make(map[string]string, len(p.provenance))
+		}
+		headers[k] = v
+	}
+	if headers == nil {
+		return nil
+	}
+	return &domain.Provenance{Headers: headers}
+}
@@ -0,0 +1,68 @@
+package pipeline
+
+import (
+	"context"
+	"math"
+
+	"golang.org/x/time/rate"
+)
+
+// WithRateLimit caps throughput at perSecond events, enforced by a token
+// bucket shared across batches so a large backfill is spread out instead of
+// reaching the sinks as fast as the source can be read. Zero or less means
+// unlimited.
+func WithRateLimit(perSecond float64) Option {
+	return func(p *Pipeline) {
+		p.limiter.SetLimit(limitFor(perSecond))
+		p.limiter.SetBurst(burstFor(perSecond))
+	}
+}
+
+// SetRateLimit changes the events-per-second cap, starting with the next
+// batch. Zero or less removes the cap.
+func (p *Pipeline) SetRateLimit(perSecond float64) {
+	perSecond = max(perSecond, 0)
+	if old := p.RateLimit(); old != perSecond {
+		p.limiter.SetLimit(limitFor(perSecond))
+		p.limiter.SetBurst(burstFor(perSecond))
+		p.metrics.RateLimit.Set(perSecond)
+		p.logger.Info("pipeline rate limit changed", "from", old, "to", perSecond)
+	}
+}
+
+// RateLimit returns the events-per-second cap, or 0 when unlimited.
+func (p *Pipeline) RateLimit() float64 {
+	if l := p.limiter.Limit(); l != rate.Inf {
+		return float64(l)
+	}
+	return 0
+}
+
+// throttle blocks until n events fit under the rate limit. The bucket holds
+// one second of tokens, so a batch larger than that waits in chunks. Returns
+// false if the context is cancelled, or its deadline would pass, while waiting.
+func (p *Pipeline) throttle(ctx context.Context, n int) bool {
+	for n > 0 {
+		chunk := min(n, p.limiter.Burst())
+		if err := p.limiter.WaitN(ctx, chunk); err != nil {
+			if ctx.Err() == nil && chunk > p.limiter.Burst() {
+				continue // SetRateLimit shrank the bucket; retry with smaller chunks
+			}
+			return false
+		}
+		n -= chunk
+	}
+	return true
+}
+
+func limitFor(perSecond float64) rate.Limit {
+	if perSecond <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(perSecond)
+}
+
+// burstFor sizes the bucket to one second of events, at least one.
+func burstFor(perSecond float64) int {
+	return max(int(math.Ceil(perSecond)), 1)
+}
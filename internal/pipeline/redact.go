@@ -0,0 +1,40 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StageRedact is the name of the comment redaction stage.
+const StageRedact = "redact"
+
+// WithCommentRedaction appends a stage that removes personal details from
+// each event's comments with r before any loader serializes the event. It
+// runs after the stages registered before it, so register it last: impact
+// extraction, keyword severity, and custom stages still read the original
+// text. Each redacted match is counted in redactions by pattern name.
+func WithCommentRedaction(r *domain.Redactor, redactions *prometheus.CounterVec) TransformerOption {
+	return WithStage(StageRedact, func(_ context.Context, _ domain.RawEvent, event domain.StormEvent, audit *domain.Audit) (domain.StormEvent, error) {
+		text, counts := r.Redact(event.Comments)
+		if counts == nil {
+			return event, nil
+		}
+		event.Comments = text
+		names := slices.Sorted(maps.Keys(counts))
+		total := 0
+		for _, name := range names {
+			redactions.WithLabelValues(name).Add(float64(counts[name]))
+			total += counts[name]
+		}
+		// The audit log must not repeat what was redacted, so only the
+		// patterns are recorded.
+		audit.Add("comments", "", "", fmt.Sprintf("redacted %d matches: %s", total, strings.Join(names, ", ")))
+		return event, nil
+	})
+}
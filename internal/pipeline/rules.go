@@ -0,0 +1,33 @@
+package pipeline
+
+import "github.com/couchcryptid/storm-data-etl/internal/domain"
+
+// WithValidationRules checks every enriched event against rules before the
+// event filter. Each failed check is counted by check and action; annotate
+// rules add the check to the event's Quality.Flags, and the first failed
+// quarantine or drop rule dead-letters the message or drops it like the
+// event filter.
+func WithValidationRules(rules []domain.ValidationRule) Option {
+	return func(p *Pipeline) {
+		p.rules = rules
+	}
+}
+
+// applyRules evaluates the validation rules against event, annotating it in
+// place, and returns the first failed quarantine or drop rule, if any.
+func (p *Pipeline) applyRules(event *domain.StormEvent) (domain.ValidationRule, bool) {
+	var blocking domain.ValidationRule
+	var blocked bool
+	for _, r := range domain.Violations(p.rules, *event) {
+		p.metrics.ValidationViolations.WithLabelValues(r.Check, r.Action).Inc()
+		switch r.Action {
+		case domain.RuleActionAnnotate:
+			event.AddQualityFlag(r.Check)
+		case domain.RuleActionQuarantine, domain.RuleActionDrop:
+			if !blocked {
+				blocking, blocked = r, true
+			}
+		}
+	}
+	return blocking, blocked
+}
@@ -0,0 +1,66 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// MessageSizer is implemented by loaders that publish each event as a
+// message of bounded size, such as the Kafka writer.
+type MessageSizer interface {
+	// MessageBytes returns the size of the largest message the event would
+	// be published as.
+	MessageBytes(event domain.StormEvent) (int, error)
+}
+
+// sizeGuard keeps oversized events out of the loader.
+type sizeGuard struct {
+	sizer  MessageSizer
+	limit  int
+	policy string
+}
+
+// WithMessageSizeLimit checks the size of every event's sink message before
+// the batch is loaded. An event over limit bytes is shrunk or dead-lettered
+// according to policy, one of domain.OversizePolicies, so one pathological
+// record cannot fail every retry of its batch. Events are counted by the
+// action taken.
+func WithMessageSizeLimit(sizer MessageSizer, limit int, policy string) Option {
+	return func(p *Pipeline) {
+		p.sizeGuard = &sizeGuard{sizer: sizer, limit: limit, policy: policy}
+	}
+}
+
+// fit returns the event, shrunk per the policy when its message is too
+// large, and the action taken, or "" when it already fit. It returns an error
+// when the event cannot be made to fit and must be dead-lettered.
+func (g *sizeGuard) fit(event domain.StormEvent) (domain.StormEvent, string, error) {
+	size, err := g.sizer.MessageBytes(event)
+	if err != nil || size <= g.limit {
+		return event, "", err
+	}
+	shrunk, action := event, ""
+	switch g.policy {
+	case domain.OversizeTruncate:
+		// The quality flag adds a few bytes of its own, so a first cut by
+		// the excess may fall short.
+		for size > g.limit && shrunk.Comments != "" {
+			shrunk, action = domain.TruncateComments(shrunk, size-g.limit), "truncated"
+			if size, err = g.sizer.MessageBytes(shrunk); err != nil {
+				return event, "", err
+			}
+		}
+	case domain.OversizeDropRaw:
+		if event.Comments != "" || event.Location.Raw != "" {
+			shrunk, action = domain.DropRawText(event), "dropped_raw"
+			if size, err = g.sizer.MessageBytes(shrunk); err != nil {
+				return event, "", err
+			}
+		}
+	}
+	if action != "" && size <= g.limit {
+		return shrunk, action, nil
+	}
+	return event, "dead_lettered", fmt.Errorf("sink message of %d bytes exceeds the %d byte limit", size, g.limit)
+}
@@ -0,0 +1,60 @@
+package pipeline
+
+import (
+	"context"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies spans created by the pipeline.
+const tracerName = "github.com/couchcryptid/storm-data-etl/internal/pipeline"
+
+// messageContext returns ctx with the message's upstream span (from its
+// traceparent header) as the parent, so per-message spans join the
+// collector's trace. Without a traceparent, ctx is returned unchanged.
+func (p *Pipeline) messageContext(ctx context.Context, raw domain.RawEvent) context.Context {
+	return p.propagator.Extract(ctx, propagation.MapCarrier(raw.Headers))
+}
+
+// messageLinks links a batch span to the upstream trace of every message in it.
+func (p *Pipeline) messageLinks(rawBatch []domain.RawEvent) []trace.Link {
+	var links []trace.Link
+	for i := range rawBatch {
+		sc := trace.SpanContextFromContext(p.messageContext(context.Background(), rawBatch[i]))
+		if sc.IsValid() {
+			links = append(links, trace.Link{SpanContext: sc})
+		}
+	}
+	return links
+}
+
+// traceContext serializes the span context in ctx for propagation to the sink.
+func (p *Pipeline) traceContext(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	p.propagator.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+func messageAttributes(raw domain.RawEvent) trace.SpanStartEventOption {
+	return trace.WithAttributes(
+		attribute.String("messaging.destination.name", raw.Topic),
+		attribute.Int("messaging.destination.partition.id", raw.Partition),
+		attribute.Int64("messaging.kafka.offset", raw.Offset),
+	)
+}
+
+// endSpan records err, if any, on the span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
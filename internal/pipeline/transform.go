@@ -2,30 +2,246 @@ package pipeline
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"slices"
+	"sync/atomic"
+	"time"
 
 	"github.com/couchcryptid/storm-data-etl/internal/domain"
 )
 
-// StormTransformer implements Transformer using domain transform functions.
+// Names of the built-in transform stages, in the order they run.
+const (
+	StageParse     = "parse"
+	StageNormalize = "normalize"
+	StageSeverity  = "severity"
+	StageGeocode   = "geocode"
+)
+
+// StageFunc is one step of the transform chain. It receives the source
+// message and the event built by the earlier stages and returns the updated
+// event. An error rejects the message, which is then dead-lettered like any
+// other transform failure. Decisions recorded in audit appear in the audit
+// log; audit is nil unless WithAuditLog is set, and (*domain.Audit).Add
+// accepts a nil receiver.
+type StageFunc func(ctx context.Context, raw domain.RawEvent, event domain.StormEvent, audit *domain.Audit) (domain.StormEvent, error)
+
+// stage is a named step of the transform chain.
+type stage struct {
+	Name  string
+	Apply StageFunc
+}
+
+// builtinStages returns parse -> normalize -> severity -> geocode. Parse
+// ignores its input event and decodes and validates the raw message. The
+// stages load t.enrichment when they run, so WithEnrichment may be applied
+// after the chain is built and SetEnrichment takes effect on the next stage.
+func (t *StormTransformer) builtinStages() []stage {
+	return []stage{
+		{Name: StageParse, Apply: t.parseStage},
+		{Name: StageNormalize, Apply: t.enrichStage(domain.Enrichment.NormalizeStormEvent)},
+		{Name: StageSeverity, Apply: t.enrichStage(domain.Enrichment.ClassifyStormEvent)},
+		{Name: StageGeocode, Apply: t.enrichStage(domain.Enrichment.GeocodeStormEvent)},
+	}
+}
+
+func (t *StormTransformer) parseStage(_ context.Context, raw domain.RawEvent, _ domain.StormEvent, _ *domain.Audit) (domain.StormEvent, error) {
+	event, err := t.enrichment.Load().ParseRawEvent(raw)
+	if err != nil {
+		return domain.StormEvent{}, err
+	}
+	// Return the parsed event with the error so the audit line can name it.
+	return event, domain.ValidateStormEvent(event)
+}
+
+func (t *StormTransformer) enrichStage(enrich func(domain.Enrichment, domain.StormEvent, *domain.Audit) domain.StormEvent) StageFunc {
+	return func(_ context.Context, _ domain.RawEvent, event domain.StormEvent, audit *domain.Audit) (domain.StormEvent, error) {
+		return enrich(*t.enrichment.Load(), event, audit), nil
+	}
+}
+
+// StormTransformer implements Transformer as a chain of stages. The built-in
+// stages reproduce (domain.Enrichment).EnrichStormEvent; site-specific stages
+// registered with WithStage or WithStageAfter run in the same chain, and the
+// time bucket, processing time, and schema version are stamped after the last
+// stage.
 type StormTransformer struct {
 	logger *slog.Logger
+	// enrichment is read by concurrent transform workers and swapped on
+	// configuration reload, so it is held behind an atomic pointer.
+	enrichment atomic.Pointer[domain.Enrichment]
+	audit      bool
+	stages     []stage
+	observe    func(stage string, d time.Duration)
+}
+
+// TransformerOption configures optional StormTransformer behavior.
+type TransformerOption func(*StormTransformer)
+
+// WithEnrichment sets the units, ID strategy, and other settings the built-in
+// stages enrich with. Without it they use the zero domain.Enrichment.
+func WithEnrichment(e domain.Enrichment) TransformerOption {
+	return func(t *StormTransformer) {
+		t.enrichment.Store(&e)
+	}
+}
+
+// WithAuditLog logs one "transform audit" line per event listing every
+// normalization decision, or the reason the event was rejected.
+func WithAuditLog() TransformerOption {
+	return func(t *StormTransformer) {
+		t.audit = true
+	}
+}
+
+// WithStage appends a custom stage after the built-in ones. Stages registered
+// this way run in registration order.
+func WithStage(name string, apply StageFunc) TransformerOption {
+	return func(t *StormTransformer) {
+		t.stages = append(t.stages, stage{Name: name, Apply: apply})
+	}
 }
 
-// NewTransformer creates a StormTransformer.
-func NewTransformer(logger *slog.Logger) *StormTransformer {
-	return &StormTransformer{
-		logger: logger,
+// WithStageAfter inserts a custom stage directly after the stage called
+// after, which may be a built-in or an earlier custom stage. NewTransformer
+// panics if no such stage is registered, since the chain is fixed at startup.
+func WithStageAfter(after, name string, apply StageFunc) TransformerOption {
+	return func(t *StormTransformer) {
+		i := slices.IndexFunc(t.stages, func(s stage) bool { return s.Name == after })
+		if i < 0 {
+			panic(fmt.Sprintf("pipeline: cannot insert transform stage %q after unknown stage %q", name, after))
+		}
+		t.stages = slices.Insert(t.stages, i+1, stage{Name: name, Apply: apply})
 	}
 }
 
+// WithoutStage removes the stage called name, which may be a built-in or an
+// earlier custom stage, e.g. to measure the chain without geocoding.
+// NewTransformer panics if no such stage is registered or name is the parse
+// stage, which every later stage depends on.
+func WithoutStage(name string) TransformerOption {
+	return func(t *StormTransformer) {
+		i := slices.IndexFunc(t.stages, func(s stage) bool { return s.Name == name })
+		if i < 0 || name == StageParse {
+			panic(fmt.Sprintf("pipeline: cannot remove transform stage %q", name))
+		}
+		t.stages = slices.Delete(t.stages, i, i+1)
+	}
+}
+
+// WithStageTiming calls observe with the name and duration of every stage
+// run, including ones that fail. Transform workers run in parallel, so
+// observe must be safe for concurrent use.
+func WithStageTiming(observe func(stage string, d time.Duration)) TransformerOption {
+	return func(t *StormTransformer) {
+		t.observe = observe
+	}
+}
+
+// NewTransformer creates a StormTransformer running the built-in stages plus
+// any registered with options.
+func NewTransformer(logger *slog.Logger, opts ...TransformerOption) *StormTransformer {
+	t := &StormTransformer{logger: logger}
+	t.enrichment.Store(&domain.Enrichment{})
+	t.stages = t.builtinStages()
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Enrichment returns the enrichment settings the built-in stages use.
+func (t *StormTransformer) Enrichment() domain.Enrichment {
+	return *t.enrichment.Load()
+}
+
+// SetEnrichment replaces the enrichment settings, starting with the next
+// stage to run. Safe to call while events are transformed, e.g. to apply
+// reloaded severity thresholds.
+func (t *StormTransformer) SetEnrichment(e domain.Enrichment) {
+	t.enrichment.Store(&e)
+}
+
+// Stages returns the stage names in the order they run.
+func (t *StormTransformer) Stages() []string {
+	names := make([]string, len(t.stages))
+	for i, s := range t.stages {
+		names[i] = s.Name
+	}
+	return names
+}
+
 func (t *StormTransformer) Transform(ctx context.Context, raw domain.RawEvent) (domain.StormEvent, error) {
-	event, err := domain.ParseRawEvent(raw)
-	if err != nil {
-		return domain.StormEvent{}, err
+	var audit *domain.Audit
+	if t.audit {
+		audit = &domain.Audit{}
 	}
+	return t.transform(ctx, raw, audit)
+}
 
-	event = domain.EnrichStormEvent(event)
+// TransformAudited is Transform that also returns the decisions every stage
+// took, whether or not WithAuditLog is set, e.g. for cmd/transform -audit.
+// The audit line is still logged only with WithAuditLog.
+func (t *StormTransformer) TransformAudited(ctx context.Context, raw domain.RawEvent) (domain.StormEvent, []domain.AuditStep, error) {
+	audit := domain.Audit{}
+	event, err := t.transform(ctx, raw, &audit)
+	return event, audit, err
+}
 
+// transform runs the stages, recording their decisions in audit when it is
+// non-nil.
+func (t *StormTransformer) transform(ctx context.Context, raw domain.RawEvent, audit *domain.Audit) (domain.StormEvent, error) {
+	var event domain.StormEvent
+	for _, s := range t.stages {
+		var start time.Time
+		if t.observe != nil {
+			start = time.Now()
+		}
+		out, err := s.Apply(ctx, raw, event, audit)
+		if t.observe != nil {
+			t.observe(s.Name, time.Since(start))
+		}
+		if err != nil {
+			// A failing stage may return a zero event; fall back to its input
+			// so the audit line still names the event.
+			if out.ID == "" {
+				out = event
+			}
+			t.auditRejected(ctx, raw, out, err)
+			// Parse errors keep their established dead-letter reasons; later
+			// stages are named so custom stage failures can be told apart.
+			if s.Name != StageParse {
+				err = fmt.Errorf("%s stage: %w", s.Name, err)
+			}
+			return domain.StormEvent{}, err
+		}
+		event = out
+	}
+	event = domain.FinalizeStormEvent(event)
+
+	if t.audit {
+		t.logger.InfoContext(ctx, "transform audit",
+			"event_id", event.ID,
+			"event_type", event.EventType,
+			"source_topic", raw.Topic,
+			"source_offset", raw.Offset,
+			"decisions", []domain.AuditStep(*audit),
+		)
+	}
 	return event, nil
 }
+
+// auditRejected logs the audit line for an event that a stage rejected.
+func (t *StormTransformer) auditRejected(ctx context.Context, raw domain.RawEvent, event domain.StormEvent, err error) {
+	if !t.audit {
+		return
+	}
+	t.logger.InfoContext(ctx, "transform audit",
+		"event_id", event.ID,
+		"event_type", event.EventType,
+		"source_topic", raw.Topic,
+		"source_offset", raw.Offset,
+		"rejected", err.Error(),
+	)
+}
@@ -0,0 +1,297 @@
+// Package stormpb encodes domain events as the protobuf messages defined in
+// proto/storm/v1. Encoding is hand-written against protowire so the service
+// does not need generated code.
+package stormpb
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers from proto/storm/v1/storm_event.proto. Keep in sync with the schema.
+const (
+	pbEventID           protowire.Number = 1
+	pbEventType         protowire.Number = 2
+	pbEventGeo          protowire.Number = 3
+	pbEventMeasurement  protowire.Number = 4
+	pbEventTime         protowire.Number = 5
+	pbEventLocation     protowire.Number = 6
+	pbEventComments     protowire.Number = 7
+	pbEventSourceOffice protowire.Number = 8
+	pbEventTimeBucket   protowire.Number = 9
+	pbEventProcessedAt  protowire.Number = 10
+	pbEventImpact       protowire.Number = 11
+	pbEventOfficeDetail protowire.Number = 12
+	pbEventSchemaVer    protowire.Number = 13
+	pbEventProvenance   protowire.Number = 14
+	pbEventEndTime      protowire.Number = 15
+	pbEventPathBegin    protowire.Number = 16
+	pbEventPathEnd      protowire.Number = 17
+	pbEventQuality      protowire.Number = 18
+	pbEventMergedFrom   protowire.Number = 19
+	pbEventEpisodeID    protowire.Number = 20
+	pbEventNearestCity  protowire.Number = 21
+	pbEventNearbyPlaces protowire.Number = 22
+	pbEventWarning      protowire.Number = 23
+	pbEventWarningIDs   protowire.Number = 24
+	pbEventGeohash      protowire.Number = 25
+
+	pbGeoLat protowire.Number = 1
+	pbGeoLon protowire.Number = 2
+
+	pbLocationRaw       protowire.Number = 1
+	pbLocationName      protowire.Number = 2
+	pbLocationDistance  protowire.Number = 3
+	pbLocationDirection protowire.Number = 4
+	pbLocationState     protowire.Number = 5
+	pbLocationCounty    protowire.Number = 6
+	pbLocationPlaceGeo  protowire.Number = 7
+	pbLocationCWA       protowire.Number = 8
+	pbLocationZone      protowire.Number = 9
+
+	pbMeasurementMagnitude protowire.Number = 1
+	pbMeasurementUnit      protowire.Number = 2
+	pbMeasurementSeverity  protowire.Number = 3
+	pbMeasurementMetric    protowire.Number = 4
+	pbMeasurementMethod    protowire.Number = 5
+
+	pbQuantityMagnitude protowire.Number = 1
+	pbQuantityUnit      protowire.Number = 2
+
+	pbOfficeCode  protowire.Number = 1
+	pbOfficeName  protowire.Number = 2
+	pbOfficeState protowire.Number = 3
+	pbOfficeGeo   protowire.Number = 4
+
+	pbCityName      protowire.Number = 1
+	pbCityState     protowire.Number = 2
+	pbCityDistance  protowire.Number = 3
+	pbCityDirection protowire.Number = 4
+	pbCityGeo       protowire.Number = 5
+
+	pbNearbyPopulation protowire.Number = 1
+	pbNearbyPlaces     protowire.Number = 2
+	pbNearbyRadius     protowire.Number = 3
+
+	pbImpactInjuries   protowire.Number = 1
+	pbImpactFatalities protowire.Number = 2
+	pbImpactDamage     protowire.Number = 3
+
+	pbProvenanceHeaders protowire.Number = 1
+
+	pbQualityInferred protowire.Number = 1
+	pbQualityFlags    protowire.Number = 2
+
+	pbMapKey   protowire.Number = 1
+	pbMapValue protowire.Number = 2
+
+	pbTimestampSeconds protowire.Number = 1
+	pbTimestampNanos   protowire.Number = 2
+)
+
+// MarshalStormEvent encodes a StormEvent as a storm.v1.StormEvent message.
+// Proto3 default values are omitted as protoc would.
+func MarshalStormEvent(e domain.StormEvent) []byte {
+	var b []byte
+	if e.SchemaVersion != 0 {
+		b = protowire.AppendTag(b, pbEventSchemaVer, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(e.SchemaVersion)) //nolint:gosec // schema versions are small positive integers
+	}
+	b = appendString(b, pbEventID, e.ID)
+	b = appendString(b, pbEventType, e.EventType)
+	b = appendMessage(b, pbEventGeo, appendGeo(nil, e.Geo))
+	b = appendMessage(b, pbEventMeasurement, appendMeasurement(nil, e.Measurement))
+	b = appendMessage(b, pbEventTime, appendTimestamp(nil, e.EventTime))
+	b = appendMessage(b, pbEventEndTime, appendTimestamp(nil, e.EndTime))
+	b = appendMessage(b, pbEventLocation, appendLocation(nil, e.Location))
+	b = appendString(b, pbEventComments, e.Comments)
+	b = appendString(b, pbEventSourceOffice, e.SourceOffice)
+	b = appendMessage(b, pbEventTimeBucket, appendTimestamp(nil, e.TimeBucket))
+	b = appendMessage(b, pbEventProcessedAt, appendTimestamp(nil, e.ProcessedAt))
+	if e.Impact != nil {
+		b = appendMessage(b, pbEventImpact, appendImpact(nil, *e.Impact))
+	}
+	if o := e.SourceOfficeDetail; o != nil {
+		var ob []byte
+		ob = appendString(ob, pbOfficeCode, o.Code)
+		ob = appendString(ob, pbOfficeName, o.Name)
+		ob = appendString(ob, pbOfficeState, o.State)
+		ob = appendMessage(ob, pbOfficeGeo, appendGeo(nil, o.Geo))
+		b = appendMessage(b, pbEventOfficeDetail, ob)
+	}
+	if e.Provenance != nil {
+		b = appendMessage(b, pbEventProvenance, appendProvenance(nil, *e.Provenance))
+	}
+	if e.PathBegin != nil && e.PathEnd != nil {
+		b = appendMessage(b, pbEventPathBegin, appendGeo(nil, *e.PathBegin))
+		b = appendMessage(b, pbEventPathEnd, appendGeo(nil, *e.PathEnd))
+	}
+	if e.Quality != nil {
+		var qb []byte
+		for _, f := range e.Quality.Inferred {
+			qb = protowire.AppendTag(qb, pbQualityInferred, protowire.BytesType)
+			qb = protowire.AppendString(qb, f)
+		}
+		for _, f := range e.Quality.Flags {
+			qb = protowire.AppendTag(qb, pbQualityFlags, protowire.BytesType)
+			qb = protowire.AppendString(qb, f)
+		}
+		b = appendMessage(b, pbEventQuality, qb)
+	}
+	for _, id := range e.MergedFrom {
+		b = appendString(b, pbEventMergedFrom, id)
+	}
+	b = appendString(b, pbEventEpisodeID, e.EpisodeID)
+	if c := e.NearestCity; c != nil {
+		var cb []byte
+		cb = appendString(cb, pbCityName, c.Name)
+		cb = appendString(cb, pbCityState, c.State)
+		cb = appendDouble(cb, pbCityDistance, c.Distance)
+		cb = appendString(cb, pbCityDirection, c.Direction)
+		cb = appendMessage(cb, pbCityGeo, appendGeo(nil, c.Geo))
+		b = appendMessage(b, pbEventNearestCity, cb)
+	}
+	if x := e.NearbyPlaces; x != nil {
+		var xb []byte
+		xb = appendVarint(xb, pbNearbyPopulation, uint64(x.Population)) //nolint:gosec // populations are non-negative
+		xb = appendVarint(xb, pbNearbyPlaces, uint64(x.Places))         //nolint:gosec // counts are non-negative
+		xb = appendDouble(xb, pbNearbyRadius, x.Radius)
+		b = appendMessage(b, pbEventNearbyPlaces, xb)
+	}
+	if e.WarningActive != nil {
+		b = protowire.AppendTag(b, pbEventWarning, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(*e.WarningActive))
+	}
+	for _, id := range e.WarningIDs {
+		b = appendString(b, pbEventWarningIDs, id)
+	}
+	b = appendString(b, pbEventGeohash, e.Geohash)
+	return b
+}
+
+// appendProvenance encodes the headers map as repeated key/value entries,
+// sorted by key so the encoding is deterministic.
+func appendProvenance(b []byte, p domain.Provenance) []byte {
+	for _, k := range slices.Sorted(maps.Keys(p.Headers)) {
+		var entry []byte
+		entry = appendString(entry, pbMapKey, k)
+		entry = appendString(entry, pbMapValue, p.Headers[k])
+		b = appendMessage(b, pbProvenanceHeaders, entry)
+	}
+	return b
+}
+
+func appendGeo(b []byte, g domain.Geo) []byte {
+	b = appendDouble(b, pbGeoLat, g.Lat)
+	b = appendDouble(b, pbGeoLon, g.Lon)
+	return b
+}
+
+func appendLocation(b []byte, l domain.Location) []byte {
+	b = appendString(b, pbLocationRaw, l.Raw)
+	b = appendString(b, pbLocationName, l.Name)
+	if l.Distance != nil {
+		b = protowire.AppendTag(b, pbLocationDistance, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(*l.Distance))
+	}
+	if l.Direction != nil {
+		b = protowire.AppendTag(b, pbLocationDirection, protowire.BytesType)
+		b = protowire.AppendString(b, *l.Direction)
+	}
+	b = appendString(b, pbLocationState, l.State)
+	b = appendString(b, pbLocationCounty, l.County)
+	if l.PlaceGeo != nil {
+		b = appendMessage(b, pbLocationPlaceGeo, appendGeo(nil, *l.PlaceGeo))
+	}
+	b = appendString(b, pbLocationCWA, l.CWA)
+	b = appendString(b, pbLocationZone, l.Zone)
+	return b
+}
+
+func appendMeasurement(b []byte, m domain.Measurement) []byte {
+	b = appendDouble(b, pbMeasurementMagnitude, m.Magnitude)
+	b = appendString(b, pbMeasurementUnit, m.Unit)
+	if m.Severity != nil {
+		b = protowire.AppendTag(b, pbMeasurementSeverity, protowire.BytesType)
+		b = protowire.AppendString(b, *m.Severity)
+	}
+	if m.Metric != nil {
+		var q []byte
+		q = appendDouble(q, pbQuantityMagnitude, m.Metric.Magnitude)
+		q = appendString(q, pbQuantityUnit, m.Metric.Unit)
+		b = appendMessage(b, pbMeasurementMetric, q)
+	}
+	b = appendString(b, pbMeasurementMethod, m.Method)
+	return b
+}
+
+// appendImpact encodes Impact; optional counts are written whenever set, even when 0.
+func appendImpact(b []byte, im domain.Impact) []byte {
+	if im.Injuries != nil {
+		b = protowire.AppendTag(b, pbImpactInjuries, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(*im.Injuries)) //nolint:gosec // casualty counts are non-negative
+	}
+	if im.Fatalities != nil {
+		b = protowire.AppendTag(b, pbImpactFatalities, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(*im.Fatalities)) //nolint:gosec // casualty counts are non-negative
+	}
+	for _, d := range im.Damage {
+		b = protowire.AppendTag(b, pbImpactDamage, protowire.BytesType)
+		b = protowire.AppendString(b, d)
+	}
+	return b
+}
+
+// appendTimestamp encodes a google.protobuf.Timestamp. Zero times encode as
+// an empty message and are omitted by appendMessage.
+func appendTimestamp(b []byte, t time.Time) []byte {
+	if t.IsZero() {
+		return b
+	}
+	if secs := t.Unix(); secs != 0 {
+		b = protowire.AppendTag(b, pbTimestampSeconds, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(secs)) //nolint:gosec // two's-complement int64 encoding is the protobuf wire format
+	}
+	if nanos := t.Nanosecond(); nanos != 0 {
+		b = protowire.AppendTag(b, pbTimestampNanos, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(nanos))
+	}
+	return b
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendDouble(b []byte, num protowire.Number, v float64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(v))
+}
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	if len(msg) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
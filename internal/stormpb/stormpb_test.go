@@ -0,0 +1,127 @@
+package stormpb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestMarshalStormEvent(t *testing.T) {
+	now := time.Date(2024, 4, 26, 15, 10, 0, 0, time.UTC)
+	severity := "severe"
+	event := domain.StormEvent{
+		ID:          "evt-1",
+		EventType:   "hail",
+		Geo:         domain.Geo{Lat: 35.0, Lon: -97.0},
+		Measurement: domain.Measurement{Magnitude: 1.75, Unit: "in", Severity: &severity, Metric: &domain.Quantity{Magnitude: 44.45, Unit: "mm"}, Method: domain.MethodMeasured},
+		Location:    domain.Location{Raw: "8 ESE Chappel", PlaceGeo: &domain.Geo{Lat: 35.04, Lon: -97.12}, CWA: "OUN", Zone: "OKZ025"},
+		Impact:      &domain.Impact{Injuries: new(int), Damage: []string{"trees down"}},
+		EventTime:   now,
+		EndTime:     now.Add(15 * time.Minute),
+		ProcessedAt: now,
+		Provenance:  &domain.Provenance{Headers: map[string]string{"collector_run_id": "run-42"}},
+		PathBegin:   &domain.Geo{Lat: 34.93, Lon: -95.8},
+		PathEnd:     &domain.Geo{Lat: 34.99, Lon: -95.71},
+		Quality:     &domain.DataQuality{Inferred: []string{"measurement.magnitude"}, Flags: []string{"missing_state"}},
+		MergedFrom:  []string{"hail-2"},
+		EpisodeID:   "episode-0123456789abcdef",
+
+		SourceOfficeDetail: &domain.SourceOfficeDetail{Code: "OUN", Name: "Norman", State: "OK", Geo: domain.Geo{Lat: 35.18, Lon: -97.44}},
+		NearestCity:        &domain.NearestCity{Name: "Norman", State: "OK", Distance: 12.4, Direction: "ESE", Geo: domain.Geo{Lat: 35.22, Lon: -97.44}},
+		NearbyPlaces:       &domain.NearbyPlaces{Population: 128026, Places: 1, Radius: 15},
+		WarningActive:      new(bool),
+		WarningIDs:         []string{"urn:oid:2.49.0.1.840.0.1"},
+		Geohash:            "9y68qew",
+	}
+
+	fields := decodeFields(t, MarshalStormEvent(event))
+	assert.Equal(t, "evt-1", string(fields[pbEventID]))
+	assert.Equal(t, "hail", string(fields[pbEventType]))
+
+	measurement := decodeFields(t, fields[pbEventMeasurement])
+	assert.Equal(t, "in", string(measurement[pbMeasurementUnit]))
+	assert.Equal(t, "severe", string(measurement[pbMeasurementSeverity]))
+	assert.Equal(t, "measured", string(measurement[pbMeasurementMethod]))
+	metric := decodeFields(t, measurement[pbMeasurementMetric])
+	assert.Equal(t, "mm", string(metric[pbQuantityUnit]))
+
+	location := decodeFields(t, fields[pbEventLocation])
+	placeGeo := decodeFields(t, location[pbLocationPlaceGeo])
+	assert.Contains(t, placeGeo, pbGeoLat)
+	assert.Equal(t, "OUN", string(location[pbLocationCWA]))
+	assert.Equal(t, "OKZ025", string(location[pbLocationZone]))
+
+	impact := decodeFields(t, fields[pbEventImpact])
+	assert.Equal(t, []byte{0}, impact[pbImpactInjuries], "explicit zero injuries should be encoded")
+	assert.Equal(t, "trees down", string(impact[pbImpactDamage]))
+
+	office := decodeFields(t, fields[pbEventOfficeDetail])
+	assert.Equal(t, "Norman", string(office[pbOfficeName]))
+
+	provenance := decodeFields(t, fields[pbEventProvenance])
+	entry := decodeFields(t, provenance[pbProvenanceHeaders])
+	assert.Equal(t, "collector_run_id", string(entry[pbMapKey]))
+	assert.Equal(t, "run-42", string(entry[pbMapValue]))
+
+	assert.Contains(t, decodeFields(t, fields[pbEventPathBegin]), pbGeoLat)
+	assert.Contains(t, decodeFields(t, fields[pbEventPathEnd]), pbGeoLon)
+
+	quality := decodeFields(t, fields[pbEventQuality])
+	assert.Equal(t, "measurement.magnitude", string(quality[pbQualityInferred]))
+	assert.Equal(t, "missing_state", string(quality[pbQualityFlags]))
+	assert.Equal(t, "hail-2", string(fields[pbEventMergedFrom]))
+	assert.Equal(t, "episode-0123456789abcdef", string(fields[pbEventEpisodeID]))
+
+	city := decodeFields(t, fields[pbEventNearestCity])
+	assert.Equal(t, "Norman", string(city[pbCityName]))
+	assert.Equal(t, "ESE", string(city[pbCityDirection]))
+	assert.Contains(t, city, pbCityDistance)
+	assert.Contains(t, decodeFields(t, city[pbCityGeo]), pbGeoLat)
+
+	nearby := decodeFields(t, fields[pbEventNearbyPlaces])
+	population, _ := protowire.ConsumeVarint(nearby[pbNearbyPopulation])
+	assert.Equal(t, uint64(128026), population)
+	assert.Contains(t, nearby, pbNearbyRadius)
+	assert.Equal(t, []byte{0}, fields[pbEventWarning], "an explicit false warning_active should be encoded")
+	assert.Equal(t, "urn:oid:2.49.0.1.840.0.1", string(fields[pbEventWarningIDs]))
+	assert.Equal(t, "9y68qew", string(fields[pbEventGeohash]))
+
+	ts := decodeFields(t, fields[pbEventTime])
+	secs, n := protowire.ConsumeVarint(ts[pbTimestampSeconds])
+	require.Positive(t, n)
+	assert.Equal(t, now.Unix(), int64(secs)) //nolint:gosec // test decodes a known-positive timestamp
+
+	end := decodeFields(t, fields[pbEventEndTime])
+	secs, _ = protowire.ConsumeVarint(end[pbTimestampSeconds])
+	assert.Equal(t, now.Add(15*time.Minute).Unix(), int64(secs)) //nolint:gosec // test decodes a known-positive timestamp
+
+	_, hasBucket := fields[pbEventTimeBucket]
+	assert.False(t, hasBucket, "zero time_bucket should be omitted")
+}
+
+// decodeFields decodes one level of a protobuf message into raw field
+// values keyed by field number. Length-delimited values are returned without
+// their length prefix; varints are returned in their encoded form.
+func decodeFields(t *testing.T, b []byte) map[protowire.Number][]byte {
+	t.Helper()
+	fields := map[protowire.Number][]byte{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.GreaterOrEqual(t, n, 0, "invalid tag")
+		b = b[n:]
+		m := protowire.ConsumeFieldValue(num, typ, b)
+		require.GreaterOrEqual(t, m, 0, "invalid field value")
+		value := b[:m]
+		if typ == protowire.BytesType {
+			v, _ := protowire.ConsumeBytes(value)
+			value = v
+		}
+		fields[num] = value
+		b = b[m:]
+	}
+	return fields
+}
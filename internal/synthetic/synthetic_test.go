@@ -0,0 +1,31 @@
+package synthetic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_RawEvents(t *testing.T) {
+	day := time.Date(2024, time.April, 26, 0, 0, 0, 0, time.UTC)
+	events := NewGenerator(1).RawEvents(500, "raw-weather-reports", day)
+	assert.Equal(t, events, NewGenerator(1).RawEvents(500, "raw-weather-reports", day), "the same seed gives the same reports")
+
+	types := map[string]int{}
+	for i, raw := range events {
+		assert.Equal(t, int64(i), raw.Offset)
+		event, err := domain.ParseRawEvent(raw)
+		require.NoError(t, err)
+		require.NoError(t, domain.ValidateStormEvent(event), string(raw.Value))
+		enriched := domain.EnrichStormEvent(event)
+		assert.NotEmpty(t, enriched.SourceOffice, "comments end with an office code")
+		assert.NotNil(t, enriched.Location.Distance, "locations parse")
+		types[enriched.EventType]++
+	}
+	for _, eventType := range []string{"hail", "wind", "tornado"} {
+		assert.Positive(t, types[eventType], eventType)
+	}
+}
@@ -0,0 +1,11 @@
+// Package schemas embeds the published JSON Schemas, generated with
+// go run ./cmd/validate -emit-schemas schemas, so the service serves and
+// registers the same documents it publishes.
+package schemas
+
+import _ "embed"
+
+// StormEvent is the JSON Schema of the events written to the sinks.
+//
+//go:embed storm_event.schema.json
+var StormEvent []byte
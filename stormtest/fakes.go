@@ -0,0 +1,114 @@
+// Package stormtest provides fake pipeline stages and canonical sample
+// events for tests, both in this repository and in downstream services that
+// consume the enriched topic and want contract fixtures without copying them.
+package stormtest
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// Extractor returns Batches in order, one per ExtractBatch call, then blocks
+// until the context is cancelled to simulate an idle source topic.
+type Extractor struct {
+	Batches [][]domain.RawEvent
+	index   atomic.Int64
+}
+
+// ExtractBatch returns the next configured batch, ignoring the size hint.
+func (e *Extractor) ExtractBatch(ctx context.Context, _ int) ([]domain.RawEvent, error) {
+	i := int(e.index.Add(1) - 1)
+	if i >= len(e.Batches) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return e.Batches[i], nil
+}
+
+// Calls reports how many times ExtractBatch has been called.
+func (e *Extractor) Calls() int {
+	return int(e.index.Load())
+}
+
+// Transformer decodes each raw value as an already-enriched StormEvent, so
+// tests can drive the pipeline with events built by RawEvent. A non-nil Err
+// fails every call.
+type Transformer struct {
+	Err error
+}
+
+// Transform returns Err or the StormEvent encoded in raw.Value.
+func (t *Transformer) Transform(_ context.Context, raw domain.RawEvent) (domain.StormEvent, error) {
+	if t.Err != nil {
+		return domain.StormEvent{}, t.Err
+	}
+	var event domain.StormEvent
+	if err := json.Unmarshal(raw.Value, &event); err != nil {
+		return domain.StormEvent{}, err
+	}
+	return event, nil
+}
+
+// Loader records every batch it is given. A non-nil Err fails every call
+// without recording the batch. It is safe to inspect while a pipeline runs.
+type Loader struct {
+	Err     error
+	mu      sync.Mutex
+	batches [][]domain.StormEvent
+}
+
+// LoadBatch records events, or returns Err.
+func (l *Loader) LoadBatch(_ context.Context, events []domain.StormEvent) error {
+	if l.Err != nil {
+		return l.Err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.batches = append(l.batches, events)
+	return nil
+}
+
+// Batches returns the batches loaded so far.
+func (l *Loader) Batches() [][]domain.StormEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.batches)
+}
+
+// Events returns every loaded event, flattened across batches.
+func (l *Loader) Events() []domain.StormEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Concat(l.batches...)
+}
+
+// DeadLetterLoader records every dead letter it is given. A non-nil Err fails
+// every call without recording the letters.
+type DeadLetterLoader struct {
+	Err     error
+	mu      sync.Mutex
+	letters []domain.DeadLetter
+}
+
+// LoadDeadLetters records letters, or returns Err.
+func (d *DeadLetterLoader) LoadDeadLetters(_ context.Context, letters []domain.DeadLetter) error {
+	if d.Err != nil {
+		return d.Err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.letters = append(d.letters, letters...)
+	return nil
+}
+
+// Letters returns the dead letters loaded so far.
+func (d *DeadLetterLoader) Letters() []domain.DeadLetter {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.letters)
+}
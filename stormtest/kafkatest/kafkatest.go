@@ -0,0 +1,104 @@
+// Package kafkatest runs end-to-end scenarios against a real Kafka broker in
+// a container: it starts the broker, creates topics, supplies the mock
+// collector records, and reads enriched events back from a sink topic. The
+// service's integration tests use it, and downstream services can import it
+// to drive their consumers with the same messages. Docker must be running.
+package kafkatest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/data/mock"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	kafkago "github.com/segmentio/kafka-go"
+	tcKafka "github.com/testcontainers/testcontainers-go/modules/kafka"
+)
+
+// Image is the Kafka container image StartKafka runs.
+const Image = "confluentinc/confluent-local:7.6.0"
+
+// readTimeout bounds how long ReadTransformed waits for a message.
+const readTimeout = 30 * time.Second
+
+// StartKafka starts a single-broker Kafka container and returns the broker
+// address. The container is terminated when the test ends.
+func StartKafka(ctx context.Context, tb testing.TB) string {
+	tb.Helper()
+	kc, err := tcKafka.Run(ctx, Image)
+	if err != nil {
+		tb.Fatalf("kafkatest: start kafka container: %v", err)
+	}
+	tb.Cleanup(func() { _ = kc.Terminate(context.Background()) })
+
+	brokers, err := kc.Brokers(ctx)
+	if err != nil {
+		tb.Fatalf("kafkatest: get kafka brokers: %v", err)
+	}
+	return brokers[0]
+}
+
+// CreateTopic creates a single-partition topic on the broker.
+func CreateTopic(tb testing.TB, broker, topic string) {
+	tb.Helper()
+	conn, err := kafkago.Dial("tcp", broker)
+	if err != nil {
+		tb.Fatalf("kafkatest: dial kafka for topic creation: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if err := conn.CreateTopics(kafkago.TopicConfig{
+		Topic:             topic,
+		NumPartitions:     1,
+		ReplicationFactor: 1,
+	}); err != nil {
+		tb.Fatalf("kafkatest: create topic %s: %v", topic, err)
+	}
+}
+
+// LoadMockData returns the mock collector records (see data/mock), in the
+// order the collector published them.
+func LoadMockData(tb testing.TB) []domain.RawCSVRecord {
+	tb.Helper()
+	var records []domain.RawCSVRecord
+	if err := json.Unmarshal(mock.StormReports, &records); err != nil {
+		tb.Fatalf("kafkatest: decode mock data: %v", err)
+	}
+	return records
+}
+
+// TransformedMessage is an enriched event read from the sink topic.
+type TransformedMessage struct {
+	Event   domain.StormEvent
+	Key     string
+	Headers map[string]string
+}
+
+// ReadTransformed reads the next message from consumer, waiting up to 30
+// seconds, and decodes it as a JSON StormEvent.
+func ReadTransformed(ctx context.Context, tb testing.TB, consumer *kafkago.Reader) TransformedMessage {
+	tb.Helper()
+	readCtx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	msg, err := consumer.ReadMessage(readCtx)
+	if err != nil {
+		tb.Fatalf("kafkatest: read from sink topic: %v", err)
+	}
+
+	headers := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	var event domain.StormEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		tb.Fatalf("kafkatest: unmarshal sink message: %v", err)
+	}
+	return TransformedMessage{
+		Event:   event,
+		Key:     string(msg.Key),
+		Headers: headers,
+	}
+}
@@ -0,0 +1,19 @@
+package kafkatest_test
+
+import (
+	"testing"
+
+	"github.com/couchcryptid/storm-data-etl/stormtest/kafkatest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMockData(t *testing.T) {
+	records := kafkatest.LoadMockData(t)
+
+	types := map[string]int{}
+	for _, rec := range records {
+		types[rec.EventType]++
+	}
+	assert.Equal(t, map[string]int{"hail": 79, "tornado": 149, "wind": 43}, types)
+	assert.Equal(t, "8 ESE Chappel", records[0].Location, "records keep the collector's order")
+}
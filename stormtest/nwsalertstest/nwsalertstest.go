@@ -0,0 +1,223 @@
+// Package nwsalertstest serves a fake api.weather.gov alerts API, so the
+// nws_alerts enrichment stage can be exercised without the real service,
+// whose alerts age out after a few days. It answers point and time window
+// queries from a fixture of alerts, by default the hand-written alerts for
+// the mock reports of April 26, 2024, and can be slowed down or made to fail
+// a share of requests to drive the stage's error path. The integration tests
+// start it with Start; cmd/nwsalertsmock serves it for docker compose.
+package nwsalertstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/data/mock"
+)
+
+// feature is a fixture alert: the GeoJSON feature as served, with the parts
+// a query matches on.
+type feature struct {
+	raw   json.RawMessage
+	ring  [][2]float64 // outer polygon ring as lon, lat pairs; nil for zone-based alerts
+	start time.Time
+	end   time.Time
+}
+
+// Server is an http.Handler that serves GET /alerts from a fixture.
+type Server struct {
+	features  []feature
+	latency   time.Duration
+	errorRate float64
+	mux       *http.ServeMux
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithLatency delays every response by d, or until the request is canceled.
+func WithLatency(d time.Duration) Option {
+	return func(s *Server) {
+		s.latency = d
+	}
+}
+
+// WithErrorRate answers a share of requests, a probability from 0 to 1, with
+// 503 Service Unavailable.
+func WithErrorRate(rate float64) Option {
+	return func(s *Server) {
+		s.errorRate = rate
+	}
+}
+
+// New returns a server for the alerts in fixture, a GeoJSON FeatureCollection
+// as returned by the alerts API. Features must have a Polygon or a null
+// geometry; alerts without a polygon are never returned, as the fake has no
+// forecast zones to match them by.
+func New(fixture []byte, opts ...Option) (*Server, error) {
+	var collection struct {
+		Features []json.RawMessage `json:"features"`
+	}
+	if err := json.Unmarshal(fixture, &collection); err != nil {
+		return nil, fmt.Errorf("decode alerts fixture: %w", err)
+	}
+	s := &Server{features: make([]feature, 0, len(collection.Features))}
+	for i, raw := range collection.Features {
+		f, err := parseFeature(raw)
+		if err != nil {
+			return nil, fmt.Errorf("alerts fixture feature %d: %w", i, err)
+		}
+		s.features = append(s.features, f)
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("GET /alerts", s.alerts)
+	return s, nil
+}
+
+// Start serves the mock alerts fixture with the options on a local port and
+// returns its base URL, for NWS_ALERTS_URL. The server is closed when the
+// test ends.
+func Start(tb testing.TB, opts ...Option) string {
+	tb.Helper()
+	s, err := New(mock.NWSAlerts, opts...)
+	if err != nil {
+		tb.Fatalf("nwsalertstest: %v", err)
+	}
+	ts := httptest.NewServer(s)
+	tb.Cleanup(ts.Close)
+	return ts.URL
+}
+
+func parseFeature(raw json.RawMessage) (feature, error) {
+	var f struct {
+		Geometry *struct {
+			Type        string         `json:"type"`
+			Coordinates [][][2]float64 `json:"coordinates"`
+		} `json:"geometry"`
+		Properties struct {
+			Effective time.Time `json:"effective"`
+			Expires   time.Time `json:"expires"`
+			Ends      time.Time `json:"ends"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return feature{}, err
+	}
+	out := feature{raw: raw, start: f.Properties.Effective, end: f.Properties.Ends}
+	if out.end.IsZero() {
+		out.end = f.Properties.Expires
+	}
+	if g := f.Geometry; g != nil {
+		if g.Type != "Polygon" || len(g.Coordinates) == 0 {
+			return feature{}, fmt.Errorf("unsupported geometry %q", g.Type)
+		}
+		out.ring = g.Coordinates[0]
+	}
+	return out, nil
+}
+
+// ServeHTTP applies the latency and error rate, then serves the request.
+// Like the real API it rejects requests without a User-Agent.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.latency > 0 {
+		select {
+		case <-time.After(s.latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if s.errorRate > 0 && rand.Float64() < s.errorRate { //nolint:gosec // fault timing, not security sensitive
+		problem(w, http.StatusServiceUnavailable, "injected failure")
+		return
+	}
+	if r.Header.Get("User-Agent") == "" {
+		problem(w, http.StatusForbidden, "a User-Agent header identifying the caller is required")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// alerts answers GET /alerts with the fixture alerts whose polygon contains
+// the point parameter and whose effective to end time overlaps the start to
+// end window. start and end are optional.
+func (s *Server) alerts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	lat, lon, err := parsePoint(q.Get("point"))
+	if err != nil {
+		problem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var window [2]time.Time
+	for i, key := range []string{"start", "end"} {
+		v := q.Get(key)
+		if v == "" {
+			continue
+		}
+		if window[i], err = time.Parse(time.RFC3339, v); err != nil {
+			problem(w, http.StatusBadRequest, fmt.Sprintf("invalid %s %q: want an RFC 3339 time", key, v))
+			return
+		}
+	}
+
+	features := make([]json.RawMessage, 0, len(s.features))
+	for _, f := range s.features {
+		if f.ring == nil || !contains(f.ring, lon, lat) {
+			continue
+		}
+		if (!window[0].IsZero() && f.end.Before(window[0])) || (!window[1].IsZero() && f.start.After(window[1])) {
+			continue
+		}
+		features = append(features, f.raw)
+	}
+	w.Header().Set("Content-Type", "application/geo+json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"type": "FeatureCollection", "features": features})
+}
+
+// parsePoint parses a "lat,lon" point parameter.
+func parsePoint(v string) (lat, lon float64, err error) {
+	latStr, lonStr, ok := strings.Cut(v, ",")
+	if ok {
+		lat, err = strconv.ParseFloat(latStr, 64)
+		if err == nil {
+			lon, err = strconv.ParseFloat(lonStr, 64)
+		}
+	}
+	if !ok || err != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return 0, 0, fmt.Errorf("invalid point %q: want lat,lon", v)
+	}
+	return lat, lon, nil
+}
+
+// contains reports whether the ring of lon, lat vertices contains the point,
+// by counting the edges a ray from it crosses.
+func contains(ring [][2]float64, lon, lat float64) bool {
+	in := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a[1] > lat) != (b[1] > lat) && lon < (b[0]-a[0])*(lat-a[1])/(b[1]-a[1])+a[0] {
+			in = !in
+		}
+	}
+	return in
+}
+
+// problem writes an application/problem+json error as the real API does.
+func problem(w http.ResponseWriter, status int, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"type":   "https://api.weather.gov/problems/" + strings.ReplaceAll(http.StatusText(status), " ", ""),
+		"title":  http.StatusText(status),
+		"status": status,
+		"detail": detail,
+	})
+}
@@ -0,0 +1,103 @@
+package nwsalertstest_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/adapter/nwsalerts"
+	"github.com/couchcryptid/storm-data-etl/internal/config"
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/observability"
+	"github.com/couchcryptid/storm-data-etl/stormtest/nwsalertstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newClient(url string) *nwsalerts.Client {
+	return nwsalerts.NewClient(&config.Config{
+		NWSAlertsURL:       url,
+		NWSAlertsUserAgent: "(nwsalertstest, ops@example.com)",
+		NWSAlertsTimeout:   time.Second,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)), observability.NewMetricsForTesting())
+}
+
+func at(hour, minute int) time.Time {
+	return time.Date(2024, time.April, 26, hour, minute, 0, 0, time.UTC)
+}
+
+func TestServer_Warnings(t *testing.T) {
+	c := newClient(nwsalertstest.Start(t))
+
+	tests := []struct {
+		name string
+		geo  domain.Geo
+		time time.Time
+		want []string
+	}{
+		{"lincoln in warning", domain.Geo{Lat: 40.86, Lon: -96.74}, at(19, 41), []string{"urn:oid:2.49.0.1.840.0.mock.ne-tor-lincoln.001.1"}},
+		{"lincoln before warning", domain.Geo{Lat: 40.86, Lon: -96.74}, at(18, 30), nil},
+		{"omaha in warning", domain.Geo{Lat: 41.15, Lon: -96.04}, at(21, 45), []string{"urn:oid:2.49.0.1.840.0.mock.ne-tor-omaha.001.1"}},
+		{"arlington in warning", domain.Geo{Lat: 32.69, Lon: -97.13}, at(17, 11), []string{"urn:oid:2.49.0.1.840.0.mock.tx-svr-arlington.001.1"}},
+		{"watch only", domain.Geo{Lat: 41.02, Lon: -98.91}, at(17, 10), nil},
+		{"outside every polygon", domain.Geo{Lat: 31.02, Lon: -98.44}, at(15, 10), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, err := c.Warnings(context.Background(), tt.geo, tt.time)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
+
+func TestServer_ErrorRate(t *testing.T) {
+	c := newClient(nwsalertstest.Start(t, nwsalertstest.WithErrorRate(1)))
+
+	_, err := c.Warnings(context.Background(), domain.Geo{Lat: 40.86, Lon: -96.74}, at(19, 41))
+	require.ErrorContains(t, err, "503")
+}
+
+func TestServer_Latency(t *testing.T) {
+	c := newClient(nwsalertstest.Start(t, nwsalertstest.WithLatency(50*time.Millisecond)))
+
+	start := time.Now()
+	_, err := c.Warnings(context.Background(), domain.Geo{Lat: 40.86, Lon: -96.74}, at(19, 41))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestServer_Rejects(t *testing.T) {
+	url := nwsalertstest.Start(t)
+
+	tests := []struct {
+		name      string
+		path      string
+		userAgent string
+		want      int
+	}{
+		{"no user agent", "/alerts?point=40.86,-96.74", "", http.StatusForbidden},
+		{"bad point", "/alerts?point=lincoln", "test", http.StatusBadRequest},
+		{"bad start", "/alerts?point=40.86,-96.74&start=yesterday", "test", http.StatusBadRequest},
+		{"unknown path", "/points/40.86,-96.74", "test", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url+tt.path, nil)
+			require.NoError(t, err)
+			req.Header.Set("User-Agent", tt.userAgent)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			assert.Equal(t, tt.want, resp.StatusCode)
+		})
+	}
+}
+
+func TestNew_InvalidFixture(t *testing.T) {
+	_, err := nwsalertstest.New([]byte(`{"features": [{"geometry": {"type": "Point", "coordinates": [1, 2]}}]}`))
+	require.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package stormtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+)
+
+// SampleTopic is the source topic SampleRawEvents claim to come from.
+const SampleTopic = "raw-weather-reports"
+
+var (
+	// SampleReportDate is the SPC report date of the sample events.
+	SampleReportDate = time.Date(2024, time.April, 26, 0, 0, 0, 0, time.UTC)
+
+	// SampleProcessedAt is the ProcessedAt stamped on SampleStormEvents.
+	SampleProcessedAt = time.Date(2024, time.April, 27, 6, 0, 0, 0, time.UTC)
+)
+
+// sampleRecords are collector records taken verbatim from
+// data/mock/storm_reports_240426_combined.json: one hail, tornado, and wind
+// report.
+var sampleRecords = []string{
+	`{"Time":"1510","Size":"125","F_Scale":"","Speed":"","Location":"8 ESE Chappel","County":"San Saba","State":"TX","Lat":"31.02","Lon":"-98.44","Comments":"1.25 inch hail reported at Colorado Bend State Park. (SJT)","EventType":"hail"}`,
+	`{"Time":"1223","Size":"","F_Scale":"UNK","Speed":"","Location":"2 N Mcalester","County":"Pittsburg","State":"OK","Lat":"34.96","Lon":"-95.77","Comments":"This tornado moved across the northwest side of McAlester... damaging the roofs of homes... uprooting trees... and snapping power poles. The damage survey was conducted (TSA)","EventType":"tornado"}`,
+	`{"Time":"2120","Size":"","F_Scale":"","Speed":"75","Location":"4 NNW Kemp","County":"Kaufman","State":"TX","Lat":"32.49","Lon":"-96.25","Comments":"Gust measured by home weather station. Damage to trees on property. Chicken coop blown away. Time estimated from radar. (FWD)","EventType":"wind"}`,
+}
+
+// SampleRawEvents returns the canonical raw reports (hail, tornado, wind) as
+// they arrive on the source topic, at consecutive offsets of partition 0.
+func SampleRawEvents() []domain.RawEvent {
+	events := make([]domain.RawEvent, len(sampleRecords))
+	for i, record := range sampleRecords {
+		events[i] = domain.RawEvent{
+			Value:     []byte(record),
+			Topic:     SampleTopic,
+			Offset:    int64(i),
+			Timestamp: SampleReportDate,
+		}
+	}
+	return events
+}
+
+// SampleStormEvents returns SampleRawEvents after parsing and enrichment,
+// with ProcessedAt fixed to SampleProcessedAt. Enrichment reads the domain
+// package settings (units, severity thresholds, ID strategy), so the result
+// reflects whatever the caller has configured; the defaults give the
+// published contract.
+func SampleStormEvents() []domain.StormEvent {
+	raws := SampleRawEvents()
+	events := make([]domain.StormEvent, len(raws))
+	for i, raw := range raws {
+		event, err := domain.ParseRawEvent(raw)
+		if err == nil {
+			err = domain.ValidateStormEvent(event)
+		}
+		if err != nil {
+			panic(fmt.Sprintf("stormtest: sample %d is invalid: %v", i, err))
+		}
+		event = domain.EnrichStormEvent(event)
+		event.ProcessedAt = SampleProcessedAt
+		events[i] = event
+	}
+	return events
+}
+
+// RawEvent encodes event as the value of a raw message keyed by its ID, for
+// use with Transformer.
+func RawEvent(tb testing.TB, event domain.StormEvent) domain.RawEvent {
+	tb.Helper()
+	data, err := json.Marshal(event)
+	if err != nil {
+		tb.Fatalf("stormtest: encode event %q: %v", event.ID, err)
+	}
+	return domain.RawEvent{Key: []byte(event.ID), Value: data}
+}
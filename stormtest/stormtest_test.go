@@ -0,0 +1,68 @@
+package stormtest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/couchcryptid/storm-data-etl/internal/domain"
+	"github.com/couchcryptid/storm-data-etl/internal/pipeline"
+	"github.com/couchcryptid/storm-data-etl/stormtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ pipeline.BatchExtractor   = (*stormtest.Extractor)(nil)
+	_ pipeline.Transformer      = (*stormtest.Transformer)(nil)
+	_ pipeline.BatchLoader      = (*stormtest.Loader)(nil)
+	_ pipeline.DeadLetterLoader = (*stormtest.DeadLetterLoader)(nil)
+)
+
+func TestSampleStormEvents(t *testing.T) {
+	events := stormtest.SampleStormEvents()
+	require.Len(t, events, 3)
+
+	types := make([]string, len(events))
+	for i, event := range events {
+		types[i] = event.EventType
+		assert.NotEmpty(t, event.ID)
+		assert.Equal(t, stormtest.SampleReportDate.Format("2006-01-02"), event.EventTime.Format("2006-01-02"))
+		assert.Equal(t, stormtest.SampleProcessedAt, event.ProcessedAt)
+		assert.NotNil(t, event.SourceOfficeDetail)
+	}
+	assert.Equal(t, []string{"hail", "tornado", "wind"}, types)
+	assert.Equal(t, "8 ESE Chappel", events[0].Location.Raw)
+	assert.InDelta(t, 1.25, events[0].Measurement.Magnitude, 1e-9)
+	assert.InDelta(t, 75.0, events[2].Measurement.Magnitude, 1e-9)
+}
+
+func TestTransformerRoundTripsRawEvent(t *testing.T) {
+	want := stormtest.SampleStormEvents()[0]
+
+	got, err := (&stormtest.Transformer{}).Transform(context.Background(), stormtest.RawEvent(t, want))
+	require.NoError(t, err)
+	assert.Equal(t, want.ID, got.ID)
+	assert.True(t, want.EventTime.Equal(got.EventTime))
+
+	_, err = (&stormtest.Transformer{Err: errors.New("bad data")}).Transform(context.Background(), stormtest.RawEvent(t, want))
+	require.EqualError(t, err, "bad data")
+}
+
+func TestLoaderRecordsBatches(t *testing.T) {
+	loader := &stormtest.Loader{}
+	events := stormtest.SampleStormEvents()
+
+	require.NoError(t, loader.LoadBatch(context.Background(), events[:2]))
+	require.NoError(t, loader.LoadBatch(context.Background(), events[2:]))
+	assert.Len(t, loader.Batches(), 2)
+	assert.Equal(t, events, loader.Events())
+
+	loader.Err = errors.New("sink down")
+	require.Error(t, loader.LoadBatch(context.Background(), events))
+	assert.Len(t, loader.Batches(), 2, "failed loads are not recorded")
+
+	dlq := &stormtest.DeadLetterLoader{}
+	require.NoError(t, dlq.LoadDeadLetters(context.Background(), []domain.DeadLetter{{Reason: "transform failure"}}))
+	assert.Len(t, dlq.Letters(), 1)
+}